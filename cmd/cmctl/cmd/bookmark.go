@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/providers"
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+// bookmarkKey is the metadata key holding the number of turns a chat had
+// when it was bookmarked
+const bookmarkKey = "bookmark"
+
+var bookmarkCmd = &cobra.Command{
+	Use:   "bookmark <memory-id>",
+	Short: "Mark how far a chat has been read",
+	Long: `Bookmark a chat memory at its latest turn, so that
+'reload-chat --since-bookmark' outputs only the turns added after it. When a
+long session is re-imported as it grows, this resumes it without pasting
+everything again. Bookmarking again moves the bookmark to the new latest
+turn.
+
+Examples:
+  cmctl bookmark mem_abc123_def456
+  cmctl import-cursor-chat --latest                        # Later, once the chat has grown
+  cmctl reload-chat mem_abc123_def456 --since-bookmark`,
+	Args: cobra.ExactArgs(1),
+	RunE: runBookmark,
+}
+
+func init() {
+	rootCmd.AddCommand(bookmarkCmd)
+}
+
+func runBookmark(cmd *cobra.Command, args []string) error {
+	fs, err := getStorageProvider()
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+
+	turns, err := setBookmark(fs, args[0])
+	if err != nil {
+		return err
+	}
+	VPrintf(Normal, "Bookmarked memory %s at turn %d\n", args[0], turns)
+	return nil
+}
+
+// setBookmark bookmarks a chat memory at its latest turn, returning the
+// number of turns before the bookmark
+func setBookmark(fs providers.StorageProvider, id string) (int, error) {
+	memory, err := fs.Get(id)
+	if errors.Is(err, storage.ErrMemoryNotFound) {
+		return 0, err
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get memory: %w", err)
+	}
+	if memory.Labels["type"] != "chat" {
+		return 0, fmt.Errorf("memory %s is not a chat conversation (type=%s)", id, memory.Labels["type"])
+	}
+
+	_, turns := parseChatTurns(memory.Content)
+	if len(turns) == 0 {
+		return 0, fmt.Errorf("memory %s has no turns to bookmark", id)
+	}
+	req := storage.UpdateMemoryRequest{ID: id, Metadata: map[string]any{bookmarkKey: len(turns)}}
+	if _, err := fs.Update(req); err != nil {
+		return 0, fmt.Errorf("failed to update memory: %w", err)
+	}
+	return len(turns), nil
+}
+
+// chatBookmark returns the number of turns before a chat's bookmark, or
+// false if it has none
+func chatBookmark(memory storage.Memory) (int, bool) {
+	switch v := memory.Metadata[bookmarkKey].(type) {
+	case int:
+		return v, true
+	case int64:
+		return int(v), true
+	case float64:
+		return int(v), true
+	}
+	return 0, false
+}
+
+// sinceBookmark returns the chat with the turns before its bookmark
+// replaced by a note, and how many turns follow the bookmark. Chats without
+// a bookmark are returned whole.
+func sinceBookmark(memory storage.Memory) (storage.Memory, int) {
+	header, turns := parseChatTurns(memory.Content)
+	bookmark, ok := chatBookmark(memory)
+	// A chat rewritten with fewer turns than were bookmarked starts over
+	if !ok || bookmark <= 0 || bookmark > len(turns) {
+		return memory, len(turns)
+	}
+
+	var content strings.Builder
+	content.WriteString(header)
+	if !strings.HasSuffix(header, "\n\n") {
+		content.WriteString("\n")
+	}
+	fmt.Fprintf(&content, "*[%d earlier turn(s) before the bookmark omitted]*\n\n", bookmark)
+	for _, turn := range turns[bookmark:] {
+		content.WriteString(turn.Raw)
+	}
+
+	memory.Content = content.String()
+	return memory, len(turns) - bookmark
+}
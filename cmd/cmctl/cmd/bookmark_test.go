@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/cursor"
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+)
+
+func TestSetBookmark(t *testing.T) {
+	fs := newTestStorage(t)
+	memory := createChatMemories(t, fs, "How do I add a flag?")[0]
+
+	turns, err := setBookmark(fs, memory.ID)
+	if err != nil {
+		t.Fatalf("Failed to set bookmark: %v", err)
+	}
+	if turns != 2 {
+		t.Errorf("Expected the bookmark at turn 2, got %d", turns)
+	}
+	stored, err := fs.Get(memory.ID)
+	if err != nil {
+		t.Fatalf("Failed to get memory: %v", err)
+	}
+	if bookmark, ok := chatBookmark(*stored); !ok || bookmark != 2 {
+		t.Errorf("Expected the bookmark stored in metadata, got %v", stored.Metadata[bookmarkKey])
+	}
+
+	note, err := fs.Create(storage.CreateMemoryRequest{Name: "Note", Content: "**User**: not a chat"})
+	if err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+	if _, err := setBookmark(fs, note.ID); err == nil || !strings.Contains(err.Error(), "not a chat conversation") {
+		t.Errorf("Expected a non-chat memory to be refused, got %v", err)
+	}
+}
+
+func TestSinceBookmark(t *testing.T) {
+	fs := newTestStorage(t)
+	memory := createChatMemories(t, fs, "How do I add a flag?")[0]
+
+	whole, remaining := sinceBookmark(memory)
+	if remaining != 2 || whole.Content != memory.Content {
+		t.Errorf("Expected a chat without a bookmark whole, got %d turns", remaining)
+	}
+
+	if _, err := setBookmark(fs, memory.ID); err != nil {
+		t.Fatalf("Failed to set bookmark: %v", err)
+	}
+	// The chat grows after it was bookmarked
+	chat := testChat("chat", "How do I add a flag?")
+	chat.Messages = append(chat.Messages,
+		cursor.Message{Role: "user", Content: "And how do I test it?"},
+		cursor.Message{Role: "assistant", Content: "Call the command's RunE."})
+	if _, err := fs.Update(storage.UpdateMemoryRequest{ID: memory.ID, Content: chat.ToMarkdown()}); err != nil {
+		t.Fatalf("Failed to update memory: %v", err)
+	}
+	stored, err := fs.Get(memory.ID)
+	if err != nil {
+		t.Fatalf("Failed to get memory: %v", err)
+	}
+
+	sliced, remaining := sinceBookmark(*stored)
+	if remaining != 2 {
+		t.Errorf("Expected 2 turns after the bookmark, got %d", remaining)
+	}
+	if !strings.HasPrefix(sliced.Content, "# Debugging session\n") ||
+		!strings.Contains(sliced.Content, "*[2 earlier turn(s) before the bookmark omitted]*\n\n**User**: And how do I test it?") {
+		t.Errorf("Expected the header and turns after the bookmark, got %q", sliced.Content)
+	}
+	if strings.Contains(sliced.Content, "How do I add a flag?") {
+		t.Errorf("Expected turns before the bookmark dropped, got %q", sliced.Content)
+	}
+
+	// Re-bookmarking leaves nothing new to reload
+	defer func() { reloadSinceMark = false }()
+	reloadSinceMark = true
+	if _, err := setBookmark(fs, memory.ID); err != nil {
+		t.Fatalf("Failed to set bookmark: %v", err)
+	}
+	if output := captureStdout(t, func() error { return reloadSpecificChat(fs, memory.ID) }); output != "" {
+		t.Errorf("Expected no output with nothing since the bookmark, got %q", output)
+	}
+}
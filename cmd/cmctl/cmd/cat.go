@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/providers"
+	"github.com/spf13/cobra"
+)
+
+// catSeparator is written between memories when cat is given several IDs
+const catSeparator = "---\n"
+
+var catCmd = &cobra.Command{
+	Use:   "cat <memory-id>...",
+	Short: "Print the content of memories",
+	Long: `Print the content of one or more memories exactly as stored, without
+headers or formatting. Multiple memories are separated by a '---' line.
+
+Examples:
+  cmctl cat mem_abc123_def456 > notes.md
+  cmctl cat mem_abc123_def456 mem_987654_fedcba`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runCat,
+}
+
+func init() {
+	rootCmd.AddCommand(catCmd)
+}
+
+func runCat(cmd *cobra.Command, args []string) error {
+	fs, err := getStorageProvider()
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	return catMemories(fs, args, os.Stdout)
+}
+
+// catMemories writes the content of each memory to w. All memories are
+// loaded first so a missing ID produces no partial output.
+func catMemories(fs providers.StorageProvider, ids []string, w io.Writer) error {
+	contents := make([][]byte, 0, len(ids))
+	for _, id := range ids {
+		memory, err := fs.Get(id)
+		if err != nil {
+			return err
+		}
+		// Binary content is stored base64-encoded, and printed as it was given
+		content, err := memory.DecodedContent()
+		if err != nil {
+			return err
+		}
+		contents = append(contents, content)
+	}
+	recordAccess(fs, ids...)
+
+	for i, content := range contents {
+		if i > 0 {
+			prev := contents[i-1]
+			if len(prev) > 0 && !bytes.HasSuffix(prev, []byte("\n")) {
+				if _, err := io.WriteString(w, "\n"); err != nil {
+					return err
+				}
+			}
+			if _, err := io.WriteString(w, catSeparator); err != nil {
+				return err
+			}
+		}
+		if _, err := w.Write(content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+	"github.com/spf13/viper"
+)
+
+func TestCatMemories(t *testing.T) {
+	fs := newTestStorage(t)
+
+	exact := "  leading spaces\r\ntabs\tand no trailing newline"
+	first, err := fs.Create(storage.CreateMemoryRequest{Content: exact})
+	if err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+	second, err := fs.Create(storage.CreateMemoryRequest{Content: "second\n"})
+	if err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := catMemories(fs, []string{first.ID}, &out); err != nil {
+		t.Fatalf("Failed to cat memory: %v", err)
+	}
+	if out.String() != exact {
+		t.Errorf("Expected byte-exact content %q, got %q", exact, out.String())
+	}
+
+	out.Reset()
+	if err := catMemories(fs, []string{first.ID, second.ID, second.ID}, &out); err != nil {
+		t.Fatalf("Failed to cat memories: %v", err)
+	}
+	want := exact + "\n---\nsecond\n---\nsecond\n"
+	if out.String() != want {
+		t.Errorf("Expected %q, got %q", want, out.String())
+	}
+
+	out.Reset()
+	err = catMemories(fs, []string{first.ID, "mem_missing"}, &out)
+	if !errors.Is(err, storage.ErrMemoryNotFound) {
+		t.Errorf("Expected ErrMemoryNotFound, got %v", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("Expected no output when an ID is missing, got %q", out.String())
+	}
+}
+
+func TestCatMemoriesRecordsAccess(t *testing.T) {
+	fs := newTestStorage(t)
+	memory, err := fs.Create(storage.CreateMemoryRequest{Content: "content"})
+	if err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+
+	lastAccessed := func() time.Time {
+		t.Helper()
+		got, err := fs.Get(memory.ID)
+		if err != nil {
+			t.Fatalf("Failed to get memory: %v", err)
+		}
+		at, _ := got.LastAccessed()
+		return at
+	}
+
+	if err := catMemories(fs, []string{memory.ID}, io.Discard); err != nil {
+		t.Fatalf("Failed to cat memory: %v", err)
+	}
+	first := lastAccessed()
+	if first.IsZero() {
+		t.Fatal("Expected reading the memory to record lastAccessedAt")
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	if err := catMemories(fs, []string{memory.ID}, io.Discard); err != nil {
+		t.Fatalf("Failed to cat memory: %v", err)
+	}
+	second := lastAccessed()
+	if !second.After(first) {
+		t.Errorf("Expected lastAccessedAt to advance past %v, got %v", first, second)
+	}
+
+	// Tracking can be turned off for read-only storage
+	viper.Set(noTrackAccessKey, true)
+	defer viper.Set(noTrackAccessKey, false)
+	if err := catMemories(fs, []string{memory.ID}, io.Discard); err != nil {
+		t.Fatalf("Failed to cat memory: %v", err)
+	}
+	if at := lastAccessed(); !at.Equal(second) {
+		t.Errorf("Expected lastAccessedAt to stay %v with tracking off, got %v", second, at)
+	}
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/cursor"
+)
+
+// chatNameTemplateKey is the config setting holding the default --name-template
+const chatNameTemplateKey = "chat-name-template"
+
+// chatNamePattern matches a {placeholder} in a chat name template
+var chatNamePattern = regexp.MustCompile(`\{([a-z]+)\}`)
+
+// chatNamePlaceholders are the placeholders a chat name template may use
+var chatNamePlaceholders = []string{"title", "date", "lang", "activity", "workspace"}
+
+// validateChatNameTemplate rejects templates using unknown placeholders
+func validateChatNameTemplate(tmpl string) error {
+	for _, match := range chatNamePattern.FindAllStringSubmatch(tmpl, -1) {
+		if !slices.Contains(chatNamePlaceholders, match[1]) {
+			return fmt.Errorf("unknown placeholder %s in name template (use {%s})", match[0], strings.Join(chatNamePlaceholders, "}, {"))
+		}
+	}
+	return nil
+}
+
+// renderChatName expands a name template for a chat with the given labels,
+// read from workspace. Placeholders the chat has no value for expand to
+// nothing; if none of them has a value the result is empty, so the caller
+// can fall back to the generated name.
+func renderChatName(tmpl string, chatTab *cursor.ChatTab, labels map[string]string, workspace string) string {
+	values := map[string]string{
+		"title":     chatTitle(chatTab),
+		"date":      labels["date"],
+		"lang":      labels["language"],
+		"activity":  labels["activity"],
+		"workspace": workspace,
+	}
+
+	expanded := false
+	name := chatNamePattern.ReplaceAllStringFunc(tmpl, func(match string) string {
+		value, ok := values[chatNamePattern.FindStringSubmatch(match)[1]]
+		if !ok {
+			return match
+		}
+		if value != "" {
+			expanded = true
+		}
+		return value
+	})
+	if chatNamePattern.MatchString(tmpl) && !expanded {
+		return ""
+	}
+	return strings.TrimSpace(name)
+}
+
+// chatTitle returns the chat's own title, or "" when it has none or only a
+// placeholder title
+func chatTitle(chatTab *cursor.ChatTab) string {
+	if chatTab.Title == "AI Service Chat" {
+		return ""
+	}
+	return cleanChatTitle(chatTab.Title)
+}
+
+// chatWorkspaceName returns a readable name for the workspace at path: the
+// name of the project folder it was opened on, or else its directory name
+func chatWorkspaceName(path string) string {
+	if path == "" {
+		return ""
+	}
+	if folder, err := cursor.WorkspaceFolder(path); err == nil {
+		return filepath.Base(folder)
+	}
+	if filepath.Ext(path) == ".vscdb" {
+		path = filepath.Dir(path)
+	}
+	return filepath.Base(path)
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/cursor"
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/cursor/cursortest"
+)
+
+func TestRenderChatName(t *testing.T) {
+	chat := testChat("chat-name", "How do I fix this Python error?")
+	labels := generateChatLabels(chat)
+	if labels["date"] == "" || labels["language"] == "" || labels["activity"] == "" {
+		t.Fatalf("Expected the fixture chat to have date, language and activity labels, got %v", labels)
+	}
+	untitled := &cursor.ChatTab{ID: "untitled", Messages: chat.Messages}
+
+	tests := []struct {
+		name      string
+		template  string
+		chat      *cursor.ChatTab
+		workspace string
+		expected  string
+	}{
+		{name: "date and language", template: "chat/{date}/{lang}", chat: chat, expected: "chat/" + labels["date"] + "/" + labels["language"]},
+		{name: "title", template: "{title} ({activity})", chat: chat, expected: "Debugging session (" + labels["activity"] + ")"},
+		{name: "workspace", template: "{workspace}: {title}", chat: chat, workspace: "api", expected: "api: Debugging session"},
+		{name: "literal", template: "Imported chat", chat: chat, expected: "Imported chat"},
+		{name: "missing values", template: "{title}{workspace}", chat: untitled, expected: ""},
+		{name: "some values", template: "{workspace}/{date}", chat: chat, expected: "/" + labels["date"]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := renderChatName(tt.template, tt.chat, generateChatLabels(tt.chat), tt.workspace)
+			if got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestConvertChatToMemoryNameTemplate(t *testing.T) {
+	chat := &cursor.ChatTab{ID: "untitled", Messages: testChat("x", "Why does my test fail?").Messages}
+	fallback := convertChatToMemory(chat, importOptions{})
+
+	tests := []struct {
+		template string
+		expected string
+	}{
+		{template: "", expected: fallback.Name},
+		{template: "{title}", expected: fallback.Name},
+		{template: "chat/{workspace}", expected: "chat/api"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			req := convertChatToMemory(chat, importOptions{NameTemplate: tt.template, Workspace: "api"})
+			if req.Name != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, req.Name)
+			}
+		})
+	}
+}
+
+func TestValidateChatNameTemplate(t *testing.T) {
+	if err := validateChatNameTemplate("chat/{date}/{lang}"); err != nil {
+		t.Errorf("Expected template to be valid: %v", err)
+	}
+	if err := validateChatNameTemplate("chat/{primary-lang}/{model}"); err == nil {
+		t.Error("Expected error for unknown placeholder")
+	}
+}
+
+func TestChatWorkspaceName(t *testing.T) {
+	storageDir := t.TempDir()
+	named := cursortest.WriteWorkspace(t, storageDir, "a1b2c3", map[string]string{})
+	cursortest.WriteWorkspaceFolder(t, named, "file:///home/me/src/api")
+	unnamed := cursortest.WriteWorkspace(t, storageDir, "d4e5f6", map[string]string{})
+
+	if got := chatWorkspaceName(named); got != "api" {
+		t.Errorf("Expected the project folder name, got %q", got)
+	}
+	if got := chatWorkspaceName(unnamed); got != "d4e5f6" {
+		t.Errorf("Expected the workspace directory name, got %q", got)
+	}
+	if got := chatWorkspaceName(""); got != "" {
+		t.Errorf("Expected no name for an unknown workspace, got %q", got)
+	}
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/cursor"
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/vscode"
+)
+
+// Supported values for the --source flag of the chat commands
+const (
+	chatSourceCursor = "cursor"
+	chatSourceVSCode = "vscode"
+)
+
+// projectFlagCurrent is the value of a bare --project, meaning the current
+// working directory
+const projectFlagCurrent = "."
+
+// newChatReader returns the chat reader for source, reading from workspace
+// when set and from the editor's default storage location otherwise. A
+// non-empty project limits a Cursor reader to that project's workspaces,
+// and noMerge stops it threading continued conversations together.
+func newChatReader(source, workspace, project string, noMerge bool) (cursor.ChatReader, error) {
+	switch source {
+	case chatSourceCursor, "":
+		reader := cursor.NewWorkspaceReader()
+		if workspace != "" {
+			reader = cursor.NewWorkspaceReaderWithPath(workspace)
+		}
+		if project != "" {
+			projectPath, err := resolveProjectPath(project)
+			if err != nil {
+				return nil, err
+			}
+			reader.ProjectPath = projectPath
+		}
+		reader.NoMerge = noMerge
+		reader.Logger = appLogger()
+		return reader, nil
+	case chatSourceVSCode:
+		if project != "" {
+			return nil, fmt.Errorf("--project is not supported for %s", chatSourceName(source))
+		}
+		if workspace != "" {
+			return vscode.NewCopilotReaderWithPath(workspace), nil
+		}
+		return vscode.NewCopilotReader(), nil
+	default:
+		return nil, fmt.Errorf("invalid source %q (must be %s or %s)", source, chatSourceCursor, chatSourceVSCode)
+	}
+}
+
+// resolveProjectPath returns the absolute, symlink-free form of a --project
+// path, which is how Cursor records the folders it opens
+func resolveProjectPath(project string) (string, error) {
+	path, err := filepath.Abs(project)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve project path: %w", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		return "", fmt.Errorf("invalid project path: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	return path, nil
+}
+
+// chatSourceName returns a display name for source
+func chatSourceName(source string) string {
+	if source == chatSourceVSCode {
+		return "VS Code"
+	}
+	return "Cursor"
+}
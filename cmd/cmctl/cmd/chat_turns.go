@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"regexp"
+	"strings"
+)
+
+// chatTurnMarker matches the speaker prefix ChatTab.ToMarkdown writes at the
+// start of each message, e.g. "**User**: " or, with timestamps,
+// "**Assistant** _(2025-09-20 10:00:00)_: ". Other roles are written in
+// lowercase, which keeps "**Date**:" and similar headers from matching.
+var chatTurnMarker = regexp.MustCompile(`^\*\*(User|Assistant|[a-z][a-z_-]*)\*\*(?: _\([^)]*\)_)?:(?: |$)`)
+
+// chatTurn is a single message recovered from stored chat markdown
+type chatTurn struct {
+	Role    string // "user", "assistant", or the role as written
+	Content string // Message text without the speaker prefix
+	Raw     string // The turn's markdown exactly as stored
+}
+
+// parseChatTurns splits chat markdown into the header that precedes the
+// first message (title, date) and the individual turns
+func parseChatTurns(content string) (string, []chatTurn) {
+	lines := strings.Split(content, "\n")
+
+	var header []string
+	var turns []chatTurn
+	var current []string
+	var role string
+
+	flush := func() {
+		if current == nil {
+			return
+		}
+		raw := strings.Join(current, "\n")
+		body := strings.TrimPrefix(raw, chatTurnMarker.FindString(current[0]))
+		turns = append(turns, chatTurn{
+			Role:    role,
+			Content: strings.TrimSpace(body),
+			Raw:     strings.TrimRight(raw, "\n") + "\n\n",
+		})
+		current = nil
+	}
+
+	for _, line := range lines {
+		if m := chatTurnMarker.FindStringSubmatch(line); m != nil {
+			flush()
+			role = strings.ToLower(m[1])
+			current = []string{line}
+			continue
+		}
+		if current != nil {
+			current = append(current, line)
+		} else {
+			header = append(header, line)
+		}
+	}
+	flush()
+
+	return strings.Join(header, "\n"), turns
+}
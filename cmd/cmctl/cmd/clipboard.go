@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// errNoClipboard is returned when no clipboard tool can be found
+var errNoClipboard = errors.New("no clipboard tool found (install pbcopy, wl-copy, xclip, or xsel)")
+
+// clipboardWriter copies text to the system clipboard. It is a variable so
+// tests can stub it.
+var clipboardWriter = writeSystemClipboard
+
+// clipboardCommand returns the command used to write the clipboard on this
+// platform, preferring the tool that matches the running display server
+func clipboardCommand() ([]string, error) {
+	var candidates [][]string
+	switch runtime.GOOS {
+	case "darwin":
+		candidates = [][]string{{"pbcopy"}}
+	case "windows":
+		candidates = [][]string{{"clip.exe"}}
+	default:
+		if os.Getenv("WAYLAND_DISPLAY") != "" {
+			candidates = append(candidates, []string{"wl-copy"})
+		}
+		candidates = append(candidates,
+			[]string{"xclip", "-selection", "clipboard"},
+			[]string{"xsel", "--clipboard", "--input"},
+			[]string{"clip.exe"}, // WSL
+		)
+	}
+
+	for _, candidate := range candidates {
+		if _, err := exec.LookPath(candidate[0]); err == nil {
+			return candidate, nil
+		}
+	}
+	return nil, errNoClipboard
+}
+
+// writeSystemClipboard copies text to the clipboard using the platform's
+// clipboard tool
+func writeSystemClipboard(text string) error {
+	command, err := clipboardCommand()
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s failed: %w: %s", command[0], err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// ANSI escape sequences used for table and diff output
+const (
+	ansiReset = "\033[0m"
+	ansiBold  = "\033[1m"
+	ansiRed   = "\033[31m"
+	ansiGreen = "\033[32m"
+	ansiCyan  = "\033[36m"
+	ansiGray  = "\033[90m"
+)
+
+// shouldColorize reports whether ANSI colors should be written to w.
+// Colors are only used for interactive terminals and can be disabled with
+// --no-color or the NO_COLOR environment variable (see https://no-color.org).
+func shouldColorize(w io.Writer) bool {
+	if viper.GetBool("no-color") || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	return isTerminal(w)
+}
+
+// isTerminal reports whether w is a character device such as a TTY
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return (stat.Mode() & os.ModeCharDevice) != 0
+}
+
+// colorize wraps s in the given ANSI code when enabled
+func colorize(s, code string, enabled bool) string {
+	if !enabled || s == "" {
+		return s
+	}
+	return code + s + ansiReset
+}
+
+// padColored left-aligns colored text to width based on the visible length
+// of plain, so escape codes don't skew column alignment
+func padColored(plain, colored string, width int) string {
+	if len(plain) >= width {
+		return colored
+	}
+	return colored + strings.Repeat(" ", width-len(plain))
+}
+
+// colorizeLabelKeys highlights the key part of each key=value pair in a
+// (possibly truncated) compact label string
+func colorizeLabelKeys(labels string, enabled bool) string {
+	if !enabled || labels == "<none>" {
+		return labels
+	}
+
+	pairs := strings.Split(labels, ",")
+	for i, pair := range pairs {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) == 2 {
+			pairs[i] = colorize(parts[0], ansiCyan, true) + "=" + parts[1]
+		}
+	}
+	return strings.Join(pairs, ",")
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/providers"
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+// compressContentKey is the config key that compresses content as it's
+// written
+const compressContentKey = "compress-content"
+
+var compactCmd = &cobra.Command{
+	Use:   "compact",
+	Short: "Compress the content of large memories on disk",
+	Long: `Rewrite memory files so that content of at least 1 KB is stored
+gzip-compressed, which shrinks large chat memories several times over.
+Compressed content is decompressed transparently when memories are read,
+listed or searched; nothing else about the memories changes.
+
+Set 'compress-content: true' in the config to compress memories as they are
+written; compact compresses the ones already stored.
+
+Examples:
+  cmctl compact
+  cmctl config set compress-content true`,
+	Args: cobra.NoArgs,
+	RunE: runCompact,
+}
+
+func init() {
+	rootCmd.AddCommand(compactCmd)
+}
+
+func runCompact(cmd *cobra.Command, args []string) error {
+	fs, err := getStorageProvider()
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	compactor, ok := fs.(providers.Compactor)
+	if !ok {
+		return fmt.Errorf("storage provider %s does not support compaction", fs.GetProviderType())
+	}
+
+	result, err := compactor.Compact()
+	if err != nil {
+		return fmt.Errorf("failed to compact memories: %w", err)
+	}
+	fmt.Println(formatCompactResult(result))
+	return nil
+}
+
+// formatCompactResult summarizes what compact rewrote
+func formatCompactResult(result storage.CompactResult) string {
+	if result.Compressed == 0 {
+		return "No memories to compress"
+	}
+	saved := result.BytesBefore - result.BytesAfter
+	return fmt.Sprintf("Compressed %d memories from %s to %s (saved %d%%)",
+		result.Compressed, formatKB(result.BytesBefore), formatKB(result.BytesAfter),
+		saved*100/result.BytesBefore)
+}
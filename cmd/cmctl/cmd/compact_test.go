@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+)
+
+func TestCompactCommand(t *testing.T) {
+	useTestStorageDir(t)
+	fs, err := getStorageProvider()
+	if err != nil {
+		t.Fatalf("Failed to get storage: %v", err)
+	}
+	content := strings.Repeat("a chat turn that repeats\n", 200)
+	memory, err := fs.Create(storage.CreateMemoryRequest{Name: "Large", Content: content})
+	if err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+
+	output := captureStdout(t, func() error { return runCompact(compactCmd, nil) })
+	if !strings.HasPrefix(output, "Compressed 1 memories from ") {
+		t.Errorf("Expected a summary of the compressed memory, got %q", output)
+	}
+	if got, err := fs.Get(memory.ID); err != nil || got.Content != content {
+		t.Errorf("Expected the content to read back unchanged, got %v", err)
+	}
+
+	output = captureStdout(t, func() error { return runCompact(compactCmd, nil) })
+	if output != "No memories to compress\n" {
+		t.Errorf("Expected nothing left to compress, got %q", output)
+	}
+}
@@ -0,0 +1,345 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// configSetting describes a key that may appear in the config file
+type configSetting struct {
+	Key         string
+	Kind        string // "string", "bool", "int", "list" or "map"
+	Default     string
+	Description string
+	// Scaffold settings are written by 'config init'
+	Scaffold bool
+	// Validate optionally checks a value before 'config set' writes it
+	Validate func(value string) error
+}
+
+// configSettings lists the keys cmctl reads from its config file. Keys
+// match the global flag names except defaultOutput, which has no flag.
+var configSettings = []configSetting{
+	{Key: "storage-dir", Kind: "string", Default: "", Description: "Storage directory (empty for $HOME/.contextmemory)", Scaffold: true},
+	{Key: contextKey, Kind: "string", Default: defaultContext, Description: "Memory store context, set by 'cmctl context use'",
+		Validate: validateContextName},
+	{Key: "provider", Kind: "string", Default: "file", Description: "Storage provider: file, git or sqlite", Scaffold: true},
+	{Key: "verbosity", Kind: "int", Default: "1", Description: "Verbosity: 0=quiet, 1=normal, 2=verbose", Scaffold: true},
+	{Key: "defaultOutput", Kind: "string", Default: "table", Description: "Output format used when -o is not given: table, json or yaml", Scaffold: true,
+		Validate: func(value string) error { _, err := ParseOutputFormat(value); return err }},
+	{Key: "no-color", Kind: "bool", Default: "false", Description: "Disable colored table output"},
+	{Key: logFormatKey, Kind: "string", Default: logFormatText, Description: "Format of warnings and diagnostics on stderr: text or json",
+		Validate: validateLogFormat},
+	{Key: "max-content-bytes", Kind: "int", Default: strconv.Itoa(storage.DefaultMaxContentBytes), Description: "Maximum size of memory content in bytes (0 for no limit)"},
+	{Key: compressContentKey, Kind: "bool", Default: "false", Description: "Gzip the content of large memories on disk (file and git providers); see 'cmctl compact'"},
+	{Key: "skip-duplicate", Kind: "bool", Default: "false", Description: "Make 'create' return an existing memory with identical content"},
+	{Key: "encryption-key-file", Kind: "string", Default: "", Description: "File containing the passphrase for encryption at rest"},
+	{Key: "encrypt-metadata", Kind: "bool", Default: "false", Description: "Also encrypt memory names and labels"},
+	{Key: noTrackAccessKey, Kind: "bool", Default: "false", Description: "Don't record when memories are read (for read-only storage)"},
+	{Key: "no-commit", Kind: "bool", Default: "false", Description: "With the git provider, don't commit changes"},
+	{Key: "git-commit-template", Kind: "string", Default: "", Description: "Commit message template for the git provider"},
+	{Key: "git-remote", Kind: "string", Default: "", Description: "Remote the git provider syncs with"},
+	{Key: "redact", Kind: "bool", Default: "true", Description: "Redact secrets from chats on import"},
+	{Key: "redact-patterns", Kind: "list", Default: "[]", Description: "Extra regular expressions to redact on import, as a YAML list",
+		Validate: validateRedactPatterns},
+	{Key: chatNameTemplateKey, Kind: "string", Default: "", Description: "Default --name-template for imported chats, e.g. chat/{date}/{lang}",
+		Validate: validateChatNameTemplate},
+	{Key: labelAliasesKey, Kind: "map", Default: "{}", Description: "Label value aliases applied on import, as a YAML map of alias to canonical value"},
+	{Key: outputTemplatesKey, Kind: "map", Default: "{}", Description: "Named Go templates for -o template=<name>, as a YAML map"},
+	{Key: eventLogKey, Kind: "bool", Default: "false", Description: "Append every create, update and delete to events.log; see 'cmctl events'"},
+	{Key: eventLogMaxBytesKey, Kind: "int", Default: strconv.Itoa(storage.DefaultEventLogMaxBytes), Description: "Size at which events.log is rotated, keeping 3 old logs (0 for no limit)"},
+	{Key: "trash-retention", Kind: "string", Default: defaultTrashRetention, Description: "Retention period for 'trash empty --expired'"},
+}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Create, view, or modify the cmctl config file",
+	Long: `Manage the cmctl config file. Settings in the file apply when the
+corresponding flag isn't given. The file is $HOME/.contextmemory/config.yaml
+unless --config names another path.
+
+Examples:
+  cmctl config init                       # Write a commented default config
+  cmctl config view                       # Show the config file
+  cmctl config set provider sqlite        # Change a setting
+  cmctl --config ./cm.yaml config init    # Scaffold a config at another path`,
+}
+
+var configInitCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Write a commented default config file",
+	Args:  cobra.NoArgs,
+	RunE:  runConfigInit,
+}
+
+var configViewCmd = &cobra.Command{
+	Use:   "view",
+	Short: "Show the config file",
+	Args:  cobra.NoArgs,
+	RunE:  runConfigView,
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Set a value in the config file",
+	Long: `Set a value in the config file, creating the file if needed. Comments
+and other settings are kept.
+
+Keys:
+` + configKeysHelp(),
+	Args: cobra.ExactArgs(2),
+	RunE: runConfigSet,
+}
+
+var configInitForce bool
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configInitCmd, configViewCmd, configSetCmd)
+
+	configInitCmd.Flags().BoolVar(&configInitForce, "force", false, "Overwrite an existing config file")
+}
+
+func runConfigInit(cmd *cobra.Command, args []string) error {
+	path, err := configFilePath()
+	if err != nil {
+		return err
+	}
+	if err := writeDefaultConfig(path, configInitForce); err != nil {
+		return err
+	}
+	VPrintf(Normal, "Wrote %s\n", path)
+	return nil
+}
+
+func runConfigView(cmd *cobra.Command, args []string) error {
+	path, err := configFilePath()
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("no config file at %s (run 'cmctl config init' to create one)", path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+	fmt.Print(string(data))
+	return nil
+}
+
+func runConfigSet(cmd *cobra.Command, args []string) error {
+	path, err := configFilePath()
+	if err != nil {
+		return err
+	}
+	if err := setConfigValue(path, args[0], args[1]); err != nil {
+		return err
+	}
+	VPrintf(Normal, "Set %s in %s\n", args[0], path)
+	return nil
+}
+
+// configFilePath returns the --config path, or the default config file
+func configFilePath() (string, error) {
+	if cfgFile != "" {
+		return cfgFile, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(home, ".contextmemory", "config.yaml"), nil
+}
+
+// defaultConfig renders the commented config written by 'config init'
+func defaultConfig() string {
+	var b strings.Builder
+	b.WriteString("# cmctl configuration. Flags override these settings.\n")
+	b.WriteString("# Run 'cmctl config set --help' for all available keys.\n")
+	for _, setting := range configSettings {
+		if !setting.Scaffold {
+			continue
+		}
+		value := setting.Default
+		if value == "" {
+			value = `""`
+		}
+		fmt.Fprintf(&b, "\n# %s\n%s: %s\n", setting.Description, setting.Key, value)
+	}
+	return b.String()
+}
+
+// writeDefaultConfig writes the default config to path, refusing to
+// replace an existing file unless force is set
+func writeDefaultConfig(path string, force bool) error {
+	if _, err := os.Stat(path); err == nil && !force {
+		return fmt.Errorf("config file %s already exists (use --force to overwrite)", path)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(defaultConfig()), 0644); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	return nil
+}
+
+// setConfigValue sets key to value in the YAML config at path, keeping
+// comments and the order of existing keys
+func setConfigValue(path, key, value string) error {
+	setting, ok := lookupConfigSetting(key)
+	if !ok {
+		return fmt.Errorf("unknown config key %q (valid keys: %s)", key, strings.Join(configKeys(), ", "))
+	}
+
+	if setting.Validate != nil {
+		if err := setting.Validate(value); err != nil {
+			return fmt.Errorf("invalid value %q for %s: %w", value, setting.Key, err)
+		}
+	}
+	valueNode, err := configValueNode(setting, value)
+	if err != nil {
+		return err
+	}
+
+	var doc yaml.Node
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return fmt.Errorf("failed to parse config %s: %w", path, err)
+		}
+	case os.IsNotExist(err):
+	default:
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+
+	if len(doc.Content) == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	mapping := doc.Content[0]
+	if mapping.Kind != yaml.MappingNode {
+		return fmt.Errorf("config %s is not a YAML mapping", path)
+	}
+
+	replaced := false
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == setting.Key {
+			existing := mapping.Content[i+1]
+			existing.Kind, existing.Tag, existing.Value, existing.Style = valueNode.Kind, valueNode.Tag, valueNode.Value, valueNode.Style
+			existing.Content = valueNode.Content
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		mapping.Content = append(mapping.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: setting.Key},
+			valueNode)
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	return nil
+}
+
+// configValueNode parses value according to the setting's kind
+func configValueNode(setting configSetting, value string) (*yaml.Node, error) {
+	switch setting.Kind {
+	case "bool":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %q for %s: expected true or false", value, setting.Key)
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(b)}, nil
+	case "int":
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %q for %s: expected an integer", value, setting.Key)
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.FormatInt(n, 10)}, nil
+	case "list":
+		var items []string
+		if err := yaml.Unmarshal([]byte(value), &items); err != nil {
+			return nil, fmt.Errorf("invalid value %q for %s: expected a YAML list such as '[a, b]'", value, setting.Key)
+		}
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Style: yaml.FlowStyle}
+		for _, item := range items {
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: item, Style: yaml.SingleQuotedStyle})
+		}
+		return node, nil
+	case "map":
+		var items map[string]string
+		if err := yaml.Unmarshal([]byte(value), &items); err != nil || items == nil {
+			return nil, fmt.Errorf("invalid value %q for %s: expected a YAML map such as '{name: value}'", value, setting.Key)
+		}
+		keys := make([]string, 0, len(items))
+		for k := range items {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		for _, k := range keys {
+			node.Content = append(node.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: k},
+				&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: items[k], Style: yaml.SingleQuotedStyle})
+		}
+		return node, nil
+	default:
+		node := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+		if value == "" {
+			node.Style = yaml.DoubleQuotedStyle
+		}
+		return node, nil
+	}
+}
+
+func lookupConfigSetting(key string) (configSetting, bool) {
+	for _, setting := range configSettings {
+		if setting.Key == key {
+			return setting, true
+		}
+	}
+	return configSetting{}, false
+}
+
+func configKeys() []string {
+	keys := make([]string, 0, len(configSettings))
+	for _, setting := range configSettings {
+		keys = append(keys, setting.Key)
+	}
+	return keys
+}
+
+// configKeysHelp formats the config keys for help text
+func configKeysHelp() string {
+	var b strings.Builder
+	for _, setting := range configSettings {
+		fmt.Fprintf(&b, "  %-20s %s\n", setting.Key, setting.Description)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
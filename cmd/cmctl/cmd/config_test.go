@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func readConfigFile(t *testing.T, path string) map[string]any {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read config: %v", err)
+	}
+	var config map[string]any
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		t.Fatalf("Failed to parse config: %v", err)
+	}
+	return config
+}
+
+func TestConfigInitRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "config.yaml")
+
+	if err := writeDefaultConfig(path, false); err != nil {
+		t.Fatalf("Failed to init config: %v", err)
+	}
+
+	config := readConfigFile(t, path)
+	expected := map[string]any{
+		"storage-dir":   "",
+		"provider":      "file",
+		"verbosity":     1,
+		"defaultOutput": "table",
+	}
+	for key, want := range expected {
+		if config[key] != want {
+			t.Errorf("Expected %s=%v, got %v", key, want, config[key])
+		}
+	}
+	if len(config) != len(expected) {
+		t.Errorf("Expected %d keys, got %v", len(expected), config)
+	}
+
+	if err := setConfigValue(path, outputTemplatesKey, `{short: '{{range .Items}}{{.ID}}{{end}}'}`); err != nil {
+		t.Fatalf("Failed to set %s: %v", outputTemplatesKey, err)
+	}
+	config = readConfigFile(t, path)
+	if templates, ok := config[outputTemplatesKey].(map[string]any); !ok || templates["short"] != "{{range .Items}}{{.ID}}{{end}}" {
+		t.Errorf("Expected %s map, got %#v", outputTemplatesKey, config[outputTemplatesKey])
+	}
+
+	// Setting a list twice replaces the items rather than appending to them
+	for _, value := range []string{"[a]", "['corp_[a-z]{8}', tok-\\d+]"} {
+		if err := setConfigValue(path, "redact-patterns", value); err != nil {
+			t.Fatalf("Failed to set redact-patterns: %v", err)
+		}
+	}
+	config = readConfigFile(t, path)
+	if patterns, ok := config["redact-patterns"].([]any); !ok || len(patterns) != 2 || patterns[0] != "corp_[a-z]{8}" || patterns[1] != `tok-\d+` {
+		t.Errorf("Expected redact-patterns list, got %#v", config["redact-patterns"])
+	}
+
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), "# Storage provider") {
+		t.Errorf("Expected a commented config, got:\n%s", data)
+	}
+
+	if err := writeDefaultConfig(path, false); err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Errorf("Expected init to refuse to overwrite, got %v", err)
+	}
+	if err := writeDefaultConfig(path, true); err != nil {
+		t.Errorf("Expected --force to overwrite, got %v", err)
+	}
+}
+
+func TestConfigSet(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := writeDefaultConfig(path, false); err != nil {
+		t.Fatalf("Failed to init config: %v", err)
+	}
+
+	for _, kv := range [][2]string{{"provider", "sqlite"}, {"verbosity", "2"}, {"no-color", "true"}, {"storage-dir", "/tmp/memories"}} {
+		if err := setConfigValue(path, kv[0], kv[1]); err != nil {
+			t.Fatalf("Failed to set %s: %v", kv[0], err)
+		}
+	}
+
+	config := readConfigFile(t, path)
+	if config["provider"] != "sqlite" || config["verbosity"] != 2 || config["no-color"] != true || config["storage-dir"] != "/tmp/memories" {
+		t.Errorf("Unexpected config after set: %v", config)
+	}
+	if config["defaultOutput"] != "table" {
+		t.Errorf("Expected other settings to be kept, got %v", config)
+	}
+
+	if err := setConfigValue(path, outputTemplatesKey, `{short: '{{range .Items}}{{.ID}}{{end}}'}`); err != nil {
+		t.Fatalf("Failed to set %s: %v", outputTemplatesKey, err)
+	}
+	config = readConfigFile(t, path)
+	if templates, ok := config[outputTemplatesKey].(map[string]any); !ok || templates["short"] != "{{range .Items}}{{.ID}}{{end}}" {
+		t.Errorf("Expected %s map, got %#v", outputTemplatesKey, config[outputTemplatesKey])
+	}
+
+	// Setting a list twice replaces the items rather than appending to them
+	for _, value := range []string{"[a]", "['corp_[a-z]{8}', tok-\\d+]"} {
+		if err := setConfigValue(path, "redact-patterns", value); err != nil {
+			t.Fatalf("Failed to set redact-patterns: %v", err)
+		}
+	}
+	config = readConfigFile(t, path)
+	if patterns, ok := config["redact-patterns"].([]any); !ok || len(patterns) != 2 || patterns[0] != "corp_[a-z]{8}" || patterns[1] != `tok-\d+` {
+		t.Errorf("Expected redact-patterns list, got %#v", config["redact-patterns"])
+	}
+
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), "# Storage provider") {
+		t.Errorf("Expected comments to be kept, got:\n%s", data)
+	}
+
+	tests := []struct {
+		name  string
+		key   string
+		value string
+	}{
+		{name: "unknown key", key: "colour", value: "red"},
+		{name: "invalid bool", key: "no-color", value: "sometimes"},
+		{name: "invalid int", key: "verbosity", value: "loud"},
+		{name: "invalid list", key: "redact-patterns", value: "{not: a list}"},
+		{name: "invalid pattern", key: "redact-patterns", value: "['(unclosed']"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := setConfigValue(path, tt.key, tt.value); err == nil {
+				t.Errorf("Expected an error setting %s=%s", tt.key, tt.value)
+			}
+		})
+	}
+}
+
+func TestConfigSetCreatesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := setConfigValue(path, "provider", "git"); err != nil {
+		t.Fatalf("Failed to set provider: %v", err)
+	}
+	if config := readConfigFile(t, path); config["provider"] != "git" {
+		t.Errorf("Expected provider git, got %v", config)
+	}
+}
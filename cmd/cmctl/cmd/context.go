@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	// contextKey is the config key and flag naming the current context
+	contextKey = "context"
+	// contextEnv overrides the context from the config file
+	contextEnv = "CONTEXTMEMORY_CONTEXT"
+	// defaultContext is the store at the root of the storage directory
+	defaultContext = "default"
+	// contextsDir holds one store per named context under the storage directory
+	contextsDir = "contexts"
+)
+
+// contextNamePattern keeps context names to a single path element
+var contextNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+var contextCmd = &cobra.Command{
+	Use:   "context",
+	Short: "Switch between isolated memory stores",
+	Long: `Contexts keep separate memory stores, such as work and personal, under
+one storage directory. Each named context is a store in the contexts/
+subdirectory; the default context is the storage directory itself, as
+before contexts existed.
+
+The context is chosen by --context, then $CONTEXTMEMORY_CONTEXT, then the
+context key in the config file that 'cmctl context use' sets.
+
+Examples:
+  cmctl context use work            # Use the work store from now on
+  cmctl --context personal get      # Use another store for one command
+  cmctl context list                # Show contexts, marking the current one
+  cmctl context use default         # Back to the storage directory itself`,
+}
+
+var contextUseCmd = &cobra.Command{
+	Use:   "use <name>",
+	Short: "Set the current context in the config file",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runContextUse,
+}
+
+var contextListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List contexts",
+	Args:  cobra.NoArgs,
+	RunE:  runContextList,
+}
+
+var contextCurrentCmd = &cobra.Command{
+	Use:   "current",
+	Short: "Print the current context",
+	Args:  cobra.NoArgs,
+	RunE:  runContextCurrent,
+}
+
+func init() {
+	rootCmd.AddCommand(contextCmd)
+	contextCmd.AddCommand(contextUseCmd, contextListCmd, contextCurrentCmd)
+}
+
+func runContextUse(cmd *cobra.Command, args []string) error {
+	if err := validateContextName(args[0]); err != nil {
+		return err
+	}
+	path, err := configFilePath()
+	if err != nil {
+		return err
+	}
+	if err := setConfigValue(path, contextKey, args[0]); err != nil {
+		return err
+	}
+	VPrintf(Normal, "Switched to context %q\n", args[0])
+	return nil
+}
+
+func runContextList(cmd *cobra.Command, args []string) error {
+	names, err := listContexts()
+	if err != nil {
+		return err
+	}
+	current := currentContext()
+	for _, name := range names {
+		marker := "  "
+		if name == current {
+			marker = "* "
+		}
+		fmt.Println(marker + name)
+	}
+	return nil
+}
+
+func runContextCurrent(cmd *cobra.Command, args []string) error {
+	fmt.Println(currentContext())
+	return nil
+}
+
+// currentContext returns the name of the context in use
+func currentContext() string {
+	if name := viper.GetString(contextKey); name != "" {
+		return name
+	}
+	return defaultContext
+}
+
+// validateContextName rejects names that aren't a single path element
+func validateContextName(name string) error {
+	if !contextNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid context name %q: use letters, digits, '.', '_' and '-'", name)
+	}
+	return nil
+}
+
+// baseStorageDir returns the storage directory contexts live under
+func baseStorageDir() (string, error) {
+	if dir := viper.GetString("storage-dir"); dir != "" {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(home, ".contextmemory"), nil
+}
+
+// contextStorageDir returns the storage directory for the current context.
+// The default context returns the --storage-dir setting unchanged so the
+// provider applies its own default.
+func contextStorageDir() (string, error) {
+	name := currentContext()
+	if name == defaultContext {
+		return viper.GetString("storage-dir"), nil
+	}
+	if err := validateContextName(name); err != nil {
+		return "", err
+	}
+	base, err := baseStorageDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, contextsDir, name), nil
+}
+
+// listContexts returns the default context, every context with a store and
+// the current context, sorted after the default
+func listContexts() ([]string, error) {
+	base, err := baseStorageDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(filepath.Join(base, contextsDir))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to list contexts: %w", err)
+	}
+
+	seen := map[string]bool{defaultContext: true}
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() && !seen[entry.Name()] {
+			seen[entry.Name()] = true
+			names = append(names, entry.Name())
+		}
+	}
+	// A context that was just selected has no store until it's written to
+	if current := currentContext(); !seen[current] {
+		names = append(names, current)
+	}
+	sort.Strings(names)
+	return append([]string{defaultContext}, names...), nil
+}
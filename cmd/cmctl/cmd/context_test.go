@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+	"github.com/spf13/viper"
+)
+
+// useTestContext selects a context for the duration of the test
+func useTestContext(t *testing.T, name string) {
+	t.Helper()
+	previous := viper.GetString(contextKey)
+	t.Cleanup(func() { viper.Set(contextKey, previous) })
+	viper.Set(contextKey, name)
+}
+
+func TestContextsIsolateMemories(t *testing.T) {
+	useTestStorageDir(t)
+
+	names := map[string]string{}
+	for _, context := range []string{"work", "personal", defaultContext} {
+		useTestContext(t, context)
+		fs, err := getStorageProvider()
+		if err != nil {
+			t.Fatalf("Failed to get storage for %s: %v", context, err)
+		}
+		memory, err := fs.Create(storage.CreateMemoryRequest{Name: context + " notes", Content: "in " + context})
+		if err != nil {
+			t.Fatalf("Failed to create memory in %s: %v", context, err)
+		}
+		names[context] = memory.Name
+	}
+
+	for context, name := range names {
+		useTestContext(t, context)
+		fs, err := getStorageProvider()
+		if err != nil {
+			t.Fatalf("Failed to get storage for %s: %v", context, err)
+		}
+		memories, err := fs.List()
+		if err != nil {
+			t.Fatalf("Failed to list memories in %s: %v", context, err)
+		}
+		if len(memories) != 1 || memories[0].Name != name {
+			t.Errorf("Expected only %q in context %s, got %+v", name, context, memories)
+		}
+	}
+}
+
+func TestContextStorageDir(t *testing.T) {
+	useTestStorageDir(t)
+	base := viper.GetString("storage-dir")
+
+	tests := []struct {
+		context string
+		want    string
+		wantErr bool
+	}{
+		{context: "", want: base},
+		{context: defaultContext, want: base},
+		{context: "work", want: filepath.Join(base, contextsDir, "work")},
+		{context: "../escape", wantErr: true},
+		{context: "a/b", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.context, func(t *testing.T) {
+			useTestContext(t, tt.context)
+			dir, err := contextStorageDir()
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error for context %q, got %s", tt.context, dir)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("contextStorageDir failed: %v", err)
+			}
+			if dir != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, dir)
+			}
+		})
+	}
+}
+
+func TestListContexts(t *testing.T) {
+	useTestStorageDir(t)
+
+	useTestContext(t, "work")
+	if _, err := getStorageProvider(); err != nil {
+		t.Fatalf("Failed to get storage: %v", err)
+	}
+
+	// A selected context is listed before it has a store
+	useTestContext(t, "archive")
+	names, err := listContexts()
+	if err != nil {
+		t.Fatalf("listContexts failed: %v", err)
+	}
+	if want := []string{defaultContext, "archive", "work"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Expected %v, got %v", want, names)
+	}
+}
+
+func TestContextUse(t *testing.T) {
+	useTestContext(t, "")
+	previous := cfgFile
+	t.Cleanup(func() { cfgFile = previous })
+	cfgFile = filepath.Join(t.TempDir(), "config.yaml")
+
+	if err := runContextUse(contextUseCmd, []string{"../work"}); err == nil {
+		t.Error("Expected an invalid context name to be rejected")
+	}
+	if err := runContextUse(contextUseCmd, []string{"work"}); err != nil {
+		t.Fatalf("context use failed: %v", err)
+	}
+
+	config := viper.New()
+	config.SetConfigFile(cfgFile)
+	if err := config.ReadInConfig(); err != nil {
+		t.Fatalf("Failed to read config: %v", err)
+	}
+	if got := config.GetString(contextKey); got != "work" {
+		t.Errorf("Expected context work in the config, got %q", got)
+	}
+}
@@ -6,7 +6,9 @@ import (
 	"io"
 	"os"
 	"strings"
+	"time"
 
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/providers"
 	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -18,17 +20,44 @@ var createCmd = &cobra.Command{
 	Long: `Create a new memory with optional name, labels, and content.
 Content can be provided via --content flag or piped from stdin.
 
+Markdown files read with --content-file or --from-dir may start with a
+'---' YAML front-matter block: its title becomes the memory name (unless
+--name is given) and its tags become labels, key=value tags as that label
+and the rest joined into tags=. The block is removed from the stored
+content; malformed front-matter is kept as content with a warning.
+
 Examples:
   cmctl create --name "API Notes" --content "REST endpoints..." --labels "type=notes,project=api"
   echo "Session context..." | cmctl create --name "Debug Session"
-  cmctl create --content "$(cat notes.txt)" --labels "type=docs"`,
+  cmctl create --content-file notes.txt --labels "type=docs"   # Content exactly as in the file
+  cmctl create --template debug-session --labels "project=api"
+  cmctl create --content "..." --labels-from-file project-labels.yaml --labels "type=notes"
+  cmctl create --content "..." --labels "language=js" --normalize-labels   # Stored as language=javascript
+  cmctl create --content "$(cat notes.txt)" --skip-duplicate   # No-op if already stored
+  cmctl create --content "Scratch context" --ttl 7d    # Expires after a week ('cmctl gc' removes it)
+  echo "Fixed the flaky test" | cmctl create --name today --append   # Create or add to "today"
+  id=$(cmctl create -q --content "Scratch")                  # Print only the memory ID
+  cmctl create --content-file diagram.png --allow-binary     # Stored base64-encoded
+  cmctl create --from-dir ./notes --pattern '*.md' --recursive --labels "source=import"`,
 	RunE: runCreate,
 }
 
 var (
-	createName    string
-	createContent string
-	createLabels  string
+	createName        string
+	createContent     string
+	createFile        string
+	createLabels      string
+	createLabelsIn    string
+	createTTL         string
+	createTemplate    string
+	createAppend      bool
+	createQuiet       bool
+	createDir         string
+	createPattern     string
+	createRecurse     bool
+	createNormalize   bool
+	createAllowBinary bool
+	createContentType string
 )
 
 func init() {
@@ -36,53 +65,104 @@ func init() {
 
 	createCmd.Flags().StringVarP(&createName, "name", "n", "", "Memory name")
 	createCmd.Flags().StringVarP(&createContent, "content", "c", "", "Memory content (or pipe from stdin)")
+	createCmd.Flags().StringVar(&createFile, "content-file", "", "Read content from a file, keeping its exact bytes (- for stdin)")
 	createCmd.Flags().StringVarP(&createLabels, "labels", "l", "", "Labels (format: key1=value1,key2=value2)")
+	createCmd.Flags().StringVar(&createLabelsIn, "labels-from-file", "", "Read labels from a YAML or JSON map; --labels overrides them")
+	createCmd.Flags().BoolVar(&createNormalize, "normalize-labels", false, "Replace aliased label values with their canonical value (see 'cmctl labels aliases')")
+	createCmd.Flags().StringVarP(&createTemplate, "template", "t", "", "Pre-fill name, labels and content from a template (see 'cmctl template list')")
+	createCmd.Flags().StringVar(&createTTL, "ttl", "", "Expire the memory after this long (e.g. 12h, 7d, 2w)")
+	createCmd.Flags().BoolVar(&createAppend, "append", false, "Append to the memory with the same --name if one exists, instead of creating another")
+	createCmd.Flags().BoolVarP(&createQuiet, "quiet", "q", false, "Print only the memory ID")
+	createCmd.Flags().StringVar(&createDir, "from-dir", "", "Create a memory from each file in a directory, named after the file")
+	createCmd.Flags().StringVar(&createPattern, "pattern", "*", "With --from-dir, only files whose names match this glob")
+	createCmd.Flags().BoolVarP(&createRecurse, "recursive", "r", false, "With --from-dir, include files in subdirectories")
+	createCmd.Flags().StringVar(&createContentType, "content-type", "", "Media type of the content, e.g. text/markdown or text/x-go (detected when not given)")
+	createCmd.Flags().BoolVar(&createAllowBinary, "allow-binary", false, "Store binary content base64-encoded instead of rejecting it ('cat' and 'get --raw-content' decode it)")
+	createCmd.Flags().Bool("skip-duplicate", false, "Return the existing memory instead of creating one with identical content")
+
+	if err := viper.BindPFlag("skip-duplicate", createCmd.Flags().Lookup("skip-duplicate")); err != nil {
+		panic(fmt.Sprintf("failed to bind skip-duplicate flag: %v", err))
+	}
 }
 
 func runCreate(cmd *cobra.Command, args []string) error {
+	if createContent != "" && createFile != "" {
+		return fmt.Errorf("--content and --content-file are mutually exclusive")
+	}
+
 	// Initialize storage
-	storageDir := viper.GetString("storage-dir")
-	fs, err := storage.NewFileStorage(storageDir)
+	fs, err := getStorageProvider()
 	if err != nil {
 		return fmt.Errorf("failed to initialize storage: %w", err)
 	}
 
+	if createDir != "" {
+		return runCreateFromDir(fs)
+	}
+
 	// Get content from stdin if not provided via flag
 	content := createContent
-	if content == "" {
+	if createFile != "" {
+		if content, err = readContentFile(createFile, os.Stdin); err != nil {
+			return err
+		}
+	} else if content == "" && createAllowBinary && stdinPiped() {
+		// Binary input has to be read as is rather than line by line
+		if content, err = readContentFile("-", os.Stdin); err != nil {
+			return err
+		}
+	} else if content == "" {
 		stdinContent, err := readStdin()
 		if err == nil && stdinContent != "" {
 			content = stdinContent
 		}
 	}
 
-	if content == "" {
-		return fmt.Errorf("content is required (use --content or pipe from stdin)")
+	req, err := buildCreateRequest(content, time.Now())
+	if err != nil {
+		return err
 	}
-
-	// Parse labels
-	labels := make(map[string]string)
-	if createLabels != "" {
-		pairs := strings.Split(createLabels, ",")
-		for _, pair := range pairs {
-			parts := strings.SplitN(pair, "=", 2)
-			if len(parts) == 2 {
-				labels[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
-			}
-		}
+	if createFile != "" && isMarkdownFile(createFile) {
+		req = applyFrontMatter(req, createFile, createName != "")
+	}
+	req = detectContentType(req, createFile)
+	if req, err = encodeBinaryContent(req, createAllowBinary); err != nil {
+		return err
+	}
+	if createAppend && req.Metadata[storage.ContentEncodingKey] != nil {
+		return fmt.Errorf("--append can't add binary content")
 	}
 
-	// Create memory
-	req := storage.CreateMemoryRequest{
-		Name:    createName,
-		Content: content,
-		Labels:  labels,
+	if createAppend {
+		memory, appended, err := createOrAppend(fs, req, storage.DefaultAppendSeparator)
+		if err != nil {
+			return err
+		}
+		if createQuiet {
+			fmt.Println(memory.ID)
+			return nil
+		}
+		action := "created"
+		if appended {
+			action = "appended"
+		}
+		fmt.Printf("memory/%s %s\n", memory.ID, action)
+		return nil
 	}
 
+	start := time.Now()
 	memory, err := fs.Create(req)
 	if err != nil {
 		return fmt.Errorf("failed to create memory: %w", err)
 	}
+	if createQuiet {
+		fmt.Println(memory.ID)
+		return nil
+	}
+	if memory.CreatedAt.Before(start) {
+		fmt.Printf("memory/%s unchanged (identical content already stored)\n", memory.ID)
+		return nil
+	}
 
 	// Output success message
 	fmt.Printf("memory/%s created\n", memory.ID)
@@ -90,11 +170,192 @@ func runCreate(cmd *cobra.Command, args []string) error {
 		fmt.Printf("NAME\t%s\n", memory.Name)
 		fmt.Printf("LABELS\t%s\n", formatLabels(memory.Labels))
 		fmt.Printf("CREATED\t%s\n", memory.CreatedAt.Format("2006-01-02T15:04:05Z"))
+		if memory.ExpiresAt != nil {
+			fmt.Printf("EXPIRES\t%s\n", memory.ExpiresAt.Format("2006-01-02T15:04:05Z"))
+		}
 	}
 
 	return nil
 }
 
+// runCreateFromDir creates memories from the files in --from-dir
+func runCreateFromDir(fs providers.StorageProvider) error {
+	if createContent != "" || createFile != "" || createName != "" || createAppend {
+		return fmt.Errorf("--from-dir takes names and content from the files and cannot be used with --content, --content-file, --name or --append")
+	}
+	req, err := baseCreateRequest(time.Now())
+	if err != nil {
+		return err
+	}
+
+	summary, err := createFromDir(fs, createDir, createPattern, createRecurse, req, viper.GetInt64("max-content-bytes"), createAllowBinary)
+	if createQuiet {
+		for _, id := range summary.IDs {
+			fmt.Println(id)
+		}
+	} else {
+		fmt.Printf("Created %d memories, skipped %d\n", summary.Created, summary.Skipped)
+	}
+	return err
+}
+
+// buildCreateRequest combines the --template defaults with the create flags.
+// Flag values override the template's name and content, and --labels is
+// merged over the template's labels.
+func buildCreateRequest(content string, now time.Time) (storage.CreateMemoryRequest, error) {
+	req, err := baseCreateRequest(now)
+	if err != nil {
+		return req, err
+	}
+	if content != "" {
+		req.Content = content
+	}
+	if req.Content == "" {
+		return req, fmt.Errorf("content is required (use --content, --content-file or pipe from stdin)")
+	}
+	return req, nil
+}
+
+// baseCreateRequest builds the parts of a create request that don't depend
+// on its content: the template, --name, --labels, --skip-duplicate and --ttl
+func baseCreateRequest(now time.Time) (storage.CreateMemoryRequest, error) {
+	req := storage.CreateMemoryRequest{Labels: make(map[string]string)}
+	if createTemplate != "" {
+		dir, err := templatesDir()
+		if err != nil {
+			return req, err
+		}
+		tmpl, err := loadTemplate(dir, createTemplate)
+		if err != nil {
+			return req, err
+		}
+		req = tmpl.request(now)
+	}
+
+	if createName != "" {
+		req.Name = createName
+	}
+
+	if createLabelsIn != "" {
+		labels, err := loadLabelsFile(createLabelsIn)
+		if err != nil {
+			return req, err
+		}
+		req.Labels = mergeLabels(req.Labels, labels)
+	}
+
+	// Parse labels
+	if createLabels != "" {
+		pairs := strings.Split(createLabels, ",")
+		for _, pair := range pairs {
+			parts := strings.SplitN(pair, "=", 2)
+			if len(parts) == 2 {
+				req.Labels[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+			}
+		}
+	}
+
+	if createNormalize {
+		req.Labels, _ = normalizeLabels(req.Labels, labelAliases())
+	}
+
+	req.SkipDuplicate = viper.GetBool("skip-duplicate")
+
+	if createContentType != "" {
+		if err := storage.ValidateContentType(createContentType); err != nil {
+			return req, err
+		}
+		req = storage.WithContentType(req, createContentType)
+	}
+
+	if createTTL != "" {
+		expiresAt, err := expiryFromTTL(createTTL, now)
+		if err != nil {
+			return req, err
+		}
+		req.ExpiresAt = &expiresAt
+	}
+	return req, nil
+}
+
+// createOrAppend appends req's content to the memory named req.Name, merging
+// its labels, or creates the memory if no memory has that name yet. It
+// reports whether the content was appended.
+func createOrAppend(fs providers.StorageProvider, req storage.CreateMemoryRequest, separator string) (*storage.Memory, bool, error) {
+	if strings.TrimSpace(req.Name) == "" {
+		return nil, false, fmt.Errorf("--append requires --name to find the memory to append to")
+	}
+
+	existing, err := findMemoryByName(fs, req.Name)
+	if err != nil {
+		return nil, false, err
+	}
+	if existing == nil {
+		memory, err := fs.Create(req)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to create memory: %w", err)
+		}
+		return memory, false, nil
+	}
+
+	memory, err := updateMemory(fs, storage.UpdateMemoryRequest{
+		ID:        existing.ID,
+		Content:   req.Content,
+		Append:    true,
+		Separator: separator,
+		ExpiresAt: req.ExpiresAt,
+	}, req.Labels)
+	if err != nil {
+		return nil, false, err
+	}
+	return memory, true, nil
+}
+
+// readContentFile reads content from path, or from stdin when path is "-",
+// keeping its bytes exactly as they are
+func readContentFile(path string, stdin io.Reader) (string, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read content file: %w", err)
+	}
+	return string(data), nil
+}
+
+// stdinPiped reports whether stdin is piped or redirected rather than a
+// terminal
+func stdinPiped() bool {
+	stat, err := os.Stdin.Stat()
+	return err == nil && (stat.Mode()&os.ModeCharDevice) == 0
+}
+
+// encodeBinaryContent base64-encodes binary content when allowed, and
+// otherwise rejects it
+func encodeBinaryContent(req storage.CreateMemoryRequest, allowBinary bool) (storage.CreateMemoryRequest, error) {
+	if !storage.IsBinary(req.Content) {
+		return req, nil
+	}
+	if !allowBinary {
+		return req, fmt.Errorf("%w; use --allow-binary to store it base64-encoded", storage.ErrBinaryContent)
+	}
+	return storage.EncodeBinary(req), nil
+}
+
+// detectContentType records the type of req's content, guessed from the
+// name of the file it was read from, unless --content-type already set it.
+// Binary content is left without a type.
+func detectContentType(req storage.CreateMemoryRequest, filename string) storage.CreateMemoryRequest {
+	if _, ok := req.Metadata[storage.ContentTypeKey]; ok || storage.IsBinary(req.Content) {
+		return req
+	}
+	return storage.WithContentType(req, storage.DetectContentType(filename, req.Content))
+}
+
 func readStdin() (string, error) {
 	stat, err := os.Stdin.Stat()
 	if err != nil {
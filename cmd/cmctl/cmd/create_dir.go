@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/providers"
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+)
+
+// dirImportSummary counts the outcome of creating memories from a directory
+type dirImportSummary struct {
+	Created int
+	Skipped int
+	IDs     []string
+}
+
+// createFromDir creates a memory from each file in dir whose name matches
+// pattern, descending into subdirectories when recursive is set. Each
+// memory is named after its file, without the extension, and gets base's
+// labels and options. The title and tags in a markdown file's front-matter
+// override the name and add labels. Hidden files and directories are left
+// out, and files
+// larger than limit bytes (0 for no limit), binary unless allowBinary is
+// set, or already stored under --skip-duplicate are skipped.
+func createFromDir(provider providers.StorageProvider, dir, pattern string, recursive bool, base storage.CreateMemoryRequest, limit int64, allowBinary bool) (dirImportSummary, error) {
+	var summary dirImportSummary
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return summary, fmt.Errorf("invalid --pattern %q: %w", pattern, err)
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return summary, fmt.Errorf("failed to read directory: %w", err)
+	}
+	if !info.IsDir() {
+		return summary, fmt.Errorf("%s is not a directory", dir)
+	}
+
+	err = filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == dir {
+			return nil
+		}
+		if strings.HasPrefix(entry.Name(), ".") {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.IsDir() {
+			if !recursive {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		if matched, _ := filepath.Match(pattern, entry.Name()); !matched {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			rel = path
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", rel, err)
+		}
+		if err := storage.ValidateContentSize(string(data), limit); err != nil {
+			VPrintf(Normal, "Skipping %s: %v\n", rel, err)
+			summary.Skipped++
+			return nil
+		}
+		if len(strings.TrimSpace(string(data))) == 0 {
+			VPrintf(Normal, "Skipping %s: file is empty\n", rel)
+			summary.Skipped++
+			return nil
+		}
+
+		req := base
+		req.Name = strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		req.Content = string(data)
+		req.Labels = make(map[string]string, len(base.Labels))
+		for k, v := range base.Labels {
+			req.Labels[k] = v
+		}
+		if isMarkdownFile(path) {
+			req = applyFrontMatter(req, rel, false)
+		}
+		req = detectContentType(req, path)
+		if storage.IsBinary(req.Content) {
+			if !allowBinary {
+				VPrintf(Normal, "Skipping %s: %v\n", rel, storage.ErrBinaryContent)
+				summary.Skipped++
+				return nil
+			}
+			req = storage.EncodeBinary(req)
+		}
+
+		start := time.Now()
+		memory, err := provider.Create(req)
+		if err != nil {
+			return fmt.Errorf("failed to create memory from %s: %w", rel, err)
+		}
+		if memory.CreatedAt.Before(start) {
+			VPrintf(Normal, "Skipping %s: identical content already stored as memory/%s\n", rel, memory.ID)
+			summary.Skipped++
+			return nil
+		}
+		DebugPrintf("Created memory/%s from %s\n", memory.ID, rel)
+		summary.Created++
+		summary.IDs = append(summary.IDs, memory.ID)
+		return nil
+	})
+	return summary, err
+}
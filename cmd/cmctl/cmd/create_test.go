@@ -0,0 +1,274 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+)
+
+func TestCreateContentFile(t *testing.T) {
+	useTestStorageDir(t)
+	defer func() { createQuiet, createContent, createFile, createName = false, "", "", "" }()
+
+	exact := "first line\n\n  indented\twith tab  \r\nlast line with trailing spaces   \n\n"
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(path, []byte(exact), 0644); err != nil {
+		t.Fatalf("Failed to write content file: %v", err)
+	}
+
+	createQuiet, createFile, createName = true, path, "From file"
+	out := captureStdout(t, func() error { return runCreate(createCmd, nil) })
+
+	fs, err := getStorageProvider()
+	if err != nil {
+		t.Fatalf("Failed to get storage: %v", err)
+	}
+	memory, err := fs.Get(strings.TrimSpace(out))
+	if err != nil {
+		t.Fatalf("Failed to get created memory: %v", err)
+	}
+	if memory.Content != exact {
+		t.Errorf("Expected content %q, got %q", exact, memory.Content)
+	}
+
+	createContent = "inline"
+	if err := runCreate(createCmd, nil); err == nil || !strings.Contains(err.Error(), "mutually exclusive") {
+		t.Errorf("Expected an error for --content with --content-file, got %v", err)
+	}
+}
+
+func TestReadContentFile(t *testing.T) {
+	exact := "piped\n  content \n\n"
+	content, err := readContentFile("-", strings.NewReader(exact))
+	if err != nil {
+		t.Fatalf("Failed to read stdin: %v", err)
+	}
+	if content != exact {
+		t.Errorf("Expected %q from stdin, got %q", exact, content)
+	}
+
+	if _, err := readContentFile(filepath.Join(t.TempDir(), "missing.txt"), nil); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
+
+func TestCreateFromDir(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"alpha.md":        "alpha notes\n",
+		"bravo.txt":       "not markdown",
+		".hidden.md":      "hidden",
+		"big.md":          strings.Repeat("x", 64),
+		"copy.md":         "alpha notes\n",
+		"sub/charlie.md":  "charlie notes",
+		".git/config.md":  "ignored",
+		"sub/deep/d.md":   "delta notes",
+		"sub/empty.md":    "",
+		"sub/deep/e.text": "echo",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+	base := storage.CreateMemoryRequest{Labels: map[string]string{"source": "import"}, SkipDuplicate: true}
+
+	tests := []struct {
+		name      string
+		recursive bool
+		created   []string
+		skipped   int
+	}{
+		// big.md is over the limit and copy.md duplicates alpha.md
+		{name: "top level", recursive: false, created: []string{"alpha"}, skipped: 2},
+		{name: "recursive", recursive: true, created: []string{"alpha", "charlie", "d"}, skipped: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := newTestStorage(t)
+			summary, err := createFromDir(fs, dir, "*.md", tt.recursive, base, 32, false)
+			if err != nil {
+				t.Fatalf("Failed to create from directory: %v", err)
+			}
+			if summary.Created != len(tt.created) || summary.Skipped != tt.skipped {
+				t.Errorf("Expected %d created and %d skipped, got %+v", len(tt.created), tt.skipped, summary)
+			}
+
+			memories, err := fs.List()
+			if err != nil {
+				t.Fatalf("Failed to list memories: %v", err)
+			}
+			var names []string
+			for _, memory := range memories {
+				names = append(names, memory.Name)
+				if memory.Labels["source"] != "import" {
+					t.Errorf("Expected %s to have the source label, got %v", memory.Name, memory.Labels)
+				}
+			}
+			sort.Strings(names)
+			if !reflect.DeepEqual(names, tt.created) {
+				t.Errorf("Expected memories %v, got %v", tt.created, names)
+			}
+		})
+	}
+
+	fs := newTestStorage(t)
+	if _, err := createFromDir(fs, dir, "[", false, base, 0, false); err == nil {
+		t.Error("Expected an error for an invalid pattern")
+	}
+	if _, err := createFromDir(fs, filepath.Join(dir, "alpha.md"), "*", false, base, 0, false); err == nil {
+		t.Error("Expected an error when --from-dir is a file")
+	}
+}
+
+func TestLabelsFromFile(t *testing.T) {
+	useTestStorageDir(t)
+	defer func() {
+		createLabels, createLabelsIn, updateLabels, updateLabelsIn, updateName = "", "", "", "", ""
+	}()
+
+	dir := t.TempDir()
+	writeFile := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write labels file: %v", err)
+		}
+		return path
+	}
+	yamlFile := writeFile("labels.yaml", "project: api\nteam: platform\nstatus: draft\n")
+	jsonFile := writeFile("labels.json", `{"project": "web", "priority": "high"}`)
+
+	// Command-line labels take precedence over the file
+	createLabelsIn, createLabels = yamlFile, "status=final,type=notes"
+	req, err := buildCreateRequest("content", time.Now())
+	if err != nil {
+		t.Fatalf("Failed to build request: %v", err)
+	}
+	want := map[string]string{"project": "api", "team": "platform", "status": "final", "type": "notes"}
+	if !reflect.DeepEqual(req.Labels, want) {
+		t.Errorf("Expected labels %v, got %v", want, req.Labels)
+	}
+
+	fs, err := getStorageProvider()
+	if err != nil {
+		t.Fatalf("Failed to get storage: %v", err)
+	}
+	memory, err := fs.Create(req)
+	if err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+
+	// update merges the file over the existing labels, then --labels over both
+	updateLabelsIn, updateLabels = jsonFile, "priority=low"
+	captureStdout(t, func() error { return runUpdate(updateCmd, []string{memory.ID}) })
+	updated, err := fs.Get(memory.ID)
+	if err != nil {
+		t.Fatalf("Failed to get memory: %v", err)
+	}
+	want = map[string]string{"project": "web", "team": "platform", "status": "final", "type": "notes", "priority": "low"}
+	if !reflect.DeepEqual(updated.Labels, want) {
+		t.Errorf("Expected labels %v after update, got %v", want, updated.Labels)
+	}
+
+	for name, content := range map[string]string{
+		"bad-key.yaml": "\"bad key\": value\n",
+		"list.yaml":    "- project\n- api\n",
+	} {
+		if _, err := loadLabelsFile(writeFile(name, content)); err == nil {
+			t.Errorf("Expected an error for %s", name)
+		}
+	}
+	if _, err := loadLabelsFile(filepath.Join(dir, "missing.yaml")); err == nil {
+		t.Error("Expected an error for a missing labels file")
+	}
+}
+
+func TestCreateBinaryContent(t *testing.T) {
+	binary := "GIF89a\x01\x00\x01\x00\x80\x00\x00\xff\xff\xff\x00\x00\x00;"
+	req := storage.CreateMemoryRequest{Name: "pixel", Content: binary}
+
+	if _, err := encodeBinaryContent(req, false); !errors.Is(err, storage.ErrBinaryContent) {
+		t.Fatalf("Expected binary content to be rejected, got %v", err)
+	}
+	text, err := encodeBinaryContent(storage.CreateMemoryRequest{Name: "notes", Content: "plain text"}, true)
+	if err != nil || text.Content != "plain text" || text.Metadata != nil {
+		t.Fatalf("Expected text to be stored as is with --allow-binary, got %+v, %v", text, err)
+	}
+
+	encoded, err := encodeBinaryContent(req, true)
+	if err != nil {
+		t.Fatalf("Failed to encode binary content: %v", err)
+	}
+	fs := newTestStorage(t)
+	memory, err := fs.Create(encoded)
+	if err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := catMemories(fs, []string{memory.ID}, &out); err != nil {
+		t.Fatalf("catMemories failed: %v", err)
+	}
+	if out.String() != binary {
+		t.Errorf("Expected cat to write the original bytes, got %q", out.String())
+	}
+}
+
+func TestCreateContentType(t *testing.T) {
+	useTestStorageDir(t)
+	defer func() { createQuiet, createFile, createContent, createContentType = false, "", "", "" }()
+
+	path := filepath.Join(t.TempDir(), "main.go")
+	if err := os.WriteFile(path, []byte("package main\n"), 0644); err != nil {
+		t.Fatalf("Failed to write content file: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		file        string
+		content     string
+		contentType string
+		want        string
+	}{
+		{name: "detected from file name", file: path, want: "text/x-go"},
+		{name: "detected from content", content: "# Plan\n\n- ship it", want: storage.ContentTypeMarkdown},
+		{name: "explicit override", file: path, contentType: "text/plain", want: storage.ContentTypePlain},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			createQuiet, createFile, createContent, createContentType = true, tt.file, tt.content, tt.contentType
+			out := captureStdout(t, func() error { return runCreate(createCmd, nil) })
+
+			fs, err := getStorageProvider()
+			if err != nil {
+				t.Fatalf("Failed to get storage: %v", err)
+			}
+			memory, err := fs.Get(strings.TrimSpace(out))
+			if err != nil {
+				t.Fatalf("Failed to get created memory: %v", err)
+			}
+			if got := memory.ContentType(); got != tt.want {
+				t.Errorf("Expected content type %s, got %s", tt.want, got)
+			}
+		})
+	}
+
+	createFile, createContent, createContentType = "", "notes", "markdown"
+	if err := runCreate(createCmd, nil); err == nil || !strings.Contains(err.Error(), "invalid content type") {
+		t.Errorf("Expected an error for an invalid content type, got %v", err)
+	}
+}
@@ -0,0 +1,290 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+var dedupeCmd = &cobra.Command{
+	Use:   "dedupe",
+	Short: "Find and remove duplicate memories",
+	Long: `Find memories that duplicate each other and optionally delete the extras.
+
+Memories are grouped by an exact hash of their content, the same hash
+create --skip-duplicate compares, their labels, or both. Labels are compared
+without the type=manual default given to memories created without a type,
+and --by labels never groups memories that have no other labels. With
+--similarity, memories whose content is nearly the same (by word overlap)
+are grouped as well.
+
+Nothing is removed unless --apply is given; by default the duplicate
+clusters are only listed. Removed duplicates are moved to the trash unless
+--purge is given.
+
+Examples:
+  cmctl dedupe                              # List exact content duplicates
+  cmctl dedupe --similarity 0.9             # Include near-duplicates
+  cmctl dedupe --by both                    # Same content and same labels
+  cmctl dedupe --apply --keep newest        # Trash all but the newest copy
+  cmctl dedupe --apply --purge              # Delete the extras permanently`,
+	RunE: runDedupe,
+}
+
+var (
+	dedupeApply      bool
+	dedupeKeep       string
+	dedupeBy         string
+	dedupeSimilarity float64
+	dedupePurge      bool
+)
+
+func init() {
+	rootCmd.AddCommand(dedupeCmd)
+
+	dedupeCmd.Flags().BoolVar(&dedupeApply, "apply", false, "Remove duplicates (default is a dry run)")
+	dedupeCmd.Flags().BoolVar(&dedupePurge, "purge", false, "With --apply, delete duplicates permanently instead of moving them to the trash")
+	dedupeCmd.Flags().StringVar(&dedupeKeep, "keep", "oldest", "Which memory of each cluster to keep: oldest|newest")
+	dedupeCmd.Flags().StringVar(&dedupeBy, "by", "content", "What makes memories duplicates: content|labels|both")
+	dedupeCmd.Flags().Float64Var(&dedupeSimilarity, "similarity", 0, "Also group memories whose content similarity is at least this (0-1, 0 for exact matches only)")
+}
+
+// dedupeOptions controls how duplicate memories are detected
+type dedupeOptions struct {
+	By         string  // "content", "labels", or "both"
+	Similarity float64 // Minimum fuzzy content similarity; 0 for exact only
+}
+
+func runDedupe(cmd *cobra.Command, args []string) error {
+	switch dedupeBy {
+	case "content", "labels", "both":
+	default:
+		return fmt.Errorf("invalid --by value %q (must be content, labels, or both)", dedupeBy)
+	}
+	if dedupeKeep != "oldest" && dedupeKeep != "newest" {
+		return fmt.Errorf("invalid --keep value %q (must be oldest or newest)", dedupeKeep)
+	}
+	if dedupeSimilarity < 0 || dedupeSimilarity > 1 {
+		return fmt.Errorf("--similarity must be between 0 and 1")
+	}
+	if dedupeSimilarity > 0 && dedupeBy == "labels" {
+		return fmt.Errorf("--similarity compares content and cannot be used with --by labels")
+	}
+
+	// Initialize storage
+	fs, err := getStorageProvider()
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+
+	memories, err := fs.List()
+	if err != nil {
+		return fmt.Errorf("failed to list memories: %w", err)
+	}
+
+	clusters := findDuplicateClusters(memories, dedupeOptions{By: dedupeBy, Similarity: dedupeSimilarity})
+	if len(clusters) == 0 {
+		fmt.Println("No duplicate memories found")
+		return nil
+	}
+
+	var toDelete []storage.Memory
+	for i, cluster := range clusters {
+		kept, duplicates := splitDuplicateCluster(cluster, dedupeKeep)
+		toDelete = append(toDelete, duplicates...)
+
+		fmt.Printf("Cluster %d (%d memories):\n", i+1, len(cluster))
+		fmt.Printf("  keep    %s  %s  (%s)\n", kept.ID, kept.Name, kept.CreatedAt.Format("2006-01-02 15:04"))
+		for _, memory := range duplicates {
+			fmt.Printf("  delete  %s  %s  (%s)\n", memory.ID, memory.Name, memory.CreatedAt.Format("2006-01-02 15:04"))
+		}
+		fmt.Println()
+	}
+
+	if !dedupeApply {
+		fmt.Printf("Found %d duplicate cluster(s); %d memories would be removed (use --apply to move them to the trash)\n", len(clusters), len(toDelete))
+		return nil
+	}
+
+	removed := 0
+	for _, memory := range toDelete {
+		if err := removeMemory(fs, memory.ID, dedupePurge); err != nil {
+			VPrintf(Quiet, "Failed to delete %s: %v\n", memory.ID, err)
+			continue
+		}
+		removed++
+	}
+	if dedupePurge {
+		fmt.Printf("Deleted %d duplicate memories from %d cluster(s)\n", removed, len(clusters))
+	} else {
+		fmt.Printf("Moved %d duplicate memories from %d cluster(s) to the trash\n", removed, len(clusters))
+	}
+	return nil
+}
+
+// findDuplicateClusters groups memories that duplicate each other. Only
+// groups with more than one memory are returned, each ordered oldest first.
+func findDuplicateClusters(memories []storage.Memory, opts dedupeOptions) [][]storage.Memory {
+	// Union-find over memory indexes so exact and fuzzy matches can merge
+	parent := make([]int, len(memories))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	union := func(i, j int) {
+		parent[find(i)] = find(j)
+	}
+
+	keys := make([]string, len(memories))
+	firstByKey := make(map[string]int)
+	for i, memory := range memories {
+		if opts.By == "labels" && len(identifyingLabels(memory.Labels)) == 0 {
+			// Memories with only default labels share nothing
+			continue
+		}
+		keys[i] = duplicateKey(memory, opts.By)
+		if first, ok := firstByKey[keys[i]]; ok {
+			union(i, first)
+		} else {
+			firstByKey[keys[i]] = i
+		}
+	}
+
+	if opts.Similarity > 0 {
+		shingles := make([]map[string]bool, len(memories))
+		for i, memory := range memories {
+			shingles[i] = contentShingles(memory.Content)
+		}
+		for i := range memories {
+			for j := i + 1; j < len(memories); j++ {
+				if find(i) == find(j) {
+					continue
+				}
+				if opts.By == "both" && canonicalLabels(memories[i].Labels) != canonicalLabels(memories[j].Labels) {
+					continue
+				}
+				if jaccard(shingles[i], shingles[j]) >= opts.Similarity {
+					union(i, j)
+				}
+			}
+		}
+	}
+
+	groups := make(map[int][]storage.Memory)
+	var roots []int
+	for i, memory := range memories {
+		root := find(i)
+		if _, ok := groups[root]; !ok {
+			roots = append(roots, root)
+		}
+		groups[root] = append(groups[root], memory)
+	}
+
+	var clusters [][]storage.Memory
+	for _, root := range roots {
+		cluster := groups[root]
+		if len(cluster) < 2 {
+			continue
+		}
+		sort.SliceStable(cluster, func(i, j int) bool {
+			return cluster[i].CreatedAt.Before(cluster[j].CreatedAt)
+		})
+		clusters = append(clusters, cluster)
+	}
+
+	// Report clusters in the order their oldest memory was created
+	sort.SliceStable(clusters, func(i, j int) bool {
+		return clusters[i][0].CreatedAt.Before(clusters[j][0].CreatedAt)
+	})
+
+	return clusters
+}
+
+// splitDuplicateCluster returns the memory to keep and the duplicates to
+// remove from a cluster ordered oldest first
+func splitDuplicateCluster(cluster []storage.Memory, keep string) (storage.Memory, []storage.Memory) {
+	if keep == "newest" {
+		last := len(cluster) - 1
+		return cluster[last], append([]storage.Memory(nil), cluster[:last]...)
+	}
+	return cluster[0], append([]storage.Memory(nil), cluster[1:]...)
+}
+
+// duplicateKey returns the exact-match key for a memory
+func duplicateKey(memory storage.Memory, by string) string {
+	switch by {
+	case "labels":
+		return canonicalLabels(memory.Labels)
+	case "both":
+		return storage.ContentHash(memory.Content) + "|" + canonicalLabels(memory.Labels)
+	default:
+		return storage.ContentHash(memory.Content)
+	}
+}
+
+// canonicalLabels renders labels as sorted key=value pairs, leaving out
+// the default type label
+func canonicalLabels(labels map[string]string) string {
+	labels = identifyingLabels(labels)
+	pairs := make([]string, 0, len(labels))
+	for _, key := range sortedKeys(labels) {
+		pairs = append(pairs, key+"="+labels[key])
+	}
+	return strings.Join(pairs, ",")
+}
+
+// identifyingLabels returns labels without the type=manual default that
+// storage gives memories created without a type, since it says nothing
+// about what a memory holds
+func identifyingLabels(labels map[string]string) map[string]string {
+	if labels["type"] != storage.DefaultMemoryType {
+		return labels
+	}
+	identifying := make(map[string]string, len(labels)-1)
+	for k, v := range labels {
+		if k != "type" {
+			identifying[k] = v
+		}
+	}
+	return identifying
+}
+
+// contentShingles returns the set of lowercase word trigrams in content,
+// or the words themselves for very short content
+func contentShingles(content string) map[string]bool {
+	words := strings.Fields(strings.ToLower(content))
+	shingles := make(map[string]bool)
+	if len(words) < 3 {
+		for _, word := range words {
+			shingles[word] = true
+		}
+		return shingles
+	}
+	for i := 0; i+3 <= len(words); i++ {
+		shingles[strings.Join(words[i:i+3], " ")] = true
+	}
+	return shingles
+}
+
+// jaccard returns the Jaccard similarity of two sets
+func jaccard(a, b map[string]bool) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+	intersection := 0
+	for key := range a {
+		if b[key] {
+			intersection++
+		}
+	}
+	return float64(intersection) / float64(len(a)+len(b)-intersection)
+}
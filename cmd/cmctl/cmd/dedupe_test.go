@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/providers"
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+)
+
+func dedupeFixtures() []storage.Memory {
+	base := time.Date(2025, 9, 20, 10, 0, 0, 0, time.UTC)
+	paragraph := "The index is rebuilt whenever a memory file is written, so concurrent writers can race and leave stale entries behind in the index file."
+	return []storage.Memory{
+		{ID: "a1", Content: paragraph, Labels: map[string]string{"type": "chat"}, CreatedAt: base},
+		{ID: "a2", Content: paragraph, Labels: map[string]string{"type": "notes"}, CreatedAt: base.Add(time.Hour)},
+		{ID: "a3", Content: paragraph, Labels: map[string]string{"type": "chat"}, CreatedAt: base.Add(2 * time.Hour)},
+		// Differs only in whitespace, which create --skip-duplicate doesn't
+		// count as the same content either
+		{ID: "w1", Content: paragraph + "\n", Labels: map[string]string{"type": "chat"}, CreatedAt: base.Add(150 * time.Minute)},
+		// Near-duplicate: one word changed
+		{ID: "b1", Content: strings.Replace(paragraph, "concurrent", "parallel", 1), Labels: map[string]string{"type": "chat"}, CreatedAt: base.Add(3 * time.Hour)},
+		// Similar topic but distinct content
+		{ID: "c1", Content: "Concurrent writers race on the index; we should add a file lock before rebuilding it.", Labels: map[string]string{"type": "chat"}, CreatedAt: base.Add(4 * time.Hour)},
+	}
+}
+
+func clusterIDs(clusters [][]storage.Memory) [][]string {
+	var ids [][]string
+	for _, cluster := range clusters {
+		var group []string
+		for _, memory := range cluster {
+			group = append(group, memory.ID)
+		}
+		ids = append(ids, group)
+	}
+	return ids
+}
+
+func TestFindDuplicateClusters(t *testing.T) {
+	tests := []struct {
+		name string
+		opts dedupeOptions
+		want [][]string
+	}{
+		{name: "exact content", opts: dedupeOptions{By: "content"}, want: [][]string{{"a1", "a2", "a3"}}},
+		{name: "content and labels", opts: dedupeOptions{By: "both"}, want: [][]string{{"a1", "a3"}}},
+		{name: "labels", opts: dedupeOptions{By: "labels"}, want: [][]string{{"a1", "a3", "w1", "b1", "c1"}}},
+		{name: "fuzzy content", opts: dedupeOptions{By: "content", Similarity: 0.7}, want: [][]string{{"a1", "a2", "a3", "w1", "b1"}}},
+		{name: "fuzzy with labels", opts: dedupeOptions{By: "both", Similarity: 0.7}, want: [][]string{{"a1", "a3", "w1", "b1"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := clusterIDs(findDuplicateClusters(dedupeFixtures(), tt.opts))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected clusters %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestFindDuplicateClustersIgnoresDefaultLabels(t *testing.T) {
+	fs := newTestStorage(t)
+	for _, req := range []storage.CreateMemoryRequest{
+		{Name: "Groceries", Content: "milk, eggs"},
+		{Name: "Deploy", Content: "kubectl apply -k ."},
+		{Name: "Standup", Content: "notes", Labels: map[string]string{"team": "infra"}},
+		{Name: "Retro", Content: "actions", Labels: map[string]string{"team": "infra"}},
+	} {
+		if _, err := fs.Create(req); err != nil {
+			t.Fatalf("Failed to create memory: %v", err)
+		}
+	}
+	memories, err := fs.List()
+	if err != nil {
+		t.Fatalf("Failed to list memories: %v", err)
+	}
+
+	// Every memory has type=manual, but only the shared team label counts
+	clusters := findDuplicateClusters(memories, dedupeOptions{By: "labels"})
+	if len(clusters) != 1 || len(clusters[0]) != 2 || clusters[0][0].Labels["team"] != "infra" {
+		t.Errorf("Expected only the infra memories grouped, got %v", clusterIDs(clusters))
+	}
+}
+
+func TestSplitDuplicateCluster(t *testing.T) {
+	cluster := findDuplicateClusters(dedupeFixtures(), dedupeOptions{By: "content"})[0]
+
+	kept, removed := splitDuplicateCluster(cluster, "oldest")
+	if kept.ID != "a1" || len(removed) != 2 || removed[0].ID != "a2" {
+		t.Errorf("Expected to keep oldest a1, got keep=%s remove=%v", kept.ID, clusterIDs([][]storage.Memory{removed}))
+	}
+
+	kept, removed = splitDuplicateCluster(cluster, "newest")
+	if kept.ID != "a3" || len(removed) != 2 || removed[1].ID != "a2" {
+		t.Errorf("Expected to keep newest a3, got keep=%s remove=%v", kept.ID, clusterIDs([][]storage.Memory{removed}))
+	}
+}
+
+func TestDedupeApplyTrashes(t *testing.T) {
+	useTestStorageDir(t)
+	defer func() { dedupeApply, dedupePurge = false, false }()
+
+	fs, err := getStorageProvider()
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	var ids []string
+	for range 3 {
+		memory, err := fs.Create(storage.CreateMemoryRequest{Content: "same notes"})
+		if err != nil {
+			t.Fatalf("Failed to create memory: %v", err)
+		}
+		if storage.MemoryContentHash(memory) != duplicateKey(*memory, "content") {
+			t.Errorf("Expected dedupe to use the stored content hash")
+		}
+		ids = append(ids, memory.ID)
+	}
+
+	dedupeApply = true
+	output := captureStdout(t, func() error { return runDedupe(dedupeCmd, nil) })
+	if !strings.Contains(output, "Moved 2 duplicate memories from 1 cluster(s) to the trash") {
+		t.Errorf("Expected the duplicates trashed, got %q", output)
+	}
+	trashed, err := fs.(providers.TrashProvider).ListTrash()
+	if err != nil {
+		t.Fatalf("Failed to list trash: %v", err)
+	}
+	if len(trashed) != 2 {
+		t.Fatalf("Expected 2 memories in the trash, got %d", len(trashed))
+	}
+
+	// --purge deletes permanently, as delete and gc do
+	if _, err := fs.Create(storage.CreateMemoryRequest{Content: "same notes"}); err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+	dedupePurge = true
+	output = captureStdout(t, func() error { return runDedupe(dedupeCmd, nil) })
+	if !strings.Contains(output, "Deleted 1 duplicate memories") {
+		t.Errorf("Expected the duplicate purged, got %q", output)
+	}
+	if trashed, _ := fs.(providers.TrashProvider).ListTrash(); len(trashed) != 2 {
+		t.Errorf("Expected the purged duplicate kept out of the trash, got %d trashed", len(trashed))
+	}
+	if _, err := fs.Get(ids[0]); err != nil {
+		t.Errorf("Expected the oldest copy kept, got %v", err)
+	}
+}
@@ -1,9 +1,12 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/providers"
 	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -14,61 +17,141 @@ var deleteCmd = &cobra.Command{
 	Short: "Delete memories by ID or criteria",
 	Long: `Delete one or more memories by ID or using label selectors.
 
+Deleted memories are moved to the trash, where they can be restored with
+'cmctl trash restore'. Use --purge to delete permanently.
+
 Examples:
   cmctl delete memory/mem_12345678_90abcd    # Delete specific memory
   cmctl delete --labels "type=test"         # Delete all memories with type=test
-  cmctl delete --all                        # Delete all memories (use with caution)`,
+  cmctl delete --all                        # Delete all memories (use with caution)
+  cmctl delete mem_12345678_90abcd --purge   # Delete permanently, bypassing the trash
+  cmctl delete --labels "type=test" --dry-run # Show what would be deleted
+  cmctl delete -i --labels "type=chat"       # Pick the chat to delete, typing to filter
+  cmctl delete mem_12345678_90abcd --clean-links # Also remove links to it from other memories
+  cmctl delete --labels "type=test" --force -q  # Print only the number deleted
+  cmctl delete --older-than 90d --dry-run     # Show memories not updated in 90 days
+  cmctl delete --older-than 30d --labels "type=chat" # Delete stale chats
+  cmctl delete --labels "type=chat" --exclude-labels "pinned=true" # Keep pinned chats`,
 	RunE: runDelete,
 }
 
 var (
-	deleteLabels string
-	deleteAll    bool
-	deleteForce  bool
+	deleteLabels      string
+	deleteExclude     string
+	deleteAll         bool
+	deleteForce       bool
+	deletePurge       bool
+	deleteDryRun      bool
+	deleteLinks       bool
+	deleteQuiet       bool
+	deleteOlder       string
+	deleteNewer       string
+	deleteInteractive bool
 )
 
 func init() {
 	rootCmd.AddCommand(deleteCmd)
 
 	deleteCmd.Flags().StringVarP(&deleteLabels, "labels", "l", "", "Delete memories matching label selector (format: key1=value1,key2=value2)")
+	deleteCmd.Flags().StringVar(&deleteExclude, "exclude-labels", "", "Keep memories with any of these labels, applied after --labels, --all or the age bounds (format: key1=value1,key2=value2)")
 	deleteCmd.Flags().BoolVar(&deleteAll, "all", false, "Delete all memories (dangerous)")
 	deleteCmd.Flags().BoolVar(&deleteForce, "force", false, "Skip confirmation prompts")
+	deleteCmd.Flags().BoolVar(&deletePurge, "purge", false, "Delete permanently instead of moving to the trash")
+	deleteCmd.Flags().BoolVar(&deleteDryRun, "dry-run", false, "Print the memories that would be deleted without deleting them")
+	deleteCmd.Flags().BoolVar(&deleteLinks, "clean-links", false, "Remove links to the deleted memories from other memories")
+	deleteCmd.Flags().StringVar(&deleteOlder, "older-than", "", "Delete memories last updated before this (relative like 90d or 2w, or YYYY-MM-DD)")
+	deleteCmd.Flags().StringVar(&deleteNewer, "newer-than", "", "Delete memories last updated after this (relative like 1d, or YYYY-MM-DD)")
+	deleteCmd.Flags().BoolVarP(&deleteInteractive, "interactive", "i", false, "Pick the memory to delete, typing to filter the list (narrowed by --labels and --exclude-labels)")
+	deleteCmd.Flags().BoolVarP(&deleteQuiet, "quiet", "q", false, "Print only the number of memories deleted, or with --dry-run their IDs (requires --force)")
 }
 
 func runDelete(cmd *cobra.Command, args []string) error {
 	// Initialize storage
-	storageDir := viper.GetString("storage-dir")
-	fs, err := storage.NewFileStorage(storageDir)
+	fs, err := getStorageProvider()
 	if err != nil {
 		return fmt.Errorf("failed to initialize storage: %w", err)
 	}
 
 	verbosity := viper.GetInt("verbosity")
+	if deleteQuiet {
+		// Quiet output can't be mixed with a confirmation prompt
+		if !deleteForce && !deleteDryRun {
+			return fmt.Errorf("--quiet requires --force or --dry-run")
+		}
+		verbosity = 0
+	}
+
+	// Age bounds narrow --labels or --all, or on their own apply to every memory
+	var since, until time.Time
+	now := time.Now()
+	if deleteOlder != "" {
+		if until, err = parseTimeSpec(deleteOlder, now); err != nil {
+			return fmt.Errorf("invalid --older-than: %w", err)
+		}
+	}
+	if deleteNewer != "" {
+		if since, err = parseTimeSpec(deleteNewer, now); err != nil {
+			return fmt.Errorf("invalid --newer-than: %w", err)
+		}
+	}
+	byAge := !since.IsZero() || !until.IsZero()
+	if byAge && len(args) == 1 {
+		return fmt.Errorf("--older-than and --newer-than can't be used with a memory ID")
+	}
+	exclude, err := parseExcludeLabels(deleteExclude)
+	if err != nil {
+		return err
+	}
+	if exclude != nil && len(args) == 1 {
+		return fmt.Errorf("--exclude-labels can't be used with a memory ID")
+	}
+
+	if deleteInteractive && (len(args) == 1 || deleteAll || byAge) {
+		return fmt.Errorf("--interactive picks the memory to delete, so it can't be used with a memory ID, --all, --older-than or --newer-than")
+	}
 
 	// Handle different delete modes
-	if len(args) == 1 {
+	var deleted int
+	if deleteInteractive {
+		deleted, err = deletePickedMemory(fs, deleteLabels, exclude, verbosity)
+	} else if byAge {
+		deleted, err = deleteMemoriesByAge(fs, deleteLabels, exclude, since, until, verbosity)
+	} else if len(args) == 1 {
 		// Delete specific memory by ID
 		memoryID := args[0]
-		return deleteMemoryByID(fs, memoryID, verbosity)
+		deleted, err = deleteMemoryByID(fs, memoryID, verbosity)
 	} else if deleteAll {
 		// Delete all memories
-		return deleteAllMemories(fs, verbosity)
+		deleted, err = deleteAllMemories(fs, exclude, verbosity)
 	} else if deleteLabels != "" {
 		// Delete by label selector
-		return deleteMemoriesByLabels(fs, deleteLabels, verbosity)
+		deleted, err = deleteMemoriesByLabels(fs, deleteLabels, exclude, verbosity)
 	} else {
-		return fmt.Errorf("must specify memory ID, --labels, or --all")
+		return fmt.Errorf("must specify memory ID, --labels, --older-than, --newer-than, or --all")
 	}
+	if err != nil {
+		return err
+	}
+
+	if deleteQuiet && !deleteDryRun {
+		fmt.Println(deleted)
+	}
+	return nil
 }
 
-func deleteMemoryByID(fs *storage.FileStorage, memoryID string, verbosity int) error {
+func deleteMemoryByID(fs providers.StorageProvider, memoryID string, verbosity int) (int, error) {
 	// Check if memory exists
 	memory, err := fs.Get(memoryID)
+	if errors.Is(err, storage.ErrMemoryNotFound) {
+		return 0, err
+	}
 	if err != nil {
-		return fmt.Errorf("failed to get memory: %w", err)
+		return 0, fmt.Errorf("failed to get memory: %w", err)
 	}
-	if memory == nil {
-		return fmt.Errorf("memory not found: %s", memoryID)
+
+	if deleteDryRun {
+		printDryRun([]storage.Memory{*memory})
+		return 0, nil
 	}
 
 	// Confirmation prompt (unless forced)
@@ -79,93 +162,125 @@ func deleteMemoryByID(fs *storage.FileStorage, memoryID string, verbosity int) e
 			_, _ = fmt.Scanln(&response) // Ignore error - treat as 'no' if input fails
 			if strings.ToLower(response) != "y" && strings.ToLower(response) != "yes" {
 				fmt.Println("Delete cancelled")
-				return nil
+				return 0, nil
 			}
 		}
 	}
 
 	// Delete the memory
-	if err := fs.Delete(memoryID); err != nil {
-		return fmt.Errorf("failed to delete memory: %w", err)
+	if err := removeMemory(fs, memoryID, deletePurge); err != nil {
+		return 0, fmt.Errorf("failed to delete memory: %w", err)
 	}
 
 	if verbosity >= 1 {
-		fmt.Printf("Memory '%s' deleted successfully\n", memory.Name)
+		if deletePurge {
+			fmt.Printf("Memory '%s' deleted successfully\n", memory.Name)
+		} else {
+			fmt.Printf("Memory '%s' moved to trash (restore with: cmctl trash restore %s)\n", memory.Name, memory.ID)
+		}
 	}
-	return nil
+	return 1, cleanDeletedLinks(fs, []string{memoryID}, verbosity)
 }
 
-func deleteAllMemories(fs *storage.FileStorage, verbosity int) error {
+// deletePickedMemory deletes the memory picked from those matching
+// labelSelector, or from all memories when it's empty, less those with any
+// exclude label
+func deletePickedMemory(fs providers.StorageProvider, labelSelector string, exclude map[string]string, verbosity int) (int, error) {
+	labels := parseLabels(labelSelector)
+	if labelSelector != "" && len(labels) == 0 {
+		return 0, fmt.Errorf("invalid label selector format: %s", labelSelector)
+	}
+
+	memories, err := listMetadata(fs)
+	if err != nil {
+		return 0, err
+	}
+	memories = storage.FilterMemories(memories, storage.SearchRequest{LabelSelector: labels, ExcludeLabels: exclude})
+	if len(memories) == 0 {
+		if verbosity >= 1 {
+			fmt.Println("No memories to delete")
+		}
+		return 0, nil
+	}
+
+	picked, err := selectMemory(memoriesByNewest(memories), "memory to delete")
+	if err != nil || picked == nil {
+		return 0, err
+	}
+	return deleteMemoryByID(fs, picked.ID, verbosity)
+}
+
+func deleteAllMemories(fs providers.StorageProvider, exclude map[string]string, verbosity int) (int, error) {
 	// Get all memories
 	memories, err := fs.List()
 	if err != nil {
-		return fmt.Errorf("failed to list memories: %w", err)
+		return 0, fmt.Errorf("failed to list memories: %w", err)
+	}
+	if exclude != nil {
+		memories = storage.FilterMemories(memories, storage.SearchRequest{ExcludeLabels: exclude})
 	}
 
 	if len(memories) == 0 {
 		if verbosity >= 1 {
 			fmt.Println("No memories to delete")
 		}
-		return nil
+		return 0, nil
+	}
+
+	if deleteDryRun {
+		printDryRun(memories)
+		return 0, nil
 	}
 
 	// Confirmation prompt (unless forced)
 	if !deleteForce {
 		if verbosity >= 1 {
-			fmt.Printf("Are you sure you want to delete ALL %d memories? This cannot be undone! (y/N): ", len(memories))
+			warning := "They can be restored from the trash."
+			if deletePurge {
+				warning = "This cannot be undone!"
+			}
+			fmt.Printf("Are you sure you want to delete ALL %d memories? %s (y/N): ", len(memories), warning)
 			var response string
 			_, _ = fmt.Scanln(&response) // Ignore error - treat as 'no' if input fails
 			if strings.ToLower(response) != "y" && strings.ToLower(response) != "yes" {
 				fmt.Println("Delete cancelled")
-				return nil
+				return 0, nil
 			}
 		}
 	}
 
 	// Delete all memories
-	deletedCount := 0
-	for _, memory := range memories {
-		if err := fs.Delete(memory.ID); err != nil {
-			if verbosity >= 1 {
-				fmt.Printf("Failed to delete memory '%s': %v\n", memory.Name, err)
-			}
-		} else {
-			deletedCount++
-			if verbosity >= 2 {
-				fmt.Printf("Deleted: %s\n", memory.Name)
-			}
-		}
-	}
-
-	if verbosity >= 1 {
-		fmt.Printf("Successfully deleted %d/%d memories\n", deletedCount, len(memories))
-	}
-	return nil
+	return removeMemories(fs, memories, verbosity)
 }
 
-func deleteMemoriesByLabels(fs *storage.FileStorage, labelSelector string, verbosity int) error {
+func deleteMemoriesByLabels(fs providers.StorageProvider, labelSelector string, exclude map[string]string, verbosity int) (int, error) {
 	// Parse label selector
 	labels := parseLabels(labelSelector)
 	if len(labels) == 0 {
-		return fmt.Errorf("invalid label selector format: %s", labelSelector)
+		return 0, fmt.Errorf("invalid label selector format: %s", labelSelector)
 	}
 
 	// Search for matching memories
 	searchReq := storage.SearchRequest{
 		LabelSelector: labels,
-		Limit:         1000, // Large limit to get all matches
+		ExcludeLabels: exclude,
 	}
 
 	searchResp, err := fs.Search(searchReq)
 	if err != nil {
-		return fmt.Errorf("failed to search memories: %w", err)
+		return 0, fmt.Errorf("failed to search memories: %w", err)
 	}
 
 	if len(searchResp.Memories) == 0 {
 		if verbosity >= 1 {
 			fmt.Println("No memories found matching the label selector")
 		}
-		return nil
+		return 0, nil
+	}
+
+	if deleteDryRun {
+		printDryRun(searchResp.Memories)
+		return 0, nil
 	}
 
 	// Confirmation prompt (unless forced)
@@ -180,20 +295,85 @@ func deleteMemoriesByLabels(fs *storage.FileStorage, labelSelector string, verbo
 			_, _ = fmt.Scanln(&response) // Ignore error - treat as 'no' if input fails
 			if strings.ToLower(response) != "y" && strings.ToLower(response) != "yes" {
 				fmt.Println("Delete cancelled")
-				return nil
+				return 0, nil
 			}
 		}
 	}
 
 	// Delete matching memories
+	return removeMemories(fs, searchResp.Memories, verbosity)
+}
+
+// deleteMemoriesByAge deletes the memories last updated within [since,
+// until], limited to those matching labelSelector when it is set and less
+// those with any exclude label
+func deleteMemoriesByAge(fs providers.StorageProvider, labelSelector string, exclude map[string]string, since, until time.Time, verbosity int) (int, error) {
+	labels := parseLabels(labelSelector)
+	if labelSelector != "" && len(labels) == 0 {
+		return 0, fmt.Errorf("invalid label selector format: %s", labelSelector)
+	}
+
+	memories, err := listMetadata(fs)
+	if err != nil {
+		return 0, err
+	}
+	memories = selectMemoriesByAge(memories, labels, exclude, since, until)
+
+	if len(memories) == 0 {
+		if verbosity >= 1 {
+			fmt.Println("No memories found in the given age range")
+		}
+		return 0, nil
+	}
+
+	if deleteDryRun {
+		printDryRun(memories)
+		return 0, nil
+	}
+
+	// Confirmation prompt (unless forced)
+	if !deleteForce && verbosity >= 1 {
+		fmt.Printf("Found %d memories in the given age range\n", len(memories))
+		for _, memory := range memories {
+			fmt.Printf("  - %s (updated %s)\n", memory.Name, memory.UpdatedAt.Format("2006-01-02"))
+		}
+		fmt.Print("Are you sure you want to delete these memories? (y/N): ")
+		var response string
+		_, _ = fmt.Scanln(&response) // Ignore error - treat as 'no' if input fails
+		if strings.ToLower(response) != "y" && strings.ToLower(response) != "yes" {
+			fmt.Println("Delete cancelled")
+			return 0, nil
+		}
+	}
+
+	return removeMemories(fs, memories, verbosity)
+}
+
+// selectMemoriesByAge returns the memories matching labels, and none of
+// exclude, whose last update falls within [since, until]
+func selectMemoriesByAge(memories []storage.Memory, labels, exclude map[string]string, since, until time.Time) []storage.Memory {
+	var selected []storage.Memory
+	for _, memory := range storage.FilterMemories(memories, storage.SearchRequest{LabelSelector: labels, ExcludeLabels: exclude}) {
+		if chatInTimeRange(memory.UpdatedAt.UnixMilli(), since, until) {
+			selected = append(selected, memory)
+		}
+	}
+	return selected
+}
+
+// removeMemories deletes each memory, reporting any that fail, and returns
+// the number deleted
+func removeMemories(fs providers.StorageProvider, memories []storage.Memory, verbosity int) (int, error) {
 	deletedCount := 0
-	for _, memory := range searchResp.Memories {
-		if err := fs.Delete(memory.ID); err != nil {
+	var deletedIDs []string
+	for _, memory := range memories {
+		if err := removeMemory(fs, memory.ID, deletePurge); err != nil {
 			if verbosity >= 1 {
 				fmt.Printf("Failed to delete memory '%s': %v\n", memory.Name, err)
 			}
 		} else {
 			deletedCount++
+			deletedIDs = append(deletedIDs, memory.ID)
 			if verbosity >= 2 {
 				fmt.Printf("Deleted: %s\n", memory.Name)
 			}
@@ -201,7 +381,51 @@ func deleteMemoriesByLabels(fs *storage.FileStorage, labelSelector string, verbo
 	}
 
 	if verbosity >= 1 {
-		fmt.Printf("Successfully deleted %d/%d memories\n", deletedCount, len(searchResp.Memories))
+		fmt.Printf("Successfully deleted %d/%d memories\n", deletedCount, len(memories))
+	}
+	return deletedCount, cleanDeletedLinks(fs, deletedIDs, verbosity)
+}
+
+// removeMemory moves a memory to the trash, or deletes it permanently when
+// purge is set
+func removeMemory(fs providers.StorageProvider, id string, purge bool) error {
+	if purge {
+		return fs.Delete(id)
+	}
+	trash, err := trashProvider(fs)
+	if err != nil {
+		return fmt.Errorf("%w (use --purge to delete permanently)", err)
+	}
+	return trash.Trash(id)
+}
+
+// cleanDeletedLinks removes links to the deleted memories when --clean-links
+// is set
+func cleanDeletedLinks(fs providers.StorageProvider, ids []string, verbosity int) error {
+	if !deleteLinks || len(ids) == 0 {
+		return nil
+	}
+	removed, err := removeRelationsTo(fs, ids)
+	if err != nil {
+		return err
+	}
+	if verbosity >= 1 && removed > 0 {
+		fmt.Printf("Removed %d dangling link(s)\n", removed)
 	}
 	return nil
 }
+
+// printDryRun lists the memories a delete would remove, or with --quiet
+// just their IDs
+func printDryRun(memories []storage.Memory) {
+	if deleteQuiet {
+		for _, memory := range memories {
+			fmt.Println(memory.ID)
+		}
+		return
+	}
+	fmt.Printf("Would delete %d memories (dry run):\n", len(memories))
+	for _, memory := range memories {
+		fmt.Printf("  %s  %s\n", memory.ID, memory.Name)
+	}
+}
@@ -0,0 +1,223 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/providers"
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+)
+
+func TestRemoveMemory(t *testing.T) {
+	fs := newTestStorage(t)
+
+	trashed, err := fs.Create(storage.CreateMemoryRequest{Name: "Trashed", Content: "soft"})
+	if err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+	purged, err := fs.Create(storage.CreateMemoryRequest{Name: "Purged", Content: "hard"})
+	if err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+
+	if err := removeMemory(fs, trashed.ID, false); err != nil {
+		t.Fatalf("Soft delete failed: %v", err)
+	}
+	if err := removeMemory(fs, purged.ID, true); err != nil {
+		t.Fatalf("Purge failed: %v", err)
+	}
+
+	memories, err := fs.List()
+	if err != nil {
+		t.Fatalf("Failed to list memories: %v", err)
+	}
+	if len(memories) != 0 {
+		t.Errorf("Expected no memories left, got %d", len(memories))
+	}
+
+	inTrash, err := fs.ListTrash()
+	if err != nil {
+		t.Fatalf("Failed to list trash: %v", err)
+	}
+	if len(inTrash) != 1 || inTrash[0].ID != trashed.ID {
+		t.Errorf("Expected only the soft-deleted memory in trash, got %+v", inTrash)
+	}
+}
+
+func TestDeleteDryRun(t *testing.T) {
+	deleteDryRun = true
+	defer func() { deleteDryRun = false }()
+
+	tests := []struct {
+		name string
+		run  func(fs providers.StorageProvider, id string) (int, error)
+	}{
+		{name: "by id", run: func(fs providers.StorageProvider, id string) (int, error) { return deleteMemoryByID(fs, id, 1) }},
+		{name: "by labels", run: func(fs providers.StorageProvider, id string) (int, error) {
+			return deleteMemoriesByLabels(fs, "type=test", nil, 1)
+		}},
+		{name: "all", run: func(fs providers.StorageProvider, id string) (int, error) { return deleteAllMemories(fs, nil, 1) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := newTestStorage(t)
+			memory, err := fs.Create(storage.CreateMemoryRequest{
+				Name:    "Test memory",
+				Content: "content",
+				Labels:  map[string]string{"type": "test"},
+			})
+			if err != nil {
+				t.Fatalf("Failed to create memory: %v", err)
+			}
+
+			if _, err := tt.run(fs, memory.ID); err != nil {
+				t.Fatalf("Dry run failed: %v", err)
+			}
+
+			if _, err := fs.Get(memory.ID); err != nil {
+				t.Errorf("Expected memory to survive dry run: %v", err)
+			}
+			trashed, err := fs.ListTrash()
+			if err != nil {
+				t.Fatalf("Failed to list trash: %v", err)
+			}
+			if len(trashed) != 0 {
+				t.Errorf("Expected nothing trashed under dry run, got %d", len(trashed))
+			}
+		})
+	}
+}
+
+func TestSelectMemoriesByAge(t *testing.T) {
+	now := time.Date(2025, 9, 20, 12, 0, 0, 0, time.UTC)
+	memory := func(name, typ string, age time.Duration) storage.Memory {
+		return storage.Memory{ID: name, Name: name, Labels: map[string]string{"type": typ}, UpdatedAt: now.Add(-age)}
+	}
+	day := 24 * time.Hour
+	memories := []storage.Memory{
+		memory("old-chat", "chat", 120*day),
+		memory("old-note", "note", 100*day),
+		memory("recent-chat", "chat", 10*day),
+		memory("new-chat", "chat", 2*time.Hour),
+	}
+
+	tests := []struct {
+		name     string
+		labels   map[string]string
+		older    string
+		newer    string
+		expected []string
+	}{
+		{name: "older than", older: "90d", expected: []string{"old-chat", "old-note"}},
+		{name: "older than with labels", older: "90d", labels: map[string]string{"type": "chat"}, expected: []string{"old-chat"}},
+		{name: "newer than", newer: "2w", labels: map[string]string{"type": "chat"}, expected: []string{"recent-chat", "new-chat"}},
+		{name: "range", older: "1d", newer: "2w", expected: []string{"recent-chat"}},
+		{name: "date", older: "2025-06-01", expected: []string{"old-chat"}},
+		{name: "no match", older: "1w", labels: map[string]string{"type": "todo"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var since, until time.Time
+			var err error
+			if tt.older != "" {
+				if until, err = parseTimeSpec(tt.older, now); err != nil {
+					t.Fatalf("Failed to parse %q: %v", tt.older, err)
+				}
+			}
+			if tt.newer != "" {
+				if since, err = parseTimeSpec(tt.newer, now); err != nil {
+					t.Fatalf("Failed to parse %q: %v", tt.newer, err)
+				}
+			}
+
+			var got []string
+			for _, m := range selectMemoriesByAge(memories, tt.labels, nil, since, until) {
+				got = append(got, m.ID)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestDeleteMemoriesByAge(t *testing.T) {
+	defer func() { deleteForce = false }()
+	deleteForce = true
+
+	fs := newTestStorage(t)
+	chat, err := fs.Create(storage.CreateMemoryRequest{Name: "Chat", Content: "chat", Labels: map[string]string{"type": "chat"}})
+	if err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+	note, err := fs.Create(storage.CreateMemoryRequest{Name: "Note", Content: "note", Labels: map[string]string{"type": "note"}})
+	if err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+
+	// Everything was updated before a cutoff in the future
+	deleted, err := deleteMemoriesByAge(fs, "type=chat", nil, time.Time{}, time.Now().Add(time.Hour), 0)
+	if err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if deleted != 1 {
+		t.Errorf("Expected 1 memory deleted, got %d", deleted)
+	}
+	if _, err := fs.Get(chat.ID); err == nil {
+		t.Error("Expected the chat to be deleted")
+	}
+	if _, err := fs.Get(note.ID); err != nil {
+		t.Errorf("Expected the note to be kept: %v", err)
+	}
+}
+
+func TestDeleteExcludeLabels(t *testing.T) {
+	defer func() { deleteForce = false }()
+	deleteForce = true
+
+	tests := []struct {
+		name string
+		run  func(fs providers.StorageProvider, exclude map[string]string) (int, error)
+	}{
+		{name: "labels", run: func(fs providers.StorageProvider, exclude map[string]string) (int, error) {
+			return deleteMemoriesByLabels(fs, "type=chat", exclude, 0)
+		}},
+		{name: "all", run: func(fs providers.StorageProvider, exclude map[string]string) (int, error) {
+			return deleteAllMemories(fs, exclude, 0)
+		}},
+		{name: "age", run: func(fs providers.StorageProvider, exclude map[string]string) (int, error) {
+			return deleteMemoriesByAge(fs, "type=chat", exclude, time.Time{}, time.Now().Add(time.Hour), 0)
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := newTestStorage(t)
+			chat, err := fs.Create(storage.CreateMemoryRequest{Name: "Chat", Content: "chat", Labels: map[string]string{"type": "chat"}})
+			if err != nil {
+				t.Fatalf("Failed to create memory: %v", err)
+			}
+			pinned, err := fs.Create(storage.CreateMemoryRequest{Name: "Pinned", Content: "pinned", Labels: map[string]string{"type": "chat", storage.PinnedLabel: "true"}})
+			if err != nil {
+				t.Fatalf("Failed to create memory: %v", err)
+			}
+
+			deleted, err := tt.run(fs, map[string]string{storage.PinnedLabel: "true"})
+			if err != nil {
+				t.Fatalf("Delete failed: %v", err)
+			}
+			if deleted != 1 {
+				t.Errorf("Expected 1 memory deleted, got %d", deleted)
+			}
+			if _, err := fs.Get(chat.ID); err == nil {
+				t.Error("Expected the chat to be deleted")
+			}
+			if _, err := fs.Get(pinned.ID); err != nil {
+				t.Errorf("Expected the excluded chat to be kept: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,259 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/spf13/cobra"
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <memory-id> <memory-id>",
+	Short: "Show differences between two memories",
+	Long: `Compare two memories, showing a unified diff of their content followed by
+the differences in name and labels.
+
+This is useful for reviewing a chat that was imported twice before deciding
+whether to keep both copies.
+
+Examples:
+  cmctl diff mem_abc123_def456 mem_abc789_ghi012                 # Content and label diff
+  cmctl diff mem_abc123_def456 mem_abc789_ghi012 --content-only  # Content diff only
+  cmctl diff mem_abc123_def456 mem_abc789_ghi012 -o json         # Structured diff`,
+	Args: cobra.ExactArgs(2),
+	RunE: runDiff,
+}
+
+var (
+	diffOutputFlag  string
+	diffContentOnly bool
+)
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+
+	diffCmd.Flags().StringVarP(&diffOutputFlag, "output", "o", "", "Output format: table|json|jsonl|yaml|jsonpath=<template>|go-template=<template>|template=<name>")
+	diffCmd.Flags().BoolVar(&diffContentOnly, "content-only", false, "Only compare content, ignoring name and labels")
+}
+
+// MemoryDiff describes the differences between two memories
+type MemoryDiff struct {
+	From           string       `json:"from" yaml:"from"`
+	To             string       `json:"to" yaml:"to"`
+	Identical      bool         `json:"identical" yaml:"identical"`
+	Name           *ValueChange `json:"name,omitempty" yaml:"name,omitempty"`
+	Labels         *LabelDiff   `json:"labels,omitempty" yaml:"labels,omitempty"`
+	ContentChanged bool         `json:"contentChanged" yaml:"contentChanged"`
+	ContentDiff    string       `json:"contentDiff,omitempty" yaml:"contentDiff,omitempty"`
+}
+
+// ValueChange records a value that differs between two memories
+type ValueChange struct {
+	From string `json:"from" yaml:"from"`
+	To   string `json:"to" yaml:"to"`
+}
+
+// LabelDiff records labels added, removed, or changed between two memories
+type LabelDiff struct {
+	Added   map[string]string      `json:"added,omitempty" yaml:"added,omitempty"`
+	Removed map[string]string      `json:"removed,omitempty" yaml:"removed,omitempty"`
+	Changed map[string]ValueChange `json:"changed,omitempty" yaml:"changed,omitempty"`
+}
+
+// empty reports whether no labels differ
+func (d *LabelDiff) empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	// Initialize storage
+	fs, err := getStorageProvider()
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+
+	outputOpts, err := ParseOutputFormat(diffOutputFlag)
+	if err != nil {
+		return fmt.Errorf("invalid output format: %w", err)
+	}
+
+	from, err := fs.Get(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to get memory: %w", err)
+	}
+	to, err := fs.Get(args[1])
+	if err != nil {
+		return fmt.Errorf("failed to get memory: %w", err)
+	}
+
+	diff, err := diffMemories(from, to, diffContentOnly)
+	if err != nil {
+		return err
+	}
+
+	if outputOpts.Format != OutputFormatTable {
+		output, err := FormatOutput(diff, outputOpts)
+		if err != nil {
+			return err
+		}
+		fmt.Println(output)
+		return nil
+	}
+
+	fmt.Print(formatMemoryDiff(diff, shouldColorize(os.Stdout)))
+	return nil
+}
+
+// diffMemories compares two memories. With contentOnly, name and label
+// differences are ignored.
+func diffMemories(from, to *storage.Memory, contentOnly bool) (*MemoryDiff, error) {
+	diff := &MemoryDiff{
+		From:           from.ID,
+		To:             to.ID,
+		ContentChanged: from.Content != to.Content,
+	}
+
+	if diff.ContentChanged {
+		unified, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        difflib.SplitLines(ensureTrailingNewline(from.Content)),
+			B:        difflib.SplitLines(ensureTrailingNewline(to.Content)),
+			FromFile: from.ID,
+			ToFile:   to.ID,
+			Context:  3,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to diff content: %w", err)
+		}
+		diff.ContentDiff = unified
+	}
+
+	if !contentOnly {
+		if from.Name != to.Name {
+			diff.Name = &ValueChange{From: from.Name, To: to.Name}
+		}
+		if labels := diffLabels(from.Labels, to.Labels); !labels.empty() {
+			diff.Labels = labels
+		}
+	}
+
+	diff.Identical = !diff.ContentChanged && diff.Name == nil && diff.Labels == nil
+	return diff, nil
+}
+
+// ensureTrailingNewline keeps a missing final newline from showing up as a
+// change on the last line
+func ensureTrailingNewline(s string) string {
+	if s == "" || strings.HasSuffix(s, "\n") {
+		return s
+	}
+	return s + "\n"
+}
+
+// diffLabels compares two label sets
+func diffLabels(from, to map[string]string) *LabelDiff {
+	diff := &LabelDiff{}
+	for key, value := range from {
+		newValue, ok := to[key]
+		switch {
+		case !ok:
+			if diff.Removed == nil {
+				diff.Removed = make(map[string]string)
+			}
+			diff.Removed[key] = value
+		case newValue != value:
+			if diff.Changed == nil {
+				diff.Changed = make(map[string]ValueChange)
+			}
+			diff.Changed[key] = ValueChange{From: value, To: newValue}
+		}
+	}
+	for key, value := range to {
+		if _, ok := from[key]; !ok {
+			if diff.Added == nil {
+				diff.Added = make(map[string]string)
+			}
+			diff.Added[key] = value
+		}
+	}
+	return diff
+}
+
+// formatMemoryDiff renders a diff for the terminal
+func formatMemoryDiff(diff *MemoryDiff, color bool) string {
+	if diff.Identical {
+		return fmt.Sprintf("Memories %s and %s are identical\n", diff.From, diff.To)
+	}
+
+	var output strings.Builder
+
+	if diff.ContentChanged {
+		for _, line := range strings.SplitAfter(diff.ContentDiff, "\n") {
+			output.WriteString(colorizeDiffLine(line, color))
+		}
+	}
+
+	if diff.Name != nil {
+		if output.Len() > 0 {
+			output.WriteString("\n")
+		}
+		output.WriteString("Name:\n")
+		output.WriteString(colorize(fmt.Sprintf("  - %s", diff.Name.From), ansiRed, color) + "\n")
+		output.WriteString(colorize(fmt.Sprintf("  + %s", diff.Name.To), ansiGreen, color) + "\n")
+	}
+
+	if diff.Labels != nil {
+		if output.Len() > 0 {
+			output.WriteString("\n")
+		}
+		output.WriteString("Labels:\n")
+		for _, key := range sortedKeys(diff.Labels.Removed) {
+			output.WriteString(colorize(fmt.Sprintf("  - %s=%s", key, diff.Labels.Removed[key]), ansiRed, color) + "\n")
+		}
+		for _, key := range sortedKeys(diff.Labels.Added) {
+			output.WriteString(colorize(fmt.Sprintf("  + %s=%s", key, diff.Labels.Added[key]), ansiGreen, color) + "\n")
+		}
+		for _, key := range sortedKeys(diff.Labels.Changed) {
+			change := diff.Labels.Changed[key]
+			output.WriteString(colorize(fmt.Sprintf("  - %s=%s", key, change.From), ansiRed, color) + "\n")
+			output.WriteString(colorize(fmt.Sprintf("  + %s=%s", key, change.To), ansiGreen, color) + "\n")
+		}
+	}
+
+	return output.String()
+}
+
+// colorizeDiffLine colors a unified diff line by its prefix
+func colorizeDiffLine(line string, color bool) string {
+	if !color || line == "" {
+		return line
+	}
+
+	text := strings.TrimSuffix(line, "\n")
+	newline := line[len(text):]
+	switch {
+	case strings.HasPrefix(text, "+++"), strings.HasPrefix(text, "---"):
+		return colorize(text, ansiBold, true) + newline
+	case strings.HasPrefix(text, "@@"):
+		return colorize(text, ansiCyan, true) + newline
+	case strings.HasPrefix(text, "+"):
+		return colorize(text, ansiGreen, true) + newline
+	case strings.HasPrefix(text, "-"):
+		return colorize(text, ansiRed, true) + newline
+	default:
+		return line
+	}
+}
+
+// sortedKeys returns the keys of m in sorted order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
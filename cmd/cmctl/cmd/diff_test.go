@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+)
+
+func diffFixtures(t *testing.T) (*storage.Memory, *storage.Memory) {
+	t.Helper()
+	fs := newTestStorage(t)
+
+	from, err := fs.Create(storage.CreateMemoryRequest{
+		Name:    "Original",
+		Content: "line one\nline two\nline three\n",
+		Labels:  map[string]string{"type": "chat", "language": "go", "stale": "yes"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+	to, err := fs.Create(storage.CreateMemoryRequest{
+		Name:    "Re-import",
+		Content: "line one\nline 2\nline three\nline four\n",
+		Labels:  map[string]string{"type": "chat", "language": "rust", "date": "2025-09-20"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+	return from, to
+}
+
+func TestDiffMemories(t *testing.T) {
+	from, to := diffFixtures(t)
+
+	diff, err := diffMemories(from, to, false)
+	if err != nil {
+		t.Fatalf("diffMemories failed: %v", err)
+	}
+
+	if diff.Identical || !diff.ContentChanged {
+		t.Fatalf("Expected a content change, got %+v", diff)
+	}
+	for _, want := range []string{"-line two\n", "+line 2\n", "+line four\n", " line one\n"} {
+		if !strings.Contains(diff.ContentDiff, want) {
+			t.Errorf("Expected content diff to contain %q, got:\n%s", want, diff.ContentDiff)
+		}
+	}
+
+	if diff.Name == nil || diff.Name.From != "Original" || diff.Name.To != "Re-import" {
+		t.Errorf("Unexpected name change: %+v", diff.Name)
+	}
+	if diff.Labels == nil {
+		t.Fatal("Expected label differences")
+	}
+	if diff.Labels.Added["date"] != "2025-09-20" {
+		t.Errorf("Expected date label added, got %v", diff.Labels.Added)
+	}
+	if diff.Labels.Removed["stale"] != "yes" {
+		t.Errorf("Expected stale label removed, got %v", diff.Labels.Removed)
+	}
+	if change := diff.Labels.Changed["language"]; change.From != "go" || change.To != "rust" {
+		t.Errorf("Expected language label change, got %v", diff.Labels.Changed)
+	}
+	if _, ok := diff.Labels.Changed["type"]; ok {
+		t.Error("Unchanged labels should not be reported")
+	}
+
+	// The structured form is valid JSON
+	output, err := FormatOutput(diff, OutputOptions{Format: OutputFormatJSON})
+	if err != nil {
+		t.Fatalf("FormatOutput failed: %v", err)
+	}
+	var decoded MemoryDiff
+	if err := json.Unmarshal([]byte(output), &decoded); err != nil {
+		t.Fatalf("Failed to parse JSON diff: %v", err)
+	}
+	if decoded.Labels.Changed["language"].To != "rust" {
+		t.Errorf("Unexpected decoded diff: %+v", decoded)
+	}
+}
+
+func TestDiffMemoriesContentOnly(t *testing.T) {
+	from, to := diffFixtures(t)
+
+	diff, err := diffMemories(from, to, true)
+	if err != nil {
+		t.Fatalf("diffMemories failed: %v", err)
+	}
+	if diff.Name != nil || diff.Labels != nil {
+		t.Errorf("Expected name and labels to be ignored, got %+v", diff)
+	}
+
+	// Memories that differ only in labels are identical by content
+	same := *from
+	same.Labels = map[string]string{"other": "label"}
+	diff, err = diffMemories(from, &same, true)
+	if err != nil {
+		t.Fatalf("diffMemories failed: %v", err)
+	}
+	if !diff.Identical {
+		t.Errorf("Expected identical content, got %+v", diff)
+	}
+}
+
+func TestFormatMemoryDiffColor(t *testing.T) {
+	from, to := diffFixtures(t)
+	diff, err := diffMemories(from, to, false)
+	if err != nil {
+		t.Fatalf("diffMemories failed: %v", err)
+	}
+
+	plain := formatMemoryDiff(diff, false)
+	colored := formatMemoryDiff(diff, true)
+
+	if strings.Contains(plain, "\033[") {
+		t.Errorf("Expected no escape codes without color, got %q", plain)
+	}
+	if !strings.Contains(colored, ansiGreen+"+line 2") {
+		t.Errorf("Expected added lines in green, got %q", colored)
+	}
+	if stripANSI(colored) != plain {
+		t.Errorf("Colored diff should match plain diff once escape codes are removed")
+	}
+	if !strings.Contains(plain, "  - language=go\n  + language=rust\n") {
+		t.Errorf("Expected label change in plain output, got:\n%s", plain)
+	}
+}
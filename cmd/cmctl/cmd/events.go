@@ -0,0 +1,245 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// Config keys that turn on the event log and bound its size
+const (
+	eventLogKey         = "event-log"
+	eventLogMaxBytesKey = "event-log-max-bytes"
+)
+
+// eventOperations are the operations --operation accepts
+var eventOperations = []string{
+	storage.EventCreate, storage.EventImport, storage.EventUpdate, storage.EventTouch,
+	storage.EventTrash, storage.EventRestore, storage.EventDelete, storage.EventEmptyTrash,
+}
+
+// eventsFollowInterval is how often --follow checks for new events
+const eventsFollowInterval = time.Second
+
+var eventsCmd = &cobra.Command{
+	Use:   "events",
+	Short: "Show the log of changes to memories",
+	Long: `Show the event log: one entry for every memory created, imported,
+updated, touched, trashed, restored or deleted, oldest first. Unlike a
+memory's version history, the log keeps a record of deletes.
+
+The log is off by default. Turn it on with 'cmctl config set event-log true';
+it is written to events.log in the storage directory and rotated once it
+reaches event-log-max-bytes, keeping the 3 previous logs.
+
+Examples:
+  cmctl events                               # Every logged event
+  cmctl events --tail 20                     # The last 20 events
+  cmctl events --operation delete,trash      # What was removed
+  cmctl events --id mem_abc123_def456        # The history of one memory
+  cmctl events --since 1d -o json            # Today's events, one JSON object per line
+  cmctl events --follow                      # Print events as they happen`,
+	Args: cobra.NoArgs,
+	RunE: runEvents,
+}
+
+var (
+	eventsOperations []string
+	eventsID         string
+	eventsSince      string
+	eventsTail       int
+	eventsFollow     bool
+	eventsOutput     string
+)
+
+func init() {
+	rootCmd.AddCommand(eventsCmd)
+
+	eventsCmd.Flags().StringSliceVar(&eventsOperations, "operation", nil, "Only show these operations (create, import, update, touch, trash, restore, delete, empty-trash)")
+	eventsCmd.Flags().StringVar(&eventsID, "id", "", "Only show events for this memory")
+	eventsCmd.Flags().StringVar(&eventsSince, "since", "", "Only show events since a time (YYYY-MM-DD, RFC3339, or a duration like 3d)")
+	eventsCmd.Flags().IntVar(&eventsTail, "tail", 0, "Only show the last N matching events (0 for all)")
+	eventsCmd.Flags().BoolVarP(&eventsFollow, "follow", "f", false, "Keep printing new events until interrupted")
+	eventsCmd.Flags().StringVarP(&eventsOutput, "output", "o", "table", "Output format: table|json (one event per line)")
+}
+
+// eventFilter selects events to show
+type eventFilter struct {
+	operations map[string]bool
+	id         string
+	since      time.Time
+}
+
+func (f eventFilter) matches(event storage.Event) bool {
+	if len(f.operations) > 0 && !f.operations[event.Operation] {
+		return false
+	}
+	if f.id != "" && event.ID != f.id {
+		return false
+	}
+	return f.since.IsZero() || !event.Time.Before(f.since)
+}
+
+// filterEvents returns the events that match filter, keeping only the last
+// tail of them when tail is positive
+func filterEvents(events []storage.Event, filter eventFilter, tail int) []storage.Event {
+	var matched []storage.Event
+	for _, event := range events {
+		if filter.matches(event) {
+			matched = append(matched, event)
+		}
+	}
+	if tail > 0 && len(matched) > tail {
+		matched = matched[len(matched)-tail:]
+	}
+	return matched
+}
+
+// eventsAfter returns the events logged after last. The log may have been
+// rotated since last was read, so when last is no longer in it, events are
+// compared by time instead.
+func eventsAfter(events []storage.Event, last storage.Event) []storage.Event {
+	for i := len(events) - 1; i >= 0; i-- {
+		if events[i] == last {
+			return events[i+1:]
+		}
+	}
+	for i, event := range events {
+		if event.Time.After(last.Time) {
+			return events[i:]
+		}
+	}
+	return nil
+}
+
+// eventLogMaxBytes returns the size at which the event log is rotated
+func eventLogMaxBytes() int64 {
+	if !viper.IsSet(eventLogMaxBytesKey) {
+		return storage.DefaultEventLogMaxBytes
+	}
+	return viper.GetInt64(eventLogMaxBytesKey)
+}
+
+// eventLog returns the event log in storageDir
+func eventLog(storageDir string) *storage.EventLog {
+	return storage.NewEventLog(filepath.Join(storageDir, storage.EventLogFile), eventLogMaxBytes())
+}
+
+func runEvents(cmd *cobra.Command, args []string) error {
+	if eventsOutput != "table" && eventsOutput != "json" {
+		return fmt.Errorf("invalid output format %q (must be table or json)", eventsOutput)
+	}
+	if eventsTail < 0 {
+		return fmt.Errorf("--tail must not be negative, got %d", eventsTail)
+	}
+
+	filter := eventFilter{id: eventsID}
+	for _, operation := range eventsOperations {
+		operation = strings.TrimSpace(operation)
+		if !slices.Contains(eventOperations, operation) {
+			return fmt.Errorf("invalid operation %q (must be one of %s)", operation, strings.Join(eventOperations, ", "))
+		}
+		if filter.operations == nil {
+			filter.operations = make(map[string]bool)
+		}
+		filter.operations[operation] = true
+	}
+	if eventsSince != "" {
+		since, err := parseTimeSpec(eventsSince, time.Now())
+		if err != nil {
+			return err
+		}
+		filter.since = since
+	}
+
+	storageDir, err := contextStorageDir()
+	if err != nil {
+		return err
+	}
+	log := eventLog(storageDir)
+
+	events, err := log.Read()
+	if err != nil {
+		return err
+	}
+	if len(events) == 0 && !eventsFollow {
+		if !viper.GetBool(eventLogKey) {
+			VPrintf(Normal, "No events logged (turn the event log on with 'cmctl config set %s true')\n", eventLogKey)
+		} else {
+			VPrintf(Normal, "No events logged\n")
+		}
+		return nil
+	}
+
+	w := os.Stdout
+	matched := filterEvents(events, filter, eventsTail)
+	if eventsOutput == "table" {
+		fmt.Fprintf(w, "%-20s %-12s %-25s %s\n", "TIME", "OPERATION", "ID", "NAME")
+	}
+	if err := printEvents(w, matched, eventsOutput); err != nil {
+		return err
+	}
+	if !eventsFollow {
+		return nil
+	}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ticker := time.NewTicker(eventsFollowInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		latest, err := log.Read()
+		if err != nil {
+			VPrintf(Quiet, "Warning: %v\n", err)
+			continue
+		}
+		added := latest
+		if len(events) > 0 {
+			added = eventsAfter(latest, events[len(events)-1])
+		}
+		if len(added) == 0 {
+			continue
+		}
+		events = latest
+		if err := printEvents(w, filterEvents(added, filter, 0), eventsOutput); err != nil {
+			return err
+		}
+	}
+}
+
+// printEvents writes events as table rows, or as one JSON object per line
+func printEvents(w io.Writer, events []storage.Event, format string) error {
+	for _, event := range events {
+		if format == "json" {
+			line, err := json.Marshal(event)
+			if err != nil {
+				return fmt.Errorf("failed to marshal event: %w", err)
+			}
+			fmt.Fprintf(w, "%s\n", line)
+			continue
+		}
+		id := event.ID
+		if event.Count > 0 {
+			id = fmt.Sprintf("(%d memories)", event.Count)
+		}
+		fmt.Fprintf(w, "%-20s %-12s %-25s %s\n", event.Time.Local().Format("2006-01-02 15:04:05"), event.Operation, id, truncateString(event.Name, 40))
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+	"github.com/spf13/viper"
+)
+
+// useEventsFlags sets the events command's flags for one test
+func useEventsFlags(t *testing.T, operations []string, tail int, output string) {
+	t.Helper()
+	prevOperations, prevTail, prevOutput := eventsOperations, eventsTail, eventsOutput
+	t.Cleanup(func() {
+		eventsOperations, eventsTail, eventsOutput = prevOperations, prevTail, prevOutput
+	})
+	eventsOperations, eventsTail, eventsOutput = operations, tail, output
+}
+
+func TestEventsCommand(t *testing.T) {
+	useTestStorageDir(t)
+	viper.Set(eventLogKey, true)
+	t.Cleanup(func() { viper.Set(eventLogKey, false) })
+
+	fs, err := getStorageProvider()
+	if err != nil {
+		t.Fatalf("Failed to get storage: %v", err)
+	}
+	kept, err := fs.Create(storage.CreateMemoryRequest{Name: "Kept", Content: "stays"})
+	if err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+	removed, err := fs.Create(storage.CreateMemoryRequest{Name: "Removed", Content: "goes"})
+	if err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+	if err := fs.Delete(removed.ID); err != nil {
+		t.Fatalf("Failed to delete memory: %v", err)
+	}
+
+	useEventsFlags(t, nil, 0, "table")
+	output := captureStdout(t, func() error { return runEvents(eventsCmd, nil) })
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], "TIME") {
+		t.Fatalf("Expected a header and 3 events, got %q", output)
+	}
+	if !strings.Contains(lines[1], kept.ID) || !strings.Contains(lines[3], "delete") || !strings.Contains(lines[3], "Removed") {
+		t.Errorf("Expected the create of %s first and the delete last, got %q", kept.ID, output)
+	}
+
+	useEventsFlags(t, []string{"delete"}, 0, "json")
+	output = captureStdout(t, func() error { return runEvents(eventsCmd, nil) })
+	var event storage.Event
+	if err := json.Unmarshal([]byte(output), &event); err != nil {
+		t.Fatalf("Expected one JSON event, got %q: %v", output, err)
+	}
+	if event.Operation != storage.EventDelete || event.ID != removed.ID || event.Name != "Removed" {
+		t.Errorf("Expected the delete of %s, got %+v", removed.ID, event)
+	}
+
+	useEventsFlags(t, []string{"remove"}, 0, "table")
+	if err := runEvents(eventsCmd, nil); err == nil || !strings.Contains(err.Error(), "invalid operation") {
+		t.Errorf("Expected an invalid operation error, got %v", err)
+	}
+}
+
+func TestFilterEventsTail(t *testing.T) {
+	events := []storage.Event{
+		{Operation: storage.EventCreate, ID: "mem_1"},
+		{Operation: storage.EventUpdate, ID: "mem_1"},
+		{Operation: storage.EventCreate, ID: "mem_2"},
+		{Operation: storage.EventUpdate, ID: "mem_2"},
+	}
+	got := filterEvents(events, eventFilter{operations: map[string]bool{storage.EventUpdate: true}}, 1)
+	if len(got) != 1 || got[0].ID != "mem_2" {
+		t.Errorf("Expected the last update, got %+v", got)
+	}
+	if got := eventsAfter(events, events[1]); len(got) != 2 || got[0].ID != "mem_2" {
+		t.Errorf("Expected the events after the second, got %+v", got)
+	}
+}
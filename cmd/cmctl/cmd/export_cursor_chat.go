@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/cursor"
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportLatest    bool
+	exportTabID     string
+	exportWorkspace string
+	exportSource    string
+	exportOutput    string
+	exportFormat    string
+	exportNoMerge   bool
+)
+
+// exportCursorChatCmd represents the export-cursor-chat command
+var exportCursorChatCmd = &cobra.Command{
+	Use:   "export-cursor-chat",
+	Short: "Export a chat from Cursor's AI pane to a file",
+	Long: `Export a chat conversation from Cursor's AI pane as a markdown or JSON
+transcript, without storing it in ContextMemory.
+
+Examples:
+  # Write the most recent chat to a markdown file
+  cmctl export-cursor-chat --latest --output chat.md
+
+  # Print a specific chat to stdout
+  cmctl export-cursor-chat --tab-id abc123
+
+  # Export as JSON and pipe it elsewhere
+  cmctl export-cursor-chat --latest --format json --output - | jq '.messages | length'`,
+	RunE: runExportCursorChat,
+}
+
+func init() {
+	rootCmd.AddCommand(exportCursorChatCmd)
+
+	exportCursorChatCmd.Flags().BoolVar(&exportLatest, "latest", false, "Export the most recent chat")
+	exportCursorChatCmd.Flags().StringVar(&exportTabID, "tab-id", "", "Export specific chat by tab ID")
+	exportCursorChatCmd.Flags().StringVar(&exportWorkspace, "workspace", "", "Path to a workspace database, workspace directory, or workspaceStorage root")
+	exportCursorChatCmd.Flags().StringVar(&exportSource, "source", chatSourceCursor, "Editor to read chats from (cursor, vscode)")
+	exportCursorChatCmd.Flags().StringVar(&exportOutput, "output", "-", "File to write the transcript to, or - for stdout")
+	exportCursorChatCmd.Flags().StringVar(&exportFormat, "format", "markdown", "Transcript format (markdown, json)")
+	exportCursorChatCmd.Flags().BoolVar(&exportNoMerge, "no-merge", false, "Keep each recorded part of a continued conversation as a separate chat")
+}
+
+func runExportCursorChat(cmd *cobra.Command, args []string) error {
+	if !exportLatest && exportTabID == "" {
+		return fmt.Errorf("must specify --latest or --tab-id")
+	}
+
+	reader, err := newChatReader(exportSource, exportWorkspace, "", exportNoMerge)
+	if err != nil {
+		return err
+	}
+
+	var chatTab *cursor.ChatTab
+	if exportLatest {
+		chatTab, err = reader.GetLatestChat()
+		if err != nil {
+			return fmt.Errorf("failed to get latest chat: %w", err)
+		}
+	} else {
+		chatTab, _, err = reader.GetChatByID(exportTabID)
+		if err != nil {
+			return fmt.Errorf("failed to get chat by ID: %w", err)
+		}
+	}
+
+	if err := exportChatToPath(chatTab, exportFormat, exportOutput); err != nil {
+		return err
+	}
+
+	if exportOutput != "-" {
+		VPrintf(Normal, "Exported chat %s to %s\n", chatTab.ID, exportOutput)
+	}
+	return nil
+}
+
+// exportChatToPath writes a chat transcript to path, or to stdout if path
+// is "-"
+func exportChatToPath(chatTab *cursor.ChatTab, format, path string) error {
+	if path == "-" {
+		return exportChat(chatTab, format, os.Stdout)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	if err := exportChat(chatTab, format, file); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	return nil
+}
+
+// exportChat renders a chat transcript in the given format to w
+func exportChat(chatTab *cursor.ChatTab, format string, w io.Writer) error {
+	var data []byte
+	switch format {
+	case "markdown", "md":
+		data = []byte(chatTab.ToMarkdown())
+	case "json":
+		var err error
+		data, err = json.MarshalIndent(chatTab, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal chat: %w", err)
+		}
+		data = append(data, '\n')
+	default:
+		return fmt.Errorf("unsupported format %q (must be markdown or json)", format)
+	}
+
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("failed to write transcript: %w", err)
+	}
+	return nil
+}
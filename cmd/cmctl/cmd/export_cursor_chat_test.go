@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/cursor"
+)
+
+func TestExportChatToPath(t *testing.T) {
+	chat := testChat("chat-123", "Why does my test fail?")
+	dir := t.TempDir()
+
+	mdPath := filepath.Join(dir, "chat.md")
+	if err := exportChatToPath(chat, "markdown", mdPath); err != nil {
+		t.Fatalf("Markdown export failed: %v", err)
+	}
+	md, err := os.ReadFile(mdPath)
+	if err != nil {
+		t.Fatalf("Failed to read export: %v", err)
+	}
+	if string(md) != chat.ToMarkdown() {
+		t.Errorf("Expected markdown export to match ToMarkdown, got:\n%s", md)
+	}
+
+	jsonPath := filepath.Join(dir, "chat.json")
+	if err := exportChatToPath(chat, "json", jsonPath); err != nil {
+		t.Fatalf("JSON export failed: %v", err)
+	}
+	data, err := os.ReadFile(jsonPath)
+	if err != nil {
+		t.Fatalf("Failed to read export: %v", err)
+	}
+	var exported cursor.ChatTab
+	if err := json.Unmarshal(data, &exported); err != nil {
+		t.Fatalf("Failed to parse JSON export: %v", err)
+	}
+	if exported.ID != "chat-123" || len(exported.Messages) != 2 {
+		t.Errorf("Unexpected JSON export: %+v", exported)
+	}
+}
+
+func TestExportChatUnsupportedFormat(t *testing.T) {
+	var out strings.Builder
+	if err := exportChat(testChat("chat-123", "Question?"), "html", &out); err == nil {
+		t.Error("Expected error for unsupported format")
+	}
+	if out.Len() != 0 {
+		t.Errorf("Expected nothing written, got %q", out.String())
+	}
+}
@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+)
+
+// memoryFields returns the JSON field names of a memory, which are the
+// names accepted by --fields
+func memoryFields() []string {
+	t := reflect.TypeOf(storage.Memory{})
+	fields := make([]string, 0, t.NumField())
+	for i := range t.NumField() {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			fields = append(fields, name)
+		}
+	}
+	return fields
+}
+
+// parseFields parses a comma-separated --fields value
+func parseFields(value string) ([]string, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+
+	known := memoryFields()
+	var fields []string
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		if !slices.Contains(known, field) {
+			return nil, fmt.Errorf("unknown field %q (valid fields: %s)", field, strings.Join(known, ", "))
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// parseLabelColumns parses a comma-separated --columns-from-labels value
+func parseLabelColumns(value string) ([]string, error) {
+	var keys []string
+	for _, key := range strings.Split(value, ",") {
+		key = strings.TrimSpace(key)
+		if key == "" || slices.Contains(keys, key) {
+			continue
+		}
+		if err := storage.ValidateLabels(map[string]string{key: ""}); err != nil {
+			return nil, fmt.Errorf("invalid --columns-from-labels: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// needsContent reports whether the requested fields include the content, so
+// callers can skip loading it
+func needsContent(fields []string) bool {
+	return len(fields) == 0 || slices.Contains(fields, "content")
+}
+
+// projectDocument converts a MemoryList or Memory document to a generic
+// value keeping only the given fields of each memory
+func projectDocument(doc any, fields []string) (any, error) {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal document: %w", err)
+	}
+	var generic map[string]any
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal document: %w", err)
+	}
+
+	if items, ok := generic["items"].([]any); ok {
+		for i, item := range items {
+			if memory, ok := item.(map[string]any); ok {
+				items[i] = projectMemory(memory, fields)
+			}
+		}
+	}
+	if spec, ok := generic["spec"].(map[string]any); ok {
+		generic["spec"] = projectMemory(spec, fields)
+	}
+	return generic, nil
+}
+
+// projectMemory keeps only the given keys of a memory object
+func projectMemory(memory map[string]any, fields []string) map[string]any {
+	projected := make(map[string]any, len(fields))
+	for _, field := range fields {
+		if value, ok := memory[field]; ok {
+			projected[field] = value
+		}
+	}
+	return projected
+}
+
+// memoryFieldValue resolves a --field path such as content or
+// labels.language against a memory as it appears in JSON, without the
+// document envelope. Path parts index into objects by key and arrays by
+// position; keys that themselves contain dots, like many label keys, are
+// matched whole.
+func memoryFieldValue(memory *storage.Memory, path string) (any, error) {
+	path = strings.TrimPrefix(strings.TrimSpace(path), ".")
+	if path == "" {
+		return nil, fmt.Errorf("--field requires a field name (valid fields: %s)", strings.Join(memoryFields(), ", "))
+	}
+
+	data, err := json.Marshal(memory)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal memory: %w", err)
+	}
+	var generic map[string]any
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal memory: %w", err)
+	}
+
+	value, ok := lookupField(generic, strings.Split(path, "."))
+	if !ok {
+		return nil, fmt.Errorf("field %q not found in memory %s", path, memory.ID)
+	}
+	return value, nil
+}
+
+// lookupField follows parts from node, preferring the longest run of parts
+// that names an object key so that dotted keys resolve
+func lookupField(node any, parts []string) (any, bool) {
+	if len(parts) == 0 {
+		return node, true
+	}
+	switch n := node.(type) {
+	case map[string]any:
+		for end := len(parts); end > 0; end-- {
+			if child, ok := n[strings.Join(parts[:end], ".")]; ok {
+				if value, ok := lookupField(child, parts[end:]); ok {
+					return value, true
+				}
+			}
+		}
+	case []any:
+		i, err := strconv.Atoi(parts[0])
+		if err == nil && i >= 0 && i < len(n) {
+			return lookupField(n[i], parts[1:])
+		}
+	}
+	return nil, false
+}
+
+// formatFieldValue prints a --field value for the shell: strings as they
+// are, objects as sorted key=value lines and arrays one element per line.
+// Values nested inside those are written as compact JSON.
+func formatFieldValue(value any) string {
+	var lines []string
+	switch v := value.(type) {
+	case map[string]any:
+		for _, key := range sortedKeys(v) {
+			lines = append(lines, key+"="+storage.MetadataString(v[key]))
+		}
+	case []any:
+		for _, item := range v {
+			lines = append(lines, storage.MetadataString(item))
+		}
+	default:
+		lines = []string{storage.MetadataString(v)}
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+
+	output := strings.Join(lines, "\n")
+	if !strings.HasSuffix(output, "\n") {
+		output += "\n"
+	}
+	return output
+}
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+	"gopkg.in/yaml.v3"
+)
+
+// tagsLabel holds the plain front-matter tags of a markdown file, joined
+// with labelValueSeparator
+const tagsLabel = "tags"
+
+// markdownExtensions are the file extensions whose front-matter is read
+var markdownExtensions = map[string]bool{
+	".md":       true,
+	".markdown": true,
+}
+
+// frontMatter holds the front-matter fields that map onto a memory
+type frontMatter struct {
+	Title string
+	Tags  []string
+}
+
+// isMarkdownFile reports whether path names a markdown file
+func isMarkdownFile(path string) bool {
+	return markdownExtensions[strings.ToLower(filepath.Ext(path))]
+}
+
+// splitFrontMatter separates a leading '---' YAML block from content,
+// returning its fields and the content after it. found is false when
+// content has no front-matter; an error means a block was opened but is
+// unclosed or isn't a YAML mapping.
+func splitFrontMatter(content string) (fm frontMatter, body string, found bool, err error) {
+	firstLine, rest, ok := strings.Cut(content, "\n")
+	if !ok || strings.TrimRight(firstLine, "\r") != "---" {
+		return fm, content, false, nil
+	}
+
+	var block []string
+	closed := false
+	for rest != "" {
+		var line string
+		line, rest, _ = strings.Cut(rest, "\n")
+		if trimmed := strings.TrimRight(line, "\r"); trimmed == "---" || trimmed == "..." {
+			closed = true
+			break
+		}
+		block = append(block, line)
+	}
+	if !closed {
+		return fm, content, true, fmt.Errorf("front-matter is not closed with '---'")
+	}
+
+	var fields map[string]any
+	if err := yaml.Unmarshal([]byte(strings.Join(block, "\n")), &fields); err != nil {
+		return fm, content, true, fmt.Errorf("invalid front-matter: %w", err)
+	}
+
+	if title, ok := fields["title"]; ok && title != nil {
+		fm.Title = strings.TrimSpace(fmt.Sprint(title))
+	}
+	switch tags := fields["tags"].(type) {
+	case []any:
+		for _, tag := range tags {
+			if tag != nil {
+				fm.Tags = append(fm.Tags, strings.TrimSpace(fmt.Sprint(tag)))
+			}
+		}
+	case string:
+		for _, tag := range strings.Split(tags, ",") {
+			fm.Tags = append(fm.Tags, strings.TrimSpace(tag))
+		}
+	}
+
+	return fm, strings.TrimLeft(rest, "\r\n"), true, nil
+}
+
+// labels maps the front-matter tags onto labels. Tags written as key=value
+// become that label; the rest are joined into the tags label.
+func (fm frontMatter) labels() map[string]string {
+	labels := make(map[string]string)
+	var tags []string
+	for _, tag := range fm.Tags {
+		if key, value, ok := strings.Cut(tag, "="); ok {
+			if key, value = sanitizeLabelValue(key), sanitizeLabelValue(value); key != "" && value != "" {
+				labels[key] = value
+			}
+			continue
+		}
+		tags = append(tags, tag)
+	}
+	if joined := joinLabelValues(tags); joined != "" {
+		labels[tagsLabel] = joined
+	}
+	return labels
+}
+
+// applyFrontMatter moves the front-matter of a markdown file out of
+// req.Content: its title becomes the name unless keepName is set, and its
+// tags become labels beneath the ones already set. Malformed front-matter
+// is left in the content with a warning naming source.
+func applyFrontMatter(req storage.CreateMemoryRequest, source string, keepName bool) storage.CreateMemoryRequest {
+	fm, body, found, err := splitFrontMatter(req.Content)
+	if err != nil {
+		VPrintf(Normal, "Warning: keeping malformed front-matter of %s in the content: %v\n", source, err)
+		return req
+	}
+	if !found {
+		return req
+	}
+
+	req.Content = body
+	if fm.Title != "" && !keepName {
+		req.Name = fm.Title
+	}
+	req.Labels = mergeLabels(fm.labels(), req.Labels)
+	return req
+}
@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+)
+
+func TestApplyFrontMatter(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		keepName bool
+		wantName string
+		wantBody string
+		labels   map[string]string
+	}{
+		{
+			name:     "title and tag list",
+			content:  "---\ntitle: Token rotation\ntags: [auth, security, project=api]\n---\n\n# Notes\nRotate on use.\n",
+			wantName: "Token rotation",
+			wantBody: "# Notes\nRotate on use.\n",
+			labels:   map[string]string{"source": "import", "tags": "auth_security", "project": "api"},
+		},
+		{
+			name:     "comma separated tags and CRLF",
+			content:  "---\r\ntitle: Windows notes\r\ntags: \"go, testing\"\r\n---\r\nBody\r\n",
+			wantName: "Windows notes",
+			wantBody: "Body\r\n",
+			labels:   map[string]string{"source": "import", "tags": "go_testing"},
+		},
+		{
+			name:     "existing name and labels win",
+			content:  "---\ntitle: Ignored\ntags: [source=blog]\n---\nBody\n",
+			keepName: true,
+			wantName: "original",
+			wantBody: "Body\n",
+			labels:   map[string]string{"source": "import"},
+		},
+		{
+			name:     "no front-matter",
+			content:  "# Heading\n---\ntitle: not front-matter\n---\n",
+			wantName: "original",
+			wantBody: "# Heading\n---\ntitle: not front-matter\n---\n",
+			labels:   map[string]string{"source": "import"},
+		},
+		{
+			name:     "unclosed front-matter",
+			content:  "---\ntitle: Never closed\n",
+			wantName: "original",
+			wantBody: "---\ntitle: Never closed\n",
+			labels:   map[string]string{"source": "import"},
+		},
+		{
+			name:     "invalid YAML",
+			content:  "---\ntitle: [unterminated\n---\nBody\n",
+			wantName: "original",
+			wantBody: "---\ntitle: [unterminated\n---\nBody\n",
+			labels:   map[string]string{"source": "import"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := storage.CreateMemoryRequest{
+				Name:    "original",
+				Content: tt.content,
+				Labels:  map[string]string{"source": "import"},
+			}
+			got := applyFrontMatter(req, "notes.md", tt.keepName)
+			if got.Name != tt.wantName {
+				t.Errorf("Expected name %q, got %q", tt.wantName, got.Name)
+			}
+			if got.Content != tt.wantBody {
+				t.Errorf("Expected content %q, got %q", tt.wantBody, got.Content)
+			}
+			if !reflect.DeepEqual(got.Labels, tt.labels) {
+				t.Errorf("Expected labels %v, got %v", tt.labels, got.Labels)
+			}
+		})
+	}
+}
+
+func TestCreateFromDirFrontMatter(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"post.md":  "---\ntitle: Release checklist\ntags: [release]\n---\nTag, build, publish.\n",
+		"plain.md": "No front-matter here.\n",
+		"data.txt": "---\ntitle: Not markdown\n---\nKept as is.\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+
+	fs := newTestStorage(t)
+	base := storage.CreateMemoryRequest{Labels: map[string]string{"source": "import"}}
+	if _, err := createFromDir(fs, dir, "*", false, base, 0, false); err != nil {
+		t.Fatalf("Failed to create from directory: %v", err)
+	}
+
+	memories, err := fs.List()
+	if err != nil {
+		t.Fatalf("Failed to list memories: %v", err)
+	}
+	byName := make(map[string]storage.Memory)
+	for _, memory := range memories {
+		byName[memory.Name] = memory
+	}
+
+	post, ok := byName["Release checklist"]
+	if !ok {
+		t.Fatalf("Expected a memory named after the front-matter title, got %v", byName)
+	}
+	if post.Content != "Tag, build, publish.\n" {
+		t.Errorf("Expected front-matter stripped from content, got %q", post.Content)
+	}
+	if post.Labels["tags"] != "release" || post.Labels["source"] != "import" {
+		t.Errorf("Expected tags and base labels, got %v", post.Labels)
+	}
+	if plain := byName["plain"]; plain.Content != files["plain.md"] {
+		t.Errorf("Expected plain markdown unchanged, got %q", plain.Content)
+	}
+	if data := byName["data"]; data.Content != files["data.txt"] {
+		t.Errorf("Expected non-markdown content unchanged, got %q", data.Content)
+	}
+}
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/providers"
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+var gcCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "Delete memories whose TTL has expired",
+	Long: `Garbage-collect memories created with --ttl once their expiry has passed.
+Expired memories are moved to the trash unless --purge is given.
+
+Examples:
+  cmctl gc --dry-run      # Show the memories that would be collected
+  cmctl gc                # Move expired memories to the trash
+  cmctl gc --purge        # Delete expired memories permanently`,
+	Args: cobra.NoArgs,
+	RunE: runGC,
+}
+
+var (
+	gcDryRun bool
+	gcPurge  bool
+)
+
+func init() {
+	rootCmd.AddCommand(gcCmd)
+
+	gcCmd.Flags().BoolVar(&gcDryRun, "dry-run", false, "Print the expired memories without deleting them")
+	gcCmd.Flags().BoolVar(&gcPurge, "purge", false, "Delete permanently instead of moving to the trash")
+}
+
+func runGC(cmd *cobra.Command, args []string) error {
+	fs, err := getStorageProvider()
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+
+	expired, err := expiredMemories(fs, time.Now())
+	if err != nil {
+		return err
+	}
+	if len(expired) == 0 {
+		VPrintf(Normal, "No expired memories\n")
+		return nil
+	}
+
+	if gcDryRun {
+		printDryRun(expired)
+		return nil
+	}
+
+	collected, err := collectMemories(fs, expired, gcPurge)
+	VPrintf(Normal, "Collected %d/%d expired memories\n", collected, len(expired))
+	return err
+}
+
+// expiredMemories returns the memories whose expiry is at or before now
+func expiredMemories(fs providers.StorageProvider, now time.Time) ([]storage.Memory, error) {
+	memories, err := listMetadata(fs)
+	if err != nil {
+		return nil, err
+	}
+	var expired []storage.Memory
+	for _, memory := range memories {
+		if memory.IsExpired(now) {
+			expired = append(expired, memory)
+		}
+	}
+	return expired, nil
+}
+
+// collectMemories removes the given memories, returning how many were
+// removed and the first error encountered
+func collectMemories(fs providers.StorageProvider, memories []storage.Memory, purge bool) (int, error) {
+	collected := 0
+	var firstErr error
+	for _, memory := range memories {
+		if err := removeMemory(fs, memory.ID, purge); err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("failed to delete memory %s: %w", memory.ID, err)
+			}
+			continue
+		}
+		collected++
+		VPrintf(Verbose, "Collected: %s\n", memory.Name)
+	}
+	return collected, firstErr
+}
+
+// filterExpired drops memories whose expiry is at or before now
+func filterExpired(memories []storage.Memory, now time.Time) []storage.Memory {
+	kept := memories[:0]
+	for _, memory := range memories {
+		if !memory.IsExpired(now) {
+			kept = append(kept, memory)
+		}
+	}
+	return kept
+}
+
+// expiryFromTTL turns a relative duration such as "7d" into an expiry time
+func expiryFromTTL(ttl string, now time.Time) (time.Time, error) {
+	d, err := parseRelativeDuration(ttl)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --ttl: %w", err)
+	}
+	if d == 0 {
+		return time.Time{}, fmt.Errorf("invalid --ttl: must be greater than zero")
+	}
+	return now.Add(d), nil
+}
+
+// listMetadata lists memories without loading content when the provider
+// supports it
+func listMetadata(fs providers.StorageProvider) ([]storage.Memory, error) {
+	var memories []storage.Memory
+	var err error
+	if lister, ok := fs.(providers.OptimizedLister); ok {
+		memories, err = lister.ListWithOptions(storage.ListOptions{UseIndex: true})
+	} else {
+		memories, err = fs.List()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list memories: %w", err)
+	}
+	return memories, nil
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+)
+
+func TestExpiryFromTTL(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		ttl     string
+		want    time.Time
+		wantErr bool
+	}{
+		{ttl: "12h", want: now.Add(12 * time.Hour)},
+		{ttl: "7d", want: now.AddDate(0, 0, 7)},
+		{ttl: "2w", want: now.AddDate(0, 0, 14)},
+		{ttl: "0d", wantErr: true},
+		{ttl: "soon", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ttl, func(t *testing.T) {
+			got, err := expiryFromTTL(tt.ttl, now)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error for %q, got %v", tt.ttl, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestGarbageCollect(t *testing.T) {
+	fs := newTestStorage(t)
+	now := time.Now()
+
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	expired, err := fs.Create(storage.CreateMemoryRequest{Name: "Expired", Content: "old", ExpiresAt: &past})
+	if err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+	live, err := fs.Create(storage.CreateMemoryRequest{Name: "Live", Content: "new", ExpiresAt: &future})
+	if err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+	permanent, err := fs.Create(storage.CreateMemoryRequest{Name: "Permanent", Content: "keep"})
+	if err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+
+	found, err := expiredMemories(fs, now)
+	if err != nil {
+		t.Fatalf("Failed to find expired memories: %v", err)
+	}
+	if len(found) != 1 || found[0].ID != expired.ID {
+		t.Fatalf("Expected only %s to be expired, got %v", expired.ID, found)
+	}
+
+	memories, err := fs.List()
+	if err != nil {
+		t.Fatalf("Failed to list memories: %v", err)
+	}
+	visible := filterExpired(memories, now)
+	if len(visible) != 2 {
+		t.Errorf("Expected 2 unexpired memories, got %d", len(visible))
+	}
+
+	collected, err := collectMemories(fs, found, false)
+	if err != nil {
+		t.Fatalf("Failed to collect memories: %v", err)
+	}
+	if collected != 1 {
+		t.Errorf("Expected 1 memory collected, got %d", collected)
+	}
+
+	for _, id := range []string{live.ID, permanent.ID} {
+		if _, err := fs.Get(id); err != nil {
+			t.Errorf("Expected %s to survive gc: %v", id, err)
+		}
+	}
+	trashed, err := fs.ListTrash()
+	if err != nil {
+		t.Fatalf("Failed to list trash: %v", err)
+	}
+	if len(trashed) != 1 || trashed[0].ID != expired.ID {
+		t.Errorf("Expected expired memory in the trash, got %v", trashed)
+	}
+}
@@ -1,11 +1,17 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/providers"
 	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
 var getCmd = &cobra.Command{
@@ -23,117 +29,573 @@ Examples:
   cmctl get --include-content=false             # Fast metadata-only listing
   cmctl get --show-id                           # List all memories with IDs
   cmctl get --labels "type=test"                # List memories with specific labels
+  cmctl get --labels "type=chat" --exclude-labels "status=draft" # All chats but drafts
+  cmctl get --metadata "source=cursor" -o json  # List memories by metadata value
+  cmctl get --pinned                            # List pinned memories only
+  cmctl get --sort-by name                      # Sort A-Z instead of most recently updated first
+  cmctl get --sort-by size --reverse            # Smallest memories first
   cmctl get -o json                             # List all memories as JSON
+  cmctl get -o json --fields id,name,labels     # JSON without content (skips reading it)
+  cmctl get --json-stream --progress > all.jsonl # One JSON line per memory, written as it's read
+  cmctl get --watch --labels "type=chat"        # Redraw the list as chats are imported
+  cmctl get --show-content                      # Add a column previewing each memory's content
+  cmctl get --columns-from-labels project,owner # Add a column for each of these labels
+  cmctl get --labels "type=meeting" --last      # The most recently created meeting
+  cmctl get --labels "type=chat" -i             # Pick a chat, typing to filter the list
   cmctl get mem_abc123_def456                   # Get specific memory
   cmctl get mem_abc123_def456 -o yaml          # Get specific memory as YAML
-  cmctl get mem_abc123_def456 -o jsonpath='{.spec.content}'  # Extract content using JSONPath`,
+  cmctl get mem_abc123_def456 --related        # List the memories it links to
+  cmctl get mem_abc123_def456 -o jsonpath='{.spec.content}'  # Extract content using JSONPath
+  cmctl get mem_abc123_def456 --field content                 # The same, without the envelope
+  cmctl get mem_abc123_def456 --field labels.language         # One label's value
+  cmctl get mem_abc123_def456 --raw-content > notes.md        # Content bytes exactly as stored
+  cmctl get mem_abc123_def456 --bytes 0:1000                  # Only the first 1000 bytes
+  cmctl get mem_abc123_def456 --raw-content -o json 2> meta.json  # Content to stdout, the rest as JSON to stderr
+
+--raw-content writes a single memory's content to stdout byte for byte,
+without escaping or a trailing newline. With a structured -o format, the
+memory's document, without its content, is written to stderr. --bytes
+start:end selects a byte range, end exclusive; either side may be left out.
+
+-o jsonl writes one Memory document per line. Listings are written as each
+memory is read, in storage order, rather than once all are loaded; give
+--sort-by or --reverse to sort them first. --json-stream is shorthand for
+-o jsonl, and --progress keeps a count of the memories read on stderr.
+
+--field prints one field of a single memory, named as in its JSON but
+without the apiVersion/kind/spec envelope: content, labels.language,
+metadata.summary.model or relations.0.targetId. Strings are printed as
+they are, objects as sorted key=value lines and arrays one element per
+line.`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runGet,
 }
 
+// defaultPreviewWidth is the --show-content width when none is given
+const defaultPreviewWidth = 60
+
 var (
-	getOutputFlag     string
-	getShowID         bool
-	getLabels         string
-	getIncludeContent bool
-	getNoIndex        bool
+	getOutputFlag        string
+	getShowID            bool
+	getLabels            string
+	getExclude           string
+	getIncludeContent    bool
+	getNoIndex           bool
+	getWatch             bool
+	getWatchInterval     time.Duration
+	getRelated           bool
+	getPinned            bool
+	getExpired           bool
+	getFields            string
+	getSortBy            string
+	getReverse           bool
+	getMetadata          string
+	getShowContent       int
+	getColumnsFromLabels string
+	getFirst             bool
+	getLast              bool
+	getInteractive       bool
+	getRawContent        bool
+	getBytes             string
+	getField             string
+	getJSONStream        bool
+	getProgress          bool
 )
 
 func init() {
 	rootCmd.AddCommand(getCmd)
 
-	getCmd.Flags().StringVarP(&getOutputFlag, "output", "o", "", "Output format: table|json|yaml|jsonpath=<template>|go-template=<template>")
+	getCmd.Flags().StringVarP(&getOutputFlag, "output", "o", "", "Output format: table|json|jsonl|yaml|jsonpath=<template>|go-template=<template>|template=<name>")
+	getCmd.Flags().BoolVar(&getJSONStream, "json-stream", false, "Write each memory as a JSON line as soon as it's read, in storage order (shorthand for -o jsonl)")
+	getCmd.Flags().BoolVar(&getProgress, "progress", false, "With jsonl output, keep a count of the memories read on stderr")
+	getCmd.Flags().StringVar(&getField, "field", "", "Print only this field of a single memory, e.g. content or labels.language")
+	getCmd.Flags().StringVar(&getFields, "fields", "", "Only include these memory fields in structured output (e.g. id,name,labels)")
 	getCmd.Flags().BoolVar(&getShowID, "show-id", false, "Show memory IDs when listing memories")
+	getCmd.Flags().IntVar(&getShowContent, "show-content", 0, "Add a column previewing the first N characters of content to the table (--show-content alone shows 60)")
+	getCmd.Flags().Lookup("show-content").NoOptDefVal = strconv.Itoa(defaultPreviewWidth)
+	getCmd.Flags().StringVar(&getColumnsFromLabels, "columns-from-labels", "", "Add a table column for each of these labels holding its value (e.g. project,status,owner)")
 	getCmd.Flags().StringVarP(&getLabels, "labels", "l", "", "Label selector for filtering (format: key1=value1,key2=value2)")
+	getCmd.Flags().StringVar(&getExclude, "exclude-labels", "", "Exclude memories with any of these labels, applied after --labels (format: key1=value1,key2=value2)")
+	getCmd.Flags().StringVar(&getMetadata, "metadata", "", "Metadata selector for filtering (format: key1=value1,nested.key=value2)")
 	getCmd.Flags().BoolVar(&getIncludeContent, "include-content", true, "Include full memory content (disable for faster metadata-only listing)")
 	getCmd.Flags().BoolVar(&getNoIndex, "no-index", false, "Disable index-based optimizations (force file-based loading)")
+	getCmd.Flags().BoolVarP(&getWatch, "watch", "w", false, "Watch for changes and redraw the output until interrupted")
+	getCmd.Flags().BoolVar(&getExpired, "include-expired", true, "Include memories whose TTL has expired but haven't been collected by 'cmctl gc'")
+	getCmd.Flags().StringVar(&getSortBy, "sort-by", defaultSortBy, sortFlagUsage)
+	getCmd.Flags().BoolVar(&getReverse, "reverse", false, "Reverse the sort order")
+	getCmd.Flags().BoolVar(&getPinned, "pinned", false, "Only list pinned memories")
+	getCmd.Flags().BoolVar(&getFirst, "first", false, "Get only the oldest memory, by creation time, of those listed")
+	getCmd.Flags().BoolVar(&getLast, "last", false, "Get only the most recently created memory of those listed")
+	getCmd.Flags().BoolVarP(&getInteractive, "interactive", "i", false, "Pick the memory to get from those listed, typing to filter")
+	getCmd.Flags().BoolVar(&getRelated, "related", false, "List the memories linked from the given memory")
+	getCmd.Flags().BoolVar(&getRawContent, "raw-content", false, "Write the memory's content to stdout exactly as stored; structured -o output goes to stderr")
+	getCmd.Flags().StringVar(&getBytes, "bytes", "", "With --raw-content, only write this byte range (start:end, e.g. 0:1000 or 4096:); implies --raw-content")
+	getCmd.Flags().DurationVar(&getWatchInterval, "watch-interval", defaultWatchInterval, "How often --watch checks for changes")
 }
 
 func runGet(cmd *cobra.Command, args []string) error {
 	// Initialize storage
-	storageDir := viper.GetString("storage-dir")
-	fs, err := storage.NewFileStorage(storageDir)
+	fs, err := getStorageProvider()
 	if err != nil {
 		return fmt.Errorf("failed to initialize storage: %w", err)
 	}
 
 	// Parse output format
-	outputOpts, err := ParseOutputFormat(getOutputFlag)
+	outputFlag := outputFormatOrDefault(getOutputFlag)
+	if getJSONStream {
+		if getOutputFlag != "" {
+			return fmt.Errorf("--json-stream and --output are mutually exclusive")
+		}
+		outputFlag = "jsonl"
+	}
+	outputOpts, err := ParseOutputFormat(outputFlag)
 	if err != nil {
 		return fmt.Errorf("invalid output format: %w", err)
 	}
+	outputOpts.Color = shouldColorize(os.Stdout)
+	outputOpts.Width = tableWidth(os.Stdout)
+	if outputOpts.Fields, err = parseFields(getFields); err != nil {
+		return err
+	}
+	if err := validateFieldsFormat(outputOpts); err != nil {
+		return err
+	}
+	if err := validateSortBy(getSortBy); err != nil {
+		return err
+	}
+	if getShowContent < 0 {
+		return fmt.Errorf("--show-content must be a positive number of characters")
+	}
+	outputOpts.PreviewWidth = getShowContent
+	if outputOpts.LabelColumns, err = parseLabelColumns(getColumnsFromLabels); err != nil {
+		return err
+	}
+	if len(outputOpts.LabelColumns) > 0 && outputOpts.Format != OutputFormatTable {
+		return fmt.Errorf("--columns-from-labels only applies to table output")
+	}
+	if getShowContent > 0 && !getIncludeContent {
+		VPrintf(Normal, "Warning: content isn't loaded with --include-content=false, so --show-content previews are empty\n")
+	}
+
+	if getProgress && outputOpts.Format != OutputFormatJSONL {
+		return fmt.Errorf("--progress only applies to jsonl output")
+	}
 
-	// If no memory ID provided, or filtering flags are used, list memories
-	if len(args) == 0 || getLabels != "" {
-		return runGetList(fs, outputOpts)
+	if err := validateFirstLastFlags(args); err != nil {
+		return err
 	}
 
-	// Otherwise, get specific memory
-	memoryID := args[0]
-	return runGetSingle(fs, memoryID, outputOpts)
+	if getBytes != "" {
+		getRawContent = true
+	}
+	if err := validateGetInteractiveFlags(args); err != nil {
+		return err
+	}
+	if err := validateFieldFlags(args); err != nil {
+		return err
+	}
+	outputOpts.Field = getField
+	if getRawContent {
+		if err := validateRawContentFlags(args); err != nil {
+			return err
+		}
+		return runGetRawContent(fs, args[0], outputOpts)
+	}
+
+	// If no memory ID provided, or filtering flags are used, list memories;
+	// otherwise get the specific memory. readID is the memory read, if only
+	// one is.
+	render := func() (string, error) { return renderGetList(fs, outputOpts) }
+	readID := ""
+	if getRelated {
+		if len(args) == 0 {
+			return fmt.Errorf("--related requires a memory ID")
+		}
+		render = func() (string, error) { return renderGetRelated(fs, args[0], outputOpts) }
+	} else if getFirst || getLast {
+		render = func() (string, error) {
+			output, id, err := renderGetSelected(fs, outputOpts)
+			readID = id
+			return output, err
+		}
+	} else if getInteractive {
+		render = func() (string, error) {
+			output, id, err := renderGetPicked(fs, outputOpts)
+			readID = id
+			return output, err
+		}
+	} else if len(args) > 0 && !getFiltering() {
+		render = func() (string, error) { return renderGetSingle(fs, args[0], outputOpts) }
+		readID = args[0]
+	}
+
+	if getWatch {
+		return runWatch(cmd, getWatchInterval, render)
+	}
+
+	// JSON lines are written as memories are read, unless they must be
+	// sorted first
+	if outputOpts.Format == OutputFormatJSONL && getStreaming(cmd, args) {
+		var progress io.Writer
+		if getProgress {
+			progress = os.Stderr
+		}
+		return streamGetList(cmd.Context(), fs, outputOpts, os.Stdout, progress)
+	}
+
+	output, err := render()
+	if err != nil {
+		return err
+	}
+	fmt.Print(output)
+
+	// Only one-shot reads count as accesses: recording one changes the
+	// memory, which would make --watch redraw it on every check
+	if readID != "" {
+		recordAccess(fs, readID)
+	}
+	return nil
 }
 
-func runGetList(fs *storage.FileStorage, outputOpts OutputOptions) error {
+// renderGetList lists the memories matching the get flags
+func renderGetList(fs providers.StorageProvider, outputOpts OutputOptions) (string, error) {
+	memories, err := listGetMemories(fs, outputOpts)
+	if err != nil {
+		return "", err
+	}
+
+	// Format and print output using the list document format
+	output, err := FormatMemoryList(memories, outputOpts, getShowID)
+	if err != nil {
+		return "", fmt.Errorf("failed to format output: %w", err)
+	}
+	return output, nil
+}
+
+// renderGetSelected formats the memory chosen by --first or --last from
+// those listed, returning its ID
+func renderGetSelected(fs providers.StorageProvider, outputOpts OutputOptions) (string, string, error) {
+	memories, err := listGetMemories(fs, outputOpts)
+	if err != nil {
+		return "", "", err
+	}
+	memory, ok := selectByCreated(memories, getFirst)
+	if !ok {
+		return "", "", fmt.Errorf("no memories found")
+	}
+
+	output, err := FormatSingleMemory(&memory, outputOpts)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to format output: %w", err)
+	}
+	return output, memory.ID, nil
+}
+
+// renderGetPicked formats the memory picked from those listed, returning
+// its ID, or "" if the pick was cancelled
+func renderGetPicked(fs providers.StorageProvider, outputOpts OutputOptions) (string, string, error) {
+	memories, err := listGetMemories(fs, outputOpts)
+	if err != nil {
+		return "", "", err
+	}
+	if len(memories) == 0 {
+		return "", "", fmt.Errorf("no memories found")
+	}
+	picked, err := selectMemory(memories, "memory to get")
+	if err != nil || picked == nil {
+		return "", "", err
+	}
+
+	output, err := renderGetSingle(fs, picked.ID, outputOpts)
+	if err != nil {
+		return "", "", err
+	}
+	return output, picked.ID, nil
+}
+
+// listGetMemories loads, filters and sorts the memories get lists
+func listGetMemories(fs providers.StorageProvider, outputOpts OutputOptions) ([]storage.Memory, error) {
 	var memories []storage.Memory
 	var err error
+	includeContent := getIncludeContent && needsContent(outputOpts.Fields)
+	// Sizes are content lengths, so sorting by size needs the content even
+	// if it isn't shown
+	loadContent := includeContent || getSortBy == storage.SortBySize
 
-	if getLabels != "" {
-		// Use search with label filtering
-		labelSelector := parseLabels(getLabels)
-		if len(labelSelector) == 0 {
-			return fmt.Errorf("invalid label selector format: %s", getLabels)
-		}
-
-		searchReq := storage.SearchRequest{
-			LabelSelector:  labelSelector,
-			Limit:          -1, // No limit for get command
-			UseIndex:       !getNoIndex,
-			IncludeContent: getIncludeContent,
+	if getFiltering() {
+		// Use search with label and metadata filtering
+		searchReq, err := getSearchRequest(loadContent)
+		if err != nil {
+			return nil, err
 		}
 		searchRes, err := fs.Search(searchReq)
 		if err != nil {
-			return fmt.Errorf("failed to search memories: %w", err)
+			return nil, fmt.Errorf("failed to search memories: %w", err)
 		}
 		memories = searchRes.Memories
 	} else {
 		// List all memories with performance options
 		listOpts := storage.ListOptions{
-			IncludeContent: getIncludeContent,
+			IncludeContent: loadContent,
 			UseIndex:       !getNoIndex,
 		}
-		memories, err = fs.ListWithOptions(listOpts)
+		if lister, ok := fs.(providers.OptimizedLister); ok {
+			memories, err = lister.ListWithOptions(listOpts)
+		} else {
+			memories, err = fs.List()
+		}
 		if err != nil {
-			return fmt.Errorf("failed to list memories: %w", err)
+			return nil, fmt.Errorf("failed to list memories: %w", err)
 		}
 	}
 
-	// Format and print output using the list document format
-	output, err := FormatMemoryList(memories, outputOpts, getShowID)
+	if !getExpired {
+		memories = filterExpired(memories, time.Now())
+	}
+	if err := sortMemories(memories, getSortBy, getReverse); err != nil {
+		return nil, err
+	}
+	if loadContent && !includeContent {
+		for i := range memories {
+			memories[i].Content = ""
+		}
+	}
+	sortPinnedFirst(memories)
+	return memories, nil
+}
+
+// getSearchRequest builds the search matching the get filtering flags
+func getSearchRequest(includeContent bool) (storage.SearchRequest, error) {
+	labelSelector := parseLabels(getLabels)
+	if getLabels != "" && len(labelSelector) == 0 {
+		return storage.SearchRequest{}, fmt.Errorf("invalid label selector format: %s", getLabels)
+	}
+	if getPinned {
+		labelSelector[storage.PinnedLabel] = "true"
+	}
+	excludeLabels, err := parseExcludeLabels(getExclude)
 	if err != nil {
-		return fmt.Errorf("failed to format output: %w", err)
+		return storage.SearchRequest{}, err
+	}
+	metadataSelector := parseLabels(getMetadata)
+	if getMetadata != "" && len(metadataSelector) == 0 {
+		return storage.SearchRequest{}, fmt.Errorf("invalid metadata selector format: %s", getMetadata)
 	}
 
-	fmt.Print(output)
+	return storage.SearchRequest{
+		LabelSelector:    labelSelector,
+		ExcludeLabels:    excludeLabels,
+		MetadataSelector: metadataSelector,
+		Limit:            -1, // No limit for get command
+		UseIndex:         !getNoIndex,
+		IncludeContent:   includeContent,
+	}, nil
+}
+
+// renderGetSingle formats a single memory
+func renderGetSingle(fs providers.StorageProvider, memoryID string, outputOpts OutputOptions) (string, error) {
+	memory, err := fs.Get(memoryID)
+	if errors.Is(err, storage.ErrMemoryNotFound) {
+		return "", err
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get memory: %w", err)
+	}
+
+	output, err := FormatSingleMemory(memory, outputOpts)
+	if err != nil {
+		return "", fmt.Errorf("failed to format output: %w", err)
+	}
+	return output, nil
+}
+
+// renderGetRelated lists the memories linked from a memory
+func renderGetRelated(fs providers.StorageProvider, memoryID string, outputOpts OutputOptions) (string, error) {
+	memory, err := fs.Get(memoryID)
+	if errors.Is(err, storage.ErrMemoryNotFound) {
+		return "", err
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get memory: %w", err)
+	}
+
+	related, err := relatedMemories(fs, memory)
+	if err != nil {
+		return "", err
+	}
+
+	output, err := FormatMemoryList(related, outputOpts, getShowID)
+	if err != nil {
+		return "", fmt.Errorf("failed to format output: %w", err)
+	}
+	return output, nil
+}
+
+// getStreaming reports whether get lists memories that can be written as
+// they're read: a plain listing, left in storage order
+func getStreaming(cmd *cobra.Command, args []string) bool {
+	if len(args) > 0 && !getFiltering() {
+		return false
+	}
+	return !getRelated && !getFirst && !getLast && !getInteractive && !getReverse && !cmd.Flags().Changed("sort-by")
+}
+
+// getFiltering reports whether get was given flags that filter a listing
+func getFiltering() bool {
+	return getLabels != "" || getExclude != "" || getMetadata != "" || getPinned
+}
+
+// validateFirstLastFlags rejects --first and --last together, or with a
+// memory to read or list links from
+func validateFirstLastFlags(args []string) error {
+	switch {
+	case getFirst && getLast:
+		return fmt.Errorf("--first and --last are mutually exclusive")
+	case !getFirst && !getLast:
+		return nil
+	case getRelated:
+		return fmt.Errorf("--first and --last can't be used with --related")
+	case len(args) > 0:
+		return fmt.Errorf("--first and --last select a memory, so they can't be used with a memory ID")
+	}
+	return nil
+}
+
+// validateGetInteractiveFlags rejects --interactive with another way of
+// choosing the memory, or with output that isn't a single memory
+func validateGetInteractiveFlags(args []string) error {
+	switch {
+	case !getInteractive:
+		return nil
+	case len(args) > 0:
+		return fmt.Errorf("--interactive picks a memory, so it can't be used with a memory ID")
+	case getFirst || getLast:
+		return fmt.Errorf("--interactive can't be used with --first or --last")
+	case getRelated:
+		return fmt.Errorf("--interactive and --related are mutually exclusive")
+	case getWatch:
+		return fmt.Errorf("--interactive and --watch are mutually exclusive")
+	case getRawContent:
+		return fmt.Errorf("--interactive and --raw-content are mutually exclusive")
+	}
+	return nil
+}
+
+// validateFieldFlags rejects --field unless a single memory is read, or
+// with flags that choose another output
+func validateFieldFlags(args []string) error {
+	single := (len(args) > 0 && !getFiltering()) || getFirst || getLast || getInteractive
+	switch {
+	case getField == "":
+		return nil
+	case !single || getRelated:
+		return fmt.Errorf("--field requires a single memory: a memory ID, --first, --last or --interactive")
+	case getOutputFlag != "":
+		return fmt.Errorf("--field and --output are mutually exclusive")
+	case getFields != "":
+		return fmt.Errorf("--field and --fields are mutually exclusive")
+	case getRawContent:
+		return fmt.Errorf("--field and --raw-content are mutually exclusive")
+	}
 	return nil
 }
 
-func runGetSingle(fs *storage.FileStorage, memoryID string, outputOpts OutputOptions) error {
-	// Get memory
+// validateRawContentFlags rejects --raw-content without a single memory to
+// read, or with flags that shape a list or table
+func validateRawContentFlags(args []string) error {
+	switch {
+	case len(args) == 0:
+		return fmt.Errorf("--raw-content requires a memory ID")
+	case getFiltering():
+		return fmt.Errorf("--raw-content reads a single memory and can't be used with --labels, --exclude-labels, --metadata or --pinned")
+	case getRelated:
+		return fmt.Errorf("--raw-content and --related are mutually exclusive")
+	case getWatch:
+		return fmt.Errorf("--raw-content and --watch are mutually exclusive")
+	case getFields != "":
+		return fmt.Errorf("--raw-content and --fields are mutually exclusive")
+	}
+	return nil
+}
+
+// runGetRawContent writes a memory's content, or the --bytes range of it,
+// to stdout, and its structured document without content to stderr
+func runGetRawContent(fs providers.StorageProvider, memoryID string, outputOpts OutputOptions) error {
 	memory, err := fs.Get(memoryID)
+	if errors.Is(err, storage.ErrMemoryNotFound) {
+		return err
+	}
 	if err != nil {
 		return fmt.Errorf("failed to get memory: %w", err)
 	}
 
-	if memory == nil {
-		return fmt.Errorf("memory not found: %s", memoryID)
+	content, err := memory.DecodedContent()
+	if err != nil {
+		return err
+	}
+	if err := writeRawContent(os.Stdout, string(content), getBytes); err != nil {
+		return err
 	}
+	recordAccess(fs, memoryID)
 
-	// Format and print output
-	output, err := FormatSingleMemory(memory, outputOpts)
+	if outputOpts.Format == OutputFormatTable {
+		return nil
+	}
+	rest := *memory
+	rest.Content = ""
+	outputOpts.Color = false
+	output, err := FormatSingleMemory(&rest, outputOpts)
 	if err != nil {
 		return fmt.Errorf("failed to format output: %w", err)
 	}
+	fmt.Fprint(os.Stderr, output)
+	return nil
+}
 
-	fmt.Print(output)
+// writeRawContent writes content, or the byteRange slice of it, to w
+// without any conversion
+func writeRawContent(w io.Writer, content, byteRange string) error {
+	start, end := 0, len(content)
+	if byteRange != "" {
+		var err error
+		if start, end, err = parseByteRange(byteRange, len(content)); err != nil {
+			return err
+		}
+	}
+	if _, err := io.WriteString(w, content[start:end]); err != nil {
+		return fmt.Errorf("failed to write content: %w", err)
+	}
 	return nil
 }
+
+// parseByteRange parses a start:end byte range, end exclusive, clamped to
+// size. An empty start means 0 and an empty end means size.
+func parseByteRange(spec string, size int) (int, int, error) {
+	startSpec, endSpec, ok := strings.Cut(spec, ":")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid --bytes %q (format: start:end)", spec)
+	}
+
+	bound := func(value string, fallback int) (int, error) {
+		if value == "" {
+			return fallback, nil
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid --bytes %q: offsets must be non-negative integers", spec)
+		}
+		return n, nil
+	}
+	start, err := bound(startSpec, 0)
+	if err != nil {
+		return 0, 0, err
+	}
+	end, err := bound(endSpec, size)
+	if err != nil {
+		return 0, 0, err
+	}
+	if start > end {
+		return 0, 0, fmt.Errorf("invalid --bytes %q: start is after end", spec)
+	}
+	return min(start, size), min(end, size), nil
+}
@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/providers"
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+)
+
+// progressInterval is how often --progress updates its count
+const progressInterval = 200 * time.Millisecond
+
+// streamGetList writes the memories get lists to w as JSON lines, each as
+// soon as it's read rather than once every memory is loaded and sorted, so
+// output from large stores starts straight away. Memories are written in
+// the order storage holds them. When progress isn't nil, a count of the
+// memories read is kept up to date on it.
+func streamGetList(ctx context.Context, fs providers.StorageProvider, outputOpts OutputOptions, w, progress io.Writer) error {
+	includeContent := getIncludeContent && needsContent(outputOpts.Fields)
+	var searchReq storage.SearchRequest
+	if getFiltering() {
+		var err error
+		if searchReq, err = getSearchRequest(includeContent); err != nil {
+			return err
+		}
+	}
+	// The index doesn't hold metadata, so matching it loads the memories
+	loadContent := includeContent || len(searchReq.MetadataSelector) > 0
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	memories := make(chan storage.Memory)
+	streamErr := make(chan error, 1)
+	go func() {
+		listOpts := storage.ListOptions{
+			IncludeContent: loadContent,
+			UseIndex:       !getNoIndex,
+		}
+		streamErr <- providers.StreamMemories(ctx, fs, listOpts, memories)
+	}()
+
+	counter := newProgressCounter(progress)
+	now := time.Now()
+	var writeErr error
+	for memory := range memories {
+		counter.add()
+		// Keep draining after a failed write so the stream can stop
+		if writeErr != nil {
+			continue
+		}
+		if !storage.MatchesSearch(memory, searchReq) || (!getExpired && memory.IsExpired(now)) {
+			continue
+		}
+		if loadContent && !includeContent {
+			memory.Content = ""
+		}
+		line, err := formatMemoryLine(&memory, outputOpts)
+		if err != nil {
+			writeErr = fmt.Errorf("failed to format output: %w", err)
+		} else if _, err := io.WriteString(w, line); err != nil {
+			writeErr = fmt.Errorf("failed to write output: %w", err)
+		}
+		if writeErr != nil {
+			cancel()
+		}
+	}
+	counter.done()
+
+	err := <-streamErr
+	if writeErr != nil {
+		return writeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to list memories: %w", err)
+	}
+	return nil
+}
+
+// progressCounter keeps a count of the memories read on one line of w,
+// redrawing it at most every progressInterval
+type progressCounter struct {
+	w     io.Writer
+	count int
+	drawn time.Time
+}
+
+// newProgressCounter returns a counter drawing on w, or one that draws
+// nothing if w is nil. Its first count is drawn after progressInterval, so
+// quick listings only show the final count.
+func newProgressCounter(w io.Writer) *progressCounter {
+	return &progressCounter{w: w, drawn: time.Now()}
+}
+
+// add counts another memory read
+func (p *progressCounter) add() {
+	p.count++
+	if p.w == nil || time.Since(p.drawn) < progressInterval {
+		return
+	}
+	p.drawn = time.Now()
+	fmt.Fprintf(p.w, "\rRead %d memories", p.count)
+}
+
+// done draws the final count and ends its line
+func (p *progressCounter) done() {
+	if p.w != nil {
+		fmt.Fprintf(p.w, "\rRead %d memories\n", p.count)
+	}
+}
@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/providers"
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+)
+
+// slowStreamer streams one memory, then holds the rest back until release
+// is closed, as a large store would while its files are still being read
+type slowStreamer struct {
+	providers.StorageProvider
+	memories []storage.Memory
+	release  chan struct{}
+}
+
+func (s *slowStreamer) StreamMemories(ctx context.Context, opts storage.ListOptions, out chan<- storage.Memory) error {
+	defer close(out)
+	for i, memory := range s.memories {
+		if i == 1 {
+			select {
+			case <-s.release:
+			case <-time.After(5 * time.Second):
+			}
+		}
+		select {
+		case out <- memory:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// firstWriteRecorder records what's written, closing written on the first
+// write
+type firstWriteRecorder struct {
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	written chan struct{}
+}
+
+func (w *firstWriteRecorder) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.buf.Len() == 0 {
+		close(w.written)
+	}
+	return w.buf.Write(p)
+}
+
+func TestStreamGetListWritesBeforeReadingAll(t *testing.T) {
+	fs := &slowStreamer{
+		memories: []storage.Memory{
+			{ID: "mem_1", Name: "First"},
+			{ID: "mem_2", Name: "Second"},
+			{ID: "mem_3", Name: "Third"},
+		},
+		release: make(chan struct{}),
+	}
+	out := &firstWriteRecorder{written: make(chan struct{})}
+	var progress bytes.Buffer
+
+	done := make(chan error, 1)
+	go func() {
+		done <- streamGetList(context.Background(), fs, OutputOptions{Format: OutputFormatJSONL}, out, &progress)
+	}()
+
+	select {
+	case <-out.written:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the first memory written before the rest were read")
+	}
+	close(fs.release)
+	if err := <-done; err != nil {
+		t.Fatalf("Failed to stream memories: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(out.buf.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 lines, got %q", out.buf.String())
+	}
+	for i, line := range lines {
+		var doc struct {
+			Metadata struct{ Name string } `json:"metadata"`
+		}
+		if err := json.Unmarshal([]byte(line), &doc); err != nil {
+			t.Fatalf("Expected a JSON document per line, got %q: %v", line, err)
+		}
+		if doc.Metadata.Name != fs.memories[i].Name {
+			t.Errorf("Expected line %d to be %q, got %q", i, fs.memories[i].Name, line)
+		}
+	}
+	if !strings.HasSuffix(progress.String(), "Read 3 memories\n") {
+		t.Errorf("Expected the final count on stderr, got %q", progress.String())
+	}
+}
+
+func TestStreamGetListFilters(t *testing.T) {
+	fs := newTestStorage(t)
+	defer func() { getLabels, getIncludeContent = "", true }()
+	for _, req := range []storage.CreateMemoryRequest{
+		{Name: "Standup", Content: "notes", Labels: map[string]string{"type": "meeting"}},
+		{Name: "Ideas", Content: "more notes", Labels: map[string]string{"type": "notes"}},
+		{Name: "Retro", Content: "actions", Labels: map[string]string{"type": "meeting"}},
+	} {
+		if _, err := fs.Create(req); err != nil {
+			t.Fatalf("Failed to create memory: %v", err)
+		}
+	}
+
+	getLabels, getIncludeContent = "type=meeting", false
+	var out bytes.Buffer
+	if err := streamGetList(context.Background(), fs, OutputOptions{Format: OutputFormatJSONL}, &out, nil); err != nil {
+		t.Fatalf("Failed to stream memories: %v", err)
+	}
+	output := out.String()
+	if strings.Count(output, "\n") != 2 || !strings.Contains(output, "Standup") || !strings.Contains(output, "Retro") {
+		t.Errorf("Expected both meetings, one per line, got %q", output)
+	}
+	if strings.Contains(output, "Ideas") || strings.Contains(output, "actions") {
+		t.Errorf("Expected other memories and content left out, got %q", output)
+	}
+}
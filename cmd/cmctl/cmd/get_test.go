@@ -0,0 +1,217 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+)
+
+func TestWriteRawContent(t *testing.T) {
+	// Content that JSON escaping would alter: quotes, backslashes, HTML,
+	// control characters, CRLF, multi-byte runes and no trailing newline
+	source := filepath.Join(t.TempDir(), "notes.txt")
+	data := []byte("say \"hi\" \\n <b>&</b>\r\n\ttab\x01\x7f ünïcödé 🎉 end")
+	if err := os.WriteFile(source, data, 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	content, err := readContentFile(source, nil)
+	if err != nil {
+		t.Fatalf("Failed to read content file: %v", err)
+	}
+
+	fs := newTestStorage(t)
+	created, err := fs.Create(storage.CreateMemoryRequest{Name: "raw", Content: content})
+	if err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+	memory, err := fs.Get(created.ID)
+	if err != nil {
+		t.Fatalf("Failed to get memory: %v", err)
+	}
+
+	tests := []struct {
+		byteRange string
+		want      []byte
+	}{
+		{"", data},
+		{"0:10", data[:10]},
+		{"10:", data[10:]},
+		{":5", data[:5]},
+		{"3:3", []byte{}},
+		{"0:100000", data}, // Clamped to the content
+	}
+	for _, tt := range tests {
+		t.Run(tt.byteRange, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := writeRawContent(&out, memory.Content, tt.byteRange); err != nil {
+				t.Fatalf("writeRawContent failed: %v", err)
+			}
+			if !bytes.Equal(out.Bytes(), tt.want) {
+				t.Errorf("Expected %q, got %q", tt.want, out.Bytes())
+			}
+		})
+	}
+
+	for _, spec := range []string{"10", "a:b", "-1:5", "5:2"} {
+		if err := writeRawContent(&bytes.Buffer{}, memory.Content, spec); err == nil {
+			t.Errorf("Expected an error for --bytes %q", spec)
+		}
+	}
+}
+
+func TestValidateRawContentFlags(t *testing.T) {
+	defer func() { getLabels, getRelated, getWatch = "", false, false }()
+
+	if err := validateRawContentFlags(nil); err == nil {
+		t.Error("Expected an error without a memory ID")
+	}
+	if err := validateRawContentFlags([]string{"mem_1"}); err != nil {
+		t.Errorf("Expected a single memory ID to be accepted, got %v", err)
+	}
+	getLabels = "type=notes"
+	if err := validateRawContentFlags([]string{"mem_1"}); err == nil {
+		t.Error("Expected an error with --labels")
+	}
+	getLabels, getWatch = "", true
+	if err := validateRawContentFlags([]string{"mem_1"}); err == nil {
+		t.Error("Expected an error with --watch")
+	}
+}
+
+func TestGetFirstLast(t *testing.T) {
+	fs := newTestStorage(t)
+	defer func() { getLabels, getFirst, getLast = "", false, false }()
+
+	for _, req := range []storage.CreateMemoryRequest{
+		{Name: "Oldest meeting", Content: "first", Labels: map[string]string{"type": "meeting"}},
+		{Name: "Newest meeting", Content: "second", Labels: map[string]string{"type": "meeting"}},
+		{Name: "Newer notes", Content: "third", Labels: map[string]string{"type": "notes"}},
+	} {
+		if _, err := fs.Create(req); err != nil {
+			t.Fatalf("Failed to create memory: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	tests := []struct {
+		labels      string
+		first, last bool
+		want        string
+	}{
+		{last: true, want: "Newer notes"},
+		{first: true, want: "Oldest meeting"},
+		{labels: "type=meeting", last: true, want: "Newest meeting"},
+		{labels: "type=meeting", first: true, want: "Oldest meeting"},
+	}
+	for _, tt := range tests {
+		getLabels, getFirst, getLast = tt.labels, tt.first, tt.last
+		output, id, err := renderGetSelected(fs, OutputOptions{Format: OutputFormatJSON})
+		if err != nil {
+			t.Fatalf("Failed to get memory: %v", err)
+		}
+		memory, err := findMemoryByName(fs, tt.want)
+		if err != nil || memory == nil {
+			t.Fatalf("Failed to find memory: %v", err)
+		}
+		if id != memory.ID || !strings.Contains(output, tt.want) {
+			t.Errorf("labels=%q first=%v last=%v: expected %q, got %s", tt.labels, tt.first, tt.last, tt.want, output)
+		}
+	}
+
+	getLabels, getFirst, getLast = "type=missing", false, true
+	if _, _, err := renderGetSelected(fs, OutputOptions{Format: OutputFormatJSON}); err == nil {
+		t.Error("Expected an error when no memory matches")
+	}
+}
+
+func TestValidateFirstLastFlags(t *testing.T) {
+	defer func() { getFirst, getLast, getRelated = false, false, false }()
+
+	getFirst, getLast = true, true
+	if err := validateFirstLastFlags(nil); err == nil {
+		t.Error("Expected an error with --first and --last")
+	}
+	getFirst = false
+	if err := validateFirstLastFlags(nil); err != nil {
+		t.Errorf("Expected --last alone to be accepted, got %v", err)
+	}
+	if err := validateFirstLastFlags([]string{"mem_1"}); err == nil {
+		t.Error("Expected an error with a memory ID")
+	}
+}
+
+func TestMemoryFieldValue(t *testing.T) {
+	memory := &storage.Memory{
+		ID:      "mem_1",
+		Name:    "Notes",
+		Content: "line one\nline two\n",
+		Labels:  map[string]string{"language": "go", "app.kubernetes.io/name": "cmctl"},
+		Metadata: map[string]any{
+			"summary": map[string]any{"model": "gpt-4o", "tokens": float64(350)},
+			"files":   []any{"main.go", "go.mod"},
+		},
+		Relations: []storage.Relation{{Type: "relates-to", TargetID: "mem_2"}},
+	}
+
+	tests := []struct {
+		field string
+		want  string
+	}{
+		{field: "content", want: "line one\nline two\n"},
+		{field: ".name", want: "Notes\n"},
+		{field: "labels.language", want: "go\n"},
+		{field: "labels.app.kubernetes.io/name", want: "cmctl\n"},
+		{field: "labels", want: "app.kubernetes.io/name=cmctl\nlanguage=go\n"},
+		{field: "metadata.summary.tokens", want: "350\n"},
+		{field: "metadata.summary", want: "model=gpt-4o\ntokens=350\n"},
+		{field: "metadata.files", want: "main.go\ngo.mod\n"},
+		{field: "relations.0.targetId", want: "mem_2\n"},
+		{field: "relations", want: `{"targetId":"mem_2","type":"relates-to"}` + "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			output, err := FormatSingleMemory(memory, OutputOptions{Format: OutputFormatTable, Field: tt.field})
+			if err != nil {
+				t.Fatalf("Failed to get field: %v", err)
+			}
+			if output != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, output)
+			}
+		})
+	}
+
+	for _, field := range []string{"labels.missing", "spec.content", "metadata.files.2", "name.first", ""} {
+		if _, err := memoryFieldValue(memory, field); err == nil {
+			t.Errorf("Expected an error for missing field %q", field)
+		}
+	}
+}
+
+func TestValidateFieldFlags(t *testing.T) {
+	defer func() { getField, getLabels, getLast, getOutputFlag = "", "", false, "" }()
+
+	getField = "content"
+	if err := validateFieldFlags([]string{"mem_1"}); err != nil {
+		t.Errorf("Expected a memory ID to be accepted, got %v", err)
+	}
+	if err := validateFieldFlags(nil); err == nil {
+		t.Error("Expected an error without a single memory")
+	}
+	getLabels = "type=notes"
+	if err := validateFieldFlags([]string{"mem_1"}); err == nil {
+		t.Error("Expected an error when listing with --labels")
+	}
+	getLast = true
+	if err := validateFieldFlags(nil); err != nil {
+		t.Errorf("Expected --last to be accepted, got %v", err)
+	}
+	getOutputFlag = "json"
+	if err := validateFieldFlags(nil); err == nil {
+		t.Error("Expected an error with --output")
+	}
+}
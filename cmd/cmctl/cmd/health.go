@@ -3,9 +3,7 @@ package cmd
 import (
 	"fmt"
 
-	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
 var healthCmd = &cobra.Command{
@@ -24,14 +22,13 @@ func init() {
 
 func runHealth(cmd *cobra.Command, args []string) error {
 	// Initialize storage
-	storageDir := viper.GetString("storage-dir")
-	fs, err := storage.NewFileStorage(storageDir)
+	fs, err := getStorageProvider()
 	if err != nil {
 		return fmt.Errorf("failed to initialize storage: %w", err)
 	}
 
 	// Check health
-	if err := fs.Health(); err != nil {
+	if err := fs.ValidateConfig(); err != nil {
 		fmt.Printf("Storage health: Unhealthy\n")
 		if !IsQuiet() {
 			fmt.Printf("Error: %v\n", err)
@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/chatgpt"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	chatgptFile       string
+	chatgptID         string
+	chatgptUpdate     bool
+	chatgptForce      bool
+	chatgptSince      string
+	chatgptAtomic     bool
+	chatgptQuiet      bool
+	chatgptTimestamps bool
+	chatgptNoRedact   bool
+)
+
+var importChatGPTCmd = &cobra.Command{
+	Use:   "import-chatgpt",
+	Short: "Import conversations from a ChatGPT data export",
+	Long: `Import conversations from the conversations.json file of a ChatGPT data
+export (Settings > Data controls > Export data) into ContextMemory.
+
+Each conversation becomes a chat memory labeled source=chatgpt. Where a
+prompt was edited or a reply regenerated, the branch that was last shown in
+ChatGPT is imported. Conversations that were already imported are skipped,
+so the command is safe to run on each new export.
+
+Examples:
+  # Import every conversation in the export
+  cmctl import-chatgpt --file conversations.json
+
+  # Only conversations updated in the last month
+  cmctl import-chatgpt --file conversations.json --since 30d
+
+  # Import or refresh a single conversation
+  cmctl import-chatgpt --file conversations.json --id 6710c0de-... --update`,
+	Args: cobra.NoArgs,
+	RunE: runImportChatGPT,
+}
+
+func init() {
+	rootCmd.AddCommand(importChatGPTCmd)
+
+	importChatGPTCmd.Flags().StringVar(&chatgptFile, "file", "", "Path to conversations.json from a ChatGPT data export (required)")
+	importChatGPTCmd.Flags().StringVar(&chatgptID, "id", "", "Import only the conversation with this ID")
+	importChatGPTCmd.Flags().BoolVar(&chatgptUpdate, "update", false, "Refresh the content of conversations that were already imported")
+	importChatGPTCmd.Flags().BoolVar(&chatgptForce, "force", false, "Import conversations even if they were already imported")
+	importChatGPTCmd.Flags().StringVar(&chatgptSince, "since", "", "Only import conversations updated after this (YYYY-MM-DD, RFC3339, or relative like 3d)")
+	importChatGPTCmd.Flags().BoolVar(&chatgptAtomic, "atomic", false, "Import every conversation or none: a failure rolls back the ones already imported")
+	importChatGPTCmd.Flags().BoolVarP(&chatgptQuiet, "quiet", "q", false, "Print only the number of conversations imported, or with --id the memory ID")
+	importChatGPTCmd.Flags().BoolVar(&chatgptTimestamps, "with-timestamps", false, "Include each message's time in the imported content")
+	importChatGPTCmd.Flags().BoolVar(&chatgptNoRedact, "no-redact", false, "Store conversations without redacting secrets")
+	if err := importChatGPTCmd.MarkFlagRequired("file"); err != nil {
+		panic(fmt.Sprintf("failed to mark file flag required: %v", err))
+	}
+}
+
+func runImportChatGPT(cmd *cobra.Command, args []string) error {
+	if chatgptUpdate && chatgptForce {
+		return fmt.Errorf("--update and --force are mutually exclusive")
+	}
+	if chatgptID != "" && (chatgptSince != "" || chatgptAtomic) {
+		return fmt.Errorf("--since and --atomic can't be used with --id")
+	}
+
+	opts := importOptions{
+		Update:         chatgptUpdate,
+		Force:          chatgptForce,
+		WithTimestamps: chatgptTimestamps,
+		NameTemplate:   viper.GetString(chatNameTemplateKey),
+		Atomic:         chatgptAtomic,
+	}
+	if !chatgptNoRedact {
+		var err error
+		if opts.Redactor, err = importRedactor(); err != nil {
+			return err
+		}
+	}
+	if err := validateChatNameTemplate(opts.NameTemplate); err != nil {
+		return err
+	}
+
+	reader := chatgpt.NewExportReader(chatgptFile)
+	provider, err := getStorageProvider()
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+
+	if chatgptID != "" {
+		chat, _, err := reader.GetChatByID(chatgptID)
+		if err != nil {
+			return err
+		}
+		memory, action, err := importChat(provider, chat, opts)
+		if err != nil {
+			return err
+		}
+		if chatgptQuiet {
+			fmt.Println(memory.ID)
+			return nil
+		}
+		switch action {
+		case importActionSkipped:
+			fmt.Printf("Conversation already imported as memory %s (use --update to refresh or --force to import again)\n", memory.ID)
+		case importActionUpdated:
+			fmt.Printf("Updated memory %s (%s)\n", memory.ID, memory.Name)
+		default:
+			fmt.Printf("Imported conversation as memory %s (%s)\n", memory.ID, memory.Name)
+		}
+		return nil
+	}
+
+	var since time.Time
+	if chatgptSince != "" {
+		if since, err = parseTimeSpec(chatgptSince, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	// Index updates are written once at the end, and with --atomic a
+	// failure undoes the whole import
+	var summary importSummary
+	err = inBatch(provider, opts.Atomic, func() error {
+		summary, err = importAllChats(reader, provider, opts, since)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if chatgptQuiet {
+		fmt.Println(summary.Imported)
+		return nil
+	}
+	printImportSummary(summary)
+	return nil
+}
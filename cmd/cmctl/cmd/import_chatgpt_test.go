@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/chatgpt"
+)
+
+func TestImportChatGPTExport(t *testing.T) {
+	fs := newTestStorage(t)
+	reader := chatgpt.NewExportReader("../internal/chatgpt/testdata/conversations.json")
+
+	summary, err := importAllChats(reader, fs, importOptions{}, time.Time{})
+	if err != nil {
+		t.Fatalf("importAllChats failed: %v", err)
+	}
+	if summary.Imported != 2 {
+		t.Errorf("Expected 2 conversations imported, got %+v", summary)
+	}
+
+	memory, err := findImportedChat(fs, "conv-tokens")
+	if err != nil || memory == nil {
+		t.Fatalf("Expected conv-tokens to be imported (err=%v)", err)
+	}
+	if memory.Labels["source"] != chatgpt.Source {
+		t.Errorf("Expected source label %s, got %s", chatgpt.Source, memory.Labels["source"])
+	}
+
+	stored, err := fs.Get(memory.ID)
+	if err != nil {
+		t.Fatalf("Failed to get memory: %v", err)
+	}
+	first := strings.Index(stored.Content, "Should refresh tokens be rotated")
+	last := strings.Index(stored.Content, "Revoke the whole token family")
+	if first < 0 || last < first {
+		t.Errorf("Expected the transcript in order, got: %s", stored.Content)
+	}
+	if strings.Contains(stored.Content, "discarded") {
+		t.Errorf("Expected the abandoned branch to be left out, got: %s", stored.Content)
+	}
+
+	// Importing the same export again is a no-op
+	summary, err = importAllChats(reader, fs, importOptions{}, time.Time{})
+	if err != nil {
+		t.Fatalf("importAllChats failed: %v", err)
+	}
+	if summary.Imported != 0 || summary.Skipped != 2 {
+		t.Errorf("Expected both conversations skipped on re-import, got %+v", summary)
+	}
+}
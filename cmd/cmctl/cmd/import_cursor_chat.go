@@ -2,10 +2,12 @@ package cmd
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/cursor"
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/providers"
 	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -14,12 +16,69 @@ import (
 )
 
 var (
-	importLatest    bool
-	importTabID     string
-	importWorkspace string
-	importPreview   bool
+	importLatest     bool
+	importTabID      string
+	importWorkspace  string
+	importPreview    bool
+	importUpdate     bool
+	importForce      bool
+	importAll        bool
+	importSince      string
+	importSource     string
+	importTimestamps bool
+	importNoRedact   bool
+	importSummarize  string
+	importSumTimeout time.Duration
+	importQuiet      bool
+	importProject    string
+	importAtomic     bool
+	importNoMerge    bool
 )
 
+// cursorChatIDLabel records the source Cursor chat ID on imported memories
+const cursorChatIDLabel = "cursor-chat-id"
+
+// importAction describes the outcome of importing a single chat
+type importAction string
+
+const (
+	importActionCreated importAction = "created"
+	importActionUpdated importAction = "updated"
+	importActionSkipped importAction = "skipped"
+)
+
+// importOptions controls how chats are rendered and how already-imported
+// chats are handled
+type importOptions struct {
+	Update         bool // Refresh content of an already-imported chat
+	Force          bool // Import again even if the chat was already imported
+	WithTimestamps bool // Tag each message with its time in the content
+	// Redactor masks secrets in the imported name and content when set
+	Redactor *redactor
+	// SummarizeCmd is a shell command that turns the chat content on stdin
+	// into a summary on stdout, stored in the memory's metadata
+	SummarizeCmd     string
+	SummarizeTimeout time.Duration
+	// NameTemplate, when set, names the memory from placeholders such as
+	// {title} and {date} instead of the generated name
+	NameTemplate string
+	// Workspace is the workspace the chat was read from, for {workspace}
+	Workspace string
+	// Atomic stops a bulk import at the first failure, so the chats
+	// imported before it can be rolled back
+	Atomic bool
+}
+
+// importSummary counts the outcomes of a bulk import
+type importSummary struct {
+	Imported int
+	Updated  int
+	Skipped  int
+	Empty    int
+	Failed   int
+	Redacted int
+}
+
 // importCursorChatCmd represents the import-cursor-chat command
 var importCursorChatCmd = &cobra.Command{
 	Use:   "import-cursor-chat",
@@ -40,7 +99,52 @@ Examples:
   cmctl import-cursor-chat --preview
 
   # Import from specific workspace
-  cmctl import-cursor-chat --latest --workspace /path/to/state.vscdb`,
+  cmctl import-cursor-chat --latest --workspace /path/to/state.vscdb
+
+  # Import the latest chat from the current directory's project
+  cmctl import-cursor-chat --latest --project
+
+  # Import every chat, or only chats from the last week
+  cmctl import-cursor-chat --all
+  cmctl import-cursor-chat --all --since 7d
+
+  # Import every chat or, if any fails, none of them
+  cmctl import-cursor-chat --all --atomic
+
+  # Name imported chats by date and language, e.g. chat/2025-09-01/go
+  cmctl import-cursor-chat --all --name-template "chat/{date}/{lang}"
+
+  # Import GitHub Copilot chats from VS Code instead of Cursor
+  cmctl import-cursor-chat --latest --source vscode
+
+Chats that were already imported (tracked via the cursor-chat-id label) are
+skipped by default, so the command is safe to run repeatedly:
+
+  # Refresh the content of an already-imported chat
+  cmctl import-cursor-chat --latest --update
+
+  # Import a duplicate copy regardless
+  cmctl import-cursor-chat --latest --force
+
+A conversation that Cursor recorded in several parts, such as one continued
+after a restart, is imported as one chat. Use --no-merge to import each part
+separately:
+
+  cmctl import-cursor-chat --all --no-merge
+
+Secrets such as AWS keys, bearer tokens, sk- API keys and private key
+blocks are replaced with [REDACTED] before the chat is stored. Add patterns
+with --redact-pattern or the redact-patterns config list, or turn redaction
+off with --no-redact:
+
+  cmctl import-cursor-chat --latest --redact-pattern 'corp_[a-z0-9]{32}'
+  cmctl import-cursor-chat --latest --no-redact
+
+Pipe each imported chat through a command, such as an LLM CLI, to store a
+summary in the memory's metadata. A command that fails or times out only
+skips the summary:
+
+  cmctl import-cursor-chat --latest --summarize-cmd "llm -s 'Summarize this chat'"`,
 	RunE: runImportCursorChat,
 }
 
@@ -49,75 +153,320 @@ func init() {
 
 	importCursorChatCmd.Flags().BoolVar(&importLatest, "latest", false, "Import the most recent chat")
 	importCursorChatCmd.Flags().StringVar(&importTabID, "tab-id", "", "Import specific chat by tab ID")
-	importCursorChatCmd.Flags().StringVar(&importWorkspace, "workspace", "", "Path to specific workspace database")
+	importCursorChatCmd.Flags().StringVar(&importWorkspace, "workspace", "", "Path to a workspace database, workspace directory, or workspaceStorage root")
+	importCursorChatCmd.Flags().StringVar(&importProject, "project", "", "Only read chats from the workspace opened on this project folder (--project=<path>; the current directory if no path is given)")
+	importCursorChatCmd.Flags().Lookup("project").NoOptDefVal = projectFlagCurrent
 	importCursorChatCmd.Flags().BoolVar(&importPreview, "preview", false, "Preview available chats without importing")
+	importCursorChatCmd.Flags().BoolVar(&importUpdate, "update", false, "Refresh the content of a chat that was already imported")
+	importCursorChatCmd.Flags().BoolVar(&importForce, "force", false, "Import the chat even if it was already imported")
+	importCursorChatCmd.Flags().BoolVar(&importAll, "all", false, "Import every chat across workspaces")
+	importCursorChatCmd.Flags().BoolVar(&importTimestamps, "with-timestamps", false, "Include each message's time in the imported content")
+	importCursorChatCmd.Flags().StringVar(&importSource, "source", chatSourceCursor, "Editor to read chats from (cursor, vscode)")
+	importCursorChatCmd.Flags().BoolVar(&importNoMerge, "no-merge", false, "Keep each recorded part of a continued conversation as a separate chat")
+	importCursorChatCmd.Flags().BoolVar(&importAtomic, "atomic", false, "With --all, import every chat or none: a failure rolls back the chats already imported")
+	importCursorChatCmd.Flags().StringVar(&importSince, "since", "", "With --all, only import chats newer than this (YYYY-MM-DD, RFC3339, or relative like 3d)")
+	importCursorChatCmd.Flags().BoolVarP(&importQuiet, "quiet", "q", false, "Print only the memory ID, or with --all the number of chats imported")
+	importCursorChatCmd.Flags().String("name-template", "", "Name imported memories from a template using {title}, {date}, {lang}, {activity} and {workspace}")
+	importCursorChatCmd.Flags().StringVar(&importSummarize, "summarize-cmd", "", "Shell command that reads the chat on stdin and prints a summary to store in metadata")
+	importCursorChatCmd.Flags().DurationVar(&importSumTimeout, "summarize-timeout", defaultSummarizeTimeout, "How long --summarize-cmd may run per chat")
+	importCursorChatCmd.Flags().Bool("redact", true, "Replace secrets such as API keys and private keys with [REDACTED]")
+	importCursorChatCmd.Flags().BoolVar(&importNoRedact, "no-redact", false, "Store chats without redacting secrets")
+	importCursorChatCmd.Flags().StringArray("redact-pattern", nil, "Additional regular expression to redact (repeatable; a group named 'secret' limits the mask to that group)")
+
+	if err := viper.BindPFlag("redact", importCursorChatCmd.Flags().Lookup("redact")); err != nil {
+		panic(fmt.Sprintf("failed to bind redact flag: %v", err))
+	}
+	if err := viper.BindPFlag(chatNameTemplateKey, importCursorChatCmd.Flags().Lookup("name-template")); err != nil {
+		panic(fmt.Sprintf("failed to bind name-template flag: %v", err))
+	}
+	if err := viper.BindPFlag("redact-patterns", importCursorChatCmd.Flags().Lookup("redact-pattern")); err != nil {
+		panic(fmt.Sprintf("failed to bind redact-pattern flag: %v", err))
+	}
+}
+
+// importRedactor returns the redactor for the redact settings, or nil when
+// redaction is turned off
+func importRedactor() (*redactor, error) {
+	if importNoRedact || !viper.GetBool("redact") {
+		return nil, nil
+	}
+	return newRedactor(viper.GetStringSlice("redact-patterns"))
 }
 
 func runImportCursorChat(cmd *cobra.Command, args []string) error {
 	// Initialize workspace reader
-	var reader *cursor.WorkspaceReader
-	if importWorkspace != "" {
-		reader = cursor.NewWorkspaceReaderWithPath(importWorkspace)
-	} else {
-		reader = cursor.NewWorkspaceReader()
+	reader, err := newChatReader(importSource, importWorkspace, importProject, importNoMerge)
+	if err != nil {
+		return err
 	}
 
 	if importPreview {
-		return previewCursorChats(reader)
+		return previewCursorChats(reader, chatSourceName(importSource))
+	}
+
+	if !importLatest && importTabID == "" && !importAll {
+		return fmt.Errorf("must specify --latest, --tab-id, or --all")
 	}
+	if importUpdate && importForce {
+		return fmt.Errorf("--update and --force are mutually exclusive")
+	}
+	if importSince != "" && !importAll {
+		return fmt.Errorf("--since can only be used with --all")
+	}
+	if importAtomic && !importAll {
+		return fmt.Errorf("--atomic can only be used with --all")
+	}
+
+	opts := importOptions{
+		Update:           importUpdate,
+		Force:            importForce,
+		WithTimestamps:   importTimestamps,
+		SummarizeCmd:     importSummarize,
+		SummarizeTimeout: importSumTimeout,
+		NameTemplate:     viper.GetString(chatNameTemplateKey),
+		Atomic:           importAtomic,
+	}
+	if opts.Redactor, err = importRedactor(); err != nil {
+		return err
+	}
+	if err := validateChatNameTemplate(opts.NameTemplate); err != nil {
+		return err
+	}
+
+	if importAll {
+		var since time.Time
+		if importSince != "" {
+			since, err = parseTimeSpec(importSince, time.Now())
+			if err != nil {
+				return err
+			}
+		}
+
+		provider, err := getStorageProvider()
+		if err != nil {
+			return fmt.Errorf("failed to initialize storage: %w", err)
+		}
+
+		// Index updates are written once at the end, and with --atomic a
+		// failure undoes the whole import
+		var summary importSummary
+		err = inBatch(provider, opts.Atomic, func() error {
+			summary, err = importAllChats(reader, provider, opts, since)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		if importQuiet {
+			fmt.Println(summary.Imported)
+			return nil
+		}
 
-	if !importLatest && importTabID == "" {
-		return fmt.Errorf("must specify either --latest or --tab-id")
+		printImportSummary(summary)
+		return nil
 	}
 
 	var chatTab *cursor.ChatTab
-	var err error
 
+	var workspacePath string
 	if importLatest {
 		chatTab, err = reader.GetLatestChat()
 		if err != nil {
 			return fmt.Errorf("failed to get latest chat: %w", err)
 		}
+		// The latest Cursor chat comes from the latest workspace
+		if wr, ok := reader.(*cursor.WorkspaceReader); ok {
+			workspacePath, _ = wr.GetLatestWorkspace()
+		}
 	} else {
-		chatTab, _, err = reader.GetChatByID(importTabID)
+		chatTab, workspacePath, err = reader.GetChatByID(importTabID)
 		if err != nil {
 			return fmt.Errorf("failed to get chat by ID: %w", err)
 		}
 	}
-
-	// Convert chat to memory format
-	memory := convertChatToMemory(chatTab)
+	opts.Workspace = chatWorkspaceName(workspacePath)
 
 	// Initialize storage
-	storageDir := viper.GetString("storage-dir")
-	provider, err := storage.NewFileStorage(storageDir)
+	provider, err := getStorageProvider()
 	if err != nil {
 		return fmt.Errorf("failed to initialize storage: %w", err)
 	}
 
-	// Create the memory
-	createdMemory, err := provider.Create(memory)
+	memory, action, err := importChat(provider, chatTab, opts)
 	if err != nil {
-		return fmt.Errorf("failed to create memory: %w", err)
+		return err
+	}
+	if importQuiet {
+		fmt.Println(memory.ID)
+		return nil
 	}
 
-	fmt.Printf("Successfully imported chat as memory:\n")
-	fmt.Printf("ID: %s\n", createdMemory.ID)
-	fmt.Printf("Name: %s\n", createdMemory.Name)
-	fmt.Printf("Labels: %v\n", createdMemory.Labels)
-	fmt.Printf("Content: %d characters\n", len(createdMemory.Content))
+	switch action {
+	case importActionSkipped:
+		fmt.Printf("Chat already imported as memory %s (use --update to refresh or --force to import again)\n", memory.ID)
+		return nil
+	case importActionUpdated:
+		fmt.Printf("Successfully updated imported chat memory:\n")
+	default:
+		fmt.Printf("Successfully imported chat as memory:\n")
+	}
+	fmt.Printf("ID: %s\n", memory.ID)
+	fmt.Printf("Name: %s\n", memory.Name)
+	fmt.Printf("Labels: %v\n", memory.Labels)
+	fmt.Printf("Content: %d characters\n", len(memory.Content))
+	if opts.Redactor != nil && opts.Redactor.Count() > 0 {
+		fmt.Printf("Redacted: %d secret(s)\n", opts.Redactor.Count())
+	}
+	if summary, ok := memory.Metadata[chatSummaryKey].(string); ok && opts.SummarizeCmd != "" {
+		fmt.Printf("Summary: %d characters\n", len(summary))
+	}
 
 	return nil
 }
 
-func previewCursorChats(reader *cursor.WorkspaceReader) error {
+// importChat stores a chat as a memory, using the cursor-chat-id label to
+// avoid creating duplicates of chats that were already imported
+func importChat(fs providers.StorageProvider, chatTab *cursor.ChatTab, opts importOptions) (*storage.Memory, importAction, error) {
+	req := convertChatToMemory(chatTab, opts)
+
+	if !opts.Force && chatTab.ID != "" {
+		existing, err := findImportedChatTab(fs, chatTab)
+		if err != nil {
+			return nil, "", err
+		}
+
+		if existing != nil {
+			if !opts.Update {
+				return existing, importActionSkipped, nil
+			}
+			addChatSummary(&req, opts)
+
+			updated, err := fs.Update(storage.UpdateMemoryRequest{
+				ID:       existing.ID,
+				Name:     req.Name,
+				Content:  req.Content,
+				Labels:   req.Labels,
+				Metadata: req.Metadata,
+			})
+			if err != nil {
+				return nil, "", fmt.Errorf("failed to update memory: %w", err)
+			}
+			return updated, importActionUpdated, nil
+		}
+	}
+
+	addChatSummary(&req, opts)
+	created, err := fs.Create(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create memory: %w", err)
+	}
+	return created, importActionCreated, nil
+}
+
+// printImportSummary reports the outcome of a bulk import
+func printImportSummary(summary importSummary) {
+	fmt.Printf("Imported %d chat(s), updated %d, skipped %d already imported, %d empty",
+		summary.Imported, summary.Updated, summary.Skipped, summary.Empty)
+	if summary.Failed > 0 {
+		fmt.Printf(", %d failed", summary.Failed)
+	}
+	if summary.Redacted > 0 {
+		fmt.Printf(", %d secret(s) redacted", summary.Redacted)
+	}
+	fmt.Println()
+}
+
+// importAllChats imports every chat the reader can find, skipping chats
+// without real messages and, when since is non-zero, chats older than since
+func importAllChats(reader cursor.ChatReader, fs providers.StorageProvider, opts importOptions, since time.Time) (importSummary, error) {
+	var summary importSummary
+
+	chats, err := reader.ListAllChats()
+	if err != nil {
+		return summary, fmt.Errorf("failed to list chats: %w", err)
+	}
+
+	for i := range chats {
+		chat := chats[i].ChatTab
+
+		if !chatInTimeRange(chat.Timestamp, since, time.Time{}) {
+			continue
+		}
+		if chat.RealMessageCount() == 0 {
+			summary.Empty++
+			continue
+		}
+
+		chatOpts := opts
+		chatOpts.Workspace = chatWorkspaceName(chats[i].WorkspacePath)
+		memory, action, err := importChat(fs, &chat, chatOpts)
+		if err != nil && opts.Atomic {
+			return summary, fmt.Errorf("failed to import chat %s, rolled back the import: %w", chat.ID, err)
+		}
+		if err != nil {
+			summary.Failed++
+			VPrintf(Normal, "Failed to import chat %s: %v\n", chat.ID, err)
+			continue
+		}
+
+		switch action {
+		case importActionCreated:
+			summary.Imported++
+			DebugPrintf("Imported chat %s as %s\n", chat.ID, memory.ID)
+		case importActionUpdated:
+			summary.Updated++
+			DebugPrintf("Updated chat %s in %s\n", chat.ID, memory.ID)
+		case importActionSkipped:
+			summary.Skipped++
+			DebugPrintf("Skipped chat %s (already imported as %s)\n", chat.ID, memory.ID)
+		}
+	}
+
+	if opts.Redactor != nil {
+		summary.Redacted = opts.Redactor.Count()
+	}
+	return summary, nil
+}
+
+// findImportedChat returns the memory previously imported from the given
+// Cursor chat ID, or nil if the chat has not been imported
+func findImportedChat(fs providers.StorageProvider, chatID string) (*storage.Memory, error) {
+	result, err := fs.Search(storage.SearchRequest{
+		LabelSelector:  map[string]string{cursorChatIDLabel: chatID},
+		Limit:          1,
+		UseIndex:       true,
+		IncludeContent: false,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to check for previously imported chat: %w", err)
+	}
+	if len(result.Memories) == 0 {
+		return nil, nil
+	}
+	return &result.Memories[0], nil
+}
+
+// findImportedChatTab returns the memory previously imported from chatTab,
+// also matching the IDs older releases derived for the same chat so those
+// imports are recognized (and relabeled on --update) rather than duplicated
+func findImportedChatTab(fs providers.StorageProvider, chatTab *cursor.ChatTab) (*storage.Memory, error) {
+	for _, id := range append([]string{chatTab.ID}, chatTab.PreviousIDs...) {
+		existing, err := findImportedChat(fs, id)
+		if err != nil || existing != nil {
+			return existing, err
+		}
+	}
+	return nil, nil
+}
+
+func previewCursorChats(reader cursor.ChatReader, sourceName string) error {
 	chats, err := reader.ListAllChats()
 	if err != nil {
 		return fmt.Errorf("failed to list chats: %w", err)
 	}
 
 	if len(chats) == 0 {
-		fmt.Println("No chats found in Cursor workspaces")
+		fmt.Printf("No chats found in %s workspaces\n", sourceName)
 		return nil
 	}
 
@@ -145,20 +494,34 @@ func previewCursorChats(reader *cursor.WorkspaceReader) error {
 	return nil
 }
 
-func convertChatToMemory(chatTab *cursor.ChatTab) storage.CreateMemoryRequest {
-	// Generate intelligent name
-	name := generateChatMemoryName(chatTab)
-
+func convertChatToMemory(chatTab *cursor.ChatTab, opts importOptions) storage.CreateMemoryRequest {
 	// Generate labels based on chat analysis
 	labels := generateChatLabels(chatTab)
 
+	// Generate intelligent name, unless a template names the chat
+	name := generateChatMemoryName(chatTab)
+	if opts.NameTemplate != "" {
+		if rendered := renderChatName(opts.NameTemplate, chatTab, labels, opts.Workspace); rendered != "" {
+			name = rendered
+		}
+	}
+
 	// Convert to markdown content
 	content := chatTab.ToMarkdown()
+	if opts.WithTimestamps {
+		content = chatTab.ToMarkdownWithTimestamps()
+	}
+
+	if opts.Redactor != nil {
+		name = opts.Redactor.Redact(name)
+		content = opts.Redactor.Redact(content)
+	}
 
 	return storage.CreateMemoryRequest{
-		Name:    name,
-		Content: content,
-		Labels:  labels,
+		Name:     name,
+		Content:  content,
+		Labels:   labels,
+		Metadata: map[string]any{storage.ContentTypeKey: storage.ContentTypeMarkdown},
 	}
 }
 
@@ -218,6 +581,14 @@ func generateChatLabels(chatTab *cursor.ChatTab) map[string]string {
 		"type":   "chat",
 		"source": "cursor-ai-pane",
 	}
+	if chatTab.Source != "" {
+		labels["source"] = chatTab.Source
+	}
+
+	// Track the source chat so re-imports can be detected
+	if chatTab.ID != "" {
+		labels[cursorChatIDLabel] = chatTab.ID
+	}
 
 	// Add date
 	if chatTab.Timestamp > 0 {
@@ -228,39 +599,65 @@ func generateChatLabels(chatTab *cursor.ChatTab) map[string]string {
 	// Add technical concepts as labels
 	concepts := chatTab.ExtractTechnicalConcepts()
 	if len(concepts) > 0 {
-		labels["language"] = concepts[0] // Primary language/concept
+		labels["language"] = sanitizeLabelValue(concepts[0]) // Primary language/concept
 		if len(concepts) > 1 {
-			labels["technologies"] = strings.Join(concepts[:3], ",") // Up to 3 technologies
+			labels["technologies"] = joinLabelValues(concepts[:min(len(concepts), 3)]) // Up to 3 technologies
 		}
 	}
 
-	// Analyze activity type
-	content := strings.ToLower(chatTab.ToMarkdown())
-	activityPatterns := map[string]string{
-		"debug":     "debugging",
-		"error":     "debugging",
-		"implement": "implementation",
-		"create":    "implementation",
-		"build":     "implementation",
-		"review":    "code-review",
-		"refactor":  "refactoring",
-		"optimize":  "optimization",
-		"test":      "testing",
-		"explain":   "learning",
-		"how":       "learning",
-		"what":      "learning",
-	}
-
-	for pattern, activity := range activityPatterns {
-		if strings.Contains(content, pattern) {
-			labels["activity"] = activity
-			break
-		}
+	// Code actually pasted in the chat is a stronger signal than prose
+	if codeLanguages := chatTab.CodeLanguages(); len(codeLanguages) > 0 {
+		labels["language"] = sanitizeLabelValue(codeLanguages[0])
+		labels["code-languages"] = joinLabelValues(codeLanguages)
+	}
+
+	// Analyze activity types; the first match in pattern order is the
+	// primary activity
+	if activities := chatActivities(chatTab); len(activities) > 0 {
+		labels["activity"] = activities[0]
+		labels["activities"] = joinLabelValues(activities)
 	}
 
+	// Detected values vary in spelling (js, javascript), which would split
+	// chats on the same topic across label values
+	labels, _ = normalizeLabels(labels, labelAliases())
 	return labels
 }
 
+// activityPatterns map words in a chat to the activity they suggest, in
+// order of priority
+var activityPatterns = []struct {
+	pattern  string
+	activity string
+}{
+	{"debug", "debugging"},
+	{"error", "debugging"},
+	{"implement", "implementation"},
+	{"create", "implementation"},
+	{"build", "implementation"},
+	{"review", "code-review"},
+	{"refactor", "refactoring"},
+	{"optimize", "optimization"},
+	{"test", "testing"},
+	{"explain", "learning"},
+	{"how", "learning"},
+	{"what", "learning"},
+}
+
+// chatActivities returns the activities a chat's content suggests, without
+// duplicates and in activityPatterns order
+func chatActivities(chatTab *cursor.ChatTab) []string {
+	content := strings.ToLower(chatTab.ToMarkdown())
+
+	var activities []string
+	for _, p := range activityPatterns {
+		if strings.Contains(content, p.pattern) && !slices.Contains(activities, p.activity) {
+			activities = append(activities, p.activity)
+		}
+	}
+	return activities
+}
+
 func cleanChatTitle(title string) string {
 	// Remove common prefixes and clean up
 	title = strings.TrimSpace(title)
@@ -0,0 +1,474 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/cursor"
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/cursor/cursortest"
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/providers"
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/vscode"
+)
+
+func newTestStorage(t *testing.T) *providers.FileStorageProvider {
+	t.Helper()
+	provider, err := providers.NewFileProvider(providers.ProviderConfig{
+		Type:       providers.FileProvider,
+		StorageDir: t.TempDir(),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create file provider: %v", err)
+	}
+	return provider.(*providers.FileStorageProvider)
+}
+
+func testChat(id, question string) *cursor.ChatTab {
+	ts := time.Date(2025, 9, 20, 10, 0, 0, 0, time.UTC).UnixMilli()
+	return &cursor.ChatTab{
+		ID:        id,
+		Title:     "Debugging session",
+		Timestamp: ts,
+		Messages: []cursor.Message{
+			{Role: "user", Content: question, Timestamp: ts},
+			{Role: "assistant", Content: "Here's how to fix it.", Timestamp: ts + 1000},
+		},
+	}
+}
+
+func TestImportChatSkipsAlreadyImported(t *testing.T) {
+	fs := newTestStorage(t)
+	chat := testChat("chat-123", "Why does my test fail?")
+
+	first, action, err := importChat(fs, chat, importOptions{})
+	if err != nil {
+		t.Fatalf("First import failed: %v", err)
+	}
+	if action != importActionCreated {
+		t.Errorf("Expected first import to create, got %s", action)
+	}
+	if first.Labels[cursorChatIDLabel] != "chat-123" {
+		t.Errorf("Expected %s label to be recorded, got %v", cursorChatIDLabel, first.Labels)
+	}
+
+	second, action, err := importChat(fs, chat, importOptions{})
+	if err != nil {
+		t.Fatalf("Second import failed: %v", err)
+	}
+	if action != importActionSkipped {
+		t.Errorf("Expected second import to be skipped, got %s", action)
+	}
+	if second.ID != first.ID {
+		t.Errorf("Expected skipped import to report existing memory %s, got %s", first.ID, second.ID)
+	}
+
+	memories, err := fs.List()
+	if err != nil {
+		t.Fatalf("Failed to list memories: %v", err)
+	}
+	if len(memories) != 1 {
+		t.Errorf("Expected 1 memory after re-import, got %d", len(memories))
+	}
+}
+
+func TestImportChatRecognizesPreviousIDs(t *testing.T) {
+	fs := newTestStorage(t)
+
+	first, _, err := importChat(fs, testChat("generations-1758540001000", "Why does my test fail?"), importOptions{})
+	if err != nil {
+		t.Fatalf("First import failed: %v", err)
+	}
+
+	chat := testChat("generations-composer-auth", "Why does my test fail?")
+	chat.PreviousIDs = []string{"generations-1758540001000"}
+	skipped, action, err := importChat(fs, chat, importOptions{})
+	if err != nil {
+		t.Fatalf("Second import failed: %v", err)
+	}
+	if action != importActionSkipped || skipped.ID != first.ID {
+		t.Errorf("Expected chat imported under its previous ID to be skipped, got %s %s", action, skipped.ID)
+	}
+
+	updated, action, err := importChat(fs, chat, importOptions{Update: true})
+	if err != nil {
+		t.Fatalf("Update import failed: %v", err)
+	}
+	if action != importActionUpdated || updated.ID != first.ID {
+		t.Errorf("Expected update of %s, got %s %s", first.ID, action, updated.ID)
+	}
+	if updated.Labels[cursorChatIDLabel] != "generations-composer-auth" {
+		t.Errorf("Expected update to relabel the chat with its new ID, got %v", updated.Labels)
+	}
+}
+
+func TestImportChatUpdate(t *testing.T) {
+	fs := newTestStorage(t)
+
+	first, _, err := importChat(fs, testChat("chat-123", "Why does my test fail?"), importOptions{})
+	if err != nil {
+		t.Fatalf("First import failed: %v", err)
+	}
+
+	updated, action, err := importChat(fs, testChat("chat-123", "Why does my build fail?"), importOptions{Update: true})
+	if err != nil {
+		t.Fatalf("Update import failed: %v", err)
+	}
+	if action != importActionUpdated {
+		t.Errorf("Expected update action, got %s", action)
+	}
+	if updated.ID != first.ID {
+		t.Errorf("Expected update to keep memory ID %s, got %s", first.ID, updated.ID)
+	}
+
+	stored, err := fs.Get(first.ID)
+	if err != nil {
+		t.Fatalf("Failed to get memory: %v", err)
+	}
+	if !strings.Contains(stored.Content, "Why does my build fail?") {
+		t.Errorf("Expected refreshed content, got: %s", stored.Content)
+	}
+
+	memories, err := fs.List()
+	if err != nil {
+		t.Fatalf("Failed to list memories: %v", err)
+	}
+	if len(memories) != 1 {
+		t.Errorf("Expected 1 memory after update, got %d", len(memories))
+	}
+}
+
+func TestImportChatForce(t *testing.T) {
+	fs := newTestStorage(t)
+	chat := testChat("chat-123", "Why does my test fail?")
+
+	if _, _, err := importChat(fs, chat, importOptions{}); err != nil {
+		t.Fatalf("First import failed: %v", err)
+	}
+
+	_, action, err := importChat(fs, chat, importOptions{Force: true})
+	if err != nil {
+		t.Fatalf("Forced import failed: %v", err)
+	}
+	if action != importActionCreated {
+		t.Errorf("Expected forced import to create, got %s", action)
+	}
+
+	memories, err := fs.List()
+	if err != nil {
+		t.Fatalf("Failed to list memories: %v", err)
+	}
+	if len(memories) != 2 {
+		t.Errorf("Expected 2 memories after forced import, got %d", len(memories))
+	}
+}
+
+func writeChatDataWorkspace(t *testing.T, storageDir, name string, tabs []cursor.ChatTab) string {
+	t.Helper()
+	data, err := json.Marshal(cursor.ChatData{Tabs: tabs})
+	if err != nil {
+		t.Fatalf("Failed to marshal chat data: %v", err)
+	}
+	return cursortest.WriteWorkspace(t, storageDir, name, map[string]string{
+		"workbench.panel.aichat.view.aichat.chatdata": string(data),
+	})
+}
+
+func TestImportAllChats(t *testing.T) {
+	storageDir := t.TempDir()
+	day := func(d int) int64 {
+		return time.Date(2025, 9, d, 12, 0, 0, 0, time.UTC).UnixMilli()
+	}
+
+	writeChatDataWorkspace(t, storageDir, "workspace-a", []cursor.ChatTab{
+		{ID: "chat-a1", Title: "Old chat", Timestamp: day(1), Messages: []cursor.Message{
+			{Role: "user", Content: "How do I write a parser?"},
+			{Role: "assistant", Content: "Here's an approach."},
+		}},
+		{ID: "chat-a2", Title: "Empty chat", Timestamp: day(15), Messages: []cursor.Message{
+			{Role: "system", Content: "Composer session"},
+		}},
+	})
+	writeChatDataWorkspace(t, storageDir, "workspace-b", []cursor.ChatTab{
+		{ID: "chat-b1", Title: "New chat", Timestamp: day(20), Messages: []cursor.Message{
+			{Role: "user", Content: "Why is the index stale?"},
+			{Role: "assistant", Content: "Let me check."},
+		}},
+	})
+
+	fs := newTestStorage(t)
+	reader := cursor.NewWorkspaceReaderWithPath(storageDir)
+
+	summary, err := importAllChats(reader, fs, importOptions{}, time.Time{})
+	if err != nil {
+		t.Fatalf("importAllChats failed: %v", err)
+	}
+	if summary.Imported != 2 || summary.Empty != 1 || summary.Skipped != 0 {
+		t.Errorf("Unexpected first summary: %+v", summary)
+	}
+
+	// A second run should skip everything that was already imported
+	summary, err = importAllChats(reader, fs, importOptions{}, time.Time{})
+	if err != nil {
+		t.Fatalf("importAllChats failed: %v", err)
+	}
+	if summary.Imported != 0 || summary.Skipped != 2 {
+		t.Errorf("Unexpected second summary: %+v", summary)
+	}
+
+	memories, err := fs.List()
+	if err != nil {
+		t.Fatalf("Failed to list memories: %v", err)
+	}
+	if len(memories) != 2 {
+		t.Errorf("Expected 2 memories, got %d", len(memories))
+	}
+}
+
+func TestImportAllChatsSinceAndWorkspace(t *testing.T) {
+	storageDir := t.TempDir()
+	day := func(d int) int64 {
+		return time.Date(2025, 9, d, 12, 0, 0, 0, time.UTC).UnixMilli()
+	}
+	messages := []cursor.Message{
+		{Role: "user", Content: "Question?"},
+		{Role: "assistant", Content: "Answer."},
+	}
+
+	writeChatDataWorkspace(t, storageDir, "workspace-a", []cursor.ChatTab{
+		{ID: "chat-a1", Timestamp: day(1), Messages: messages},
+		{ID: "chat-a2", Timestamp: day(18), Messages: messages},
+	})
+	dbPathB := writeChatDataWorkspace(t, storageDir, "workspace-b", []cursor.ChatTab{
+		{ID: "chat-b1", Timestamp: day(2), Messages: messages},
+		{ID: "chat-b2", Timestamp: day(19), Messages: messages},
+	})
+
+	since := time.Date(2025, 9, 10, 0, 0, 0, 0, time.UTC)
+
+	fs := newTestStorage(t)
+	summary, err := importAllChats(cursor.NewWorkspaceReaderWithPath(storageDir), fs, importOptions{}, since)
+	if err != nil {
+		t.Fatalf("importAllChats failed: %v", err)
+	}
+	if summary.Imported != 2 {
+		t.Errorf("Expected 2 chats newer than %s, got %+v", since, summary)
+	}
+
+	// Scoping to a single workspace database only imports its chats
+	scoped := newTestStorage(t)
+	summary, err = importAllChats(cursor.NewWorkspaceReaderWithPath(dbPathB), scoped, importOptions{}, time.Time{})
+	if err != nil {
+		t.Fatalf("importAllChats failed: %v", err)
+	}
+	if summary.Imported != 2 {
+		t.Errorf("Expected 2 chats from workspace-b, got %+v", summary)
+	}
+	for _, id := range []string{"chat-b1", "chat-b2"} {
+		if existing, err := findImportedChat(scoped, id); err != nil || existing == nil {
+			t.Errorf("Expected chat %s to be imported (err=%v)", id, err)
+		}
+	}
+}
+
+func TestNewChatReader(t *testing.T) {
+	reader, err := newChatReader(chatSourceVSCode, "../internal/vscode/testdata/workspaceStorage", "", false)
+	if err != nil {
+		t.Fatalf("newChatReader failed: %v", err)
+	}
+
+	fs := newTestStorage(t)
+	summary, err := importAllChats(reader, fs, importOptions{}, time.Time{})
+	if err != nil {
+		t.Fatalf("importAllChats failed: %v", err)
+	}
+	if summary.Imported != 2 {
+		t.Errorf("Expected 2 VS Code chats imported, got %+v", summary)
+	}
+
+	memory, err := findImportedChat(fs, "session-a")
+	if err != nil || memory == nil {
+		t.Fatalf("Expected session-a to be imported (err=%v)", err)
+	}
+	if memory.Labels["source"] != vscode.Source {
+		t.Errorf("Expected source label %s, got %s", vscode.Source, memory.Labels["source"])
+	}
+
+	if _, err := newChatReader("emacs", "", "", false); err == nil {
+		t.Error("Expected error for unknown source")
+	}
+}
+
+func TestNewChatReaderProject(t *testing.T) {
+	dir := t.TempDir()
+	reader, err := newChatReader(chatSourceCursor, "", dir, false)
+	if err != nil {
+		t.Fatalf("newChatReader failed: %v", err)
+	}
+	expected, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		t.Fatalf("Failed to resolve temp dir: %v", err)
+	}
+	if got := reader.(*cursor.WorkspaceReader).ProjectPath; got != expected {
+		t.Errorf("Expected project path %s, got %s", expected, got)
+	}
+
+	if _, err := newChatReader(chatSourceCursor, "", filepath.Join(dir, "missing"), false); err == nil {
+		t.Error("Expected error for a project path that doesn't exist")
+	}
+	if _, err := newChatReader(chatSourceVSCode, "", dir, false); err == nil {
+		t.Error("Expected error for --project with VS Code")
+	}
+}
+
+func TestGenerateChatLabelsPrefersFencedLanguage(t *testing.T) {
+	chat := &cursor.ChatTab{
+		ID: "chat-rust",
+		Messages: []cursor.Message{
+			{Role: "user", Content: "Let's go through this python-looking error in my rust code:\n\n```rust\nfn main() {}\n```"},
+			{Role: "assistant", Content: "Here's the fix:\n\n```rust\nfn main() { run(); }\n```\n\nThen:\n\n```bash\ncargo run\n```"},
+		},
+	}
+
+	labels := generateChatLabels(chat)
+	if labels["language"] != "rust" {
+		t.Errorf("Expected language label rust, got %q", labels["language"])
+	}
+	if labels["code-languages"] != "rust_bash" {
+		t.Errorf("Expected code-languages rust_bash, got %q", labels["code-languages"])
+	}
+
+	// Without fenced code the keyword heuristic still applies
+	labels = generateChatLabels(testChat("chat-prose", "How do I use python decorators?"))
+	if _, ok := labels["code-languages"]; ok {
+		t.Errorf("Expected no code-languages label without code blocks, got %v", labels)
+	}
+	if labels["language"] != "python" {
+		t.Errorf("Expected language label python, got %q", labels["language"])
+	}
+}
+
+func TestGenerateChatLabelsActivities(t *testing.T) {
+	chat := &cursor.ChatTab{
+		ID: "chat-mixed",
+		Messages: []cursor.Message{
+			{Role: "user", Content: "Please implement a parser for the config file, then add a unit test for it."},
+			{Role: "assistant", Content: "Done: the parser is in config.go and the test covers empty files."},
+		},
+	}
+
+	// Repeat to catch any dependence on map iteration order
+	for i := 0; i < 20; i++ {
+		labels := generateChatLabels(chat)
+		if labels["activity"] != "implementation" {
+			t.Fatalf("Expected primary activity implementation, got %q", labels["activity"])
+		}
+		if labels["activities"] != "implementation_testing" {
+			t.Fatalf("Expected activities implementation_testing, got %q", labels["activities"])
+		}
+	}
+}
+
+func TestConvertChatToMemoryWithTimestamps(t *testing.T) {
+	chat := testChat("chat-123", "Why does my test fail?")
+	stamp := time.UnixMilli(chat.Messages[0].Timestamp).Format("2006-01-02 15:04:05")
+
+	plain := convertChatToMemory(chat, importOptions{})
+	if strings.Contains(plain.Content, "_(") {
+		t.Errorf("Expected no message timestamps by default, got: %s", plain.Content)
+	}
+	if plain.Metadata[storage.ContentTypeKey] != storage.ContentTypeMarkdown {
+		t.Errorf("Expected chats to be %s, got %v", storage.ContentTypeMarkdown, plain.Metadata)
+	}
+
+	timed := convertChatToMemory(chat, importOptions{WithTimestamps: true})
+	if !strings.Contains(timed.Content, "**User** _("+stamp+")_:") {
+		t.Errorf("Expected timestamped user message, got: %s", timed.Content)
+	}
+}
+
+func TestJoinLabelValues(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []string
+		want   string
+	}{
+		{name: "plain", values: []string{"go", "python"}, want: "go_python"},
+		{name: "symbols", values: []string{"c++", "c#", "objective c"}, want: "cpp_csharp_objective-c"},
+		{name: "duplicates after sanitizing", values: []string{"cpp", "c++"}, want: "cpp"},
+		{name: "too long", values: []string{strings.Repeat("a", 40), strings.Repeat("b", 40), "go"}, want: strings.Repeat("a", 40) + "_go"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := joinLabelValues(tt.values); got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+
+	if got := splitLabelValues("go,python"); len(got) != 2 || got[1] != "python" {
+		t.Errorf("Expected legacy comma-separated values to split, got %v", got)
+	}
+}
+
+// chatList is a ChatReader over a fixed set of chats
+type chatList []cursor.ChatTabWithWorkspace
+
+func (c chatList) GetLatestChat() (*cursor.ChatTab, error) { return &c[0].ChatTab, nil }
+func (c chatList) GetChatByID(string) (*cursor.ChatTab, string, error) {
+	return nil, "", fmt.Errorf("not implemented")
+}
+func (c chatList) ListAllChats() ([]cursor.ChatTabWithWorkspace, error) { return c, nil }
+func (c chatList) SearchChats(string) ([]cursor.ChatTabWithWorkspace, error) {
+	return c, nil
+}
+
+func TestImportAllChatsAtomic(t *testing.T) {
+	tooLarge := testChat("chat-large", strings.Repeat("Why does this fail? ", 100))
+	chats := chatList{
+		{ChatTab: *testChat("chat-ok", "Why does my test fail?")},
+		{ChatTab: *tooLarge},
+	}
+
+	tests := []struct {
+		atomic   bool
+		expected int
+	}{
+		{atomic: false, expected: 1},
+		{atomic: true, expected: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("atomic=%v", tt.atomic), func(t *testing.T) {
+			fs := newTestStorage(t)
+			fs.SetMaxContentBytes(1000)
+
+			opts := importOptions{Atomic: tt.atomic}
+			err := inBatch(fs, tt.atomic, func() error {
+				_, err := importAllChats(chats, fs, opts, time.Time{})
+				return err
+			})
+			if tt.atomic && err == nil {
+				t.Error("Expected the atomic import to fail")
+			}
+			if !tt.atomic && err != nil {
+				t.Fatalf("Expected failures to be counted, got %v", err)
+			}
+
+			memories, err := fs.List()
+			if err != nil {
+				t.Fatalf("Failed to list memories: %v", err)
+			}
+			if len(memories) != tt.expected {
+				t.Errorf("Expected %d memories, got %d", tt.expected, len(memories))
+			}
+			if imported, _ := findImportedChat(fs, "chat-ok"); (imported != nil) != (tt.expected == 1) {
+				t.Errorf("Expected chat-ok imported=%v in the index, got %v", tt.expected == 1, imported)
+			}
+		})
+	}
+}
@@ -2,44 +2,81 @@ package cmd
 
 import (
 	"fmt"
+	"io"
+	"os"
 
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/providers"
 	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
 var infoCmd = &cobra.Command{
 	Use:   "info",
 	Short: "Show storage information",
-	Long: `Display information about the storage system including location, 
-memory count, and total storage size.
+	Long: `Display information about the storage system including location,
+memory count, and total, average and median memory size.
 
 Example:
-  cmctl info`,
+  cmctl info
+  cmctl info --top 10   # Also list the 10 largest memories`,
 	RunE: runInfo,
 }
 
+var infoTop int
+
 func init() {
 	rootCmd.AddCommand(infoCmd)
+
+	infoCmd.Flags().IntVar(&infoTop, "top", 0, "List the N largest memories")
 }
 
 func runInfo(cmd *cobra.Command, args []string) error {
+	if infoTop < 0 {
+		return fmt.Errorf("--top must not be negative")
+	}
+
 	// Initialize storage
-	storageDir := viper.GetString("storage-dir")
-	fs, err := storage.NewFileStorage(storageDir)
+	fs, err := getStorageProvider()
 	if err != nil {
 		return fmt.Errorf("failed to initialize storage: %w", err)
 	}
 
 	// Get storage info
-	info, err := fs.GetStorageInfo()
+	infoProvider, ok := fs.(providers.StorageInfoProvider)
+	if !ok {
+		return fmt.Errorf("storage provider %s does not report storage info", fs.GetProviderType())
+	}
+	info, err := infoProvider.GetStorageInfo()
 	if err != nil {
 		return fmt.Errorf("failed to get storage info: %w", err)
 	}
 
-	fmt.Printf("Storage Directory:\t%s\n", info.StorageDir)
-	fmt.Printf("Total Memories:\t\t%d\n", info.MemoriesCount)
-	fmt.Printf("Storage Size:\t\t%.1f KB\n", float64(info.TotalSize)/1024)
-
+	printStorageInfo(os.Stdout, info, infoTop)
 	return nil
 }
+
+// printStorageInfo writes the storage summary and, when top is set, the
+// top largest memories
+func printStorageInfo(w io.Writer, info *storage.StorageInfo, top int) {
+	fmt.Fprintf(w, "Storage Directory:\t%s\n", info.StorageDir)
+	fmt.Fprintf(w, "Total Memories:\t\t%d\n", info.MemoriesCount)
+	fmt.Fprintf(w, "Storage Size:\t\t%s\n", formatKB(info.TotalSize))
+	if len(info.Memories) > 0 {
+		fmt.Fprintf(w, "Average Size:\t\t%s\n", formatKB(info.AverageSize()))
+		fmt.Fprintf(w, "Median Size:\t\t%s\n", formatKB(info.MedianSize()))
+	}
+
+	if top == 0 || len(info.Memories) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\nLargest Memories:\n")
+	fmt.Fprintf(w, "%-24s %-40s %10s\n", "ID", "NAME", "SIZE")
+	for _, memory := range info.Largest(top) {
+		fmt.Fprintf(w, "%-24s %-40s %10s\n", memory.ID, truncateString(memory.Name, 40), formatKB(memory.Size))
+	}
+}
+
+// formatKB formats a byte count in kilobytes
+func formatKB(size int64) string {
+	return fmt.Sprintf("%.1f KB", float64(size)/1024)
+}
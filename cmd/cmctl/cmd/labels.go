@@ -0,0 +1,213 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// labelAliasesKey is the config key holding label value aliases, a map of
+// alias to canonical value
+const labelAliasesKey = "label-aliases"
+
+// defaultLabelAliases canonicalize the spellings of common technologies,
+// matching the values chat import detects
+var defaultLabelAliases = map[string]string{
+	"js":         "javascript",
+	"jsx":        "javascript",
+	"ecmascript": "javascript",
+	"ts":         "typescript",
+	"py":         "python",
+	"python3":    "python",
+	"golang":     "go",
+	"rs":         "rust",
+	"rb":         "ruby",
+	"cplusplus":  "cpp",
+	"cxx":        "cpp",
+	"cs":         "csharp",
+	"sh":         "bash",
+	"shell":      "bash",
+	"yml":        "yaml",
+	"node":       "nodejs",
+	"node.js":    "nodejs",
+	"next.js":    "nextjs",
+	"react.js":   "react",
+	"reactjs":    "react",
+	"vue.js":     "vue",
+	"vuejs":      "vue",
+	"k8s":        "kubernetes",
+	"postgres":   "postgresql",
+	"psql":       "postgresql",
+}
+
+// multiValueLabels hold several values joined with labelValueSeparator,
+// each of which is canonicalized on its own
+var multiValueLabels = map[string]bool{
+	"technologies":   true,
+	"code-languages": true,
+	"activities":     true,
+	tagsLabel:        true,
+}
+
+// labelAliases returns the default aliases overlaid with those in the
+// config file. Map an alias to itself to turn a default off.
+func labelAliases() map[string]string {
+	aliases := make(map[string]string, len(defaultLabelAliases))
+	for alias, canonical := range defaultLabelAliases {
+		aliases[alias] = canonical
+	}
+	for alias, canonical := range viper.GetStringMapString(labelAliasesKey) {
+		aliases[strings.ToLower(alias)] = canonical
+	}
+	return aliases
+}
+
+// normalizeLabels returns labels with aliased values replaced by their
+// canonical value, and whether anything changed. Aliases match whole values
+// case-insensitively; the chat ID label is an identifier and is left alone.
+func normalizeLabels(labels map[string]string, aliases map[string]string) (map[string]string, bool) {
+	normalized := make(map[string]string, len(labels))
+	changed := false
+	for key, value := range labels {
+		canonical := value
+		switch {
+		case key == cursorChatIDLabel:
+		case multiValueLabels[key]:
+			parts := splitLabelValues(value)
+			for i, part := range parts {
+				parts[i] = canonicalLabelValue(part, aliases)
+			}
+			canonical = joinLabelValues(parts)
+		default:
+			canonical = canonicalLabelValue(value, aliases)
+		}
+		if canonical != value {
+			changed = true
+		}
+		normalized[key] = canonical
+	}
+	return normalized, changed
+}
+
+// canonicalLabelValue returns the canonical form of value
+func canonicalLabelValue(value string, aliases map[string]string) string {
+	if canonical, ok := aliases[strings.ToLower(value)]; ok && canonical != "" {
+		return canonical
+	}
+	return value
+}
+
+var labelsCmd = &cobra.Command{
+	Use:   "labels",
+	Short: "Manage label values across memories",
+	Long: `Manage label values across memories.
+
+Chat import replaces aliased label values, such as js or node, with their
+canonical value, such as javascript or nodejs, so filtering by label finds
+every chat. Add your own aliases, or override the defaults, under
+label-aliases in the config file; map an alias to itself to turn it off:
+
+  label-aliases:
+    tf: terraform
+    shell: shell`,
+}
+
+var labelsNormalizeCmd = &cobra.Command{
+	Use:   "normalize",
+	Short: "Apply label aliases to existing memories",
+	Long: `Replace aliased label values in existing memories with their canonical
+value, for memories imported before an alias was added.
+
+Examples:
+  cmctl labels normalize --dry-run       # Show what would change
+  cmctl labels normalize                 # Normalize every memory
+  cmctl labels normalize -l type=chat    # Only imported chats`,
+	Args: cobra.NoArgs,
+	RunE: runLabelsNormalize,
+}
+
+var labelsAliasesCmd = &cobra.Command{
+	Use:   "aliases",
+	Short: "List the label aliases in effect",
+	Args:  cobra.NoArgs,
+	RunE:  runLabelsAliases,
+}
+
+var (
+	labelsSelector string
+	labelsDryRun   bool
+)
+
+func init() {
+	rootCmd.AddCommand(labelsCmd)
+	labelsCmd.AddCommand(labelsNormalizeCmd, labelsAliasesCmd)
+
+	labelsNormalizeCmd.Flags().StringVarP(&labelsSelector, "labels", "l", "", "Only normalize memories matching this label selector (format: key1=value1,key2=value2)")
+	labelsNormalizeCmd.Flags().BoolVar(&labelsDryRun, "dry-run", false, "Print the label changes without saving them")
+}
+
+func runLabelsNormalize(cmd *cobra.Command, args []string) error {
+	fs, err := getStorageProvider()
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	memories, err := listMetadata(fs)
+	if err != nil {
+		return err
+	}
+	memories = storage.FilterMemories(memories, storage.SearchRequest{LabelSelector: parseLabels(labelsSelector)})
+
+	changes := labelChanges(memories, labelAliases())
+	if labelsDryRun {
+		fmt.Printf("Would normalize %d memories (dry run):\n", len(changes))
+		for _, memory := range changes {
+			fmt.Printf("  %s  %s  %s\n", memory.ID, memory.Name, formatLabels(memory.Labels))
+		}
+		return nil
+	}
+
+	normalized := 0
+	for _, memory := range changes {
+		if _, err := fs.Update(storage.UpdateMemoryRequest{ID: memory.ID, Labels: memory.Labels}); err != nil {
+			VPrintf(Normal, "Normalized %d/%d memories\n", normalized, len(changes))
+			return fmt.Errorf("failed to update memory %s: %w", memory.ID, err)
+		}
+		DebugPrintf("Normalized labels of memory/%s: %s\n", memory.ID, formatLabels(memory.Labels))
+		normalized++
+	}
+	VPrintf(Normal, "Normalized %d memories\n", normalized)
+	return nil
+}
+
+// labelChanges returns the memories whose labels aliases would change,
+// carrying their normalized labels
+func labelChanges(memories []storage.Memory, aliases map[string]string) []storage.Memory {
+	var changes []storage.Memory
+	for _, memory := range memories {
+		if labels, changed := normalizeLabels(memory.Labels, aliases); changed {
+			memory.Labels = labels
+			changes = append(changes, memory)
+		}
+	}
+	return changes
+}
+
+func runLabelsAliases(cmd *cobra.Command, args []string) error {
+	aliases := labelAliases()
+	names := make([]string, 0, len(aliases))
+	for alias := range aliases {
+		names = append(names, alias)
+	}
+	sort.Strings(names)
+	for _, alias := range names {
+		if aliases[alias] == alias {
+			continue
+		}
+		fmt.Printf("%-12s -> %s\n", alias, aliases[alias])
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/cursor"
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+	"github.com/spf13/viper"
+)
+
+func TestNormalizeLabels(t *testing.T) {
+	aliases := map[string]string{"js": "javascript", "node": "nodejs", "golang": "go"}
+
+	tests := []struct {
+		name    string
+		labels  map[string]string
+		want    map[string]string
+		changed bool
+	}{
+		{
+			name:    "aliases collapse",
+			labels:  map[string]string{"language": "JS", "runtime": "node"},
+			want:    map[string]string{"language": "javascript", "runtime": "nodejs"},
+			changed: true,
+		},
+		{
+			name:    "multi-value labels dedupe",
+			labels:  map[string]string{"technologies": "js_javascript_golang"},
+			want:    map[string]string{"technologies": "javascript_go"},
+			changed: true,
+		},
+		{
+			name:   "canonical values unchanged",
+			labels: map[string]string{"language": "javascript", "type": "chat"},
+			want:   map[string]string{"language": "javascript", "type": "chat"},
+		},
+		{
+			name:   "chat ID left alone",
+			labels: map[string]string{cursorChatIDLabel: "js"},
+			want:   map[string]string{cursorChatIDLabel: "js"},
+		},
+		{
+			name:   "only whole values match",
+			labels: map[string]string{"project": "js-tools"},
+			want:   map[string]string{"project": "js-tools"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, changed := normalizeLabels(tt.labels, aliases)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+			if changed != tt.changed {
+				t.Errorf("Expected changed=%v, got %v", tt.changed, changed)
+			}
+		})
+	}
+}
+
+func TestLabelAliasesConfig(t *testing.T) {
+	defer viper.Set(labelAliasesKey, nil)
+	viper.Set(labelAliasesKey, map[string]string{"TF": "terraform", "shell": "shell"})
+
+	aliases := labelAliases()
+	if aliases["tf"] != "terraform" {
+		t.Errorf("Expected configured alias tf -> terraform, got %q", aliases["tf"])
+	}
+	if got := canonicalLabelValue("shell", aliases); got != "shell" {
+		t.Errorf("Expected a self-alias to turn off the default, got %q", got)
+	}
+	if aliases["js"] != "javascript" {
+		t.Errorf("Expected defaults to remain, got %q", aliases["js"])
+	}
+}
+
+func TestGenerateChatLabelsAliases(t *testing.T) {
+	chat := &cursor.ChatTab{
+		ID: "chat-js",
+		Messages: []cursor.Message{
+			{Role: "user", Content: "Why is this undefined?\n\n```js\nconsole.log(x)\n```"},
+			{Role: "assistant", Content: "Declare it first:\n\n```javascript\nlet x = 1\n```"},
+		},
+	}
+
+	labels := generateChatLabels(chat)
+	if labels["language"] != "javascript" {
+		t.Errorf("Expected language label javascript, got %q", labels["language"])
+	}
+	if labels["code-languages"] != "javascript" {
+		t.Errorf("Expected js and javascript to collapse, got %q", labels["code-languages"])
+	}
+}
+
+func TestLabelChanges(t *testing.T) {
+	fs := newTestStorage(t)
+	for name, labels := range map[string]map[string]string{
+		"old":   {"type": "chat", "language": "golang"},
+		"new":   {"type": "chat", "language": "go"},
+		"notes": {"type": "notes"},
+	} {
+		if _, err := fs.Create(storage.CreateMemoryRequest{Name: name, Content: name, Labels: labels}); err != nil {
+			t.Fatalf("Failed to create memory: %v", err)
+		}
+	}
+
+	memories, err := listMetadata(fs)
+	if err != nil {
+		t.Fatalf("Failed to list memories: %v", err)
+	}
+	changes := labelChanges(memories, labelAliases())
+	if len(changes) != 1 || changes[0].Name != "old" || changes[0].Labels["language"] != "go" {
+		t.Fatalf("Expected only the golang memory to change, got %v", changes)
+	}
+}
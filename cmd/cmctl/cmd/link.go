@@ -0,0 +1,194 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/providers"
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+var linkCmd = &cobra.Command{
+	Use:   "link <memory-id> <target-id>",
+	Short: "Link a memory to a related memory",
+	Long: `Add a typed relation from one memory to another. Relations are stored on
+the source memory, shown by 'cmctl get <id>', and followed by
+'cmctl get <id> --related'.
+
+Examples:
+  cmctl link mem_chat mem_notes                      # relates-to link
+  cmctl link mem_chat mem_decision --type implements # Custom relation type`,
+	Args: cobra.ExactArgs(2),
+	RunE: runLink,
+}
+
+var unlinkCmd = &cobra.Command{
+	Use:   "unlink <memory-id> <target-id>",
+	Short: "Remove links between memories",
+	Long: `Remove relations from one memory to another. Without --type, every
+relation to the target is removed.
+
+Examples:
+  cmctl unlink mem_chat mem_notes
+  cmctl unlink mem_chat mem_decision --type implements`,
+	Args: cobra.ExactArgs(2),
+	RunE: runUnlink,
+}
+
+var (
+	linkType   string
+	unlinkType string
+)
+
+func init() {
+	rootCmd.AddCommand(linkCmd, unlinkCmd)
+
+	linkCmd.Flags().StringVarP(&linkType, "type", "t", storage.DefaultRelationType, "Relation type")
+	unlinkCmd.Flags().StringVarP(&unlinkType, "type", "t", "", "Only remove relations of this type")
+}
+
+func runLink(cmd *cobra.Command, args []string) error {
+	fs, err := getStorageProvider()
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+
+	added, err := addRelation(fs, args[0], args[1], linkType)
+	if err != nil {
+		return err
+	}
+	if added {
+		VPrintf(Normal, "Linked %s -[%s]-> %s\n", args[0], linkType, args[1])
+	} else {
+		VPrintf(Normal, "%s is already linked to %s as %s\n", args[0], args[1], linkType)
+	}
+	return nil
+}
+
+func runUnlink(cmd *cobra.Command, args []string) error {
+	fs, err := getStorageProvider()
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+
+	removed, err := removeRelations(fs, args[0], args[1], unlinkType)
+	if err != nil {
+		return err
+	}
+	if removed == 0 {
+		return fmt.Errorf("%s is not linked to %s", args[0], args[1])
+	}
+	VPrintf(Normal, "Removed %d link(s) from %s to %s\n", removed, args[0], args[1])
+	return nil
+}
+
+// addRelation links id to targetID, checking that both memories exist. It
+// reports false if the relation was already present.
+func addRelation(fs providers.StorageProvider, id, targetID, relationType string) (bool, error) {
+	memory, err := fs.Get(id)
+	if err != nil {
+		return false, err
+	}
+	if _, err := fs.Get(targetID); err != nil {
+		if errors.Is(err, storage.ErrMemoryNotFound) {
+			return false, fmt.Errorf("link target does not exist: %w", err)
+		}
+		return false, err
+	}
+
+	relation := storage.Relation{Type: relationType, TargetID: targetID}
+	for _, existing := range memory.Relations {
+		if existing == relation {
+			return false, nil
+		}
+	}
+
+	relations := append(memory.Relations, relation)
+	if _, err := fs.Update(storage.UpdateMemoryRequest{ID: id, Relations: relations}); err != nil {
+		return false, fmt.Errorf("failed to link memories: %w", err)
+	}
+	return true, nil
+}
+
+// removeRelations removes the relations from id to targetID, only those of
+// relationType when it is set, and returns how many were removed
+func removeRelations(fs providers.StorageProvider, id, targetID, relationType string) (int, error) {
+	memory, err := fs.Get(id)
+	if err != nil {
+		return 0, err
+	}
+
+	kept := make([]storage.Relation, 0, len(memory.Relations))
+	for _, relation := range memory.Relations {
+		if relation.TargetID == targetID && (relationType == "" || relation.Type == relationType) {
+			continue
+		}
+		kept = append(kept, relation)
+	}
+
+	removed := len(memory.Relations) - len(kept)
+	if removed == 0 {
+		return 0, nil
+	}
+	if _, err := fs.Update(storage.UpdateMemoryRequest{ID: id, Relations: kept}); err != nil {
+		return 0, fmt.Errorf("failed to unlink memories: %w", err)
+	}
+	return removed, nil
+}
+
+// relatedMemories returns the memories a memory links to, skipping links
+// whose target no longer exists
+func relatedMemories(fs providers.StorageProvider, memory *storage.Memory) ([]storage.Memory, error) {
+	var related []storage.Memory
+	seen := make(map[string]bool)
+	for _, relation := range memory.Relations {
+		if seen[relation.TargetID] {
+			continue
+		}
+		seen[relation.TargetID] = true
+
+		target, err := fs.Get(relation.TargetID)
+		if errors.Is(err, storage.ErrMemoryNotFound) {
+			VPrintf(Normal, "Warning: %s links to missing memory %s\n", memory.ID, relation.TargetID)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get related memory: %w", err)
+		}
+		related = append(related, *target)
+	}
+	return related, nil
+}
+
+// removeRelationsTo deletes every relation pointing at one of ids, returning
+// the number removed. It is used to clean up after deleting memories.
+func removeRelationsTo(fs providers.StorageProvider, ids []string) (int, error) {
+	deleted := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		deleted[id] = true
+	}
+
+	memories, err := fs.List()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list memories: %w", err)
+	}
+
+	removed := 0
+	for _, memory := range memories {
+		kept := make([]storage.Relation, 0, len(memory.Relations))
+		for _, relation := range memory.Relations {
+			if !deleted[relation.TargetID] {
+				kept = append(kept, relation)
+			}
+		}
+		if len(kept) == len(memory.Relations) {
+			continue
+		}
+		if _, err := fs.Update(storage.UpdateMemoryRequest{ID: memory.ID, Relations: kept}); err != nil {
+			return removed, fmt.Errorf("failed to remove links from %s: %w", memory.ID, err)
+		}
+		removed += len(memory.Relations) - len(kept)
+	}
+	return removed, nil
+}
@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+)
+
+func TestAddRelation(t *testing.T) {
+	fs := newTestStorage(t)
+
+	source, err := fs.Create(storage.CreateMemoryRequest{Name: "Source", Content: "a"})
+	if err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+	target, err := fs.Create(storage.CreateMemoryRequest{Name: "Target", Content: "b"})
+	if err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+
+	added, err := addRelation(fs, source.ID, target.ID, storage.DefaultRelationType)
+	if err != nil {
+		t.Fatalf("Failed to link memories: %v", err)
+	}
+	if !added {
+		t.Error("Expected the first link to be added")
+	}
+
+	added, err = addRelation(fs, source.ID, target.ID, storage.DefaultRelationType)
+	if err != nil {
+		t.Fatalf("Failed to repeat link: %v", err)
+	}
+	if added {
+		t.Error("Expected a duplicate link to be ignored")
+	}
+
+	got, err := fs.Get(source.ID)
+	if err != nil {
+		t.Fatalf("Failed to get memory: %v", err)
+	}
+	want := storage.Relation{Type: storage.DefaultRelationType, TargetID: target.ID}
+	if len(got.Relations) != 1 || got.Relations[0] != want {
+		t.Errorf("Expected relations [%v], got %v", want, got.Relations)
+	}
+
+	tests := []struct {
+		name   string
+		target string
+		kind   string
+	}{
+		{"missing target", "mem_missing", storage.DefaultRelationType},
+		{"self link", source.ID, storage.DefaultRelationType},
+		{"invalid type", target.ID, "relates to"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := addRelation(fs, source.ID, tt.target, tt.kind); err == nil {
+				t.Error("Expected link to be rejected")
+			}
+		})
+	}
+
+	if _, err := addRelation(fs, source.ID, "mem_missing", storage.DefaultRelationType); !errors.Is(err, storage.ErrMemoryNotFound) {
+		t.Errorf("Expected ErrMemoryNotFound for a missing target, got %v", err)
+	}
+}
+
+func TestRemoveRelations(t *testing.T) {
+	fs := newTestStorage(t)
+
+	target, err := fs.Create(storage.CreateMemoryRequest{Name: "Target", Content: "b"})
+	if err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+	source, err := fs.Create(storage.CreateMemoryRequest{
+		Name:    "Source",
+		Content: "a",
+		Relations: []storage.Relation{
+			{Type: "relates-to", TargetID: target.ID},
+			{Type: "implements", TargetID: target.ID},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+
+	removed, err := removeRelations(fs, source.ID, target.ID, "implements")
+	if err != nil {
+		t.Fatalf("Failed to unlink: %v", err)
+	}
+	if removed != 1 {
+		t.Errorf("Expected 1 relation removed, got %d", removed)
+	}
+
+	removed, err = removeRelations(fs, source.ID, target.ID, "")
+	if err != nil {
+		t.Fatalf("Failed to unlink: %v", err)
+	}
+	if removed != 1 {
+		t.Errorf("Expected 1 relation removed, got %d", removed)
+	}
+
+	got, err := fs.Get(source.ID)
+	if err != nil {
+		t.Fatalf("Failed to get memory: %v", err)
+	}
+	if len(got.Relations) != 0 {
+		t.Errorf("Expected no relations left, got %v", got.Relations)
+	}
+}
+
+func TestRelatedMemories(t *testing.T) {
+	fs := newTestStorage(t)
+
+	first, err := fs.Create(storage.CreateMemoryRequest{Name: "First", Content: "1"})
+	if err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+	second, err := fs.Create(storage.CreateMemoryRequest{Name: "Second", Content: "2"})
+	if err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+	source, err := fs.Create(storage.CreateMemoryRequest{
+		Name:    "Source",
+		Content: "a",
+		Relations: []storage.Relation{
+			{Type: "relates-to", TargetID: first.ID},
+			{Type: "implements", TargetID: first.ID},
+			{Type: "relates-to", TargetID: second.ID},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+
+	if err := fs.Delete(second.ID); err != nil {
+		t.Fatalf("Failed to delete memory: %v", err)
+	}
+
+	related, err := relatedMemories(fs, source)
+	if err != nil {
+		t.Fatalf("Failed to get related memories: %v", err)
+	}
+	if len(related) != 1 || related[0].ID != first.ID {
+		t.Errorf("Expected only %s to be related, got %v", first.ID, related)
+	}
+}
+
+func TestRemoveRelationsTo(t *testing.T) {
+	fs := newTestStorage(t)
+
+	deleted, err := fs.Create(storage.CreateMemoryRequest{Name: "Deleted", Content: "x"})
+	if err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+	kept, err := fs.Create(storage.CreateMemoryRequest{Name: "Kept", Content: "y"})
+	if err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+	source, err := fs.Create(storage.CreateMemoryRequest{
+		Name:    "Source",
+		Content: "a",
+		Relations: []storage.Relation{
+			{Type: "relates-to", TargetID: deleted.ID},
+			{Type: "relates-to", TargetID: kept.ID},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+
+	if err := removeMemory(fs, deleted.ID, false); err != nil {
+		t.Fatalf("Failed to delete memory: %v", err)
+	}
+
+	removed, err := removeRelationsTo(fs, []string{deleted.ID})
+	if err != nil {
+		t.Fatalf("Failed to remove dangling links: %v", err)
+	}
+	if removed != 1 {
+		t.Errorf("Expected 1 dangling link removed, got %d", removed)
+	}
+
+	got, err := fs.Get(source.ID)
+	if err != nil {
+		t.Fatalf("Failed to get memory: %v", err)
+	}
+	if len(got.Relations) != 1 || got.Relations[0].TargetID != kept.ID {
+		t.Errorf("Expected only the link to %s to remain, got %v", kept.ID, got.Relations)
+	}
+}
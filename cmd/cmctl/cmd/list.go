@@ -2,10 +2,10 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"time"
 
-	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
 var listCmd = &cobra.Command{
@@ -19,6 +19,7 @@ DEPRECATED: This command is deprecated. Use 'cmctl get' instead.
 Examples:
   cmctl list                              # List memories without IDs
   cmctl list --show-id                    # List memories with IDs
+  cmctl list --sort-by created --reverse  # Oldest first
   cmctl list -o json                      # Output as JSON
   cmctl list -o yaml                      # Output as YAML
   cmctl list -o jsonpath='{.items[*].metadata.name}'     # JSONPath output
@@ -27,43 +28,77 @@ Examples:
 }
 
 var (
-	showID     bool
-	outputFlag string
+	showID            bool
+	outputFlag        string
+	listWatch         bool
+	listWatchInterval time.Duration
+	listPinned        bool
+	listExpired       bool
+	listSortBy        string
+	listReverse       bool
 )
 
 func init() {
 	rootCmd.AddCommand(listCmd)
 
 	listCmd.Flags().BoolVar(&showID, "show-id", false, "Show memory IDs in the output")
-	listCmd.Flags().StringVarP(&outputFlag, "output", "o", "", "Output format: table|json|yaml|jsonpath=<template>|go-template=<template>")
+	listCmd.Flags().StringVarP(&outputFlag, "output", "o", "", "Output format: table|json|jsonl|yaml|jsonpath=<template>|go-template=<template>|template=<name>")
+	listCmd.Flags().StringVar(&listSortBy, "sort-by", defaultSortBy, sortFlagUsage)
+	listCmd.Flags().BoolVar(&listReverse, "reverse", false, "Reverse the sort order")
+	listCmd.Flags().BoolVar(&listPinned, "pinned", false, "Only list pinned memories")
+	listCmd.Flags().BoolVar(&listExpired, "include-expired", true, "Include memories whose TTL has expired")
+	listCmd.Flags().BoolVarP(&listWatch, "watch", "w", false, "Watch for changes and redraw the output until interrupted")
+	listCmd.Flags().DurationVar(&listWatchInterval, "watch-interval", defaultWatchInterval, "How often --watch checks for changes")
 }
 
 func runList(cmd *cobra.Command, args []string) error {
 	// Initialize storage
-	storageDir := viper.GetString("storage-dir")
-	fs, err := storage.NewFileStorage(storageDir)
+	fs, err := getStorageProvider()
 	if err != nil {
 		return fmt.Errorf("failed to initialize storage: %w", err)
 	}
 
-	// List memories
-	memories, err := fs.List()
-	if err != nil {
-		return fmt.Errorf("failed to list memories: %w", err)
-	}
-
 	// Parse output format
-	outputOpts, err := ParseOutputFormat(outputFlag)
+	outputOpts, err := ParseOutputFormat(outputFormatOrDefault(outputFlag))
 	if err != nil {
 		return fmt.Errorf("invalid output format: %w", err)
 	}
+	outputOpts.Color = shouldColorize(os.Stdout)
+	outputOpts.Width = tableWidth(os.Stdout)
+	if err := validateSortBy(listSortBy); err != nil {
+		return err
+	}
 
-	// Format and print output
-	output, err := FormatMemoryList(memories, outputOpts, showID)
-	if err != nil {
-		return fmt.Errorf("failed to format output: %w", err)
+	render := func() (string, error) {
+		memories, err := fs.List()
+		if err != nil {
+			return "", fmt.Errorf("failed to list memories: %w", err)
+		}
+		if listPinned {
+			memories = filterPinned(memories)
+		}
+		if !listExpired {
+			memories = filterExpired(memories, time.Now())
+		}
+		if err := sortMemories(memories, listSortBy, listReverse); err != nil {
+			return "", err
+		}
+		sortPinnedFirst(memories)
+		output, err := FormatMemoryList(memories, outputOpts, showID)
+		if err != nil {
+			return "", fmt.Errorf("failed to format output: %w", err)
+		}
+		return output, nil
 	}
 
+	if listWatch {
+		return runWatch(cmd, listWatchInterval, render)
+	}
+
+	output, err := render()
+	if err != nil {
+		return err
+	}
 	fmt.Print(output)
 	return nil
 }
@@ -1,7 +1,12 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/cursor"
@@ -12,6 +17,14 @@ var (
 	listWorkspace string
 	listSearch    string
 	listLimit     int
+	listSource    string
+	listSince     string
+	listUntil     string
+	listSort      string
+	listDiagnose  bool
+	listNoMerge   bool
+	listPreview   int
+	listProject   string
 )
 
 // listCursorChatsCmd represents the list-cursor-chats command
@@ -23,6 +36,12 @@ var listCursorChatsCmd = &cobra.Command{
 This command helps you discover what chats are available for import
 from Cursor's AI pane across all workspaces.
 
+Workspaces are gathered from every Cursor install found: the stable,
+Nightly and Insiders channels, under $XDG_CONFIG_HOME as well as ~/.config
+on Linux, and a portable install whose data directory is $CURSOR_PORTABLE.
+--workspace reads only the path given. Run with -v=2 to see which
+locations were scanned.
+
 Examples:
   # List all available chats
   cmctl list-cursor-chats
@@ -33,48 +52,118 @@ Examples:
   # List chats from specific workspace
   cmctl list-cursor-chats --workspace /path/to/state.vscdb
 
+  # List chats from the workspace of the current directory, or of a project
+  cmctl list-cursor-chats --project
+  cmctl list-cursor-chats --project=$HOME/src/api
+
   # Limit number of results
-  cmctl list-cursor-chats --limit 5`,
+  cmctl list-cursor-chats --limit 5
+
+  # Preview the first three messages of each chat
+  cmctl list-cursor-chats --preview-lines 3
+
+  # List chats from the last day, or from a date range, oldest first
+  cmctl list-cursor-chats --since 1d
+  cmctl list-cursor-chats --since 2025-09-01 --until 2025-09-15 --sort asc
+
+  # List GitHub Copilot chats from VS Code instead
+  cmctl list-cursor-chats --source vscode
+
+  # Show which chat keys each workspace database holds (include this when
+  # reporting chats that don't import)
+  cmctl list-cursor-chats --diagnose`,
 	RunE: runListCursorChats,
 }
 
 func init() {
 	rootCmd.AddCommand(listCursorChatsCmd)
 
-	listCursorChatsCmd.Flags().StringVar(&listWorkspace, "workspace", "", "Path to specific workspace database")
+	listCursorChatsCmd.Flags().StringVar(&listWorkspace, "workspace", "", "Path to a workspace database, workspace directory, or workspaceStorage root")
+	listCursorChatsCmd.Flags().StringVar(&listProject, "project", "", "Only show chats from the workspace opened on this project folder (--project=<path>; the current directory if no path is given)")
+	listCursorChatsCmd.Flags().Lookup("project").NoOptDefVal = projectFlagCurrent
 	listCursorChatsCmd.Flags().StringVar(&listSearch, "search", "", "Search for chats containing text")
 	listCursorChatsCmd.Flags().IntVar(&listLimit, "limit", 20, "Maximum number of chats to show")
+	listCursorChatsCmd.Flags().IntVar(&listPreview, "preview-lines", 1, "Preview the first N messages of each chat on their own lines (0 to hide the preview)")
+	listCursorChatsCmd.Flags().StringVar(&listSince, "since", "", "Only show chats newer than this (YYYY-MM-DD, RFC3339, or relative like 3d)")
+	listCursorChatsCmd.Flags().StringVar(&listUntil, "until", "", "Only show chats older than this (YYYY-MM-DD, RFC3339, or relative like 3d)")
+	listCursorChatsCmd.Flags().StringVar(&listSort, "sort", "desc", "Sort chats by date (asc, desc)")
+	listCursorChatsCmd.Flags().StringVar(&listSource, "source", chatSourceCursor, "Editor to read chats from (cursor, vscode)")
+	listCursorChatsCmd.Flags().BoolVar(&listNoMerge, "no-merge", false, "Keep each recorded part of a continued conversation as a separate chat")
+	listCursorChatsCmd.Flags().BoolVar(&listDiagnose, "diagnose", false, "Report the known chat keys found in each workspace database, their sizes and the parser that handled them")
 }
 
 func runListCursorChats(cmd *cobra.Command, args []string) error {
+	if listSort != "asc" && listSort != "desc" {
+		return fmt.Errorf("invalid sort order %q (must be asc or desc)", listSort)
+	}
+
+	now := time.Now()
+	var since, until time.Time
+	if listSince != "" {
+		t, err := parseTimeSpec(listSince, now)
+		if err != nil {
+			return err
+		}
+		since = t
+	}
+	if listUntil != "" {
+		t, err := parseTimeSpec(listUntil, now)
+		if err != nil {
+			return err
+		}
+		until = t
+	}
+
+	if listPreview < 0 {
+		return fmt.Errorf("--preview-lines must not be negative")
+	}
+
 	// Initialize workspace reader
-	var reader *cursor.WorkspaceReader
-	if listWorkspace != "" {
-		reader = cursor.NewWorkspaceReaderWithPath(listWorkspace)
-	} else {
-		reader = cursor.NewWorkspaceReader()
+	reader, err := newChatReader(listSource, listWorkspace, listProject, listNoMerge)
+	if err != nil {
+		return err
+	}
+
+	if listDiagnose {
+		diagnoser, ok := reader.(cursor.ChatDiagnoser)
+		if !ok {
+			return fmt.Errorf("--diagnose is not supported for %s", chatSourceName(listSource))
+		}
+		diagnoses, err := diagnoser.Diagnose()
+		if err != nil {
+			return fmt.Errorf("failed to diagnose workspaces: %w", err)
+		}
+		printDiagnoses(os.Stdout, diagnoses)
+		return nil
 	}
 
 	var chats []cursor.ChatTabWithWorkspace
-	var err error
 
 	if listSearch != "" {
 		chats, err = reader.SearchChats(listSearch)
-		if err != nil {
-			return fmt.Errorf("failed to search chats: %w", err)
-		}
 	} else {
 		chats, err = reader.ListAllChats()
-		if err != nil {
-			return fmt.Errorf("failed to list chats: %w", err)
-		}
 	}
+	var schemaErr *cursor.UnrecognizedSchemaError
+	if errors.As(err, &schemaErr) {
+		printUnrecognizedSchema(os.Stdout, chatSourceName(listSource), err)
+		return nil
+	}
+	if err != nil && listSearch != "" {
+		return fmt.Errorf("failed to search chats: %w", err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to list chats: %w", err)
+	}
+
+	chats = filterChatsByTime(chats, since, until)
+	sortChatsByTime(chats, listSort)
 
 	if len(chats) == 0 {
 		if listSearch != "" {
 			fmt.Printf("No chats found matching '%s'\n", listSearch)
 		} else {
-			fmt.Println("No chats found in Cursor workspaces")
+			fmt.Printf("No chats found in %s workspaces\n", chatSourceName(listSource))
 		}
 		return nil
 	}
@@ -113,7 +202,11 @@ func runListCursorChats(cmd *cobra.Command, args []string) error {
 			fmt.Printf("  Concepts: %s\n", conceptsStr)
 		}
 
-		fmt.Printf("  Preview: %s\n", truncateString(chat.GetContentPreview(150), 150))
+		if lowConfidence := chat.LowConfidenceRoleCount(); lowConfidence > 0 {
+			fmt.Printf("  Roles: %d message(s) with uncertain user/assistant attribution\n", lowConfidence)
+		}
+
+		printChatPreview(os.Stdout, &chat.ChatTab, listPreview)
 		fmt.Println()
 	}
 
@@ -123,3 +216,124 @@ func runListCursorChats(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// previewLineLength is the width of each --preview-lines line
+const previewLineLength = 150
+
+// printChatPreview writes a preview of the chat: one truncated line of its
+// opening messages, or with lines above 1, that many messages one per line
+func printChatPreview(w io.Writer, chat *cursor.ChatTab, lines int) {
+	switch {
+	case lines == 0:
+		return
+	case lines == 1:
+		fmt.Fprintf(w, "  Preview: %s\n", truncateString(chat.GetContentPreview(previewLineLength), previewLineLength))
+	default:
+		fmt.Fprintf(w, "  Preview:\n")
+		for _, line := range chat.GetTurnPreviews(lines, previewLineLength) {
+			fmt.Fprintf(w, "    %s\n", line)
+		}
+	}
+}
+
+// printUnrecognizedSchema explains that no chats were found because the
+// workspaces hold chat data in a format cmctl can't read yet
+func printUnrecognizedSchema(w io.Writer, source string, err error) {
+	fmt.Fprintf(w, "No chats found: %s workspaces hold chat data in a format cmctl doesn't recognize:\n", source)
+	for _, line := range strings.Split(err.Error(), "\n") {
+		fmt.Fprintf(w, "  %s\n", line)
+	}
+	fmt.Fprintf(w, "Please file an issue about the new format, including the output of 'cmctl list-cursor-chats --diagnose'.\n")
+}
+
+// maxUnknownKeys limits how many unrecognized keys are listed per workspace
+const maxUnknownKeys = 10
+
+// printDiagnoses writes a report of the chat keys found in each workspace
+func printDiagnoses(w io.Writer, diagnoses []cursor.WorkspaceDiagnosis) {
+	if len(diagnoses) == 0 {
+		fmt.Fprintln(w, "No workspace databases found")
+		return
+	}
+
+	for _, diagnosis := range diagnoses {
+		fmt.Fprintf(w, "Workspace: %s\n", diagnosis.Path)
+		if diagnosis.Error != "" {
+			fmt.Fprintf(w, "  Error: %s\n\n", diagnosis.Error)
+			continue
+		}
+
+		for _, kd := range diagnosis.Keys {
+			if !kd.Found() {
+				fmt.Fprintf(w, "  %-50s not found\n", kd.Key)
+				continue
+			}
+			result := fmt.Sprintf("%d chat(s)", kd.Chats)
+			if kd.Error != "" {
+				result = "parse error: " + kd.Error
+			}
+			fmt.Fprintf(w, "  %-50s %d row(s), %d bytes, parser %s: %s\n", kd.Key, kd.Rows, kd.Bytes, kd.Parser, result)
+		}
+
+		if len(diagnosis.UnknownKeys) > 0 {
+			fmt.Fprintf(w, "  Unrecognized chat-like keys:\n")
+			for i, key := range diagnosis.UnknownKeys {
+				if i == maxUnknownKeys {
+					fmt.Fprintf(w, "    ... (+%d more)\n", len(diagnosis.UnknownKeys)-maxUnknownKeys)
+					break
+				}
+				fmt.Fprintf(w, "    %s\n", key)
+			}
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+// chatInTimeRange reports whether a chat timestamp (in milliseconds) falls
+// within [since, until]. Zero bounds are open; chats without a timestamp
+// never match once a bound is set.
+func chatInTimeRange(timestamp int64, since, until time.Time) bool {
+	if since.IsZero() && until.IsZero() {
+		return true
+	}
+	if timestamp == 0 {
+		return false
+	}
+	if !since.IsZero() && timestamp < since.UnixMilli() {
+		return false
+	}
+	if !until.IsZero() && timestamp > until.UnixMilli() {
+		return false
+	}
+	return true
+}
+
+// filterChatsByTime returns the chats within [since, until]
+func filterChatsByTime(chats []cursor.ChatTabWithWorkspace, since, until time.Time) []cursor.ChatTabWithWorkspace {
+	if since.IsZero() && until.IsZero() {
+		return chats
+	}
+
+	var filtered []cursor.ChatTabWithWorkspace
+	for _, chat := range chats {
+		if chatInTimeRange(chat.Timestamp, since, until) {
+			filtered = append(filtered, chat)
+		}
+	}
+	return filtered
+}
+
+// sortChatsByTime orders chats by timestamp, "asc" for oldest first or
+// "desc" for newest first. Chats without a timestamp always sort last.
+func sortChatsByTime(chats []cursor.ChatTabWithWorkspace, order string) {
+	sort.SliceStable(chats, func(i, j int) bool {
+		ti, tj := chats[i].Timestamp, chats[j].Timestamp
+		if ti == 0 || tj == 0 {
+			return tj == 0 && ti != 0
+		}
+		if order == "asc" {
+			return ti < tj
+		}
+		return ti > tj
+	})
+}
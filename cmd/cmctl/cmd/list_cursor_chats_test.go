@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/cursor"
+)
+
+func fixtureChatsWithTimestamps() []cursor.ChatTabWithWorkspace {
+	day := func(d int) int64 {
+		return time.Date(2025, 9, d, 12, 0, 0, 0, time.UTC).UnixMilli()
+	}
+	return []cursor.ChatTabWithWorkspace{
+		{ChatTab: cursor.ChatTab{ID: "undated"}},
+		{ChatTab: cursor.ChatTab{ID: "sep-10", Timestamp: day(10)}},
+		{ChatTab: cursor.ChatTab{ID: "sep-01", Timestamp: day(1)}},
+		{ChatTab: cursor.ChatTab{ID: "sep-20", Timestamp: day(20)}},
+	}
+}
+
+func chatIDs(chats []cursor.ChatTabWithWorkspace) []string {
+	ids := []string{}
+	for _, chat := range chats {
+		ids = append(ids, chat.ID)
+	}
+	return ids
+}
+
+func TestFilterChatsByTime(t *testing.T) {
+	at := func(d int) time.Time {
+		return time.Date(2025, 9, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name  string
+		since time.Time
+		until time.Time
+		want  []string
+	}{
+		{name: "no bounds", want: []string{"undated", "sep-10", "sep-01", "sep-20"}},
+		{name: "since", since: at(5), want: []string{"sep-10", "sep-20"}},
+		{name: "until", until: at(15), want: []string{"sep-10", "sep-01"}},
+		{name: "range", since: at(5), until: at(15), want: []string{"sep-10"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := chatIDs(filterChatsByTime(fixtureChatsWithTimestamps(), tt.since, tt.until))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSortChatsByTime(t *testing.T) {
+	tests := []struct {
+		order string
+		want  []string
+	}{
+		{order: "desc", want: []string{"sep-20", "sep-10", "sep-01", "undated"}},
+		{order: "asc", want: []string{"sep-01", "sep-10", "sep-20", "undated"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.order, func(t *testing.T) {
+			chats := fixtureChatsWithTimestamps()
+			sortChatsByTime(chats, tt.order)
+			if got := chatIDs(chats); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParseTimeSpec(t *testing.T) {
+	now := time.Date(2025, 9, 20, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		spec    string
+		want    time.Time
+		wantErr bool
+	}{
+		{spec: "2025-09-01", want: time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)},
+		{spec: "2025-09-01T08:30:00Z", want: time.Date(2025, 9, 1, 8, 30, 0, 0, time.UTC)},
+		{spec: "3d", want: now.Add(-72 * time.Hour)},
+		{spec: "2w", want: now.Add(-14 * 24 * time.Hour)},
+		{spec: "90m", want: now.Add(-90 * time.Minute)},
+		{spec: "yesterday", wantErr: true},
+		{spec: "-3d", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := parseTimeSpec(tt.spec, now)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error for %q, got %v", tt.spec, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseTimeSpec failed: %v", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPrintDiagnoses(t *testing.T) {
+	var buf bytes.Buffer
+	printDiagnoses(&buf, []cursor.WorkspaceDiagnosis{
+		{
+			Path: "/ws/a/state.vscdb",
+			Keys: []cursor.KeyDiagnosis{
+				{Key: "aiService.prompts", Parser: "prompts", Rows: 1, Bytes: 120, Chats: 1},
+				{Key: "aiService.generations", Parser: "generations"},
+				{Key: "composer.composerData", Parser: "composer", Rows: 1, Bytes: 8, Error: "unexpected end of JSON input"},
+			},
+			UnknownKeys: []string{"chatV3:abc"},
+		},
+		{Path: "/ws/b/state.vscdb", Error: "file is not a database"},
+	})
+
+	output := buf.String()
+	for _, want := range []string{
+		"Workspace: /ws/a/state.vscdb",
+		"1 row(s), 120 bytes, parser prompts: 1 chat(s)",
+		"aiService.generations",
+		"not found",
+		"parser composer: parse error: unexpected end of JSON input",
+		"Unrecognized chat-like keys:\n    chatV3:abc",
+		"Error: file is not a database",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, output)
+		}
+	}
+}
+
+func TestPrintUnrecognizedSchema(t *testing.T) {
+	var buf bytes.Buffer
+	err := errors.Join(
+		&cursor.UnrecognizedSchemaError{Path: "/ws/a/state.vscdb", Keys: []string{"chatV3:abc"}},
+		&cursor.UnrecognizedSchemaError{Path: "/ws/b/state.vscdb", Keys: []string{"chatV3:def", "chatV3:ghi"}},
+	)
+	printUnrecognizedSchema(&buf, "Cursor", err)
+
+	output := buf.String()
+	for _, want := range []string{
+		"No chats found: Cursor workspaces hold chat data in a format cmctl doesn't recognize",
+		"  unrecognized chat format in /ws/a/state.vscdb (keys: chatV3:abc)\n",
+		"  unrecognized chat format in /ws/b/state.vscdb (keys: chatV3:def, chatV3:ghi)\n",
+		"cmctl list-cursor-chats --diagnose",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, output)
+		}
+	}
+}
+
+func TestPrintChatPreview(t *testing.T) {
+	chat := testChat("chat-1", "Why does my test fail?")
+
+	tests := []struct {
+		lines    int
+		expected string
+	}{
+		{0, ""},
+		{1, "  Preview: User: Why does my test fail?\nAssistant: Here's how to fix it.\n\n"},
+		{2, "  Preview:\n    User: Why does my test fail?\n    Assistant: Here's how to fix it.\n"},
+		{5, "  Preview:\n    User: Why does my test fail?\n    Assistant: Here's how to fix it.\n"},
+	}
+	for _, tt := range tests {
+		var out strings.Builder
+		printChatPreview(&out, chat, tt.lines)
+		if out.String() != tt.expected {
+			t.Errorf("With %d lines, expected %q, got %q", tt.lines, tt.expected, out.String())
+		}
+	}
+}
@@ -0,0 +1,452 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/api"
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/providers"
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+// mcpProtocolVersion is the MCP revision this server implements
+const mcpProtocolVersion = "2024-11-05"
+
+// mcpDefaultSearchLimit keeps search results small enough for a model's
+// context when the client doesn't ask for a limit
+const mcpDefaultSearchLimit = 10
+
+// mcpMemoryURIPrefix identifies memories exposed as MCP resources
+const mcpMemoryURIPrefix = "memory://"
+
+// JSON-RPC 2.0 error codes
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcInternalError  = -32603
+)
+
+var mcpCmd = &cobra.Command{
+	Use:   "mcp",
+	Short: "Run a Model Context Protocol server over stdio",
+	Long: `Run a Model Context Protocol (MCP) server on stdin/stdout so AI clients such
+as Claude Desktop or Cursor can search and read stored memories directly.
+
+Tools:
+  search_memories   Search by text query and labels
+  get_memory        Get a memory by ID
+  create_memory     Store a new memory
+
+Every memory is also exposed as a resource with a memory://<id> URI.
+
+Example client configuration:
+  {
+    "mcpServers": {
+      "contextmemory": {"command": "cmctl", "args": ["mcp"]}
+    }
+  }`,
+	Args: cobra.NoArgs,
+	RunE: runMCP,
+}
+
+func init() {
+	rootCmd.AddCommand(mcpCmd)
+}
+
+func runMCP(cmd *cobra.Command, args []string) error {
+	fs, err := getStorageProvider()
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	DebugPrintf("MCP server ready on stdio\n")
+	return newMCPServer(fs).serve(os.Stdin, os.Stdout)
+}
+
+// rpcRequest is a JSON-RPC 2.0 request or notification
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// rpcResponse is a JSON-RPC 2.0 response
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string { return e.Message }
+
+// mcpTool describes a tool in tools/list
+type mcpTool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	InputSchema map[string]any `json:"inputSchema"`
+}
+
+// mcpContent is a text content block in a tool or resource result
+type mcpContent struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type mcpToolResult struct {
+	Content []mcpContent `json:"content"`
+	IsError bool         `json:"isError,omitempty"`
+}
+
+type mcpResource struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType"`
+}
+
+type mcpResourceContents struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+// getMemoryArgs are the arguments of the get_memory tool
+type getMemoryArgs struct {
+	ID string `json:"id"`
+}
+
+// mcpServer answers MCP requests from the memory store
+type mcpServer struct {
+	fs    providers.StorageProvider
+	tools []mcpTool
+}
+
+func newMCPServer(fs providers.StorageProvider) *mcpServer {
+	return &mcpServer{
+		fs: fs,
+		tools: []mcpTool{
+			{
+				Name:        "search_memories",
+				Description: "Search stored memories (notes, code snippets, imported AI chats) by text and labels. Returns matching memories with their content.",
+				InputSchema: toolSchema(storage.SearchRequest{}, map[string]string{
+					"query":         "Case-insensitive text to find in memory names and content",
+					"labelSelector": "Labels that must all match exactly, e.g. {\"type\": \"chat\"}",
+					"limit":         fmt.Sprintf("Maximum number of results (default %d)", mcpDefaultSearchLimit),
+				}),
+			},
+			{
+				Name:        "get_memory",
+				Description: "Get a stored memory by its ID.",
+				InputSchema: toolSchema(getMemoryArgs{}, map[string]string{
+					"id": "Memory ID, e.g. mem_18a3c2f4e5b6d7c8_9f2e4a1b3c",
+				}, "id"),
+			},
+			{
+				Name:        "create_memory",
+				Description: "Store a new memory for later sessions.",
+				InputSchema: toolSchema(storage.CreateMemoryRequest{}, map[string]string{
+					"name":    "Short descriptive name",
+					"content": "Content to remember",
+					"labels":  "Labels for organizing the memory, e.g. {\"type\": \"notes\"}",
+				}, "content"),
+			},
+		},
+	}
+}
+
+// toolSchema builds a JSON Schema for the fields of v named in
+// descriptions, using their JSON names and Go types
+func toolSchema(v any, descriptions map[string]string, required ...string) map[string]any {
+	properties := map[string]any{}
+	t := reflect.TypeOf(v)
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		description, ok := descriptions[name]
+		if !ok {
+			continue
+		}
+		property := jsonSchemaType(field.Type)
+		property["description"] = description
+		properties[name] = property
+	}
+
+	schema := map[string]any{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// jsonSchemaType maps a Go type to its JSON Schema type
+func jsonSchemaType(t reflect.Type) map[string]any {
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Int, reflect.Int64:
+		return map[string]any{"type": "integer"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": jsonSchemaType(t.Elem())}
+	default:
+		return map[string]any{}
+	}
+}
+
+// serve reads newline-delimited JSON-RPC messages from r and writes
+// responses to w until r is exhausted
+func (s *mcpServer) serve(r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxRequestBodyBytes)
+	encoder := json.NewEncoder(w)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if resp := s.handleMessage([]byte(line)); resp != nil {
+			if err := encoder.Encode(resp); err != nil {
+				return fmt.Errorf("failed to write response: %w", err)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read request: %w", err)
+	}
+	return nil
+}
+
+// handleMessage handles one JSON-RPC message, returning nil for
+// notifications
+func (s *mcpServer) handleMessage(data []byte) *rpcResponse {
+	var req rpcRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return &rpcResponse{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{Code: rpcParseError, Message: "parse error"}}
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return &rpcResponse{JSONRPC: "2.0", ID: idOrNull(req.ID), Error: &rpcError{Code: rpcInvalidRequest, Message: "invalid request"}}
+	}
+
+	result, err := s.dispatch(req)
+	if len(req.ID) == 0 {
+		return nil
+	}
+
+	resp := &rpcResponse{JSONRPC: "2.0", ID: req.ID, Result: result}
+	if err != nil {
+		var rpcErr *rpcError
+		if !errors.As(err, &rpcErr) {
+			rpcErr = &rpcError{Code: rpcInternalError, Message: err.Error()}
+		}
+		resp.Result = nil
+		resp.Error = rpcErr
+	}
+	return resp
+}
+
+func (s *mcpServer) dispatch(req rpcRequest) (any, error) {
+	switch req.Method {
+	case "initialize":
+		return map[string]any{
+			"protocolVersion": mcpProtocolVersion,
+			"capabilities": map[string]any{
+				"tools":     map[string]any{},
+				"resources": map[string]any{},
+			},
+			"serverInfo": map[string]any{
+				"name":    "cmctl",
+				"version": rootCmd.Version,
+			},
+		}, nil
+	case "ping":
+		return map[string]any{}, nil
+	case "tools/list":
+		return map[string]any{"tools": s.tools}, nil
+	case "tools/call":
+		return s.callTool(req.Params)
+	case "resources/list":
+		return s.listResources()
+	case "resources/read":
+		return s.readResource(req.Params)
+	default:
+		if strings.HasPrefix(req.Method, "notifications/") {
+			return nil, nil
+		}
+		return nil, &rpcError{Code: rpcMethodNotFound, Message: "method not found: " + req.Method}
+	}
+}
+
+// callTool runs a tool. Tool failures are reported in the result so the
+// model can see them; only malformed calls, including invalid memory IDs,
+// are protocol errors.
+func (s *mcpServer) callTool(params json.RawMessage) (any, error) {
+	var call struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	}
+	if err := json.Unmarshal(params, &call); err != nil {
+		return nil, &rpcError{Code: rpcInvalidParams, Message: "invalid params: " + err.Error()}
+	}
+	if len(call.Arguments) == 0 {
+		call.Arguments = json.RawMessage("{}")
+	}
+
+	var output any
+	var err error
+	switch call.Name {
+	case "search_memories":
+		output, err = s.searchMemories(call.Arguments)
+	case "get_memory":
+		output, err = s.getMemory(call.Arguments)
+	case "create_memory":
+		output, err = s.createMemory(call.Arguments)
+	default:
+		return nil, &rpcError{Code: rpcInvalidParams, Message: "unknown tool: " + call.Name}
+	}
+	var rpcErr *rpcError
+	if errors.As(err, &rpcErr) {
+		return nil, rpcErr
+	}
+	if err != nil {
+		return mcpToolResult{Content: []mcpContent{{Type: "text", Text: err.Error()}}, IsError: true}, nil
+	}
+
+	text, err := json.MarshalIndent(output, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcpToolResult{Content: []mcpContent{{Type: "text", Text: string(text)}}}, nil
+}
+
+func (s *mcpServer) searchMemories(arguments json.RawMessage) (any, error) {
+	var req storage.SearchRequest
+	if err := json.Unmarshal(arguments, &req); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	if req.Limit <= 0 {
+		req.Limit = mcpDefaultSearchLimit
+	}
+	req.IncludeContent = true
+
+	resp, err := s.fs.Search(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search memories: %w", err)
+	}
+	return api.NewMemoryListDocument(resp.Memories), nil
+}
+
+func (s *mcpServer) getMemory(arguments json.RawMessage) (any, error) {
+	var args getMemoryArgs
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	if args.ID == "" {
+		return nil, errors.New("id is required")
+	}
+	if err := storage.ValidateID(args.ID); err != nil {
+		return nil, &rpcError{Code: rpcInvalidParams, Message: err.Error()}
+	}
+
+	memory, err := s.fs.Get(args.ID)
+	if err != nil {
+		return nil, err
+	}
+	return api.NewMemoryDocument(memory), nil
+}
+
+func (s *mcpServer) createMemory(arguments json.RawMessage) (any, error) {
+	var req storage.CreateMemoryRequest
+	if err := json.Unmarshal(arguments, &req); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	if req.Content == "" {
+		return nil, errors.New("content is required")
+	}
+
+	memory, err := s.fs.Create(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create memory: %w", err)
+	}
+	return api.NewMemoryDocument(memory), nil
+}
+
+// listResources lists every memory as a resource
+func (s *mcpServer) listResources() (any, error) {
+	lister, ok := s.fs.(providers.OptimizedLister)
+	var memories []storage.Memory
+	var err error
+	if ok {
+		memories, err = lister.ListWithOptions(storage.ListOptions{UseIndex: true})
+	} else {
+		memories, err = s.fs.List()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list memories: %w", err)
+	}
+
+	resources := make([]mcpResource, 0, len(memories))
+	for _, memory := range memories {
+		resources = append(resources, mcpResource{
+			URI:         mcpMemoryURIPrefix + memory.ID,
+			Name:        memory.Name,
+			Description: formatLabels(memory.Labels),
+			MimeType:    "text/markdown",
+		})
+	}
+	return map[string]any{"resources": resources}, nil
+}
+
+// readResource returns the content of a memory resource
+func (s *mcpServer) readResource(params json.RawMessage) (any, error) {
+	var req struct {
+		URI string `json:"uri"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, &rpcError{Code: rpcInvalidParams, Message: "invalid params: " + err.Error()}
+	}
+	id, ok := strings.CutPrefix(req.URI, mcpMemoryURIPrefix)
+	if !ok || id == "" {
+		return nil, &rpcError{Code: rpcInvalidParams, Message: "unknown resource: " + req.URI}
+	}
+	if err := storage.ValidateID(id); err != nil {
+		return nil, &rpcError{Code: rpcInvalidParams, Message: err.Error()}
+	}
+
+	memory, err := s.fs.Get(id)
+	if errors.Is(err, storage.ErrMemoryNotFound) {
+		return nil, &rpcError{Code: rpcInvalidParams, Message: err.Error()}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{
+		"contents": []mcpResourceContents{{URI: req.URI, MimeType: "text/markdown", Text: memory.Content}},
+	}, nil
+}
+
+func idOrNull(id json.RawMessage) json.RawMessage {
+	if len(id) == 0 {
+		return json.RawMessage("null")
+	}
+	return id
+}
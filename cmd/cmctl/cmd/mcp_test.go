@@ -0,0 +1,229 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/api"
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+)
+
+// mcpCall sends one JSON-RPC request and decodes the response
+func mcpCall(t *testing.T, s *mcpServer, request string) rpcResponse {
+	t.Helper()
+	resp := s.handleMessage([]byte(request))
+	if resp == nil {
+		t.Fatalf("Expected a response to %s", request)
+	}
+	data, err := json.Marshal(resp)
+	if err != nil {
+		t.Fatalf("Failed to marshal response: %v", err)
+	}
+	var decoded rpcResponse
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	return decoded
+}
+
+// toolText returns the text of a tools/call result
+func toolText(t *testing.T, resp rpcResponse) (string, bool) {
+	t.Helper()
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %+v", resp.Error)
+	}
+	data, _ := json.Marshal(resp.Result)
+	var result mcpToolResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		t.Fatalf("Failed to decode tool result: %v", err)
+	}
+	if len(result.Content) != 1 {
+		t.Fatalf("Expected 1 content block, got %d", len(result.Content))
+	}
+	return result.Content[0].Text, result.IsError
+}
+
+func TestMCPInitializeAndListTools(t *testing.T) {
+	s := newMCPServer(newTestStorage(t))
+
+	resp := mcpCall(t, s, `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`)
+	result, _ := resp.Result.(map[string]any)
+	if result["protocolVersion"] != mcpProtocolVersion {
+		t.Errorf("Expected protocol version %s, got %v", mcpProtocolVersion, result["protocolVersion"])
+	}
+
+	if resp := s.handleMessage([]byte(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)); resp != nil {
+		t.Errorf("Expected no response to a notification, got %+v", resp)
+	}
+
+	resp = mcpCall(t, s, `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`)
+	data, _ := json.Marshal(resp.Result)
+	var tools struct {
+		Tools []mcpTool `json:"tools"`
+	}
+	if err := json.Unmarshal(data, &tools); err != nil {
+		t.Fatalf("Failed to decode tools: %v", err)
+	}
+	names := map[string]mcpTool{}
+	for _, tool := range tools.Tools {
+		names[tool.Name] = tool
+	}
+	for _, name := range []string{"search_memories", "get_memory", "create_memory"} {
+		if _, ok := names[name]; !ok {
+			t.Errorf("Expected tool %s in %v", name, tools.Tools)
+		}
+	}
+
+	search := names["search_memories"].InputSchema["properties"].(map[string]any)
+	if _, ok := search["sortBy"]; ok {
+		t.Error("Expected search schema to only expose described fields")
+	}
+	labelSelector := search["labelSelector"].(map[string]any)
+	if labelSelector["type"] != "object" {
+		t.Errorf("Expected labelSelector to be an object, got %v", labelSelector["type"])
+	}
+	if limit := search["limit"].(map[string]any); limit["type"] != "integer" {
+		t.Errorf("Expected limit to be an integer, got %v", limit["type"])
+	}
+}
+
+func TestMCPTools(t *testing.T) {
+	s := newMCPServer(newTestStorage(t))
+
+	text, isError := toolText(t, mcpCall(t, s, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"create_memory","arguments":{"name":"Auth notes","content":"Use OAuth with PKCE","labels":{"type":"notes"}}}}`))
+	if isError {
+		t.Fatalf("Failed to create memory: %s", text)
+	}
+	var created api.MemoryDocument
+	if err := json.Unmarshal([]byte(text), &created); err != nil {
+		t.Fatalf("Failed to decode created memory: %v", err)
+	}
+	id := created.Spec.ID
+
+	text, isError = toolText(t, mcpCall(t, s, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"get_memory","arguments":{"id":"`+id+`"}}}`))
+	if isError || !strings.Contains(text, "Use OAuth with PKCE") {
+		t.Errorf("Expected memory content, got %s", text)
+	}
+
+	text, isError = toolText(t, mcpCall(t, s, `{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"search_memories","arguments":{"query":"pkce","labelSelector":{"type":"notes"}}}}`))
+	var results api.MemoryListDocument
+	if err := json.Unmarshal([]byte(text), &results); err != nil || isError {
+		t.Fatalf("Failed to decode search results %s: %v", text, err)
+	}
+	if len(results.Items) != 1 || results.Items[0].ID != id {
+		t.Errorf("Expected search to find %s, got %+v", id, results.Items)
+	}
+
+	tests := []struct {
+		name    string
+		request string
+	}{
+		{name: "missing memory", request: `{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"get_memory","arguments":{"id":"mem_missing"}}}`},
+		{name: "missing id", request: `{"jsonrpc":"2.0","id":5,"method":"tools/call","params":{"name":"get_memory","arguments":{}}}`},
+		{name: "missing content", request: `{"jsonrpc":"2.0","id":6,"method":"tools/call","params":{"name":"create_memory","arguments":{"name":"empty"}}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if text, isError := toolText(t, mcpCall(t, s, tt.request)); !isError {
+				t.Errorf("Expected a tool error, got %s", text)
+			}
+		})
+	}
+}
+
+func TestMCPResources(t *testing.T) {
+	fs := newTestStorage(t)
+	s := newMCPServer(fs)
+
+	memory, err := fs.Create(storage.CreateMemoryRequest{Name: "Deploy steps", Content: "kubectl apply -k .", Labels: map[string]string{"type": "notes"}})
+	if err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+
+	resp := mcpCall(t, s, `{"jsonrpc":"2.0","id":1,"method":"resources/list"}`)
+	data, _ := json.Marshal(resp.Result)
+	var list struct {
+		Resources []mcpResource `json:"resources"`
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		t.Fatalf("Failed to decode resources: %v", err)
+	}
+	if len(list.Resources) != 1 || list.Resources[0].URI != "memory://"+memory.ID {
+		t.Fatalf("Expected one resource for %s, got %+v", memory.ID, list.Resources)
+	}
+
+	resp = mcpCall(t, s, `{"jsonrpc":"2.0","id":2,"method":"resources/read","params":{"uri":"memory://`+memory.ID+`"}}`)
+	if resp.Error != nil || !strings.Contains(mustMarshal(t, resp.Result), "kubectl apply -k .") {
+		t.Errorf("Expected resource contents, got %+v", resp)
+	}
+
+	resp = mcpCall(t, s, `{"jsonrpc":"2.0","id":3,"method":"resources/read","params":{"uri":"file:///etc/passwd"}}`)
+	if resp.Error == nil || resp.Error.Code != rpcInvalidParams {
+		t.Errorf("Expected invalid params for unknown resource, got %+v", resp)
+	}
+
+	for _, uri := range []string{"memory://../index", "memory://../../config"} {
+		resp = mcpCall(t, s, `{"jsonrpc":"2.0","id":4,"method":"resources/read","params":{"uri":"`+uri+`"}}`)
+		if resp.Error == nil || resp.Error.Code != rpcInvalidParams {
+			t.Errorf("Expected invalid params for %s, got %+v", uri, resp)
+		}
+	}
+}
+
+func TestMCPProtocolErrors(t *testing.T) {
+	s := newMCPServer(newTestStorage(t))
+
+	tests := []struct {
+		name    string
+		request string
+		want    int
+	}{
+		{name: "parse error", request: `{"jsonrpc":`, want: rpcParseError},
+		{name: "wrong version", request: `{"jsonrpc":"1.0","id":1,"method":"ping"}`, want: rpcInvalidRequest},
+		{name: "unknown method", request: `{"jsonrpc":"2.0","id":1,"method":"prompts/list"}`, want: rpcMethodNotFound},
+		{name: "unknown tool", request: `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"rm_rf"}}`, want: rpcInvalidParams},
+		{name: "invalid memory ID", request: `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"get_memory","arguments":{"id":"../index"}}}`, want: rpcInvalidParams},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := mcpCall(t, s, tt.request)
+			if resp.Error == nil || resp.Error.Code != tt.want {
+				t.Errorf("Expected error code %d, got %+v", tt.want, resp)
+			}
+		})
+	}
+}
+
+func TestMCPServe(t *testing.T) {
+	s := newMCPServer(newTestStorage(t))
+
+	input := strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		``,
+		`{"jsonrpc":"2.0","id":"two","method":"ping"}`,
+	}, "\n")
+	var output bytes.Buffer
+	if err := s.serve(strings.NewReader(input), &output); err != nil {
+		t.Fatalf("Failed to serve: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 responses, got %d: %s", len(lines), output.String())
+	}
+	if !strings.Contains(lines[1], `"id":"two"`) {
+		t.Errorf("Expected response ID to be echoed, got %s", lines[1])
+	}
+}
+
+func mustMarshal(t *testing.T, v any) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+	return string(data)
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/providers"
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var migrateTo string
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Copy memories into a different storage backend",
+	Long: `Copy every memory from the current storage provider into another one,
+keeping IDs, labels, metadata and timestamps. Memories already present in the
+target are skipped, so an interrupted migration can be re-run. The trash is
+not migrated.
+
+Both stores live in the same storage directory. After migrating, select the
+new backend with --provider or by setting "provider" in the config file.
+
+Examples:
+  # Convert the default file store to SQLite
+  cmctl migrate --to sqlite
+
+  # And back again
+  cmctl --provider sqlite migrate --to file`,
+	Args: cobra.NoArgs,
+	RunE: runMigrate,
+}
+
+func init() {
+	rootCmd.AddCommand(migrateCmd)
+
+	migrateCmd.Flags().StringVar(&migrateTo, "to", "", "target storage provider (file, git, sqlite)")
+	_ = migrateCmd.MarkFlagRequired("to")
+}
+
+func runMigrate(cmd *cobra.Command, args []string) error {
+	from := providers.ProviderType(viper.GetString("provider"))
+	to := providers.ProviderType(migrateTo)
+	if from == to {
+		return fmt.Errorf("source and target provider are both %s", to)
+	}
+
+	source, err := getStorageProvider()
+	if err != nil {
+		return fmt.Errorf("failed to initialize source storage: %w", err)
+	}
+	target, err := newStorageProvider(to)
+	if err != nil {
+		return fmt.Errorf("failed to initialize target storage: %w", err)
+	}
+
+	migrated, skipped, err := migrateMemories(source, target)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Migrated %d memories from %s to %s", migrated, from, to)
+	if skipped > 0 {
+		fmt.Printf(" (%d already present)", skipped)
+	}
+	fmt.Println()
+	VPrintf(Normal, "Use --provider %s (or set provider: %s in the config file) to use the new store\n", to, to)
+	return nil
+}
+
+// migrateMemories copies every memory from source into target, skipping
+// IDs the target already has. It returns the number copied and skipped.
+func migrateMemories(source, target providers.StorageProvider) (int, int, error) {
+	importer, ok := target.(providers.MemoryImporter)
+	if !ok {
+		return 0, 0, fmt.Errorf("storage provider %s does not support importing memories", target.GetProviderType())
+	}
+
+	memories, err := source.List()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to list memories: %w", err)
+	}
+
+	migrated, skipped := 0, 0
+	for _, memory := range memories {
+		if _, err := target.Get(memory.ID); err == nil {
+			skipped++
+			continue
+		} else if !errors.Is(err, storage.ErrMemoryNotFound) {
+			return migrated, skipped, fmt.Errorf("failed to check memory %s: %w", memory.ID, err)
+		}
+		if err := importer.Import(memory); err != nil {
+			return migrated, skipped, fmt.Errorf("failed to migrate memory %s: %w", memory.ID, err)
+		}
+		migrated++
+	}
+
+	return migrated, skipped, nil
+}
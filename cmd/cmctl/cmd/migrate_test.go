@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/providers"
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+)
+
+func TestMigrateMemories(t *testing.T) {
+	source := newTestStorage(t)
+	for _, name := range []string{"First", "Second"} {
+		if _, err := source.Create(storage.CreateMemoryRequest{
+			Name:    name,
+			Content: name + " content",
+			Labels:  map[string]string{"type": "notes"},
+		}); err != nil {
+			t.Fatalf("Failed to create memory: %v", err)
+		}
+	}
+
+	created, err := providers.NewSQLiteProvider(providers.ProviderConfig{
+		Type:       providers.SQLiteProvider,
+		StorageDir: source.StorageDir(),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create sqlite provider: %v", err)
+	}
+	target := created.(*providers.SQLiteStorageProvider)
+	defer target.Close()
+
+	migrated, skipped, err := migrateMemories(source, target)
+	if err != nil {
+		t.Fatalf("Migration failed: %v", err)
+	}
+	if migrated != 2 || skipped != 0 {
+		t.Errorf("Expected 2 migrated and 0 skipped, got %d and %d", migrated, skipped)
+	}
+
+	memories, err := source.List()
+	if err != nil {
+		t.Fatalf("Failed to list memories: %v", err)
+	}
+	for _, memory := range memories {
+		got, err := target.Get(memory.ID)
+		if err != nil {
+			t.Fatalf("Expected %s in target: %v", memory.ID, err)
+		}
+		if got.Content != memory.Content || got.Labels["type"] != "notes" || !got.CreatedAt.Equal(memory.CreatedAt) {
+			t.Errorf("Expected migrated memory to match, got %+v", got)
+		}
+	}
+
+	// Re-running skips everything already migrated
+	migrated, skipped, err = migrateMemories(source, target)
+	if err != nil {
+		t.Fatalf("Second migration failed: %v", err)
+	}
+	if migrated != 0 || skipped != 2 {
+		t.Errorf("Expected 0 migrated and 2 skipped, got %d and %d", migrated, skipped)
+	}
+}
@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+var openCmd = &cobra.Command{
+	Use:     "open <memory-id>",
+	Aliases: []string{"view"},
+	Short:   "View a memory in the pager",
+	Long: `Show a memory through $PAGER (falling back to less, then more). When
+stdout isn't a terminal the memory is written to stdout instead. Markdown
+headings and code fences are highlighted unless colors are disabled, and
+memories whose content type is source code are shown as code.
+
+Examples:
+  cmctl open mem_abc123_def456            # Page through a memory
+  cmctl view mem_abc123_def456            # Same as open
+  cmctl open mem_abc123_def456 --raw      # Print the content only, without the pager
+  PAGER="less -S" cmctl open mem_abc123_def456`,
+	Args: cobra.ExactArgs(1),
+	RunE: runOpen,
+}
+
+var openRaw bool
+
+func init() {
+	rootCmd.AddCommand(openCmd)
+
+	openCmd.Flags().BoolVar(&openRaw, "raw", false, "Print the raw content to stdout without the pager or header")
+}
+
+func runOpen(cmd *cobra.Command, args []string) error {
+	fs, err := getStorageProvider()
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+
+	memory, err := fs.Get(args[0])
+	if err != nil {
+		return err
+	}
+
+	if openRaw {
+		fmt.Print(memory.Content)
+		if !strings.HasSuffix(memory.Content, "\n") {
+			fmt.Println()
+		}
+		return nil
+	}
+
+	text := renderMemoryView(memory, shouldColorize(os.Stdout))
+	return page(text, os.Stdout, isTerminal(os.Stdout))
+}
+
+// renderMemoryView formats a memory for reading: a short header followed by
+// the content, with markdown highlighted when color is enabled
+func renderMemoryView(memory *storage.Memory, color bool) string {
+	var b strings.Builder
+	b.WriteString(colorize(memory.Name, ansiBold, color) + "\n")
+	meta := fmt.Sprintf("%s  updated %s", memory.ID, memory.UpdatedAt.Format("2006-01-02 15:04"))
+	if len(memory.Labels) > 0 {
+		meta += "  " + formatLabels(memory.Labels)
+	}
+	b.WriteString(colorize(meta, ansiGray, color) + "\n\n")
+
+	content := memory.Content
+	if color {
+		switch {
+		case isMarkdown(memory):
+			content = highlightMarkdown(content)
+		case storage.CodeLanguage(memory.ContentType()) != "":
+			content = highlightCode(content)
+		}
+	}
+	b.WriteString(content)
+	if !strings.HasSuffix(content, "\n") {
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// isMarkdown reports whether a memory's content is markdown, going by its
+// content type. Memories created before content types were recorded are
+// guessed: chat imports always are markdown, and other memories are checked
+// for headings or code fences.
+func isMarkdown(memory *storage.Memory) bool {
+	if contentType := memory.ContentType(); contentType != "" {
+		return contentType == storage.ContentTypeMarkdown
+	}
+	if memory.Labels["type"] == "chat" {
+		return true
+	}
+	return storage.LooksLikeMarkdown(memory.Content)
+}
+
+// highlightMarkdown colors headings, block quotes and fenced code blocks
+func highlightMarkdown(content string) string {
+	lines := strings.Split(content, "\n")
+	inFence := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "```"):
+			inFence = !inFence
+			lines[i] = colorize(line, ansiGray, true)
+		case inFence:
+			lines[i] = colorize(line, ansiGreen, true)
+		case strings.HasPrefix(trimmed, "#"):
+			lines[i] = colorize(line, ansiBold+ansiCyan, true)
+		case strings.HasPrefix(trimmed, ">"):
+			lines[i] = colorize(line, ansiGray, true)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// highlightCode colors source code as highlightMarkdown colors fenced code
+func highlightCode(content string) string {
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		lines[i] = colorize(line, ansiGreen, true)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// pagerCommand returns the pager to run: $PAGER, then less, then more. It
+// returns nil if no pager is available or $PAGER is "cat".
+func pagerCommand() []string {
+	if pager := strings.TrimSpace(os.Getenv("PAGER")); pager != "" {
+		if pager == "cat" {
+			return nil
+		}
+		if runtime.GOOS == "windows" {
+			return strings.Fields(pager)
+		}
+		// Run through the shell so $PAGER may carry arguments and quoting
+		return []string{"sh", "-c", pager}
+	}
+	for _, candidate := range []string{"less", "more"} {
+		if _, err := exec.LookPath(candidate); err == nil {
+			return []string{candidate}
+		}
+	}
+	return nil
+}
+
+// page pipes text through the pager when out is a terminal, and writes it to
+// out directly otherwise
+func page(text string, out io.Writer, terminal bool) error {
+	command := pagerCommand()
+	if !terminal || command == nil {
+		_, err := io.WriteString(out, text)
+		return err
+	}
+
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+	cmd.Stdout = out
+	cmd.Stderr = os.Stderr
+	if os.Getenv("LESS") == "" {
+		// Like git: quit if one screen, pass colors through, don't clear
+		cmd.Env = append(os.Environ(), "LESS=FRX")
+	}
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("pager %s failed: %w", command[0], err)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+)
+
+func TestPagePipesToPager(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("pager stub uses sh")
+	}
+
+	captured := filepath.Join(t.TempDir(), "paged.txt")
+	t.Setenv("PAGER", "cat > "+captured)
+
+	var out bytes.Buffer
+	if err := page("# Notes\nbody\n", &out, true); err != nil {
+		t.Fatalf("Failed to page: %v", err)
+	}
+
+	data, err := os.ReadFile(captured)
+	if err != nil {
+		t.Fatalf("Expected pager to receive content: %v", err)
+	}
+	if string(data) != "# Notes\nbody\n" {
+		t.Errorf("Expected content piped to pager, got %q", data)
+	}
+	if out.Len() != 0 {
+		t.Errorf("Expected nothing written directly, got %q", out.String())
+	}
+}
+
+func TestPageWithoutTerminal(t *testing.T) {
+	captured := filepath.Join(t.TempDir(), "paged.txt")
+	t.Setenv("PAGER", "cat > "+captured)
+
+	var out bytes.Buffer
+	if err := page("plain\n", &out, false); err != nil {
+		t.Fatalf("Failed to page: %v", err)
+	}
+	if out.String() != "plain\n" {
+		t.Errorf("Expected content on stdout, got %q", out.String())
+	}
+	if _, err := os.Stat(captured); err == nil {
+		t.Error("Expected the pager not to run without a terminal")
+	}
+}
+
+func TestRenderMemoryView(t *testing.T) {
+	memory := &storage.Memory{
+		ID:        "mem_1",
+		Name:      "Chat",
+		Content:   "## Question\n```go\nfmt.Println()\n```",
+		Labels:    map[string]string{"type": "chat"},
+		UpdatedAt: time.Date(2025, 1, 2, 3, 4, 0, 0, time.UTC),
+	}
+
+	plain := renderMemoryView(memory, false)
+	if strings.Contains(plain, "\033[") {
+		t.Errorf("Expected no ANSI codes without color, got %q", plain)
+	}
+	if !strings.Contains(plain, "mem_1  updated 2025-01-02 03:04  type=chat") {
+		t.Errorf("Expected header with ID and labels, got %q", plain)
+	}
+
+	colored := renderMemoryView(memory, true)
+	if !strings.Contains(colored, ansiBold+ansiCyan+"## Question"+ansiReset) {
+		t.Errorf("Expected highlighted heading, got %q", colored)
+	}
+	if !strings.Contains(colored, colorize("fmt.Println()", ansiGreen, true)) {
+		t.Errorf("Expected highlighted code, got %q", colored)
+	}
+}
+
+func TestRenderMemoryViewContentType(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		want        string
+	}{
+		// The recorded type wins over the markdown guess
+		{name: "plain text", contentType: storage.ContentTypePlain, want: "# not a heading"},
+		{name: "markdown", contentType: storage.ContentTypeMarkdown, want: colorize("# not a heading", ansiBold+ansiCyan, true)},
+		{name: "source code", contentType: "text/x-python", want: colorize("# not a heading", ansiGreen, true)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			memory := &storage.Memory{
+				ID:       "mem_1",
+				Name:     "Typed",
+				Content:  "# not a heading\n",
+				Metadata: map[string]any{storage.ContentTypeKey: tt.contentType},
+			}
+			colored := renderMemoryView(memory, true)
+			if !strings.HasSuffix(colored, "\n\n"+tt.want+"\n") {
+				t.Errorf("Expected content rendered as %q, got %q", tt.want, colored)
+			}
+		})
+	}
+}
@@ -7,7 +7,9 @@ import (
 	"strings"
 	"text/template"
 
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/api"
 	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+	"github.com/spf13/viper"
 	"gopkg.in/yaml.v3"
 	"k8s.io/client-go/util/jsonpath"
 )
@@ -18,6 +20,7 @@ type OutputFormat string
 const (
 	OutputFormatTable      OutputFormat = "table"
 	OutputFormatJSON       OutputFormat = "json"
+	OutputFormatJSONL      OutputFormat = "jsonl"
 	OutputFormatYAML       OutputFormat = "yaml"
 	OutputFormatJSONPath   OutputFormat = "jsonpath"
 	OutputFormatGoTemplate OutputFormat = "go-template"
@@ -27,6 +30,25 @@ const (
 type OutputOptions struct {
 	Format   OutputFormat
 	Template string // For jsonpath or go-template
+	// TemplateName is set when Template came from a named output template
+	TemplateName string
+	Color        bool // Apply ANSI colors to table output
+	// ShowScore adds a SCORE column with search relevance to tables
+	ShowScore bool
+	// Fields limits structured output to these memory fields
+	Fields []string
+	// PreviewWidth adds a CONTENT column to tables showing up to this many
+	// characters of each memory's content
+	PreviewWidth int
+	// LabelColumns adds a column to tables for each of these label keys,
+	// holding each memory's value for it
+	LabelColumns []string
+	// Field prints only this field of a single memory, resolved by
+	// memoryFieldValue, instead of formatting the memory
+	Field string
+	// Width is the width tables fit their name and labels columns to; 0
+	// keeps the fixed widths
+	Width int
 }
 
 // FormatOutput formats the given data according to the output options
@@ -34,6 +56,8 @@ func FormatOutput(data interface{}, opts OutputOptions) (string, error) {
 	switch opts.Format {
 	case OutputFormatJSON:
 		return formatJSON(data)
+	case OutputFormatJSONL:
+		return formatJSONLine(data)
 	case OutputFormatYAML:
 		return formatYAML(data)
 	case OutputFormatJSONPath:
@@ -56,6 +80,15 @@ func formatJSON(data interface{}) (string, error) {
 	return string(jsonData), nil
 }
 
+// formatJSONLine formats data as JSON on a single line
+func formatJSONLine(data interface{}) (string, error) {
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	return string(jsonData), nil
+}
+
 // formatYAML formats data as YAML
 func formatYAML(data interface{}) (string, error) {
 	yamlData, err := yaml.Marshal(data)
@@ -104,7 +137,7 @@ func formatGoTemplate(data interface{}, templateStr string) (string, error) {
 	}
 
 	// Parse the template
-	tmpl, err := template.New("output").Parse(templateStr)
+	tmpl, err := template.New("output").Funcs(outputTemplateFuncs).Parse(templateStr)
 	if err != nil {
 		return "", fmt.Errorf("failed to parse go template: %w", err)
 	}
@@ -118,6 +151,28 @@ func formatGoTemplate(data interface{}, templateStr string) (string, error) {
 	return buf.String(), nil
 }
 
+// defaultOutputEnv overrides the defaultOutput config key
+const defaultOutputEnv = "CONTEXTMEMORY_OUTPUT"
+
+// outputFormatOrDefault returns the --output flag value, falling back to
+// $CONTEXTMEMORY_OUTPUT and then the defaultOutput config key when the flag
+// is empty
+func outputFormatOrDefault(flag string) string {
+	if flag != "" {
+		return flag
+	}
+	return viper.GetString("defaultOutput")
+}
+
+// validateDefaultOutput checks the configured default output format
+func validateDefaultOutput() error {
+	format := viper.GetString("defaultOutput")
+	if _, err := ParseOutputFormat(format); err != nil {
+		return fmt.Errorf("invalid defaultOutput %q in config or $%s: %w", format, defaultOutputEnv, err)
+	}
+	return nil
+}
+
 // ParseOutputFormat parses the output format string
 func ParseOutputFormat(format string) (OutputOptions, error) {
 	// Handle formats like "jsonpath=.items[*].metadata.name" or "go-template={{.name}}"
@@ -135,6 +190,12 @@ func ParseOutputFormat(format string) (OutputOptions, error) {
 			return OutputOptions{Format: OutputFormatJSONPath, Template: template}, nil
 		case "go-template":
 			return OutputOptions{Format: OutputFormatGoTemplate, Template: template}, nil
+		case "template":
+			text, err := lookupOutputTemplate(template)
+			if err != nil {
+				return OutputOptions{}, err
+			}
+			return OutputOptions{Format: OutputFormatGoTemplate, Template: text, TemplateName: template}, nil
 		default:
 			return OutputOptions{}, fmt.Errorf("unknown output format: %s", formatType)
 		}
@@ -144,6 +205,8 @@ func ParseOutputFormat(format string) (OutputOptions, error) {
 	switch format {
 	case "json":
 		return OutputOptions{Format: OutputFormatJSON}, nil
+	case "jsonl":
+		return OutputOptions{Format: OutputFormatJSONL}, nil
 	case "yaml":
 		return OutputOptions{Format: OutputFormatYAML}, nil
 	case "table", "":
@@ -157,19 +220,19 @@ func ParseOutputFormat(format string) (OutputOptions, error) {
 func FormatMemoryList(memories []storage.Memory, opts OutputOptions, showID bool) (string, error) {
 	switch opts.Format {
 	case OutputFormatTable:
-		return formatMemoryTable(memories, showID), nil
-	case OutputFormatJSON, OutputFormatYAML, OutputFormatJSONPath, OutputFormatGoTemplate:
-		// Create a wrapper structure for consistent API output
-		output := struct {
-			APIVersion string           `json:"apiVersion" yaml:"apiVersion"`
-			Kind       string           `json:"kind" yaml:"kind"`
-			Items      []storage.Memory `json:"items" yaml:"items"`
-		}{
-			APIVersion: "contextmemory.io/v1",
-			Kind:       "MemoryList",
-			Items:      memories,
+		return formatMemoryTable(memories, showID, opts), nil
+	case OutputFormatJSONL:
+		var lines strings.Builder
+		for i := range memories {
+			line, err := formatMemoryLine(&memories[i], opts)
+			if err != nil {
+				return "", err
+			}
+			lines.WriteString(line)
 		}
-		return FormatOutput(output, opts)
+		return lines.String(), nil
+	case OutputFormatJSON, OutputFormatYAML, OutputFormatJSONPath, OutputFormatGoTemplate:
+		return formatDocument(api.NewMemoryListDocument(memories), opts)
 	default:
 		return "", fmt.Errorf("unsupported output format: %s", opts.Format)
 	}
@@ -177,33 +240,67 @@ func FormatMemoryList(memories []storage.Memory, opts OutputOptions, showID bool
 
 // FormatSingleMemory formats a single memory according to output options
 func FormatSingleMemory(memory *storage.Memory, opts OutputOptions) (string, error) {
+	if opts.Field != "" {
+		value, err := memoryFieldValue(memory, opts.Field)
+		if err != nil {
+			return "", err
+		}
+		return formatFieldValue(value), nil
+	}
 	switch opts.Format {
 	case OutputFormatTable:
-		return formatSingleMemoryTable(memory), nil
+		return formatSingleMemoryTable(memory, opts.Color), nil
+	case OutputFormatJSONL:
+		return formatMemoryLine(memory, opts)
 	case OutputFormatJSON, OutputFormatYAML, OutputFormatJSONPath, OutputFormatGoTemplate:
-		// Create a wrapper structure for consistent API output
-		output := struct {
-			APIVersion string         `json:"apiVersion" yaml:"apiVersion"`
-			Kind       string         `json:"kind" yaml:"kind"`
-			Metadata   map[string]any `json:"metadata" yaml:"metadata"`
-			Spec       storage.Memory `json:"spec" yaml:"spec"`
-		}{
-			APIVersion: "contextmemory.io/v1",
-			Kind:       "Memory",
-			Metadata: map[string]any{
-				"id":   memory.ID,
-				"name": memory.Name,
-			},
-			Spec: *memory,
+		if opts.TemplateName != "" {
+			// Named templates are written for lists
+			return formatDocument(api.NewMemoryListDocument([]storage.Memory{*memory}), opts)
 		}
-		return FormatOutput(output, opts)
+		return formatDocument(api.NewMemoryDocument(memory), opts)
 	default:
 		return "", fmt.Errorf("unsupported output format: %s", opts.Format)
 	}
 }
 
+// formatMemoryLine formats memory as a Memory document on one line, as
+// written for each memory with -o jsonl
+func formatMemoryLine(memory *storage.Memory, opts OutputOptions) (string, error) {
+	line, err := formatDocument(api.NewMemoryDocument(memory), opts)
+	if err != nil {
+		return "", err
+	}
+	return line + "\n", nil
+}
+
+// formatDocument applies any --fields projection before formatting a
+// structured document
+func formatDocument(doc any, opts OutputOptions) (string, error) {
+	if len(opts.Fields) == 0 {
+		return FormatOutput(doc, opts)
+	}
+	projected, err := projectDocument(doc, opts.Fields)
+	if err != nil {
+		return "", err
+	}
+	return FormatOutput(projected, opts)
+}
+
+// validateFieldsFormat rejects --fields with table output, which has fixed
+// columns
+func validateFieldsFormat(opts OutputOptions) error {
+	if len(opts.Fields) > 0 && opts.Format == OutputFormatTable {
+		return fmt.Errorf("--fields requires a structured output format such as -o json or -o yaml")
+	}
+	return nil
+}
+
+// pinnedMarker prefixes the names of pinned memories in tables
+const pinnedMarker = "* "
+
 // formatMemoryTable formats memories as a table (existing logic)
-func formatMemoryTable(memories []storage.Memory, showID bool) string {
+func formatMemoryTable(memories []storage.Memory, showID bool, opts OutputOptions) string {
+	color := opts.Color
 	if len(memories) == 0 {
 		return "No resources found."
 	}
@@ -211,57 +308,173 @@ func formatMemoryTable(memories []storage.Memory, showID bool) string {
 	var result strings.Builder
 
 	// Print header with conditional ID column
+	labelWidths := labelColumnWidths(memories, opts.LabelColumns)
+	var labelHeaders string
+	for i, key := range opts.LabelColumns {
+		labelHeaders += fmt.Sprintf("%-*s ", labelWidths[i], strings.ToUpper(key))
+	}
+	columns := allocateTableColumns(opts.Width, tableFixedWidth(labelWidths, opts), showID)
+	var header string
 	if showID {
-		result.WriteString(fmt.Sprintf("%-24s %-32s %-26s %-20s\n", "ID", "NAME", "LABELS", "AGE"))
+		header = fmt.Sprintf("%-*s %-*s %-*s %s%-20s", columns.ID, "ID", columns.Name, "NAME", columns.Labels, "LABELS", labelHeaders, "AGE")
 	} else {
-		result.WriteString(fmt.Sprintf("%-40s %-30s %-20s\n", "NAME", "LABELS", "AGE"))
+		header = fmt.Sprintf("%-*s %-*s %s%-20s", columns.Name, "NAME", columns.Labels, "LABELS", labelHeaders, "AGE")
+	}
+	if opts.ShowScore {
+		header += " SCORE"
+	}
+	if opts.PreviewWidth > 0 {
+		header += " CONTENT"
 	}
+	result.WriteString(colorize(header, ansiBold, color) + "\n")
 
 	// Print memories with conditional ID column
 	for _, memory := range memories {
 		labels := formatLabelsCompact(memory.Labels)
 		age := formatAge(memory.UpdatedAt)
+		coloredAge := padColored(age, colorize(age, ansiGray, color), 20)
+		if opts.ShowScore {
+			coloredAge += fmt.Sprintf(" %5.2f", memory.Score)
+		}
+		if opts.PreviewWidth > 0 && memory.IsEncoded() {
+			coloredAge += " [binary]"
+		} else if opts.PreviewWidth > 0 {
+			coloredAge += " " + contentPreview(memory.Content, opts.PreviewWidth)
+		}
+		name := memory.Name
+		if memory.IsPinned() {
+			name = pinnedMarker + name
+		}
+		var labelCells string
+		for i, key := range opts.LabelColumns {
+			labelCells += fmt.Sprintf("%-*s ", labelWidths[i], labelColumnValue(memory, key))
+		}
 
+		labels = truncateString(labels, columns.Labels-2)
 		if showID {
-			result.WriteString(fmt.Sprintf("%-24s %-32s %-26s %-20s\n",
-				truncateString(memory.ID, 22),
-				truncateString(memory.Name, 30),
-				truncateString(labels, 24),
-				age))
+			result.WriteString(fmt.Sprintf("%-*s %-*s %s %s%s\n",
+				columns.ID, truncateString(memory.ID, columns.ID-2),
+				columns.Name, truncateString(name, columns.Name-2),
+				padColored(labels, colorizeLabelKeys(labels, color), columns.Labels),
+				labelCells,
+				coloredAge))
 		} else {
-			result.WriteString(fmt.Sprintf("%-40s %-30s %-20s\n",
-				truncateString(memory.Name, 38),
-				truncateString(labels, 28),
-				age))
+			result.WriteString(fmt.Sprintf("%-*s %s %s%s\n",
+				columns.Name, truncateString(name, columns.Name-2),
+				padColored(labels, colorizeLabelKeys(labels, color), columns.Labels),
+				labelCells,
+				coloredAge))
 		}
 	}
 
 	return result.String()
 }
 
+// tableFixedWidth returns the width of the table columns after the labels,
+// which keep their width whatever the width of the table
+func tableFixedWidth(labelWidths []int, opts OutputOptions) int {
+	fixed := 20 // AGE
+	for _, width := range labelWidths {
+		fixed += width + 1
+	}
+	if opts.ShowScore {
+		fixed += 6
+	}
+	if opts.PreviewWidth > 0 {
+		fixed += opts.PreviewWidth + 1
+	}
+	return fixed
+}
+
+// labelColumnValue returns a memory's value for a --columns-from-labels
+// column, or <none> if it doesn't have the label
+func labelColumnValue(memory storage.Memory, key string) string {
+	if value, ok := memory.Labels[key]; ok && value != "" {
+		return value
+	}
+	return "<none>"
+}
+
+// labelColumnWidths returns the width of each label column: enough for its
+// header and the longest of the memories' values
+func labelColumnWidths(memories []storage.Memory, keys []string) []int {
+	widths := make([]int, len(keys))
+	for i, key := range keys {
+		widths[i] = len(key)
+		for _, memory := range memories {
+			widths[i] = max(widths[i], len(labelColumnValue(memory, key)))
+		}
+	}
+	return widths
+}
+
+// formatMemoryPorcelain formats memories as one id<TAB>name<TAB>labels line
+// each, for scripts. Labels are sorted key=value pairs joined by commas.
+// Nothing is truncated, aligned or colored, and the format is kept stable
+// across versions.
+func formatMemoryPorcelain(memories []storage.Memory) string {
+	var result strings.Builder
+	for _, memory := range memories {
+		result.WriteString(porcelainField(memory.ID) + "\t" +
+			porcelainField(memory.Name) + "\t" +
+			porcelainField(canonicalLabels(memory.Labels)) + "\n")
+	}
+	return result.String()
+}
+
+// porcelainField replaces the tabs and line breaks that would split a
+// porcelain record with spaces
+func porcelainField(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '\t' || r == '\n' || r == '\r' {
+			return ' '
+		}
+		return r
+	}, s)
+}
+
 // formatSingleMemoryTable formats a single memory as table
-func formatSingleMemoryTable(memory *storage.Memory) string {
+func formatSingleMemoryTable(memory *storage.Memory, color bool) string {
 	var result strings.Builder
 
-	result.WriteString(fmt.Sprintf("Name:\t%s\n", memory.Name))
-	result.WriteString(fmt.Sprintf("ID:\t%s\n", memory.ID))
-	result.WriteString(fmt.Sprintf("Created:\t%s\n", memory.CreatedAt.Format("2006-01-02 15:04:05")))
-	result.WriteString(fmt.Sprintf("Updated:\t%s\n", memory.UpdatedAt.Format("2006-01-02 15:04:05")))
+	field := func(name string) string {
+		return colorize(name+":", ansiBold, color)
+	}
+
+	result.WriteString(fmt.Sprintf("%s\t%s\n", field("Name"), memory.Name))
+	result.WriteString(fmt.Sprintf("%s\t%s\n", field("ID"), memory.ID))
+	result.WriteString(fmt.Sprintf("%s\t%s\n", field("Created"), colorize(memory.CreatedAt.Format("2006-01-02 15:04:05"), ansiGray, color)))
+	result.WriteString(fmt.Sprintf("%s\t%s\n", field("Updated"), colorize(memory.UpdatedAt.Format("2006-01-02 15:04:05"), ansiGray, color)))
+	if memory.ExpiresAt != nil {
+		result.WriteString(fmt.Sprintf("%s\t%s\n", field("Expires"), colorize(memory.ExpiresAt.Format("2006-01-02 15:04:05"), ansiGray, color)))
+	}
 
 	if len(memory.Labels) > 0 {
-		result.WriteString("Labels:\t")
+		result.WriteString(field("Labels") + "\t")
 		labels := make([]string, 0, len(memory.Labels))
 		for key, value := range memory.Labels {
-			labels = append(labels, fmt.Sprintf("%s=%s", key, value))
+			labels = append(labels, fmt.Sprintf("%s=%s", colorize(key, ansiCyan, color), value))
 		}
 		result.WriteString(strings.Join(labels, ","))
 		result.WriteString("\n")
 	} else {
-		result.WriteString("Labels:\tnone\n")
+		result.WriteString(field("Labels") + "\tnone\n")
+	}
+
+	if len(memory.Relations) > 0 {
+		relations := make([]string, 0, len(memory.Relations))
+		for _, relation := range memory.Relations {
+			relations = append(relations, fmt.Sprintf("%s %s", colorize(relation.Type, ansiCyan, color), relation.TargetID))
+		}
+		result.WriteString(fmt.Sprintf("%s\t%s\n", field("Relations"), strings.Join(relations, ", ")))
 	}
 
-	result.WriteString("\nContent:\n")
-	result.WriteString(memory.Content)
+	result.WriteString("\n" + field("Content") + "\n")
+	if memory.IsEncoded() {
+		result.WriteString(fmt.Sprintf("[binary content, %s-encoded; use 'cmctl cat %s' to extract it]", storage.ContentEncodingBase64, memory.ID))
+	} else {
+		result.WriteString(memory.Content)
+	}
 	result.WriteString("\n")
 
 	return result.String()
@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// outputTemplatesKey is the config key holding custom named output
+// templates, a map of name to Go template
+const outputTemplatesKey = "output-templates"
+
+// outputTemplate is a Go template selectable with -o template=<name>
+type outputTemplate struct {
+	Name        string
+	Description string
+	Template    string
+	Builtin     bool
+}
+
+// builtinOutputTemplates render a MemoryList document; single memories are
+// wrapped in a one-item list so the same templates work for 'get <id>'
+var builtinOutputTemplates = []outputTemplate{
+	{
+		Name:        "ids",
+		Description: "One memory ID per line",
+		Template:    `{{range .Items}}{{.ID}}{{"\n"}}{{end}}`,
+	},
+	{
+		Name:        "names",
+		Description: "One memory name per line",
+		Template:    `{{range .Items}}{{.Name}}{{"\n"}}{{end}}`,
+	},
+	{
+		Name:        "table-wide",
+		Description: "ID, name, size, update time and labels",
+		Template: `{{printf "%-32s  %-40s  %8s  %-16s  %s\n" "ID" "NAME" "SIZE" "UPDATED" "LABELS"}}` +
+			`{{range .Items}}{{printf "%-32s  %-40s  %8d  %-16s  %s\n" .ID (truncate .Name 40) (len .Content) (date .UpdatedAt) (labels .Labels)}}{{end}}`,
+	},
+	{
+		Name:        "markdown",
+		Description: "Each memory as a markdown section",
+		Template:    `{{range .Items}}## {{.Name}}{{"\n\n"}}{{body .}}{{"\n\n"}}{{end}}`,
+	},
+}
+
+// outputTemplateFuncs are available to all Go templates
+var outputTemplateFuncs = template.FuncMap{
+	"labels":   formatLabels,
+	"truncate": truncateString,
+	"date":     func(t time.Time) string { return t.Format("2006-01-02 15:04") },
+	"body":     markdownBody,
+}
+
+// markdownBody returns a memory's content for a markdown document: source
+// code, by its content type, goes in a fence tagged with its language
+func markdownBody(memory storage.Memory) string {
+	language := storage.CodeLanguage(memory.ContentType())
+	if language == "" {
+		return memory.Content
+	}
+
+	// The fence must be longer than any run of backticks in the code
+	fence := "```"
+	for strings.Contains(memory.Content, fence) {
+		fence += "`"
+	}
+	code := memory.Content
+	if !strings.HasSuffix(code, "\n") {
+		code += "\n"
+	}
+	return fence + language + "\n" + code + fence
+}
+
+var templatesCmd = &cobra.Command{
+	Use:   "templates",
+	Short: "List named output templates for -o template=<name>",
+	Long: `Named output templates are Go templates selected with -o template=<name>.
+Add your own under output-templates in the config file; they may use the
+labels, truncate, date and body functions and override built-ins of the
+same name. body renders a memory's content as markdown, fencing source code:
+
+  output-templates:
+    short: '{{range .Items}}{{.ID}} {{.Name}}{{"\n"}}{{end}}'
+
+Memory templates for 'cmctl create' are managed with 'cmctl template'.
+
+Examples:
+  cmctl templates list
+  cmctl get -o template=names
+  cmctl search -q auth -o template=table-wide`,
+}
+
+var templatesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List named output templates",
+	Args:  cobra.NoArgs,
+	RunE:  runTemplatesList,
+}
+
+func init() {
+	rootCmd.AddCommand(templatesCmd)
+	templatesCmd.AddCommand(templatesListCmd)
+}
+
+func runTemplatesList(cmd *cobra.Command, args []string) error {
+	fmt.Printf("%-16s %-8s %s\n", "NAME", "SOURCE", "DESCRIPTION")
+	for _, tmpl := range outputTemplates() {
+		source := "config"
+		if tmpl.Builtin {
+			source = "built-in"
+		}
+		fmt.Printf("%-16s %-8s %s\n", tmpl.Name, source, tmpl.Description)
+	}
+	return nil
+}
+
+// outputTemplates returns the built-in templates merged with those from the
+// config file, sorted by name
+func outputTemplates() []outputTemplate {
+	byName := make(map[string]outputTemplate)
+	for _, tmpl := range builtinOutputTemplates {
+		tmpl.Builtin = true
+		byName[tmpl.Name] = tmpl
+	}
+	for name, text := range viper.GetStringMapString(outputTemplatesKey) {
+		byName[name] = outputTemplate{Name: name, Description: truncateString(text, 60), Template: text}
+	}
+
+	templates := make([]outputTemplate, 0, len(byName))
+	for _, tmpl := range byName {
+		templates = append(templates, tmpl)
+	}
+	sort.Slice(templates, func(i, j int) bool {
+		return templates[i].Name < templates[j].Name
+	})
+	return templates
+}
+
+// lookupOutputTemplate returns the Go template with the given name
+func lookupOutputTemplate(name string) (string, error) {
+	var names []string
+	for _, tmpl := range outputTemplates() {
+		if tmpl.Name == name {
+			return tmpl.Template, nil
+		}
+		names = append(names, tmpl.Name)
+	}
+	return "", fmt.Errorf("unknown output template %q (available: %s)", name, strings.Join(names, ", "))
+}
@@ -0,0 +1,463 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+	"github.com/spf13/viper"
+)
+
+func testMemories() []storage.Memory {
+	now := time.Now()
+	return []storage.Memory{
+		{
+			ID:        "mem_00000001_aaaaaa",
+			Name:      "First Memory",
+			Content:   "first",
+			Labels:    map[string]string{"type": "notes"},
+			CreatedAt: now.Add(-2 * time.Hour),
+			UpdatedAt: now.Add(-2 * time.Hour),
+		},
+		{
+			ID:        "mem_00000002_bbbbbb",
+			Name:      "Second Memory",
+			Content:   "second",
+			Labels:    map[string]string{},
+			CreatedAt: now.Add(-48 * time.Hour),
+			UpdatedAt: now.Add(-48 * time.Hour),
+		},
+	}
+}
+
+func TestFormatMemoryTableColor(t *testing.T) {
+	memories := testMemories()
+
+	tests := []struct {
+		name       string
+		color      bool
+		wantEscape bool
+	}{
+		{name: "color enabled", color: true, wantEscape: true},
+		{name: "color disabled", color: false, wantEscape: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := OutputOptions{Format: OutputFormatTable, Color: tt.color}
+			for _, showID := range []bool{false, true} {
+				output, err := FormatMemoryList(memories, opts, showID)
+				if err != nil {
+					t.Fatalf("FormatMemoryList failed: %v", err)
+				}
+				if got := strings.Contains(output, "\033["); got != tt.wantEscape {
+					t.Errorf("showID=%v: expected escape codes present=%v, got output:\n%q", showID, tt.wantEscape, output)
+				}
+			}
+
+			single, err := FormatSingleMemory(&memories[0], opts)
+			if err != nil {
+				t.Fatalf("FormatSingleMemory failed: %v", err)
+			}
+			if got := strings.Contains(single, "\033["); got != tt.wantEscape {
+				t.Errorf("single memory: expected escape codes present=%v, got output:\n%q", tt.wantEscape, single)
+			}
+		})
+	}
+}
+
+func TestFormatMemoryTableColorAlignment(t *testing.T) {
+	memories := testMemories()
+
+	plain := formatMemoryTable(memories, false, OutputOptions{})
+	colored := formatMemoryTable(memories, false, OutputOptions{Color: true})
+
+	stripped := stripANSI(colored)
+	if stripped != plain {
+		t.Errorf("colored table should match plain table once escape codes are removed\nplain:\n%q\nstripped:\n%q", plain, stripped)
+	}
+}
+
+func TestFormatMemoryTablePreview(t *testing.T) {
+	memories := testMemories()
+	memories[0].Content = "line one\n\n  line two\twith tabs"
+	memories[1].Content = strings.Repeat("日本語", 30)
+
+	for _, showID := range []bool{false, true} {
+		output := formatMemoryTable(memories, showID, OutputOptions{PreviewWidth: 20})
+		lines := strings.Split(strings.TrimSuffix(output, "\n"), "\n")
+		if len(lines) != 3 {
+			t.Fatalf("Expected a header and 2 rows, got %q", output)
+		}
+
+		// The CONTENT column starts at the same offset in every line
+		column := strings.Index(lines[0], "CONTENT")
+		if column < 0 {
+			t.Fatalf("Expected a CONTENT header, got %q", lines[0])
+		}
+		var previews []string
+		for _, line := range lines[1:] {
+			previews = append(previews, line[column:])
+		}
+
+		if previews[0] != "line one line two..." {
+			t.Errorf("showID=%v: expected whitespace collapsed and cut to 20 characters, got %q", showID, previews[0])
+		}
+		want := strings.Repeat("日本語", 6)[:len("日本語")*5+len("日本")] + "..."
+		if previews[1] != want {
+			t.Errorf("showID=%v: expected a rune-aware cut to %q, got %q", showID, want, previews[1])
+		}
+		for _, preview := range previews {
+			if n := len([]rune(preview)); n > 20 {
+				t.Errorf("showID=%v: expected at most 20 characters, got %d in %q", showID, n, preview)
+			}
+		}
+	}
+
+	if output := formatMemoryTable(memories, false, OutputOptions{}); strings.Contains(output, "CONTENT") {
+		t.Error("Expected no CONTENT column without a preview width")
+	}
+}
+
+func TestFormatMemoryTableLabelColumns(t *testing.T) {
+	memories := testMemories()
+	memories[0].Labels = map[string]string{"project": "contextmemory", "status": "active", "owner": "ana"}
+	memories[1].Labels = map[string]string{"status": "done"}
+	memories = append(memories, storage.Memory{
+		ID:        "mem_00000003_cccccc",
+		Name:      "Third Memory",
+		Labels:    map[string]string{"owner": "bo", "type": "notes"},
+		UpdatedAt: time.Now(),
+	})
+	keys := []string{"project", "status", "owner"}
+
+	for _, showID := range []bool{false, true} {
+		output := formatMemoryTable(memories, showID, OutputOptions{LabelColumns: keys})
+		lines := strings.Split(strings.TrimSuffix(output, "\n"), "\n")
+		if len(lines) != 4 {
+			t.Fatalf("Expected a header and 3 rows, got %q", output)
+		}
+
+		// Each label column starts at the same offset in every line, and
+		// comes before AGE
+		want := [][]string{
+			{"contextmemory", "active", "ana"},
+			{"<none>", "done", "<none>"},
+			{"<none>", "<none>", "bo"},
+		}
+		ageColumn := strings.Index(lines[0], "AGE")
+		for i, key := range keys {
+			column := strings.Index(lines[0], strings.ToUpper(key))
+			if column < 0 || column > ageColumn {
+				t.Fatalf("showID=%v: expected a %s column before AGE, got %q", showID, strings.ToUpper(key), lines[0])
+			}
+			for row, line := range lines[1:] {
+				if got := strings.Fields(line[column:])[0]; got != want[row][i] {
+					t.Errorf("showID=%v: expected %s of row %d to be %q, got %q", showID, key, row, want[row][i], got)
+				}
+			}
+		}
+	}
+
+	if output := formatMemoryTable(memories, false, OutputOptions{}); strings.Contains(output, "PROJECT") {
+		t.Error("Expected no label columns without --columns-from-labels")
+	}
+}
+
+func TestParseLabelColumns(t *testing.T) {
+	keys, err := parseLabelColumns(" project, status,,project ")
+	if err != nil {
+		t.Fatalf("Failed to parse label columns: %v", err)
+	}
+	if strings.Join(keys, ",") != "project,status" {
+		t.Errorf("Expected [project status], got %v", keys)
+	}
+	if _, err := parseLabelColumns("project,bad key"); err == nil {
+		t.Error("Expected an error for an invalid label key")
+	}
+}
+
+func TestFormatMemoryPorcelain(t *testing.T) {
+	memories := testMemories()
+	memories[0].Name = "A long memory name that a table would truncate\tand split"
+	memories[0].Labels = map[string]string{"type": "notes", "language": "go", "activity": "debugging"}
+	memories[0].Labels[storage.PinnedLabel] = "true"
+
+	output := formatMemoryPorcelain(memories)
+	if strings.Contains(output, "\033[") {
+		t.Errorf("Expected no escape codes, got %q", output)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(output, "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected one line per memory, got %q", output)
+	}
+	want := [][]string{
+		{"mem_00000001_aaaaaa", "A long memory name that a table would truncate and split", "activity=debugging,language=go," + storage.PinnedLabel + "=true,type=notes"},
+		{"mem_00000002_bbbbbb", "Second Memory", ""},
+	}
+	for i, line := range lines {
+		fields := strings.Split(line, "\t")
+		if len(fields) != 3 {
+			t.Fatalf("Expected 3 tab-separated fields, got %q", line)
+		}
+		for j := range fields {
+			if fields[j] != want[i][j] {
+				t.Errorf("Line %d field %d: expected %q, got %q", i, j, want[i][j], fields[j])
+			}
+		}
+	}
+
+	if output := formatMemoryPorcelain(nil); output != "" {
+		t.Errorf("Expected no output for no memories, got %q", output)
+	}
+}
+
+func TestShouldColorize(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	viper.Set("no-color", false)
+	defer viper.Set("no-color", false)
+
+	// Non-TTY writers (pipes, buffers) must never be colorized
+	var buf bytes.Buffer
+	if shouldColorize(&buf) {
+		t.Error("expected no color for non-terminal writer")
+	}
+
+	viper.Set("no-color", true)
+	if shouldColorize(&buf) {
+		t.Error("expected no color when --no-color is set")
+	}
+	viper.Set("no-color", false)
+
+	t.Setenv("NO_COLOR", "1")
+	if shouldColorize(&buf) {
+		t.Error("expected no color when NO_COLOR is set")
+	}
+}
+
+// stripANSI removes ANSI escape sequences from s
+func stripANSI(s string) string {
+	var result strings.Builder
+	inEscape := false
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\033':
+			inEscape = true
+		case inEscape && s[i] == 'm':
+			inEscape = false
+		case !inEscape:
+			result.WriteByte(s[i])
+		}
+	}
+	return result.String()
+}
+
+func TestOutputFormatOrDefault(t *testing.T) {
+	viper.SetConfigType("yaml")
+	defer func() { _ = viper.ReadConfig(strings.NewReader("")) }()
+	t.Setenv(defaultOutputEnv, "")
+
+	if got := outputFormatOrDefault(""); got != "" {
+		t.Errorf("Expected no default without config, got %q", got)
+	}
+
+	if err := viper.ReadConfig(strings.NewReader("defaultOutput: json\n")); err != nil {
+		t.Fatalf("Failed to read config: %v", err)
+	}
+	if got := outputFormatOrDefault(""); got != "json" {
+		t.Errorf("Expected config default json, got %q", got)
+	}
+
+	t.Setenv(defaultOutputEnv, "yaml")
+	if got := outputFormatOrDefault(""); got != "yaml" {
+		t.Errorf("Expected $%s to override the config, got %q", defaultOutputEnv, got)
+	}
+
+	if got := outputFormatOrDefault("table"); got != "table" {
+		t.Errorf("Expected the flag to win, got %q", got)
+	}
+}
+
+func TestValidateDefaultOutput(t *testing.T) {
+	t.Setenv(defaultOutputEnv, "jsonpath={.items[*].id}")
+	if err := validateDefaultOutput(); err != nil {
+		t.Errorf("Expected a valid default output, got %v", err)
+	}
+
+	t.Setenv(defaultOutputEnv, "xml")
+	err := validateDefaultOutput()
+	if err == nil || !strings.Contains(err.Error(), `invalid defaultOutput "xml"`) {
+		t.Errorf("Expected an invalid defaultOutput error, got %v", err)
+	}
+
+	if err := validateSettings(configSetCmd, nil); err != nil {
+		t.Errorf("Expected config commands to skip validation, got %v", err)
+	}
+	if err := validateSettings(getCmd, nil); err == nil {
+		t.Error("Expected get to reject an invalid defaultOutput")
+	}
+}
+
+func TestFieldsProjection(t *testing.T) {
+	fields, err := parseFields("id, name,labels")
+	if err != nil {
+		t.Fatalf("Failed to parse fields: %v", err)
+	}
+
+	listOut, err := FormatMemoryList(testMemories(), OutputOptions{Format: OutputFormatJSON, Fields: fields}, false)
+	if err != nil {
+		t.Fatalf("Failed to format list: %v", err)
+	}
+	var list struct {
+		Kind  string           `json:"kind"`
+		Items []map[string]any `json:"items"`
+	}
+	if err := json.Unmarshal([]byte(listOut), &list); err != nil {
+		t.Fatalf("Failed to parse output: %v", err)
+	}
+	if list.Kind != "MemoryList" || len(list.Items) != 2 {
+		t.Fatalf("Expected a MemoryList with 2 items, got %s", listOut)
+	}
+	for _, item := range list.Items {
+		if len(item) != 3 || item["id"] == nil || item["name"] == nil || item["labels"] == nil {
+			t.Errorf("Expected only id, name and labels, got %v", item)
+		}
+	}
+
+	memory := testMemories()[0]
+	yamlOut, err := FormatSingleMemory(&memory, OutputOptions{Format: OutputFormatYAML, Fields: []string{"name"}})
+	if err != nil {
+		t.Fatalf("Failed to format memory: %v", err)
+	}
+	if !strings.Contains(yamlOut, "name: First Memory") || strings.Contains(yamlOut, "content") || strings.Contains(yamlOut, "createdAt") {
+		t.Errorf("Expected only the name in spec, got:\n%s", yamlOut)
+	}
+
+	if _, err := parseFields("id,bogus"); err == nil {
+		t.Error("Expected an unknown field to be rejected")
+	}
+	if err := validateFieldsFormat(OutputOptions{Format: OutputFormatTable, Fields: fields}); err == nil {
+		t.Error("Expected --fields to be rejected for table output")
+	}
+	if needsContent(fields) || !needsContent(nil) || !needsContent([]string{"content"}) {
+		t.Error("Expected content to be needed only when requested or when no fields are given")
+	}
+}
+
+func TestFormatMemoryListJSONL(t *testing.T) {
+	opts, err := ParseOutputFormat("jsonl")
+	if err != nil {
+		t.Fatalf("Failed to parse jsonl: %v", err)
+	}
+	opts.Fields = []string{"id", "name"}
+
+	output, err := FormatMemoryList(testMemories(), opts, false)
+	if err != nil {
+		t.Fatalf("Failed to format list: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(output, "\n"), "\n")
+	if len(lines) != 2 || !strings.HasSuffix(output, "\n") {
+		t.Fatalf("Expected one line per memory, got %q", output)
+	}
+	for i, line := range lines {
+		var doc struct {
+			Kind     string         `json:"kind"`
+			Metadata map[string]any `json:"metadata"`
+			Spec     map[string]any `json:"spec"`
+		}
+		if err := json.Unmarshal([]byte(line), &doc); err != nil {
+			t.Fatalf("Expected a JSON document per line, got %q: %v", line, err)
+		}
+		if doc.Kind != "Memory" || doc.Metadata["name"] != testMemories()[i].Name || doc.Spec["content"] != nil {
+			t.Errorf("Expected only the projected fields of memory %d, got %v", i, doc)
+		}
+	}
+
+	if output, err := FormatMemoryList(nil, opts, false); err != nil || output != "" {
+		t.Errorf("Expected no lines for no memories, got %q, %v", output, err)
+	}
+}
+
+func TestNamedOutputTemplates(t *testing.T) {
+	viper.Set(outputTemplatesKey, map[string]string{
+		"short": `{{range .Items}}{{.ID}}:{{.Name}};{{end}}`,
+		"names": `{{range .Items}}custom {{.Name}}{{end}}`,
+	})
+	defer viper.Set(outputTemplatesKey, nil)
+
+	memories := []storage.Memory{
+		{ID: "mem_1", Name: "First", Content: "abc", Labels: map[string]string{"type": "note"}},
+		{ID: "mem_2", Name: "Second"},
+	}
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"ids", "mem_1\nmem_2\n"},
+		{"short", "mem_1:First;mem_2:Second;"},
+		{"names", "custom Firstcustom Second"}, // Config overrides the built-in
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := ParseOutputFormat("template=" + tt.name)
+			if err != nil {
+				t.Fatalf("Failed to parse output format: %v", err)
+			}
+			if opts.Format != OutputFormatGoTemplate || opts.TemplateName != tt.name {
+				t.Errorf("Expected a named go-template, got %+v", opts)
+			}
+			output, err := FormatMemoryList(memories, opts, false)
+			if err != nil {
+				t.Fatalf("Failed to format memories: %v", err)
+			}
+			if output != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, output)
+			}
+		})
+	}
+
+	opts, err := ParseOutputFormat("template=table-wide")
+	if err != nil {
+		t.Fatalf("Failed to parse output format: %v", err)
+	}
+	output, err := FormatSingleMemory(&memories[0], opts)
+	if err != nil {
+		t.Fatalf("Failed to format memory: %v", err)
+	}
+	if !strings.Contains(output, "NAME") || !strings.Contains(output, "mem_1") || !strings.Contains(output, "type=note") {
+		t.Errorf("Expected a wide table row for a single memory, got:\n%s", output)
+	}
+
+	if _, err := ParseOutputFormat("template=missing"); err == nil {
+		t.Error("Expected an error for an unknown template")
+	}
+}
+
+func TestMarkdownTemplateFencesCode(t *testing.T) {
+	memories := []storage.Memory{
+		{ID: "mem_1", Name: "Notes", Content: "Some *notes*", Metadata: map[string]any{storage.ContentTypeKey: storage.ContentTypeMarkdown}},
+		{ID: "mem_2", Name: "Snippet", Content: "fmt.Println(\"```\")", Metadata: map[string]any{storage.ContentTypeKey: "text/x-go"}},
+		{ID: "mem_3", Name: "Untyped", Content: "plain"},
+	}
+
+	opts, err := ParseOutputFormat("template=markdown")
+	if err != nil {
+		t.Fatalf("Failed to parse output format: %v", err)
+	}
+	output, err := FormatMemoryList(memories, opts, false)
+	if err != nil {
+		t.Fatalf("Failed to format memories: %v", err)
+	}
+
+	want := "## Notes\n\nSome *notes*\n\n" +
+		"## Snippet\n\n````go\nfmt.Println(\"```\")\n````\n\n" +
+		"## Untyped\n\nplain\n\n"
+	if output != want {
+		t.Errorf("Expected %q, got %q", want, output)
+	}
+}
@@ -0,0 +1,393 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+)
+
+// defaultTerminalWidth is assumed when the terminal size can't be read
+const defaultTerminalWidth = 80
+
+// pickerRows is how many matches the picker shows at once
+const pickerRows = 10
+
+// selectMemory asks the user to choose one of memories, described by
+// subject (e.g. "chat to reload"), returning nil if they cancel. On a
+// terminal the choice is made in a picker that filters as you type;
+// otherwise, or where raw mode isn't available, from a numbered list.
+// Prompts go to stderr so stdout holds only the command's output.
+func selectMemory(memories []storage.Memory, subject string) (*storage.Memory, error) {
+	if isTerminal(os.Stdin) && isTerminal(os.Stderr) {
+		restore, err := enableRawMode(os.Stdin)
+		if err == nil {
+			defer restore()
+			return runPicker(os.Stdin, os.Stderr, newPicker(memories), subject, terminalWidth(os.Stderr), shouldColorize(os.Stderr))
+		}
+		DebugPrintf("Falling back to a numbered list: %v\n", err)
+	}
+	return promptNumbered(os.Stdin, os.Stderr, memories, subject)
+}
+
+// promptNumbered lists memories with numbers and reads the number of the
+// one chosen
+func promptNumbered(r io.Reader, w io.Writer, memories []storage.Memory, subject string) (*storage.Memory, error) {
+	fmt.Fprintf(w, "Found %d memories:\n\n", len(memories))
+	for i, memory := range memories {
+		fmt.Fprintf(w, "%d. %s\n   %s\n\n", i+1, memory.Name, pickerDetails(memory))
+	}
+
+	fmt.Fprintf(w, "Enter the number of the %s (1-%d), or 0 to cancel: ", subject, len(memories))
+	var choice string
+	if _, err := fmt.Fscanln(r, &choice); err != nil {
+		fmt.Fprintln(w, "Invalid input. Cancelled.")
+		return nil, nil
+	}
+	n, err := strconv.Atoi(choice)
+	if err != nil || n < 0 || n > len(memories) {
+		fmt.Fprintln(w, "Invalid choice.")
+		return nil, nil
+	}
+	if n == 0 {
+		fmt.Fprintln(w, "Cancelled.")
+		return nil, nil
+	}
+	return &memories[n-1], nil
+}
+
+// pickerDetails describes a memory on the line below or beside its name
+func pickerDetails(memory storage.Memory) string {
+	details := "Created: " + memory.CreatedAt.Format("2006-01-02 15:04")
+	if len(memory.Labels) > 0 {
+		details += " | " + strings.Join(sortedLabelPairs(memory.Labels), ",")
+	}
+	return details
+}
+
+// sortedLabelPairs returns labels as key=value pairs in key order
+func sortedLabelPairs(labels map[string]string) []string {
+	pairs := make([]string, 0, len(labels))
+	for k, v := range labels {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return pairs
+}
+
+// fuzzyScore reports whether the runes of query appear in order in text,
+// ignoring case, and scores the best such match. Every matched rune scores,
+// more if it follows the previous match or starts a word, so "gd" ranks
+// "go debug" above "good".
+func fuzzyScore(text, query string) (int, bool) {
+	target := []rune(strings.ToLower(text))
+	pattern := []rune(strings.ToLower(query))
+	if len(pattern) == 0 {
+		return 0, true
+	}
+
+	// scores[i] is the best score for the pattern so far ending with a
+	// match at target[i], or -1 if there is none
+	scores := make([]int, len(target))
+	for i, r := range target {
+		scores[i] = -1
+		if r == pattern[0] {
+			scores[i] = 1 + wordStartBonus(target, i)
+		}
+	}
+	for _, p := range pattern[1:] {
+		next := make([]int, len(target))
+		// earlier is the best score ending before target[i-1]
+		earlier := -1
+		for i, r := range target {
+			next[i] = -1
+			if i >= 2 {
+				earlier = max(earlier, scores[i-2])
+			}
+			if r != p {
+				continue
+			}
+			best := earlier
+			if i >= 1 && scores[i-1] >= 0 {
+				best = max(best, scores[i-1]+2)
+			}
+			if best >= 0 {
+				next[i] = best + 1 + wordStartBonus(target, i)
+			}
+		}
+		scores = next
+	}
+
+	best := -1
+	for _, score := range scores {
+		best = max(best, score)
+	}
+	return max(best, 0), best >= 0
+}
+
+// wordStartBonus scores a match at target[i] that starts a word
+func wordStartBonus(target []rune, i int) int {
+	if i == 0 || !unicode.IsLetter(target[i-1]) && !unicode.IsDigit(target[i-1]) {
+		return 3
+	}
+	return 0
+}
+
+// pickerText is the text the picker matches a memory against: its name
+// and labels
+func pickerText(memory storage.Memory) string {
+	return memory.Name + " " + strings.Join(sortedLabelPairs(memory.Labels), " ")
+}
+
+// filterPickerMemories returns the memories matching every word of query,
+// best matches first and otherwise in their original order
+func filterPickerMemories(memories []storage.Memory, query string) []storage.Memory {
+	words := strings.Fields(query)
+	if len(words) == 0 {
+		return memories
+	}
+
+	type match struct {
+		memory storage.Memory
+		score  int
+	}
+	var matches []match
+	for _, memory := range memories {
+		text := pickerText(memory)
+		total, matched := 0, true
+		for _, word := range words {
+			score, ok := fuzzyScore(text, word)
+			if !ok {
+				matched = false
+				break
+			}
+			total += score
+		}
+		if matched {
+			matches = append(matches, match{memory, total})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+
+	filtered := make([]storage.Memory, len(matches))
+	for i, m := range matches {
+		filtered[i] = m.memory
+	}
+	return filtered
+}
+
+// pickerKeyKind is a key the picker responds to
+type pickerKeyKind int
+
+const (
+	keyRune pickerKeyKind = iota
+	keyUp
+	keyDown
+	keyBackspace
+	keyClear
+	keyEnter
+	keyCancel
+)
+
+// pickerKey is a key press; rune is set for keyRune
+type pickerKey struct {
+	kind pickerKeyKind
+	r    rune
+}
+
+// parseKeys decodes the bytes read from a raw terminal into key presses.
+// Arrow keys arrive as escape sequences; an escape on its own cancels.
+func parseKeys(data []byte) []pickerKey {
+	var keys []pickerKey
+	for len(data) > 0 {
+		switch b := data[0]; {
+		case b == 0x1b:
+			if len(data) >= 3 && (data[1] == '[' || data[1] == 'O') {
+				switch data[2] {
+				case 'A':
+					keys = append(keys, pickerKey{kind: keyUp})
+				case 'B':
+					keys = append(keys, pickerKey{kind: keyDown})
+				}
+				data = data[3:]
+				continue
+			}
+			keys = append(keys, pickerKey{kind: keyCancel})
+			data = data[1:]
+		case b == '\r' || b == '\n':
+			keys = append(keys, pickerKey{kind: keyEnter})
+			data = data[1:]
+		case b == 0x7f || b == 0x08:
+			keys = append(keys, pickerKey{kind: keyBackspace})
+			data = data[1:]
+		case b == 0x03 || b == 0x04 || b == 0x07:
+			// Ctrl-C, Ctrl-D and Ctrl-G
+			keys = append(keys, pickerKey{kind: keyCancel})
+			data = data[1:]
+		case b == 0x10:
+			// Ctrl-P
+			keys = append(keys, pickerKey{kind: keyUp})
+			data = data[1:]
+		case b == 0x0e:
+			// Ctrl-N
+			keys = append(keys, pickerKey{kind: keyDown})
+			data = data[1:]
+		case b == 0x15:
+			// Ctrl-U
+			keys = append(keys, pickerKey{kind: keyClear})
+			data = data[1:]
+		case b < 0x20:
+			data = data[1:]
+		default:
+			r, size := utf8.DecodeRune(data)
+			if r != utf8.RuneError {
+				keys = append(keys, pickerKey{kind: keyRune, r: r})
+			}
+			data = data[size:]
+		}
+	}
+	return keys
+}
+
+// picker is the state of the fuzzy picker, kept apart from the terminal so
+// it can be driven by tests
+type picker struct {
+	memories []storage.Memory
+	query    []rune
+	matches  []storage.Memory
+	cursor   int
+	// offset is the index of the first match shown
+	offset int
+}
+
+func newPicker(memories []storage.Memory) *picker {
+	return &picker{memories: memories, matches: memories}
+}
+
+// handle applies a key press, reporting whether the picker is done and,
+// if so, whether a memory was chosen
+func (p *picker) handle(key pickerKey) (done, chosen bool) {
+	switch key.kind {
+	case keyRune:
+		p.setQuery(append(p.query, key.r))
+	case keyBackspace:
+		if len(p.query) > 0 {
+			p.setQuery(p.query[:len(p.query)-1])
+		}
+	case keyClear:
+		p.setQuery(nil)
+	case keyUp:
+		p.move(-1)
+	case keyDown:
+		p.move(1)
+	case keyEnter:
+		return len(p.matches) > 0, len(p.matches) > 0
+	case keyCancel:
+		return true, false
+	}
+	return false, false
+}
+
+// setQuery filters the memories by query and moves back to the best match
+func (p *picker) setQuery(query []rune) {
+	p.query = query
+	p.matches = filterPickerMemories(p.memories, string(query))
+	p.cursor, p.offset = 0, 0
+}
+
+// move moves the cursor by delta, scrolling to keep it in view
+func (p *picker) move(delta int) {
+	if len(p.matches) == 0 {
+		return
+	}
+	p.cursor = min(max(p.cursor+delta, 0), len(p.matches)-1)
+	if p.cursor < p.offset {
+		p.offset = p.cursor
+	} else if p.cursor >= p.offset+pickerRows {
+		p.offset = p.cursor - pickerRows + 1
+	}
+}
+
+// selected returns the memory under the cursor
+func (p *picker) selected() (storage.Memory, bool) {
+	if len(p.matches) == 0 {
+		return storage.Memory{}, false
+	}
+	return p.matches[p.cursor], true
+}
+
+// lines renders the picker as lines no wider than width: the query, the
+// matches in view with the cursor marked, and a key hint
+func (p *picker) lines(subject string, width int, color bool) []string {
+	width = max(width-1, 10)
+	lines := []string{contentPreview(fmt.Sprintf("Select the %s: %s", subject, string(p.query)), width)}
+	end := min(p.offset+pickerRows, len(p.matches))
+	for i := p.offset; i < end; i++ {
+		memory := p.matches[i]
+		row := contentPreview(memory.Name+"  "+pickerDetails(memory), width-2)
+		if i == p.cursor {
+			lines = append(lines, colorize("> "+row, ansiBold, color))
+		} else {
+			lines = append(lines, "  "+row)
+		}
+	}
+	if len(p.matches) == 0 {
+		lines = append(lines, "  No matches")
+	}
+	hint := fmt.Sprintf("  %d/%d  up/down to move, enter to choose, esc to cancel", len(p.matches), len(p.memories))
+	return append(lines, colorize(contentPreview(hint, width), ansiGray, color))
+}
+
+// runPicker shows the picker on w and reads keys from r until a memory is
+// chosen or the picker is cancelled. The picker is redrawn in place and
+// erased when done.
+func runPicker(r io.Reader, w io.Writer, p *picker, subject string, width int, color bool) (*storage.Memory, error) {
+	drawn := 0
+	draw := func(lines []string) {
+		if drawn > 1 {
+			fmt.Fprintf(w, "\033[%dA", drawn-1)
+		}
+		fmt.Fprint(w, "\r\033[J", strings.Join(lines, "\n"))
+		drawn = len(lines)
+	}
+	defer draw(nil)
+
+	draw(p.lines(subject, width, color))
+	buf := make([]byte, 64)
+	for {
+		n, err := r.Read(buf)
+		if n == 0 && err != nil {
+			if err == io.EOF {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("failed to read from terminal: %w", err)
+		}
+		for _, key := range parseKeys(buf[:n]) {
+			done, chosen := p.handle(key)
+			if !done {
+				continue
+			}
+			if !chosen {
+				return nil, nil
+			}
+			memory, _ := p.selected()
+			return &memory, nil
+		}
+		draw(p.lines(subject, width, color))
+	}
+}
+
+// memoriesByNewest returns memories sorted newest first, the order pickers
+// list them in
+func memoriesByNewest(memories []storage.Memory) []storage.Memory {
+	sorted := slices.Clone(memories)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].CreatedAt.After(sorted[j].CreatedAt) })
+	return sorted
+}
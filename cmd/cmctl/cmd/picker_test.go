@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+)
+
+func pickerMemories() []storage.Memory {
+	created := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	return []storage.Memory{
+		{ID: "mem_1", Name: "Good bye party", CreatedAt: created},
+		{ID: "mem_2", Name: "Go debugging session", Labels: map[string]string{"language": "go"}, CreatedAt: created},
+		{ID: "mem_3", Name: "Release notes", Labels: map[string]string{"type": "chat"}, CreatedAt: created},
+	}
+}
+
+func pickedIDs(memories []storage.Memory) []string {
+	ids := make([]string, len(memories))
+	for i, memory := range memories {
+		ids[i] = memory.ID
+	}
+	return ids
+}
+
+func TestFuzzyScore(t *testing.T) {
+	if _, ok := fuzzyScore("Release notes", "rln"); !ok {
+		t.Errorf("Expected runes in order to match")
+	}
+	if _, ok := fuzzyScore("Release notes", "nlr"); ok {
+		t.Errorf("Expected runes out of order not to match")
+	}
+	if _, ok := fuzzyScore("Release notes", "RELEASE"); !ok {
+		t.Errorf("Expected matching to ignore case")
+	}
+	words, _ := fuzzyScore("go debug", "gd")
+	scattered, _ := fuzzyScore("good", "gd")
+	if words <= scattered {
+		t.Errorf("Expected word starts to score higher (%d) than scattered runes (%d)", words, scattered)
+	}
+}
+
+func TestFilterPickerMemories(t *testing.T) {
+	memories := pickerMemories()
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{query: "", want: []string{"mem_1", "mem_2", "mem_3"}},
+		{query: "gd", want: []string{"mem_2", "mem_1"}},
+		{query: "rn", want: []string{"mem_3"}},
+		{query: "type=chat", want: []string{"mem_3"}},
+		{query: "go  session", want: []string{"mem_2"}},
+		{query: "zzz", want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			got := pickedIDs(filterPickerMemories(memories, tt.query))
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	keys := parseKeys([]byte("gé\x7f\x1b[A\x1b[B\x10\x0e\x15\r\x1b\x03"))
+	want := []pickerKey{
+		{kind: keyRune, r: 'g'}, {kind: keyRune, r: 'é'}, {kind: keyBackspace},
+		{kind: keyUp}, {kind: keyDown}, {kind: keyUp}, {kind: keyDown},
+		{kind: keyClear}, {kind: keyEnter}, {kind: keyCancel}, {kind: keyCancel},
+	}
+	if len(keys) != len(want) {
+		t.Fatalf("Expected %d keys, got %v", len(want), keys)
+	}
+	for i := range want {
+		if keys[i] != want[i] {
+			t.Errorf("Expected key %d to be %v, got %v", i, want[i], keys[i])
+		}
+	}
+}
+
+func TestPickerHandle(t *testing.T) {
+	p := newPicker(pickerMemories())
+
+	p.handle(pickerKey{kind: keyDown})
+	p.handle(pickerKey{kind: keyDown})
+	p.handle(pickerKey{kind: keyDown})
+	if memory, _ := p.selected(); memory.ID != "mem_3" {
+		t.Errorf("Expected moving down to stop at the last memory, got %s", memory.ID)
+	}
+
+	// Typing filters and moves back to the best match
+	for _, r := range "gd" {
+		p.handle(pickerKey{kind: keyRune, r: r})
+	}
+	if memory, _ := p.selected(); memory.ID != "mem_2" || len(p.matches) != 2 {
+		t.Errorf("Expected the best of 2 matches, got %s of %d", memory.ID, len(p.matches))
+	}
+
+	p.handle(pickerKey{kind: keyRune, r: 'z'})
+	if done, _ := p.handle(pickerKey{kind: keyEnter}); done {
+		t.Errorf("Expected enter to do nothing without matches")
+	}
+	p.handle(pickerKey{kind: keyBackspace})
+	p.handle(pickerKey{kind: keyDown})
+	if done, chosen := p.handle(pickerKey{kind: keyEnter}); !done || !chosen {
+		t.Fatalf("Expected enter to choose a memory")
+	}
+	if memory, _ := p.selected(); memory.ID != "mem_1" {
+		t.Errorf("Expected the second match to be chosen, got %s", memory.ID)
+	}
+
+	if done, chosen := p.handle(pickerKey{kind: keyCancel}); !done || chosen {
+		t.Errorf("Expected cancel to finish without a choice")
+	}
+}
+
+func TestPickerScrolls(t *testing.T) {
+	var memories []storage.Memory
+	for i := 0; i < pickerRows+5; i++ {
+		memories = append(memories, storage.Memory{ID: string(rune('a' + i)), Name: "memory"})
+	}
+	p := newPicker(memories)
+	for i := 0; i < pickerRows+2; i++ {
+		p.handle(pickerKey{kind: keyDown})
+	}
+	if p.cursor != pickerRows+2 || p.offset != 3 {
+		t.Errorf("Expected the cursor in view at the bottom, got cursor %d offset %d", p.cursor, p.offset)
+	}
+	// A header, the rows in view and a hint
+	if lines := p.lines("memory", 80, false); len(lines) != pickerRows+2 {
+		t.Errorf("Expected %d lines, got %d", pickerRows+2, len(lines))
+	}
+}
+
+func TestRunPicker(t *testing.T) {
+	var out bytes.Buffer
+	picked, err := runPicker(strings.NewReader("release\r"), &out, newPicker(pickerMemories()), "memory to get", 40, false)
+	if err != nil {
+		t.Fatalf("Failed to run picker: %v", err)
+	}
+	if picked == nil || picked.ID != "mem_3" {
+		t.Errorf("Expected mem_3 to be picked, got %v", picked)
+	}
+	for _, line := range strings.Split(out.String(), "\n") {
+		if visible := strings.TrimPrefix(line, "\r\033[J"); len([]rune(visible)) > 40 && !strings.Contains(visible, "\033[") {
+			t.Errorf("Expected lines to fit the width, got %q", visible)
+		}
+	}
+
+	picked, err = runPicker(strings.NewReader("rel\x1b"), &out, newPicker(pickerMemories()), "memory to get", 80, false)
+	if err != nil || picked != nil {
+		t.Errorf("Expected escape to cancel, got %v, %v", picked, err)
+	}
+	picked, err = runPicker(strings.NewReader("rel"), &out, newPicker(pickerMemories()), "memory to get", 80, false)
+	if err != nil || picked != nil {
+		t.Errorf("Expected end of input to cancel, got %v, %v", picked, err)
+	}
+}
+
+func TestPromptNumbered(t *testing.T) {
+	var out bytes.Buffer
+	picked, err := promptNumbered(strings.NewReader("2\n"), &out, pickerMemories(), "chat to reload")
+	if err != nil || picked == nil || picked.ID != "mem_2" {
+		t.Errorf("Expected the second memory, got %v, %v", picked, err)
+	}
+	if !strings.Contains(out.String(), "2. Go debugging session\n   Created: 2025-03-01 12:00 | language=go") {
+		t.Errorf("Expected a numbered list, got %q", out.String())
+	}
+
+	for _, input := range []string{"0\n", "9\n", "x\n"} {
+		picked, err := promptNumbered(strings.NewReader(input), &out, pickerMemories(), "chat to reload")
+		if err != nil || picked != nil {
+			t.Errorf("Expected %q to choose nothing, got %v, %v", input, picked, err)
+		}
+	}
+}
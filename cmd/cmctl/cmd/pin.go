@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/providers"
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+var pinCmd = &cobra.Command{
+	Use:   "pin <memory-id>",
+	Short: "Pin a memory to the top of listings",
+	Long: `Pin a memory so that 'get', 'list' and 'search' show it before other
+results. Pinned memories carry the label pinned=true and are marked with
+'*' in table output.
+
+Examples:
+  cmctl pin mem_abc123_def456
+  cmctl get --pinned                 # Show only pinned memories`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error { return runPin(args[0], true) },
+}
+
+var unpinCmd = &cobra.Command{
+	Use:   "unpin <memory-id>",
+	Short: "Unpin a memory",
+	Args:  cobra.ExactArgs(1),
+	RunE:  func(cmd *cobra.Command, args []string) error { return runPin(args[0], false) },
+}
+
+func init() {
+	rootCmd.AddCommand(pinCmd, unpinCmd)
+}
+
+func runPin(id string, pinned bool) error {
+	fs, err := getStorageProvider()
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+
+	changed, err := setPinned(fs, id, pinned)
+	if err != nil {
+		return err
+	}
+
+	state := "pinned"
+	if !pinned {
+		state = "unpinned"
+	}
+	if changed {
+		VPrintf(Normal, "Memory %s %s\n", id, state)
+	} else {
+		VPrintf(Normal, "Memory %s is already %s\n", id, state)
+	}
+	return nil
+}
+
+// setPinned pins or unpins a memory, reporting whether anything changed
+func setPinned(fs providers.StorageProvider, id string, pinned bool) (bool, error) {
+	memory, err := fs.Get(id)
+	if err != nil {
+		return false, err
+	}
+	if memory.IsPinned() == pinned {
+		return false, nil
+	}
+
+	labels := make(map[string]string, len(memory.Labels)+1)
+	for k, v := range memory.Labels {
+		labels[k] = v
+	}
+	if pinned {
+		labels[storage.PinnedLabel] = "true"
+	} else {
+		delete(labels, storage.PinnedLabel)
+	}
+
+	if _, err := fs.Update(storage.UpdateMemoryRequest{ID: id, Labels: labels}); err != nil {
+		return false, fmt.Errorf("failed to update memory: %w", err)
+	}
+	return true, nil
+}
+
+// sortPinnedFirst moves pinned memories ahead of the rest, keeping the
+// existing order within each group
+func sortPinnedFirst(memories []storage.Memory) {
+	sort.SliceStable(memories, func(i, j int) bool {
+		return memories[i].IsPinned() && !memories[j].IsPinned()
+	})
+}
+
+// filterPinned returns only the pinned memories
+func filterPinned(memories []storage.Memory) []storage.Memory {
+	var pinned []storage.Memory
+	for _, memory := range memories {
+		if memory.IsPinned() {
+			pinned = append(pinned, memory)
+		}
+	}
+	return pinned
+}
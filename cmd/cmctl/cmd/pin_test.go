@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/api"
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+)
+
+func TestPinOrdering(t *testing.T) {
+	fs := newTestStorage(t)
+
+	var ids []string
+	for _, name := range []string{"First", "Second", "Third"} {
+		memory, err := fs.Create(storage.CreateMemoryRequest{Name: name, Content: name})
+		if err != nil {
+			t.Fatalf("Failed to create memory: %v", err)
+		}
+		ids = append(ids, memory.ID)
+	}
+
+	changed, err := setPinned(fs, ids[2], true)
+	if err != nil {
+		t.Fatalf("Failed to pin memory: %v", err)
+	}
+	if !changed {
+		t.Error("Expected pinning to change the memory")
+	}
+	if changed, _ := setPinned(fs, ids[2], true); changed {
+		t.Error("Expected pinning twice to be a no-op")
+	}
+
+	memories, err := fs.List()
+	if err != nil {
+		t.Fatalf("Failed to list memories: %v", err)
+	}
+	sortPinnedFirst(memories)
+	if memories[0].ID != ids[2] {
+		t.Errorf("Expected pinned memory %s first, got %s", ids[2], memories[0].ID)
+	}
+
+	table := formatMemoryTable(memories, false, OutputOptions{})
+	if !strings.Contains(table, pinnedMarker+"Third") {
+		t.Errorf("Expected pinned marker in table, got:\n%s", table)
+	}
+	if strings.Contains(table, pinnedMarker+"First") {
+		t.Errorf("Expected no marker on unpinned memory, got:\n%s", table)
+	}
+
+	if _, err := setPinned(fs, ids[2], false); err != nil {
+		t.Fatalf("Failed to unpin memory: %v", err)
+	}
+	unpinned, err := fs.Get(ids[2])
+	if err != nil {
+		t.Fatalf("Failed to get memory: %v", err)
+	}
+	if unpinned.IsPinned() {
+		t.Error("Expected memory to be unpinned")
+	}
+	if _, ok := unpinned.Labels[storage.PinnedLabel]; ok {
+		t.Errorf("Expected pinned label to be removed, got %v", unpinned.Labels)
+	}
+}
+
+func TestGetPinnedFilter(t *testing.T) {
+	fs := newTestStorage(t)
+
+	pinned, err := fs.Create(storage.CreateMemoryRequest{Name: "Pinned", Content: "a"})
+	if err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+	if _, err := fs.Create(storage.CreateMemoryRequest{Name: "Other", Content: "b"}); err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+	if _, err := setPinned(fs, pinned.ID, true); err != nil {
+		t.Fatalf("Failed to pin memory: %v", err)
+	}
+
+	oldPinned, oldIncludeContent := getPinned, getIncludeContent
+	defer func() { getPinned, getIncludeContent = oldPinned, oldIncludeContent }()
+	getPinned, getIncludeContent = true, true
+
+	output, err := renderGetList(fs, OutputOptions{Format: OutputFormatJSON})
+	if err != nil {
+		t.Fatalf("Failed to render list: %v", err)
+	}
+	var doc api.MemoryListDocument
+	if err := json.Unmarshal([]byte(output), &doc); err != nil {
+		t.Fatalf("Failed to parse output: %v", err)
+	}
+	if len(doc.Items) != 1 || doc.Items[0].ID != pinned.ID {
+		t.Errorf("Expected only %s, got %v", pinned.ID, doc.Items)
+	}
+}
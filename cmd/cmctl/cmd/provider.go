@@ -0,0 +1,199 @@
+package cmd
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/providers"
+	"github.com/spf13/viper"
+)
+
+// encryptionKeyEnv holds the encryption passphrase when no key file is given
+const encryptionKeyEnv = "CONTEXTMEMORY_KEY"
+
+// getStorageProvider creates the storage provider selected by --provider
+func getStorageProvider() (providers.StorageProvider, error) {
+	return newStorageProvider(providers.ProviderType(viper.GetString("provider")))
+}
+
+// newStorageProvider creates a storage provider of the given type,
+// configured from the provider's defaults and the global flags. The
+// provider is wrapped with the event log when it's enabled, and with
+// encryption at rest when a key is configured.
+func newStorageProvider(providerType providers.ProviderType) (providers.StorageProvider, error) {
+	if providerType == "" {
+		providerType = providers.FileProvider
+	}
+
+	storageDir, err := contextStorageDir()
+	if err != nil {
+		return nil, err
+	}
+
+	config := providers.GetProviderDefaults(providerType)
+	config.Type = providerType
+	config.Logger = appLogger()
+	switch providerType {
+	case providers.FileProvider, providers.SQLiteProvider:
+		config.StorageDir = storageDir
+		config.MaxContentBytes = viper.GetInt64("max-content-bytes")
+		config.CompressContent = viper.GetBool(compressContentKey)
+	case providers.GitProvider:
+		config.StorageDir = storageDir
+		config.MaxContentBytes = viper.GetInt64("max-content-bytes")
+		config.CompressContent = viper.GetBool(compressContentKey)
+		config.NoCommit = viper.GetBool("no-commit")
+		if tmpl := viper.GetString("git-commit-template"); tmpl != "" {
+			config.CommitTemplate = tmpl
+		}
+		if remote := viper.GetString("git-remote"); remote != "" {
+			config.Remote = remote
+		}
+	}
+
+	created, err := providers.NewProviderFactory().CreateProvider(config)
+	if err != nil {
+		return nil, err
+	}
+
+	provider, ok := created.(providers.StorageProvider)
+	if !ok {
+		return nil, fmt.Errorf("provider %s does not implement storage operations", providerType)
+	}
+
+	passphrase, err := encryptionPassphrase()
+	if err != nil {
+		return nil, err
+	}
+	if passphrase == "" {
+		return withEventLog(provider, storageDir), nil
+	}
+
+	salt, err := encryptionSalt(provider)
+	if err != nil {
+		return nil, err
+	}
+	// Events are logged inside the encryption, so names are only logged
+	// in the clear when they're stored that way
+	return providers.NewEncryptedProvider(withEventLog(provider, storageDir), providers.EncryptionConfig{
+		Passphrase:      passphrase,
+		Salt:            salt,
+		EncryptMetadata: viper.GetBool("encrypt-metadata"),
+		MaxContentBytes: viper.GetInt64("max-content-bytes"),
+	})
+}
+
+// withEventLog wraps provider so that its changes are appended to the
+// event log in storageDir, when the event-log setting is on
+func withEventLog(provider providers.StorageProvider, storageDir string) providers.StorageProvider {
+	if !viper.GetBool(eventLogKey) {
+		return provider
+	}
+	return providers.NewEventLoggingProvider(provider, eventLog(storageDir), appLogger())
+}
+
+// encryptionPassphrase reads the passphrase from --encryption-key-file, or
+// from $CONTEXTMEMORY_KEY. An empty result means encryption is disabled.
+func encryptionPassphrase() (string, error) {
+	if keyFile := viper.GetString("encryption-key-file"); keyFile != "" {
+		data, err := os.ReadFile(keyFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read encryption key file: %w", err)
+		}
+		passphrase := strings.TrimSpace(string(data))
+		if passphrase == "" {
+			return "", fmt.Errorf("encryption key file %s is empty", keyFile)
+		}
+		return passphrase, nil
+	}
+	return os.Getenv(encryptionKeyEnv), nil
+}
+
+// encryptionSalt loads the store's key derivation salt, creating it on
+// first use. Providers without a local storage directory get a random salt
+// per run; values record their salt, so they still decrypt.
+func encryptionSalt(provider providers.StorageProvider) ([]byte, error) {
+	local, ok := provider.(interface{ StorageDir() string })
+	if !ok {
+		return nil, nil
+	}
+
+	saltPath := filepath.Join(local.StorageDir(), providers.EncryptionSaltFile)
+	if data, err := os.ReadFile(saltPath); err == nil {
+		salt, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+		if err != nil || len(salt) != providers.EncryptionSaltSize {
+			return nil, fmt.Errorf("invalid encryption salt in %s", saltPath)
+		}
+		return salt, nil
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read encryption salt: %w", err)
+	}
+
+	salt := make([]byte, providers.EncryptionSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate encryption salt: %w", err)
+	}
+	if err := os.WriteFile(saltPath, []byte(base64.StdEncoding.EncodeToString(salt)+"\n"), 0600); err != nil {
+		return nil, fmt.Errorf("failed to write encryption salt: %w", err)
+	}
+	return salt, nil
+}
+
+// trashProvider returns the provider's trash support, or an error if the
+// provider cannot soft-delete
+func trashProvider(provider providers.StorageProvider) (providers.TrashProvider, error) {
+	trash, ok := provider.(providers.TrashProvider)
+	if !ok {
+		return nil, fmt.Errorf("storage provider %s does not support the trash", provider.GetProviderType())
+	}
+	return trash, nil
+}
+
+// inBatch runs fn as one batch when the provider supports batches, so its
+// changes are undone if it fails. Otherwise fn runs as is, unless atomic
+// requires a batch.
+func inBatch(provider providers.StorageProvider, atomic bool, fn func() error) error {
+	if batcher, ok := provider.(providers.Batcher); ok {
+		err := batcher.Batch(fn)
+		if !errors.Is(err, providers.ErrBatchUnsupported) {
+			return err
+		}
+	}
+	if atomic {
+		return fmt.Errorf("storage provider %s does not support atomic changes", provider.GetProviderType())
+	}
+	return fn()
+}
+
+// noTrackAccessKey is the setting that turns off recording reads, for
+// read-only storage
+const noTrackAccessKey = "no-track-access"
+
+// recordAccess notes that memories were read, unless tracking is turned off
+// or the provider can't record reads. A read shouldn't fail because its
+// access couldn't be recorded, so errors are only warnings.
+func recordAccess(provider providers.StorageProvider, ids ...string) {
+	if viper.GetBool(noTrackAccessKey) {
+		return
+	}
+	recorder, ok := provider.(providers.AccessRecorder)
+	if !ok {
+		return
+	}
+	now := time.Now()
+	for _, id := range ids {
+		err := recorder.RecordAccess(id, now)
+		if errors.Is(err, providers.ErrAccessUnsupported) {
+			return
+		}
+		if err != nil {
+			VPrintf(Verbose, "Warning: failed to record access to %s: %v\n", id, err)
+		}
+	}
+}
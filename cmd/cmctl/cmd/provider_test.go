@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/providers"
+	"github.com/spf13/viper"
+)
+
+func TestGetStorageProvider(t *testing.T) {
+	defer viper.Set("provider", "file")
+	defer viper.Set("storage-dir", viper.GetString("storage-dir"))
+
+	viper.Set("provider", "file")
+	viper.Set("storage-dir", t.TempDir())
+	provider, err := getStorageProvider()
+	if err != nil {
+		t.Fatalf("Failed to create file provider: %v", err)
+	}
+	if provider.GetProviderType() != providers.FileProvider {
+		t.Errorf("Expected file provider, got %s", provider.GetProviderType())
+	}
+
+	viper.Set("provider", "s3")
+	if _, err := getStorageProvider(); err == nil || !strings.Contains(err.Error(), "not yet implemented") {
+		t.Errorf("Expected unimplemented error for s3 provider, got %v", err)
+	}
+
+	viper.Set("provider", "floppy")
+	if _, err := getStorageProvider(); err == nil {
+		t.Error("Expected error for unknown provider")
+	}
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+	"github.com/spf13/viper"
+)
+
+// captureStdout returns what fn writes to stdout
+func captureStdout(t *testing.T, fn func() error) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Failed to create pipe: %v", err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	runErr := fn()
+	os.Stdout = stdout
+	w.Close()
+
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("Failed to read stdout: %v", err)
+	}
+	if runErr != nil {
+		t.Fatalf("Command failed: %v", runErr)
+	}
+	return string(out)
+}
+
+// useTestStorageDir points the commands at a fresh file store
+func useTestStorageDir(t *testing.T) {
+	t.Helper()
+	provider, dir := viper.GetString("provider"), viper.GetString("storage-dir")
+	t.Cleanup(func() {
+		viper.Set("provider", provider)
+		viper.Set("storage-dir", dir)
+	})
+	viper.Set("provider", "file")
+	viper.Set("storage-dir", t.TempDir())
+}
+
+func TestCreateQuiet(t *testing.T) {
+	useTestStorageDir(t)
+	defer func() { createQuiet, createContent, createName, createAppend = false, "", "", false }()
+	createQuiet = true
+
+	tests := []struct {
+		name   string
+		append bool
+	}{
+		{name: "create", append: false},
+		{name: "append", append: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			createName, createContent, createAppend = "Quiet", "content for "+tt.name, tt.append
+
+			out := captureStdout(t, func() error { return runCreate(createCmd, nil) })
+
+			id := strings.TrimSpace(out)
+			if out != id+"\n" || strings.ContainsAny(id, " \t\n") {
+				t.Fatalf("Expected only the memory ID, got %q", out)
+			}
+			fs, err := getStorageProvider()
+			if err != nil {
+				t.Fatalf("Failed to get storage: %v", err)
+			}
+			if _, err := fs.Get(id); err != nil {
+				t.Errorf("Expected printed ID %q to be a stored memory: %v", id, err)
+			}
+		})
+	}
+}
+
+func TestDeleteQuiet(t *testing.T) {
+	useTestStorageDir(t)
+	defer func() { deleteQuiet, deleteForce, deleteLabels, deleteDryRun = false, false, "", false }()
+	deleteQuiet, deleteLabels = true, "type=test"
+
+	fs, err := getStorageProvider()
+	if err != nil {
+		t.Fatalf("Failed to get storage: %v", err)
+	}
+	var ids []string
+	for _, name := range []string{"One", "Two"} {
+		memory, err := fs.Create(storage.CreateMemoryRequest{Name: name, Content: name, Labels: map[string]string{"type": "test"}})
+		if err != nil {
+			t.Fatalf("Failed to create memory: %v", err)
+		}
+		ids = append(ids, memory.ID)
+	}
+
+	if err := runDelete(deleteCmd, nil); err == nil {
+		t.Fatal("Expected --quiet without --force to fail")
+	}
+
+	deleteDryRun = true
+	out := captureStdout(t, func() error { return runDelete(deleteCmd, nil) })
+	for _, id := range ids {
+		if !strings.Contains(out, id+"\n") {
+			t.Errorf("Expected dry run to print %s, got %q", id, out)
+		}
+	}
+	if lines := strings.Count(out, "\n"); lines != len(ids) {
+		t.Errorf("Expected %d lines from dry run, got %q", len(ids), out)
+	}
+
+	deleteDryRun, deleteForce = false, true
+	out = captureStdout(t, func() error { return runDelete(deleteCmd, nil) })
+	if out != "2\n" {
+		t.Errorf("Expected only the deleted count, got %q", out)
+	}
+}
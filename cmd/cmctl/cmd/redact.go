@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// redactedText replaces each secret found by a redactor
+const redactedText = "[REDACTED]"
+
+// secretGroup names the capture group holding the secret in a pattern that
+// also matches surrounding context, such as the "Bearer " prefix
+const secretGroup = "secret"
+
+// defaultRedactPatterns match common secrets pasted into chats
+var defaultRedactPatterns = []string{
+	// AWS access key IDs and secret access keys
+	`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`,
+	`(?i)aws_secret_access_key["']?\s*[=:]\s*["']?(?P<secret>[A-Za-z0-9/+=]{40})`,
+	// Authorization bearer tokens
+	`(?i)\bbearer\s+(?P<secret>[A-Za-z0-9\-._~+/]{16,}=*)`,
+	// OpenAI, Anthropic and similar sk- API keys
+	`\bsk-[A-Za-z0-9_\-]{20,}`,
+	// GitHub and Slack tokens
+	`\bgh[pousr]_[A-Za-z0-9]{36,}\b`,
+	`\bxox[abpors]-[A-Za-z0-9\-]{10,}`,
+	// PEM private key blocks
+	`(?s)-----BEGIN [A-Z ]*PRIVATE KEY-----.*?-----END [A-Z ]*PRIVATE KEY-----`,
+}
+
+// redactor masks secrets in text, counting the secrets it has replaced
+type redactor struct {
+	patterns []*regexp.Regexp
+	count    int
+}
+
+// newRedactor compiles the default secret patterns plus any extra patterns.
+// A pattern with a capture group named "secret" only masks that group.
+func newRedactor(extra []string) (*redactor, error) {
+	r := &redactor{}
+	for _, pattern := range append(append([]string{}, defaultRedactPatterns...), extra...) {
+		if strings.TrimSpace(pattern) == "" {
+			continue
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redact pattern %q: %w", pattern, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return r, nil
+}
+
+// Redact returns text with every secret replaced by [REDACTED]
+func (r *redactor) Redact(text string) string {
+	for _, re := range r.patterns {
+		text = r.redactPattern(re, text)
+	}
+	return text
+}
+
+// redactPattern masks the matches of one pattern
+func (r *redactor) redactPattern(re *regexp.Regexp, text string) string {
+	group := re.SubexpIndex(secretGroup)
+	matches := re.FindAllStringSubmatchIndex(text, -1)
+	if len(matches) == 0 {
+		return text
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, match := range matches {
+		start, end := match[0], match[1]
+		if group > 0 && match[2*group] >= 0 {
+			start, end = match[2*group], match[2*group+1]
+		}
+		b.WriteString(text[last:start])
+		b.WriteString(redactedText)
+		last = end
+		r.count++
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// Count returns the number of secrets replaced so far
+func (r *redactor) Count() int {
+	return r.count
+}
+
+// validateRedactPatterns checks a YAML list of patterns for 'config set'
+func validateRedactPatterns(value string) error {
+	var patterns []string
+	if err := yaml.Unmarshal([]byte(value), &patterns); err != nil {
+		return fmt.Errorf("expected a YAML list such as '[a, b]'")
+	}
+	_, err := newRedactor(patterns)
+	return err
+}
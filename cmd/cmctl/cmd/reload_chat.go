@@ -1,15 +1,20 @@
 package cmd
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"sort"
-	"strconv"
 	"strings"
 	"time"
 
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/api"
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/providers"
 	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
 var (
@@ -21,8 +26,30 @@ var (
 	reloadFormat      string
 	reloadInteractive bool
 	reloadMemoryID    string
+	reloadMaxTokens   int
+	reloadClipboard   bool
+	reloadClipOnly    bool
+	reloadCombine     bool
+	reloadOutputFile  string
+	reloadForce       bool
+	reloadLabels      string
+	reloadFirst       bool
+	reloadLast        bool
+	reloadSinceMark   bool
 )
 
+// TokenEstimator approximates how many tokens a model would count in text
+type TokenEstimator func(text string) int
+
+// estimateTokens is the estimator used for --max-tokens
+var estimateTokens TokenEstimator = approxTokens
+
+// approxTokens estimates tokens as one per four characters, which is close
+// enough for English prose and code with common tokenizers
+func approxTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
 // reloadChatCmd represents the reload-chat command
 var reloadChatCmd = &cobra.Command{
 	Use:   "reload-chat [memory-id]",
@@ -37,9 +64,12 @@ Output Formats:
   context-only      Clean context without chat formatting
   summary           Condensed version with key points
   raw              Original markdown format
+  messages-json     JSON array of {role, content} turns for chat APIs
+  raw-json          The stored memory as a contextmemory.io/v1 Memory document,
+                    with labels, timestamps and metadata (a MemoryList with --combine)
 
 Examples:
-  # Interactive mode - search and select from available chats
+  # Pick a chat, typing to filter the list
   cmctl reload-chat --interactive
 
   # Search for specific topics
@@ -51,7 +81,28 @@ Examples:
 
   # Different output formats
   cmctl reload-chat --search "React hooks" --format context-only
-  cmctl reload-chat mem_abc123 --format summary`,
+  cmctl reload-chat mem_abc123 --format summary
+
+  # Reload the most recently captured matching chat without choosing
+  cmctl reload-chat --last
+  cmctl reload-chat --search "auth" --labels "project=api" --last
+
+  # Reload every matching chat as one document, oldest first
+  cmctl reload-chat --search "auth refactor" --combine --limit 3
+
+  # Reload only the turns added since the chat was bookmarked
+  cmctl reload-chat mem_abc123 --since-bookmark
+
+  # Keep the output within an approximate token budget, eliding older turns
+  cmctl reload-chat mem_abc123 --max-tokens 4000
+
+  # Copy the output to the clipboard, ready to paste into a new AI pane
+  cmctl reload-chat mem_abc123 --clipboard
+  cmctl reload-chat mem_abc123 --clipboard-only
+
+  # Save the output to a file to share or attach
+  cmctl reload-chat mem_abc123 --output-file context/auth.md
+  cmctl reload-chat --search "auth" --combine --output-file auth.md --force`,
 	RunE: runReloadChat,
 }
 
@@ -61,21 +112,46 @@ func init() {
 	reloadChatCmd.Flags().StringVarP(&reloadSearch, "search", "s", "", "Search chat content and titles")
 	reloadChatCmd.Flags().StringVarP(&reloadLanguage, "language", "l", "", "Filter by programming language")
 	reloadChatCmd.Flags().StringVarP(&reloadActivity, "activity", "a", "", "Filter by activity type (debugging, implementation, learning, etc.)")
+	reloadChatCmd.Flags().StringVar(&reloadLabels, "labels", "", "Only consider chats with these labels (format: key1=value1,key2=value2)")
 	reloadChatCmd.Flags().StringVarP(&reloadDate, "date", "d", "", "Filter by date (YYYY-MM-DD or relative like 'today', 'yesterday', 'week')")
-	reloadChatCmd.Flags().IntVar(&reloadLimit, "limit", 10, "Limit number of results to show")
-	reloadChatCmd.Flags().StringVarP(&reloadFormat, "format", "f", "conversational", "Output format: conversational|context-only|summary|raw")
-	reloadChatCmd.Flags().BoolVarP(&reloadInteractive, "interactive", "i", false, "Interactive mode to browse and select chats")
+	reloadChatCmd.Flags().IntVar(&reloadLimit, "limit", 10, "Limit number of results to show (0 for no limit)")
+	reloadChatCmd.Flags().StringVarP(&reloadFormat, "format", "f", "conversational", "Output format: conversational|context-only|summary|raw|messages-json|raw-json")
+	reloadChatCmd.Flags().BoolVarP(&reloadInteractive, "interactive", "i", false, "Pick the chat to reload from a list that filters as you type")
+	reloadChatCmd.Flags().IntVar(&reloadMaxTokens, "max-tokens", 0, "Trim output to about this many tokens, keeping the most recent turns (0 for no limit)")
+	reloadChatCmd.Flags().BoolVar(&reloadClipboard, "clipboard", false, "Also copy the output to the system clipboard")
+	reloadChatCmd.Flags().BoolVar(&reloadClipOnly, "clipboard-only", false, "Copy the output to the system clipboard instead of printing it")
+	reloadChatCmd.Flags().BoolVar(&reloadFirst, "first", false, "Reload the oldest matching chat instead of choosing one")
+	reloadChatCmd.Flags().BoolVar(&reloadLast, "last", false, "Reload the most recently captured matching chat instead of choosing one")
+	reloadChatCmd.Flags().BoolVar(&reloadSinceMark, "since-bookmark", false, "Only reload the turns after each chat's bookmark (see 'cmctl bookmark')")
+	reloadChatCmd.Flags().BoolVar(&reloadCombine, "combine", false, "Combine all matching chats into one document, oldest first, instead of choosing one")
+	reloadChatCmd.Flags().StringVar(&reloadOutputFile, "output-file", "", "Write the output to this file instead of stdout, creating parent directories ('-' for stdout)")
+	reloadChatCmd.Flags().BoolVar(&reloadForce, "force", false, "Overwrite an existing --output-file")
 	reloadChatCmd.Flags().StringVar(&reloadMemoryID, "memory-id", "", "Specific memory ID to reload (alternative to positional arg)")
 }
 
 func runReloadChat(cmd *cobra.Command, args []string) error {
 	// Initialize storage
-	storageDir := viper.GetString("storage-dir")
-	fs, err := storage.NewFileStorage(storageDir)
+	fs, err := getStorageProvider()
 	if err != nil {
 		return fmt.Errorf("failed to initialize storage: %w", err)
 	}
 
+	if reloadCombine && reloadInteractive {
+		return fmt.Errorf("--combine and --interactive are mutually exclusive")
+	}
+	if err := validateReloadSelection(len(args) > 0 || reloadMemoryID != ""); err != nil {
+		return err
+	}
+	if reloadToFile() {
+		if reloadClipOnly {
+			return fmt.Errorf("--output-file and --clipboard-only are mutually exclusive")
+		}
+		// Refuse before anything is loaded or recorded as accessed
+		if err := checkOutputFile(reloadOutputFile, reloadForce); err != nil {
+			return err
+		}
+	}
+
 	// Handle specific memory ID
 	if len(args) > 0 || reloadMemoryID != "" {
 		memoryID := reloadMemoryID
@@ -94,8 +170,11 @@ func runReloadChat(cmd *cobra.Command, args []string) error {
 	return runSearchAndReload(fs)
 }
 
-func reloadSpecificChat(fs *storage.FileStorage, memoryID string) error {
+func reloadSpecificChat(fs providers.StorageProvider, memoryID string) error {
 	memory, err := fs.Get(memoryID)
+	if errors.Is(err, storage.ErrMemoryNotFound) {
+		return err
+	}
 	if err != nil {
 		return fmt.Errorf("failed to get memory: %w", err)
 	}
@@ -104,13 +183,12 @@ func reloadSpecificChat(fs *storage.FileStorage, memoryID string) error {
 	if memory.Labels["type"] != "chat" {
 		return fmt.Errorf("memory %s is not a chat conversation (type=%s)", memoryID, memory.Labels["type"])
 	}
+	recordAccess(fs, memory.ID)
 
-	output := formatChatForReload(*memory, reloadFormat)
-	fmt.Print(output)
-	return nil
+	return reloadChat(*memory)
 }
 
-func runSearchAndReload(fs *storage.FileStorage) error {
+func runSearchAndReload(fs providers.StorageProvider) error {
 	// Build search criteria
 	req := storage.SearchRequest{
 		LabelSelector:  map[string]string{"type": "chat"},
@@ -119,7 +197,19 @@ func runSearchAndReload(fs *storage.FileStorage) error {
 		IncludeContent: false, // We'll load content only for matches
 	}
 
+	// --first and --last choose from every match, not just the first page
+	if reloadFirst || reloadLast {
+		req.Limit = -1
+	}
+
 	// Add filters
+	if reloadLabels != "" {
+		labelSelector := parseLabels(reloadLabels)
+		if len(labelSelector) == 0 {
+			return fmt.Errorf("invalid label selector format: %s", reloadLabels)
+		}
+		req.LabelSelector = mergeLabels(labelSelector, req.LabelSelector)
+	}
 	if reloadLanguage != "" {
 		req.LabelSelector["language"] = reloadLanguage
 	}
@@ -155,6 +245,11 @@ func runSearchAndReload(fs *storage.FileStorage) error {
 		return nil
 	}
 
+	if reloadFirst || reloadLast {
+		selected, _ := selectByCreated(result.Memories, reloadFirst)
+		result.Memories = []storage.Memory{selected}
+	}
+
 	// If only one result, output it directly
 	if len(result.Memories) == 1 {
 		// Load full content if we don't have it
@@ -165,21 +260,73 @@ func runSearchAndReload(fs *storage.FileStorage) error {
 			}
 			result.Memories[0] = *fullMemory
 		}
+		recordAccess(fs, result.Memories[0].ID)
 
-		output := formatChatForReload(result.Memories[0], reloadFormat)
-		fmt.Print(output)
-		return nil
+		return reloadChat(result.Memories[0])
+	}
+
+	if reloadCombine {
+		return reloadCombinedChats(fs, result.Memories)
 	}
 
 	// Multiple results - show selection list
 	return showChatSelection(fs, result.Memories)
 }
 
-func runInteractiveReload(fs *storage.FileStorage) error {
+// reloadCombinedChats loads the full content of every matching chat and
+// emits them as a single document
+func reloadCombinedChats(fs providers.StorageProvider, memories []storage.Memory) error {
+	chats := make([]storage.Memory, 0, len(memories))
+	ids := make([]string, 0, len(memories))
+	for _, memory := range memories {
+		if memory.Content == "" {
+			fullMemory, err := fs.Get(memory.ID)
+			if err != nil {
+				return fmt.Errorf("failed to load memory content: %w", err)
+			}
+			memory = *fullMemory
+		}
+		ids = append(ids, memory.ID)
+		if reloadSinceMark {
+			var remaining int
+			if memory, remaining = sinceBookmark(memory); remaining == 0 {
+				continue
+			}
+		}
+		chats = append(chats, memory)
+	}
+	recordAccess(fs, ids...)
+	if len(chats) == 0 {
+		VPrintf(Normal, "No turns in the matching chats since their bookmarks\n")
+		return nil
+	}
+
+	output := combineChatsForReload(chats, reloadFormat, reloadMaxTokens, estimateTokens)
+	return writeReloadOutput(output)
+}
+
+// validateReloadSelection rejects --first and --last together, or with
+// another way of choosing the chat to reload
+func validateReloadSelection(hasID bool) error {
+	switch {
+	case reloadFirst && reloadLast:
+		return fmt.Errorf("--first and --last are mutually exclusive")
+	case !reloadFirst && !reloadLast:
+		return nil
+	case hasID:
+		return fmt.Errorf("--first and --last select a chat, so they can't be used with a memory ID")
+	case reloadInteractive:
+		return fmt.Errorf("--first and --last can't be used with --interactive")
+	case reloadCombine:
+		return fmt.Errorf("--first and --last can't be used with --combine")
+	}
+	return nil
+}
+
+func runInteractiveReload(fs providers.StorageProvider) error {
 	// Get all chat memories
 	req := storage.SearchRequest{
 		LabelSelector:  map[string]string{"type": "chat"},
-		Limit:          100, // Show more for interactive mode
 		UseIndex:       true,
 		IncludeContent: false,
 	}
@@ -200,53 +347,12 @@ func runInteractiveReload(fs *storage.FileStorage) error {
 	return showChatSelection(fs, result.Memories)
 }
 
-func showChatSelection(fs *storage.FileStorage, memories []storage.Memory) error {
-	// Sort by creation date (newest first)
-	sort.Slice(memories, func(i, j int) bool {
-		return memories[i].CreatedAt.After(memories[j].CreatedAt)
-	})
-
-	fmt.Printf("Found %d chat memories:\n\n", len(memories))
-
-	for i, memory := range memories {
-		fmt.Printf("%d. %s\n", i+1, memory.Name)
-		fmt.Printf("   Date: %s", memory.CreatedAt.Format("2006-01-02 15:04"))
-
-		if lang := memory.Labels["language"]; lang != "" {
-			fmt.Printf(" | Language: %s", lang)
-		}
-		if activity := memory.Labels["activity"]; activity != "" {
-			fmt.Printf(" | Activity: %s", activity)
-		}
-		fmt.Printf("\n")
-
-		if len(memory.Content) > 0 {
-			// Show preview if we have content
-			preview := extractContentPreview(memory.Content, 100)
-			fmt.Printf("   Preview: %s\n", preview)
-		}
-		fmt.Println()
-	}
-
-	fmt.Printf("Enter the number of the chat to reload (1-%d), or 0 to cancel: ", len(memories))
-	var choice string
-	if _, err := fmt.Scanln(&choice); err != nil {
-		fmt.Println("Invalid input. Cancelled.")
-		return nil
+func showChatSelection(fs providers.StorageProvider, memories []storage.Memory) error {
+	selected, err := selectMemory(memoriesByNewest(memories), "chat to reload")
+	if err != nil || selected == nil {
+		return err
 	}
-
-	choiceNum, err := strconv.Atoi(choice)
-	if err != nil || choiceNum < 0 || choiceNum > len(memories) {
-		fmt.Println("Invalid choice.")
-		return nil
-	}
-
-	if choiceNum == 0 {
-		fmt.Println("Cancelled.")
-		return nil
-	}
-
-	selectedMemory := memories[choiceNum-1]
+	selectedMemory := *selected
 
 	// Load full content if needed
 	if selectedMemory.Content == "" {
@@ -256,12 +362,24 @@ func showChatSelection(fs *storage.FileStorage, memories []storage.Memory) error
 		}
 		selectedMemory = *fullMemory
 	}
+	recordAccess(fs, selectedMemory.ID)
 
 	fmt.Printf("\n--- Loading Chat: %s ---\n\n", selectedMemory.Name)
-	output := formatChatForReload(selectedMemory, reloadFormat)
-	fmt.Print(output)
+	return reloadChat(selectedMemory)
+}
 
-	return nil
+// reloadChat formats a single chat and emits it, from its bookmark with
+// --since-bookmark
+func reloadChat(memory storage.Memory) error {
+	if reloadSinceMark {
+		var remaining int
+		if memory, remaining = sinceBookmark(memory); remaining == 0 {
+			VPrintf(Normal, "No turns in %s since the bookmark\n", memory.ID)
+			return nil
+		}
+	}
+	output := fitChatToTokenBudget(memory, reloadFormat, reloadMaxTokens, estimateTokens)
+	return writeReloadOutput(output)
 }
 
 func formatChatForReload(memory storage.Memory, format string) string {
@@ -272,11 +390,296 @@ func formatChatForReload(memory storage.Memory, format string) string {
 		return formatAsSummary(memory)
 	case "raw":
 		return memory.Content
+	case "messages-json":
+		return formatAsMessagesJSON(memory)
+	case "raw-json":
+		return formatAsRawJSON(memory)
 	default: // "conversational"
 		return formatAsConversational(memory)
 	}
 }
 
+// emitReloadOutput prints reload output to w and/or copies it to the
+// clipboard. If the clipboard is unavailable, a warning is shown and the
+// output is printed instead so it is never lost.
+func emitReloadOutput(w io.Writer, output string, clipboard, clipboardOnly bool) error {
+	if clipboard || clipboardOnly {
+		if err := clipboardWriter(output); err != nil {
+			VPrintf(Quiet, "Warning: could not copy to clipboard: %v\n", err)
+			clipboardOnly = false
+		} else {
+			VPrintf(Normal, "Copied %d characters to the clipboard\n", len(output))
+		}
+	}
+
+	if !clipboardOnly {
+		if _, err := fmt.Fprint(w, output); err != nil {
+			return fmt.Errorf("failed to write output: %w", err)
+		}
+	}
+	return nil
+}
+
+// reloadToFile reports whether --output-file names a file rather than stdout
+func reloadToFile() bool {
+	return reloadOutputFile != "" && reloadOutputFile != "-"
+}
+
+// writeReloadOutput emits reload output to stdout or --output-file, and to
+// the clipboard if asked
+func writeReloadOutput(output string) error {
+	if !reloadToFile() {
+		return emitReloadOutput(os.Stdout, output, reloadClipboard, reloadClipOnly)
+	}
+	if err := emitReloadOutput(io.Discard, output, reloadClipboard, false); err != nil {
+		return err
+	}
+	if err := writeOutputFile(reloadOutputFile, output, reloadForce); err != nil {
+		return err
+	}
+	VPrintf(Normal, "Wrote %d characters to %s\n", len(output), reloadOutputFile)
+	return nil
+}
+
+// checkOutputFile refuses to replace an existing file at path unless force
+// is set
+func checkOutputFile(path string, force bool) error {
+	if _, err := os.Stat(path); err == nil && !force {
+		return fmt.Errorf("output file %s already exists (use --force to overwrite)", path)
+	}
+	return nil
+}
+
+// writeOutputFile writes output to path, creating its parent directories
+// and refusing to replace an existing file unless force is set
+func writeOutputFile(path, output string, force bool) error {
+	if err := checkOutputFile(path, force); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(output), 0644); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	return nil
+}
+
+// fitChatToTokenBudget formats a chat for reload, eliding the oldest turns
+// until the output fits within maxTokens. Elided turns are replaced by a
+// note and a short list of the questions they contained. A non-positive
+// maxTokens disables trimming.
+func fitChatToTokenBudget(memory storage.Memory, format string, maxTokens int, estimate TokenEstimator) string {
+	output := formatChatForReload(memory, format)
+	if maxTokens <= 0 || estimate(output) <= maxTokens {
+		return output
+	}
+
+	header, turns := parseChatTurns(memory.Content)
+	for keep := len(turns) - 1; keep >= 0; keep-- {
+		elided, kept := turns[:len(turns)-keep], turns[len(turns)-keep:]
+		for _, withSummary := range []bool{true, false} {
+			trimmed := memory
+			trimmed.Content = buildTrimmedChat(header, elided, kept, withSummary)
+			output = formatChatForReload(trimmed, format)
+			if estimate(output) <= maxTokens {
+				return output
+			}
+		}
+	}
+
+	// Even the header alone is too large; cut the text itself
+	return truncateToTokens(output, maxTokens, estimate)
+}
+
+// combineChatsForReload formats several chats as one document, oldest
+// first, each introduced by a separator naming it. With a token budget the
+// newest chats are kept whole, and the first that doesn't fit is trimmed
+// with every older chat omitted, as older turns are within a single chat.
+func combineChatsForReload(memories []storage.Memory, format string, maxTokens int, estimate TokenEstimator) string {
+	chats := make([]storage.Memory, len(memories))
+	copy(chats, memories)
+	sort.SliceStable(chats, func(i, j int) bool {
+		return chats[i].CreatedAt.Before(chats[j].CreatedAt)
+	})
+
+	// Fill the budget from the newest chat backwards
+	sections := make([]string, len(chats))
+	omitted := 0
+	remaining := maxTokens - estimate(combinedHeader(chats, format, 0))
+	for i := len(chats) - 1; i >= 0; i-- {
+		if maxTokens <= 0 {
+			sections[i] = formatChatForReload(chats[i], format)
+			continue
+		}
+		budget := remaining - estimate(combinedSeparator(chats[i], i, len(chats), format))
+		if budget <= 0 {
+			omitted = i + 1
+			break
+		}
+		sections[i] = fitChatToTokenBudget(chats[i], format, budget, estimate)
+		remaining = budget - estimate(sections[i])
+		if sections[i] != formatChatForReload(chats[i], format) {
+			// Trimmed, so anything older is elided with its oldest turns
+			omitted = i
+			break
+		}
+	}
+
+	switch format {
+	case "messages-json":
+		return combineMessagesJSON(chats[omitted:], sections[omitted:], omitted)
+	case "raw-json":
+		return combineRawJSON(sections[omitted:])
+	}
+
+	var output strings.Builder
+	output.WriteString(combinedHeader(chats, format, omitted))
+	for i := omitted; i < len(chats); i++ {
+		output.WriteString(combinedSeparator(chats[i], i, len(chats), format))
+		output.WriteString(sections[i])
+		if !strings.HasSuffix(sections[i], "\n") {
+			output.WriteString("\n")
+		}
+	}
+	return output.String()
+}
+
+// combinedHeader introduces a combined reload, noting how many of the
+// oldest chats were omitted to fit the token budget
+func combinedHeader(chats []storage.Memory, format string, omitted int) string {
+	if isJSONReloadFormat(format) || len(chats) == 0 {
+		return ""
+	}
+
+	var header strings.Builder
+	header.WriteString(fmt.Sprintf("# Combined Context: %d conversations\n\n", len(chats)))
+	header.WriteString(fmt.Sprintf("*Captured from %s to %s*\n",
+		chats[0].CreatedAt.Format("2006-01-02 15:04"),
+		chats[len(chats)-1].CreatedAt.Format("2006-01-02 15:04")))
+	if omitted > 0 {
+		header.WriteString(fmt.Sprintf("\n*[%d earlier conversation(s) omitted to fit the token budget]*\n", omitted))
+	}
+	return header.String()
+}
+
+// combinedSeparator introduces the i-th of n chats in a combined reload
+func combinedSeparator(memory storage.Memory, i, n int, format string) string {
+	if isJSONReloadFormat(format) {
+		return ""
+	}
+	return fmt.Sprintf("\n========== Conversation %d of %d: %s (%s) ==========\n\n", i+1, n, memory.Name, memory.ID)
+}
+
+// isJSONReloadFormat reports whether format emits a JSON document, which
+// combined reloads join rather than separating with headings
+func isJSONReloadFormat(format string) bool {
+	return format == "messages-json" || format == "raw-json"
+}
+
+// combineRawJSON gathers each chat's raw-json section into a MemoryList
+// document
+func combineRawJSON(sections []string) string {
+	memories := make([]storage.Memory, 0, len(sections))
+	for _, section := range sections {
+		var doc api.MemoryDocument
+		if err := json.Unmarshal([]byte(section), &doc); err != nil {
+			// Trimmed beyond a valid document; the chat doesn't fit
+			continue
+		}
+		memories = append(memories, doc.Spec)
+	}
+
+	data, err := json.MarshalIndent(api.NewMemoryListDocument(memories), "", "  ")
+	if err != nil {
+		return "{}\n" // Unreachable: memories read from JSON always marshal
+	}
+	return string(data) + "\n"
+}
+
+// combineMessagesJSON joins the turns of each chat's messages-json section
+// into one array, introducing each chat with a system turn
+func combineMessagesJSON(chats []storage.Memory, sections []string, omitted int) string {
+	n := len(chats) + omitted
+	var messages []chatAPIMessage
+	for i, section := range sections {
+		var turns []chatAPIMessage
+		if err := json.Unmarshal([]byte(section), &turns); err != nil {
+			// Trimmed beyond a valid document; the chat's turns don't fit
+			continue
+		}
+		messages = append(messages, chatAPIMessage{
+			Role:    "system",
+			Content: fmt.Sprintf("Conversation %d of %d: %s", i+omitted+1, n, chats[i].Name),
+		})
+		messages = append(messages, turns...)
+	}
+	if messages == nil {
+		messages = []chatAPIMessage{}
+	}
+
+	data, err := json.MarshalIndent(messages, "", "  ")
+	if err != nil {
+		return "[]\n" // Unreachable: strings always marshal
+	}
+	return string(data) + "\n"
+}
+
+// maxElidedSummaryItems bounds the summary of elided turns
+const maxElidedSummaryItems = 5
+
+// buildTrimmedChat reassembles chat markdown from the header and kept
+// turns, noting how many earlier turns were elided
+func buildTrimmedChat(header string, elided, kept []chatTurn, withSummary bool) string {
+	var content strings.Builder
+	content.WriteString(header)
+	if !strings.HasSuffix(header, "\n\n") {
+		content.WriteString("\n")
+	}
+
+	content.WriteString(fmt.Sprintf("*[%d earlier turn(s) elided to fit the token budget]*\n\n", len(elided)))
+
+	if withSummary {
+		var questions []string
+		for _, turn := range elided {
+			if turn.Role == "user" && turn.Content != "" {
+				questions = append(questions, truncateString(strings.Join(strings.Fields(turn.Content), " "), 80))
+			}
+		}
+		if len(questions) > maxElidedSummaryItems {
+			questions = questions[len(questions)-maxElidedSummaryItems:]
+		}
+		if len(questions) > 0 {
+			content.WriteString("Earlier in this conversation the user asked:\n")
+			for _, q := range questions {
+				content.WriteString("- " + q + "\n")
+			}
+			content.WriteString("\n")
+		}
+	}
+
+	for _, turn := range kept {
+		content.WriteString(turn.Raw)
+	}
+
+	return content.String()
+}
+
+// truncateToTokens returns the longest prefix of text that fits within
+// maxTokens according to estimate
+func truncateToTokens(text string, maxTokens int, estimate TokenEstimator) string {
+	lo, hi := 0, len(text)
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if estimate(text[:mid]) <= maxTokens {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	return strings.ToValidUTF8(text[:lo], "")
+}
+
 func formatAsConversational(memory storage.Memory) string {
 	var output strings.Builder
 
@@ -298,6 +701,44 @@ func formatAsConversational(memory storage.Memory) string {
 	return output.String()
 }
 
+// chatAPIMessage is a turn in the shape chat completion APIs expect
+type chatAPIMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// formatAsMessagesJSON converts the stored markdown back into a JSON array
+// of {role, content} turns. Roles other than user and assistant become
+// system.
+func formatAsMessagesJSON(memory storage.Memory) string {
+	_, turns := parseChatTurns(memory.Content)
+
+	messages := make([]chatAPIMessage, 0, len(turns))
+	for _, turn := range turns {
+		role := turn.Role
+		if role != "user" && role != "assistant" {
+			role = "system"
+		}
+		messages = append(messages, chatAPIMessage{Role: role, Content: turn.Content})
+	}
+
+	data, err := json.MarshalIndent(messages, "", "  ")
+	if err != nil {
+		return "[]\n" // Unreachable: strings always marshal
+	}
+	return string(data) + "\n"
+}
+
+// formatAsRawJSON emits the stored memory, labels, timestamps, metadata
+// and all, as a Memory document
+func formatAsRawJSON(memory storage.Memory) string {
+	data, err := json.MarshalIndent(api.NewMemoryDocument(&memory), "", "  ")
+	if err != nil {
+		return "{}\n" // Unreachable: memories read from JSON always marshal
+	}
+	return string(data) + "\n"
+}
+
 func formatAsContext(memory storage.Memory) string {
 	// Strip out the conversational markers and just provide clean context
 	content := memory.Content
@@ -337,7 +778,7 @@ func formatAsSummary(memory storage.Memory) string {
 
 	// Extract key topics and concepts
 	if techs := memory.Labels["technologies"]; techs != "" {
-		output.WriteString(fmt.Sprintf("**Technologies discussed**: %s\n", techs))
+		output.WriteString(fmt.Sprintf("**Technologies discussed**: %s\n", strings.Join(splitLabelValues(techs), ", ")))
 	}
 	if lang := memory.Labels["language"]; lang != "" {
 		output.WriteString(fmt.Sprintf("**Primary language**: %s\n", lang))
@@ -373,11 +814,7 @@ func extractContentPreview(content string, maxLength int) string {
 
 	for _, line := range lines {
 		if strings.HasPrefix(line, "**User**: ") {
-			userContent := strings.TrimPrefix(line, "**User**: ")
-			if len(userContent) > maxLength {
-				return userContent[:maxLength-3] + "..."
-			}
-			return userContent
+			return contentPreview(strings.TrimPrefix(line, "**User**: "), maxLength)
 		}
 	}
 
@@ -385,10 +822,7 @@ func extractContentPreview(content string, maxLength int) string {
 	for _, line := range lines {
 		line = strings.TrimSpace(line)
 		if line != "" && !strings.HasPrefix(line, "#") && !strings.HasPrefix(line, "**Date**:") {
-			if len(line) > maxLength {
-				return line[:maxLength-3] + "..."
-			}
-			return line
+			return contentPreview(line, maxLength)
 		}
 	}
 
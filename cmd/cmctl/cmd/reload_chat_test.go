@@ -0,0 +1,468 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/api"
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/cursor"
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/providers"
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+)
+
+// longChatMemory builds a chat memory with the given number of
+// question/answer exchanges, as import-cursor-chat would store it
+func longChatMemory(exchanges int) storage.Memory {
+	chat := cursor.ChatTab{Title: "Long session", Timestamp: time.Date(2025, 9, 20, 10, 0, 0, 0, time.UTC).UnixMilli()}
+	for i := 1; i <= exchanges; i++ {
+		chat.Messages = append(chat.Messages,
+			cursor.Message{Role: "user", Content: fmt.Sprintf("Question %d about the storage layer?", i)},
+			cursor.Message{Role: "assistant", Content: fmt.Sprintf("Answer %d: %s", i, strings.Repeat("details ", 30))},
+		)
+	}
+	return storage.Memory{
+		ID:        "mem_long",
+		Name:      "Long session",
+		Content:   chat.ToMarkdown(),
+		Labels:    map[string]string{"type": "chat"},
+		CreatedAt: time.Date(2025, 9, 20, 10, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestParseChatTurns(t *testing.T) {
+	content := "# Title\n\n**Date**: 2025-09-20 10:00:00\n\n" +
+		"**User**: First line\nsecond line\n\n" +
+		"**Assistant** _(2025-09-20 10:00:05)_: Reply\n\n" +
+		"**system**: Composer session\n\n"
+
+	header, turns := parseChatTurns(content)
+
+	if !strings.Contains(header, "**Date**:") || strings.Contains(header, "First line") {
+		t.Errorf("Unexpected header: %q", header)
+	}
+
+	want := []struct{ role, content string }{
+		{"user", "First line\nsecond line"},
+		{"assistant", "Reply"},
+		{"system", "Composer session"},
+	}
+	if len(turns) != len(want) {
+		t.Fatalf("Expected %d turns, got %d: %+v", len(want), len(turns), turns)
+	}
+	for i, turn := range turns {
+		if turn.Role != want[i].role || turn.Content != want[i].content {
+			t.Errorf("Turn %d: got %s %q, want %s %q", i, turn.Role, turn.Content, want[i].role, want[i].content)
+		}
+	}
+}
+
+func TestFitChatToTokenBudget(t *testing.T) {
+	memory := longChatMemory(20)
+
+	full := formatChatForReload(memory, "conversational")
+	if approxTokens(full) <= 1000 {
+		t.Fatalf("Fixture too small to exercise trimming: %d tokens", approxTokens(full))
+	}
+
+	for _, format := range []string{"conversational", "context-only", "raw"} {
+		t.Run(format, func(t *testing.T) {
+			output := fitChatToTokenBudget(memory, format, 1000, approxTokens)
+
+			if got := approxTokens(output); got > 1000 {
+				t.Errorf("Expected at most 1000 tokens, got %d", got)
+			}
+			if !strings.Contains(output, "Question 20 about") || !strings.Contains(output, "Answer 20:") {
+				t.Errorf("Expected the latest turns to be kept, got:\n%s", output)
+			}
+			if strings.Contains(output, "Answer 1:") {
+				t.Errorf("Expected the earliest turns to be elided, got:\n%s", output)
+			}
+			if !strings.Contains(output, "earlier turn(s) elided") {
+				t.Errorf("Expected an elision note, got:\n%s", output)
+			}
+		})
+	}
+}
+
+func TestFitChatToTokenBudgetUnderBudget(t *testing.T) {
+	memory := longChatMemory(2)
+
+	full := formatChatForReload(memory, "conversational")
+	if got := fitChatToTokenBudget(memory, "conversational", 100000, approxTokens); got != full {
+		t.Error("Expected output within budget to be unchanged")
+	}
+	if got := fitChatToTokenBudget(memory, "conversational", 0, approxTokens); got != full {
+		t.Error("Expected a zero budget to disable trimming")
+	}
+}
+
+func TestFitChatToTokenBudgetCustomEstimator(t *testing.T) {
+	memory := longChatMemory(10)
+
+	words := func(text string) int { return len(strings.Fields(text)) }
+	output := fitChatToTokenBudget(memory, "raw", 150, words)
+
+	if got := words(output); got > 150 {
+		t.Errorf("Expected at most 150 words, got %d", got)
+	}
+	if !strings.Contains(output, "Answer 10:") {
+		t.Errorf("Expected the latest turn to be kept, got:\n%s", output)
+	}
+
+	// A budget smaller than any turn still produces bounded output
+	tiny := fitChatToTokenBudget(memory, "raw", 5, approxTokens)
+	if got := approxTokens(tiny); got > 5 {
+		t.Errorf("Expected at most 5 tokens, got %d", got)
+	}
+}
+
+func TestFormatAsMessagesJSON(t *testing.T) {
+	chat := cursor.ChatTab{
+		Title: "Round trip",
+		Messages: []cursor.Message{
+			{Role: "system", Content: "Composer session"},
+			{Role: "user", Content: "How do I parse JSON?\n\nWith nested objects?", Timestamp: 1758362400000},
+			{Role: "assistant", Content: "Use encoding/json:\n\n```go\njson.Unmarshal(data, &v)\n```"},
+			{Role: "tool", Content: "ran go test"},
+		},
+	}
+
+	for _, content := range []string{chat.ToMarkdown(), chat.ToMarkdownWithTimestamps()} {
+		memory := storage.Memory{Name: "Round trip", Content: content, Labels: map[string]string{"type": "chat"}}
+
+		var messages []chatAPIMessage
+		if err := json.Unmarshal([]byte(formatChatForReload(memory, "messages-json")), &messages); err != nil {
+			t.Fatalf("Output is not valid JSON: %v", err)
+		}
+
+		want := []chatAPIMessage{
+			{Role: "system", Content: "Composer session"},
+			{Role: "user", Content: chat.Messages[1].Content},
+			{Role: "assistant", Content: chat.Messages[2].Content},
+			{Role: "system", Content: "ran go test"},
+		}
+		if len(messages) != len(want) {
+			t.Fatalf("Expected %d messages, got %d: %+v", len(want), len(messages), messages)
+		}
+		for i := range want {
+			if messages[i] != want[i] {
+				t.Errorf("Message %d: got %+v, want %+v", i, messages[i], want[i])
+			}
+		}
+	}
+}
+
+func TestFormatAsMessagesJSONEmpty(t *testing.T) {
+	output := formatChatForReload(storage.Memory{Content: "# No turns here\n"}, "messages-json")
+	if strings.TrimSpace(output) != "[]" {
+		t.Errorf("Expected an empty JSON array, got %q", output)
+	}
+}
+
+func TestFormatAsRawJSON(t *testing.T) {
+	memory := longChatMemory(2)
+	memory.Labels["language"] = "go"
+	memory.Metadata = map[string]any{"source": "cursor", "summary": map[string]any{"tokens": float64(350)}}
+	memory.UpdatedAt = memory.CreatedAt.Add(time.Hour)
+
+	var doc api.MemoryDocument
+	if err := json.Unmarshal([]byte(formatChatForReload(memory, "raw-json")), &doc); err != nil {
+		t.Fatalf("Output is not valid JSON: %v", err)
+	}
+	if doc.APIVersion != api.Version || doc.Kind != api.KindMemory {
+		t.Errorf("Expected a %s Memory document, got %s %s", api.Version, doc.APIVersion, doc.Kind)
+	}
+	got := doc.Spec
+	if got.ID != memory.ID || got.Name != memory.Name || got.Content != memory.Content {
+		t.Errorf("Expected the stored memory, got %+v", got)
+	}
+	if got.Labels["language"] != "go" || got.Labels["type"] != "chat" {
+		t.Errorf("Expected labels to round-trip, got %v", got.Labels)
+	}
+	if !got.CreatedAt.Equal(memory.CreatedAt) || !got.UpdatedAt.Equal(memory.UpdatedAt) {
+		t.Errorf("Expected timestamps to round-trip, got %v and %v", got.CreatedAt, got.UpdatedAt)
+	}
+	if got.Metadata["source"] != "cursor" {
+		t.Errorf("Expected metadata to round-trip, got %v", got.Metadata)
+	}
+}
+
+// stubClipboard replaces the clipboard writer for the duration of a test
+func stubClipboard(t *testing.T, err error) *string {
+	t.Helper()
+	var copied string
+	original := clipboardWriter
+	clipboardWriter = func(text string) error {
+		if err != nil {
+			return err
+		}
+		copied = text
+		return nil
+	}
+	t.Cleanup(func() { clipboardWriter = original })
+	return &copied
+}
+
+func TestEmitReloadOutputClipboard(t *testing.T) {
+	tests := []struct {
+		name          string
+		clipboard     bool
+		clipboardOnly bool
+		clipboardErr  error
+		wantPrinted   bool
+		wantCopied    bool
+	}{
+		{name: "print only", wantPrinted: true},
+		{name: "clipboard", clipboard: true, wantPrinted: true, wantCopied: true},
+		{name: "clipboard only", clipboardOnly: true, wantCopied: true},
+		{name: "clipboard unavailable", clipboardOnly: true, clipboardErr: errNoClipboard, wantPrinted: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			copied := stubClipboard(t, tt.clipboardErr)
+
+			var out strings.Builder
+			if err := emitReloadOutput(&out, "reloaded context", tt.clipboard, tt.clipboardOnly); err != nil {
+				t.Fatalf("emitReloadOutput failed: %v", err)
+			}
+
+			if printed := out.String() == "reloaded context"; printed != tt.wantPrinted {
+				t.Errorf("Expected printed=%v, got output %q", tt.wantPrinted, out.String())
+			}
+			if gotCopied := *copied == "reloaded context"; gotCopied != tt.wantCopied {
+				t.Errorf("Expected copied=%v, got clipboard %q", tt.wantCopied, *copied)
+			}
+		})
+	}
+}
+
+func TestReloadSpecificChatMissingMemory(t *testing.T) {
+	fs := newTestStorage(t)
+
+	err := reloadSpecificChat(fs, "mem_missing")
+	if !errors.Is(err, storage.ErrMemoryNotFound) {
+		t.Errorf("Expected ErrMemoryNotFound, got %v", err)
+	}
+}
+
+// useReloadOutputFile sets --output-file and --force for a test
+func useReloadOutputFile(t *testing.T, path string, force bool) {
+	t.Helper()
+	prevPath, prevForce := reloadOutputFile, reloadForce
+	t.Cleanup(func() { reloadOutputFile, reloadForce = prevPath, prevForce })
+	reloadOutputFile, reloadForce = path, force
+}
+
+// createChatMemories stores chat memories asking each question
+func createChatMemories(t *testing.T, fs providers.StorageProvider, questions ...string) []storage.Memory {
+	t.Helper()
+	var memories []storage.Memory
+	for _, question := range questions {
+		chat := testChat(question, question)
+		memory, err := fs.Create(storage.CreateMemoryRequest{
+			Name:    question,
+			Content: chat.ToMarkdown(),
+			Labels:  map[string]string{"type": "chat"},
+		})
+		if err != nil {
+			t.Fatalf("Failed to create memory: %v", err)
+		}
+		memories = append(memories, *memory)
+		// Keep creation times distinct
+		time.Sleep(10 * time.Millisecond)
+	}
+	return memories
+}
+
+func TestReloadOutputFile(t *testing.T) {
+	fs := newTestStorage(t)
+	memories := createChatMemories(t, fs, "How do I add a flag?", "How do I test it?")
+
+	reloads := map[string]func() error{
+		"single":  func() error { return reloadSpecificChat(fs, memories[0].ID) },
+		"combine": func() error { return reloadCombinedChats(fs, memories) },
+	}
+	for name, reload := range reloads {
+		t.Run(name, func(t *testing.T) {
+			useReloadOutputFile(t, "-", false)
+			stdout := captureStdout(t, reload)
+
+			path := filepath.Join(t.TempDir(), "shared", "context.md")
+			useReloadOutputFile(t, path, false)
+			if printed := captureStdout(t, reload); printed != "" {
+				t.Errorf("Expected nothing on stdout, got %q", printed)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("Failed to read output file: %v", err)
+			}
+			if string(data) != stdout {
+				t.Errorf("Expected the file to match stdout output\nfile:\n%s\nstdout:\n%s", data, stdout)
+			}
+
+			if err := reload(); err == nil || !strings.Contains(err.Error(), "--force") {
+				t.Errorf("Expected an existing file to be refused without --force, got %v", err)
+			}
+			useReloadOutputFile(t, path, true)
+			if err := reload(); err != nil {
+				t.Errorf("Expected --force to overwrite, got %v", err)
+			}
+		})
+	}
+}
+
+func TestReloadChatFirstLast(t *testing.T) {
+	fs := newTestStorage(t)
+	createChatMemories(t, fs, "Oldest question?", "Middle question?")
+	if _, err := fs.Create(storage.CreateMemoryRequest{
+		Name:    "Newest question?",
+		Content: testChat("newest", "Newest question?").ToMarkdown(),
+		Labels:  map[string]string{"type": "chat", "project": "api"},
+	}); err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	createChatMemories(t, fs, "Latest unrelated question?")
+	defer func() { reloadFirst, reloadLast, reloadLabels, reloadSearch = false, false, "", "" }()
+
+	tests := []struct {
+		first, last    bool
+		labels, search string
+		want           string
+	}{
+		{last: true, want: "Latest unrelated question?"},
+		{first: true, want: "Oldest question?"},
+		{last: true, labels: "project=api", want: "Newest question?"},
+		{last: true, search: "Middle", want: "Middle question?"},
+		{first: true, search: "question", want: "Oldest question?"},
+	}
+	for _, tt := range tests {
+		reloadFirst, reloadLast, reloadLabels, reloadSearch = tt.first, tt.last, tt.labels, tt.search
+		output := captureStdout(t, func() error { return runSearchAndReload(fs) })
+		if !strings.Contains(output, "# Previous Conversation: "+tt.want) {
+			t.Errorf("first=%v last=%v labels=%q search=%q: expected %q, got:\n%s", tt.first, tt.last, tt.labels, tt.search, tt.want, output)
+		}
+	}
+
+	reloadFirst, reloadLast = true, true
+	if err := validateReloadSelection(false); err == nil {
+		t.Error("Expected an error with --first and --last")
+	}
+	reloadFirst = false
+	if err := validateReloadSelection(true); err == nil {
+		t.Error("Expected an error with a memory ID")
+	}
+}
+
+// chatMemoryAt builds a small chat memory captured at the given time
+func chatMemoryAt(id, question string, createdAt time.Time) storage.Memory {
+	chat := testChat(id, question)
+	return storage.Memory{
+		ID:        id,
+		Name:      question,
+		Content:   chat.ToMarkdown(),
+		Labels:    map[string]string{"type": "chat"},
+		CreatedAt: createdAt,
+	}
+}
+
+func TestCombineChatsForReload(t *testing.T) {
+	base := time.Date(2025, 9, 20, 10, 0, 0, 0, time.UTC)
+	memories := []storage.Memory{
+		chatMemoryAt("mem_newest", "Third question?", base.Add(2*time.Hour)),
+		chatMemoryAt("mem_oldest", "First question?", base),
+		chatMemoryAt("mem_middle", "Second question?", base.Add(time.Hour)),
+	}
+
+	output := combineChatsForReload(memories, "conversational", 0, approxTokens)
+
+	if !strings.HasPrefix(output, "# Combined Context: 3 conversations") {
+		t.Errorf("Expected combined header, got: %s", output)
+	}
+	if strings.Count(output, "# Previous Conversation:") != 3 {
+		t.Errorf("Expected each chat in the chosen format, got: %s", output)
+	}
+	last := -1
+	for i, question := range []string{"First question?", "Second question?", "Third question?"} {
+		separator := fmt.Sprintf("Conversation %d of 3: %s", i+1, question)
+		pos := strings.Index(output, separator)
+		if pos < 0 {
+			t.Fatalf("Expected separator %q, got: %s", separator, output)
+		}
+		if pos < last {
+			t.Errorf("Expected %q after the previous chat", separator)
+		}
+		last = pos
+	}
+}
+
+func TestCombineChatsForReloadMessagesJSON(t *testing.T) {
+	base := time.Date(2025, 9, 20, 10, 0, 0, 0, time.UTC)
+	memories := []storage.Memory{
+		chatMemoryAt("mem_b", "Second question?", base.Add(time.Hour)),
+		chatMemoryAt("mem_a", "First question?", base),
+	}
+
+	var messages []chatAPIMessage
+	if err := json.Unmarshal([]byte(combineChatsForReload(memories, "messages-json", 0, approxTokens)), &messages); err != nil {
+		t.Fatalf("Expected a single JSON array: %v", err)
+	}
+	if len(messages) != 6 {
+		t.Fatalf("Expected a system turn and 2 turns per chat, got %+v", messages)
+	}
+	if messages[0].Role != "system" || messages[1].Content != "First question?" || messages[4].Content != "Second question?" {
+		t.Errorf("Expected chats oldest first, got %+v", messages)
+	}
+}
+
+func TestCombineChatsForReloadRawJSON(t *testing.T) {
+	base := time.Date(2025, 9, 20, 10, 0, 0, 0, time.UTC)
+	memories := []storage.Memory{
+		chatMemoryAt("mem_b", "Second question?", base.Add(time.Hour)),
+		chatMemoryAt("mem_a", "First question?", base),
+	}
+
+	var doc api.MemoryListDocument
+	if err := json.Unmarshal([]byte(combineChatsForReload(memories, "raw-json", 0, approxTokens)), &doc); err != nil {
+		t.Fatalf("Expected a single JSON document: %v", err)
+	}
+	if doc.Kind != api.KindMemoryList || len(doc.Items) != 2 {
+		t.Fatalf("Expected a MemoryList of 2 chats, got %+v", doc)
+	}
+	if doc.Items[0].ID != "mem_a" || doc.Items[1].ID != "mem_b" {
+		t.Errorf("Expected chats oldest first, got %s, %s", doc.Items[0].ID, doc.Items[1].ID)
+	}
+}
+
+func TestCombineChatsForReloadTokenBudget(t *testing.T) {
+	base := time.Date(2025, 9, 20, 10, 0, 0, 0, time.UTC)
+	var memories []storage.Memory
+	for i := 0; i < 3; i++ {
+		memory := longChatMemory(10)
+		memory.ID = fmt.Sprintf("mem_%d", i)
+		memory.Name = fmt.Sprintf("Session %d", i)
+		memory.CreatedAt = base.Add(time.Duration(i) * time.Hour)
+		memories = append(memories, memory)
+	}
+
+	full := combineChatsForReload(memories, "conversational", 0, approxTokens)
+	budget := approxTokens(full) / 2
+	output := combineChatsForReload(memories, "conversational", budget, approxTokens)
+
+	if got := approxTokens(output); got > budget {
+		t.Errorf("Expected at most %d tokens, got %d", budget, got)
+	}
+	if !strings.Contains(output, "Conversation 3 of 3: Session 2") || !strings.Contains(output, "Answer 10:") {
+		t.Errorf("Expected the newest chat to be kept, got: %s", output)
+	}
+	if !strings.Contains(output, "omitted to fit the token budget") {
+		t.Errorf("Expected the oldest chat to be omitted, got: %s", output)
+	}
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/providers"
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+var renameCmd = &cobra.Command{
+	Use:   "rename <memory-id> <new-name>",
+	Short: "Rename a memory",
+	Long: `Change the name of a memory, leaving its content and labels untouched.
+
+Examples:
+  cmctl rename mem_abc123_def456 "Auth Debugging Session"`,
+	Args: cobra.ExactArgs(2),
+	RunE: runRename,
+}
+
+func init() {
+	rootCmd.AddCommand(renameCmd)
+}
+
+func runRename(cmd *cobra.Command, args []string) error {
+	// Initialize storage
+	fs, err := getStorageProvider()
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+
+	memory, err := renameMemory(fs, args[0], args[1])
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("memory/%s renamed\n", memory.ID)
+	VPrintf(Normal, "NAME\t%s\n", memory.Name)
+	return nil
+}
+
+// renameMemory changes only the name of a memory
+func renameMemory(fs providers.StorageProvider, id, name string) (*storage.Memory, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("new name cannot be empty")
+	}
+
+	memory, err := fs.Update(storage.UpdateMemoryRequest{
+		ID:   id,
+		Name: name,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to rename memory: %w", err)
+	}
+	return memory, nil
+}
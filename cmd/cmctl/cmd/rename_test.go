@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+)
+
+func TestRenameMemory(t *testing.T) {
+	fs := newTestStorage(t)
+
+	original, err := fs.Create(storage.CreateMemoryRequest{
+		Name:    "Old Name",
+		Content: "Some content",
+		Labels:  map[string]string{"type": "notes", "project": "api"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+
+	if _, err := renameMemory(fs, original.ID, "New Name"); err != nil {
+		t.Fatalf("renameMemory failed: %v", err)
+	}
+
+	renamed, err := fs.Get(original.ID)
+	if err != nil {
+		t.Fatalf("Failed to get memory: %v", err)
+	}
+	if renamed.Name != "New Name" {
+		t.Errorf("Expected name 'New Name', got %q", renamed.Name)
+	}
+	if renamed.Content != original.Content {
+		t.Errorf("Expected content unchanged, got %q", renamed.Content)
+	}
+	if !reflect.DeepEqual(renamed.Labels, original.Labels) {
+		t.Errorf("Expected labels unchanged, got %v", renamed.Labels)
+	}
+	if !renamed.CreatedAt.Equal(original.CreatedAt) {
+		t.Errorf("Expected CreatedAt unchanged")
+	}
+	if !renamed.UpdatedAt.After(original.UpdatedAt) {
+		t.Errorf("Expected UpdatedAt to be bumped")
+	}
+}
+
+func TestRenameMemoryValidation(t *testing.T) {
+	fs := newTestStorage(t)
+
+	memory, err := fs.Create(storage.CreateMemoryRequest{Name: "Name", Content: "Content"})
+	if err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+
+	for _, name := range []string{"", "   ", strings.Repeat("x", 201)} {
+		if _, err := renameMemory(fs, memory.ID, name); err == nil {
+			t.Errorf("Expected error renaming to %d-character name", len(name))
+		}
+	}
+
+	if _, err := renameMemory(fs, "mem_missing", "New Name"); err == nil {
+		t.Error("Expected error renaming a missing memory")
+	}
+
+	stored, err := fs.Get(memory.ID)
+	if err != nil {
+		t.Fatalf("Failed to get memory: %v", err)
+	}
+	if stored.Name != "Name" {
+		t.Errorf("Expected name unchanged after failed renames, got %q", stored.Name)
+	}
+}
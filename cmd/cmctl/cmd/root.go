@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -32,8 +33,14 @@ Features:
 Verbosity levels:
 - -v=0 (quiet): Only essential output
 - -v=1 (normal): Standard messages (default)
-- -v=2 (verbose): Debug info and config details`,
+- -v=2 (verbose): Debug info and config details
+
+Warnings from storage and chat readers are logged to stderr at these
+levels; --log-format json writes them, and all other diagnostics, as JSON
+lines for filtering.`,
 	Version: "0.7.0",
+
+	PersistentPreRunE: validateSettings,
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -47,20 +54,76 @@ func init() {
 	// Global flags
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.contextmemory/config.yaml)")
 	rootCmd.PersistentFlags().String("storage-dir", "", "storage directory (default is $HOME/.contextmemory)")
-	rootCmd.PersistentFlags().String("provider", "file", "storage provider (file, s3, gcs, remote)")
+	rootCmd.PersistentFlags().String(contextKey, "", "memory store context, a subdirectory of the storage directory (default is the current context)")
+	rootCmd.PersistentFlags().String("provider", "file", "storage provider (file, git, sqlite, s3, gcs, remote)")
+	rootCmd.PersistentFlags().Bool("no-commit", false, "with the git provider, don't commit changes (for batch operations)")
+	rootCmd.PersistentFlags().String("encryption-key-file", "", "file containing the passphrase for encryption at rest (or set $CONTEXTMEMORY_KEY)")
+	rootCmd.PersistentFlags().Bool("encrypt-metadata", false, "also encrypt memory names and labels")
+	rootCmd.PersistentFlags().Int64("max-content-bytes", storage.DefaultMaxContentBytes, "maximum size of memory content in bytes (0 for no limit)")
 	rootCmd.PersistentFlags().IntVarP(&verbosity, "verbosity", "v", 1, "verbosity level (0=quiet, 1=normal, 2=verbose)")
+	rootCmd.PersistentFlags().Bool(noTrackAccessKey, false, "don't record when memories are read (for read-only storage)")
+	rootCmd.PersistentFlags().Bool("no-color", false, "disable colored table output (also respects NO_COLOR)")
+	rootCmd.PersistentFlags().Int(maxWidthKey, 0, "fit table output to this many columns (default is $COLUMNS or the terminal width)")
+	rootCmd.PersistentFlags().String(logFormatKey, logFormatText, "format of warnings and diagnostics on stderr (text, json)")
 
 	// Bind flags to viper
 	if err := viper.BindPFlag("storage-dir", rootCmd.PersistentFlags().Lookup("storage-dir")); err != nil {
 		// This should never happen for flags we define ourselves
 		panic(fmt.Sprintf("failed to bind storage-dir flag: %v", err))
 	}
+	if err := viper.BindPFlag(contextKey, rootCmd.PersistentFlags().Lookup(contextKey)); err != nil {
+		panic(fmt.Sprintf("failed to bind context flag: %v", err))
+	}
+	if err := viper.BindEnv(contextKey, contextEnv); err != nil {
+		panic(fmt.Sprintf("failed to bind %s: %v", contextEnv, err))
+	}
 	if err := viper.BindPFlag("provider", rootCmd.PersistentFlags().Lookup("provider")); err != nil {
 		panic(fmt.Sprintf("failed to bind provider flag: %v", err))
 	}
+	if err := viper.BindPFlag("no-commit", rootCmd.PersistentFlags().Lookup("no-commit")); err != nil {
+		panic(fmt.Sprintf("failed to bind no-commit flag: %v", err))
+	}
+	if err := viper.BindPFlag("encryption-key-file", rootCmd.PersistentFlags().Lookup("encryption-key-file")); err != nil {
+		panic(fmt.Sprintf("failed to bind encryption-key-file flag: %v", err))
+	}
+	if err := viper.BindPFlag("encrypt-metadata", rootCmd.PersistentFlags().Lookup("encrypt-metadata")); err != nil {
+		panic(fmt.Sprintf("failed to bind encrypt-metadata flag: %v", err))
+	}
+	if err := viper.BindPFlag("max-content-bytes", rootCmd.PersistentFlags().Lookup("max-content-bytes")); err != nil {
+		panic(fmt.Sprintf("failed to bind max-content-bytes flag: %v", err))
+	}
 	if err := viper.BindPFlag("verbosity", rootCmd.PersistentFlags().Lookup("verbosity")); err != nil {
 		panic(fmt.Sprintf("failed to bind verbosity flag: %v", err))
 	}
+	if err := viper.BindPFlag(noTrackAccessKey, rootCmd.PersistentFlags().Lookup(noTrackAccessKey)); err != nil {
+		panic(fmt.Sprintf("failed to bind no-track-access flag: %v", err))
+	}
+	if err := viper.BindPFlag("no-color", rootCmd.PersistentFlags().Lookup("no-color")); err != nil {
+		panic(fmt.Sprintf("failed to bind no-color flag: %v", err))
+	}
+	if err := viper.BindPFlag(logFormatKey, rootCmd.PersistentFlags().Lookup(logFormatKey)); err != nil {
+		panic(fmt.Sprintf("failed to bind log-format flag: %v", err))
+	}
+	if err := viper.BindPFlag(maxWidthKey, rootCmd.PersistentFlags().Lookup(maxWidthKey)); err != nil {
+		panic(fmt.Sprintf("failed to bind max-width flag: %v", err))
+	}
+	if err := viper.BindEnv("defaultOutput", defaultOutputEnv); err != nil {
+		panic(fmt.Sprintf("failed to bind %s: %v", defaultOutputEnv, err))
+	}
+}
+
+// validateSettings rejects invalid config values before any command runs.
+// The config commands are exempt so a bad value can still be fixed.
+func validateSettings(cmd *cobra.Command, args []string) error {
+	for c := cmd; c != nil; c = c.Parent() {
+		if c == configCmd {
+			return nil
+		}
+	}
+	if err := validateLogFormat(viper.GetString(logFormatKey)); err != nil {
+		return err
+	}
+	return validateDefaultOutput()
 }
 
 // initConfig reads in config file and ENV variables if set.
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/api"
+	"github.com/spf13/cobra"
+)
+
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the JSON Schema of cmctl's structured output",
+	Long: `Print the JSON Schema of the contextmemory.io/v1 documents that -o json,
+-o yaml, 'cmctl serve' and 'cmctl mcp' emit, for validating output or
+generating client code.
+
+Memory wraps a single memory (get <id>, create, update), MemoryList wraps a
+list (get, search) and Status reports a server error. Documents may gain
+fields within v1, but existing fields keep their names and types.
+
+Examples:
+  cmctl schema                    # Schema of every document kind
+  cmctl schema --kind MemoryList  # Schema of one kind
+  cmctl schema > contextmemory-v1.schema.json`,
+	RunE: runSchema,
+}
+
+var schemaKinds []string
+
+func init() {
+	rootCmd.AddCommand(schemaCmd)
+
+	schemaCmd.Flags().StringSliceVar(&schemaKinds, "kind", nil, fmt.Sprintf("Only include these document kinds (%s)", strings.Join(api.Kinds(), ", ")))
+}
+
+func runSchema(cmd *cobra.Command, args []string) error {
+	for _, kind := range schemaKinds {
+		if !api.IsKind(kind) {
+			return fmt.Errorf("invalid --kind %q (must be one of %s)", kind, strings.Join(api.Kinds(), ", "))
+		}
+	}
+
+	data, err := json.MarshalIndent(api.Schema(schemaKinds...), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode schema: %w", err)
+	}
+	fmt.Println(string(data))
+	return nil
+}
@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/api"
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+)
+
+// validateSchema checks value against the subset of JSON Schema that
+// api.Schema generates, returning the first violation
+func validateSchema(root, schema map[string]any, value any, path string) error {
+	if ref, ok := schema["$ref"].(string); ok {
+		def, ok := root["$defs"].(map[string]any)[strings.TrimPrefix(ref, "#/$defs/")].(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s: unresolved $ref %s", path, ref)
+		}
+		return validateSchema(root, def, value, path)
+	}
+	if c, ok := schema["const"]; ok && !reflect.DeepEqual(c, value) {
+		return fmt.Errorf("%s: expected %v, got %v", path, c, value)
+	}
+	for _, keyword := range []string{"oneOf", "anyOf"} {
+		options, ok := schema[keyword].([]any)
+		if !ok {
+			continue
+		}
+		matched := 0
+		for _, option := range options {
+			if validateSchema(root, option.(map[string]any), value, path) == nil {
+				matched++
+			}
+		}
+		if matched == 0 || (keyword == "oneOf" && matched > 1) {
+			return fmt.Errorf("%s: %d of the %s schemas match", path, matched, keyword)
+		}
+	}
+
+	switch schema["type"] {
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s: expected an object, got %T", path, value)
+		}
+		for _, name := range asStrings(schema["required"]) {
+			if _, ok := object[name]; !ok {
+				return fmt.Errorf("%s: missing required property %q", path, name)
+			}
+		}
+		properties, _ := schema["properties"].(map[string]any)
+		for name, v := range object {
+			propSchema, ok := properties[name].(map[string]any)
+			if !ok {
+				propSchema, ok = schema["additionalProperties"].(map[string]any)
+			}
+			if !ok {
+				continue
+			}
+			if err := validateSchema(root, propSchema, v, path+"."+name); err != nil {
+				return err
+			}
+		}
+	case "array":
+		items, ok := value.([]any)
+		if !ok {
+			return fmt.Errorf("%s: expected an array, got %T", path, value)
+		}
+		for i, item := range items {
+			if err := validateSchema(root, schema["items"].(map[string]any), item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%s: expected a string, got %T", path, value)
+		}
+		if schema["format"] == "date-time" {
+			if _, err := time.Parse(time.RFC3339Nano, s); err != nil {
+				return fmt.Errorf("%s: invalid date-time %q", path, s)
+			}
+		}
+	case "number", "integer":
+		if _, ok := value.(float64); !ok {
+			return fmt.Errorf("%s: expected a number, got %T", path, value)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s: expected a boolean, got %T", path, value)
+		}
+	case "null":
+		if value != nil {
+			return fmt.Errorf("%s: expected null, got %T", path, value)
+		}
+	}
+	return nil
+}
+
+func asStrings(value any) []string {
+	var result []string
+	switch v := value.(type) {
+	case []string:
+		result = v
+	case []any:
+		for _, s := range v {
+			result = append(result, s.(string))
+		}
+	}
+	return result
+}
+
+func TestSchemaValidatesGetOutput(t *testing.T) {
+	useTestStorageDir(t)
+	fs, err := getStorageProvider()
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	expires := time.Now().Add(time.Hour)
+	memory, err := fs.Create(storage.CreateMemoryRequest{
+		Name:      "Auth notes",
+		Content:   "Use OAuth with PKCE",
+		Labels:    map[string]string{"type": "note"},
+		Metadata:  map[string]any{"source": "test"},
+		ExpiresAt: &expires,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+	if _, err := fs.Create(storage.CreateMemoryRequest{Name: "Unlabeled", Content: "No labels"}); err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+
+	listOutput, err := renderGetList(fs, OutputOptions{Format: OutputFormatJSON})
+	if err != nil {
+		t.Fatalf("Failed to render list: %v", err)
+	}
+	single, err := fs.Get(memory.ID)
+	if err != nil {
+		t.Fatalf("Failed to get memory: %v", err)
+	}
+	singleOutput, err := FormatSingleMemory(single, OutputOptions{Format: OutputFormatJSON})
+	if err != nil {
+		t.Fatalf("Failed to format memory: %v", err)
+	}
+
+	// Round-trip the schema through JSON, as a consumer would read it
+	var schema map[string]any
+	if err := json.Unmarshal([]byte(captureStdout(t, func() error { return runSchema(schemaCmd, nil) })), &schema); err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+
+	for name, output := range map[string]string{"list": listOutput, "single": singleOutput} {
+		var doc any
+		if err := json.Unmarshal([]byte(output), &doc); err != nil {
+			t.Fatalf("Failed to parse %s output: %v", name, err)
+		}
+		if err := validateSchema(schema, schema, doc, "$"); err != nil {
+			t.Errorf("Expected %s output to validate, got %v", name, err)
+		}
+	}
+
+	// The schema must reject documents of another version or shape
+	invalid := []string{
+		`{"apiVersion": "contextmemory.io/v2", "kind": "MemoryList", "items": []}`,
+		`{"apiVersion": "contextmemory.io/v1", "kind": "MemoryList"}`,
+		`{"apiVersion": "contextmemory.io/v1", "kind": "Memory", "metadata": {}, "spec": {"id": 1}}`,
+	}
+	for _, doc := range invalid {
+		var value any
+		if err := json.Unmarshal([]byte(doc), &value); err != nil {
+			t.Fatalf("Failed to parse %s: %v", doc, err)
+		}
+		if err := validateSchema(schema, schema, value, "$"); err == nil {
+			t.Errorf("Expected %s not to validate", doc)
+		}
+	}
+
+	if api.Schema(api.KindStatus)["$ref"] != "#/$defs/StatusDocument" {
+		t.Errorf("Expected a single-kind schema to reference its document, got %v", api.Schema(api.KindStatus)["$ref"])
+	}
+}
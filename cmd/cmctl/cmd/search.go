@@ -2,16 +2,22 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
 var searchCmd = &cobra.Command{
 	Use:   "search",
 	Short: "Search memories",
-	Long: `Search memories by text query and/or label selectors.
+	Long: `Search memories by text query and/or label selectors. Results for a text
+query are ranked by relevance: name matches first, then label matches, then
+content matches by frequency. With several --query flags a memory must
+match all of them, or any of them with --or.
 
 Performance Options:
   --no-content   Fast metadata-only search (exclude memory content)
@@ -20,63 +26,203 @@ Performance Options:
 Examples:
   cmctl search --query "authentication"                        # Search by text
   cmctl search --labels "type=session"                         # Search by labels
+  cmctl search --labels "type=chat" --exclude-labels "source=test" # Chats, less the test imports
   cmctl search --labels "type=session" --no-content            # Metadata-only search
+  cmctl search --metadata "summary.model=gpt-4o"               # Match metadata values (dotted paths for nesting)
   cmctl search --query "API" --labels "type=code" --limit 5    # Combined search
-  cmctl search --query "auth" -o json                          # JSON output
-  cmctl search -q "session" -o jsonpath='{.items[*].spec.name}' # Extract names`,
+  cmctl search -q auth -q oauth                                # Memories mentioning both
+  cmctl search -q auth -q oauth --or                           # Memories mentioning either
+  cmctl search --query "auth" -o json                          # JSON output (includes score)
+  cmctl search --query "auth" --porcelain                       # Stable id<TAB>name<TAB>labels lines for scripts
+  cmctl search --query "auth" --show-score                     # Add a SCORE column
+  cmctl search --labels "type=task" --sort-by label:priority   # Order by a label's value
+  cmctl search -q "session" -o jsonpath='{.items[*].spec.name}' # Extract names
+  cmctl search -q auth --count-by language                     # How many matches per language
+
+--count-by counts every match, not just the first --limit, unless --limit is
+given explicitly.`,
 	RunE: runSearch,
 }
 
 var (
-	searchQuery      string
+	searchQueries    []string
+	searchAnd        bool
+	searchOr         bool
 	searchLabels     string
 	searchLimit      int
 	searchOutputFlag string
 	searchNoIndex    bool
 	searchNoContent  bool
+	searchPinned     bool
+	searchExpired    bool
+	searchShowScore  bool
+	searchFields     string
+	searchMetadata   string
+	searchSortBy     string
+	searchReverse    bool
+	searchPorcelain  bool
+	searchExclude    string
+	searchCountBy    string
 )
 
 func init() {
 	rootCmd.AddCommand(searchCmd)
 
-	searchCmd.Flags().StringVarP(&searchQuery, "query", "q", "", "Text search query")
+	searchCmd.Flags().StringArrayVarP(&searchQueries, "query", "q", nil, "Text search query (repeatable)")
+	searchCmd.Flags().BoolVar(&searchAnd, "and", false, "Match memories containing every --query (the default)")
+	searchCmd.Flags().BoolVar(&searchOr, "or", false, "Match memories containing any --query")
 	searchCmd.Flags().StringVarP(&searchLabels, "labels", "l", "", "Label selector (format: key1=value1,key2=value2)")
-	searchCmd.Flags().IntVar(&searchLimit, "limit", 10, "Limit results")
-	searchCmd.Flags().StringVarP(&searchOutputFlag, "output", "o", "", "Output format: table|json|yaml|jsonpath=<template>|go-template=<template>")
+	searchCmd.Flags().StringVar(&searchExclude, "exclude-labels", "", "Exclude memories with any of these labels, applied after --labels (format: key1=value1,key2=value2)")
+	searchCmd.Flags().StringVar(&searchMetadata, "metadata", "", "Metadata selector (format: key1=value1,nested.key=value2)")
+	searchCmd.Flags().IntVar(&searchLimit, "limit", 10, "Limit results (0 for no limit)")
+	searchCmd.Flags().StringVarP(&searchOutputFlag, "output", "o", "", "Output format: table|json|jsonl|yaml|jsonpath=<template>|go-template=<template>|template=<name>")
 	searchCmd.Flags().BoolVar(&searchNoIndex, "no-index", false, "Disable index-based optimizations (force file-based search)")
+	searchCmd.Flags().BoolVar(&searchPinned, "pinned", false, "Only search pinned memories")
+	searchCmd.Flags().BoolVar(&searchExpired, "include-expired", true, "Include memories whose TTL has expired")
+	searchCmd.Flags().StringVar(&searchFields, "fields", "", "Only include these memory fields in structured output (e.g. id,name,score)")
+	searchCmd.Flags().BoolVar(&searchShowScore, "show-score", false, "Show the relevance score of each result in table output")
+	searchCmd.Flags().StringVar(&searchSortBy, "sort-by", "", sortFlagUsage+" (default: relevance)")
+	searchCmd.Flags().BoolVar(&searchReverse, "reverse", false, "Reverse the --sort-by order")
+	searchCmd.Flags().BoolVar(&searchPorcelain, "porcelain", false, "Print stable tab-separated id, name and labels lines for scripts")
+	searchCmd.Flags().StringVar(&searchCountBy, "count-by", "", "Print the number of matches for each value of this label instead of the matches")
 	searchCmd.Flags().BoolVar(&searchNoContent, "no-content", false, "Exclude memory content from results (faster for metadata-only searches)")
 }
 
 func runSearch(cmd *cobra.Command, args []string) error {
 	// Initialize storage
-	storageDir := viper.GetString("storage-dir")
-	fs, err := storage.NewFileStorage(storageDir)
+	fs, err := getStorageProvider()
 	if err != nil {
 		return fmt.Errorf("failed to initialize storage: %w", err)
 	}
 
+	if searchAnd && searchOr {
+		return fmt.Errorf("--and and --or are mutually exclusive")
+	}
+	if searchSortBy != "" {
+		if err := validateSortBy(searchSortBy); err != nil {
+			return err
+		}
+	} else if searchReverse {
+		return fmt.Errorf("--reverse requires --sort-by")
+	}
+
+	if searchPorcelain {
+		if searchOutputFlag != "" {
+			return fmt.Errorf("--porcelain and --output are mutually exclusive")
+		}
+		if searchShowScore {
+			return fmt.Errorf("--porcelain and --show-score are mutually exclusive")
+		}
+		if searchFields != "" {
+			return fmt.Errorf("--porcelain and --fields are mutually exclusive")
+		}
+	}
+
+	if searchCountBy != "" {
+		if err := validateCountBy(searchCountBy); err != nil {
+			return err
+		}
+	}
+
+	// Parse output format
+	outputOpts, err := ParseOutputFormat(outputFormatOrDefault(searchOutputFlag))
+	if err != nil {
+		return fmt.Errorf("invalid output format: %w", err)
+	}
+	outputOpts.Color = shouldColorize(os.Stdout)
+	outputOpts.Width = tableWidth(os.Stdout)
+	outputOpts.ShowScore = searchShowScore
+
+	if outputOpts.Fields, err = parseFields(searchFields); err != nil {
+		return err
+	}
+	if err := validateFieldsFormat(outputOpts); err != nil {
+		return err
+	}
+
 	// Parse label selector
 	labelSelector := parseLabels(searchLabels)
+	if searchPinned {
+		labelSelector[storage.PinnedLabel] = "true"
+	}
+
+	excludeLabels, err := parseExcludeLabels(searchExclude)
+	if err != nil {
+		return err
+	}
+
+	metadataSelector := parseLabels(searchMetadata)
+	if searchMetadata != "" && len(metadataSelector) == 0 {
+		return fmt.Errorf("invalid metadata selector format: %s", searchMetadata)
+	}
 
 	// Create search request with performance options
+	includeContent := !searchNoContent && needsContent(outputOpts.Fields)
 	req := storage.SearchRequest{
-		Query:          searchQuery,
-		LabelSelector:  labelSelector,
-		Limit:          searchLimit,
-		UseIndex:       !searchNoIndex,
-		IncludeContent: !searchNoContent,
+		Queries:          searchQueries,
+		MatchAny:         searchOr,
+		LabelSelector:    labelSelector,
+		ExcludeLabels:    excludeLabels,
+		MetadataSelector: metadataSelector,
+		Limit:            searchLimit,
+		UseIndex:         !searchNoIndex,
+		IncludeContent:   includeContent,
+	}
+	if searchCountBy != "" {
+		// Counts cover every match, and labels are all they need
+		if !cmd.Flags().Changed("limit") {
+			req.Limit = -1
+		}
+		includeContent, req.IncludeContent = false, false
+	}
+	if searchSortBy != "" {
+		req.SortBy = searchSortBy
+		req.SortOrder = sortOrder(searchSortBy, searchReverse)
+		// Sizes are content lengths, so sorting by size needs the content
+		// even if it isn't shown
+		req.IncludeContent = includeContent || searchSortBy == storage.SortBySize
 	}
 
+	// Pinned memories come first, so the limit is applied after they're
+	// sorted ahead rather than by the provider, which would drop pinned
+	// matches that rank past it
+	limit := req.Limit
+	req.Limit = 0
+
 	// Search memories
 	result, err := fs.Search(req)
 	if err != nil {
 		return fmt.Errorf("failed to search memories: %w", err)
 	}
 
-	// Parse output format
-	outputOpts, err := ParseOutputFormat(searchOutputFlag)
-	if err != nil {
-		return fmt.Errorf("invalid output format: %w", err)
+	if !searchExpired {
+		result.Memories = filterExpired(result.Memories, time.Now())
+	}
+	if req.IncludeContent && !includeContent {
+		for i := range result.Memories {
+			result.Memories[i].Content = ""
+		}
+	}
+	sortPinnedFirst(result.Memories)
+	result.Memories = storage.ApplyLimit(result.Memories, limit)
+
+	if searchCountBy != "" {
+		counts := countByLabel(result.Memories, searchCountBy)
+		if outputOpts.Format == OutputFormatTable {
+			fmt.Print(formatLabelCounts(counts, outputOpts.Color))
+			return nil
+		}
+		output, err := FormatOutput(counts, outputOpts)
+		if err != nil {
+			return err
+		}
+		fmt.Println(output)
+		return nil
+	}
+
+	if searchPorcelain {
+		fmt.Print(formatMemoryPorcelain(result.Memories))
+		return nil
 	}
 
 	// Format and print output
@@ -88,3 +234,76 @@ func runSearch(cmd *cobra.Command, args []string) error {
 	fmt.Print(output)
 	return nil
 }
+
+// LabelCounts is the number of search results with each value of a label
+type LabelCounts struct {
+	Label string `json:"label" yaml:"label"`
+	// Total is the number of results counted
+	Total  int          `json:"total" yaml:"total"`
+	Counts []LabelCount `json:"counts" yaml:"counts"`
+}
+
+// LabelCount is the number of results with one value of a label. Results
+// without the label are counted under <none>.
+type LabelCount struct {
+	Value string `json:"value" yaml:"value"`
+	Count int    `json:"count" yaml:"count"`
+}
+
+// validateCountBy checks a --count-by label key and the flags it can't be
+// combined with
+func validateCountBy(key string) error {
+	switch {
+	case searchPorcelain:
+		return fmt.Errorf("--count-by and --porcelain are mutually exclusive")
+	case searchFields != "":
+		return fmt.Errorf("--count-by and --fields are mutually exclusive")
+	case searchShowScore:
+		return fmt.Errorf("--count-by and --show-score are mutually exclusive")
+	}
+	if err := storage.ValidateLabels(map[string]string{key: ""}); err != nil {
+		return fmt.Errorf("invalid --count-by: %w", err)
+	}
+	return nil
+}
+
+// countByLabel groups memories by their value of the label key, largest
+// group first and ties in value order
+func countByLabel(memories []storage.Memory, key string) LabelCounts {
+	byValue := make(map[string]int)
+	for _, memory := range memories {
+		byValue[labelColumnValue(memory, key)]++
+	}
+
+	counts := LabelCounts{Label: key, Total: len(memories), Counts: []LabelCount{}}
+	for value, count := range byValue {
+		counts.Counts = append(counts.Counts, LabelCount{Value: value, Count: count})
+	}
+	sort.Slice(counts.Counts, func(i, j int) bool {
+		a, b := counts.Counts[i], counts.Counts[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Value < b.Value
+	})
+	return counts
+}
+
+// formatLabelCounts formats counts as a table of label values and counts
+func formatLabelCounts(counts LabelCounts, color bool) string {
+	if counts.Total == 0 {
+		return "No resources found.\n"
+	}
+
+	width := len(counts.Label)
+	for _, count := range counts.Counts {
+		width = max(width, len(count.Value))
+	}
+
+	var result strings.Builder
+	result.WriteString(colorize(fmt.Sprintf("%-*s %s", width, strings.ToUpper(counts.Label), "COUNT"), ansiBold, color) + "\n")
+	for _, count := range counts.Counts {
+		fmt.Fprintf(&result, "%-*s %d\n", width, count.Value, count.Count)
+	}
+	return result.String()
+}
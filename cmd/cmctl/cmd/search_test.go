@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+)
+
+// useSearchCountBy sets the search flags for a --count-by test
+func useSearchCountBy(t *testing.T, queries []string, key, output string) {
+	t.Helper()
+	prevQueries, prevKey, prevOutput := searchQueries, searchCountBy, searchOutputFlag
+	t.Cleanup(func() {
+		searchQueries, searchCountBy, searchOutputFlag = prevQueries, prevKey, prevOutput
+	})
+	searchQueries, searchCountBy, searchOutputFlag = queries, key, output
+}
+
+func TestSearchCountBy(t *testing.T) {
+	useTestStorageDir(t)
+	fs, err := getStorageProvider()
+	if err != nil {
+		t.Fatalf("Failed to get storage: %v", err)
+	}
+	// More auth matches than the default --limit, which counting ignores
+	fixture := []struct {
+		language string
+		count    int
+	}{
+		{"go", 6}, {"python", 4}, {"", 2}, {"rust", 1},
+	}
+	for _, group := range fixture {
+		for i := 0; i < group.count; i++ {
+			req := storage.CreateMemoryRequest{Name: "auth notes", Content: "auth flow", Labels: map[string]string{"type": "chat"}}
+			if group.language != "" {
+				req.Labels["language"] = group.language
+			}
+			if _, err := fs.Create(req); err != nil {
+				t.Fatalf("Failed to create memory: %v", err)
+			}
+		}
+	}
+	if _, err := fs.Create(storage.CreateMemoryRequest{Name: "unrelated", Content: "billing", Labels: map[string]string{"language": "go"}}); err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+
+	useSearchCountBy(t, []string{"auth"}, "language", "table")
+	output := captureStdout(t, func() error { return runSearch(searchCmd, nil) })
+	want := "LANGUAGE COUNT\ngo       6\npython   4\n<none>   2\nrust     1\n"
+	if output != want {
+		t.Errorf("Expected counts table %q, got %q", want, output)
+	}
+
+	useSearchCountBy(t, []string{"auth"}, "language", "json")
+	output = captureStdout(t, func() error { return runSearch(searchCmd, nil) })
+	var counts LabelCounts
+	if err := json.Unmarshal([]byte(output), &counts); err != nil {
+		t.Fatalf("Failed to parse JSON counts %q: %v", output, err)
+	}
+	if counts.Label != "language" || counts.Total != 13 || len(counts.Counts) != 4 || counts.Counts[2] != (LabelCount{Value: "<none>", Count: 2}) {
+		t.Errorf("Expected 13 matches in 4 groups, got %+v", counts)
+	}
+
+	useSearchCountBy(t, nil, "not a key!", "table")
+	if err := runSearch(searchCmd, nil); err == nil || !strings.Contains(err.Error(), "invalid --count-by") {
+		t.Errorf("Expected an invalid label key error, got %v", err)
+	}
+}
+
+func TestSearchLimitKeepsPinned(t *testing.T) {
+	useTestStorageDir(t)
+	fs, err := getStorageProvider()
+	if err != nil {
+		t.Fatalf("Failed to get storage: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := fs.Create(storage.CreateMemoryRequest{Name: "auth notes", Content: "auth auth auth"}); err != nil {
+			t.Fatalf("Failed to create memory: %v", err)
+		}
+	}
+	// Ranks last for "auth", so the limit would drop it if applied first
+	if _, err := fs.Create(storage.CreateMemoryRequest{Name: "Runbook", Content: "see auth", Labels: map[string]string{storage.PinnedLabel: "true"}}); err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+
+	prevQueries, prevLimit, prevOutput := searchQueries, searchLimit, searchOutputFlag
+	t.Cleanup(func() { searchQueries, searchLimit, searchOutputFlag = prevQueries, prevLimit, prevOutput })
+	searchQueries, searchLimit, searchOutputFlag = []string{"auth"}, 2, "json"
+
+	output := captureStdout(t, func() error { return runSearch(searchCmd, nil) })
+	if !strings.Contains(output, "Runbook") || strings.Count(output, `"auth notes"`) != 1 {
+		t.Errorf("Expected the pinned memory and 1 other match, got %s", output)
+	}
+}
@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// shutdownTimeout bounds how long in-flight requests get to finish
+const shutdownTimeout = 5 * time.Second
+
+var (
+	serveAddr     string
+	serveReadOnly bool
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve memories over a local HTTP REST API",
+	Long: `Serve memory CRUD over HTTP so editor extensions and scripts can use the
+store without running cmctl per call. Responses use the same contextmemory.io/v1
+documents as -o json.
+
+Endpoints:
+  GET    /memories                 List memories (MemoryList)
+  POST   /memories                 Create a memory from a JSON CreateMemoryRequest
+  GET    /memories/{id}            Get a memory (Memory)
+  PUT    /memories/{id}            Update a memory from a JSON UpdateMemoryRequest
+  DELETE /memories/{id}            Move a memory to the trash (?purge=true to delete)
+  GET    /search?q=&labels=&limit= Search memories (MemoryList)
+
+The server listens on localhost by default and has no authentication; only
+bind it to other interfaces on trusted networks. Requests must name a
+loopback Host, or the host given to --addr, and create and update bodies
+must be sent as Content-Type: application/json, so web pages can't write
+to or read the store. Requests are handled one at a time.
+
+Examples:
+  # Serve on the default address
+  cmctl serve
+
+  # Serve read-only on a different port
+  cmctl serve --addr 127.0.0.1:8080 --read-only
+
+  # Query it
+  curl 'http://127.0.0.1:7070/search?q=auth&labels=type=chat'`,
+	Args: cobra.NoArgs,
+	RunE: runServe,
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+
+	serveCmd.Flags().StringVar(&serveAddr, "addr", "127.0.0.1:7070", "address to listen on")
+	serveCmd.Flags().BoolVar(&serveReadOnly, "read-only", false, "reject requests that create, update or delete memories")
+}
+
+func runServe(cmd *cobra.Command, args []string) error {
+	fs, err := getStorageProvider()
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+
+	listener, err := net.Listen("tcp", serveAddr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", serveAddr, err)
+	}
+
+	server := &http.Server{
+		Handler:           newMemoryServer(fs, serveReadOnly, serveAddr),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.Serve(listener)
+	}()
+
+	mode := ""
+	if serveReadOnly {
+		mode = " (read-only)"
+	}
+	VPrintf(Normal, "Serving memories on http://%s%s\n", listener.Addr(), mode)
+
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("server failed: %w", err)
+	case <-ctx.Done():
+	}
+
+	VPrintf(Normal, "Shutting down\n")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to shut down server: %w", err)
+	}
+	if err := <-errCh; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("server failed: %w", err)
+	}
+	return nil
+}
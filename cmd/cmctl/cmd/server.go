@@ -0,0 +1,256 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/api"
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/providers"
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+)
+
+// maxRequestBodyBytes caps the size of create and update request bodies
+const maxRequestBodyBytes = 10 << 20
+
+// memoryServer serves memory CRUD over HTTP
+type memoryServer struct {
+	fs       providers.StorageProvider
+	readOnly bool
+	// hosts are the Host header values requests may name, besides loopback
+	hosts map[string]bool
+	// mu serializes requests: storage providers read and rewrite their
+	// index without locking, so concurrent creates would lose entries
+	mu sync.Mutex
+}
+
+// newMemoryServer returns the HTTP handler for the REST API. Requests
+// must name a loopback Host, or the host of addr when the server listens
+// on a specific other interface, so web pages can't reach the store by
+// rebinding their own domain to the server's address.
+func newMemoryServer(fs providers.StorageProvider, readOnly bool, addr string) http.Handler {
+	s := &memoryServer{fs: fs, readOnly: readOnly, hosts: map[string]bool{}}
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		if ip := net.ParseIP(host); host != "" && (ip == nil || !ip.IsUnspecified()) {
+			s.hosts[strings.ToLower(host)] = true
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /memories", s.handleList)
+	mux.HandleFunc("POST /memories", s.mutating(s.handleCreate))
+	mux.HandleFunc("GET /memories/{id}", s.handleGet)
+	mux.HandleFunc("PUT /memories/{id}", s.mutating(s.handleUpdate))
+	mux.HandleFunc("DELETE /memories/{id}", s.mutating(s.handleDelete))
+	mux.HandleFunc("GET /search", s.handleSearch)
+	return s.guard(mux)
+}
+
+// guard rejects requests for other hosts and serves the rest one at a time
+func (s *memoryServer) guard(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.allowedHost(r.Host) {
+			writeError(w, http.StatusForbidden, fmt.Errorf("host %q is not allowed", r.Host))
+			return
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// allowedHost reports whether a request's Host header names this server
+func (s *memoryServer) allowedHost(hostport string) bool {
+	host := hostport
+	if h, _, err := net.SplitHostPort(hostport); err == nil {
+		host = h
+	}
+	host = strings.ToLower(strings.Trim(host, "[]"))
+	if host == "localhost" || s.hosts[host] {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// mutating rejects requests that would change the store in read-only mode,
+// and those whose bodies aren't JSON
+func (s *memoryServer) mutating(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.readOnly {
+			writeError(w, http.StatusForbidden, errors.New("server is read-only"))
+			return
+		}
+		// Browsers send text/plain bodies cross-site without asking first;
+		// requiring JSON makes them ask, and this server never agrees
+		if r.Method != http.MethodDelete && !isJSONRequest(r) {
+			writeError(w, http.StatusUnsupportedMediaType, errors.New("Content-Type must be application/json"))
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (s *memoryServer) handleList(w http.ResponseWriter, r *http.Request) {
+	memories, err := s.fs.List()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to list memories: %w", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, api.NewMemoryListDocument(memories))
+}
+
+func (s *memoryServer) handleCreate(w http.ResponseWriter, r *http.Request) {
+	var req storage.CreateMemoryRequest
+	if err := decodeBody(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.Content == "" {
+		writeError(w, http.StatusBadRequest, errors.New("content is required"))
+		return
+	}
+
+	memory, err := s.fs.Create(req)
+	if err != nil {
+		writeError(w, statusForError(err), fmt.Errorf("failed to create memory: %w", err))
+		return
+	}
+	w.Header().Set("Location", "/memories/"+memory.ID)
+	writeJSON(w, http.StatusCreated, api.NewMemoryDocument(memory))
+}
+
+func (s *memoryServer) handleGet(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	memory, err := s.fs.Get(id)
+	if err != nil {
+		writeError(w, statusForError(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, api.NewMemoryDocument(memory))
+}
+
+func (s *memoryServer) handleUpdate(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	var req storage.UpdateMemoryRequest
+	if err := decodeBody(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	req.ID = id
+
+	memory, err := s.fs.Update(req)
+	if err != nil {
+		writeError(w, statusForError(err), fmt.Errorf("failed to update memory: %w", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, api.NewMemoryDocument(memory))
+}
+
+// handleDelete moves a memory to the trash, or deletes it permanently with
+// ?purge=true
+func (s *memoryServer) handleDelete(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	purge, _ := strconv.ParseBool(r.URL.Query().Get("purge"))
+	if err := removeMemory(s.fs, id, purge); err != nil {
+		writeError(w, statusForError(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleSearch searches with ?q= (text, repeatable; all must match unless
+// ?match=any), ?labels=key=value,... and ?limit=
+func (s *memoryServer) handleSearch(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	req := storage.SearchRequest{
+		Queries:        query["q"],
+		MatchAny:       query.Get("match") == "any",
+		LabelSelector:  parseLabels(query.Get("labels")),
+		IncludeContent: true,
+	}
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid limit %q", limit))
+			return
+		}
+		req.Limit = n
+	}
+
+	resp, err := s.fs.Search(req)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to search memories: %w", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, api.NewMemoryListDocument(resp.Memories))
+}
+
+// isJSONRequest reports whether r's body is declared as JSON
+func isJSONRequest(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && mediaType == "application/json"
+}
+
+// pathID returns the request's {id}, writing a 400 and returning false
+// when it isn't a valid memory ID. Path values are unescaped, so
+// /memories/..%2Findex would otherwise name a file outside memories/.
+func pathID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	id := r.PathValue("id")
+	if err := storage.ValidateID(id); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return "", false
+	}
+	return id, true
+}
+
+// decodeBody decodes a JSON request body into v
+func decodeBody(w http.ResponseWriter, r *http.Request, v any) error {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBodyBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("invalid request body: %w", err)
+	}
+	return nil
+}
+
+// statusForError maps a storage error to an HTTP status
+func statusForError(err error) int {
+	switch {
+	case errors.Is(err, storage.ErrMemoryNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, storage.ErrInvalidID):
+		return http.StatusBadRequest
+	case strings.Contains(err.Error(), "validation failed"):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, api.NewStatusDocument(status, err.Error()))
+}
@@ -0,0 +1,249 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/api"
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+)
+
+func doRequest(t *testing.T, handler http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Host = "127.0.0.1:7070"
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func decodeResponse[T any](t *testing.T, rec *httptest.ResponseRecorder) T {
+	t.Helper()
+	var v T
+	if err := json.NewDecoder(rec.Body).Decode(&v); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	return v
+}
+
+func TestMemoryServerCRUD(t *testing.T) {
+	handler := newMemoryServer(newTestStorage(t), false, "127.0.0.1:7070")
+
+	rec := doRequest(t, handler, "POST", "/memories", `{"name":"Auth notes","content":"Use OAuth","labels":{"type":"notes"}}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", rec.Code, rec.Body)
+	}
+	created := decodeResponse[api.MemoryDocument](t, rec)
+	if created.APIVersion != api.Version || created.Kind != api.KindMemory || created.Spec.Content != "Use OAuth" {
+		t.Errorf("Unexpected create response: %+v", created)
+	}
+	id := created.Spec.ID
+	if rec.Header().Get("Location") != "/memories/"+id {
+		t.Errorf("Expected Location header for %s, got %q", id, rec.Header().Get("Location"))
+	}
+
+	rec = doRequest(t, handler, "GET", "/memories/"+id, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	if got := decodeResponse[api.MemoryDocument](t, rec); got.Spec.Name != "Auth notes" {
+		t.Errorf("Expected memory name Auth notes, got %q", got.Spec.Name)
+	}
+
+	rec = doRequest(t, handler, "PUT", "/memories/"+id, `{"content":"Use OAuth with PKCE"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	if got := decodeResponse[api.MemoryDocument](t, rec); got.Spec.Content != "Use OAuth with PKCE" || got.Spec.ID != id {
+		t.Errorf("Unexpected update response: %+v", got.Spec)
+	}
+
+	rec = doRequest(t, handler, "GET", "/memories", "")
+	list := decodeResponse[api.MemoryListDocument](t, rec)
+	if list.Kind != "MemoryList" || len(list.Items) != 1 {
+		t.Errorf("Expected a MemoryList with 1 item, got %+v", list)
+	}
+
+	rec = doRequest(t, handler, "GET", "/search?q=pkce&labels=type=notes", "")
+	if results := decodeResponse[api.MemoryListDocument](t, rec); len(results.Items) != 1 {
+		t.Errorf("Expected 1 search result, got %+v", results)
+	}
+	rec = doRequest(t, handler, "GET", "/search?labels=type=chat", "")
+	if results := decodeResponse[api.MemoryListDocument](t, rec); len(results.Items) != 0 {
+		t.Errorf("Expected no search results, got %+v", results)
+	}
+
+	rec = doRequest(t, handler, "DELETE", "/memories/"+id, "")
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("Expected 204, got %d: %s", rec.Code, rec.Body)
+	}
+
+	rec = doRequest(t, handler, "GET", "/memories/"+id, "")
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 after delete, got %d", rec.Code)
+	}
+	if status := decodeResponse[api.StatusDocument](t, rec); status.Kind != api.KindStatus || status.Code != http.StatusNotFound {
+		t.Errorf("Unexpected error response: %+v", status)
+	}
+}
+
+func TestMemoryServerErrors(t *testing.T) {
+	handler := newMemoryServer(newTestStorage(t), false, "127.0.0.1:7070")
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		want   int
+	}{
+		{name: "malformed body", method: "POST", target: "/memories", body: `{"content":`, want: http.StatusBadRequest},
+		{name: "unknown field", method: "POST", target: "/memories", body: `{"text":"hi"}`, want: http.StatusBadRequest},
+		{name: "missing content", method: "POST", target: "/memories", body: `{"name":"empty"}`, want: http.StatusBadRequest},
+		{name: "update missing", method: "PUT", target: "/memories/mem_missing", body: `{"content":"x"}`, want: http.StatusNotFound},
+		{name: "delete missing", method: "DELETE", target: "/memories/mem_missing", want: http.StatusNotFound},
+		{name: "bad limit", method: "GET", target: "/search?limit=many", want: http.StatusBadRequest},
+		{name: "wrong method", method: "PATCH", target: "/memories", want: http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := doRequest(t, handler, tt.method, tt.target, tt.body)
+			if rec.Code != tt.want {
+				t.Errorf("Expected %d, got %d: %s", tt.want, rec.Code, rec.Body)
+			}
+		})
+	}
+}
+
+func TestMemoryServerReadOnly(t *testing.T) {
+	fs := newTestStorage(t)
+	handler := newMemoryServer(fs, true, "127.0.0.1:7070")
+
+	rec := doRequest(t, handler, "POST", "/memories", `{"content":"nope"}`)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for create in read-only mode, got %d", rec.Code)
+	}
+	rec = doRequest(t, handler, "DELETE", "/memories/mem_any", "")
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for delete in read-only mode, got %d", rec.Code)
+	}
+
+	rec = doRequest(t, handler, "GET", "/memories", "")
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected reads to work in read-only mode, got %d", rec.Code)
+	}
+	if list := decodeResponse[api.MemoryListDocument](t, rec); list.Items == nil {
+		t.Error("Expected an empty items array rather than null")
+	}
+}
+
+func TestMemoryServerConcurrentCreates(t *testing.T) {
+	fs := newTestStorage(t)
+	handler := newMemoryServer(fs, false, "127.0.0.1:7070")
+
+	// Let creates interleave even on a single CPU
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(8))
+
+	const creates = 60
+	var wg sync.WaitGroup
+	for i := range creates {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rec := doRequest(t, handler, "POST", "/memories", fmt.Sprintf(`{"name":"Note %d","content":"note %d"}`, i, i))
+			if rec.Code != http.StatusCreated {
+				t.Errorf("Expected 201, got %d: %s", rec.Code, rec.Body)
+			}
+		}()
+	}
+	wg.Wait()
+
+	indexed, err := fs.ListWithOptions(storage.ListOptions{UseIndex: true})
+	if err != nil {
+		t.Fatalf("Failed to list memories: %v", err)
+	}
+	if len(indexed) != creates {
+		t.Errorf("Expected %d memories in the index, got %d", creates, len(indexed))
+	}
+}
+
+func TestMemoryServerRejectsCrossSiteRequests(t *testing.T) {
+	fs := newTestStorage(t)
+	handler := newMemoryServer(fs, false, "0.0.0.0:7070")
+
+	tests := []struct {
+		name        string
+		method      string
+		host        string
+		contentType string
+		want        int
+	}{
+		{name: "localhost", method: "GET", host: "localhost:7070", want: http.StatusOK},
+		{name: "IPv6 loopback", method: "GET", host: "[::1]:7070", want: http.StatusOK},
+		{name: "rebound domain", method: "GET", host: "attacker.example:7070", want: http.StatusForbidden},
+		{name: "text/plain create", method: "POST", host: "127.0.0.1:7070", contentType: "text/plain", want: http.StatusUnsupportedMediaType},
+		{name: "form create", method: "POST", host: "127.0.0.1:7070", contentType: "application/x-www-form-urlencoded", want: http.StatusUnsupportedMediaType},
+		{name: "JSON create", method: "POST", host: "127.0.0.1:7070", contentType: "application/json; charset=utf-8", want: http.StatusCreated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/memories", strings.NewReader(`{"content":"planted"}`))
+			req.Host = tt.host
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("Expected %d, got %d: %s", tt.want, rec.Code, rec.Body)
+			}
+		})
+	}
+
+	// A server bound to a specific interface also answers to its address
+	handler = newMemoryServer(fs, false, "192.168.1.10:7070")
+	req := httptest.NewRequest("GET", "/memories", nil)
+	req.Host = "192.168.1.10:7070"
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected the --addr host to be allowed, got %d", rec.Code)
+	}
+}
+
+func TestMemoryServerRejectsPathTraversal(t *testing.T) {
+	fs := newTestStorage(t)
+	handler := newMemoryServer(fs, false, "127.0.0.1:7070")
+	if _, err := fs.Create(storage.CreateMemoryRequest{Name: "Notes", Content: "content"}); err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+
+	for _, tt := range []struct{ method, target, body string }{
+		{"GET", "/memories/..%2Findex", ""},
+		{"GET", "/memories/..%2F..%2Fconfig", ""},
+		{"PUT", "/memories/..%2Findex", `{"content":"x"}`},
+		{"DELETE", "/memories/..%2Findex?purge=true", ""},
+		{"DELETE", "/memories/..%5Cindex", ""},
+	} {
+		rec := doRequest(t, handler, tt.method, tt.target, tt.body)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s %s: expected 400, got %d: %s", tt.method, tt.target, rec.Code, rec.Body)
+		}
+	}
+
+	// The index the requests named is untouched
+	memories, err := fs.ListWithOptions(storage.ListOptions{UseIndex: true})
+	if err != nil || len(memories) != 1 {
+		t.Errorf("Expected the index to still list 1 memory, got %d (%v)", len(memories), err)
+	}
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+)
+
+// defaultSortBy shows the most recently updated memories first
+const defaultSortBy = storage.SortByUpdated
+
+// sortFlagUsage describes the --sort-by flag
+var sortFlagUsage = fmt.Sprintf("Sort by %s or %s<key> (names and labels sort A-Z, the others largest or newest first; memories without the label go last)", strings.Join(storage.SortKeys, "|"), storage.LabelSortPrefix)
+
+// validateSortBy checks a --sort-by value before any memories are loaded
+func validateSortBy(sortBy string) error {
+	if !storage.IsSortKey(sortBy) {
+		return fmt.Errorf("invalid --sort-by %q (valid keys: %s, or %s<key>)", sortBy, strings.Join(storage.SortKeys, ", "), storage.LabelSortPrefix)
+	}
+	return nil
+}
+
+// sortMemories orders memories by key in its default order, or the opposite
+// order when reverse is set
+func sortMemories(memories []storage.Memory, sortBy string, reverse bool) error {
+	return storage.SortMemories(memories, sortBy, sortOrder(sortBy, reverse))
+}
+
+// sortOrder returns the default order for a sort key, or the opposite order
+// when reverse is set
+func sortOrder(sortBy string, reverse bool) string {
+	order := storage.DefaultSortOrder(sortBy)
+	if reverse {
+		if order == storage.SortAscending {
+			order = storage.SortDescending
+		} else {
+			order = storage.SortAscending
+		}
+	}
+	return order
+}
+
+// selectByCreated returns the most recently created of memories, or with
+// first set the oldest. ok is false if there are none.
+func selectByCreated(memories []storage.Memory, first bool) (selected storage.Memory, ok bool) {
+	for i, memory := range memories {
+		newer := memory.CreatedAt.After(selected.CreatedAt)
+		older := memory.CreatedAt.Before(selected.CreatedAt)
+		if i == 0 || (first && older) || (!first && newer) {
+			selected = memory
+		}
+	}
+	return selected, len(memories) > 0
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/api"
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+)
+
+func TestGetSortBy(t *testing.T) {
+	fs := newTestStorage(t)
+
+	// Names, sizes and update times all order differently
+	for _, req := range []storage.CreateMemoryRequest{
+		{Name: "bravo", Content: "medium size"},
+		{Name: "charlie", Content: "x"},
+		{Name: "alpha", Content: "the largest of the three"},
+	} {
+		if _, err := fs.Create(req); err != nil {
+			t.Fatalf("Failed to create memory: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	charlie, err := findMemoryByName(fs, "charlie")
+	if err != nil || charlie == nil {
+		t.Fatalf("Failed to find memory: %v", err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if _, err := fs.Update(storage.UpdateMemoryRequest{ID: charlie.ID, Name: "charlie"}); err != nil {
+		t.Fatalf("Failed to update memory: %v", err)
+	}
+
+	// bravo was read before alpha, and charlie never
+	now := time.Now()
+	for i, name := range []string{"bravo", "alpha"} {
+		memory, err := findMemoryByName(fs, name)
+		if err != nil || memory == nil {
+			t.Fatalf("Failed to find memory: %v", err)
+		}
+		if err := fs.RecordAccess(memory.ID, now.Add(time.Duration(i)*time.Minute)); err != nil {
+			t.Fatalf("Failed to record access: %v", err)
+		}
+	}
+
+	oldSortBy, oldReverse, oldIncludeContent := getSortBy, getReverse, getIncludeContent
+	defer func() { getSortBy, getReverse, getIncludeContent = oldSortBy, oldReverse, oldIncludeContent }()
+
+	tests := []struct {
+		sortBy  string
+		reverse bool
+		want    []string
+	}{
+		{storage.SortByUpdated, false, []string{"charlie", "alpha", "bravo"}},
+		{storage.SortByUpdated, true, []string{"bravo", "alpha", "charlie"}},
+		{storage.SortByCreated, false, []string{"alpha", "charlie", "bravo"}},
+		{storage.SortByName, false, []string{"alpha", "bravo", "charlie"}},
+		{storage.SortByName, true, []string{"charlie", "bravo", "alpha"}},
+		{storage.SortBySize, false, []string{"alpha", "bravo", "charlie"}},
+		{storage.SortBySize, true, []string{"charlie", "bravo", "alpha"}},
+		{storage.SortByAccessed, false, []string{"alpha", "bravo", "charlie"}},
+		{storage.SortByAccessed, true, []string{"charlie", "bravo", "alpha"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sortBy, func(t *testing.T) {
+			getSortBy, getReverse, getIncludeContent = tt.sortBy, tt.reverse, false
+
+			output, err := renderGetList(fs, OutputOptions{Format: OutputFormatJSON})
+			if err != nil {
+				t.Fatalf("Failed to render list: %v", err)
+			}
+			var doc api.MemoryListDocument
+			if err := json.Unmarshal([]byte(output), &doc); err != nil {
+				t.Fatalf("Failed to parse output: %v", err)
+			}
+			if len(doc.Items) != len(tt.want) {
+				t.Fatalf("Expected %d items, got %d", len(tt.want), len(doc.Items))
+			}
+			for i, name := range tt.want {
+				if doc.Items[i].Name != name {
+					t.Errorf("Expected %q at position %d, got %q", name, i, doc.Items[i].Name)
+				}
+				if doc.Items[i].Content != "" {
+					t.Errorf("Expected content to stay excluded, got %q", doc.Items[i].Content)
+				}
+			}
+		})
+	}
+
+	if err := validateSortBy("colour"); err == nil {
+		t.Error("Expected an error for an unknown sort key")
+	}
+}
+
+func TestSelectByCreated(t *testing.T) {
+	base := time.Date(2025, 9, 20, 10, 0, 0, 0, time.UTC)
+	memories := []storage.Memory{
+		{ID: "mem_middle", CreatedAt: base.Add(time.Hour)},
+		{ID: "mem_newest", CreatedAt: base.Add(2 * time.Hour)},
+		{ID: "mem_oldest", CreatedAt: base},
+		{ID: "mem_middle_too", CreatedAt: base.Add(time.Hour)},
+	}
+
+	if last, ok := selectByCreated(memories, false); !ok || last.ID != "mem_newest" {
+		t.Errorf("Expected mem_newest for --last, got %q", last.ID)
+	}
+	if first, ok := selectByCreated(memories, true); !ok || first.ID != "mem_oldest" {
+		t.Errorf("Expected mem_oldest for --first, got %q", first.ID)
+	}
+	if _, ok := selectByCreated(nil, false); ok {
+		t.Error("Expected no memory to be selected from none")
+	}
+}
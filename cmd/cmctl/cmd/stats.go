@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show how memories are used",
+	Long: `Show how many memories have been read and when, or with --unused, list
+the memories that haven't been read recently.
+
+Reads by 'get <id>', 'cat' and 'reload-chat' are recorded in each memory's
+lastAccessedAt and accessCount metadata, unless --no-track-access is set.
+A memory that has never been read counts as unused once it is older than
+the --unused period.
+
+Examples:
+  cmctl stats                    # Summary of memory reads
+  cmctl stats --unused 30d       # Memories not read in 30 days
+  cmctl stats --unused 2025-01-01 --show-id -o json`,
+	RunE: runStats,
+}
+
+var (
+	statsUnused string
+	statsShowID bool
+	statsOutput string
+)
+
+func init() {
+	rootCmd.AddCommand(statsCmd)
+
+	statsCmd.Flags().StringVar(&statsUnused, "unused", "", "List memories not read since this (relative like 30d or 2w, or YYYY-MM-DD)")
+	statsCmd.Flags().BoolVar(&statsShowID, "show-id", false, "Show memory IDs in the --unused list")
+	statsCmd.Flags().StringVarP(&statsOutput, "output", "o", "", "Output format for the --unused list: table|json|yaml|jsonpath=<template>|go-template=<template>|template=<name>")
+}
+
+func runStats(cmd *cobra.Command, args []string) error {
+	now := time.Now()
+	var cutoff time.Time
+	if statsUnused != "" {
+		var err error
+		if cutoff, err = parseTimeSpec(statsUnused, now); err != nil {
+			return fmt.Errorf("invalid --unused: %w", err)
+		}
+	}
+
+	fs, err := getStorageProvider()
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	memories, err := listMetadata(fs)
+	if err != nil {
+		return err
+	}
+
+	if statsUnused == "" {
+		printAccessStats(memories)
+		return nil
+	}
+
+	outputOpts, err := ParseOutputFormat(outputFormatOrDefault(statsOutput))
+	if err != nil {
+		return fmt.Errorf("invalid output format: %w", err)
+	}
+	outputOpts.Color = shouldColorize(os.Stdout)
+	outputOpts.Width = tableWidth(os.Stdout)
+
+	unused := unusedMemories(memories, cutoff)
+	if err := storage.SortMemories(unused, storage.SortByAccessed, storage.SortAscending); err != nil {
+		return err
+	}
+	output, err := FormatMemoryList(unused, outputOpts, statsShowID)
+	if err != nil {
+		return fmt.Errorf("failed to format output: %w", err)
+	}
+	fmt.Print(output)
+	return nil
+}
+
+// unusedMemories returns the memories not read since cutoff. Memories that
+// have never been read only count once they were created before cutoff, so
+// new memories aren't reported as unused.
+func unusedMemories(memories []storage.Memory, cutoff time.Time) []storage.Memory {
+	var unused []storage.Memory
+	for _, memory := range memories {
+		last, ok := memory.LastAccessed()
+		if !ok {
+			last = memory.CreatedAt
+		}
+		if last.Before(cutoff) {
+			unused = append(unused, memory)
+		}
+	}
+	return unused
+}
+
+// printAccessStats summarizes how many memories have been read
+func printAccessStats(memories []storage.Memory) {
+	read := 0
+	var latest time.Time
+	for _, memory := range memories {
+		if at, ok := memory.LastAccessed(); ok {
+			read++
+			if at.After(latest) {
+				latest = at
+			}
+		}
+	}
+
+	fmt.Printf("Total Memories:\t\t%d\n", len(memories))
+	fmt.Printf("Read:\t\t\t%d\n", read)
+	fmt.Printf("Never Read:\t\t%d\n", len(memories)-read)
+	if read > 0 {
+		fmt.Printf("Last Read:\t\t%s\n", latest.Local().Format("2006-01-02 15:04:05"))
+	}
+}
@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+)
+
+func TestUnusedMemories(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	cutoff := now.AddDate(0, 0, -30)
+	accessed := func(at time.Time) map[string]any {
+		return map[string]any{storage.AccessedAtKey: at.Format(time.RFC3339Nano)}
+	}
+
+	memories := []storage.Memory{
+		{ID: "recent-read", CreatedAt: now.AddDate(-1, 0, 0), Metadata: accessed(now.AddDate(0, 0, -2))},
+		{ID: "stale-read", CreatedAt: now.AddDate(-1, 0, 0), Metadata: accessed(now.AddDate(0, 0, -60))},
+		{ID: "never-read-old", CreatedAt: now.AddDate(0, -3, 0)},
+		{ID: "never-read-new", CreatedAt: now.AddDate(0, 0, -1)},
+	}
+
+	unused := unusedMemories(memories, cutoff)
+	var ids []string
+	for _, memory := range unused {
+		ids = append(ids, memory.ID)
+	}
+	if len(ids) != 2 || ids[0] != "stale-read" || ids[1] != "never-read-old" {
+		t.Errorf("Expected stale-read and never-read-old to be unused, got %v", ids)
+	}
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+)
+
+// chatSummaryKey is the metadata key holding a chat's generated summary
+const chatSummaryKey = storage.SummaryKey
+
+// defaultSummarizeTimeout bounds how long a --summarize-cmd may run per chat
+const defaultSummarizeTimeout = 2 * time.Minute
+
+// runSummarizer pipes text to command through the shell and returns its
+// trimmed output. The command is killed if it runs longer than timeout.
+func runSummarizer(command, text string, timeout time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	cmd.Stdin = strings.NewReader(text)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	// Don't wait on children of the shell that still hold the output open
+	cmd.WaitDelay = time.Second
+
+	output, err := cmd.Output()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return "", fmt.Errorf("timed out after %s", timeout)
+	}
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+
+	summary := strings.TrimSpace(string(output))
+	if summary == "" {
+		return "", fmt.Errorf("command produced no output")
+	}
+	return summary, nil
+}
+
+// addChatSummary runs the summarize command over the chat content and stores
+// its output in the request metadata. Failures are reported as warnings so
+// the chat is still imported without a summary.
+func addChatSummary(req *storage.CreateMemoryRequest, opts importOptions) {
+	if opts.SummarizeCmd == "" {
+		return
+	}
+
+	timeout := opts.SummarizeTimeout
+	if timeout <= 0 {
+		timeout = defaultSummarizeTimeout
+	}
+	summary, err := runSummarizer(opts.SummarizeCmd, req.Content, timeout)
+	if err != nil {
+		VPrintf(Normal, "Warning: failed to summarize chat %q: %v\n", req.Name, err)
+		return
+	}
+
+	if req.Metadata == nil {
+		req.Metadata = make(map[string]any)
+	}
+	req.Metadata[chatSummaryKey] = summary
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunSummarizer(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("summarizer stubs use sh")
+	}
+
+	summary, err := runSummarizer("tr a-z A-Z | head -n 1", "first line\nsecond line\n", time.Second)
+	if err != nil {
+		t.Fatalf("Failed to run summarizer: %v", err)
+	}
+	if summary != "FIRST LINE" {
+		t.Errorf("Expected %q, got %q", "FIRST LINE", summary)
+	}
+
+	tests := []struct {
+		name    string
+		command string
+		timeout time.Duration
+		wantErr string
+	}{
+		{name: "non-zero exit", command: "echo 'model unavailable' >&2; exit 3", timeout: time.Second, wantErr: "model unavailable"},
+		{name: "timeout", command: "sleep 5", timeout: 100 * time.Millisecond, wantErr: "timed out"},
+		{name: "no output", command: "cat >/dev/null", timeout: time.Second, wantErr: "no output"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			_, err := runSummarizer(tt.command, "chat", tt.timeout)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+			if elapsed := time.Since(start); elapsed > 3*time.Second {
+				t.Errorf("Expected the command to be stopped promptly, took %s", elapsed)
+			}
+		})
+	}
+}
+
+func TestImportChatSummary(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("summarizer stubs use sh")
+	}
+	fs := newTestStorage(t)
+
+	memory, _, err := importChat(fs, testChat("chat-summary", "Why does my test fail?"), importOptions{
+		SummarizeCmd: "grep -c 'test fail'",
+	})
+	if err != nil {
+		t.Fatalf("Failed to import chat: %v", err)
+	}
+	if memory.Metadata[chatSummaryKey] != "1" {
+		t.Errorf("Expected summary from the stub command, got %v", memory.Metadata)
+	}
+
+	// A failing command still imports the chat, without a summary
+	failed, action, err := importChat(fs, testChat("chat-failed", "Why does my build fail?"), importOptions{
+		SummarizeCmd:     "exit 1",
+		SummarizeTimeout: time.Second,
+	})
+	if err != nil {
+		t.Fatalf("Expected the import to succeed, got %v", err)
+	}
+	if action != importActionCreated {
+		t.Errorf("Expected the chat to be created, got %s", action)
+	}
+	if _, ok := failed.Metadata[chatSummaryKey]; ok {
+		t.Errorf("Expected no summary, got %v", failed.Metadata)
+	}
+}
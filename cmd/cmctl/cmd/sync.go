@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/providers"
+	"github.com/spf13/cobra"
+)
+
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Synchronize the memory store with its remote",
+	Long: `Synchronize the memory store with its remote copy.
+
+With the git provider this commits any pending changes, pulls from the
+upstream branch (rebasing local commits), and pushes.
+
+Examples:
+  # Sync a git-backed store with origin
+  cmctl --provider git sync
+
+  # Make many changes without committing, then sync them as one commit
+  cmctl --provider git --no-commit import-cursor-chat --all
+  cmctl --provider git sync`,
+	Args: cobra.NoArgs,
+	RunE: runSync,
+}
+
+func init() {
+	rootCmd.AddCommand(syncCmd)
+}
+
+func runSync(cmd *cobra.Command, args []string) error {
+	fs, err := getStorageProvider()
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+
+	syncer, ok := fs.(providers.Syncer)
+	if !ok {
+		return fmt.Errorf("storage provider %s does not support sync (try --provider git)", fs.GetProviderType())
+	}
+	if err := syncer.Sync(); err != nil {
+		return fmt.Errorf("failed to sync: %w", err)
+	}
+
+	if !IsQuiet() {
+		fmt.Println("Memory store synchronized")
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/spf13/viper"
+)
+
+// maxWidthKey is the config key and flag setting the width tables fit to
+const maxWidthKey = "max-width"
+
+// tableWidth returns the width that tables written to f should fit:
+// --max-width when set, then $COLUMNS, then the width of the terminal f.
+// It returns 0 when none is known, as when f is a pipe, so tables keep
+// their fixed column widths.
+func tableWidth(f *os.File) int {
+	if width := viper.GetInt(maxWidthKey); width > 0 {
+		return width
+	}
+	if width, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && width > 0 {
+		return width
+	}
+	if width, ok := terminalColumns(f); ok {
+		return width
+	}
+	return 0
+}
+
+// tableColumns are the widths of the memory table columns that grow and
+// shrink with the table, each including the space padding its text
+type tableColumns struct {
+	ID     int
+	Name   int
+	Labels int
+}
+
+// Fixed column widths, used when the table width isn't known and as the
+// proportions columns are given when it is
+var (
+	defaultTableColumns   = tableColumns{Name: 40, Labels: 30}
+	defaultIDTableColumns = tableColumns{ID: 24, Name: 32, Labels: 26}
+)
+
+// Narrowest the name and labels columns get on small terminals; tables
+// wider than the terminal wrap rather than cutting names to nothing
+const (
+	minNameColumn   = 20
+	minLabelsColumn = 12
+)
+
+// allocateTableColumns fits the name and labels columns into width, less
+// the fixed columns that take up the rest of each row, keeping them in
+// their default proportions. IDs are all the same length, so the ID column
+// keeps its default width. A width of 0 or less keeps the defaults.
+func allocateTableColumns(width, fixed int, showID bool) tableColumns {
+	columns := defaultTableColumns
+	if showID {
+		columns = defaultIDTableColumns
+	}
+	if width <= 0 {
+		return columns
+	}
+
+	// One space separates each column from the next
+	available := width - fixed - columns.ID - 2
+	if showID {
+		available--
+	}
+	name := available * columns.Name / (columns.Name + columns.Labels)
+	columns.Name = max(name, minNameColumn)
+	columns.Labels = max(available-name, minLabelsColumn)
+	return columns
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+	"github.com/spf13/viper"
+)
+
+func TestAllocateTableColumns(t *testing.T) {
+	tests := []struct {
+		name   string
+		width  int
+		fixed  int
+		showID bool
+		want   tableColumns
+	}{
+		{name: "unknown width", width: 0, fixed: 20, want: tableColumns{Name: 40, Labels: 30}},
+		{name: "unknown width with IDs", width: 0, fixed: 20, showID: true, want: tableColumns{ID: 24, Name: 32, Labels: 26}},
+		{name: "80 columns", width: 80, fixed: 20, want: tableColumns{Name: 33, Labels: 25}},
+		{name: "120 columns", width: 120, fixed: 20, want: tableColumns{Name: 56, Labels: 42}},
+		{name: "200 columns", width: 200, fixed: 20, want: tableColumns{Name: 101, Labels: 77}},
+		{name: "200 columns with IDs", width: 200, fixed: 20, showID: true, want: tableColumns{ID: 24, Name: 84, Labels: 69}},
+		{name: "200 columns with a preview", width: 200, fixed: 81, want: tableColumns{Name: 66, Labels: 51}},
+		{name: "too narrow", width: 40, fixed: 20, want: tableColumns{Name: minNameColumn, Labels: minLabelsColumn}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := allocateTableColumns(tt.width, tt.fixed, tt.showID)
+			if got != tt.want {
+				t.Errorf("Expected %+v, got %+v", tt.want, got)
+			}
+			if tt.width >= 80 {
+				row := got.ID + got.Name + got.Labels + tt.fixed + 2
+				if tt.showID {
+					row++
+				}
+				if row != tt.width {
+					t.Errorf("Expected rows %d wide, got %d", tt.width, row)
+				}
+			}
+		})
+	}
+}
+
+func TestTableWidth(t *testing.T) {
+	prev := viper.GetInt(maxWidthKey)
+	t.Cleanup(func() { viper.Set(maxWidthKey, prev) })
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Failed to create pipe: %v", err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	viper.Set(maxWidthKey, 0)
+	t.Setenv("COLUMNS", "")
+	if width := tableWidth(w); width != 0 {
+		t.Errorf("Expected no width for a pipe, got %d", width)
+	}
+	t.Setenv("COLUMNS", "132")
+	if width := tableWidth(w); width != 132 {
+		t.Errorf("Expected $COLUMNS to be honored, got %d", width)
+	}
+	viper.Set(maxWidthKey, 200)
+	if width := tableWidth(w); width != 200 {
+		t.Errorf("Expected --max-width to win, got %d", width)
+	}
+}
+
+func TestFormatMemoryTableWidth(t *testing.T) {
+	name := "A long descriptive name for a memory about refactoring the storage layer"
+	memories := []storage.Memory{{ID: "mem_1", Name: name, UpdatedAt: time.Now()}}
+
+	if output := formatMemoryTable(memories, false, OutputOptions{}); strings.Contains(output, name) {
+		t.Errorf("Expected the name cut to the fixed width, got %q", output)
+	}
+	output := formatMemoryTable(memories, false, OutputOptions{Width: 200})
+	if !strings.Contains(output, name) {
+		t.Errorf("Expected the whole name on a wide table, got %q", output)
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(output, "\n"), "\n") {
+		if len(line) != 200 {
+			t.Errorf("Expected lines 200 wide, got %d: %q", len(line), line)
+		}
+	}
+}
@@ -0,0 +1,343 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// memoryTemplate pre-fills the name, labels and content of a new memory.
+// Name and Content may contain placeholders such as {{date}}.
+type memoryTemplate struct {
+	Key         string            `yaml:"-"`
+	Builtin     bool              `yaml:"-"`
+	Description string            `yaml:"description,omitempty"`
+	Name        string            `yaml:"name,omitempty"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Content     string            `yaml:"content,omitempty"`
+}
+
+// builtinTemplates are available without saving anything. A saved template
+// with the same name takes precedence.
+var builtinTemplates = []memoryTemplate{
+	{
+		Key:         "debug-session",
+		Builtin:     true,
+		Description: "Debugging session notes",
+		Name:        "Debug session {{date}}",
+		Labels:      map[string]string{"type": "debug"},
+		Content: `# Debug session {{date}}
+
+## Symptom
+
+## Investigation
+
+## Root cause
+
+## Fix
+`,
+	},
+	{
+		Key:         "decision-record",
+		Builtin:     true,
+		Description: "Architecture decision record",
+		Name:        "Decision {{date}}",
+		Labels:      map[string]string{"type": "decision", "status": "proposed"},
+		Content: `# Decision record ({{date}})
+
+## Context
+
+## Decision
+
+## Consequences
+`,
+	},
+}
+
+// templateKeyPattern restricts template names to safe file names
+var templateKeyPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// placeholderPattern matches {{name}} placeholders, allowing inner spaces
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([a-z]+)\s*\}\}`)
+
+var templateCmd = &cobra.Command{
+	Use:   "template",
+	Short: "List, show, or save memory templates",
+	Long: `Manage templates used by 'cmctl create --template'. Templates pre-fill the
+name, labels and content of a new memory and are stored as YAML files in the
+templates directory next to the config file.
+
+Placeholders in the name and content are expanded when the memory is created:
+  {{date}}      2006-01-02
+  {{time}}      15:04
+  {{datetime}}  2006-01-02 15:04
+
+Examples:
+  cmctl template list                                   # Built-in and saved templates
+  cmctl template show debug-session                     # Print a template
+  cmctl template save standup --labels "type=standup" --content "## Yesterday"
+  cmctl template save review --from mem_abc123_def456   # Use a memory as the skeleton
+  cmctl create --template debug-session                 # Create from a template`,
+}
+
+var templateListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List memory templates",
+	Args:  cobra.NoArgs,
+	RunE:  runTemplateList,
+}
+
+var templateShowCmd = &cobra.Command{
+	Use:   "show <name>",
+	Short: "Show a memory template",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runTemplateShow,
+}
+
+var templateSaveCmd = &cobra.Command{
+	Use:   "save <name>",
+	Short: "Save a memory template",
+	Long: `Save a memory template. Content is taken from --content, stdin, or the
+memory given with --from, which also provides the labels unless --labels is set.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runTemplateSave,
+}
+
+var (
+	templateSaveFrom        string
+	templateSaveName        string
+	templateSaveLabels      string
+	templateSaveContent     string
+	templateSaveDescription string
+	templateSaveForce       bool
+)
+
+func init() {
+	rootCmd.AddCommand(templateCmd)
+	templateCmd.AddCommand(templateListCmd, templateShowCmd, templateSaveCmd)
+
+	templateSaveCmd.Flags().StringVar(&templateSaveFrom, "from", "", "Memory ID to copy labels and content from")
+	templateSaveCmd.Flags().StringVarP(&templateSaveName, "name", "n", "", "Name for memories created from the template")
+	templateSaveCmd.Flags().StringVarP(&templateSaveLabels, "labels", "l", "", "Labels (format: key1=value1,key2=value2)")
+	templateSaveCmd.Flags().StringVarP(&templateSaveContent, "content", "c", "", "Content skeleton (or pipe from stdin)")
+	templateSaveCmd.Flags().StringVarP(&templateSaveDescription, "description", "d", "", "Description shown by 'template list'")
+	templateSaveCmd.Flags().BoolVar(&templateSaveForce, "force", false, "Overwrite an existing template")
+}
+
+func runTemplateList(cmd *cobra.Command, args []string) error {
+	dir, err := templatesDir()
+	if err != nil {
+		return err
+	}
+	templates, err := listTemplates(dir)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%-20s %-8s %s\n", "NAME", "SOURCE", "DESCRIPTION")
+	for _, tmpl := range templates {
+		source := "saved"
+		if tmpl.Builtin {
+			source = "built-in"
+		}
+		fmt.Printf("%-20s %-8s %s\n", tmpl.Key, source, tmpl.Description)
+	}
+	return nil
+}
+
+func runTemplateShow(cmd *cobra.Command, args []string) error {
+	dir, err := templatesDir()
+	if err != nil {
+		return err
+	}
+	tmpl, err := loadTemplate(dir, args[0])
+	if err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(tmpl)
+	if err != nil {
+		return fmt.Errorf("failed to marshal template: %w", err)
+	}
+	fmt.Print(string(data))
+	return nil
+}
+
+func runTemplateSave(cmd *cobra.Command, args []string) error {
+	tmpl := memoryTemplate{
+		Key:         args[0],
+		Description: templateSaveDescription,
+		Name:        templateSaveName,
+		Labels:      parseLabels(templateSaveLabels),
+		Content:     templateSaveContent,
+	}
+
+	if templateSaveFrom != "" {
+		fs, err := getStorageProvider()
+		if err != nil {
+			return fmt.Errorf("failed to initialize storage: %w", err)
+		}
+		memory, err := fs.Get(templateSaveFrom)
+		if err != nil {
+			return err
+		}
+		if templateSaveLabels == "" {
+			tmpl.Labels = memory.Labels
+		}
+		if tmpl.Content == "" {
+			tmpl.Content = memory.Content
+		}
+	}
+	if tmpl.Content == "" {
+		if stdinContent, err := readStdin(); err == nil {
+			tmpl.Content = stdinContent
+		}
+	}
+
+	dir, err := templatesDir()
+	if err != nil {
+		return err
+	}
+	path, err := saveTemplate(dir, tmpl, templateSaveForce)
+	if err != nil {
+		return err
+	}
+	VPrintf(Normal, "Saved template %s to %s\n", tmpl.Key, path)
+	return nil
+}
+
+// templatesDir returns the templates directory next to the config file
+func templatesDir() (string, error) {
+	configPath, err := configFilePath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(configPath), "templates"), nil
+}
+
+// loadTemplate finds a saved template in dir, falling back to the built-in
+// templates
+func loadTemplate(dir, key string) (memoryTemplate, error) {
+	if !templateKeyPattern.MatchString(key) {
+		return memoryTemplate{}, fmt.Errorf("invalid template name %q", key)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, key+".yaml"))
+	if err == nil {
+		var tmpl memoryTemplate
+		if err := yaml.Unmarshal(data, &tmpl); err != nil {
+			return memoryTemplate{}, fmt.Errorf("failed to parse template %s: %w", key, err)
+		}
+		tmpl.Key = key
+		return tmpl, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return memoryTemplate{}, fmt.Errorf("failed to read template %s: %w", key, err)
+	}
+
+	for _, tmpl := range builtinTemplates {
+		if tmpl.Key == key {
+			return tmpl, nil
+		}
+	}
+	return memoryTemplate{}, fmt.Errorf("template %q not found (see 'cmctl template list')", key)
+}
+
+// listTemplates returns the saved templates in dir and the built-in
+// templates they don't override, sorted by name
+func listTemplates(dir string) ([]memoryTemplate, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list templates: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	var templates []memoryTemplate
+	for _, file := range files {
+		key := strings.TrimSuffix(filepath.Base(file), ".yaml")
+		if !templateKeyPattern.MatchString(key) {
+			continue
+		}
+		tmpl, err := loadTemplate(dir, key)
+		if err != nil {
+			VPrintf(Normal, "Warning: skipping template %s: %v\n", key, err)
+			continue
+		}
+		templates = append(templates, tmpl)
+		seen[key] = true
+	}
+	for _, tmpl := range builtinTemplates {
+		if !seen[tmpl.Key] {
+			templates = append(templates, tmpl)
+		}
+	}
+
+	sort.Slice(templates, func(i, j int) bool { return templates[i].Key < templates[j].Key })
+	return templates, nil
+}
+
+// saveTemplate writes tmpl to dir, refusing to replace an existing saved
+// template unless force is set, and returns the file path
+func saveTemplate(dir string, tmpl memoryTemplate, force bool) (string, error) {
+	if !templateKeyPattern.MatchString(tmpl.Key) {
+		return "", fmt.Errorf("invalid template name %q (use letters, digits, '-' and '_')", tmpl.Key)
+	}
+	if err := storage.ValidateLabels(tmpl.Labels); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, tmpl.Key+".yaml")
+	if _, err := os.Stat(path); err == nil && !force {
+		return "", fmt.Errorf("template %s already exists (use --force to overwrite)", tmpl.Key)
+	}
+
+	data, err := yaml.Marshal(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal template: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create templates directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write template: %w", err)
+	}
+	return path, nil
+}
+
+// expandPlaceholders replaces {{date}}, {{time}} and {{datetime}} with the
+// given time. Unknown placeholders are left as they are.
+func expandPlaceholders(s string, now time.Time) string {
+	return placeholderPattern.ReplaceAllStringFunc(s, func(match string) string {
+		switch placeholderPattern.FindStringSubmatch(match)[1] {
+		case "date":
+			return now.Format("2006-01-02")
+		case "time":
+			return now.Format("15:04")
+		case "datetime":
+			return now.Format("2006-01-02 15:04")
+		default:
+			return match
+		}
+	})
+}
+
+// request builds a create request from the template, expanding placeholders
+func (t memoryTemplate) request(now time.Time) storage.CreateMemoryRequest {
+	labels := make(map[string]string, len(t.Labels))
+	for k, v := range t.Labels {
+		labels[k] = v
+	}
+	return storage.CreateMemoryRequest{
+		Name:    expandPlaceholders(t.Name, now),
+		Content: expandPlaceholders(t.Content, now),
+		Labels:  labels,
+	}
+}
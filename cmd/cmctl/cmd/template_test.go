@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestExpandPlaceholders(t *testing.T) {
+	now := time.Date(2025, 3, 14, 9, 26, 0, 0, time.UTC)
+
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"Debug {{date}}", "Debug 2025-03-14"},
+		{"at {{ time }}", "at 09:26"},
+		{"{{datetime}}", "2025-03-14 09:26"},
+		{"keep {{unknown}} as is", "keep {{unknown}} as is"},
+		{"no placeholders", "no placeholders"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if got := expandPlaceholders(tt.in, now); got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSaveAndLoadTemplate(t *testing.T) {
+	dir := t.TempDir()
+
+	tmpl := memoryTemplate{
+		Key:         "standup",
+		Description: "Daily standup",
+		Name:        "Standup {{date}}",
+		Labels:      map[string]string{"type": "standup"},
+		Content:     "## Yesterday\n\n## Today\n",
+	}
+	if _, err := saveTemplate(dir, tmpl, false); err != nil {
+		t.Fatalf("Failed to save template: %v", err)
+	}
+	if _, err := saveTemplate(dir, tmpl, false); err == nil {
+		t.Error("Expected saving over an existing template to fail without force")
+	}
+	if _, err := saveTemplate(dir, memoryTemplate{Key: "../escape"}, false); err == nil {
+		t.Error("Expected an invalid template name to be rejected")
+	}
+
+	loaded, err := loadTemplate(dir, "standup")
+	if err != nil {
+		t.Fatalf("Failed to load template: %v", err)
+	}
+	if loaded.Name != tmpl.Name || loaded.Content != tmpl.Content || loaded.Labels["type"] != "standup" || loaded.Builtin {
+		t.Errorf("Unexpected template: %+v", loaded)
+	}
+
+	builtin, err := loadTemplate(dir, "debug-session")
+	if err != nil {
+		t.Fatalf("Failed to load built-in template: %v", err)
+	}
+	if !builtin.Builtin {
+		t.Error("Expected debug-session to be built in")
+	}
+	if _, err := loadTemplate(dir, "missing"); err == nil {
+		t.Error("Expected an error for a missing template")
+	}
+
+	templates, err := listTemplates(dir)
+	if err != nil {
+		t.Fatalf("Failed to list templates: %v", err)
+	}
+	if len(templates) != len(builtinTemplates)+1 {
+		t.Errorf("Expected %d templates, got %d", len(builtinTemplates)+1, len(templates))
+	}
+}
+
+func TestBuildCreateRequestFromTemplate(t *testing.T) {
+	oldCfg, oldTemplate, oldName, oldLabels, oldTTL := cfgFile, createTemplate, createName, createLabels, createTTL
+	defer func() {
+		cfgFile, createTemplate, createName, createLabels, createTTL = oldCfg, oldTemplate, oldName, oldLabels, oldTTL
+	}()
+
+	cfgFile = filepath.Join(t.TempDir(), "config.yaml")
+	createTemplate = "decision-record"
+	createName = ""
+	createLabels = "status=accepted,project=api"
+	createTTL = ""
+
+	now := time.Date(2025, 3, 14, 9, 26, 0, 0, time.UTC)
+	req, err := buildCreateRequest("", now)
+	if err != nil {
+		t.Fatalf("Failed to build request: %v", err)
+	}
+
+	if req.Name != "Decision 2025-03-14" {
+		t.Errorf("Expected expanded name, got %q", req.Name)
+	}
+	if req.Content == "" || req.Content == builtinTemplates[1].Content {
+		t.Errorf("Expected expanded template content, got %q", req.Content)
+	}
+	want := map[string]string{"type": "decision", "status": "accepted", "project": "api"}
+	for k, v := range want {
+		if req.Labels[k] != v {
+			t.Errorf("Expected label %s=%s, got %v", k, v, req.Labels)
+		}
+	}
+	if _, ok := builtinTemplates[1].Labels["project"]; ok {
+		t.Error("Expected the built-in template labels to be left unchanged")
+	}
+
+	createName = "Use SQLite"
+	req, err = buildCreateRequest("We chose SQLite.", now)
+	if err != nil {
+		t.Fatalf("Failed to build request: %v", err)
+	}
+	if req.Name != "Use SQLite" || req.Content != "We chose SQLite." {
+		t.Errorf("Expected flags to override the template, got %+v", req)
+	}
+}
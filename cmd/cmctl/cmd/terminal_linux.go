@@ -0,0 +1,11 @@
+//go:build linux
+
+package cmd
+
+import "golang.org/x/sys/unix"
+
+// ioctl requests that read and write terminal attributes
+const (
+	ioctlGetTermios = unix.TCGETS
+	ioctlSetTermios = unix.TCSETS
+)
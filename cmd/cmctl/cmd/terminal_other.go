@@ -0,0 +1,26 @@
+//go:build !(linux || darwin || dragonfly || freebsd || netbsd || openbsd)
+
+package cmd
+
+import (
+	"errors"
+	"os"
+)
+
+// enableRawMode reports that raw mode isn't supported on this platform, so
+// callers fall back to line-based prompts
+func enableRawMode(f *os.File) (func(), error) {
+	return nil, errors.New("raw terminal mode is not supported on this platform")
+}
+
+// terminalWidth returns defaultTerminalWidth, since the terminal size
+// can't be read on this platform
+func terminalWidth(f *os.File) int {
+	return defaultTerminalWidth
+}
+
+// terminalColumns reports that the terminal size can't be read on this
+// platform
+func terminalColumns(f *os.File) (int, bool) {
+	return 0, false
+}
@@ -0,0 +1,51 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// enableRawMode switches the terminal f to reading keys as they're pressed,
+// without echoing them, and returns a function that restores it
+func enableRawMode(f *os.File) (func(), error) {
+	fd := int(f.Fd())
+	saved, err := unix.IoctlGetTermios(fd, ioctlGetTermios)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read terminal settings: %w", err)
+	}
+
+	raw := *saved
+	raw.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP | unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IXON
+	raw.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.ISIG | unix.IEXTEN
+	raw.Cflag &^= unix.CSIZE | unix.PARENB
+	raw.Cflag |= unix.CS8
+	raw.Cc[unix.VMIN] = 1
+	raw.Cc[unix.VTIME] = 0
+	if err := unix.IoctlSetTermios(fd, ioctlSetTermios, &raw); err != nil {
+		return nil, fmt.Errorf("failed to set terminal to raw mode: %w", err)
+	}
+	return func() { _ = unix.IoctlSetTermios(fd, ioctlSetTermios, saved) }, nil
+}
+
+// terminalWidth returns the number of columns of the terminal f, or
+// defaultTerminalWidth if f isn't a terminal
+func terminalWidth(f *os.File) int {
+	if cols, ok := terminalColumns(f); ok {
+		return cols
+	}
+	return defaultTerminalWidth
+}
+
+// terminalColumns returns the number of columns of the terminal f, or false
+// if f isn't a terminal
+func terminalColumns(f *os.File) (int, bool) {
+	size, err := unix.IoctlGetWinsize(int(f.Fd()), unix.TIOCGWINSZ)
+	if err != nil || size.Col == 0 {
+		return 0, false
+	}
+	return int(size.Col), true
+}
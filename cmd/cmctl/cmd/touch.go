@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/providers"
+	"github.com/spf13/cobra"
+)
+
+var touchTime string
+
+var touchCmd = &cobra.Command{
+	Use:   "touch <memory-id>...",
+	Short: "Mark memories as recently updated",
+	Long: `Set the updated time of one or more memories to now, without changing
+their content, labels or metadata. Touched memories sort as recently
+updated and are kept by age-based cleanup such as 'delete --older-than'.
+
+Examples:
+  cmctl touch mem_abc123_def456
+  cmctl touch mem_abc123_def456 mem_987654_fedcba
+  cmctl touch mem_abc123_def456 --time 2025-01-31   # Set a specific time`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runTouch,
+}
+
+func init() {
+	rootCmd.AddCommand(touchCmd)
+
+	touchCmd.Flags().StringVar(&touchTime, "time", "", "Time to set instead of now (YYYY-MM-DD, RFC3339, or relative like 3d)")
+}
+
+func runTouch(cmd *cobra.Command, args []string) error {
+	at := time.Now()
+	if touchTime != "" {
+		var err error
+		if at, err = parseTimeSpec(touchTime, at); err != nil {
+			return err
+		}
+	}
+
+	fs, err := getStorageProvider()
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	if err := touchMemories(fs, args, at); err != nil {
+		return err
+	}
+	for _, id := range args {
+		VPrintf(Normal, "Touched memory %s\n", id)
+	}
+	return nil
+}
+
+// touchMemories sets the UpdatedAt of each memory to at. Every ID is
+// checked first so a missing one leaves all the memories untouched.
+func touchMemories(fs providers.StorageProvider, ids []string, at time.Time) error {
+	toucher, ok := fs.(providers.Toucher)
+	if !ok {
+		return fmt.Errorf("storage provider %s doesn't support touch", fs.GetProviderType())
+	}
+	for _, id := range ids {
+		if _, err := fs.Get(id); err != nil {
+			return err
+		}
+	}
+	for _, id := range ids {
+		if err := toucher.Touch(id, at); err != nil {
+			return fmt.Errorf("failed to touch memory %s: %w", id, err)
+		}
+	}
+	return nil
+}
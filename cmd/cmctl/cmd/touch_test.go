@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+)
+
+func TestTouchMemories(t *testing.T) {
+	fs := newTestStorage(t)
+
+	var ids []string
+	for _, name := range []string{"first", "second"} {
+		memory, err := fs.Create(storage.CreateMemoryRequest{Name: name, Content: name + " content"})
+		if err != nil {
+			t.Fatalf("Failed to create memory: %v", err)
+		}
+		ids = append(ids, memory.ID)
+	}
+
+	at, err := parseTimeSpec("2030-01-02T03:04:05Z", time.Now())
+	if err != nil {
+		t.Fatalf("Failed to parse time: %v", err)
+	}
+	if err := touchMemories(fs, ids, at); err != nil {
+		t.Fatalf("Failed to touch memories: %v", err)
+	}
+	for _, id := range ids {
+		memory, err := fs.Get(id)
+		if err != nil {
+			t.Fatalf("Failed to get memory: %v", err)
+		}
+		if !memory.UpdatedAt.Equal(at) {
+			t.Errorf("Expected %s updated at %v, got %v", id, at, memory.UpdatedAt)
+		}
+	}
+
+	// A missing ID is reported before any memory is touched
+	later := at.Add(time.Hour)
+	if err := touchMemories(fs, []string{ids[0], "missing"}, later); !errors.Is(err, storage.ErrMemoryNotFound) {
+		t.Fatalf("Expected ErrMemoryNotFound, got %v", err)
+	}
+	memory, err := fs.Get(ids[0])
+	if err != nil {
+		t.Fatalf("Failed to get memory: %v", err)
+	}
+	if !memory.UpdatedAt.Equal(at) {
+		t.Errorf("Expected %s to be left at %v, got %v", ids[0], at, memory.UpdatedAt)
+	}
+}
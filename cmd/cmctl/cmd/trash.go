@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// defaultTrashRetention is how long trashed memories are kept before
+// 'trash empty --expired' removes them
+const defaultTrashRetention = "30d"
+
+var trashCmd = &cobra.Command{
+	Use:   "trash",
+	Short: "List, restore, or empty deleted memories",
+	Long: `Manage memories that were moved to the trash by 'cmctl delete'.
+
+Examples:
+  cmctl trash list                          # Show trashed memories
+  cmctl trash restore mem_12345678_90abcd   # Restore a trashed memory
+  cmctl trash empty --expired               # Purge memories past the retention period
+  cmctl trash empty                         # Purge everything in the trash`,
+}
+
+var trashListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List trashed memories",
+	Args:  cobra.NoArgs,
+	RunE:  runTrashList,
+}
+
+var trashRestoreCmd = &cobra.Command{
+	Use:   "restore <memory-id>...",
+	Short: "Restore trashed memories",
+	Args:  cobra.MinimumNArgs(1),
+	RunE:  runTrashRestore,
+}
+
+var trashEmptyCmd = &cobra.Command{
+	Use:   "empty",
+	Short: "Permanently delete trashed memories",
+	Long: `Permanently delete memories from the trash.
+
+With --expired, only memories deleted longer ago than the retention period
+are removed. The retention defaults to 30d and can be set with --retention
+or the trash-retention config key.`,
+	Args: cobra.NoArgs,
+	RunE: runTrashEmpty,
+}
+
+var (
+	trashEmptyExpired bool
+	trashEmptyForce   bool
+)
+
+func init() {
+	rootCmd.AddCommand(trashCmd)
+	trashCmd.AddCommand(trashListCmd, trashRestoreCmd, trashEmptyCmd)
+
+	trashEmptyCmd.Flags().BoolVar(&trashEmptyExpired, "expired", false, "Only remove memories trashed longer ago than the retention period")
+	trashEmptyCmd.Flags().String("retention", defaultTrashRetention, "Retention period for --expired (e.g. 7d, 2w, 720h)")
+	trashEmptyCmd.Flags().BoolVar(&trashEmptyForce, "force", false, "Skip confirmation prompt")
+
+	if err := viper.BindPFlag("trash-retention", trashEmptyCmd.Flags().Lookup("retention")); err != nil {
+		panic(fmt.Sprintf("failed to bind retention flag: %v", err))
+	}
+}
+
+func runTrashList(cmd *cobra.Command, args []string) error {
+	provider, err := getStorageProvider()
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	fs, err := trashProvider(provider)
+	if err != nil {
+		return err
+	}
+
+	trashed, err := fs.ListTrash()
+	if err != nil {
+		return fmt.Errorf("failed to list trash: %w", err)
+	}
+
+	if len(trashed) == 0 {
+		fmt.Println("Trash is empty")
+		return nil
+	}
+
+	fmt.Printf("%-25s %-40s %s\n", "ID", "NAME", "DELETED")
+	for _, memory := range trashed {
+		fmt.Printf("%-25s %-40s %s\n", memory.ID, truncateString(memory.Name, 40), formatAge(memory.DeletedAt))
+	}
+	return nil
+}
+
+func runTrashRestore(cmd *cobra.Command, args []string) error {
+	provider, err := getStorageProvider()
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	fs, err := trashProvider(provider)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range args {
+		memory, err := fs.Restore(id)
+		if err != nil {
+			return fmt.Errorf("failed to restore memory: %w", err)
+		}
+		fmt.Printf("memory/%s restored\n", memory.ID)
+	}
+	return nil
+}
+
+func runTrashEmpty(cmd *cobra.Command, args []string) error {
+	provider, err := getStorageProvider()
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	fs, err := trashProvider(provider)
+	if err != nil {
+		return err
+	}
+
+	var olderThan time.Duration
+	if trashEmptyExpired {
+		olderThan, err = parseRelativeDuration(viper.GetString("trash-retention"))
+		if err != nil {
+			return fmt.Errorf("invalid trash retention: %w", err)
+		}
+		if olderThan == 0 {
+			return fmt.Errorf("trash retention must be greater than zero")
+		}
+	}
+
+	if !trashEmptyForce && !trashEmptyExpired && GetVerbosity() >= Normal {
+		fmt.Print("Are you sure you want to permanently delete everything in the trash? (y/N): ")
+		var response string
+		_, _ = fmt.Scanln(&response) // Ignore error - treat as 'no' if input fails
+		if strings.ToLower(response) != "y" && strings.ToLower(response) != "yes" {
+			fmt.Println("Empty cancelled")
+			return nil
+		}
+	}
+
+	removed, err := fs.EmptyTrash(olderThan)
+	if err != nil {
+		return fmt.Errorf("failed to empty trash: %w", err)
+	}
+
+	fmt.Printf("Permanently deleted %d memories from the trash\n", removed)
+	return nil
+}
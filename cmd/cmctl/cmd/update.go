@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/providers"
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+var updateCmd = &cobra.Command{
+	Use:   "update <memory-id>",
+	Short: "Update a memory",
+	Long: `Update the content, name or labels of an existing memory. Content can be
+provided via --content or piped from stdin and replaces the existing content,
+unless --append is given, which adds it to the end after a separator.
+Labels given with --labels are merged into the existing labels. --ttl
+sets the memory to expire that long from now; --ttl 0 removes its expiry.
+
+Examples:
+  cmctl update mem_abc123_def456 --content "Revised notes"
+  echo "Found the root cause" | cmctl update mem_abc123_def456 --append
+  cmctl update mem_abc123_def456 --append --separator $'\n---\n' --content "Next step"
+  cmctl update mem_abc123_def456 --labels "status=done"
+  cmctl update mem_abc123_def456 --labels-from-file project-labels.yaml
+  cmctl update mem_abc123_def456 --ttl 7d
+  cmctl update mem_abc123_def456 --ttl 0`,
+	Args: cobra.ExactArgs(1),
+	RunE: runUpdate,
+}
+
+var (
+	updateName      string
+	updateContent   string
+	updateLabels    string
+	updateLabelsIn  string
+	updateAppend    bool
+	updateSeparator string
+	updateTTL       string
+)
+
+func init() {
+	rootCmd.AddCommand(updateCmd)
+
+	updateCmd.Flags().StringVarP(&updateName, "name", "n", "", "New memory name")
+	updateCmd.Flags().StringVarP(&updateContent, "content", "c", "", "New content (or pipe from stdin)")
+	updateCmd.Flags().StringVarP(&updateLabels, "labels", "l", "", "Labels to add or change (format: key1=value1,key2=value2)")
+	updateCmd.Flags().StringVar(&updateLabelsIn, "labels-from-file", "", "Read labels to add or change from a YAML or JSON map; --labels overrides them")
+	updateCmd.Flags().BoolVar(&updateAppend, "append", false, "Append the content to the existing content instead of replacing it")
+	updateCmd.Flags().StringVar(&updateSeparator, "separator", storage.DefaultAppendSeparator, "Separator inserted before appended content")
+	updateCmd.Flags().StringVar(&updateTTL, "ttl", "", "Expire the memory this long from now (e.g. 12h, 7d, 2w); 0 removes the expiry")
+}
+
+func runUpdate(cmd *cobra.Command, args []string) error {
+	// Initialize storage
+	fs, err := getStorageProvider()
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+
+	content := updateContent
+	if content == "" {
+		stdinContent, err := readStdin()
+		if err == nil && stdinContent != "" {
+			content = stdinContent
+		}
+	}
+
+	req := storage.UpdateMemoryRequest{
+		ID:        args[0],
+		Name:      strings.TrimSpace(updateName),
+		Content:   content,
+		Append:    updateAppend,
+		Separator: updateSeparator,
+	}
+	if updateTTL != "" {
+		if req.ExpiresAt, err = updateExpiry(updateTTL, time.Now()); err != nil {
+			return err
+		}
+	}
+	if req.Name == "" && req.Content == "" && req.ExpiresAt == nil && updateLabels == "" && updateLabelsIn == "" {
+		return fmt.Errorf("nothing to update (use --content, --name, --labels, --labels-from-file or --ttl, or pipe from stdin)")
+	}
+
+	labels := parseLabels(updateLabels)
+	if updateLabelsIn != "" {
+		fileLabels, err := loadLabelsFile(updateLabelsIn)
+		if err != nil {
+			return err
+		}
+		labels = mergeLabels(fileLabels, labels)
+	}
+
+	memory, err := updateMemory(fs, req, labels)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("memory/%s updated\n", memory.ID)
+	VPrintf(Normal, "NAME\t%s\n", memory.Name)
+	VPrintf(Normal, "LABELS\t%s\n", formatLabels(memory.Labels))
+	if memory.ExpiresAt != nil {
+		VPrintf(Normal, "EXPIRES\t%s\n", memory.ExpiresAt.Format("2006-01-02T15:04:05Z"))
+	}
+	return nil
+}
+
+// updateExpiry returns the ExpiresAt for an update's --ttl: now plus the
+// TTL, or the zero time, which removes the expiry, for a TTL of 0
+func updateExpiry(ttl string, now time.Time) (*time.Time, error) {
+	d, err := parseRelativeDuration(ttl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --ttl: %w", err)
+	}
+	if d == 0 {
+		return &time.Time{}, nil
+	}
+	expiresAt := now.Add(d)
+	return &expiresAt, nil
+}
+
+// updateMemory applies req, merging labels over the memory's existing labels
+// rather than replacing them
+func updateMemory(fs providers.StorageProvider, req storage.UpdateMemoryRequest, labels map[string]string) (*storage.Memory, error) {
+	if len(labels) > 0 {
+		existing, err := fs.Get(req.ID)
+		if err != nil {
+			return nil, err
+		}
+		req.Labels = mergeLabels(existing.Labels, labels)
+	}
+
+	memory, err := fs.Update(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update memory: %w", err)
+	}
+	return memory, nil
+}
+
+// findMemoryByName returns the memory with exactly the given name, or nil if
+// there is none. More than one match is an error, since the caller couldn't
+// tell which one was meant.
+func findMemoryByName(fs providers.StorageProvider, name string) (*storage.Memory, error) {
+	memories, err := listMetadata(fs)
+	if err != nil {
+		return nil, err
+	}
+
+	var found []storage.Memory
+	for _, memory := range memories {
+		if memory.Name == name {
+			found = append(found, memory)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return nil, nil
+	case 1:
+		return &found[0], nil
+	default:
+		ids := make([]string, len(found))
+		for i, memory := range found {
+			ids[i] = memory.ID
+		}
+		return nil, fmt.Errorf("%d memories are named %q (%s); use 'cmctl update <id> --append' instead", len(found), name, strings.Join(ids, ", "))
+	}
+}
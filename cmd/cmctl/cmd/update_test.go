@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+)
+
+func TestAppendContent(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		addition  string
+		separator string
+		want      string
+	}{
+		{"inserts separator", "first", "second", "\n\n", "first\n\nsecond"},
+		{"empty content", "", "second", "\n\n", "second"},
+		{"already separated", "first\n\n", "second", "\n\n", "first\n\nsecond"},
+		{"custom separator", "first", "second", "\n---\n", "first\n---\nsecond"},
+		{"no separator", "first", "second", "", "firstsecond"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := storage.AppendContent(tt.content, tt.addition, tt.separator); got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCreateOrAppend(t *testing.T) {
+	fs := newTestStorage(t)
+
+	first, appended, err := createOrAppend(fs, storage.CreateMemoryRequest{
+		Name:    "today",
+		Content: "morning",
+		Labels:  map[string]string{"type": "log"},
+	}, storage.DefaultAppendSeparator)
+	if err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+	if appended {
+		t.Error("Expected the first call to create the memory")
+	}
+
+	second, appended, err := createOrAppend(fs, storage.CreateMemoryRequest{
+		Name:    "today",
+		Content: "afternoon",
+		Labels:  map[string]string{"mood": "good"},
+	}, storage.DefaultAppendSeparator)
+	if err != nil {
+		t.Fatalf("Failed to append to memory: %v", err)
+	}
+	if !appended {
+		t.Error("Expected the second call to append")
+	}
+	if second.ID != first.ID {
+		t.Errorf("Expected to append to %s, got %s", first.ID, second.ID)
+	}
+	if second.Content != "morning\n\nafternoon" {
+		t.Errorf("Expected content with separator, got %q", second.Content)
+	}
+	if second.Labels["type"] != "log" || second.Labels["mood"] != "good" {
+		t.Errorf("Expected merged labels, got %v", second.Labels)
+	}
+
+	memories, err := fs.List()
+	if err != nil {
+		t.Fatalf("Failed to list memories: %v", err)
+	}
+	if len(memories) != 1 {
+		t.Errorf("Expected 1 memory, got %d", len(memories))
+	}
+
+	if _, _, err := createOrAppend(fs, storage.CreateMemoryRequest{Content: "x"}, "\n"); err == nil {
+		t.Error("Expected an error without a name")
+	}
+}
+
+func TestCreateOrAppendAmbiguousName(t *testing.T) {
+	fs := newTestStorage(t)
+	for range 2 {
+		if _, err := fs.Create(storage.CreateMemoryRequest{Name: "today", Content: "a"}); err != nil {
+			t.Fatalf("Failed to create memory: %v", err)
+		}
+	}
+
+	if _, _, err := createOrAppend(fs, storage.CreateMemoryRequest{Name: "today", Content: "b"}, "\n"); err == nil {
+		t.Error("Expected an error when several memories share the name")
+	}
+}
+
+func TestUpdateExpiry(t *testing.T) {
+	fs := newTestStorage(t)
+	created, err := fs.Create(storage.CreateMemoryRequest{Name: "Scratch", Content: "content"})
+	if err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+
+	now := time.Now()
+	expiresAt, err := updateExpiry("7d", now)
+	if err != nil {
+		t.Fatalf("Failed to parse --ttl: %v", err)
+	}
+	updated, err := updateMemory(fs, storage.UpdateMemoryRequest{ID: created.ID, ExpiresAt: expiresAt}, nil)
+	if err != nil {
+		t.Fatalf("Failed to set expiry: %v", err)
+	}
+	if want := now.Add(7 * 24 * time.Hour); updated.ExpiresAt == nil || !updated.ExpiresAt.Equal(want) {
+		t.Errorf("Expected expiry %v, got %v", want, updated.ExpiresAt)
+	}
+
+	// --ttl 0 removes the expiry
+	expiresAt, err = updateExpiry("0", now)
+	if err != nil {
+		t.Fatalf("Failed to parse --ttl 0: %v", err)
+	}
+	if _, err := updateMemory(fs, storage.UpdateMemoryRequest{ID: created.ID, ExpiresAt: expiresAt}, nil); err != nil {
+		t.Fatalf("Failed to remove expiry: %v", err)
+	}
+	got, err := fs.Get(created.ID)
+	if err != nil {
+		t.Fatalf("Failed to get memory: %v", err)
+	}
+	if got.ExpiresAt != nil {
+		t.Errorf("Expected the expiry removed, got %v", got.ExpiresAt)
+	}
+
+	if _, err := updateExpiry("soon", now); err == nil {
+		t.Error("Expected an invalid --ttl to be rejected")
+	}
+}
@@ -2,8 +2,13 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+	"gopkg.in/yaml.v3"
 )
 
 // formatLabels formats labels for detailed display
@@ -69,6 +74,76 @@ func truncateString(s string, maxLen int) string {
 	return s[:maxLen-3] + "..."
 }
 
+// contentPreview returns content on one line, with runs of whitespace
+// collapsed to single spaces, cut to at most maxRunes characters with "..."
+// marking the cut
+func contentPreview(content string, maxRunes int) string {
+	line := []rune(strings.Join(strings.Fields(content), " "))
+	if len(line) <= maxRunes {
+		return string(line)
+	}
+	if maxRunes <= 3 {
+		return string(line[:maxRunes])
+	}
+	return string(line[:maxRunes-3]) + "..."
+}
+
+// labelValueSeparator joins several values in one label. Commas would break
+// label selectors.
+const labelValueSeparator = "_"
+
+// maxLabelValueLength matches the storage limit on label values
+const maxLabelValueLength = 63
+
+// sanitizeLabelValue rewrites s into the characters allowed in label
+// values, spelling out '+' and '#' so c++ and c# stay recognizable
+func sanitizeLabelValue(s string) string {
+	s = strings.NewReplacer("+", "p", "#", "sharp").Replace(s)
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('-')
+		}
+	}
+	value := strings.Trim(b.String(), "-")
+	if len(value) > maxLabelValueLength {
+		value = value[:maxLabelValueLength]
+	}
+	return value
+}
+
+// joinLabelValues sanitizes values and joins them into one label value,
+// dropping duplicates and any values that don't fit
+func joinLabelValues(values []string) string {
+	var joined []string
+	seen := make(map[string]bool)
+	length := 0
+	for _, v := range values {
+		v = sanitizeLabelValue(v)
+		if v == "" || seen[v] {
+			continue
+		}
+		if length+len(v) > maxLabelValueLength {
+			continue
+		}
+		seen[v] = true
+		joined = append(joined, v)
+		length += len(v) + len(labelValueSeparator)
+	}
+	return strings.Join(joined, labelValueSeparator)
+}
+
+// splitLabelValues splits a multi-value label, also accepting the commas
+// written by older imports
+func splitLabelValues(value string) []string {
+	return strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == rune(labelValueSeparator[0])
+	})
+}
+
 // parseLabels parses a comma-separated label selector string into a map
 // Format: "key1=value1,key2=value2" -> map[string]string{"key1": "value1", "key2": "value2"}
 func parseLabels(labelSelector string) map[string]string {
@@ -90,3 +165,91 @@ func parseLabels(labelSelector string) map[string]string {
 	}
 	return labelMap
 }
+
+// parseExcludeLabels parses an --exclude-labels selector, returning nil
+// when it is empty
+func parseExcludeLabels(selector string) (map[string]string, error) {
+	if selector == "" {
+		return nil, nil
+	}
+	labels := parseLabels(selector)
+	if len(labels) == 0 {
+		return nil, fmt.Errorf("invalid --exclude-labels selector format: %s", selector)
+	}
+	return labels, nil
+}
+
+// loadLabelsFile reads a label set from a YAML or JSON file mapping label
+// keys to values
+func loadLabelsFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read labels file: %w", err)
+	}
+	labels := make(map[string]string)
+	if err := yaml.Unmarshal(data, &labels); err != nil {
+		return nil, fmt.Errorf("failed to parse labels file %s (expected a map of label keys to values): %w", path, err)
+	}
+	if err := storage.ValidateLabels(labels); err != nil {
+		return nil, fmt.Errorf("invalid labels in %s: %w", path, err)
+	}
+	return labels, nil
+}
+
+// mergeLabels returns the labels in base with those in overrides added or
+// replacing them
+func mergeLabels(base, overrides map[string]string) map[string]string {
+	merged := make(map[string]string, len(base)+len(overrides))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range overrides {
+		merged[k] = v
+	}
+	return merged
+}
+
+// parseRelativeDuration parses durations like "30m", "12h", "3d" or "2w".
+// Days and weeks are supported in addition to time.ParseDuration units.
+func parseRelativeDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, fmt.Errorf("empty duration")
+	}
+
+	unit := s[len(s)-1]
+	if unit == 'd' || unit == 'w' {
+		n, err := strconv.Atoi(s[:len(s)-1])
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		days := n
+		if unit == 'w' {
+			days = n * 7
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid duration %q (use e.g. 30m, 12h, 3d, 2w)", s)
+	}
+	return d, nil
+}
+
+// parseTimeSpec parses an absolute time (RFC3339 or YYYY-MM-DD) or a
+// relative duration such as "3d", interpreted as that long before now
+func parseTimeSpec(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, now.Location()); err == nil {
+		return t, nil
+	}
+	d, err := parseRelativeDuration(s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q (use YYYY-MM-DD, RFC3339, or a relative duration like 3d)", s)
+	}
+	return now.Add(-d), nil
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/providers"
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check stored memories against the memory schema",
+	Long: `Check every stored memory file against the constraints enforced when
+memories are written: non-empty IDs and names, name length, label syntax,
+content size and relations. Hand-edited or externally written files can
+break them. Each violation is printed with the file it was found in, and
+validate exits non-zero if any remain.
+
+With --fix, overlong names are truncated and invalid labels dropped, and each
+repair is reported. Other violations have to be fixed by hand.
+
+Examples:
+  cmctl validate          # Report memories that break the schema
+  cmctl validate --fix    # Repair names and labels in place`,
+	Args: cobra.NoArgs,
+	RunE: runValidate,
+}
+
+var validateFix bool
+
+func init() {
+	rootCmd.AddCommand(validateCmd)
+
+	validateCmd.Flags().BoolVar(&validateFix, "fix", false, "Truncate overlong names and drop invalid labels")
+}
+
+func runValidate(cmd *cobra.Command, args []string) error {
+	fs, err := getStorageProvider()
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	validator, ok := fs.(providers.Validator)
+	if !ok {
+		return fmt.Errorf("storage provider %s does not support validation", fs.GetProviderType())
+	}
+
+	result, err := validator.Validate(validateFix)
+	if err != nil {
+		return fmt.Errorf("failed to validate memories: %w", err)
+	}
+	fmt.Print(formatValidateResult(result))
+	if invalid := result.Invalid(); invalid > 0 {
+		return fmt.Errorf("%d schema violations found", invalid)
+	}
+	return nil
+}
+
+// formatValidateResult lists each violation by file, then a summary
+func formatValidateResult(result storage.ValidateResult) string {
+	var out strings.Builder
+	for _, violation := range result.Violations {
+		prefix := ""
+		if violation.Fixed {
+			prefix = "fixed: "
+		}
+		fmt.Fprintf(&out, "%s: %s%s\n", violation.File, prefix, violation.Problem)
+	}
+
+	switch invalid := result.Invalid(); {
+	case result.Rewritten > 0:
+		fmt.Fprintf(&out, "Checked %d memories, repaired %d, %d violations left\n", result.Checked, result.Rewritten, invalid)
+	case invalid > 0:
+		fmt.Fprintf(&out, "Checked %d memories, %d violations\n", result.Checked, invalid)
+	default:
+		fmt.Fprintf(&out, "Checked %d memories, all valid\n", result.Checked)
+	}
+	return out.String()
+}
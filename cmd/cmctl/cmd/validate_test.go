@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+	"github.com/spf13/viper"
+)
+
+func TestValidateCommand(t *testing.T) {
+	useTestStorageDir(t)
+	defer func() { validateFix = false }()
+	fs, err := getStorageProvider()
+	if err != nil {
+		t.Fatalf("Failed to get storage: %v", err)
+	}
+	if _, err := fs.Create(storage.CreateMemoryRequest{Name: "Valid", Content: "content"}); err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+
+	output := captureStdout(t, func() error { return runValidate(validateCmd, nil) })
+	if output != "Checked 1 memories, all valid\n" {
+		t.Errorf("Expected every memory valid, got %q", output)
+	}
+
+	file := filepath.Join(viper.GetString("storage-dir"), "memories", "mem_edited.json")
+	edited := `{"id":"mem_edited","name":"Edited","content":"content","labels":{"bad key":"x"}}`
+	if err := os.WriteFile(file, []byte(edited), 0644); err != nil {
+		t.Fatalf("Failed to write memory file: %v", err)
+	}
+
+	var runErr error
+	output = captureStdout(t, func() error { runErr = runValidate(validateCmd, nil); return nil })
+	if runErr == nil || runErr.Error() != "1 schema violations found" {
+		t.Errorf("Expected validate to fail, got %v", runErr)
+	}
+	if !strings.HasPrefix(output, file+`: invalid label key "bad key"`) {
+		t.Errorf("Expected the violation reported with its file, got %q", output)
+	}
+
+	validateFix = true
+	output = captureStdout(t, func() error { return runValidate(validateCmd, nil) })
+	if !strings.HasPrefix(output, file+`: fixed: dropped label "bad key"`) ||
+		!strings.HasSuffix(output, "Checked 2 memories, repaired 1, 0 violations left\n") {
+		t.Errorf("Expected the repair reported, got %q", output)
+	}
+}
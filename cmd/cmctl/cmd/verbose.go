@@ -1,8 +1,12 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
+	"strings"
 
 	"github.com/spf13/viper"
 )
@@ -16,23 +20,105 @@ const (
 	Verbose VerbosityLevel = 2 // Debug info, config details, etc.
 )
 
+// Values of --log-format
+const (
+	logFormatKey  = "log-format"
+	logFormatText = "text"
+	logFormatJSON = "json"
+)
+
+// logOutput is where diagnostics are written
+var logOutput io.Writer = os.Stderr
+
 // GetVerbosity returns the current verbosity level
 func GetVerbosity() VerbosityLevel {
 	return VerbosityLevel(viper.GetInt("verbosity"))
 }
 
-// VPrintf prints formatted output only if verbosity level is met
+// slogLevel maps a verbosity level to the slog level of the messages shown
+// at it: warnings even when quiet, info normally, and debug when verbose
+func slogLevel(level VerbosityLevel) slog.Level {
+	switch {
+	case level <= Quiet:
+		return slog.LevelWarn
+	case level == Normal:
+		return slog.LevelInfo
+	default:
+		return slog.LevelDebug
+	}
+}
+
+// validateLogFormat checks a --log-format value
+func validateLogFormat(format string) error {
+	if format != logFormatText && format != logFormatJSON {
+		return fmt.Errorf("invalid log format %q (must be %s or %s)", format, logFormatText, logFormatJSON)
+	}
+	return nil
+}
+
+// newLogger returns a logger writing to w in the given format, showing
+// records at the level that verbosity maps to and above. Text records
+// leave out the time, which is noise on a terminal.
+func newLogger(w io.Writer, format string, verbosity VerbosityLevel) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: slogLevel(verbosity)}
+	if format == logFormatJSON {
+		return slog.New(slog.NewJSONHandler(w, opts))
+	}
+	opts.ReplaceAttr = func(groups []string, attr slog.Attr) slog.Attr {
+		if len(groups) == 0 && attr.Key == slog.TimeKey {
+			return slog.Attr{}
+		}
+		return attr
+	}
+	return slog.New(slog.NewTextHandler(w, opts))
+}
+
+// appLogger returns the logger given to the storage and chat readers,
+// configured by --log-format and --verbosity
+func appLogger() *slog.Logger {
+	return newLogger(logOutput, viper.GetString(logFormatKey), GetVerbosity())
+}
+
+// logMessage writes a VPrintf message as a log record. A "Warning: " or
+// "[DEBUG] " prefix sets the record's level and is dropped from it.
+func logMessage(level VerbosityLevel, message string) {
+	message = strings.TrimSpace(message)
+	recordLevel := slogLevel(level)
+	if rest, ok := strings.CutPrefix(message, "Warning: "); ok {
+		message, recordLevel = rest, slog.LevelWarn
+	}
+	if rest, ok := strings.CutPrefix(message, "[DEBUG] "); ok {
+		message, recordLevel = rest, slog.LevelDebug
+	}
+	if message == "" {
+		return
+	}
+	appLogger().Log(context.Background(), recordLevel, message)
+}
+
+// VPrintf prints formatted output only if verbosity level is met. With
+// --log-format json the message is written as a log record instead.
 func VPrintf(level VerbosityLevel, format string, args ...interface{}) {
-	if GetVerbosity() >= level {
-		fmt.Fprintf(os.Stderr, format, args...)
+	if GetVerbosity() < level {
+		return
 	}
+	if viper.GetString(logFormatKey) == logFormatJSON {
+		logMessage(level, fmt.Sprintf(format, args...))
+		return
+	}
+	fmt.Fprintf(logOutput, format, args...)
 }
 
 // VPrintln prints a line only if verbosity level is met
 func VPrintln(level VerbosityLevel, args ...interface{}) {
-	if GetVerbosity() >= level {
-		fmt.Fprintln(os.Stderr, args...)
+	if GetVerbosity() < level {
+		return
+	}
+	if viper.GetString(logFormatKey) == logFormatJSON {
+		logMessage(level, fmt.Sprintln(args...))
+		return
 	}
+	fmt.Fprintln(logOutput, args...)
 }
 
 // DebugPrintf prints debug information (verbosity >= 2)
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+	"github.com/spf13/viper"
+)
+
+// useLogOutput captures diagnostics with the given format and verbosity
+func useLogOutput(t *testing.T, format string, verbosity VerbosityLevel) *bytes.Buffer {
+	t.Helper()
+	output, prevFormat, prevVerbosity := logOutput, viper.GetString(logFormatKey), viper.GetInt("verbosity")
+	t.Cleanup(func() {
+		logOutput = output
+		viper.Set(logFormatKey, prevFormat)
+		viper.Set("verbosity", prevVerbosity)
+	})
+
+	var buf bytes.Buffer
+	logOutput = &buf
+	viper.Set(logFormatKey, format)
+	viper.Set("verbosity", int(verbosity))
+	return &buf
+}
+
+// logRecords decodes JSON log lines into level and message pairs
+func logRecords(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var records []map[string]any
+	decoder := json.NewDecoder(buf)
+	for decoder.More() {
+		var record map[string]any
+		if err := decoder.Decode(&record); err != nil {
+			t.Fatalf("Failed to decode log record: %v", err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestVPrintfJSON(t *testing.T) {
+	buf := useLogOutput(t, logFormatJSON, Normal)
+
+	VPrintf(Normal, "Imported %d chats\n", 3)
+	VPrintf(Normal, "Warning: skipping %s\n", "notes.md")
+	DebugPrintf("hidden at normal verbosity\n")
+
+	records := logRecords(t, buf)
+	want := []struct{ level, msg string }{
+		{"INFO", "Imported 3 chats"},
+		{"WARN", "skipping notes.md"},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("Expected %d records, got %v", len(want), records)
+	}
+	for i, record := range records {
+		if record["level"] != want[i].level || record["msg"] != want[i].msg {
+			t.Errorf("Expected %s %q, got %v", want[i].level, want[i].msg, record)
+		}
+	}
+}
+
+func TestVPrintfText(t *testing.T) {
+	buf := useLogOutput(t, logFormatText, Quiet)
+
+	VPrintf(Normal, "not shown when quiet\n")
+	VPrintf(Quiet, "Warning: shown when quiet\n")
+
+	if got := buf.String(); got != "Warning: shown when quiet\n" {
+		t.Errorf("Expected plain text output, got %q", got)
+	}
+}
+
+func TestStorageWarningsUseAppLogger(t *testing.T) {
+	useTestStorageDir(t)
+	// Warnings are shown even when quiet
+	buf := useLogOutput(t, logFormatJSON, Quiet)
+
+	fs, err := getStorageProvider()
+	if err != nil {
+		t.Fatalf("Failed to get storage: %v", err)
+	}
+	memory, err := fs.Create(storage.CreateMemoryRequest{Name: "Listed", Content: "content"})
+	if err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+	// A memory that is indexed but missing on disk is skipped with a
+	// warning when searched with content
+	if err := os.Remove(filepath.Join(viper.GetString("storage-dir"), "memories", memory.ID+".json")); err != nil {
+		t.Fatalf("Failed to remove memory file: %v", err)
+	}
+	if _, err := fs.Search(storage.SearchRequest{IncludeContent: true}); err != nil {
+		t.Fatalf("Failed to search: %v", err)
+	}
+
+	records := logRecords(t, buf)
+	if len(records) != 1 || records[0]["level"] != "WARN" || records[0]["msg"] != "skipping memory" || records[0]["id"] != memory.ID {
+		t.Errorf("Expected one warning for the skipped memory, got %v", records)
+	}
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// defaultWatchInterval is how often --watch re-reads the store
+const defaultWatchInterval = 2 * time.Second
+
+// clearScreen moves the cursor home and clears a terminal
+const clearScreen = "\033[H\033[2J"
+
+// runWatch redraws render's output on stdout whenever it changes, polling
+// the store every interval until interrupted
+func runWatch(cmd *cobra.Command, interval time.Duration, render func() (string, error)) error {
+	if interval <= 0 {
+		return fmt.Errorf("watch interval must be positive, got %s", interval)
+	}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	return watchLoop(ctx, ticker.C, render, os.Stdout, isTerminal(os.Stdout))
+}
+
+// watchLoop renders once, then re-renders on every event and writes the
+// output when it differs from the last one. On a terminal the screen is
+// cleared and redrawn; otherwise output is appended so it can be piped.
+// Errors after the first render are reported and the loop keeps going,
+// since a read can race with a concurrent write.
+func watchLoop(ctx context.Context, events <-chan time.Time, render func() (string, error), w io.Writer, redraw bool) error {
+	output, err := render()
+	if err != nil {
+		return err
+	}
+	if redraw {
+		fmt.Fprint(w, clearScreen)
+	}
+	fmt.Fprint(w, output)
+	last := output
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case _, ok := <-events:
+			if !ok {
+				return nil
+			}
+			output, err := render()
+			if err != nil {
+				VPrintf(Normal, "Warning: %v\n", err)
+				continue
+			}
+			if output == last {
+				continue
+			}
+			if redraw {
+				fmt.Fprint(w, clearScreen)
+			}
+			fmt.Fprint(w, output)
+			last = output
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+)
+
+func TestWatchLoop(t *testing.T) {
+	fs := newTestStorage(t)
+	if _, err := fs.Create(storage.CreateMemoryRequest{Name: "First", Content: "one", Labels: map[string]string{"type": "chat"}}); err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+
+	outputOpts, err := ParseOutputFormat("json")
+	if err != nil {
+		t.Fatalf("Failed to parse output format: %v", err)
+	}
+	// rendered lets the test wait for each render to finish before it
+	// changes the store
+	rendered := make(chan struct{})
+	events := make(chan time.Time)
+	render := func() (string, error) {
+		defer func() { rendered <- struct{}{} }()
+		resp, err := fs.Search(storage.SearchRequest{LabelSelector: map[string]string{"type": "chat"}})
+		if err != nil {
+			return "", err
+		}
+		return FormatMemoryList(resp.Memories, outputOpts, false)
+	}
+	tick := func() {
+		events <- time.Now()
+		<-rendered
+	}
+
+	var out bytes.Buffer
+	done := make(chan error)
+	go func() {
+		done <- watchLoop(context.Background(), events, render, &out, false)
+	}()
+	<-rendered
+
+	// An unchanged store doesn't print again
+	tick()
+
+	// A matching memory triggers a redraw; one filtered out doesn't
+	if _, err := fs.Create(storage.CreateMemoryRequest{Name: "Second", Content: "two", Labels: map[string]string{"type": "chat"}}); err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+	tick()
+	if _, err := fs.Create(storage.CreateMemoryRequest{Name: "Notes", Content: "three", Labels: map[string]string{"type": "notes"}}); err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+	tick()
+	close(events)
+
+	if err := <-done; err != nil {
+		t.Fatalf("Watch loop failed: %v", err)
+	}
+
+	if count := strings.Count(out.String(), `"kind": "MemoryList"`); count != 2 {
+		t.Errorf("Expected 2 renders, got %d:\n%s", count, out.String())
+	}
+	if strings.Contains(out.String(), "Notes") {
+		t.Error("Expected the label filter to exclude Notes")
+	}
+	if strings.Contains(out.String(), clearScreen) {
+		t.Error("Expected append-only output when not redrawing a terminal")
+	}
+}
+
+func TestWatchLoopRedrawAndCancel(t *testing.T) {
+	renders := 0
+	render := func() (string, error) {
+		renders++
+		return strings.Repeat("x", renders), nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan time.Time)
+	var out bytes.Buffer
+	done := make(chan error)
+	go func() {
+		done <- watchLoop(ctx, events, render, &out, true)
+	}()
+
+	events <- time.Now()
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Watch loop failed: %v", err)
+	}
+	if count := strings.Count(out.String(), clearScreen); count != 2 {
+		t.Errorf("Expected the screen to be cleared before each of 2 renders, got %d", count)
+	}
+}
+
+func TestWatchLoopInitialError(t *testing.T) {
+	render := func() (string, error) { return "", errors.New("boom") }
+	if err := watchLoop(context.Background(), nil, render, &bytes.Buffer{}, false); err == nil {
+		t.Error("Expected the first render error to be returned")
+	}
+}
@@ -0,0 +1,79 @@
+// Package api defines the versioned documents cmctl emits for structured
+// output (-o json, -o yaml), the HTTP server and the MCP server.
+//
+// The shape of a document is part of the API version: fields may be added
+// within contextmemory.io/v1, but existing fields are not renamed, removed
+// or retyped.
+package api
+
+import "github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+
+// Version is the version of the structured documents cmctl emits
+const Version = "contextmemory.io/v1"
+
+// Document kinds
+const (
+	KindMemory     = "Memory"
+	KindMemoryList = "MemoryList"
+	KindStatus     = "Status"
+)
+
+// MemoryListDocument wraps a list of memories for consistent API output
+type MemoryListDocument struct {
+	APIVersion string           `json:"apiVersion" yaml:"apiVersion"`
+	Kind       string           `json:"kind" yaml:"kind"`
+	Items      []storage.Memory `json:"items" yaml:"items"`
+}
+
+// NewMemoryListDocument creates a MemoryList document
+func NewMemoryListDocument(memories []storage.Memory) MemoryListDocument {
+	if memories == nil {
+		memories = []storage.Memory{}
+	}
+	return MemoryListDocument{
+		APIVersion: Version,
+		Kind:       KindMemoryList,
+		Items:      memories,
+	}
+}
+
+// MemoryDocument wraps a single memory for consistent API output
+type MemoryDocument struct {
+	APIVersion string         `json:"apiVersion" yaml:"apiVersion"`
+	Kind       string         `json:"kind" yaml:"kind"`
+	Metadata   map[string]any `json:"metadata" yaml:"metadata"`
+	Spec       storage.Memory `json:"spec" yaml:"spec"`
+}
+
+// NewMemoryDocument creates a Memory document
+func NewMemoryDocument(memory *storage.Memory) MemoryDocument {
+	return MemoryDocument{
+		APIVersion: Version,
+		Kind:       KindMemory,
+		Metadata: map[string]any{
+			"id":   memory.ID,
+			"name": memory.Name,
+		},
+		Spec: *memory,
+	}
+}
+
+// StatusDocument reports an API error
+type StatusDocument struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Status     string `json:"status"`
+	Message    string `json:"message"`
+	Code       int    `json:"code"`
+}
+
+// NewStatusDocument creates a failure Status document
+func NewStatusDocument(code int, message string) StatusDocument {
+	return StatusDocument{
+		APIVersion: Version,
+		Kind:       KindStatus,
+		Status:     "Failure",
+		Message:    message,
+		Code:       code,
+	}
+}
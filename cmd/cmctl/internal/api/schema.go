@@ -0,0 +1,164 @@
+package api
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// SchemaDialect is the JSON Schema draft the generated schemas follow
+const SchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// documentTypes maps each document kind to its Go type
+var documentTypes = map[string]reflect.Type{
+	KindMemory:     reflect.TypeOf(MemoryDocument{}),
+	KindMemoryList: reflect.TypeOf(MemoryListDocument{}),
+	KindStatus:     reflect.TypeOf(StatusDocument{}),
+}
+
+// Kinds returns the document kinds, in a stable order
+func Kinds() []string {
+	return []string{KindMemory, KindMemoryList, KindStatus}
+}
+
+// IsKind reports whether kind names a document kind
+func IsKind(kind string) bool {
+	_, ok := documentTypes[kind]
+	return ok
+}
+
+// Schema returns the JSON Schema of the documents of the given kinds, or of
+// every kind when none are given. The schema is generated from the document
+// types, so it can't drift from what cmctl emits. Objects allow properties
+// the schema doesn't list, so documents from later v1 releases still
+// validate.
+func Schema(kinds ...string) map[string]any {
+	if len(kinds) == 0 {
+		kinds = Kinds()
+	}
+
+	gen := schemaGenerator{defs: make(map[string]any)}
+	var refs []any
+	for _, kind := range kinds {
+		t, ok := documentTypes[kind]
+		if !ok {
+			continue
+		}
+		refs = append(refs, gen.typeSchema(t))
+
+		// apiVersion and kind are fixed for each document type
+		def := gen.defs[t.Name()].(map[string]any)
+		properties := def["properties"].(map[string]any)
+		properties["apiVersion"] = map[string]any{"const": Version}
+		properties["kind"] = map[string]any{"const": kind}
+	}
+
+	schema := map[string]any{
+		"$schema": SchemaDialect,
+		"title":   Version + " documents",
+		"$defs":   gen.defs,
+	}
+	if len(refs) == 1 {
+		schema["$ref"] = refs[0].(map[string]any)["$ref"]
+	} else {
+		schema["oneOf"] = refs
+	}
+	return schema
+}
+
+// schemaGenerator builds JSON Schemas from Go types, collecting named
+// structs as definitions
+type schemaGenerator struct {
+	defs map[string]any
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// typeSchema returns the schema of values of type t, as encoding/json
+// writes them
+func (g *schemaGenerator) typeSchema(t reflect.Type) map[string]any {
+	if t == timeType {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return g.typeSchema(t.Elem())
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": g.typeSchema(t.Elem())}
+	case reflect.Map:
+		schema := map[string]any{"type": "object"}
+		if t.Elem().Kind() != reflect.Interface {
+			schema["additionalProperties"] = g.typeSchema(t.Elem())
+		}
+		return schema
+	case reflect.Struct:
+		if _, ok := g.defs[t.Name()]; !ok {
+			g.defs[t.Name()] = nil // Reserve the name for recursive types
+			g.defs[t.Name()] = g.structSchema(t)
+		}
+		return map[string]any{"$ref": "#/$defs/" + t.Name()}
+	default:
+		return map[string]any{}
+	}
+}
+
+// structSchema returns the object schema of a struct's JSON fields
+func (g *schemaGenerator) structSchema(t reflect.Type) map[string]any {
+	properties := make(map[string]any)
+	required := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, omitEmpty := jsonFieldName(field)
+		if name == "-" {
+			continue
+		}
+
+		schema := g.typeSchema(field.Type)
+		if omitEmpty {
+			properties[name] = schema
+			continue
+		}
+		required = append(required, name)
+		switch field.Type.Kind() {
+		case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Interface:
+			// A nil value is written as null
+			schema = map[string]any{"anyOf": []any{schema, map[string]any{"type": "null"}}}
+		}
+		properties[name] = schema
+	}
+
+	return map[string]any{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
+
+// jsonFieldName returns the name encoding/json gives field and whether the
+// field is left out when empty
+func jsonFieldName(field reflect.StructField) (string, bool) {
+	tag := strings.Split(field.Tag.Get("json"), ",")
+	name := tag[0]
+	if name == "" {
+		name = field.Name
+	}
+	for _, option := range tag[1:] {
+		if option == "omitempty" || option == "omitzero" {
+			return name, true
+		}
+	}
+	return name, false
+}
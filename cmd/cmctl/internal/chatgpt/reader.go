@@ -0,0 +1,249 @@
+// Package chatgpt reads conversations from a ChatGPT data export
+// (conversations.json) and maps them onto the chat types used for Cursor
+// imports.
+package chatgpt
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/cursor"
+)
+
+// Source is recorded on chats read from a ChatGPT export
+const Source = "chatgpt"
+
+var _ cursor.ChatReader = (*ExportReader)(nil)
+
+// ExportReader provides access to the conversations in a ChatGPT export
+type ExportReader struct {
+	Path string
+}
+
+// NewExportReader creates a reader for the conversations.json at path
+func NewExportReader(path string) *ExportReader {
+	return &ExportReader{Path: path}
+}
+
+// conversation is one entry of conversations.json. Messages form a tree:
+// editing a prompt or regenerating a reply adds a sibling branch, and
+// current_node is the leaf of the branch last shown.
+type conversation struct {
+	ID             string          `json:"id"`
+	ConversationID string          `json:"conversation_id"`
+	Title          string          `json:"title"`
+	CreateTime     float64         `json:"create_time"`
+	UpdateTime     float64         `json:"update_time"`
+	CurrentNode    string          `json:"current_node"`
+	Mapping        map[string]node `json:"mapping"`
+}
+
+// node is a position in the message tree. The root and some structural
+// nodes carry no message.
+type node struct {
+	ID       string   `json:"id"`
+	Message  *message `json:"message"`
+	Parent   string   `json:"parent"`
+	Children []string `json:"children"`
+}
+
+// message is a single turn of a conversation
+type message struct {
+	ID     string `json:"id"`
+	Author struct {
+		Role string `json:"role"`
+	} `json:"author"`
+	CreateTime float64 `json:"create_time"`
+	Content    struct {
+		ContentType string            `json:"content_type"`
+		Parts       []json.RawMessage `json:"parts"`
+		Text        string            `json:"text"`
+	} `json:"content"`
+	Metadata struct {
+		Hidden bool `json:"is_visually_hidden_from_conversation"`
+	} `json:"metadata"`
+}
+
+// text returns the message's text. Parts that aren't strings, such as
+// image pointers, are dropped.
+func (m *message) text() string {
+	var parts []string
+	for _, raw := range m.Content.Parts {
+		var part string
+		if json.Unmarshal(raw, &part) == nil && strings.TrimSpace(part) != "" {
+			parts = append(parts, part)
+		}
+	}
+	if len(parts) == 0 && m.Content.Text != "" {
+		parts = append(parts, m.Content.Text)
+	}
+	return strings.TrimSpace(strings.Join(parts, "\n\n"))
+}
+
+// ReadConversations parses every conversation in the export
+func (er *ExportReader) ReadConversations() ([]cursor.ChatTab, error) {
+	data, err := os.ReadFile(er.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ChatGPT export: %w", err)
+	}
+
+	var conversations []conversation
+	if err := json.Unmarshal(data, &conversations); err != nil {
+		return nil, fmt.Errorf("failed to parse ChatGPT export %s: %w", er.Path, err)
+	}
+
+	chats := make([]cursor.ChatTab, 0, len(conversations))
+	for _, conv := range conversations {
+		chats = append(chats, *conversationToChatTab(conv))
+	}
+	return chats, nil
+}
+
+// conversationToChatTab maps a conversation onto a ChatTab with the
+// messages of its current branch in order
+func conversationToChatTab(conv conversation) *cursor.ChatTab {
+	chat := &cursor.ChatTab{
+		ID:             conv.ConversationID,
+		Title:          conv.Title,
+		Timestamp:      unixMillis(conv.UpdateTime),
+		ConversationID: conv.ConversationID,
+		Source:         Source,
+	}
+	if chat.ID == "" {
+		chat.ID = conv.ID
+		chat.ConversationID = conv.ID
+	}
+	if conv.CreateTime > 0 {
+		chat.CreatedAt = time.UnixMilli(unixMillis(conv.CreateTime))
+	}
+
+	for _, n := range currentBranch(conv) {
+		msg := n.Message
+		role := msg.Author.Role
+		// System prompts and tool output aren't part of the transcript
+		if msg.Metadata.Hidden || (role != "user" && role != "assistant") {
+			continue
+		}
+		text := msg.text()
+		if text == "" {
+			continue
+		}
+		chat.Messages = append(chat.Messages, cursor.Message{
+			ID:        msg.ID,
+			Role:      role,
+			Content:   text,
+			Timestamp: unixMillis(msg.CreateTime),
+		})
+	}
+
+	if chat.Timestamp == 0 && len(chat.Messages) > 0 {
+		chat.Timestamp = chat.Messages[len(chat.Messages)-1].Timestamp
+	}
+	return chat
+}
+
+// currentBranch returns the nodes with messages from the root to the
+// current node, following parent pointers. Without a known current node
+// the latest leaf is used.
+func currentBranch(conv conversation) []node {
+	leaf, ok := conv.Mapping[conv.CurrentNode]
+	if !ok {
+		leaf, ok = latestLeaf(conv.Mapping)
+		if !ok {
+			return nil
+		}
+	}
+
+	var branch []node
+	seen := make(map[string]bool)
+	for n, ok := leaf, true; ok && !seen[n.ID]; n, ok = conv.Mapping[n.Parent] {
+		seen[n.ID] = true
+		if n.Message != nil {
+			branch = append(branch, n)
+		}
+	}
+
+	for i, j := 0, len(branch)-1; i < j; i, j = i+1, j-1 {
+		branch[i], branch[j] = branch[j], branch[i]
+	}
+	return branch
+}
+
+// latestLeaf returns the childless node with the latest message
+func latestLeaf(mapping map[string]node) (node, bool) {
+	var latest node
+	found := false
+	for _, n := range mapping {
+		if len(n.Children) > 0 || n.Message == nil {
+			continue
+		}
+		if !found || n.Message.CreateTime > latest.Message.CreateTime ||
+			(n.Message.CreateTime == latest.Message.CreateTime && n.ID > latest.ID) {
+			latest, found = n, true
+		}
+	}
+	return latest, found
+}
+
+// unixMillis converts the export's fractional Unix seconds to milliseconds
+func unixMillis(seconds float64) int64 {
+	return int64(math.Round(seconds * 1000))
+}
+
+// ListAllChats returns every conversation in the export, newest first
+func (er *ExportReader) ListAllChats() ([]cursor.ChatTabWithWorkspace, error) {
+	chats, err := er.ReadConversations()
+	if err != nil {
+		return nil, err
+	}
+
+	allChats := make([]cursor.ChatTabWithWorkspace, 0, len(chats))
+	for _, chat := range chats {
+		allChats = append(allChats, cursor.ChatTabWithWorkspace{ChatTab: chat})
+	}
+
+	sort.SliceStable(allChats, func(i, j int) bool {
+		return allChats[i].Timestamp > allChats[j].Timestamp
+	})
+	return allChats, nil
+}
+
+// GetLatestChat returns the most recently updated conversation
+func (er *ExportReader) GetLatestChat() (*cursor.ChatTab, error) {
+	chats, err := er.ListAllChats()
+	if err != nil {
+		return nil, err
+	}
+	if len(chats) == 0 {
+		return nil, fmt.Errorf("no conversations found in %s", er.Path)
+	}
+	return &chats[0].ChatTab, nil
+}
+
+// GetChatByID retrieves a conversation by its ID
+func (er *ExportReader) GetChatByID(chatID string) (*cursor.ChatTab, string, error) {
+	chats, err := er.ListAllChats()
+	if err != nil {
+		return nil, "", err
+	}
+	for i := range chats {
+		if chats[i].ID == chatID {
+			return &chats[i].ChatTab, "", nil
+		}
+	}
+	return nil, "", fmt.Errorf("conversation with ID %s not found", chatID)
+}
+
+// SearchChats searches for conversations containing specific text
+func (er *ExportReader) SearchChats(query string) ([]cursor.ChatTabWithWorkspace, error) {
+	allChats, err := er.ListAllChats()
+	if err != nil {
+		return nil, err
+	}
+	return cursor.FilterChats(allChats, query), nil
+}
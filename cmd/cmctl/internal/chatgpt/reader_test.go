@@ -0,0 +1,95 @@
+package chatgpt
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const fixtureExport = "testdata/conversations.json"
+
+func TestListAllChats(t *testing.T) {
+	reader := NewExportReader(fixtureExport)
+
+	chats, err := reader.ListAllChats()
+	if err != nil {
+		t.Fatalf("ListAllChats failed: %v", err)
+	}
+	if len(chats) != 2 {
+		t.Fatalf("Expected 2 chats, got %d", len(chats))
+	}
+
+	latest := chats[0]
+	if latest.ID != "conv-tokens" {
+		t.Errorf("Expected newest chat first, got %s", latest.ID)
+	}
+	if latest.Title != "Rotating refresh tokens" {
+		t.Errorf("Expected the conversation title, got %q", latest.Title)
+	}
+	if latest.Source != Source {
+		t.Errorf("Expected source %s, got %q", Source, latest.Source)
+	}
+	if latest.Timestamp != 1758700300500 {
+		t.Errorf("Expected timestamp 1758700300500, got %d", latest.Timestamp)
+	}
+	if latest.CreatedAt.UnixMilli() != 1758700000123 {
+		t.Errorf("Expected creation time 1758700000123, got %d", latest.CreatedAt.UnixMilli())
+	}
+
+	// The current branch is followed through the parent pointers; the
+	// discarded reply, hidden system prompt, tool output and image part
+	// are dropped
+	want := []struct {
+		id      string
+		role    string
+		content string
+	}{
+		{"msg-question-1", "user", "Should refresh tokens be rotated on every use?"},
+		{"msg-answer-1b", "assistant", "Yes, rotate them and revoke the old token."},
+		{"msg-question-2", "user", "What about reuse detection?"},
+		{"msg-answer-2", "assistant", "Revoke the whole token family when a rotated token is reused."},
+	}
+	if len(latest.Messages) != len(want) {
+		t.Fatalf("Expected %d messages, got %d: %+v", len(want), len(latest.Messages), latest.Messages)
+	}
+	for i, msg := range latest.Messages {
+		if msg.ID != want[i].id || msg.Role != want[i].role || msg.Content != want[i].content {
+			t.Errorf("Message %d: got %s %s %q, want %s %s %q", i, msg.ID, msg.Role, msg.Content, want[i].id, want[i].role, want[i].content)
+		}
+	}
+
+	// Without a current node or conversation_id, the latest leaf and id are used
+	older := chats[1]
+	if older.ID != "conv-generics" {
+		t.Errorf("Expected the id as chat ID, got %s", older.ID)
+	}
+	if len(older.Messages) != 2 || older.Messages[1].Content != "No, only the receiver's type can be generic." {
+		t.Errorf("Expected text content from a code message, got %+v", older.Messages)
+	}
+}
+
+func TestGetChatByID(t *testing.T) {
+	reader := NewExportReader(fixtureExport)
+
+	chat, _, err := reader.GetChatByID("conv-generics")
+	if err != nil {
+		t.Fatalf("GetChatByID failed: %v", err)
+	}
+	if chat.Title != "Go generics question" {
+		t.Errorf("Expected the generics chat, got %q", chat.Title)
+	}
+
+	if _, _, err := reader.GetChatByID("missing"); err == nil {
+		t.Error("Expected error for unknown conversation")
+	}
+}
+
+func TestReadConversationsMalformed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "conversations.json")
+	if err := os.WriteFile(path, []byte(`{"not": "a list"}`), 0644); err != nil {
+		t.Fatalf("Failed to write export: %v", err)
+	}
+	if _, err := NewExportReader(path).ReadConversations(); err == nil {
+		t.Error("Expected error for a malformed export")
+	}
+}
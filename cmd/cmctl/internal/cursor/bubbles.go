@@ -0,0 +1,347 @@
+package cursor
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Newer Cursor builds store each chat message ("bubble") as its own row,
+// keyed "bubbleId:<composerId>:<bubbleId>", with per-conversation headers
+// under "composerData:<composerId>". Rows may live in ItemTable or in the
+// cursorDiskKV table.
+const (
+	bubbleKeyPrefix       = "bubbleId:"
+	composerDataKeyPrefix = "composerData:"
+)
+
+// CursorDiskKVItem represents a key-value item in Cursor's cursorDiskKV table
+type CursorDiskKVItem struct {
+	Key   string `gorm:"column:key;primaryKey"`
+	Value string `gorm:"column:value"`
+}
+
+// TableName specifies the table name for CursorDiskKVItem
+func (CursorDiskKVItem) TableName() string {
+	return "cursorDiskKV"
+}
+
+// Bubble types used by Cursor's bubble storage
+const (
+	bubbleTypeUser      = 1
+	bubbleTypeAssistant = 2
+)
+
+// BubbleEntry represents a single message row in the bubble format
+type BubbleEntry struct {
+	BubbleID  string        `json:"bubbleId"`
+	Type      int           `json:"type"`
+	Role      string        `json:"role,omitempty"`
+	Text      string        `json:"text"`
+	CreatedAt flexTimestamp `json:"createdAt,omitempty"`
+
+	// CodeBlocks are the code blocks Cursor extracted from the bubble, with
+	// the language and file it identified for each
+	CodeBlocks []bubbleCodeBlock `json:"codeBlocks,omitempty"`
+	// RelevantFiles are the files attached to or referenced by the bubble
+	RelevantFiles []string `json:"relevantFiles,omitempty"`
+}
+
+// bubbleCodeBlock is a code block recorded on a bubble
+type bubbleCodeBlock struct {
+	LanguageID string `json:"languageId"`
+	Content    string `json:"content"`
+	URI        struct {
+		Path   string `json:"path"`
+		FsPath string `json:"fsPath"`
+	} `json:"uri"`
+}
+
+// path returns the file the code block belongs to, if any
+func (cb bubbleCodeBlock) path() string {
+	if cb.URI.FsPath != "" {
+		return cb.URI.FsPath
+	}
+	return cb.URI.Path
+}
+
+// BubbleComposer represents the per-conversation header in the bubble format
+type BubbleComposer struct {
+	ComposerID    string        `json:"composerId"`
+	Name          string        `json:"name"`
+	CreatedAt     flexTimestamp `json:"createdAt,omitempty"`
+	LastUpdatedAt flexTimestamp `json:"lastUpdatedAt,omitempty"`
+	Headers       []struct {
+		BubbleID string `json:"bubbleId"`
+		Type     int    `json:"type"`
+	} `json:"fullConversationHeadersOnly,omitempty"`
+}
+
+// flexTimestamp holds a Unix millisecond timestamp that may be encoded as a
+// JSON number or an RFC3339 string
+type flexTimestamp int64
+
+// UnmarshalJSON accepts numbers, numeric strings and RFC3339 strings
+func (ft *flexTimestamp) UnmarshalJSON(data []byte) error {
+	var n int64
+	if err := json.Unmarshal(data, &n); err == nil {
+		*ft = flexTimestamp(n)
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil // Unknown encodings are treated as missing
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		*ft = flexTimestamp(n)
+		return nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		*ft = flexTimestamp(t.UnixMilli())
+	}
+	return nil
+}
+
+// bubbleRole maps a bubble's explicit role or numeric type to a role string
+func bubbleRole(bubble BubbleEntry) string {
+	if bubble.Role != "" {
+		return bubble.Role
+	}
+	switch bubble.Type {
+	case bubbleTypeUser:
+		return "user"
+	case bubbleTypeAssistant:
+		return "assistant"
+	default:
+		return ""
+	}
+}
+
+// loadBubbleRows returns all bubble and composer header rows from both
+// ItemTable and cursorDiskKV, keyed by row key
+func loadBubbleRows(db *gorm.DB) map[string]string {
+	rows := make(map[string]string)
+	pattern := func(prefix string) string { return prefix + "%" }
+
+	var items []CursorItem
+	if err := db.Where("key LIKE ? OR key LIKE ?", pattern(bubbleKeyPrefix), pattern(composerDataKeyPrefix)).Find(&items).Error; err == nil {
+		for _, item := range items {
+			rows[item.Key] = item.Value
+		}
+	}
+
+	if db.Migrator().HasTable(&CursorDiskKVItem{}) {
+		var kvItems []CursorDiskKVItem
+		if err := db.Where("key LIKE ? OR key LIKE ?", pattern(bubbleKeyPrefix), pattern(composerDataKeyPrefix)).Find(&kvItems).Error; err == nil {
+			for _, item := range kvItems {
+				rows[item.Key] = item.Value
+			}
+		}
+	}
+
+	return rows
+}
+
+// parseBubbleRows groups bubble rows into chats by composer ID, preserving
+// the conversation order recorded in the composer header when available and
+// falling back to message timestamps otherwise
+func (wr *WorkspaceReader) parseBubbleRows(rows map[string]string, titles composerTitleIndex) []ChatTab {
+	bubblesByComposer := make(map[string][]BubbleEntry)
+	composers := make(map[string]BubbleComposer)
+
+	// Iterate keys in sorted order so parsing is deterministic
+	keys := make([]string, 0, len(rows))
+	for key := range rows {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		switch {
+		case strings.HasPrefix(key, bubbleKeyPrefix):
+			parts := strings.SplitN(strings.TrimPrefix(key, bubbleKeyPrefix), ":", 2)
+			if len(parts) != 2 {
+				continue
+			}
+			var bubble BubbleEntry
+			if err := json.Unmarshal([]byte(rows[key]), &bubble); err != nil {
+				wr.log().Debug("skipping unparseable bubble", "key", key, "error", err)
+				continue
+			}
+			if bubble.BubbleID == "" {
+				bubble.BubbleID = parts[1]
+			}
+			bubblesByComposer[parts[0]] = append(bubblesByComposer[parts[0]], bubble)
+		case strings.HasPrefix(key, composerDataKeyPrefix):
+			var composer BubbleComposer
+			if err := json.Unmarshal([]byte(rows[key]), &composer); err != nil {
+				wr.log().Debug("skipping unparseable composer", "key", key, "error", err)
+				continue
+			}
+			composerID := strings.TrimPrefix(key, composerDataKeyPrefix)
+			if composer.ComposerID == "" {
+				composer.ComposerID = composerID
+			}
+			composers[composerID] = composer
+		}
+	}
+
+	composerIDs := make([]string, 0, len(bubblesByComposer))
+	for id := range bubblesByComposer {
+		composerIDs = append(composerIDs, id)
+	}
+	sort.Strings(composerIDs)
+
+	var chatTabs []ChatTab
+	for _, composerID := range composerIDs {
+		composer := composers[composerID]
+		bubbles := orderBubbles(bubblesByComposer[composerID], composer)
+
+		var messages []Message
+		var explicitRoles []string
+		for _, bubble := range bubbles {
+			blocks := bubbleBlocks(bubble)
+			content := bubble.Text
+			if strings.TrimSpace(content) == "" {
+				if !hasCodeBlock(blocks) {
+					continue // Tool calls and other non-text bubbles
+				}
+				content = renderBlocks(blocks)
+			}
+			message := Message{
+				ID:        bubble.BubbleID,
+				Content:   content,
+				Timestamp: int64(bubble.CreatedAt),
+				Blocks:    blocks,
+			}
+			if bubble.CreatedAt > 0 {
+				message.CreatedAt = time.UnixMilli(int64(bubble.CreatedAt))
+			}
+			messages = append(messages, message)
+			explicitRoles = append(explicitRoles, bubbleRole(bubble))
+		}
+		if len(messages) == 0 {
+			continue
+		}
+		resolveRoles(messages, explicitRoles)
+
+		title := composer.Name
+		if title == "" {
+			if t, ok := titles.lookup(composerID); ok {
+				title = t
+			}
+		}
+
+		createdAt := int64(composer.CreatedAt)
+		if createdAt == 0 {
+			createdAt = messages[0].Timestamp
+		}
+		timestamp := int64(composer.LastUpdatedAt)
+		if last := messages[len(messages)-1].Timestamp; last > timestamp {
+			timestamp = last
+		}
+		if timestamp == 0 {
+			timestamp = createdAt
+		}
+
+		chatTab := ChatTab{
+			ID:             composerID,
+			Title:          title,
+			Messages:       messages,
+			Timestamp:      timestamp,
+			ConversationID: composerID,
+		}
+		if createdAt > 0 {
+			chatTab.CreatedAt = time.UnixMilli(createdAt)
+		}
+		chatTabs = append(chatTabs, chatTab)
+	}
+
+	return chatTabs
+}
+
+// bubbleBlocks splits a bubble into content blocks when Cursor recorded code
+// blocks or files alongside its text, and returns nil otherwise. Code blocks
+// already fenced in the text take their language and file from Cursor's
+// record; the rest follow the text.
+func bubbleBlocks(bubble BubbleEntry) []ContentBlock {
+	if len(bubble.CodeBlocks) == 0 && len(bubble.RelevantFiles) == 0 {
+		return nil
+	}
+
+	blocks := splitContentBlocks(bubble.Text)
+	fenced := make(map[string]int)
+	for i, block := range blocks {
+		if block.Type == ContentBlockCode {
+			fenced[strings.TrimSpace(block.Text)] = i
+		}
+	}
+	for _, code := range bubble.CodeBlocks {
+		text := strings.TrimRight(code.Content, "\n")
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		language := normalizeCodeLanguage(code.LanguageID)
+		if i, ok := fenced[strings.TrimSpace(text)]; ok {
+			if blocks[i].Language == "" {
+				blocks[i].Language = language
+			}
+			if blocks[i].Path == "" {
+				blocks[i].Path = code.path()
+			}
+			continue
+		}
+		blocks = append(blocks, ContentBlock{Type: ContentBlockCode, Text: text, Language: language, Path: code.path()})
+	}
+
+	seen := make(map[string]bool)
+	for _, file := range bubble.RelevantFiles {
+		if file != "" && !seen[file] {
+			seen[file] = true
+			blocks = append(blocks, ContentBlock{Type: ContentBlockFile, Path: file})
+		}
+	}
+
+	if len(blocks) == 0 {
+		return nil
+	}
+	return blocks
+}
+
+// hasCodeBlock reports whether any of blocks is code
+func hasCodeBlock(blocks []ContentBlock) bool {
+	for _, block := range blocks {
+		if block.Type == ContentBlockCode {
+			return true
+		}
+	}
+	return false
+}
+
+// orderBubbles sorts bubbles by the composer's recorded conversation order,
+// placing any bubbles missing from the header afterwards by timestamp
+func orderBubbles(bubbles []BubbleEntry, composer BubbleComposer) []BubbleEntry {
+	position := make(map[string]int, len(composer.Headers))
+	for i, header := range composer.Headers {
+		position[header.BubbleID] = i
+	}
+
+	sort.SliceStable(bubbles, func(i, j int) bool {
+		pi, okI := position[bubbles[i].BubbleID]
+		pj, okJ := position[bubbles[j].BubbleID]
+		switch {
+		case okI && okJ:
+			return pi < pj
+		case okI != okJ:
+			return okI
+		default:
+			return bubbles[i].CreatedAt < bubbles[j].CreatedAt
+		}
+	})
+	return bubbles
+}
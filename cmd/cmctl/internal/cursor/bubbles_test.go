@@ -0,0 +1,151 @@
+package cursor
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/cursor/cursortest"
+)
+
+// bubbleFixtureRows loads the bubble fixture as raw key/value rows
+func bubbleFixtureRows(t *testing.T) map[string]string {
+	t.Helper()
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(readFixture(t, "bubbles.json")), &raw); err != nil {
+		t.Fatalf("Failed to parse bubble fixture: %v", err)
+	}
+	rows := make(map[string]string, len(raw))
+	for key, value := range raw {
+		rows[key] = string(value)
+	}
+	return rows
+}
+
+func assertBubbleChats(t *testing.T, tabs []ChatTab) {
+	t.Helper()
+
+	chats := make(map[string]ChatTab)
+	for _, tab := range tabs {
+		chats[tab.ID] = tab
+	}
+
+	chat, ok := chats["composer-1"]
+	if !ok {
+		t.Fatalf("Expected composer-1 chat, got %+v", tabs)
+	}
+	if chat.Title != "Bubble format chat" {
+		t.Errorf("Expected composer title, got %q", chat.Title)
+	}
+
+	// Order follows the composer header; empty bubbles are dropped
+	wantContent := []string{"First: the user asks", "Second: the assistant replies", "Third: the user follows up"}
+	wantRoles := []string{"user", "assistant", "user"}
+	if len(chat.Messages) != len(wantContent) {
+		t.Fatalf("Expected %d messages, got %d: %+v", len(wantContent), len(chat.Messages), chat.Messages)
+	}
+	for i, msg := range chat.Messages {
+		if msg.Content != wantContent[i] || msg.Role != wantRoles[i] {
+			t.Errorf("Message %d: got %s %q, want %s %q", i, msg.Role, msg.Content, wantRoles[i], wantContent[i])
+		}
+		if msg.Timestamp == 0 {
+			t.Errorf("Message %d: expected timestamp to be preserved", i)
+		}
+	}
+	if chat.Timestamp != 1758600030000 {
+		t.Errorf("Expected chat timestamp from lastUpdatedAt, got %d", chat.Timestamp)
+	}
+
+	// Without a header, bubbles are ordered by timestamp
+	headerless, ok := chats["composer-2"]
+	if !ok {
+		t.Fatalf("Expected composer-2 chat")
+	}
+	if len(headerless.Messages) != 2 || headerless.Messages[0].Content != "Question without a header" {
+		t.Errorf("Expected headerless bubbles ordered by timestamp, got %+v", headerless.Messages)
+	}
+}
+
+func TestGetChatDataBubbleFormatItemTable(t *testing.T) {
+	storageDir := t.TempDir()
+	dbPath := cursortest.WriteWorkspace(t, storageDir, "workspace", bubbleFixtureRows(t))
+
+	chatData, err := NewWorkspaceReaderWithPath(storageDir).GetChatData(dbPath)
+	if err != nil {
+		t.Fatalf("GetChatData failed: %v", err)
+	}
+	assertBubbleChats(t, chatData.Tabs)
+}
+
+func TestGetChatDataBubbleFormatDiskKV(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "state.vscdb")
+	cursortest.WriteDB(t, dbPath, map[string]string{})
+	cursortest.WriteDiskKV(t, dbPath, bubbleFixtureRows(t))
+
+	chatData, err := NewWorkspaceReaderWithPath(dbPath).GetChatData(dbPath)
+	if err != nil {
+		t.Fatalf("GetChatData failed: %v", err)
+	}
+	assertBubbleChats(t, chatData.Tabs)
+}
+
+func TestGetChatDataWithoutBubblesFallsBackToLegacy(t *testing.T) {
+	storageDir := t.TempDir()
+	dbPath := cursortest.WriteWorkspace(t, storageDir, "workspace", map[string]string{
+		"aiService.prompts": `[{"text": "legacy prompt", "role": "user"}]`,
+	})
+
+	chatData, err := NewWorkspaceReaderWithPath(storageDir).GetChatData(dbPath)
+	if err != nil {
+		t.Fatalf("GetChatData failed: %v", err)
+	}
+	if len(chatData.Tabs) != 1 || chatData.Tabs[0].Messages[0].Content != "legacy prompt" {
+		t.Errorf("Expected legacy prompts chat, got %+v", chatData.Tabs)
+	}
+}
+
+func TestParseBubbleBlocks(t *testing.T) {
+	rows := map[string]string{
+		"bubbleId:composer-1:b1": `{"type": 1, "text": "Why does this fail?", "createdAt": 1758600000000,
+			"relevantFiles": ["src/main.go"]}`,
+		"bubbleId:composer-1:b2": `{"type": 2, "text": "Use this:\n\n` + "```" + `\nfmt.Println(x)\n` + "```" + `", "createdAt": 1758600010000,
+			"codeBlocks": [
+				{"languageId": "golang", "content": "fmt.Println(x)\n", "uri": {"fsPath": "/repo/src/main.go"}},
+				{"languageId": "shellscript", "content": "go run ./src"}
+			]}`,
+		"bubbleId:composer-1:b3": `{"type": 2, "text": "", "createdAt": 1758600020000,
+			"codeBlocks": [{"languageId": "python", "content": "print(x)"}]}`,
+		"bubbleId:composer-1:b4": `{"type": 2, "text": "Plain reply", "createdAt": 1758600030000}`,
+	}
+
+	tabs := NewWorkspaceReaderWithPath(t.TempDir()).parseBubbleRows(rows, composerTitleIndex{})
+	if len(tabs) != 1 || len(tabs[0].Messages) != 4 {
+		t.Fatalf("Expected 1 chat of 4 messages, got %+v", tabs)
+	}
+	messages := tabs[0].Messages
+
+	if want := []ContentBlock{
+		{Type: ContentBlockText, Text: "Why does this fail?"},
+		{Type: ContentBlockFile, Path: "src/main.go"},
+	}; !reflect.DeepEqual(messages[0].Blocks, want) {
+		t.Errorf("Expected prose and a file reference, got %+v", messages[0].Blocks)
+	}
+	// The fenced block takes Cursor's language and file; the other follows
+	if want := []ContentBlock{
+		{Type: ContentBlockText, Text: "Use this:"},
+		{Type: ContentBlockCode, Text: "fmt.Println(x)", Language: "go", Path: "/repo/src/main.go"},
+		{Type: ContentBlockCode, Text: "go run ./src", Language: "bash"},
+	}; !reflect.DeepEqual(messages[1].Blocks, want) {
+		t.Errorf("Expected the fenced block enriched, got %+v", messages[1].Blocks)
+	}
+	if messages[1].Content != "Use this:\n\n```\nfmt.Println(x)\n```" {
+		t.Errorf("Expected the flat text kept as content, got %q", messages[1].Content)
+	}
+	if messages[2].Content != "```python\nprint(x)\n```" {
+		t.Errorf("Expected a code-only bubble rendered as content, got %q", messages[2].Content)
+	}
+	if messages[3].Blocks != nil {
+		t.Errorf("Expected no blocks without recorded structure, got %+v", messages[3].Blocks)
+	}
+}
@@ -0,0 +1,211 @@
+package cursor
+
+import (
+	"sort"
+	"strings"
+)
+
+// CodeBlock is a fenced code block found in a chat message
+type CodeBlock struct {
+	Language string // Normalized language from the fence info string; empty if untagged
+	Code     string
+}
+
+// codeLanguageAliases maps common fence tags onto a canonical language name
+var codeLanguageAliases = map[string]string{
+	"golang":     "go",
+	"js":         "javascript",
+	"jsx":        "javascript",
+	"mjs":        "javascript",
+	"node":       "javascript",
+	"ts":         "typescript",
+	"tsx":        "typescript",
+	"py":         "python",
+	"python3":    "python",
+	"rs":         "rust",
+	"sh":         "bash",
+	"shell":      "bash",
+	"zsh":        "bash",
+	"console":    "bash",
+	"c++":        "cpp",
+	"cc":         "cpp",
+	"cxx":        "cpp",
+	"hpp":        "cpp",
+	"cs":         "csharp",
+	"c#":         "csharp",
+	"rb":         "ruby",
+	"kt":         "kotlin",
+	"yml":        "yaml",
+	"dockerfile": "docker",
+	"ps1":        "powershell",
+	"postgresql": "sql",
+	"mysql":      "sql",
+
+	// VS Code language IDs, which Cursor records on code blocks
+	"javascriptreact": "javascript",
+	"typescriptreact": "typescript",
+	"shellscript":     "bash",
+}
+
+// normalizeCodeLanguage canonicalizes a fence info string into a language
+func normalizeCodeLanguage(info string) string {
+	fields := strings.Fields(info)
+	if len(fields) == 0 {
+		return ""
+	}
+
+	// Info strings may carry attributes, e.g. "go title=main.go" or "{.go}"
+	lang := strings.ToLower(strings.Trim(fields[0], "{}."))
+	if alias, ok := codeLanguageAliases[lang]; ok {
+		return alias
+	}
+	return lang
+}
+
+// parseCodeBlocks extracts ``` and ~~~ fenced code blocks from markdown.
+// An unterminated block runs to the end of the text.
+func parseCodeBlocks(text string) []CodeBlock {
+	var blocks []CodeBlock
+	for _, block := range splitContentBlocks(text) {
+		if block.Type == ContentBlockCode {
+			blocks = append(blocks, CodeBlock{Language: block.Language, Code: block.Text})
+		}
+	}
+	return blocks
+}
+
+// splitContentBlocks splits markdown into prose and the ``` and ~~~ fenced
+// code blocks between it. Blank prose between blocks is dropped.
+func splitContentBlocks(text string) []ContentBlock {
+	var blocks []ContentBlock
+	var fence string
+	var current *ContentBlock
+	var lines []string
+
+	flushText := func() {
+		if prose := strings.Trim(strings.Join(lines, "\n"), "\n"); strings.TrimSpace(prose) != "" {
+			blocks = append(blocks, ContentBlock{Type: ContentBlockText, Text: prose})
+		}
+		lines = nil
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		if current == nil {
+			for _, marker := range []string{"```", "~~~"} {
+				if strings.HasPrefix(trimmed, marker) {
+					flushText()
+					run := len(trimmed) - len(strings.TrimLeft(trimmed, marker[:1]))
+					fence = trimmed[:run]
+					current = &ContentBlock{Type: ContentBlockCode, Language: normalizeCodeLanguage(trimmed[run:])}
+					break
+				}
+			}
+			if current == nil {
+				lines = append(lines, line)
+			}
+			continue
+		}
+
+		if strings.HasPrefix(trimmed, fence) && strings.TrimLeft(trimmed, fence[:1]) == "" {
+			current.Text = strings.Join(lines, "\n")
+			blocks = append(blocks, *current)
+			current, lines = nil, nil
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	if current != nil {
+		current.Text = strings.Join(lines, "\n")
+		blocks = append(blocks, *current)
+	} else {
+		flushText()
+	}
+
+	return blocks
+}
+
+// renderBlocks renders content blocks as markdown, fencing code with its
+// language and file so that parseCodeBlocks reads it back
+func renderBlocks(blocks []ContentBlock) string {
+	parts := make([]string, 0, len(blocks))
+	for _, block := range blocks {
+		switch block.Type {
+		case ContentBlockCode:
+			info := block.Language
+			if info != "" && block.Path != "" {
+				info += " title=" + block.Path
+			}
+			fence := codeFence(block.Text)
+			parts = append(parts, fence+info+"\n"+block.Text+"\n"+fence)
+		case ContentBlockFile:
+			parts = append(parts, "File: `"+block.Path+"`")
+		default:
+			parts = append(parts, block.Text)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// codeFence returns a backtick fence longer than any run of backticks in
+// code, so the code can't close it early
+func codeFence(code string) string {
+	fence := "```"
+	for strings.Contains(code, fence) {
+		fence += "`"
+	}
+	return fence
+}
+
+// CodeBlocks returns the code blocks from all messages in order, from their
+// content blocks where a message has them and fenced blocks otherwise
+func (ct *ChatTab) CodeBlocks() []CodeBlock {
+	var blocks []CodeBlock
+	for _, msg := range ct.Messages {
+		if len(msg.Blocks) == 0 {
+			blocks = append(blocks, parseCodeBlocks(msg.Content)...)
+			continue
+		}
+		for _, block := range msg.Blocks {
+			if block.Type == ContentBlockCode {
+				blocks = append(blocks, CodeBlock{Language: block.Language, Code: block.Text})
+			}
+		}
+	}
+	return blocks
+}
+
+// CodeLanguages returns the languages of tagged code blocks, most frequent
+// first. Ties are broken alphabetically so the order is stable.
+func (ct *ChatTab) CodeLanguages() []string {
+	counts := make(map[string]int)
+	for _, block := range ct.CodeBlocks() {
+		if block.Language != "" {
+			counts[block.Language]++
+		}
+	}
+
+	languages := make([]string, 0, len(counts))
+	for lang := range counts {
+		languages = append(languages, lang)
+	}
+	sort.Slice(languages, func(i, j int) bool {
+		if counts[languages[i]] != counts[languages[j]] {
+			return counts[languages[i]] > counts[languages[j]]
+		}
+		return languages[i] < languages[j]
+	})
+
+	return languages
+}
+
+// DominantCodeLanguage returns the most frequent code block language, or
+// an empty string if the chat has no tagged code blocks
+func (ct *ChatTab) DominantCodeLanguage() string {
+	if languages := ct.CodeLanguages(); len(languages) > 0 {
+		return languages[0]
+	}
+	return ""
+}
@@ -0,0 +1,135 @@
+package cursor
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseCodeBlocks(t *testing.T) {
+	text := "Try this:\n\n```Golang title=main.go\nfmt.Println(\"hi\")\n```\n\n" +
+		"~~~py\nprint('hi')\n~~~\n\n" +
+		"````markdown\n```rust\nnested\n```\n````\n\n" +
+		"```\nuntagged\n```\n\n" +
+		"```ts\nconst unterminated = true"
+
+	blocks := parseCodeBlocks(text)
+
+	want := []CodeBlock{
+		{Language: "go", Code: "fmt.Println(\"hi\")"},
+		{Language: "python", Code: "print('hi')"},
+		{Language: "markdown", Code: "```rust\nnested\n```"},
+		{Language: "", Code: "untagged"},
+		{Language: "typescript", Code: "const unterminated = true"},
+	}
+	if !reflect.DeepEqual(blocks, want) {
+		t.Errorf("Unexpected code blocks:\ngot  %+v\nwant %+v", blocks, want)
+	}
+}
+
+func TestDominantCodeLanguage(t *testing.T) {
+	tests := []struct {
+		name      string
+		messages  []Message
+		wantLangs []string
+	}{
+		{
+			name: "rust code with go in prose",
+			messages: []Message{
+				{Role: "user", Content: "How do I go about fixing this borrow error?\n\n```rust\nlet x = &mut v;\n```"},
+				{Role: "assistant", Content: "Go ahead and clone it:\n\n```rs\nlet x = v.clone();\n```\n\nOr in a shell:\n\n```sh\ncargo build\n```"},
+			},
+			wantLangs: []string{"rust", "bash"},
+		},
+		{
+			name: "ties are alphabetical",
+			messages: []Message{
+				{Role: "assistant", Content: "```python\npass\n```\n```go\nreturn\n```"},
+			},
+			wantLangs: []string{"go", "python"},
+		},
+		{
+			name: "untagged blocks are ignored",
+			messages: []Message{
+				{Role: "assistant", Content: "```\nplain\n```"},
+			},
+			wantLangs: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chat := ChatTab{Messages: tt.messages}
+
+			if got := chat.CodeLanguages(); !reflect.DeepEqual(got, tt.wantLangs) {
+				t.Errorf("Expected languages %v, got %v", tt.wantLangs, got)
+			}
+
+			wantDominant := ""
+			if len(tt.wantLangs) > 0 {
+				wantDominant = tt.wantLangs[0]
+			}
+			if got := chat.DominantCodeLanguage(); got != wantDominant {
+				t.Errorf("Expected dominant language %q, got %q", wantDominant, got)
+			}
+		})
+	}
+}
+
+func TestContentBlocksRoundTrip(t *testing.T) {
+	message := Message{
+		Role:    "assistant",
+		Content: "flattened",
+		Blocks: []ContentBlock{
+			{Type: ContentBlockCode, Text: "package main", Language: "go", Path: "main.go"},
+			{Type: ContentBlockText, Text: "Then document it:"},
+			{Type: ContentBlockCode, Text: "```sh\nmake\n```", Language: "markdown"},
+			{Type: ContentBlockFile, Path: "README.md"},
+		},
+	}
+	chat := ChatTab{Title: "Blocks", Messages: []Message{message}}
+
+	markdown := chat.ToMarkdown()
+	if !strings.Contains(markdown, "**Assistant**:\n```go title=main.go\npackage main\n```") {
+		t.Errorf("Expected the leading code block fenced on its own line, got %q", markdown)
+	}
+	if !strings.Contains(markdown, "File: `README.md`") {
+		t.Errorf("Expected the file reference rendered, got %q", markdown)
+	}
+
+	want := []CodeBlock{
+		{Language: "go", Code: "package main"},
+		{Language: "markdown", Code: "```sh\nmake\n```"},
+	}
+	if got := parseCodeBlocks(markdown); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected code blocks to survive markdown:\ngot  %+v\nwant %+v", got, want)
+	}
+	if got := chat.CodeBlocks(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected code blocks from the content blocks:\ngot  %+v\nwant %+v", got, want)
+	}
+
+	data, err := json.Marshal(message)
+	if err != nil {
+		t.Fatalf("Failed to marshal message: %v", err)
+	}
+	var decoded Message
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal message: %v", err)
+	}
+	if !reflect.DeepEqual(decoded.Blocks, message.Blocks) {
+		t.Errorf("Expected blocks to survive JSON, got %+v", decoded.Blocks)
+	}
+}
+
+func TestSplitContentBlocks(t *testing.T) {
+	blocks := splitContentBlocks("Intro\n\n```py\nprint(1)\n```\n\n\nOutro\n  indented")
+	want := []ContentBlock{
+		{Type: ContentBlockText, Text: "Intro"},
+		{Type: ContentBlockCode, Text: "print(1)", Language: "python"},
+		{Type: ContentBlockText, Text: "Outro\n  indented"},
+	}
+	if !reflect.DeepEqual(blocks, want) {
+		t.Errorf("Unexpected blocks:\ngot  %+v\nwant %+v", blocks, want)
+	}
+}
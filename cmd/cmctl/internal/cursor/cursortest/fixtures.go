@@ -0,0 +1,150 @@
+// Package cursortest provides helpers for building Cursor workspace
+// fixtures in tests.
+package cursortest
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// item mirrors cursor.CursorItem without importing the cursor package,
+// so cursor's own tests can use these helpers
+type item struct {
+	Key   string `gorm:"column:key;primaryKey"`
+	Value string `gorm:"column:value"`
+}
+
+func (item) TableName() string {
+	return "ItemTable"
+}
+
+// WriteWorkspace creates <storageDir>/<name>/state.vscdb populated with the
+// given key/value items and returns the database path
+func WriteWorkspace(t testing.TB, storageDir, name string, items map[string]string) string {
+	t.Helper()
+
+	workspaceDir := filepath.Join(storageDir, name)
+	if err := os.MkdirAll(workspaceDir, 0755); err != nil {
+		t.Fatalf("Failed to create workspace dir: %v", err)
+	}
+
+	dbPath := filepath.Join(workspaceDir, "state.vscdb")
+	WriteDB(t, dbPath, items)
+	return dbPath
+}
+
+// WriteDB creates a state.vscdb-style database at dbPath with the given items
+func WriteDB(t testing.TB, dbPath string, items map[string]string) {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(dbPath), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("Failed to open fixture database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("Failed to access fixture database: %v", err)
+	}
+	defer sqlDB.Close()
+
+	if err := db.AutoMigrate(&item{}); err != nil {
+		t.Fatalf("Failed to create ItemTable: %v", err)
+	}
+	for key, value := range items {
+		if err := db.Create(&item{Key: key, Value: value}).Error; err != nil {
+			t.Fatalf("Failed to insert fixture item %s: %v", key, err)
+		}
+	}
+}
+
+// WriteWAL sets items in the ItemTable of the database at dbPath the way a
+// running Cursor does: in WAL mode with automatic checkpoints off, so they
+// are written to the -wal file and not the database itself. The connection
+// stays open, as Cursor's would, until the test ends, since closing it
+// would checkpoint the WAL into the database.
+func WriteWAL(t testing.TB, dbPath string, items map[string]string) {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(dbPath), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("Failed to open fixture database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("Failed to access fixture database: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	for _, pragma := range []string{"PRAGMA journal_mode=WAL", "PRAGMA wal_autocheckpoint=0"} {
+		if err := db.Exec(pragma).Error; err != nil {
+			t.Fatalf("Failed to set %s: %v", pragma, err)
+		}
+	}
+	for key, value := range items {
+		if err := db.Save(&item{Key: key, Value: value}).Error; err != nil {
+			t.Fatalf("Failed to write fixture item %s: %v", key, err)
+		}
+	}
+}
+
+// diskKVItem mirrors cursor.CursorDiskKVItem
+type diskKVItem struct {
+	Key   string `gorm:"column:key;primaryKey"`
+	Value string `gorm:"column:value"`
+}
+
+func (diskKVItem) TableName() string {
+	return "cursorDiskKV"
+}
+
+// WriteDiskKV adds the given items to the cursorDiskKV table of the
+// database at dbPath, creating the database and table if needed
+func WriteDiskKV(t testing.TB, dbPath string, items map[string]string) {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(dbPath), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("Failed to open fixture database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("Failed to access fixture database: %v", err)
+	}
+	defer sqlDB.Close()
+
+	if err := db.AutoMigrate(&diskKVItem{}); err != nil {
+		t.Fatalf("Failed to create cursorDiskKV: %v", err)
+	}
+	for key, value := range items {
+		if err := db.Create(&diskKVItem{Key: key, Value: value}).Error; err != nil {
+			t.Fatalf("Failed to insert fixture item %s: %v", key, err)
+		}
+	}
+}
+
+// WriteWorkspaceFolder writes the workspace.json beside dbPath, recording
+// folderURI as the folder the workspace was opened on
+func WriteWorkspaceFolder(t testing.TB, dbPath, folderURI string) {
+	t.Helper()
+
+	data, err := json.Marshal(map[string]string{"folder": folderURI})
+	if err != nil {
+		t.Fatalf("Failed to encode workspace.json: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(filepath.Dir(dbPath), "workspace.json"), data, 0644); err != nil {
+		t.Fatalf("Failed to write workspace.json: %v", err)
+	}
+}
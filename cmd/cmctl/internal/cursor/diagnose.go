@@ -0,0 +1,165 @@
+package cursor
+
+import (
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// ChatDiagnoser is implemented by readers that can report what they found
+// in each workspace database, to debug imports that come back empty
+type ChatDiagnoser interface {
+	Diagnose() ([]WorkspaceDiagnosis, error)
+}
+
+var _ ChatDiagnoser = (*WorkspaceReader)(nil)
+
+// WorkspaceDiagnosis reports the chat keys found in one workspace database
+type WorkspaceDiagnosis struct {
+	Path string
+	// Error is set if the database couldn't be opened
+	Error string
+	// Keys has an entry for every known chat key, found or not
+	Keys []KeyDiagnosis
+	// UnknownKeys are keys that look chat-related but no parser reads, which
+	// usually means Cursor changed its storage format
+	UnknownKeys []string
+}
+
+// KeyDiagnosis reports how a known chat key was handled
+type KeyDiagnosis struct {
+	Key string
+	// Parser names the parser for the key's format
+	Parser string
+	// Rows and Bytes count the rows found and the total size of their values
+	Rows  int
+	Bytes int
+	// Chats is the number of chats the parser produced
+	Chats int
+	// Error is set if the value couldn't be parsed
+	Error string
+}
+
+// Found reports whether any rows were found for the key
+func (kd KeyDiagnosis) Found() bool {
+	return kd.Rows > 0
+}
+
+// chatKeyParsers names the parser used for each of chatKeys
+var chatKeyParsers = map[string]string{
+	bubbleKeyPrefix: "bubbles",
+	chatDataKey:     "chatdata",
+	generationsKey:  "generations",
+	promptsKey:      "prompts",
+	composerDataKey: "composer",
+}
+
+// chatKeyHints are substrings of keys that probably hold chat data
+var chatKeyHints = []string{"aichat", "aiservice", "composer", "bubble", "chat"}
+
+// Diagnose reports the chat keys found in every workspace database
+func (wr *WorkspaceReader) Diagnose() ([]WorkspaceDiagnosis, error) {
+	workspaces, err := wr.FindWorkspaces()
+	if err != nil {
+		return nil, err
+	}
+
+	diagnoses := make([]WorkspaceDiagnosis, 0, len(workspaces))
+	for _, dbPath := range workspaces {
+		diagnoses = append(diagnoses, wr.DiagnoseWorkspace(dbPath))
+	}
+	return diagnoses, nil
+}
+
+// DiagnoseWorkspace reports which known chat keys a workspace database
+// contains, their sizes, and how many chats each parser read from them
+func (wr *WorkspaceReader) DiagnoseWorkspace(dbPath string) WorkspaceDiagnosis {
+	diagnosis := WorkspaceDiagnosis{Path: dbPath}
+
+	db, release, err := wr.OpenWorkspaceDB(dbPath)
+	if err != nil {
+		diagnosis.Error = err.Error()
+		return diagnosis
+	}
+	defer release()
+
+	titles := loadComposerTitles(db)
+	for _, key := range chatKeys {
+		kd := KeyDiagnosis{Key: key, Parser: chatKeyParsers[key]}
+
+		if key == bubbleKeyPrefix {
+			kd.Key = bubbleKeyPrefix + "* / " + composerDataKeyPrefix + "*"
+			rows := loadBubbleRows(db)
+			kd.Rows = len(rows)
+			for _, value := range rows {
+				kd.Bytes += len(value)
+			}
+			kd.Chats = len(wr.parseBubbleRows(rows, titles))
+			diagnosis.Keys = append(diagnosis.Keys, kd)
+			continue
+		}
+
+		var item CursorItem
+		if result := db.Where("key = ?", key).First(&item); result.Error == nil {
+			kd.Rows = 1
+			kd.Bytes = len(item.Value)
+			tabs, err := wr.parseChatItem(key, item.Value, titles)
+			if err != nil {
+				kd.Error = err.Error()
+			}
+			kd.Chats = len(tabs)
+		}
+		diagnosis.Keys = append(diagnosis.Keys, kd)
+	}
+
+	diagnosis.UnknownKeys = unknownChatKeys(db)
+	return diagnosis
+}
+
+// unknownChatKeys returns the keys in ItemTable and cursorDiskKV that look
+// chat-related but aren't read by any parser
+func unknownChatKeys(db *gorm.DB) []string {
+	var keys []string
+	if err := db.Model(&CursorItem{}).Pluck("key", &keys).Error; err != nil {
+		keys = nil
+	}
+	if db.Migrator().HasTable(&CursorDiskKVItem{}) {
+		var kvKeys []string
+		if err := db.Model(&CursorDiskKVItem{}).Pluck("key", &kvKeys).Error; err == nil {
+			keys = append(keys, kvKeys...)
+		}
+	}
+
+	seen := make(map[string]bool)
+	var unknown []string
+	for _, key := range keys {
+		if seen[key] || isKnownChatKey(key) || !looksLikeChatKey(key) {
+			continue
+		}
+		seen[key] = true
+		unknown = append(unknown, key)
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// isKnownChatKey reports whether a parser reads key
+func isKnownChatKey(key string) bool {
+	if strings.HasPrefix(key, bubbleKeyPrefix) || strings.HasPrefix(key, composerDataKeyPrefix) {
+		return true
+	}
+	_, ok := chatKeyParsers[key]
+	return ok
+}
+
+// looksLikeChatKey reports whether key probably holds chat data
+func looksLikeChatKey(key string) bool {
+	lower := strings.ToLower(key)
+	for _, hint := range chatKeyHints {
+		if strings.Contains(lower, hint) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+package cursor
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/cursor/cursortest"
+)
+
+// keyDiagnoses indexes a diagnosis by parser name
+func keyDiagnoses(diagnosis WorkspaceDiagnosis) map[string]KeyDiagnosis {
+	keys := make(map[string]KeyDiagnosis)
+	for _, kd := range diagnosis.Keys {
+		keys[kd.Parser] = kd
+	}
+	return keys
+}
+
+func TestDiagnoseWorkspace(t *testing.T) {
+	storageDir := t.TempDir()
+
+	generations := readFixture(t, "generations_multi.json")
+	composers := readFixture(t, "composer_multi.json")
+	legacy := cursortest.WriteWorkspace(t, storageDir, "legacy", map[string]string{
+		"aiService.generations": generations,
+		"composer.composerData": composers,
+	})
+
+	bubbles := filepath.Join(storageDir, "bubbles", "state.vscdb")
+	cursortest.WriteWorkspace(t, storageDir, "bubbles", map[string]string{"unrelated.setting": "{}"})
+	cursortest.WriteDiskKV(t, bubbles, bubbleFixtureRows(t))
+
+	drifted := cursortest.WriteWorkspace(t, storageDir, "drifted", map[string]string{
+		"aiService.prompts":       "not json",
+		"aiService.conversations": "[]",
+		"chatV3:composer-1":       "{}",
+		"editor.fontSize":         "14",
+	})
+
+	reader := NewWorkspaceReaderWithPath(storageDir)
+	diagnoses, err := reader.Diagnose()
+	if err != nil {
+		t.Fatalf("Failed to diagnose workspaces: %v", err)
+	}
+	if len(diagnoses) != 3 {
+		t.Fatalf("Expected 3 workspaces, got %d", len(diagnoses))
+	}
+
+	byPath := make(map[string]WorkspaceDiagnosis)
+	for _, diagnosis := range diagnoses {
+		if diagnosis.Error != "" {
+			t.Fatalf("Unexpected error for %s: %s", diagnosis.Path, diagnosis.Error)
+		}
+		if len(diagnosis.Keys) != len(chatKeys) {
+			t.Errorf("Expected an entry for each of %d known keys, got %d", len(chatKeys), len(diagnosis.Keys))
+		}
+		byPath[diagnosis.Path] = diagnosis
+	}
+
+	t.Run("legacy", func(t *testing.T) {
+		keys := keyDiagnoses(byPath[legacy])
+		gen := keys["generations"]
+		if !gen.Found() || gen.Bytes != len(generations) || gen.Chats == 0 {
+			t.Errorf("Expected generations to be found and parsed, got %+v", gen)
+		}
+		if composer := keys["composer"]; !composer.Found() || composer.Bytes != len(composers) {
+			t.Errorf("Expected composer data to be found, got %+v", composer)
+		}
+		if keys["bubbles"].Found() || keys["prompts"].Found() {
+			t.Errorf("Expected no bubbles or prompts, got %+v", keys)
+		}
+	})
+
+	t.Run("bubbles", func(t *testing.T) {
+		keys := keyDiagnoses(byPath[bubbles])
+		b := keys["bubbles"]
+		if b.Rows != len(bubbleFixtureRows(t)) || b.Chats != 2 {
+			t.Errorf("Expected every bubble row and both chats, got %+v", b)
+		}
+		if len(byPath[bubbles].UnknownKeys) != 0 {
+			t.Errorf("Expected no unknown keys, got %v", byPath[bubbles].UnknownKeys)
+		}
+	})
+
+	t.Run("drifted", func(t *testing.T) {
+		diagnosis := byPath[drifted]
+		prompts := keyDiagnoses(diagnosis)["prompts"]
+		if !prompts.Found() || prompts.Error == "" || prompts.Chats != 0 {
+			t.Errorf("Expected a parse error for malformed prompts, got %+v", prompts)
+		}
+		want := []string{"aiService.conversations", "chatV3:composer-1"}
+		if !reflect.DeepEqual(diagnosis.UnknownKeys, want) {
+			t.Errorf("Expected unknown keys %v, got %v", want, diagnosis.UnknownKeys)
+		}
+	})
+}
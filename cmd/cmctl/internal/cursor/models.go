@@ -1,6 +1,7 @@
 package cursor
 
 import (
+	"strings"
 	"time"
 )
 
@@ -28,6 +29,17 @@ type ChatTab struct {
 	Timestamp int64     `json:"timestamp"`
 	CreatedAt time.Time `json:"createdAt,omitempty"`
 	UpdatedAt time.Time `json:"updatedAt,omitempty"`
+
+	// ConversationID is Cursor's ID for the conversation, when the chat's
+	// source records one
+	ConversationID string `json:"conversationId,omitempty"`
+
+	// PreviousIDs are IDs older releases gave the same chat, so chats
+	// imported under them are still recognized
+	PreviousIDs []string `json:"previousIds,omitempty"`
+
+	// Source identifies the editor the chat was read from; empty means Cursor
+	Source string `json:"source,omitempty"`
 }
 
 // Message represents a single message in the chat
@@ -37,6 +49,44 @@ type Message struct {
 	Content   string    `json:"content"`
 	Timestamp int64     `json:"timestamp"`
 	CreatedAt time.Time `json:"createdAt,omitempty"`
+
+	// RoleConfidence is how certain the parser is about Role, from 0 to 1.
+	// Zero means the role was not assessed (e.g. it came from a format that
+	// always records roles).
+	RoleConfidence float64 `json:"roleConfidence,omitempty"`
+
+	// Blocks is the message split into prose, code and file references,
+	// when its source records that structure. Content still holds the flat
+	// text.
+	Blocks []ContentBlock `json:"blocks,omitempty"`
+}
+
+// Content block types
+const (
+	ContentBlockText = "text"
+	ContentBlockCode = "code"
+	ContentBlockFile = "file"
+)
+
+// ContentBlock is one structured part of a message
+type ContentBlock struct {
+	Type string `json:"type"` // ContentBlockText, ContentBlockCode or ContentBlockFile
+	// Text is the prose or code; empty for file references
+	Text string `json:"text,omitempty"`
+	// Language is the normalized language of a code block
+	Language string `json:"language,omitempty"`
+	// Path is the file a code block belongs to, or a file reference
+	Path string `json:"path,omitempty"`
+}
+
+// LowConfidenceRoleThreshold is the confidence below which an inferred role
+// should be treated as a guess
+const LowConfidenceRoleThreshold = 0.65
+
+// HasLowConfidenceRole reports whether the message role was inferred with
+// low confidence
+func (m Message) HasLowConfidenceRole() bool {
+	return m.RoleConfidence > 0 && m.RoleConfidence < LowConfidenceRoleThreshold
 }
 
 // GetDisplayTitle returns a human-readable title for the chat tab
@@ -58,6 +108,30 @@ func (ct *ChatTab) GetDisplayTitle() string {
 	return "Untitled Chat"
 }
 
+// RealMessageCount returns the number of user and assistant messages with
+// content, ignoring system placeholders
+func (ct *ChatTab) RealMessageCount() int {
+	count := 0
+	for _, msg := range ct.Messages {
+		if (msg.Role == "user" || msg.Role == "assistant") && strings.TrimSpace(msg.Content) != "" {
+			count++
+		}
+	}
+	return count
+}
+
+// LowConfidenceRoleCount returns the number of messages whose role was
+// inferred with low confidence
+func (ct *ChatTab) LowConfidenceRoleCount() int {
+	count := 0
+	for _, msg := range ct.Messages {
+		if msg.HasLowConfidenceRole() {
+			count++
+		}
+	}
+	return count
+}
+
 // GetContentPreview returns a preview of the chat content
 func (ct *ChatTab) GetContentPreview(maxLength int) string {
 	content := ""
@@ -75,8 +149,46 @@ func (ct *ChatTab) GetContentPreview(maxLength int) string {
 	return content
 }
 
+// GetTurnPreviews returns a one-line preview of each of the first n
+// non-empty user and assistant messages, labelled with their role.
+// Whitespace is collapsed and each line is cut to maxLength characters.
+func (ct *ChatTab) GetTurnPreviews(n, maxLength int) []string {
+	var previews []string
+	for _, msg := range ct.Messages {
+		if len(previews) >= n {
+			break
+		}
+		text := strings.Join(strings.Fields(msg.Content), " ")
+		if text == "" || msg.Role == "system" {
+			continue
+		}
+		speaker := "Assistant: "
+		if msg.Role == "user" {
+			speaker = "User: "
+		}
+		line := []rune(speaker + text)
+		if len(line) > maxLength {
+			line = append(line[:max(maxLength-3, 0)], []rune("...")...)
+		}
+		previews = append(previews, string(line))
+	}
+	return previews
+}
+
 // ToMarkdown converts the chat tab to markdown format
 func (ct *ChatTab) ToMarkdown() string {
+	return ct.toMarkdown(false)
+}
+
+// ToMarkdownWithTimestamps converts the chat tab to markdown format, tagging
+// each message with its local time. Messages without a timestamp are
+// rendered as in ToMarkdown.
+func (ct *ChatTab) ToMarkdownWithTimestamps() string {
+	return ct.toMarkdown(true)
+}
+
+// toMarkdown renders the chat, optionally with per-message timestamps
+func (ct *ChatTab) toMarkdown(withTimestamps bool) string {
 	md := "# " + ct.GetDisplayTitle() + "\n\n"
 
 	if ct.CreatedAt.IsZero() && ct.Timestamp > 0 {
@@ -88,13 +200,29 @@ func (ct *ChatTab) ToMarkdown() string {
 	}
 
 	for _, msg := range ct.Messages {
+		var speaker string
 		switch msg.Role {
 		case "user":
-			md += "**User**: " + msg.Content + "\n\n"
+			speaker = "**User**"
 		case "assistant":
-			md += "**Assistant**: " + msg.Content + "\n\n"
+			speaker = "**Assistant**"
 		default:
-			md += "**" + msg.Role + "**: " + msg.Content + "\n\n"
+			speaker = "**" + msg.Role + "**"
+		}
+
+		if withTimestamps && msg.Timestamp > 0 {
+			speaker += " _(" + time.UnixMilli(msg.Timestamp).Format("2006-01-02 15:04:05") + ")_"
+		}
+
+		content := msg.Content
+		if len(msg.Blocks) > 0 {
+			content = renderBlocks(msg.Blocks)
+		}
+		// A fence has to start its own line
+		if strings.HasPrefix(content, "```") || strings.HasPrefix(content, "~~~") {
+			md += speaker + ":\n" + content + "\n\n"
+		} else {
+			md += speaker + ": " + content + "\n\n"
 		}
 	}
 
@@ -1,6 +1,7 @@
 package cursor
 
 import (
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -131,6 +132,42 @@ func TestChatTabToMarkdown(t *testing.T) {
 	}
 }
 
+func TestChatTabToMarkdownWithTimestamps(t *testing.T) {
+	sent := time.Date(2025, 9, 20, 14, 3, 22, 0, time.Local)
+	chat := ChatTab{
+		Title: "Test Chat",
+		Messages: []Message{
+			{Role: "user", Content: "What time is it?", Timestamp: sent.UnixMilli()},
+			{Role: "assistant", Content: "No timestamp here."},
+		},
+	}
+
+	plain := chat.ToMarkdown()
+	timed := chat.ToMarkdownWithTimestamps()
+
+	stamped := "**User** _(2025-09-20 14:03:22)_: What time is it?"
+	if !strings.Contains(timed, stamped) {
+		t.Errorf("Expected timestamped user turn %q, got: %s", stamped, timed)
+	}
+	if strings.Contains(plain, "14:03:22") {
+		t.Errorf("ToMarkdown should not include message timestamps, got: %s", plain)
+	}
+
+	// Zero timestamps are omitted rather than rendered as the epoch
+	if !strings.Contains(timed, "**Assistant**: No timestamp here.") {
+		t.Errorf("Expected untimed assistant turn, got: %s", timed)
+	}
+	if strings.Contains(timed, "1970") {
+		t.Errorf("Zero timestamps should not be rendered, got: %s", timed)
+	}
+
+	// Without any message timestamps both renderings match
+	untimed := ChatTab{Title: "Test Chat", Messages: []Message{{Role: "user", Content: "Hi"}}}
+	if untimed.ToMarkdown() != untimed.ToMarkdownWithTimestamps() {
+		t.Error("Expected identical output when no message has a timestamp")
+	}
+}
+
 func TestChatTabExtractTechnicalConcepts(t *testing.T) {
 	chat := ChatTab{
 		Messages: []Message{
@@ -181,3 +218,54 @@ func TestMessageTimestampParsing(t *testing.T) {
 	// Test passes if we get here without panics
 	_ = now // Use the variable to avoid unused warning
 }
+
+func TestChatTabGetTurnPreviews(t *testing.T) {
+	chat := ChatTab{
+		Messages: []Message{
+			{Role: "system", Content: "Composer session"},
+			{Role: "user", Content: "Why does the\n\n  build fail?"},
+			{Role: "assistant", Content: "   "},
+			{Role: "assistant", Content: "The linker can't find libssl, which is installed under /opt on this machine."},
+			{Role: "user", Content: "Thanks ✓"},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		n         int
+		maxLength int
+		expected  []string
+	}{
+		{
+			name:      "First turn",
+			n:         1,
+			maxLength: 80,
+			expected:  []string{"User: Why does the build fail?"},
+		},
+		{
+			name:      "Several turns, truncated",
+			n:         2,
+			maxLength: 30,
+			expected:  []string{"User: Why does the build fail?", "Assistant: The linker can't..."},
+		},
+		{
+			name:      "More turns than the chat has",
+			n:         10,
+			maxLength: 80,
+			expected: []string{
+				"User: Why does the build fail?",
+				"Assistant: The linker can't find libssl, which is installed under /opt on thi...",
+				"User: Thanks ✓",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := chat.GetTurnPreviews(tt.n, tt.maxLength)
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("Expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
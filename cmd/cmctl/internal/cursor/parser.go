@@ -1,6 +1,8 @@
 package cursor
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"sort"
@@ -12,11 +14,13 @@ import (
 
 // AIServicePrompt represents the structure of aiService.prompts data
 type AIServicePrompt struct {
-	Text      string    `json:"text"`
-	Timestamp int64     `json:"timestamp,omitempty"`
-	ID        string    `json:"id,omitempty"`
-	Role      string    `json:"role,omitempty"`
-	CreatedAt time.Time `json:"createdAt,omitempty"`
+	Text           string    `json:"text"`
+	Timestamp      int64     `json:"timestamp,omitempty"`
+	ID             string    `json:"id,omitempty"`
+	Role           string    `json:"role,omitempty"`
+	CreatedAt      time.Time `json:"createdAt,omitempty"`
+	ComposerID     string    `json:"composerId,omitempty"`
+	ConversationID string    `json:"conversationId,omitempty"`
 }
 
 // ComposerData represents the structure of composer.composerData
@@ -45,8 +49,57 @@ type AIServiceGeneration struct {
 	Role            string `json:"role,omitempty"`
 }
 
+// composerTitleIndex maps composer/conversation IDs to user-set chat titles
+// from composer.composerData
+type composerTitleIndex struct {
+	byID   map[string]string
+	latest string // Title of the most recently created composer
+}
+
+// newComposerTitleIndex builds a title index from composer entries
+func newComposerTitleIndex(composers []ComposerEntry) composerTitleIndex {
+	index := composerTitleIndex{byID: make(map[string]string)}
+	var latestCreatedAt int64
+	for _, composer := range composers {
+		if composer.Name == "" {
+			continue
+		}
+		index.byID[composer.ComposerID] = composer.Name
+		if index.latest == "" || composer.CreatedAt > latestCreatedAt {
+			index.latest = composer.Name
+			latestCreatedAt = composer.CreatedAt
+		}
+	}
+	return index
+}
+
+// lookup returns the title for the first ID that matches a composer
+func (idx composerTitleIndex) lookup(ids ...string) (string, bool) {
+	for _, id := range ids {
+		if id == "" {
+			continue
+		}
+		if title, ok := idx.byID[id]; ok {
+			return title, true
+		}
+	}
+	return "", false
+}
+
+// only returns the title when exactly one composer is known, which is the
+// only case where a title can be assigned without ID correlation
+func (idx composerTitleIndex) only() (string, bool) {
+	if len(idx.byID) != 1 {
+		return "", false
+	}
+	for _, title := range idx.byID {
+		return title, true
+	}
+	return "", false
+}
+
 // parseAIServicePromptsWithTitles converts aiService.prompts data to ChatTab format with composer titles
-func (wr *WorkspaceReader) parseAIServicePromptsWithTitles(value string, composerTitles map[string]string) ([]ChatTab, error) {
+func (wr *WorkspaceReader) parseAIServicePromptsWithTitles(value string, titles composerTitleIndex) ([]ChatTab, error) {
 	chatTab, err := wr.parseAIServicePromptsToSingleChat(value)
 	if err != nil {
 		return nil, err
@@ -57,29 +110,35 @@ func (wr *WorkspaceReader) parseAIServicePromptsWithTitles(value string, compose
 		return []ChatTab{}, nil
 	}
 
-	// Try to match with composer title
-	// If there's only one composer title, use it for this chat
-	if len(composerTitles) == 1 {
-		for _, title := range composerTitles {
-			chatTab.Title = title
-			break
-		}
-	} else if len(composerTitles) > 1 {
-		// Multiple titles available - for now, use the most recently created one
-		// This is a reasonable assumption for the "latest" chat
-		for _, title := range composerTitles {
-			// We could add more sophisticated matching logic here
-			// For now, prefer any non-empty title
-			if chatTab.Title == "AI Service Chat" && title != "" {
-				chatTab.Title = title
-				break
-			}
-		}
+	// Match the composer title by ID when the prompts carry one; otherwise
+	// fall back to heuristics. Prompts are collapsed into a single "latest"
+	// chat, so the most recently created composer is the best guess.
+	if title, ok := titles.lookup(promptComposerIDs(value)...); ok {
+		chatTab.Title = title
+	} else if title, ok := titles.only(); ok {
+		chatTab.Title = title
+	} else if titles.latest != "" {
+		chatTab.Title = titles.latest
 	}
 
 	return []ChatTab{*chatTab}, nil
 }
 
+// promptComposerIDs returns the composer/conversation IDs referenced by
+// aiService.prompts entries, most recent prompt first
+func promptComposerIDs(value string) []string {
+	var prompts []AIServicePrompt
+	if err := json.Unmarshal([]byte(value), &prompts); err != nil {
+		return nil
+	}
+
+	var ids []string
+	for i := len(prompts) - 1; i >= 0; i-- {
+		ids = append(ids, prompts[i].ComposerID, prompts[i].ConversationID)
+	}
+	return ids
+}
+
 // parseAIServicePromptsToSingleChat is the core logic for parsing aiService.prompts
 func (wr *WorkspaceReader) parseAIServicePromptsToSingleChat(value string) (*ChatTab, error) {
 	// Try to parse as array of prompts
@@ -99,25 +158,8 @@ func (wr *WorkspaceReader) parseAIServicePromptsToSingleChat(value string) (*Cha
 
 	// Convert to ChatTab format
 	var messages []Message
+	var explicitRoles []string
 	for i, prompt := range prompts {
-		// Determine role - prioritize explicit role, then use smarter heuristics
-		role := "user" // default
-		if prompt.Role != "" {
-			role = prompt.Role
-		} else {
-			// Improved role detection: look at content patterns
-			content := prompt.Text
-			if len(content) > 0 {
-				// Look for assistant-like responses (longer, explanatory)
-				if len(content) > 200 || containsAssistantMarkers(content) {
-					role = "assistant"
-				} else {
-					// Shorter content typically from user
-					role = "user"
-				}
-			}
-		}
-
 		timestamp := prompt.Timestamp
 		if timestamp == 0 && !prompt.CreatedAt.IsZero() {
 			timestamp = prompt.CreatedAt.Unix() * 1000
@@ -128,20 +170,44 @@ func (wr *WorkspaceReader) parseAIServicePromptsToSingleChat(value string) (*Cha
 
 		message := Message{
 			ID:        fmt.Sprintf("prompt-%d", i),
-			Role:      role,
 			Content:   prompt.Text,
 			Timestamp: timestamp,
 		}
 		messages = append(messages, message)
+		explicitRoles = append(explicitRoles, prompt.Role)
+	}
+
+	// Prefer explicit roles, falling back to content and turn-order heuristics
+	resolveRoles(messages, explicitRoles)
+
+	// Key the chat on the composer the prompts belong to, or failing that on
+	// the first prompt, so it keeps its ID across reads
+	var conversationID string
+	for _, prompt := range prompts {
+		if conversationID = prompt.ComposerID; conversationID == "" {
+			conversationID = prompt.ConversationID
+		}
+		if conversationID != "" {
+			break
+		}
+	}
+	key := conversationID
+	if key == "" {
+		key = prompts[0].ID
+	}
+	if key == "" {
+		first := prompts[0]
+		key = contentHashID(fmt.Sprintf("%s\x00%d\x00%s", first.Text, first.Timestamp, first.CreatedAt.Format(time.RFC3339Nano)))
 	}
 
 	// Create a single chat tab from all prompts
 	chatTab := &ChatTab{
-		ID:        fmt.Sprintf("ai-service-%d", time.Now().Unix()),
-		Title:     "AI Service Chat",
-		Messages:  messages,
-		Timestamp: time.Now().Unix() * 1000,
-		CreatedAt: time.Now(),
+		ID:             "ai-service-" + key,
+		ConversationID: conversationID,
+		Title:          "AI Service Chat",
+		Messages:       messages,
+		Timestamp:      time.Now().Unix() * 1000,
+		CreatedAt:      time.Now(),
 	}
 
 	return chatTab, nil
@@ -170,18 +236,19 @@ func (wr *WorkspaceReader) parseComposerData(value string) ([]ChatTab, error) {
 		}
 
 		chatTab := ChatTab{
-			ID:        composer.ComposerID,
-			Title:     title,
-			Messages:  composer.Messages, // May be empty, that's ok
-			Timestamp: composer.CreatedAt,
-			CreatedAt: time.Unix(composer.CreatedAt/1000, 0),
+			ID:             composer.ComposerID,
+			Title:          title,
+			Messages:       composer.Messages, // May be empty, that's ok
+			Timestamp:      composer.CreatedAt,
+			CreatedAt:      time.Unix(composer.CreatedAt/1000, 0),
+			ConversationID: composer.ComposerID,
 		}
 
 		// If no messages but we have composer data, create a placeholder
 		if len(chatTab.Messages) == 0 {
 			chatTab.Messages = []Message{
 				{
-					ID:        "composer-info",
+					ID:        composerPlaceholderID,
 					Role:      "system",
 					Content:   fmt.Sprintf("Composer session: %s mode, created at %s", composer.UnifiedMode, chatTab.CreatedAt.Format("2006-01-02 15:04:05")),
 					Timestamp: composer.CreatedAt,
@@ -195,8 +262,17 @@ func (wr *WorkspaceReader) parseComposerData(value string) ([]ChatTab, error) {
 	return chatTabs, nil
 }
 
+// composerPlaceholderID is the message ID of the placeholder given to
+// composer chats whose messages aren't in composer.composerData
+const composerPlaceholderID = "composer-info"
+
+// isComposerPlaceholder reports whether tab holds only a composer placeholder
+func isComposerPlaceholder(tab ChatTab) bool {
+	return len(tab.Messages) == 1 && tab.Messages[0].ID == composerPlaceholderID
+}
+
 // parseAIServiceGenerations converts aiService.generations to ChatTab format (richer data source)
-func (wr *WorkspaceReader) parseAIServiceGenerations(value string, composerTitles map[string]string) ([]ChatTab, error) {
+func (wr *WorkspaceReader) parseAIServiceGenerations(value string, titles composerTitleIndex) ([]ChatTab, error) {
 	var generations []AIServiceGeneration
 	if err := json.Unmarshal([]byte(value), &generations); err != nil {
 		return nil, fmt.Errorf("failed to parse AI service generations: %w", err)
@@ -219,57 +295,76 @@ func (wr *WorkspaceReader) parseAIServiceGenerations(value string, composerTitle
 		}
 	}
 
-	var chatTabs []ChatTab
+	conversations := make([][]AIServiceGeneration, 0, len(conversationMap))
 	for _, convGenerations := range conversationMap {
-		if len(convGenerations) == 0 {
-			continue
-		}
-
 		// Sort generations by timestamp
 		sort.Slice(convGenerations, func(i, j int) bool {
 			return convGenerations[i].UnixMs < convGenerations[j].UnixMs
 		})
+		conversations = append(conversations, convGenerations)
+	}
+	if !wr.NoMerge {
+		conversations = threadGenerations(conversations, ThreadWindow)
+	}
+
+	var chatTabs []ChatTab
+	for _, convGenerations := range conversations {
+		if len(convGenerations) == 0 {
+			continue
+		}
 
 		// Extract full conversation from textDescription fields
 		var messages []Message
-		conversationContent := ""
+		var explicitRoles []string
 
-		for i, gen := range convGenerations {
+		for _, gen := range convGenerations {
 			if gen.TextDescription != "" {
-				conversationContent += gen.TextDescription + "\n\n"
-
 				// Create message from generation
 				message := Message{
 					ID:        gen.GenerationUUID,
-					Role:      determineRoleFromContent(gen.TextDescription, i),
 					Content:   gen.TextDescription,
 					Timestamp: gen.UnixMs,
 					CreatedAt: time.Unix(gen.UnixMs/1000, 0),
 				}
 				messages = append(messages, message)
+				explicitRoles = append(explicitRoles, gen.Role)
 			}
 		}
 
+		// Prefer explicit roles, falling back to content and turn-order heuristics
+		resolveRoles(messages, explicitRoles)
+
 		if len(messages) == 0 {
 			continue
 		}
 
-		// Get title from composer titles or generate from content
+		// Match the composer title by conversation ID, only falling back to
+		// the sole known title when there is no ID correlation
+		conversationID := generationsConversationID(convGenerations)
 		title := "AI Service Chat"
-		if len(composerTitles) == 1 {
-			for _, t := range composerTitles {
-				title = t
-				break
-			}
+		if t, ok := titles.lookup(conversationID); ok {
+			title = t
+		} else if t, ok := titles.only(); ok {
+			title = t
 		}
 
-		// Create chat tab
+		// Key the chat on Cursor's own identifiers so it keeps its ID
+		// across reads
+		key := conversationID
+		if key == "" {
+			key = convGenerations[0].GenerationUUID
+		}
+		if key == "" {
+			key = contentHashID(messages[0].Content)
+		}
 		chatTab := ChatTab{
-			ID:        fmt.Sprintf("generations-%d", convGenerations[0].UnixMs),
-			Title:     title,
-			Messages:  messages,
-			Timestamp: convGenerations[len(convGenerations)-1].UnixMs,
-			CreatedAt: time.Unix(convGenerations[0].UnixMs/1000, 0),
+			ID:             "generations-" + key,
+			Title:          title,
+			Messages:       messages,
+			Timestamp:      convGenerations[len(convGenerations)-1].UnixMs,
+			CreatedAt:      time.Unix(convGenerations[0].UnixMs/1000, 0),
+			ConversationID: conversationID,
+			PreviousIDs:    []string{fmt.Sprintf("generations-%d", convGenerations[0].UnixMs)},
 		}
 
 		chatTabs = append(chatTabs, chatTab)
@@ -278,53 +373,60 @@ func (wr *WorkspaceReader) parseAIServiceGenerations(value string, composerTitle
 	return chatTabs, nil
 }
 
-// determineRoleFromContent uses content analysis to determine if content is from user or assistant
-func determineRoleFromContent(content string, index int) string {
-	// Look for clear indicators of assistant responses
-	if containsAssistantMarkers(content) {
-		return "assistant"
-	}
-
-	// Look for user-like patterns (questions, short requests, file references)
-	if containsUserMarkers(content) {
-		return "user"
-	}
-
-	// Fallback: longer content is typically assistant, shorter is user
-	if len(content) > 300 {
-		return "assistant"
+// ThreadWindow is the longest gap between two parts of a conversation that
+// are threaded together on timing alone
+const ThreadWindow = 10 * time.Minute
+
+// threadGenerations joins conversation parts that Cursor recorded
+// separately, such as generations written without a conversation ID after a
+// restart, into whole conversations ordered by time. A part without a
+// conversation ID joins the conversation before it when it starts within
+// window of that conversation's last generation. Parts with different IDs
+// are never joined, since Cursor tracked them as separate chats. Each part
+// must be sorted by time.
+func threadGenerations(parts [][]AIServiceGeneration, window time.Duration) [][]AIServiceGeneration {
+	sort.SliceStable(parts, func(i, j int) bool {
+		return parts[i][0].UnixMs < parts[j][0].UnixMs
+	})
+
+	var threads [][]AIServiceGeneration
+	for _, part := range parts {
+		if len(threads) > 0 {
+			last := len(threads) - 1
+			prev := threads[last]
+			gap := time.Duration(part[0].UnixMs-prev[len(prev)-1].UnixMs) * time.Millisecond
+			if gap <= window && (generationsConversationID(part) == "" || generationsConversationID(prev) == "") {
+				thread := append(prev, part...)
+				sort.SliceStable(thread, func(i, j int) bool {
+					return thread[i].UnixMs < thread[j].UnixMs
+				})
+				threads[last] = thread
+				continue
+			}
+		}
+		threads = append(threads, part)
 	}
-
-	return "user"
+	return threads
 }
 
-// containsUserMarkers checks if content looks like user input
-func containsUserMarkers(content string) bool {
-	userMarkers := []string{
-		"@", "?", "Can you", "How do I", "What is", "Please", "I want", "I need",
-		"Let's", "Could you", "Would you", "Show me", "Help me", "I'm trying",
-	}
-
-	for _, marker := range userMarkers {
-		if containsIgnoreCase(content, marker) {
-			return true
+// generationsConversationID returns the first conversation ID recorded in
+// generations, or "" if none has one
+func generationsConversationID(generations []AIServiceGeneration) string {
+	for _, gen := range generations {
+		if gen.ConversationID != "" {
+			return gen.ConversationID
 		}
 	}
-	return false
+	return ""
 }
 
-// containsAssistantMarkers checks if content looks like assistant response
-func containsAssistantMarkers(content string) bool {
-	assistantMarkers := []string{
-		"I'll", "I can", "Let me", "Here's", "You can", "This will",
-		"```", "Here are", "To do this", "First,", "Next,", "Finally,",
-		"## ", "### ", "**", "- [", "1. ", "2. ", "3. ",
-	}
+// contentHashIDLength is how many hex digits of a content hash make up a
+// chat ID
+const contentHashIDLength = 16
 
-	for _, marker := range assistantMarkers {
-		if containsIgnoreCase(content, marker) {
-			return true
-		}
-	}
-	return false
+// contentHashID returns a stable ID derived from content, for chats whose
+// source records no identifier
+func contentHashID(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])[:contentHashIDLength]
 }
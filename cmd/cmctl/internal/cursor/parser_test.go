@@ -0,0 +1,335 @@
+package cursor
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/cursor/cursortest"
+)
+
+func readFixture(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("Failed to read fixture %s: %v", name, err)
+	}
+	return string(data)
+}
+
+// chatsByFirstMessage indexes parsed chats by their first message ID so
+// tests don't depend on map iteration order
+func chatsByFirstMessage(tabs []ChatTab) map[string]ChatTab {
+	result := make(map[string]ChatTab)
+	for _, tab := range tabs {
+		if len(tab.Messages) > 0 {
+			result[tab.Messages[0].ID] = tab
+		}
+	}
+	return result
+}
+
+func TestParseAIServiceGenerationsRoles(t *testing.T) {
+	wr := NewWorkspaceReaderWithPath(t.TempDir())
+	tabs, err := wr.parseAIServiceGenerations(readFixture(t, "generations_roles.json"), composerTitleIndex{})
+	if err != nil {
+		t.Fatalf("parseAIServiceGenerations failed: %v", err)
+	}
+
+	chats := chatsByFirstMessage(tabs)
+	if len(chats) != 2 {
+		t.Fatalf("Expected 2 conversations, got %d", len(chats))
+	}
+
+	tests := []struct {
+		name      string
+		firstID   string
+		wantRoles []string
+		wantLow   []bool
+	}{
+		{
+			// Explicit role fields win even when content suggests otherwise
+			name:      "explicit roles",
+			firstID:   "gen-1",
+			wantRoles: []string{"user", "assistant", "user"},
+			wantLow:   []bool{false, false, false},
+		},
+		{
+			// Without role fields, content and turn order decide; terse
+			// replies are attributed by alternation with low confidence
+			name:      "inferred roles",
+			firstID:   "gen-4",
+			wantRoles: []string{"user", "assistant", "user", "assistant"},
+			wantLow:   []bool{false, false, false, true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chat, ok := chats[tt.firstID]
+			if !ok {
+				t.Fatalf("Conversation starting with %s not found", tt.firstID)
+			}
+			if len(chat.Messages) != len(tt.wantRoles) {
+				t.Fatalf("Expected %d messages, got %d", len(tt.wantRoles), len(chat.Messages))
+			}
+			for i, msg := range chat.Messages {
+				if msg.Role != tt.wantRoles[i] {
+					t.Errorf("Message %s: expected role %s, got %s (confidence %.2f)", msg.ID, tt.wantRoles[i], msg.Role, msg.RoleConfidence)
+				}
+				if msg.HasLowConfidenceRole() != tt.wantLow[i] {
+					t.Errorf("Message %s: expected low confidence=%v, got confidence %.2f", msg.ID, tt.wantLow[i], msg.RoleConfidence)
+				}
+			}
+		})
+	}
+}
+
+func TestNormalizeRole(t *testing.T) {
+	tests := []struct {
+		raw    string
+		want   string
+		wantOK bool
+	}{
+		{"user", "user", true},
+		{"Human", "user", true},
+		{"assistant", "assistant", true},
+		{"AI", "assistant", true},
+		{"bot", "assistant", true},
+		{"system", "system", true},
+		{"", "", false},
+		{"narrator", "", false},
+	}
+
+	for _, tt := range tests {
+		role, ok := normalizeRole(tt.raw)
+		if role != tt.want || ok != tt.wantOK {
+			t.Errorf("normalizeRole(%q) = %q, %v; want %q, %v", tt.raw, role, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestGetChatDataCorrelatesComposerTitlesByID(t *testing.T) {
+	storageDir := t.TempDir()
+	dbPath := cursortest.WriteWorkspace(t, storageDir, "workspace", map[string]string{
+		"composer.composerData": readFixture(t, "composer_multi.json"),
+		"aiService.generations": readFixture(t, "generations_multi.json"),
+	})
+
+	chatData, err := NewWorkspaceReaderWithPath(storageDir).GetChatData(dbPath)
+	if err != nil {
+		t.Fatalf("GetChatData failed: %v", err)
+	}
+
+	// Generation-based chats are keyed by their first message
+	chats := chatsByFirstMessage(chatData.Tabs)
+	expected := map[string]string{
+		"gen-auth-1":   "Fix OAuth refresh flow",
+		"gen-index-1":  "Index rebuild performance",
+		"gen-orphan-1": "AI Service Chat", // No ID correlation and several composers
+	}
+	for firstID, wantTitle := range expected {
+		chat, ok := chats[firstID]
+		if !ok {
+			t.Errorf("Chat starting with %s not found", firstID)
+			continue
+		}
+		if chat.Title != wantTitle {
+			t.Errorf("Chat %s: expected title %q, got %q", firstID, wantTitle, chat.Title)
+		}
+	}
+}
+
+func TestParseAIServicePromptsWithTitlesByComposerID(t *testing.T) {
+	wr := NewWorkspaceReaderWithPath(t.TempDir())
+	titles := newComposerTitleIndex([]ComposerEntry{
+		{ComposerID: "composer-a", Name: "First chat", CreatedAt: 1},
+		{ComposerID: "composer-b", Name: "Second chat", CreatedAt: 2},
+	})
+
+	tabs, err := wr.parseAIServicePromptsWithTitles(`[{"text": "hello", "composerId": "composer-a"}]`, titles)
+	if err != nil {
+		t.Fatalf("parseAIServicePromptsWithTitles failed: %v", err)
+	}
+	if len(tabs) != 1 || tabs[0].Title != "First chat" {
+		t.Errorf("Expected title matched by composer ID, got %+v", tabs)
+	}
+
+	// Without an ID, the most recently created composer is the best guess
+	tabs, err = wr.parseAIServicePromptsWithTitles(`[{"text": "hello"}]`, titles)
+	if err != nil {
+		t.Fatalf("parseAIServicePromptsWithTitles failed: %v", err)
+	}
+	if len(tabs) != 1 || tabs[0].Title != "Second chat" {
+		t.Errorf("Expected latest composer title, got %+v", tabs)
+	}
+}
+
+// messageIDs returns the IDs of a chat's messages in order
+func messageIDs(tab ChatTab) []string {
+	ids := make([]string, len(tab.Messages))
+	for i, message := range tab.Messages {
+		ids[i] = message.ID
+	}
+	return ids
+}
+
+func TestParseAIServiceGenerationsThreadsSplitConversations(t *testing.T) {
+	tests := []struct {
+		name     string
+		noMerge  bool
+		expected map[string][]string // First message ID -> message IDs
+	}{
+		{
+			name: "merged",
+			expected: map[string][]string{
+				// Parts without an ID written within the window continue the chat
+				"gen-auth-1": {"gen-auth-1", "gen-auth-2", "gen-auth-3", "gen-auth-4"},
+				// A different conversation ID is never merged, however close
+				"gen-index-1": {"gen-index-1"},
+				// Too long after the previous chat to be a continuation
+				"gen-later-1": {"gen-later-1"},
+			},
+		},
+		{
+			name:    "no merge",
+			noMerge: true,
+			expected: map[string][]string{
+				"gen-auth-1":  {"gen-auth-1", "gen-auth-2"},
+				"gen-auth-3":  {"gen-auth-3"},
+				"gen-auth-4":  {"gen-auth-4"},
+				"gen-index-1": {"gen-index-1"},
+				"gen-later-1": {"gen-later-1"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wr := NewWorkspaceReaderWithPath(t.TempDir())
+			wr.NoMerge = tt.noMerge
+			tabs, err := wr.parseAIServiceGenerations(readFixture(t, "generations_split.json"), composerTitleIndex{})
+			if err != nil {
+				t.Fatalf("parseAIServiceGenerations failed: %v", err)
+			}
+			if len(tabs) != len(tt.expected) {
+				t.Fatalf("Expected %d chats, got %d", len(tt.expected), len(tabs))
+			}
+
+			chats := chatsByFirstMessage(tabs)
+			for firstID, wantIDs := range tt.expected {
+				chat, ok := chats[firstID]
+				if !ok {
+					t.Errorf("Chat starting with %s not found", firstID)
+					continue
+				}
+				if got := messageIDs(chat); !reflect.DeepEqual(got, wantIDs) {
+					t.Errorf("Chat %s: expected messages %v, got %v", firstID, wantIDs, got)
+				}
+			}
+
+			if !tt.noMerge {
+				auth := chats["gen-auth-1"]
+				if auth.ConversationID != "composer-auth" {
+					t.Errorf("Expected merged chat to keep conversation ID composer-auth, got %q", auth.ConversationID)
+				}
+				if auth.Timestamp != 1758540201000 {
+					t.Errorf("Expected merged chat timestamp from its last part, got %d", auth.Timestamp)
+				}
+			}
+		})
+	}
+}
+
+func TestGetChatDataMergesComposerPlaceholders(t *testing.T) {
+	storageDir := t.TempDir()
+	dbPath := cursortest.WriteWorkspace(t, storageDir, "workspace", map[string]string{
+		"composer.composerData": readFixture(t, "composer_multi.json"),
+		"aiService.generations": readFixture(t, "generations_multi.json"),
+	})
+
+	tests := []struct {
+		name        string
+		noMerge     bool
+		expectedIDs []string // Composer chats listed on their own
+	}{
+		// Composers with generations are shown through them
+		{name: "merged", expectedIDs: []string{"composer-docs"}},
+		{name: "no merge", noMerge: true, expectedIDs: []string{"composer-auth", "composer-docs", "composer-index"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wr := NewWorkspaceReaderWithPath(storageDir)
+			wr.NoMerge = tt.noMerge
+			chatData, err := wr.GetChatData(dbPath)
+			if err != nil {
+				t.Fatalf("GetChatData failed: %v", err)
+			}
+
+			var composerIDs []string
+			for _, tab := range chatData.Tabs {
+				if isComposerPlaceholder(tab) {
+					composerIDs = append(composerIDs, tab.ID)
+				}
+			}
+			sort.Strings(composerIDs)
+			if !reflect.DeepEqual(composerIDs, tt.expectedIDs) {
+				t.Errorf("Expected composer chats %v, got %v", tt.expectedIDs, composerIDs)
+			}
+		})
+	}
+}
+
+func TestChatIDsAreStableAcrossParses(t *testing.T) {
+	parse := func() []string {
+		wr := NewWorkspaceReaderWithPath(t.TempDir())
+		generations, err := wr.parseAIServiceGenerations(readFixture(t, "generations_split.json"), composerTitleIndex{})
+		if err != nil {
+			t.Fatalf("parseAIServiceGenerations failed: %v", err)
+		}
+		prompts, err := wr.parseAIServicePromptsWithTitles(`[{"text": "hello", "timestamp": 1758540000000}]`, composerTitleIndex{})
+		if err != nil {
+			t.Fatalf("parseAIServicePromptsWithTitles failed: %v", err)
+		}
+
+		var ids []string
+		for _, tab := range append(generations, prompts...) {
+			ids = append(ids, tab.ID)
+		}
+		sort.Strings(ids)
+		return ids
+	}
+
+	first := parse()
+	if second := parse(); !reflect.DeepEqual(first, second) {
+		t.Errorf("Expected the same IDs from both parses, got %v and %v", first, second)
+	}
+}
+
+func TestChatIDsDeriveFromConversationIdentifiers(t *testing.T) {
+	wr := NewWorkspaceReaderWithPath(t.TempDir())
+	tabs, err := wr.parseAIServiceGenerations(readFixture(t, "generations_split.json"), composerTitleIndex{})
+	if err != nil {
+		t.Fatalf("parseAIServiceGenerations failed: %v", err)
+	}
+	chats := chatsByFirstMessage(tabs)
+
+	auth := chats["gen-auth-1"]
+	if auth.ID != "generations-composer-auth" {
+		t.Errorf("Expected ID from the conversation ID, got %q", auth.ID)
+	}
+	if len(auth.PreviousIDs) != 1 || auth.PreviousIDs[0] != "generations-1758540001000" {
+		t.Errorf("Expected the timestamp-based ID as a previous ID, got %v", auth.PreviousIDs)
+	}
+
+	tabs, err = wr.parseAIServicePromptsWithTitles(`[{"text": "hello"}, {"text": "more", "composerId": "composer-a"}]`, composerTitleIndex{})
+	if err != nil {
+		t.Fatalf("parseAIServicePromptsWithTitles failed: %v", err)
+	}
+	if len(tabs) != 1 || tabs[0].ID != "ai-service-composer-a" {
+		t.Errorf("Expected ID from the composer ID, got %+v", tabs)
+	}
+}
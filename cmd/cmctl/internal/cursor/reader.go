@@ -2,26 +2,61 @@ package cursor
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"sort"
+	"strings"
 
 	"github.com/glebarez/sqlite"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
 
+// ChatReader is implemented by each editor whose chat history can be
+// listed and imported
+type ChatReader interface {
+	// GetLatestChat returns the most recent chat
+	GetLatestChat() (*ChatTab, error)
+	// GetChatByID returns a chat and the workspace it was found in
+	GetChatByID(chatID string) (*ChatTab, string, error)
+	// ListAllChats returns every chat, newest first
+	ListAllChats() ([]ChatTabWithWorkspace, error)
+	// SearchChats returns chats whose title or content contains query
+	SearchChats(query string) ([]ChatTabWithWorkspace, error)
+}
+
+var _ ChatReader = (*WorkspaceReader)(nil)
+
 // WorkspaceReader provides access to Cursor's workspace storage
 type WorkspaceReader struct {
+	// StoragePath, when set, is the only place workspaces are read from
 	StoragePath string
+	// CandidatePaths are the workspaceStorage roots searched when
+	// StoragePath is empty. Workspaces are gathered from every one that
+	// exists, so several Cursor installs can be read at once.
+	CandidatePaths []string
+	// ProjectPath, when set, limits the reader to the workspaces opened on
+	// this project folder
+	ProjectPath string
+	// NoMerge keeps each recorded conversation part a separate chat instead
+	// of threading continued conversations together
+	NoMerge bool
+	// Logger receives notes about workspaces and records that were skipped;
+	// nil means slog's default
+	Logger *slog.Logger
 }
 
-// NewWorkspaceReader creates a new workspace reader
+// NewWorkspaceReader creates a workspace reader for every Cursor install
+// found in the default locations
 func NewWorkspaceReader() *WorkspaceReader {
 	return &WorkspaceReader{
-		StoragePath: getDefaultStoragePath(),
+		CandidatePaths: defaultStoragePaths(),
 	}
 }
 
@@ -32,25 +67,131 @@ func NewWorkspaceReaderWithPath(path string) *WorkspaceReader {
 	}
 }
 
-// getDefaultStoragePath returns the default Cursor workspace storage path
-func getDefaultStoragePath() string {
+// log returns the reader's logger, or slog's default when none is set
+func (wr *WorkspaceReader) log() *slog.Logger {
+	if wr.Logger != nil {
+		return wr.Logger
+	}
+	return slog.Default()
+}
+
+// cursorProducts are the application directory names of Cursor's release
+// channels
+var cursorProducts = []string{"Cursor", "Cursor Nightly", "Cursor - Insiders"}
+
+// portableEnv names the data directory of a portable Cursor install
+const portableEnv = "CURSOR_PORTABLE"
+
+// defaultStoragePaths returns the workspaceStorage roots Cursor may use on
+// this platform
+func defaultStoragePaths() []string {
 	homeDir, _ := os.UserHomeDir()
+	return candidateStoragePaths(runtime.GOOS, homeDir, os.Getenv)
+}
 
-	switch runtime.GOOS {
+// candidateStoragePaths returns the workspaceStorage roots of every Cursor
+// release channel on goos, a portable install's first when $CURSOR_PORTABLE
+// is set. On Linux, $XDG_CONFIG_HOME is searched before ~/.config.
+func candidateStoragePaths(goos, homeDir string, getenv func(string) string) []string {
+	var configDirs []string
+	switch goos {
 	case "darwin":
-		return filepath.Join(homeDir, "Library", "Application Support", "Cursor", "User", "workspaceStorage")
+		configDirs = []string{filepath.Join(homeDir, "Library", "Application Support")}
 	case "windows":
-		return filepath.Join(os.Getenv("APPDATA"), "Cursor", "User", "workspaceStorage")
+		configDirs = []string{getenv("APPDATA")}
 	case "linux":
-		return filepath.Join(homeDir, ".config", "Cursor", "User", "workspaceStorage")
+		configDirs = []string{getenv("XDG_CONFIG_HOME"), filepath.Join(homeDir, ".config")}
 	default:
-		return filepath.Join(homeDir, ".cursor", "workspaceStorage")
+		return []string{filepath.Join(homeDir, ".cursor", "workspaceStorage")}
+	}
+
+	var paths []string
+	if portable := getenv(portableEnv); portable != "" {
+		paths = append(paths, filepath.Join(portable, "user-data", "User", "workspaceStorage"))
+	}
+	for _, dir := range configDirs {
+		if dir == "" {
+			continue
+		}
+		for _, product := range cursorProducts {
+			path := filepath.Join(dir, product, "User", "workspaceStorage")
+			if !slices.Contains(paths, path) {
+				paths = append(paths, path)
+			}
+		}
 	}
+	return paths
 }
 
-// FindWorkspaces returns all available workspace database paths
+// location describes where the reader looks for workspaces, for errors
+func (wr *WorkspaceReader) location() string {
+	if wr.StoragePath != "" {
+		return wr.StoragePath
+	}
+	return strings.Join(wr.CandidatePaths, ", ")
+}
+
+// FindWorkspaces returns all available workspace database paths.
+// StoragePath may point at the workspaceStorage root, a single workspace
+// directory, or a state.vscdb file directly. When ProjectPath is set only
+// the workspaces for that project are returned.
 func (wr *WorkspaceReader) FindWorkspaces() ([]string, error) {
-	entries, err := os.ReadDir(wr.StoragePath)
+	workspaces, err := wr.findAllWorkspaces()
+	if err != nil || wr.ProjectPath == "" {
+		return workspaces, err
+	}
+
+	matches := filterProjectWorkspaces(workspaces, wr.ProjectPath)
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("no workspace found for project %s in %s", wr.ProjectPath, wr.location())
+	}
+	return matches, nil
+}
+
+// findAllWorkspaces returns every workspace database under StoragePath, or
+// under each of CandidatePaths that exists
+func (wr *WorkspaceReader) findAllWorkspaces() ([]string, error) {
+	if wr.StoragePath != "" {
+		return findWorkspacesIn(wr.StoragePath)
+	}
+
+	var workspaces []string
+	scanned := 0
+	for _, root := range wr.CandidatePaths {
+		if info, err := os.Stat(root); err != nil || !info.IsDir() {
+			wr.log().Debug("no Cursor workspace storage", "path", root)
+			continue
+		}
+		found, err := findWorkspacesIn(root)
+		if err != nil {
+			wr.log().Warn("skipping unreadable workspace storage", "path", root, "error", err)
+			continue
+		}
+		wr.log().Debug("scanned Cursor workspace storage", "path", root, "workspaces", len(found))
+		workspaces = append(workspaces, found...)
+		scanned++
+	}
+	if scanned == 0 {
+		return nil, fmt.Errorf("no Cursor workspace storage found in %s", wr.location())
+	}
+	return workspaces, nil
+}
+
+// findWorkspacesIn returns every workspace database under path, which may
+// be a workspaceStorage root, a single workspace directory, or a
+// state.vscdb file
+func findWorkspacesIn(path string) ([]string, error) {
+	if info, err := os.Stat(path); err == nil {
+		if !info.IsDir() {
+			return []string{path}, nil
+		}
+		dbPath := filepath.Join(path, "state.vscdb")
+		if _, err := os.Stat(dbPath); err == nil {
+			return []string{dbPath}, nil
+		}
+	}
+
+	entries, err := os.ReadDir(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read workspace storage: %w", err)
 	}
@@ -58,7 +199,7 @@ func (wr *WorkspaceReader) FindWorkspaces() ([]string, error) {
 	var workspaces []string
 	for _, entry := range entries {
 		if entry.IsDir() {
-			dbPath := filepath.Join(wr.StoragePath, entry.Name(), "state.vscdb")
+			dbPath := filepath.Join(path, entry.Name(), "state.vscdb")
 			if _, err := os.Stat(dbPath); err == nil {
 				workspaces = append(workspaces, dbPath)
 			}
@@ -76,7 +217,7 @@ func (wr *WorkspaceReader) GetLatestWorkspace() (string, error) {
 	}
 
 	if len(workspaces) == 0 {
-		return "", fmt.Errorf("no workspaces found in %s", wr.StoragePath)
+		return "", fmt.Errorf("no workspaces found in %s", wr.location())
 	}
 
 	// Sort by modification time
@@ -92,89 +233,284 @@ func (wr *WorkspaceReader) GetLatestWorkspace() (string, error) {
 	return workspaces[0], nil
 }
 
-// OpenWorkspaceDB opens a GORM connection to a workspace database
-func (wr *WorkspaceReader) OpenWorkspaceDB(dbPath string) (*gorm.DB, error) {
-	// Configure GORM with pure Go SQLite driver
-	db, err := gorm.Open(sqlite.Open(dbPath+"?mode=ro"), &gorm.Config{
+// busyTimeoutMs bounds how long a read waits on a database locked by a
+// running Cursor instance before falling back to a temporary copy
+const busyTimeoutMs = 1000
+
+// OpenWorkspaceDB opens a read-only GORM connection to a workspace database.
+//
+// Cursor keeps its databases in WAL mode, so its newest writes may still be
+// in the -wal file beside state.vscdb rather than in the database itself.
+// A read-only connection reads them through the -shm index it shares with
+// Cursor; the database is never opened immutable, which would skip the WAL.
+// If the database is locked (e.g. by a running Cursor instance), or its WAL
+// can't be read in place because the -shm index can't be created beside
+// it, the database and its -wal are copied to a temporary directory and the
+// copy is opened instead. The returned close function releases the
+// connection and removes any temporary copy.
+func (wr *WorkspaceReader) OpenWorkspaceDB(dbPath string) (*gorm.DB, func(), error) {
+	db, err := openReadOnlyDB(dbPath)
+	if err == nil {
+		if err = probeDB(db); err == nil {
+			return db, func() { closeDB(db) }, nil
+		}
+		closeDB(db)
+	}
+
+	if !needsCopy(dbPath, err) {
+		return nil, nil, fmt.Errorf("failed to open workspace database: %w", err)
+	}
+
+	tempDir, err := copyWorkspaceDB(dbPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("workspace database can't be read in place and copying it failed: %w", err)
+	}
+
+	db, err = openReadOnlyDB(filepath.Join(tempDir, filepath.Base(dbPath)))
+	if err == nil {
+		err = probeDB(db)
+	}
+	if err != nil {
+		if db != nil {
+			closeDB(db)
+		}
+		os.RemoveAll(tempDir)
+		return nil, nil, fmt.Errorf("failed to open copy of workspace database: %w", err)
+	}
+
+	return db, func() {
+		closeDB(db)
+		os.RemoveAll(tempDir)
+	}, nil
+}
+
+// openReadOnlyDB opens a SQLite database read-only with the pure Go driver
+func openReadOnlyDB(dbPath string) (*gorm.DB, error) {
+	dsn := fmt.Sprintf("%s?mode=ro&_pragma=busy_timeout(%d)", dbPath, busyTimeoutMs)
+	return gorm.Open(sqlite.Open(dsn), &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Silent),
 	})
+}
+
+// probeDB forces SQLite to actually read the database, since opening a
+// connection is lazy and would not surface lock errors
+func probeDB(db *gorm.DB) error {
+	var count int64
+	return db.Raw("SELECT count(*) FROM sqlite_master").Scan(&count).Error
+}
+
+// closeDB closes the underlying connection pool of a GORM database
+func closeDB(db *gorm.DB) {
+	if sqlDB, err := db.DB(); err == nil {
+		sqlDB.Close()
+	}
+}
+
+// isLockError reports whether err indicates a busy or locked SQLite database
+func isLockError(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "SQLITE_BUSY") ||
+		strings.Contains(msg, "SQLITE_LOCKED") ||
+		strings.Contains(msg, "database is locked")
+}
+
+// needsCopy reports whether a database that failed to open in place may be
+// read from a copy: when it is locked, or when it has a WAL that SQLite
+// couldn't read, typically because a read-only connection can't create the
+// -shm index in the database's directory
+func needsCopy(dbPath string, err error) bool {
+	if isLockError(err) {
+		return true
+	}
+	if _, statErr := os.Stat(dbPath + "-wal"); statErr != nil {
+		return false
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "SQLITE_CANTOPEN") ||
+		strings.Contains(msg, "unable to open database file") ||
+		strings.Contains(msg, "SQLITE_READONLY") ||
+		strings.Contains(msg, "readonly database")
+}
+
+// copyWorkspaceDB copies a database and its -wal sidecar into a new
+// temporary directory and returns that directory. The -shm index isn't
+// copied: it describes the live files, and SQLite rebuilds it from the WAL
+// when the copy is opened, so every committed write in the WAL is seen.
+func copyWorkspaceDB(dbPath string) (string, error) {
+	tempDir, err := os.MkdirTemp("", "cmctl-cursor-db-")
 	if err != nil {
-		return nil, fmt.Errorf("failed to open workspace database: %w", err)
+		return "", fmt.Errorf("failed to create temp directory: %w", err)
+	}
+
+	base := filepath.Base(dbPath)
+	for _, suffix := range []string{"", "-wal"} {
+		src := dbPath + suffix
+		if _, err := os.Stat(src); err != nil {
+			if suffix == "" || !os.IsNotExist(err) {
+				os.RemoveAll(tempDir)
+				return "", err
+			}
+			continue // The WAL is optional
+		}
+		if err := copyFile(src, filepath.Join(tempDir, base+suffix)); err != nil {
+			os.RemoveAll(tempDir)
+			return "", err
+		}
 	}
 
-	return db, nil
+	return tempDir, nil
 }
 
-// GetChatData retrieves and parses chat data from workspace
-func (wr *WorkspaceReader) GetChatData(dbPath string) (*ChatData, error) {
-	db, err := wr.OpenWorkspaceDB(dbPath)
+// copyFile copies src to dst
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
 	if err != nil {
-		return nil, err
+		return err
 	}
+	defer in.Close()
 
-	chatData := &ChatData{Tabs: []ChatTab{}}
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
 
-	// First, get composer data to extract titles
-	composerTitles := make(map[string]string) // map[composerID]title
+// Keys that hold chats in ItemTable
+const (
+	chatDataKey     = "workbench.panel.aichat.view.aichat.chatdata"
+	generationsKey  = "aiService.generations"
+	promptsKey      = "aiService.prompts"
+	composerDataKey = "composer.composerData"
+)
+
+// chatKeys are the keys chats have been stored under across Cursor
+// versions, in the order they're read
+var chatKeys = []string{
+	bubbleKeyPrefix, // Newest format: one row per message, grouped by composer
+	chatDataKey,     // Newer format with actual titles
+	generationsKey,  // Full generation data (likely contains complete conversation)
+	promptsKey,      // Legacy format (partial data)
+	composerDataKey, // Composer chats
+}
+
+// loadComposerTitles reads the chat titles from composer.composerData
+func loadComposerTitles(db *gorm.DB) composerTitleIndex {
 	var composerItem CursorItem
-	if result := db.Where("key = ?", "composer.composerData").First(&composerItem); result.Error == nil {
-		var composerData ComposerData
-		if err := json.Unmarshal([]byte(composerItem.Value), &composerData); err == nil {
-			for _, composer := range composerData.AllComposers {
-				if composer.Name != "" {
-					composerTitles[composer.ComposerID] = composer.Name
-				}
-			}
+	if result := db.Where("key = ?", composerDataKey).First(&composerItem); result.Error != nil {
+		return composerTitleIndex{}
+	}
+	var composerData ComposerData
+	if err := json.Unmarshal([]byte(composerItem.Value), &composerData); err != nil {
+		return composerTitleIndex{}
+	}
+	return newComposerTitleIndex(composerData.AllComposers)
+}
+
+// parseChatItem parses the value of a single-row chat key
+func (wr *WorkspaceReader) parseChatItem(key, value string, titles composerTitleIndex) ([]ChatTab, error) {
+	switch key {
+	case generationsKey:
+		// Full generation data - richest source
+		return wr.parseAIServiceGenerations(value, titles)
+	case promptsKey:
+		return wr.parseAIServicePromptsWithTitles(value, titles)
+	case composerDataKey:
+		return wr.parseComposerData(value)
+	default:
+		// Modern Cursor format with proper titles, also the fallback format
+		var tempData ChatData
+		if err := json.Unmarshal([]byte(value), &tempData); err != nil {
+			return nil, err
 		}
+		return tempData.Tabs, nil
 	}
+}
 
-	// Try different possible chat data keys (different Cursor versions)
-	chatKeys := []string{
-		"workbench.panel.aichat.view.aichat.chatdata", // Newer format with actual titles
-		"aiService.generations",                       // Full generation data (likely contains complete conversation)
-		"aiService.prompts",                           // Legacy format (partial data)
-		"composer.composerData",                       // Composer chats
+// UnrecognizedSchemaError is returned for a workspace database that has
+// none of the known chat keys but does have keys that look chat-related,
+// which usually means Cursor changed its storage format. A database with
+// neither simply has no chats.
+type UnrecognizedSchemaError struct {
+	Path string
+	// Keys are the chat-related keys that no parser reads
+	Keys []string
+}
+
+func (e *UnrecognizedSchemaError) Error() string {
+	return fmt.Sprintf("unrecognized chat format in %s (keys: %s)", e.Path, strings.Join(e.Keys, ", "))
+}
+
+// GetChatData retrieves and parses chat data from workspace. It returns an
+// *UnrecognizedSchemaError if the database holds chat data in a format no
+// parser reads.
+func (wr *WorkspaceReader) GetChatData(dbPath string) (*ChatData, error) {
+	db, release, err := wr.OpenWorkspaceDB(dbPath)
+	if err != nil {
+		return nil, err
 	}
+	defer release()
+
+	chatData := &ChatData{Tabs: []ChatTab{}}
+	// found is set once any known chat key has rows
+	found := false
+
+	// First, get composer data to extract titles
+	composerTitles := loadComposerTitles(db)
+
+	seenIDs := make(map[string]bool)
+	seenConversations := make(map[string]bool)
 
 	for _, key := range chatKeys {
+		if key == bubbleKeyPrefix {
+			// Bubble rows are spread across many keys, so they're loaded by prefix
+			rows := loadBubbleRows(db)
+			found = found || len(rows) > 0
+			for _, tab := range wr.parseBubbleRows(rows, composerTitles) {
+				seenIDs[tab.ID] = true
+				chatData.Tabs = append(chatData.Tabs, tab)
+			}
+			continue
+		}
+
 		var item CursorItem
 		result := db.Where("key = ?", key).First(&item)
 		if result.Error != nil {
 			continue // Try next key
 		}
+		found = true
 
-		// Parse based on key type
-		if key == "workbench.panel.aichat.view.aichat.chatdata" {
-			// Modern Cursor format with proper titles
-			var tempData ChatData
-			if err := json.Unmarshal([]byte(item.Value), &tempData); err == nil {
-				chatData.Tabs = append(chatData.Tabs, tempData.Tabs...)
-			}
-		} else if key == "aiService.generations" {
-			// Full generation data - richest source
-			tabs, err := wr.parseAIServiceGenerations(item.Value, composerTitles)
-			if err == nil && len(tabs) > 0 {
-				chatData.Tabs = append(chatData.Tabs, tabs...)
-			}
-		} else if key == "aiService.prompts" {
-			tabs, err := wr.parseAIServicePromptsWithTitles(item.Value, composerTitles)
-			if err == nil && len(tabs) > 0 {
-				chatData.Tabs = append(chatData.Tabs, tabs...)
-			}
-		} else if key == "composer.composerData" {
-			tabs, err := wr.parseComposerData(item.Value)
-			if err == nil && len(tabs) > 0 {
-				chatData.Tabs = append(chatData.Tabs, tabs...)
+		tabs, err := wr.parseChatItem(key, item.Value, composerTitles)
+		if err != nil {
+			wr.log().Debug("skipping unparseable chat data", "workspace", dbPath, "key", key, "error", err)
+			continue
+		}
+		for _, tab := range tabs {
+			// Skip composer placeholders for chats already parsed from bubbles,
+			// and when merging, for conversations already parsed from
+			// generations
+			if key == composerDataKey && (seenIDs[tab.ID] || (!wr.NoMerge && seenConversations[tab.ID] && isComposerPlaceholder(tab))) {
+				continue
 			}
-		} else {
-			// Fallback format
-			var tempData ChatData
-			if err := json.Unmarshal([]byte(item.Value), &tempData); err == nil {
-				chatData.Tabs = append(chatData.Tabs, tempData.Tabs...)
+			if tab.ConversationID != "" {
+				seenConversations[tab.ConversationID] = true
 			}
+			chatData.Tabs = append(chatData.Tabs, tab)
 		}
 	}
 
+	if !found {
+		if unknown := unknownChatKeys(db); len(unknown) > 0 {
+			return nil, &UnrecognizedSchemaError{Path: dbPath, Keys: unknown}
+		}
+	}
 	return chatData, nil
 }
 
@@ -213,11 +549,15 @@ func (wr *WorkspaceReader) GetChatByID(chatID string) (*ChatTab, string, error)
 	for _, workspacePath := range workspaces {
 		chatData, err := wr.GetChatData(workspacePath)
 		if err != nil {
-			continue // Skip errored workspaces
+			wr.log().Warn("skipping unreadable workspace", "workspace", workspacePath, "error", err)
+			continue
 		}
 
-		for _, tab := range chatData.Tabs {
-			if tab.ID == chatID {
+		for i := range chatData.Tabs {
+			if chatData.Tabs[i].ID == chatID {
+				// Return a copy rather than the address of a loop variable
+				// or slice element, so the result never aliases another chat
+				tab := chatData.Tabs[i]
 				return &tab, workspacePath, nil
 			}
 		}
@@ -234,11 +574,18 @@ func (wr *WorkspaceReader) ListAllChats() ([]ChatTabWithWorkspace, error) {
 	}
 
 	var allChats []ChatTabWithWorkspace
+	var unrecognized []error
 
 	for _, workspacePath := range workspaces {
 		chatData, err := wr.GetChatData(workspacePath)
+		var schemaErr *UnrecognizedSchemaError
+		if errors.As(err, &schemaErr) {
+			unrecognized = append(unrecognized, err)
+			continue
+		}
 		if err != nil {
-			continue // Skip errored workspaces
+			wr.log().Warn("skipping unreadable workspace", "workspace", workspacePath, "error", err)
+			continue
 		}
 
 		workspaceName := filepath.Base(filepath.Dir(workspacePath))
@@ -252,6 +599,15 @@ func (wr *WorkspaceReader) ListAllChats() ([]ChatTabWithWorkspace, error) {
 		}
 	}
 
+	// Finding no chats at all because of unrecognized formats is reported
+	// as an error, so it isn't mistaken for having no chats
+	if len(allChats) == 0 && len(unrecognized) > 0 {
+		return nil, errors.Join(unrecognized...)
+	}
+	for _, err := range unrecognized {
+		wr.log().Warn("skipping workspace with unrecognized chat format", "error", err)
+	}
+
 	// Sort by timestamp (newest first)
 	sort.Slice(allChats, func(i, j int) bool {
 		return allChats[i].Timestamp > allChats[j].Timestamp
@@ -274,9 +630,15 @@ func (wr *WorkspaceReader) SearchChats(query string) ([]ChatTabWithWorkspace, er
 		return nil, err
 	}
 
+	return FilterChats(allChats, query), nil
+}
+
+// FilterChats returns the chats whose title or content contains query,
+// ignoring case
+func FilterChats(chats []ChatTabWithWorkspace, query string) []ChatTabWithWorkspace {
 	var matches []ChatTabWithWorkspace
 
-	for _, chat := range allChats {
+	for _, chat := range chats {
 		// Search in title and content
 		if containsIgnoreCase(chat.GetDisplayTitle(), query) ||
 			containsIgnoreCase(chat.ToMarkdown(), query) {
@@ -284,5 +646,5 @@ func (wr *WorkspaceReader) SearchChats(query string) ([]ChatTabWithWorkspace, er
 		}
 	}
 
-	return matches, nil
+	return matches
 }
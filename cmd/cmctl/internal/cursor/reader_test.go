@@ -0,0 +1,410 @@
+package cursor
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/cursor/cursortest"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func chatDataFixture(t *testing.T, tabs ...ChatTab) map[string]string {
+	t.Helper()
+	data, err := json.Marshal(ChatData{Tabs: tabs})
+	if err != nil {
+		t.Fatalf("Failed to marshal chat data: %v", err)
+	}
+	return map[string]string{"workbench.panel.aichat.view.aichat.chatdata": string(data)}
+}
+
+func TestGetChatDataLockedDatabaseFallsBackToCopy(t *testing.T) {
+	storageDir := t.TempDir()
+	dbPath := cursortest.WriteWorkspace(t, storageDir, "workspace", chatDataFixture(t, ChatTab{
+		ID:       "chat-1",
+		Title:    "Locked chat",
+		Messages: []Message{{Role: "user", Content: "hello"}},
+	}))
+
+	// Simulate a running Cursor instance holding an exclusive lock
+	writer, err := gorm.Open(sqlite.Open(dbPath), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("Failed to open writer connection: %v", err)
+	}
+	sqlDB, err := writer.DB()
+	if err != nil {
+		t.Fatalf("Failed to access writer connection: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	defer sqlDB.Close()
+	if err := writer.Exec("PRAGMA locking_mode=EXCLUSIVE").Error; err != nil {
+		t.Fatalf("Failed to set locking mode: %v", err)
+	}
+	if err := writer.Exec("BEGIN EXCLUSIVE").Error; err != nil {
+		t.Fatalf("Failed to lock database: %v", err)
+	}
+
+	reader := NewWorkspaceReaderWithPath(storageDir)
+
+	// Confirm the direct read really is blocked
+	direct, err := openReadOnlyDB(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	if err := probeDB(direct); !isLockError(err) {
+		t.Fatalf("Expected lock error from direct read, got %v", err)
+	}
+	closeDB(direct)
+
+	chatData, err := reader.GetChatData(dbPath)
+	if err != nil {
+		t.Fatalf("GetChatData failed on locked database: %v", err)
+	}
+	if len(chatData.Tabs) != 1 || chatData.Tabs[0].ID != "chat-1" {
+		t.Errorf("Expected chat-1 from copied database, got %+v", chatData.Tabs)
+	}
+}
+
+func TestGetChatDataReadsWAL(t *testing.T) {
+	storageDir := t.TempDir()
+	dbPath := cursortest.WriteWorkspace(t, storageDir, "workspace", chatDataFixture(t, ChatTab{
+		ID:       "chat-old",
+		Messages: []Message{{Role: "user", Content: "checkpointed"}},
+	}))
+	cursortest.WriteWAL(t, dbPath, chatDataFixture(t,
+		ChatTab{ID: "chat-old", Messages: []Message{{Role: "user", Content: "checkpointed"}}},
+		ChatTab{ID: "chat-new", Messages: []Message{{Role: "user", Content: "only in the WAL"}}},
+	))
+
+	// The newest chat must be in the WAL, not yet in the database
+	data, err := os.ReadFile(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to read database: %v", err)
+	}
+	wal, err := os.ReadFile(dbPath + "-wal")
+	if err != nil {
+		t.Fatalf("Failed to read WAL: %v", err)
+	}
+	if bytes.Contains(data, []byte("chat-new")) || !bytes.Contains(wal, []byte("chat-new")) {
+		t.Fatal("Expected chat-new to be written to the WAL only")
+	}
+
+	// A copy of the files without the -shm index, as a crash or backup
+	// leaves them, must be read the same way
+	snapshotDir := t.TempDir()
+	snapshot := filepath.Join(snapshotDir, "snapshot", "state.vscdb")
+	if err := os.MkdirAll(filepath.Dir(snapshot), 0755); err != nil {
+		t.Fatalf("Failed to create snapshot dir: %v", err)
+	}
+	for _, suffix := range []string{"", "-wal"} {
+		if err := copyFile(dbPath+suffix, snapshot+suffix); err != nil {
+			t.Fatalf("Failed to copy database: %v", err)
+		}
+	}
+
+	reader := NewWorkspaceReaderWithPath(storageDir)
+	for _, path := range []string{dbPath, snapshot} {
+		chatData, err := reader.GetChatData(path)
+		if err != nil {
+			t.Fatalf("GetChatData failed for %s: %v", path, err)
+		}
+		if len(chatData.Tabs) != 2 || chatData.Tabs[1].ID != "chat-new" {
+			t.Errorf("Expected chat-new from the WAL of %s, got %+v", path, chatData.Tabs)
+		}
+	}
+
+	// The copy made of a locked database leaves the live -shm index behind
+	// and still sees the WAL
+	tempDir, err := copyWorkspaceDB(dbPath)
+	if err != nil {
+		t.Fatalf("copyWorkspaceDB failed: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+	if _, err := os.Stat(filepath.Join(tempDir, "state.vscdb-shm")); !os.IsNotExist(err) {
+		t.Errorf("Expected the -shm index not to be copied, got %v", err)
+	}
+	chatData, err := reader.GetChatData(filepath.Join(tempDir, "state.vscdb"))
+	if err != nil || len(chatData.Tabs) != 2 {
+		t.Errorf("Expected both chats from the copy, got %+v (err=%v)", chatData, err)
+	}
+}
+
+func TestNeedsCopy(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "state.vscdb")
+	cantOpen := errors.New("unable to open database file: out of memory (14)")
+
+	if !needsCopy(dbPath, errors.New("database is locked (5) (SQLITE_BUSY)")) {
+		t.Error("Expected a locked database to be copied")
+	}
+	if needsCopy(dbPath, cantOpen) {
+		t.Error("Expected a database without a WAL not to be copied")
+	}
+	if err := os.WriteFile(dbPath+"-wal", nil, 0644); err != nil {
+		t.Fatalf("Failed to write WAL: %v", err)
+	}
+	if !needsCopy(dbPath, cantOpen) {
+		t.Error("Expected a WAL that can't be read in place to be copied")
+	}
+}
+
+func TestCopyWorkspaceDB(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "state.vscdb")
+	cursortest.WriteDB(t, dbPath, map[string]string{"key": "value"})
+
+	tempDir, err := copyWorkspaceDB(dbPath)
+	if err != nil {
+		t.Fatalf("copyWorkspaceDB failed: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	db, err := openReadOnlyDB(filepath.Join(tempDir, "state.vscdb"))
+	if err != nil {
+		t.Fatalf("Failed to open copy: %v", err)
+	}
+	defer closeDB(db)
+
+	var item CursorItem
+	if err := db.Where("key = ?", "key").First(&item).Error; err != nil {
+		t.Fatalf("Failed to read from copy: %v", err)
+	}
+	if item.Value != "value" {
+		t.Errorf("Expected value from copy, got %q", item.Value)
+	}
+}
+
+func TestGetChatByIDReturnsDistinctChats(t *testing.T) {
+	storageDir := t.TempDir()
+	cursortest.WriteWorkspace(t, storageDir, "workspace", chatDataFixture(t,
+		ChatTab{ID: "chat-1", Title: "First chat", Messages: []Message{{Role: "user", Content: "first"}}},
+		ChatTab{ID: "chat-2", Title: "Second chat", Messages: []Message{{Role: "user", Content: "second"}}},
+	))
+	reader := NewWorkspaceReaderWithPath(storageDir)
+
+	first, _, err := reader.GetChatByID("chat-1")
+	if err != nil {
+		t.Fatalf("Failed to get chat-1: %v", err)
+	}
+	second, _, err := reader.GetChatByID("chat-2")
+	if err != nil {
+		t.Fatalf("Failed to get chat-2: %v", err)
+	}
+
+	if first == second {
+		t.Fatal("Expected distinct chats, got the same pointer twice")
+	}
+	if first.ID != "chat-1" || first.Title != "First chat" || first.Messages[0].Content != "first" {
+		t.Errorf("Expected chat-1 to be unchanged by the second lookup, got %+v", first)
+	}
+	if second.ID != "chat-2" || second.Title != "Second chat" || second.Messages[0].Content != "second" {
+		t.Errorf("Expected chat-2, got %+v", second)
+	}
+
+	// Changing one result doesn't change the other
+	first.Title = "Renamed"
+	first.Messages[0].Content = "changed"
+	if second.Title != "Second chat" || second.Messages[0].Content != "second" {
+		t.Errorf("Expected chat-2 not to alias chat-1, got %+v", second)
+	}
+}
+
+func TestGetChatDataEmptyVersusUnrecognized(t *testing.T) {
+	storageDir := t.TempDir()
+	empty := cursortest.WriteWorkspace(t, storageDir, "empty", map[string]string{"editor.fontSize": "14"})
+	unrecognized := cursortest.WriteWorkspace(t, storageDir, "unrecognized", map[string]string{
+		"chatV3:composer-1": "{}",
+		"editor.fontSize":   "14",
+	})
+	reader := NewWorkspaceReaderWithPath(storageDir)
+
+	chatData, err := reader.GetChatData(empty)
+	if err != nil {
+		t.Fatalf("Expected an empty database to have no error, got %v", err)
+	}
+	if len(chatData.Tabs) != 0 {
+		t.Errorf("Expected no chats, got %d", len(chatData.Tabs))
+	}
+
+	_, err = reader.GetChatData(unrecognized)
+	var schemaErr *UnrecognizedSchemaError
+	if !errors.As(err, &schemaErr) {
+		t.Fatalf("Expected an UnrecognizedSchemaError, got %v", err)
+	}
+	if schemaErr.Path != unrecognized || len(schemaErr.Keys) != 1 || schemaErr.Keys[0] != "chatV3:composer-1" {
+		t.Errorf("Expected the unrecognized key of %s, got %+v", unrecognized, schemaErr)
+	}
+
+	// With no chats anywhere, listing reports the unrecognized format
+	if _, err := reader.ListAllChats(); !errors.As(err, &schemaErr) {
+		t.Errorf("Expected ListAllChats to report the unrecognized format, got %v", err)
+	}
+
+	// Once another workspace has chats, the unrecognized one is skipped
+	cursortest.WriteWorkspace(t, storageDir, "good", chatDataFixture(t, ChatTab{
+		ID:       "chat-1",
+		Messages: []Message{{Role: "user", Content: "hello"}},
+	}))
+	chats, err := reader.ListAllChats()
+	if err != nil {
+		t.Fatalf("ListAllChats failed: %v", err)
+	}
+	if len(chats) != 1 || chats[0].ID != "chat-1" {
+		t.Errorf("Expected only chat-1, got %+v", chats)
+	}
+}
+
+func TestListAllChatsLogsSkipped(t *testing.T) {
+	storageDir := t.TempDir()
+	items := chatDataFixture(t, ChatTab{
+		ID:       "chat-1",
+		Title:    "Readable chat",
+		Messages: []Message{{Role: "user", Content: "hello"}},
+	})
+	items[generationsKey] = "not json"
+	cursortest.WriteWorkspace(t, storageDir, "good", items)
+
+	// A directory where the database should be can't be opened
+	if err := os.MkdirAll(filepath.Join(storageDir, "bad", "state.vscdb"), 0755); err != nil {
+		t.Fatalf("Failed to create workspace dir: %v", err)
+	}
+
+	var logs bytes.Buffer
+	reader := NewWorkspaceReaderWithPath(storageDir)
+	reader.Logger = slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	chats, err := reader.ListAllChats()
+	if err != nil {
+		t.Fatalf("ListAllChats failed: %v", err)
+	}
+	if len(chats) != 1 || chats[0].ID != "chat-1" {
+		t.Errorf("Expected only chat-1, got %+v", chats)
+	}
+
+	levels := make(map[string]string)
+	decoder := json.NewDecoder(&logs)
+	for decoder.More() {
+		var record struct {
+			Level string `json:"level"`
+			Msg   string `json:"msg"`
+		}
+		if err := decoder.Decode(&record); err != nil {
+			t.Fatalf("Failed to decode log record: %v", err)
+		}
+		levels[record.Msg] = record.Level
+	}
+	if levels["skipping unreadable workspace"] != "WARN" {
+		t.Errorf("Expected a warning for the unreadable workspace, got %v", levels)
+	}
+	if levels["skipping unparseable chat data"] != "DEBUG" {
+		t.Errorf("Expected a debug record for the unparseable generations, got %v", levels)
+	}
+}
+
+func TestCandidateStoragePaths(t *testing.T) {
+	env := map[string]string{"XDG_CONFIG_HOME": "/xdg", "CURSOR_PORTABLE": "/opt/cursor/data"}
+	paths := candidateStoragePaths("linux", "/home/me", func(key string) string { return env[key] })
+
+	want := []string{
+		filepath.Join("/opt/cursor/data", "user-data", "User", "workspaceStorage"),
+		filepath.Join("/xdg", "Cursor", "User", "workspaceStorage"),
+		filepath.Join("/xdg", "Cursor Nightly", "User", "workspaceStorage"),
+		filepath.Join("/xdg", "Cursor - Insiders", "User", "workspaceStorage"),
+		filepath.Join("/home/me", ".config", "Cursor", "User", "workspaceStorage"),
+		filepath.Join("/home/me", ".config", "Cursor Nightly", "User", "workspaceStorage"),
+		filepath.Join("/home/me", ".config", "Cursor - Insiders", "User", "workspaceStorage"),
+	}
+	if len(paths) != len(want) {
+		t.Fatalf("Expected %d paths, got %v", len(want), paths)
+	}
+	for i := range want {
+		if paths[i] != want[i] {
+			t.Errorf("Expected path %d to be %s, got %s", i, want[i], paths[i])
+		}
+	}
+
+	// $XDG_CONFIG_HOME set to the usual place isn't searched twice
+	env = map[string]string{"XDG_CONFIG_HOME": filepath.Join("/home/me", ".config")}
+	if paths := candidateStoragePaths("linux", "/home/me", func(key string) string { return env[key] }); len(paths) != 3 {
+		t.Errorf("Expected duplicate paths to be dropped, got %v", paths)
+	}
+
+	darwin := candidateStoragePaths("darwin", "/Users/me", func(string) string { return "" })
+	if darwin[0] != filepath.Join("/Users/me", "Library", "Application Support", "Cursor", "User", "workspaceStorage") {
+		t.Errorf("Expected the stable macOS path first, got %v", darwin)
+	}
+}
+
+func TestFindWorkspacesAcrossInstalls(t *testing.T) {
+	stable := filepath.Join(t.TempDir(), "Cursor", "User", "workspaceStorage")
+	nightly := filepath.Join(t.TempDir(), "Cursor Nightly", "User", "workspaceStorage")
+	missing := filepath.Join(t.TempDir(), "Cursor - Insiders", "User", "workspaceStorage")
+	cursortest.WriteWorkspace(t, stable, "one", chatDataFixture(t, ChatTab{
+		ID:       "chat-stable",
+		Messages: []Message{{Role: "user", Content: "from stable"}},
+	}))
+	cursortest.WriteWorkspace(t, nightly, "two", chatDataFixture(t, ChatTab{
+		ID:       "chat-nightly",
+		Messages: []Message{{Role: "user", Content: "from nightly"}},
+	}))
+
+	var logs bytes.Buffer
+	reader := &WorkspaceReader{
+		CandidatePaths: []string{missing, stable, nightly},
+		Logger:         slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})),
+	}
+
+	workspaces, err := reader.FindWorkspaces()
+	if err != nil {
+		t.Fatalf("FindWorkspaces failed: %v", err)
+	}
+	if len(workspaces) != 2 {
+		t.Fatalf("Expected a workspace from each install, got %v", workspaces)
+	}
+	scanned := make(map[string]string)
+	decoder := json.NewDecoder(&logs)
+	for decoder.More() {
+		var record struct {
+			Msg  string `json:"msg"`
+			Path string `json:"path"`
+		}
+		if err := decoder.Decode(&record); err != nil {
+			t.Fatalf("Failed to decode log record: %v", err)
+		}
+		scanned[record.Path] = record.Msg
+	}
+	if scanned[stable] != "scanned Cursor workspace storage" || scanned[nightly] != "scanned Cursor workspace storage" {
+		t.Errorf("Expected both installs to be reported as scanned, got %v", scanned)
+	}
+	if scanned[missing] != "no Cursor workspace storage" {
+		t.Errorf("Expected the missing install to be reported, got %v", scanned)
+	}
+
+	chats, err := reader.ListAllChats()
+	if err != nil {
+		t.Fatalf("ListAllChats failed: %v", err)
+	}
+	if len(chats) != 2 {
+		t.Errorf("Expected chats from both installs, got %+v", chats)
+	}
+
+	// An explicit StoragePath overrides the candidates
+	reader.StoragePath = nightly
+	if workspaces, err := reader.FindWorkspaces(); err != nil || len(workspaces) != 1 {
+		t.Errorf("Expected only the nightly workspace, got %v (err=%v)", workspaces, err)
+	}
+
+	// With no install found the error names where it looked
+	reader = &WorkspaceReader{CandidatePaths: []string{missing}}
+	if _, err := reader.FindWorkspaces(); err == nil || !strings.Contains(err.Error(), missing) {
+		t.Errorf("Expected an error naming %s, got %v", missing, err)
+	}
+}
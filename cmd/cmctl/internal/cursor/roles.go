@@ -0,0 +1,118 @@
+package cursor
+
+import "strings"
+
+// Role confidence values used when resolving message roles
+const (
+	roleConfidenceExplicit = 1.0
+	roleConfidenceMin      = 0.05
+	roleConfidenceMax      = 0.95
+)
+
+// normalizeRole maps the role spellings used across Cursor data formats onto
+// "user", "assistant" or "system". ok is false for empty or unknown roles.
+func normalizeRole(raw string) (role string, ok bool) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "user", "human":
+		return "user", true
+	case "assistant", "ai", "bot", "model":
+		return "assistant", true
+	case "system":
+		return "system", true
+	default:
+		return "", false
+	}
+}
+
+// resolveRoles assigns a role and confidence to each message. Explicit roles
+// from the source data always win; otherwise roles are inferred from content
+// heuristics combined with a prior that turns alternate between user and
+// assistant. explicit holds the raw role field for each message, if any.
+func resolveRoles(messages []Message, explicit []string) {
+	prevRole := ""
+	for i := range messages {
+		raw := ""
+		if i < len(explicit) {
+			raw = explicit[i]
+		}
+
+		if role, ok := normalizeRole(raw); ok {
+			messages[i].Role = role
+			messages[i].RoleConfidence = roleConfidenceExplicit
+		} else {
+			messages[i].Role, messages[i].RoleConfidence = inferRole(messages[i].Content, prevRole)
+		}
+
+		if messages[i].Role != "system" {
+			prevRole = messages[i].Role
+		}
+	}
+}
+
+// inferRole guesses whether content came from the user or the assistant.
+// The previous turn's role provides the prior: conversations usually
+// alternate, and the first turn is usually the user's.
+func inferRole(content, prevRole string) (string, float64) {
+	pAssistant := 0.3 // Conversations usually open with the user
+	switch prevRole {
+	case "user":
+		pAssistant = 0.7
+	case "assistant":
+		pAssistant = 0.3
+	}
+
+	if containsAssistantMarkers(content) {
+		pAssistant += 0.2
+	}
+	if containsUserMarkers(content) {
+		pAssistant -= 0.15
+	}
+	if len(content) > 300 {
+		pAssistant += 0.15
+	} else if len(content) < 80 {
+		pAssistant -= 0.1
+	}
+
+	if pAssistant < roleConfidenceMin {
+		pAssistant = roleConfidenceMin
+	}
+	if pAssistant > roleConfidenceMax {
+		pAssistant = roleConfidenceMax
+	}
+
+	if pAssistant >= 0.5 {
+		return "assistant", pAssistant
+	}
+	return "user", 1 - pAssistant
+}
+
+// containsUserMarkers checks if content looks like user input
+func containsUserMarkers(content string) bool {
+	userMarkers := []string{
+		"@", "?", "Can you", "How do I", "What is", "Please", "I want", "I need",
+		"Let's", "Could you", "Would you", "Show me", "Help me", "I'm trying",
+	}
+
+	for _, marker := range userMarkers {
+		if containsIgnoreCase(content, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsAssistantMarkers checks if content looks like assistant response
+func containsAssistantMarkers(content string) bool {
+	assistantMarkers := []string{
+		"I'll", "I can", "Let me", "Here's", "You can", "This will",
+		"```", "Here are", "To do this", "First,", "Next,", "Finally,",
+		"## ", "### ", "**", "- [", "1. ", "2. ", "3. ",
+	}
+
+	for _, marker := range assistantMarkers {
+		if containsIgnoreCase(content, marker) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,106 @@
+package cursor
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// workspaceFile sits beside each workspace's state.vscdb and records the
+// folder, or .code-workspace file, the workspace was opened on
+const workspaceFile = "workspace.json"
+
+// workspaceInfo is the content of workspace.json
+type workspaceInfo struct {
+	Folder    string `json:"folder"`
+	Workspace string `json:"workspace"`
+}
+
+// WorkspaceFolder returns the local project folder the workspace database at
+// dbPath belongs to. For a multi-root workspace this is the directory holding
+// its .code-workspace file.
+func WorkspaceFolder(dbPath string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(filepath.Dir(dbPath), workspaceFile))
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", workspaceFile, err)
+	}
+
+	var info workspaceInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return "", fmt.Errorf("failed to parse %s: %w", workspaceFile, err)
+	}
+
+	switch {
+	case info.Folder != "":
+		return fileURIToPath(info.Folder)
+	case info.Workspace != "":
+		path, err := fileURIToPath(info.Workspace)
+		if err != nil {
+			return "", err
+		}
+		return filepath.Dir(path), nil
+	default:
+		return "", fmt.Errorf("%s names no folder", workspaceFile)
+	}
+}
+
+// fileURIToPath converts a file:// URI such as file:///c%3A/src/app to a
+// local path
+func fileURIToPath(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", fmt.Errorf("invalid folder URI %q: %w", uri, err)
+	}
+	if u.Scheme != "file" {
+		return "", fmt.Errorf("folder URI %q is not a local folder", uri)
+	}
+
+	path := u.Path
+	// Windows drive paths are written as /c:/...
+	if len(path) >= 3 && path[0] == '/' && path[2] == ':' {
+		path = path[1:]
+	}
+	return filepath.Clean(filepath.FromSlash(path)), nil
+}
+
+// filterProjectWorkspaces returns the workspaces opened on project or on the
+// nearest folder containing it, so a project can be given by any directory
+// inside it. Workspaces without a readable local folder are skipped.
+func filterProjectWorkspaces(workspaces []string, project string) []string {
+	project = filepath.Clean(project)
+
+	var matches []string
+	best := -1
+	for _, dbPath := range workspaces {
+		folder, err := WorkspaceFolder(dbPath)
+		if err != nil || !pathWithin(project, folder) {
+			continue
+		}
+		switch {
+		case len(folder) > best:
+			best = len(folder)
+			matches = []string{dbPath}
+		case len(folder) == best:
+			// Cursor can keep several workspaces for the same folder
+			matches = append(matches, dbPath)
+		}
+	}
+	return matches
+}
+
+// pathWithin reports whether path is dir or inside it. Paths compare without
+// case on Windows and macOS, whose filesystems usually ignore it.
+func pathWithin(path, dir string) bool {
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		path, dir = strings.ToLower(path), strings.ToLower(dir)
+	}
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
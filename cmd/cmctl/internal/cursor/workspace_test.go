@@ -0,0 +1,119 @@
+package cursor
+
+import (
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/cursor/cursortest"
+)
+
+func TestFileURIToPath(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Unix paths")
+	}
+
+	tests := []struct {
+		uri      string
+		expected string
+		wantErr  bool
+	}{
+		{uri: "file:///home/me/src/api", expected: "/home/me/src/api"},
+		{uri: "file:///home/me/my%20project/", expected: "/home/me/my project"},
+		{uri: "vscode-remote://ssh-remote+host/home/me/src", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.uri, func(t *testing.T) {
+			path, err := fileURIToPath(tt.uri)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected an error, got %q", path)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to convert URI: %v", err)
+			}
+			if path != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, path)
+			}
+		})
+	}
+}
+
+func TestProjectPathFiltersWorkspaces(t *testing.T) {
+	storageDir := t.TempDir()
+	projects := t.TempDir()
+	generations := readFixture(t, "generations_multi.json")
+
+	folderURI := func(path string) string {
+		return "file://" + filepath.ToSlash(path)
+	}
+	api := cursortest.WriteWorkspace(t, storageDir, "api", map[string]string{"aiService.generations": generations})
+	cursortest.WriteWorkspaceFolder(t, api, folderURI(filepath.Join(projects, "api")))
+	web := cursortest.WriteWorkspace(t, storageDir, "web", map[string]string{"aiService.generations": generations})
+	cursortest.WriteWorkspaceFolder(t, web, folderURI(filepath.Join(projects, "web")))
+	remote := cursortest.WriteWorkspace(t, storageDir, "remote", map[string]string{"aiService.generations": generations})
+	cursortest.WriteWorkspaceFolder(t, remote, "vscode-remote://ssh-remote+host"+filepath.ToSlash(filepath.Join(projects, "api")))
+	cursortest.WriteWorkspace(t, storageDir, "unknown", map[string]string{"aiService.generations": generations})
+
+	tests := []struct {
+		name     string
+		project  string
+		expected []string
+	}{
+		{name: "project folder", project: filepath.Join(projects, "api"), expected: []string{api}},
+		{name: "subdirectory", project: filepath.Join(projects, "web", "cmd", "server"), expected: []string{web}},
+		{name: "other project", project: filepath.Join(projects, "docs")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := NewWorkspaceReaderWithPath(storageDir)
+			reader.ProjectPath = tt.project
+
+			workspaces, err := reader.FindWorkspaces()
+			if tt.expected == nil {
+				if err == nil {
+					t.Errorf("Expected an error for a project without a workspace, got %v", workspaces)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to find workspaces: %v", err)
+			}
+			if len(workspaces) != 1 || workspaces[0] != tt.expected[0] {
+				t.Errorf("Expected %v, got %v", tt.expected, workspaces)
+			}
+
+			chats, err := reader.ListAllChats()
+			if err != nil {
+				t.Fatalf("Failed to list chats: %v", err)
+			}
+			for _, chat := range chats {
+				if chat.WorkspacePath != tt.expected[0] {
+					t.Errorf("Expected chats only from %s, got one from %s", tt.expected[0], chat.WorkspacePath)
+				}
+			}
+			if len(chats) == 0 {
+				t.Error("Expected chats from the project's workspace")
+			}
+		})
+	}
+}
+
+func TestProjectPathPrefersNearestFolder(t *testing.T) {
+	storageDir := t.TempDir()
+	projects := t.TempDir()
+
+	outer := cursortest.WriteWorkspace(t, storageDir, "outer", map[string]string{})
+	cursortest.WriteWorkspaceFolder(t, outer, "file://"+filepath.ToSlash(projects))
+	inner := cursortest.WriteWorkspace(t, storageDir, "inner", map[string]string{})
+	cursortest.WriteWorkspaceFolder(t, inner, "file://"+filepath.ToSlash(filepath.Join(projects, "api")))
+
+	matches := filterProjectWorkspaces([]string{outer, inner}, filepath.Join(projects, "api", "internal"))
+	if len(matches) != 1 || matches[0] != inner {
+		t.Errorf("Expected only the nearest workspace %s, got %v", inner, matches)
+	}
+}
@@ -0,0 +1,987 @@
+package providers
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+)
+
+// providerFactories are the backends every provider test runs against
+var providerFactories = []struct {
+	name string
+	new  func(t testing.TB) StorageProvider
+}{
+	{name: "file", new: func(t testing.TB) StorageProvider {
+		provider, err := NewFileProvider(ProviderConfig{Type: FileProvider, StorageDir: t.TempDir(), MaxContentBytes: storage.DefaultMaxContentBytes})
+		if err != nil {
+			t.Fatalf("Failed to create file provider: %v", err)
+		}
+		return provider
+	}},
+	{name: "sqlite", new: func(t testing.TB) StorageProvider {
+		provider, err := NewSQLiteProvider(ProviderConfig{Type: SQLiteProvider, StorageDir: t.TempDir(), MaxContentBytes: storage.DefaultMaxContentBytes})
+		if err != nil {
+			t.Fatalf("Failed to create sqlite provider: %v", err)
+		}
+		t.Cleanup(func() { provider.(*SQLiteStorageProvider).Close() })
+		return provider
+	}},
+	{name: "encrypted", new: func(t testing.TB) StorageProvider {
+		return newConformanceEncrypted(t, false)
+	}},
+	{name: "encrypted-metadata", new: func(t testing.TB) StorageProvider {
+		return newConformanceEncrypted(t, true)
+	}},
+}
+
+// conformanceSalt is shared by the encrypted providers under test so the
+// key is only derived once
+var conformanceSalt = []byte("conformance-salt")
+
+// newConformanceEncrypted returns an encrypted file provider
+func newConformanceEncrypted(t testing.TB, encryptMetadata bool) StorageProvider {
+	inner, err := NewFileProvider(ProviderConfig{Type: FileProvider, StorageDir: t.TempDir(), MaxContentBytes: storage.DefaultMaxContentBytes})
+	if err != nil {
+		t.Fatalf("Failed to create file provider: %v", err)
+	}
+	provider, err := NewEncryptedProvider(inner, EncryptionConfig{
+		Passphrase:      "correct horse",
+		Salt:            conformanceSalt,
+		EncryptMetadata: encryptMetadata,
+		MaxContentBytes: storage.DefaultMaxContentBytes,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create encrypted provider: %v", err)
+	}
+	return provider
+}
+
+func TestProviderCRUD(t *testing.T) {
+	for _, factory := range providerFactories {
+		t.Run(factory.name, func(t *testing.T) {
+			provider := factory.new(t)
+
+			created, err := provider.Create(storage.CreateMemoryRequest{
+				Name:     "Test Memory",
+				Content:  "Test content",
+				Labels:   map[string]string{"test": "true"},
+				Metadata: map[string]any{"source": "test"},
+			})
+			if err != nil {
+				t.Fatalf("Failed to create memory: %v", err)
+			}
+			if created.ID == "" || created.CreatedAt.IsZero() {
+				t.Errorf("Expected ID and creation time to be set, got %+v", created)
+			}
+			if created.Labels["type"] != "manual" {
+				t.Errorf("Expected default type label, got %v", created.Labels)
+			}
+
+			got, err := provider.Get(created.ID)
+			if err != nil {
+				t.Fatalf("Failed to get memory: %v", err)
+			}
+			if got.Name != "Test Memory" || got.Content != "Test content" || got.Labels["test"] != "true" {
+				t.Errorf("Unexpected memory: %+v", got)
+			}
+			if got.Metadata["source"] != "test" {
+				t.Errorf("Expected metadata to round-trip, got %v", got.Metadata)
+			}
+
+			updated, err := provider.Update(storage.UpdateMemoryRequest{
+				ID:      created.ID,
+				Content: "Updated content",
+				Labels:  map[string]string{"type": "notes"},
+			})
+			if err != nil {
+				t.Fatalf("Failed to update memory: %v", err)
+			}
+			if updated.Name != "Test Memory" || updated.Content != "Updated content" || updated.Labels["type"] != "notes" {
+				t.Errorf("Unexpected updated memory: %+v", updated)
+			}
+			if _, ok := updated.Labels["test"]; ok {
+				t.Errorf("Expected labels to be replaced, got %v", updated.Labels)
+			}
+
+			if err := provider.Delete(created.ID); err != nil {
+				t.Fatalf("Failed to delete memory: %v", err)
+			}
+			if _, err := provider.Get(created.ID); !errors.Is(err, storage.ErrMemoryNotFound) {
+				t.Errorf("Expected ErrMemoryNotFound after delete, got %v", err)
+			}
+			if err := provider.Delete(created.ID); !errors.Is(err, storage.ErrMemoryNotFound) {
+				t.Errorf("Expected ErrMemoryNotFound deleting a missing memory, got %v", err)
+			}
+			if _, err := provider.Update(storage.UpdateMemoryRequest{ID: created.ID, Content: "x"}); !errors.Is(err, storage.ErrMemoryNotFound) {
+				t.Errorf("Expected ErrMemoryNotFound updating a missing memory, got %v", err)
+			}
+		})
+	}
+}
+
+func TestProviderValidation(t *testing.T) {
+	for _, factory := range providerFactories {
+		t.Run(factory.name, func(t *testing.T) {
+			provider := factory.new(t)
+
+			_, err := provider.Create(storage.CreateMemoryRequest{
+				Content: "content",
+				Labels:  map[string]string{"key": strings.Repeat("x", 64)},
+			})
+			if err == nil {
+				t.Error("Expected validation error for long label value")
+			}
+		})
+	}
+}
+
+func TestProviderLimits(t *testing.T) {
+	for _, factory := range providerFactories {
+		t.Run(factory.name, func(t *testing.T) {
+			provider := factory.new(t)
+
+			// Limits apply to what was written, not how it's stored
+			created, err := provider.Create(storage.CreateMemoryRequest{
+				Name:    strings.Repeat("n", storage.MaxNameLength),
+				Content: strings.Repeat("c", storage.DefaultMaxContentBytes),
+			})
+			if err != nil {
+				t.Fatalf("Failed to create memory at the limits: %v", err)
+			}
+			renamed := strings.Repeat("r", storage.MaxNameLength)
+			if _, err := provider.Update(storage.UpdateMemoryRequest{ID: created.ID, Name: renamed}); err != nil {
+				t.Fatalf("Failed to rename memory at the limit: %v", err)
+			}
+
+			_, err = provider.Create(storage.CreateMemoryRequest{Name: strings.Repeat("n", storage.MaxNameLength+1), Content: "content"})
+			if err == nil || !strings.Contains(err.Error(), "name too long") {
+				t.Errorf("Expected an overlong name rejected, got %v", err)
+			}
+			if _, err := provider.Update(storage.UpdateMemoryRequest{ID: created.ID, Name: renamed + "r"}); err == nil {
+				t.Error("Expected an overlong rename rejected")
+			}
+
+			_, err = provider.Create(storage.CreateMemoryRequest{Name: "Large", Content: strings.Repeat("c", storage.DefaultMaxContentBytes+1)})
+			if want := fmt.Sprintf("(%d bytes, max %d bytes)", storage.DefaultMaxContentBytes+1, storage.DefaultMaxContentBytes); err == nil || !strings.Contains(err.Error(), want) {
+				t.Errorf("Expected oversized content rejected with %s, got %v", want, err)
+			}
+			if _, err := provider.Update(storage.UpdateMemoryRequest{ID: created.ID, Content: "c", Append: true, Separator: ""}); err == nil {
+				t.Error("Expected appending past the content limit rejected")
+			}
+		})
+	}
+}
+
+func TestProviderRelations(t *testing.T) {
+	for _, factory := range providerFactories {
+		t.Run(factory.name, func(t *testing.T) {
+			provider := factory.new(t)
+
+			target, err := provider.Create(storage.CreateMemoryRequest{Content: "target"})
+			if err != nil {
+				t.Fatalf("Failed to create memory: %v", err)
+			}
+			relation := storage.Relation{Type: storage.DefaultRelationType, TargetID: target.ID}
+			source, err := provider.Create(storage.CreateMemoryRequest{Content: "source", Relations: []storage.Relation{relation}})
+			if err != nil {
+				t.Fatalf("Failed to create memory: %v", err)
+			}
+
+			got, err := provider.Get(source.ID)
+			if err != nil {
+				t.Fatalf("Failed to get memory: %v", err)
+			}
+			if len(got.Relations) != 1 || got.Relations[0] != relation {
+				t.Errorf("Expected relations to round-trip, got %v", got.Relations)
+			}
+
+			updated, err := provider.Update(storage.UpdateMemoryRequest{ID: source.ID, Content: "edited"})
+			if err != nil {
+				t.Fatalf("Failed to update memory: %v", err)
+			}
+			if len(updated.Relations) != 1 {
+				t.Errorf("Expected relations to be kept when not updated, got %v", updated.Relations)
+			}
+
+			updated, err = provider.Update(storage.UpdateMemoryRequest{ID: source.ID, Relations: []storage.Relation{}})
+			if err != nil {
+				t.Fatalf("Failed to update memory: %v", err)
+			}
+			if len(updated.Relations) != 0 {
+				t.Errorf("Expected an empty slice to clear relations, got %v", updated.Relations)
+			}
+		})
+	}
+}
+
+func TestProviderExpiry(t *testing.T) {
+	for _, factory := range providerFactories {
+		t.Run(factory.name, func(t *testing.T) {
+			provider := factory.new(t)
+
+			expiresAt := time.Now().Add(-time.Minute).Truncate(time.Second)
+			created, err := provider.Create(storage.CreateMemoryRequest{Content: "ephemeral", ExpiresAt: &expiresAt})
+			if err != nil {
+				t.Fatalf("Failed to create memory: %v", err)
+			}
+
+			got, err := provider.Get(created.ID)
+			if err != nil {
+				t.Fatalf("Failed to get memory: %v", err)
+			}
+			if got.ExpiresAt == nil || !got.ExpiresAt.Equal(expiresAt) {
+				t.Errorf("Expected expiry %v to round-trip, got %v", expiresAt, got.ExpiresAt)
+			}
+			if !got.IsExpired(time.Now()) {
+				t.Error("Expected memory to be expired")
+			}
+
+			if lister, ok := provider.(OptimizedLister); ok {
+				memories, err := lister.ListWithOptions(storage.ListOptions{UseIndex: true})
+				if err != nil {
+					t.Fatalf("Failed to list memories: %v", err)
+				}
+				if len(memories) != 1 || memories[0].ExpiresAt == nil {
+					t.Errorf("Expected expiry in metadata-only listing, got %+v", memories)
+				}
+			}
+
+			updated, err := provider.Update(storage.UpdateMemoryRequest{ID: created.ID, ExpiresAt: &time.Time{}})
+			if err != nil {
+				t.Fatalf("Failed to update memory: %v", err)
+			}
+			if updated.ExpiresAt != nil {
+				t.Errorf("Expected a zero time to clear the expiry, got %v", updated.ExpiresAt)
+			}
+		})
+	}
+}
+
+func TestProviderSkipDuplicate(t *testing.T) {
+	for _, factory := range providerFactories {
+		t.Run(factory.name, func(t *testing.T) {
+			provider := factory.new(t)
+
+			first, err := provider.Create(storage.CreateMemoryRequest{Name: "Notes", Content: "same notes"})
+			if err != nil {
+				t.Fatalf("Failed to create memory: %v", err)
+			}
+			if storage.MemoryContentHash(first) != storage.ContentHash("same notes") {
+				t.Errorf("Expected content hash in metadata, got %v", first.Metadata)
+			}
+
+			again, err := provider.Create(storage.CreateMemoryRequest{Name: "Notes again", Content: "same notes", SkipDuplicate: true})
+			if err != nil {
+				t.Fatalf("Failed to create duplicate: %v", err)
+			}
+			if again.ID != first.ID {
+				t.Errorf("Expected the existing memory %s, got %s", first.ID, again.ID)
+			}
+
+			distinct, err := provider.Create(storage.CreateMemoryRequest{Content: "other notes", SkipDuplicate: true})
+			if err != nil {
+				t.Fatalf("Failed to create memory: %v", err)
+			}
+			if distinct.ID == first.ID {
+				t.Error("Expected distinct content to create a new memory")
+			}
+
+			copied, err := provider.Create(storage.CreateMemoryRequest{Content: "same notes"})
+			if err != nil {
+				t.Fatalf("Failed to create memory: %v", err)
+			}
+			if copied.ID == first.ID {
+				t.Error("Expected a duplicate to be created without SkipDuplicate")
+			}
+
+			updated, err := provider.Update(storage.UpdateMemoryRequest{ID: copied.ID, Content: "changed"})
+			if err != nil {
+				t.Fatalf("Failed to update memory: %v", err)
+			}
+			if storage.MemoryContentHash(updated) != storage.ContentHash("changed") {
+				t.Errorf("Expected the hash to follow content updates, got %v", updated.Metadata)
+			}
+		})
+	}
+}
+
+func TestProviderAppend(t *testing.T) {
+	for _, factory := range providerFactories {
+		t.Run(factory.name, func(t *testing.T) {
+			provider := factory.new(t)
+
+			memory, err := provider.Create(storage.CreateMemoryRequest{Name: "Log", Content: "first"})
+			if err != nil {
+				t.Fatalf("Failed to create memory: %v", err)
+			}
+
+			updated, err := provider.Update(storage.UpdateMemoryRequest{ID: memory.ID, Content: "second", Append: true, Separator: "\n---\n"})
+			if err != nil {
+				t.Fatalf("Failed to append: %v", err)
+			}
+			if updated.Content != "first\n---\nsecond" {
+				t.Errorf("Expected appended content, got %q", updated.Content)
+			}
+			if storage.MemoryContentHash(updated) != storage.ContentHash(updated.Content) {
+				t.Errorf("Expected the hash to follow appended content, got %v", updated.Metadata)
+			}
+
+			got, err := provider.Get(memory.ID)
+			if err != nil {
+				t.Fatalf("Failed to get memory: %v", err)
+			}
+			if got.Content != updated.Content {
+				t.Errorf("Expected stored content %q, got %q", updated.Content, got.Content)
+			}
+		})
+	}
+}
+
+func TestProviderMetadataSearch(t *testing.T) {
+	for _, factory := range providerFactories {
+		t.Run(factory.name, func(t *testing.T) {
+			provider := factory.new(t)
+
+			cursorChat, err := provider.Create(storage.CreateMemoryRequest{
+				Name:    "Imported",
+				Content: "chat",
+				Metadata: map[string]any{
+					"source":  "cursor",
+					"turns":   12,
+					"summary": map[string]any{"model": "gpt-4o", "tokens": 350},
+				},
+			})
+			if err != nil {
+				t.Fatalf("Failed to create memory: %v", err)
+			}
+			if _, err := provider.Create(storage.CreateMemoryRequest{
+				Name:     "Manual",
+				Content:  "note",
+				Metadata: map[string]any{"source": "manual"},
+			}); err != nil {
+				t.Fatalf("Failed to create memory: %v", err)
+			}
+
+			tests := []struct {
+				name     string
+				selector map[string]string
+				want     int
+			}{
+				{"string value", map[string]string{"source": "cursor"}, 1},
+				{"number value", map[string]string{"turns": "12"}, 1},
+				{"nested path", map[string]string{"summary.model": "gpt-4o"}, 1},
+				{"all must match", map[string]string{"source": "cursor", "summary.tokens": "351"}, 0},
+				{"missing key", map[string]string{"owner": "me"}, 0},
+				{"no match", map[string]string{"source": "vscode"}, 0},
+			}
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					response, err := provider.Search(storage.SearchRequest{MetadataSelector: tt.selector, UseIndex: true})
+					if err != nil {
+						t.Fatalf("Failed to search memories: %v", err)
+					}
+					if len(response.Memories) != tt.want {
+						t.Fatalf("Expected %d result(s), got %d", tt.want, len(response.Memories))
+					}
+					if tt.want == 1 && response.Memories[0].ID != cursorChat.ID {
+						t.Errorf("Expected %s, got %s", cursorChat.ID, response.Memories[0].ID)
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestProviderExcludeLabels(t *testing.T) {
+	for _, factory := range providerFactories {
+		t.Run(factory.name, func(t *testing.T) {
+			provider := factory.new(t)
+
+			for _, m := range []struct{ name, labels string }{
+				{"chat", "type=chat,source=cursor"},
+				{"test chat", "type=chat,source=test"},
+				{"draft chat", "type=chat,status=draft"},
+				{"test note", "type=note,source=test"},
+			} {
+				labels := map[string]string{}
+				for _, pair := range strings.Split(m.labels, ",") {
+					kv := strings.SplitN(pair, "=", 2)
+					labels[kv[0]] = kv[1]
+				}
+				if _, err := provider.Create(storage.CreateMemoryRequest{Name: m.name, Content: m.name + " content", Labels: labels}); err != nil {
+					t.Fatalf("Failed to create memory: %v", err)
+				}
+			}
+
+			tests := []struct {
+				name string
+				req  storage.SearchRequest
+				want []string
+			}{
+				{
+					name: "include then exclude",
+					req:  storage.SearchRequest{LabelSelector: map[string]string{"type": "chat"}, ExcludeLabels: map[string]string{"source": "test"}},
+					want: []string{"chat", "draft chat"},
+				},
+				{
+					name: "any exclude label removes a match",
+					req:  storage.SearchRequest{LabelSelector: map[string]string{"type": "chat"}, ExcludeLabels: map[string]string{"source": "test", "status": "draft"}},
+					want: []string{"chat"},
+				},
+				{
+					name: "exclude alone",
+					req:  storage.SearchRequest{ExcludeLabels: map[string]string{"source": "test"}},
+					want: []string{"chat", "draft chat"},
+				},
+				{
+					name: "with text query",
+					req:  storage.SearchRequest{Query: "content", ExcludeLabels: map[string]string{"type": "chat"}},
+					want: []string{"test note"},
+				},
+			}
+			for _, tt := range tests {
+				for _, useIndex := range []bool{true, false} {
+					tt.req.UseIndex = useIndex
+					response, err := provider.Search(tt.req)
+					if err != nil {
+						t.Fatalf("Failed to search memories: %v", err)
+					}
+					var got []string
+					for _, memory := range response.Memories {
+						got = append(got, memory.Name)
+					}
+					sort.Strings(got)
+					if !reflect.DeepEqual(got, tt.want) {
+						t.Errorf("%s (index=%v): expected %v, got %v", tt.name, useIndex, tt.want, got)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestProviderListAndSearch(t *testing.T) {
+	for _, factory := range providerFactories {
+		t.Run(factory.name, func(t *testing.T) {
+			provider := factory.new(t)
+
+			for _, req := range []storage.CreateMemoryRequest{
+				{Name: "Go Tutorial", Content: "Learning Go programming language", Labels: map[string]string{"type": "tutorial", "lang": "go"}},
+				{Name: "Python Guide", Content: "Python best practices", Labels: map[string]string{"type": "tutorial", "lang": "python"}},
+				{Name: "Go Advanced", Content: "Advanced Go concepts, 100% coverage", Labels: map[string]string{"type": "notes", "lang": "go"}},
+			} {
+				if _, err := provider.Create(req); err != nil {
+					t.Fatalf("Failed to create memory: %v", err)
+				}
+			}
+
+			memories, err := provider.List()
+			if err != nil {
+				t.Fatalf("Failed to list memories: %v", err)
+			}
+			if len(memories) != 3 {
+				t.Fatalf("Expected 3 memories, got %d", len(memories))
+			}
+			for _, memory := range memories {
+				if memory.Content == "" {
+					t.Errorf("Expected List to include content for %s", memory.ID)
+				}
+			}
+
+			tests := []struct {
+				name string
+				req  storage.SearchRequest
+				want []string
+			}{
+				{name: "short query", req: storage.SearchRequest{Query: "Go"}, want: []string{"Go Tutorial", "Go Advanced"}},
+				{name: "case insensitive", req: storage.SearchRequest{Query: "PRACTICES"}, want: []string{"Python Guide"}},
+				{name: "substring", req: storage.SearchRequest{Query: "ogramm"}, want: []string{"Go Tutorial"}},
+				{name: "literal percent", req: storage.SearchRequest{Query: "100%"}, want: []string{"Go Advanced"}},
+				{name: "quotes", req: storage.SearchRequest{Query: `"go"`}, want: nil},
+				{name: "labels", req: storage.SearchRequest{LabelSelector: map[string]string{"lang": "go"}}, want: []string{"Go Tutorial", "Go Advanced"}},
+				{name: "query and labels", req: storage.SearchRequest{Query: "go", LabelSelector: map[string]string{"type": "notes"}}, want: []string{"Go Advanced"}},
+				{name: "limit", req: storage.SearchRequest{LabelSelector: map[string]string{"type": "tutorial"}, Limit: 1}, want: []string{"Go Tutorial"}},
+				{name: "zero limit", req: storage.SearchRequest{LabelSelector: map[string]string{"type": "tutorial"}, Limit: 0}, want: []string{"Go Tutorial", "Python Guide"}},
+				{name: "negative limit", req: storage.SearchRequest{Query: "go", Limit: -1}, want: []string{"Go Tutorial", "Go Advanced"}},
+				{name: "query and limit", req: storage.SearchRequest{Query: "go", Limit: 1}, want: []string{"Go Tutorial"}},
+			}
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					resp, err := provider.Search(tt.req)
+					if err != nil {
+						t.Fatalf("Search failed: %v", err)
+					}
+					var names []string
+					for _, memory := range resp.Memories {
+						names = append(names, memory.Name)
+					}
+					if strings.Join(names, ",") != strings.Join(tt.want, ",") {
+						t.Errorf("Expected %v, got %v", tt.want, names)
+					}
+					if resp.Total != 3 {
+						t.Errorf("Expected total 3, got %d", resp.Total)
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestProviderSearchRanking(t *testing.T) {
+	for _, factory := range providerFactories {
+		t.Run(factory.name, func(t *testing.T) {
+			provider := factory.new(t)
+
+			// The content-only match is stored first, so results only put
+			// the name match ahead of it if they're ranked
+			for _, req := range []storage.CreateMemoryRequest{
+				{Name: "Deploy checklist", Content: "Rotate the auth tokens, then check auth logs"},
+				{Name: "Auth notes", Content: "Use OAuth with PKCE"},
+			} {
+				if _, err := provider.Create(req); err != nil {
+					t.Fatalf("Failed to create memory: %v", err)
+				}
+			}
+
+			resp, err := provider.Search(storage.SearchRequest{Query: "auth", IncludeContent: true})
+			if err != nil {
+				t.Fatalf("Search failed: %v", err)
+			}
+			if len(resp.Memories) != 2 || resp.Memories[0].Name != "Auth notes" {
+				t.Fatalf("Expected the name match first, got %v", memoryNames(resp.Memories))
+			}
+			if resp.Memories[0].Score <= resp.Memories[1].Score || resp.Memories[1].Score <= 0 {
+				t.Errorf("Expected descending relevance scores, got %v and %v", resp.Memories[0].Score, resp.Memories[1].Score)
+			}
+
+			resp, err = provider.Search(storage.SearchRequest{Query: "auth", Limit: 1, IncludeContent: true})
+			if err != nil {
+				t.Fatalf("Search failed: %v", err)
+			}
+			if len(resp.Memories) != 1 || resp.Memories[0].Name != "Auth notes" {
+				t.Errorf("Expected the limit to keep the best match, got %v", memoryNames(resp.Memories))
+			}
+		})
+	}
+}
+
+func TestProviderSearchSort(t *testing.T) {
+	for _, factory := range providerFactories {
+		t.Run(factory.name, func(t *testing.T) {
+			provider := factory.new(t)
+
+			for _, req := range []storage.CreateMemoryRequest{
+				{Name: "Charlie auth", Content: "notes", Labels: map[string]string{"owner": "bea"}},
+				{Name: "Alpha", Content: "auth notes", Labels: map[string]string{"owner": "cal"}},
+				{Name: "Bravo auth", Content: "auth notes", Labels: map[string]string{"owner": "ada"}},
+			} {
+				if _, err := provider.Create(req); err != nil {
+					t.Fatalf("Failed to create memory: %v", err)
+				}
+			}
+
+			tests := []struct {
+				sortBy string
+				order  string
+				limit  int
+				want   []string
+			}{
+				{sortBy: storage.SortByName, want: []string{"Alpha", "Bravo auth", "Charlie auth"}},
+				{sortBy: storage.SortByName, order: storage.SortDescending, limit: 2, want: []string{"Charlie auth", "Bravo auth"}},
+				{sortBy: storage.LabelSortPrefix + "owner", limit: 1, want: []string{"Bravo auth"}},
+			}
+			for _, tt := range tests {
+				resp, err := provider.Search(storage.SearchRequest{Query: "auth", SortBy: tt.sortBy, SortOrder: tt.order, Limit: tt.limit, IncludeContent: true})
+				if err != nil {
+					t.Fatalf("Search failed: %v", err)
+				}
+				if got := memoryNames(resp.Memories); strings.Join(got, ",") != strings.Join(tt.want, ",") {
+					t.Errorf("sort %s %s limit %d: expected %v, got %v", tt.sortBy, tt.order, tt.limit, tt.want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestProviderTrash(t *testing.T) {
+	for _, factory := range providerFactories {
+		t.Run(factory.name, func(t *testing.T) {
+			provider := factory.new(t)
+			trash, ok := provider.(TrashProvider)
+			if !ok {
+				t.Skip("provider has no trash")
+			}
+
+			memory, err := provider.Create(storage.CreateMemoryRequest{Name: "Doomed", Content: "soon gone"})
+			if err != nil {
+				t.Fatalf("Failed to create memory: %v", err)
+			}
+			if err := trash.Trash(memory.ID); err != nil {
+				t.Fatalf("Failed to trash memory: %v", err)
+			}
+			if _, err := provider.Get(memory.ID); err == nil {
+				t.Error("Expected trashed memory to be hidden")
+			}
+			if resp, err := provider.Search(storage.SearchRequest{Query: "soon"}); err != nil || len(resp.Memories) != 0 {
+				t.Errorf("Expected trashed memory to be excluded from search, got %v (err=%v)", resp, err)
+			}
+
+			trashed, err := trash.ListTrash()
+			if err != nil || len(trashed) != 1 || trashed[0].ID != memory.ID || trashed[0].DeletedAt.IsZero() {
+				t.Fatalf("Expected memory in trash, got %+v (err=%v)", trashed, err)
+			}
+
+			restored, err := trash.Restore(memory.ID)
+			if err != nil {
+				t.Fatalf("Failed to restore memory: %v", err)
+			}
+			if restored.Content != "soon gone" {
+				t.Errorf("Expected restored content, got %q", restored.Content)
+			}
+			if _, err := trash.Restore(memory.ID); err == nil {
+				t.Error("Expected error restoring a memory that isn't trashed")
+			}
+
+			if err := trash.Trash(memory.ID); err != nil {
+				t.Fatalf("Failed to trash memory: %v", err)
+			}
+			removed, err := trash.EmptyTrash(0)
+			if err != nil || removed != 1 {
+				t.Errorf("Expected 1 memory removed, got %d (err=%v)", removed, err)
+			}
+			if trashed, _ := trash.ListTrash(); len(trashed) != 0 {
+				t.Errorf("Expected empty trash, got %+v", trashed)
+			}
+		})
+	}
+}
+
+func TestProviderImport(t *testing.T) {
+	for _, factory := range providerFactories {
+		t.Run(factory.name, func(t *testing.T) {
+			source := factory.new(t)
+			memory, err := source.Create(storage.CreateMemoryRequest{Name: "Original", Content: "keep my id"})
+			if err != nil {
+				t.Fatalf("Failed to create memory: %v", err)
+			}
+
+			target := factory.new(t)
+			importer, ok := target.(MemoryImporter)
+			if !ok {
+				t.Fatalf("Expected %s provider to support import", factory.name)
+			}
+			if err := importer.Import(*memory); err != nil {
+				t.Fatalf("Failed to import memory: %v", err)
+			}
+			if err := importer.Import(*memory); err == nil {
+				t.Error("Expected error importing a duplicate ID")
+			}
+
+			got, err := target.Get(memory.ID)
+			if err != nil {
+				t.Fatalf("Failed to get imported memory: %v", err)
+			}
+			if !got.CreatedAt.Equal(memory.CreatedAt) || got.Content != memory.Content {
+				t.Errorf("Expected imported memory to match original, got %+v", got)
+			}
+		})
+	}
+}
+
+func BenchmarkProviderSearch(b *testing.B) {
+	const count = 1000
+	for _, factory := range providerFactories {
+		provider := factory.new(b)
+		now := time.Now()
+		for i := range count {
+			// Import with explicit IDs so generated IDs can't collide
+			err := provider.(MemoryImporter).Import(storage.Memory{
+				ID:        fmt.Sprintf("mem_bench_%04d", i),
+				Name:      fmt.Sprintf("Memory %d", i),
+				Content:   fmt.Sprintf("Session notes %d about topic-%d and some filler text", i, i%50),
+				Labels:    map[string]string{"topic": fmt.Sprintf("t%d", i%50)},
+				CreatedAt: now,
+				UpdatedAt: now,
+			})
+			if err != nil {
+				b.Fatalf("Failed to create memory: %v", err)
+			}
+		}
+
+		b.Run(factory.name+"/query", func(b *testing.B) {
+			for b.Loop() {
+				if _, err := provider.Search(storage.SearchRequest{Query: "topic-7 "}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(factory.name+"/labels", func(b *testing.B) {
+			for b.Loop() {
+				if _, err := provider.Search(storage.SearchRequest{LabelSelector: map[string]string{"topic": "t7"}}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestProviderMultiQuerySearch(t *testing.T) {
+	for _, factory := range providerFactories {
+		t.Run(factory.name, func(t *testing.T) {
+			provider := factory.new(t)
+
+			fixtures := map[string]string{
+				"Login flow":    "Session cookies and auth middleware",
+				"OAuth setup":   "Configure the oauth client for auth",
+				"Token refresh": "Refreshing oauth tokens in the background",
+				"Build cache":   "Speeding up CI with a shared cache",
+			}
+			for name, content := range fixtures {
+				if _, err := provider.Create(storage.CreateMemoryRequest{Name: name, Content: content}); err != nil {
+					t.Fatalf("Failed to create memory: %v", err)
+				}
+			}
+
+			tests := []struct {
+				name     string
+				req      storage.SearchRequest
+				expected []string
+			}{
+				{"and", storage.SearchRequest{Queries: []string{"auth", "oauth"}}, []string{"OAuth setup", "Token refresh"}},
+				{"and with query", storage.SearchRequest{Query: "oauth", Queries: []string{"client"}}, []string{"OAuth setup"}},
+				{"or", storage.SearchRequest{Queries: []string{"cookies", "cache"}, MatchAny: true}, []string{"Build cache", "Login flow"}},
+				{"or short queries", storage.SearchRequest{Queries: []string{"CI", "xyz"}, MatchAny: true}, []string{"Build cache"}},
+				{"and no match", storage.SearchRequest{Queries: []string{"cookies", "cache"}}, nil},
+			}
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					response, err := provider.Search(tt.req)
+					if err != nil {
+						t.Fatalf("Failed to search memories: %v", err)
+					}
+					var names []string
+					for _, memory := range response.Memories {
+						names = append(names, memory.Name)
+					}
+					sort.Strings(names)
+					if !reflect.DeepEqual(names, tt.expected) {
+						t.Errorf("Expected %v, got %v", tt.expected, names)
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestProviderRecordAccess(t *testing.T) {
+	for _, factory := range providerFactories {
+		t.Run(factory.name, func(t *testing.T) {
+			provider := factory.new(t)
+			recorder, ok := provider.(AccessRecorder)
+			if !ok {
+				t.Fatalf("Expected %s to record access", factory.name)
+			}
+
+			created, err := provider.Create(storage.CreateMemoryRequest{Name: "Read me", Content: "content"})
+			if err != nil {
+				t.Fatalf("Failed to create memory: %v", err)
+			}
+			if _, ok := created.LastAccessed(); ok {
+				t.Error("Expected a new memory to have no access time")
+			}
+
+			first := time.Now().Add(-time.Hour).Truncate(time.Second)
+			second := first.Add(30 * time.Minute)
+			for _, at := range []time.Time{first, second} {
+				if err := recorder.RecordAccess(created.ID, at); err != nil {
+					t.Fatalf("Failed to record access: %v", err)
+				}
+			}
+
+			got, err := provider.Get(created.ID)
+			if err != nil {
+				t.Fatalf("Failed to get memory: %v", err)
+			}
+			if at, ok := got.LastAccessed(); !ok || !at.Equal(second) {
+				t.Errorf("Expected lastAccessedAt %v, got %v", second, at)
+			}
+			if got.AccessCount() != 2 {
+				t.Errorf("Expected access count 2, got %d", got.AccessCount())
+			}
+			if !got.UpdatedAt.Equal(created.UpdatedAt) || got.Content != "content" {
+				t.Errorf("Expected reads to leave the memory unchanged, got %+v", got)
+			}
+
+			// Listings without content can still sort by last access
+			memories, err := provider.(OptimizedLister).ListWithOptions(storage.ListOptions{UseIndex: true})
+			if err != nil {
+				t.Fatalf("Failed to list memories: %v", err)
+			}
+			if at, ok := memories[0].LastAccessed(); !ok || !at.Equal(second) {
+				t.Errorf("Expected the listing to include lastAccessedAt %v, got %v", second, at)
+			}
+
+			if err := recorder.RecordAccess("missing", second); !errors.Is(err, storage.ErrMemoryNotFound) {
+				t.Errorf("Expected ErrMemoryNotFound for a missing memory, got %v", err)
+			}
+		})
+	}
+}
+
+func TestProviderTouch(t *testing.T) {
+	for _, factory := range providerFactories {
+		t.Run(factory.name, func(t *testing.T) {
+			provider := factory.new(t)
+			toucher, ok := provider.(Toucher)
+			if !ok {
+				t.Fatalf("Expected %s to support touch", factory.name)
+			}
+
+			created, err := provider.Create(storage.CreateMemoryRequest{
+				Name:     "Still relevant",
+				Content:  "content",
+				Labels:   map[string]string{"type": "notes"},
+				Metadata: map[string]any{"origin": "test"},
+			})
+			if err != nil {
+				t.Fatalf("Failed to create memory: %v", err)
+			}
+
+			at := created.UpdatedAt.Add(48 * time.Hour).Truncate(time.Second)
+			if err := toucher.Touch(created.ID, at); err != nil {
+				t.Fatalf("Failed to touch memory: %v", err)
+			}
+
+			got, err := provider.Get(created.ID)
+			if err != nil {
+				t.Fatalf("Failed to get memory: %v", err)
+			}
+			if !got.UpdatedAt.Equal(at) {
+				t.Errorf("Expected UpdatedAt %v, got %v", at, got.UpdatedAt)
+			}
+			touched := *got
+			touched.UpdatedAt = created.UpdatedAt
+			if !reflect.DeepEqual(touched.Labels, created.Labels) || touched.Content != created.Content ||
+				touched.Name != created.Name || !touched.CreatedAt.Equal(created.CreatedAt) ||
+				!reflect.DeepEqual(touched.Metadata, created.Metadata) {
+				t.Errorf("Expected only UpdatedAt to change, got %+v from %+v", got, created)
+			}
+
+			// Listings from the index see the new time
+			memories, err := provider.(OptimizedLister).ListWithOptions(storage.ListOptions{UseIndex: true})
+			if err != nil {
+				t.Fatalf("Failed to list memories: %v", err)
+			}
+			if !memories[0].UpdatedAt.Equal(at) {
+				t.Errorf("Expected the listing to include UpdatedAt %v, got %v", at, memories[0].UpdatedAt)
+			}
+
+			if err := toucher.Touch("missing", at); !errors.Is(err, storage.ErrMemoryNotFound) {
+				t.Errorf("Expected ErrMemoryNotFound for a missing memory, got %v", err)
+			}
+		})
+	}
+}
+
+func TestProviderStorageInfoLargest(t *testing.T) {
+	for _, factory := range providerFactories {
+		t.Run(factory.name, func(t *testing.T) {
+			provider := factory.new(t)
+
+			ids := make(map[int]string)
+			for _, size := range []int{10, 5000, 100, 1000} {
+				memory, err := provider.Create(storage.CreateMemoryRequest{
+					Name:    fmt.Sprintf("Size %d", size),
+					Content: strings.Repeat("x", size),
+				})
+				if err != nil {
+					t.Fatalf("Failed to create memory: %v", err)
+				}
+				ids[size] = memory.ID
+			}
+
+			info, err := provider.(StorageInfoProvider).GetStorageInfo()
+			if err != nil {
+				t.Fatalf("Failed to get storage info: %v", err)
+			}
+			if len(info.Memories) != 4 {
+				t.Fatalf("Expected sizes for 4 memories, got %d", len(info.Memories))
+			}
+
+			top := info.Largest(3)
+			want := []int{5000, 1000, 100}
+			if len(top) != len(want) {
+				t.Fatalf("Expected %d largest memories, got %d", len(want), len(top))
+			}
+			for i, size := range want {
+				if top[i].ID != ids[size] || top[i].Name != fmt.Sprintf("Size %d", size) {
+					t.Errorf("Expected the memory of size %d at position %d, got %+v", size, i, top[i])
+				}
+				if top[i].Size < int64(size) {
+					t.Errorf("Expected a size of at least %d, got %d", size, top[i].Size)
+				}
+			}
+			if len(info.Largest(10)) != 4 {
+				t.Errorf("Expected all 4 memories when asking for more, got %d", len(info.Largest(10)))
+			}
+		})
+	}
+}
+
+func TestProviderBinaryContent(t *testing.T) {
+	binary := "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\xff\xfe"
+
+	for _, factory := range providerFactories {
+		t.Run(factory.name, func(t *testing.T) {
+			provider := factory.new(t)
+
+			_, err := provider.Create(storage.CreateMemoryRequest{Name: "Image", Content: binary})
+			if !errors.Is(err, storage.ErrBinaryContent) {
+				t.Fatalf("Expected ErrBinaryContent, got %v", err)
+			}
+
+			created, err := provider.Create(storage.EncodeBinary(storage.CreateMemoryRequest{Name: "Image", Content: binary}))
+			if err != nil {
+				t.Fatalf("Failed to create encoded memory: %v", err)
+			}
+			got, err := provider.Get(created.ID)
+			if err != nil {
+				t.Fatalf("Failed to get memory: %v", err)
+			}
+			if !got.IsEncoded() {
+				t.Fatalf("Expected the content encoding to be recorded, got %v", got.Metadata)
+			}
+			data, err := got.DecodedContent()
+			if err != nil {
+				t.Fatalf("Failed to decode content: %v", err)
+			}
+			if string(data) != binary {
+				t.Errorf("Expected content to round-trip, got %q", data)
+			}
+
+			// Content replacing encoded content must be encoded too
+			if _, err := provider.Update(storage.UpdateMemoryRequest{ID: created.ID, Content: "not base64!"}); err == nil {
+				t.Error("Expected an error replacing encoded content with text")
+			}
+			// Updates that leave the content alone aren't affected
+			if _, err := provider.Update(storage.UpdateMemoryRequest{ID: created.ID, Name: "Logo"}); err != nil {
+				t.Errorf("Failed to rename encoded memory: %v", err)
+			}
+		})
+	}
+}
+
+// memoryNames returns the names of memories, in order
+func memoryNames(memories []storage.Memory) []string {
+	names := make([]string, len(memories))
+	for i, memory := range memories {
+		names[i] = memory.Name
+	}
+	return names
+}
@@ -0,0 +1,739 @@
+package providers
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+)
+
+const (
+	// EncryptionHeader prefixes every encrypted value and identifies the
+	// format version
+	EncryptionHeader = storage.SealedPrefix
+
+	// EncryptionSaltSize is the size of the key derivation salt in bytes
+	EncryptionSaltSize = 16
+
+	// EncryptionSaltFile stores the key derivation salt in the storage
+	// directory
+	EncryptionSaltFile = "encryption.salt"
+
+	encryptionKeySize    = 32
+	encryptionIterations = 600000
+
+	// encryptedLabelsKey is the metadata field holding the encrypted labels
+	// when metadata encryption is enabled
+	encryptedLabelsKey = "encryptedLabels"
+)
+
+// sealedMetadataKeys are metadata fields derived from the content, such as
+// generated summaries, which are encrypted whenever the content is
+var sealedMetadataKeys = []string{storage.SummaryKey}
+
+// ErrDecryptionFailed is returned when an encrypted value cannot be
+// decrypted, usually because the key is wrong
+var ErrDecryptionFailed = errors.New("failed to decrypt: wrong encryption key or corrupted data")
+
+var (
+	_ StorageProvider     = (*EncryptedProvider)(nil)
+	_ OptimizedLister     = (*EncryptedProvider)(nil)
+	_ MemoryStreamer      = (*EncryptedProvider)(nil)
+	_ StorageInfoProvider = (*EncryptedProvider)(nil)
+	_ TrashProvider       = (*EncryptedProvider)(nil)
+	_ Syncer              = (*EncryptedProvider)(nil)
+	_ MemoryImporter      = (*EncryptedProvider)(nil)
+	_ Batcher             = (*EncryptedProvider)(nil)
+	_ AccessRecorder      = (*EncryptedProvider)(nil)
+	_ Toucher             = (*EncryptedProvider)(nil)
+	_ Compactor           = (*EncryptedProvider)(nil)
+	_ Validator           = (*EncryptedProvider)(nil)
+)
+
+// EncryptionConfig configures an EncryptedProvider
+type EncryptionConfig struct {
+	// Passphrase the encryption key is derived from
+	Passphrase string
+	// Salt for key derivation. A random salt is generated when empty; every
+	// value records the salt it was written with, so reads work either way.
+	Salt []byte
+	// EncryptMetadata also encrypts memory names and labels. Labels are
+	// too short to hold ciphertext, so they are moved into an encrypted
+	// metadata field and the stored label set is left empty.
+	EncryptMetadata bool
+	// MaxContentBytes limits the size of the plaintext content; 0 means
+	// unlimited. The wrapped provider only sees ciphertext, so it doesn't
+	// apply its own limit to encrypted content.
+	MaxContentBytes int64
+}
+
+// EncryptedProvider wraps another provider and encrypts memory content, and
+// the summaries generated from it, at rest with AES-256-GCM. Each value is
+// stored as the version header followed by base64(salt || nonce ||
+// ciphertext), with a fresh nonce per write. Values without the header are
+// passed through unchanged, so an existing plaintext store stays readable.
+type EncryptedProvider struct {
+	inner           StorageProvider
+	passphrase      string
+	salt            []byte
+	encryptMetadata bool
+	maxContentBytes int64
+
+	mu    sync.Mutex
+	aeads map[string]cipher.AEAD
+}
+
+// NewEncryptedProvider wraps inner with transparent encryption
+func NewEncryptedProvider(inner StorageProvider, config EncryptionConfig) (*EncryptedProvider, error) {
+	if config.Passphrase == "" {
+		return nil, errors.New("encryption passphrase must not be empty")
+	}
+
+	salt := config.Salt
+	if len(salt) == 0 {
+		salt = make([]byte, EncryptionSaltSize)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, fmt.Errorf("failed to generate salt: %w", err)
+		}
+	}
+	if len(salt) != EncryptionSaltSize {
+		return nil, fmt.Errorf("encryption salt must be %d bytes, got %d", EncryptionSaltSize, len(salt))
+	}
+
+	return &EncryptedProvider{
+		inner:           inner,
+		passphrase:      config.Passphrase,
+		salt:            salt,
+		encryptMetadata: config.EncryptMetadata,
+		maxContentBytes: config.MaxContentBytes,
+		aeads:           make(map[string]cipher.AEAD),
+	}, nil
+}
+
+// Create encrypts and stores a new memory
+func (e *EncryptedProvider) Create(req storage.CreateMemoryRequest) (*storage.Memory, error) {
+	if req.SkipDuplicate {
+		// Ciphertext hashes never match because of the random nonce, so
+		// compare decrypted content instead
+		req.SkipDuplicate = false
+		existing, err := e.findByContent(req.Content)
+		if err != nil || existing != nil {
+			return existing, err
+		}
+	}
+
+	if err := e.validatePlaintext(&storage.Memory{Name: req.Name, Labels: req.Labels, Content: req.Content, Metadata: req.Metadata}); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if req.Content, err = e.encrypt(req.Content); err != nil {
+		return nil, err
+	}
+	if req.Metadata, err = e.sealMetadata(req.Metadata); err != nil {
+		return nil, err
+	}
+	if e.encryptMetadata {
+		if req.Name, err = e.encrypt(req.Name); err != nil {
+			return nil, err
+		}
+		if req.Labels, req.Metadata, err = e.encryptLabels(req.Labels, req.Metadata); err != nil {
+			return nil, err
+		}
+	}
+
+	memory, err := e.inner.Create(req)
+	if err != nil {
+		return nil, err
+	}
+	return memory, e.decryptMemory(memory)
+}
+
+// findByContent returns a memory whose decrypted content equals content, or
+// nil if there is none
+func (e *EncryptedProvider) findByContent(content string) (*storage.Memory, error) {
+	memories, err := e.List()
+	if err != nil {
+		return nil, err
+	}
+	for i := range memories {
+		if memories[i].Content == content {
+			return &memories[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// Import encrypts a memory and stores it with its existing ID and
+// timestamps
+func (e *EncryptedProvider) Import(memory storage.Memory) error {
+	importer, ok := e.inner.(MemoryImporter)
+	if !ok {
+		return fmt.Errorf("storage provider %s does not support import", e.inner.GetProviderType())
+	}
+
+	if err := e.validatePlaintext(&memory); err != nil {
+		return err
+	}
+
+	var err error
+	if memory.Content, err = e.encrypt(memory.Content); err != nil {
+		return err
+	}
+	if memory.Metadata, err = e.sealMetadata(memory.Metadata); err != nil {
+		return err
+	}
+	if e.encryptMetadata {
+		if memory.Name, err = e.encrypt(memory.Name); err != nil {
+			return err
+		}
+		if memory.Labels, memory.Metadata, err = e.encryptLabels(memory.Labels, memory.Metadata); err != nil {
+			return err
+		}
+	}
+	return importer.Import(memory)
+}
+
+// Get retrieves and decrypts a memory
+func (e *EncryptedProvider) Get(id string) (*storage.Memory, error) {
+	memory, err := e.inner.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	return memory, e.decryptMemory(memory)
+}
+
+// Update encrypts the changed fields and updates the memory
+func (e *EncryptedProvider) Update(req storage.UpdateMemoryRequest) (*storage.Memory, error) {
+	var err error
+	target := &storage.Memory{Name: req.Name, Labels: req.Labels, Metadata: req.Metadata}
+	if req.Content != "" {
+		existing, err := e.Get(req.ID)
+		if err != nil {
+			return nil, err
+		}
+		if req.Append {
+			// Ciphertexts can't be concatenated, so append to the decrypted
+			// content and replace it
+			req.Content = storage.AppendContent(existing.Content, req.Content, req.Separator)
+			req.Append = false
+		}
+
+		// The new content is checked against the memory's encoding
+		target.Content = req.Content
+		target.Metadata = make(map[string]any, len(existing.Metadata)+len(req.Metadata))
+		for _, metadata := range []map[string]any{existing.Metadata, req.Metadata} {
+			for k, v := range metadata {
+				target.Metadata[k] = v
+			}
+		}
+	}
+	// The wrapped provider only sees ciphertext, so check the plaintext
+	// first
+	if err := e.validatePlaintext(target); err != nil {
+		return nil, err
+	}
+	if req.Content != "" {
+		if req.Content, err = e.encrypt(req.Content); err != nil {
+			return nil, err
+		}
+	}
+	if req.Metadata, err = e.sealMetadata(req.Metadata); err != nil {
+		return nil, err
+	}
+	if e.encryptMetadata {
+		if req.Name != "" {
+			if req.Name, err = e.encrypt(req.Name); err != nil {
+				return nil, err
+			}
+		}
+		if req.Labels != nil {
+			if req.Labels, req.Metadata, err = e.encryptLabels(req.Labels, req.Metadata); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	memory, err := e.inner.Update(req)
+	if err != nil {
+		return nil, err
+	}
+	return memory, e.decryptMemory(memory)
+}
+
+// Delete deletes a memory
+func (e *EncryptedProvider) Delete(id string) error {
+	return e.inner.Delete(id)
+}
+
+// List returns all memories, decrypted
+func (e *EncryptedProvider) List() ([]storage.Memory, error) {
+	memories, err := e.inner.List()
+	if err != nil {
+		return nil, err
+	}
+	return memories, e.decryptMemories(memories)
+}
+
+// ListWithOptions lists memories through the wrapped provider's optimized
+// path when it has one. Encrypted labels live alongside the content, so
+// they force a full load.
+func (e *EncryptedProvider) ListWithOptions(opts storage.ListOptions) ([]storage.Memory, error) {
+	if e.encryptMetadata {
+		opts.IncludeContent = true
+	}
+	lister, ok := e.inner.(OptimizedLister)
+	if !ok {
+		return e.List()
+	}
+	memories, err := lister.ListWithOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	return memories, e.decryptMemories(memories)
+}
+
+// StreamMemories decrypts memories streamed from the wrapped provider as
+// they arrive. Encrypted labels force a full load, as in ListWithOptions.
+func (e *EncryptedProvider) StreamMemories(ctx context.Context, opts storage.ListOptions, out chan<- storage.Memory) error {
+	defer close(out)
+	if e.encryptMetadata {
+		opts.IncludeContent = true
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	in := make(chan storage.Memory)
+	streamErr := make(chan error, 1)
+	go func() { streamErr <- StreamMemories(ctx, e.inner, opts, in) }()
+
+	var err error
+	for memory := range in {
+		if err != nil {
+			continue // Drain what the stream already read
+		}
+		if err = e.decryptMemory(&memory); err != nil {
+			cancel()
+			continue
+		}
+		select {
+		case out <- memory:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+	}
+	if innerErr := <-streamErr; err == nil {
+		err = innerErr
+	}
+	return err
+}
+
+// Search decrypts memories before matching, since the wrapped provider only
+// sees ciphertext. Label-only searches are delegated when labels are stored
+// in plaintext.
+func (e *EncryptedProvider) Search(req storage.SearchRequest) (*storage.SearchResponse, error) {
+	if len(req.TextQueries()) == 0 && !e.encryptMetadata {
+		resp, err := e.inner.Search(req)
+		if err != nil {
+			return nil, err
+		}
+		return resp, e.decryptMemories(resp.Memories)
+	}
+
+	memories, err := e.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list memories: %w", err)
+	}
+
+	filtered := storage.FilterMemories(memories, req)
+	if queries := req.TextQueries(); len(queries) > 0 {
+		storage.RankByRelevance(filtered, queries...)
+	}
+	if req.SortBy != "" {
+		if err := storage.SortMemories(filtered, req.SortBy, req.SortOrder); err != nil {
+			return nil, err
+		}
+	}
+	filtered = storage.ApplyLimit(filtered, req.Limit)
+
+	return &storage.SearchResponse{
+		Memories: filtered,
+		Total:    len(memories),
+	}, nil
+}
+
+// GetProviderType returns the wrapped provider's type
+func (e *EncryptedProvider) GetProviderType() ProviderType {
+	return e.inner.GetProviderType()
+}
+
+// GetProviderInfo returns the wrapped provider's information along with the
+// encryption settings
+func (e *EncryptedProvider) GetProviderInfo() map[string]interface{} {
+	info := e.inner.GetProviderInfo()
+	info["encryption"] = "aes-256-gcm"
+	info["encryptMetadata"] = e.encryptMetadata
+	return info
+}
+
+// ValidateConfig validates the wrapped provider
+func (e *EncryptedProvider) ValidateConfig() error {
+	return e.inner.ValidateConfig()
+}
+
+// GetStorageInfo returns the wrapped provider's storage information, with
+// memory names decrypted
+func (e *EncryptedProvider) GetStorageInfo() (*storage.StorageInfo, error) {
+	infoProvider, ok := e.inner.(StorageInfoProvider)
+	if !ok {
+		return nil, fmt.Errorf("storage provider %s does not report storage info", e.inner.GetProviderType())
+	}
+	info, err := infoProvider.GetStorageInfo()
+	if err != nil {
+		return nil, err
+	}
+	for i := range info.Memories {
+		if info.Memories[i].Name, err = e.decrypt(info.Memories[i].Name); err != nil {
+			return nil, fmt.Errorf("memory %s: %w", info.Memories[i].ID, err)
+		}
+	}
+	return info, nil
+}
+
+// Trash soft-deletes a memory
+func (e *EncryptedProvider) Trash(id string) error {
+	trash, err := e.trash()
+	if err != nil {
+		return err
+	}
+	return trash.Trash(id)
+}
+
+// ListTrash returns trashed memories, decrypted
+func (e *EncryptedProvider) ListTrash() ([]storage.TrashedMemory, error) {
+	trash, err := e.trash()
+	if err != nil {
+		return nil, err
+	}
+	trashed, err := trash.ListTrash()
+	if err != nil {
+		return nil, err
+	}
+	for i := range trashed {
+		if err := e.decryptMemory(&trashed[i].Memory); err != nil {
+			return nil, err
+		}
+	}
+	return trashed, nil
+}
+
+// Restore moves a memory out of the trash
+func (e *EncryptedProvider) Restore(id string) (*storage.Memory, error) {
+	trash, err := e.trash()
+	if err != nil {
+		return nil, err
+	}
+	memory, err := trash.Restore(id)
+	if err != nil {
+		return nil, err
+	}
+	return memory, e.decryptMemory(memory)
+}
+
+// EmptyTrash permanently deletes trashed memories
+func (e *EncryptedProvider) EmptyTrash(olderThan time.Duration) (int, error) {
+	trash, err := e.trash()
+	if err != nil {
+		return 0, err
+	}
+	return trash.EmptyTrash(olderThan)
+}
+
+// Sync synchronizes the wrapped provider. Values are already encrypted, so
+// remotes only ever see ciphertext.
+func (e *EncryptedProvider) Sync() error {
+	syncer, ok := e.inner.(Syncer)
+	if !ok {
+		return fmt.Errorf("storage provider %s does not support sync", e.inner.GetProviderType())
+	}
+	return syncer.Sync()
+}
+
+// Batch runs fn as a batch of the wrapped provider
+func (e *EncryptedProvider) Batch(fn func() error) error {
+	batcher, ok := e.inner.(Batcher)
+	if !ok {
+		return fmt.Errorf("storage provider %s: %w", e.inner.GetProviderType(), ErrBatchUnsupported)
+	}
+	return batcher.Batch(fn)
+}
+
+// RecordAccess records a read in the wrapped provider. Access metadata
+// isn't sensitive, so it's stored as-is.
+func (e *EncryptedProvider) RecordAccess(id string, at time.Time) error {
+	recorder, ok := e.inner.(AccessRecorder)
+	if !ok {
+		return fmt.Errorf("storage provider %s: %w", e.inner.GetProviderType(), ErrAccessUnsupported)
+	}
+	return recorder.RecordAccess(id, at)
+}
+
+// Touch sets a memory's UpdatedAt in the wrapped provider. The content
+// isn't rewritten, so nothing needs re-encrypting.
+func (e *EncryptedProvider) Touch(id string, at time.Time) error {
+	toucher, ok := e.inner.(Toucher)
+	if !ok {
+		return fmt.Errorf("storage provider %s: %w", e.inner.GetProviderType(), ErrTouchUnsupported)
+	}
+	return toucher.Touch(id, at)
+}
+
+// Compact compresses memories in the wrapped provider. Encrypted content is
+// base64 ciphertext, so compressing it only undoes the base64 expansion.
+func (e *EncryptedProvider) Compact() (storage.CompactResult, error) {
+	compactor, ok := e.inner.(Compactor)
+	if !ok {
+		return storage.CompactResult{}, fmt.Errorf("storage provider %s: %w", e.inner.GetProviderType(), ErrCompactUnsupported)
+	}
+	return compactor.Compact()
+}
+
+// Validate checks memories in the wrapped provider. Only content is
+// ciphertext unless names and labels are encrypted too, in which case the
+// stored values can't be checked and a repair would corrupt them.
+func (e *EncryptedProvider) Validate(fix bool) (storage.ValidateResult, error) {
+	validator, ok := e.inner.(Validator)
+	if !ok || e.encryptMetadata {
+		return storage.ValidateResult{}, fmt.Errorf("storage provider %s with encrypted names and labels: %w", e.inner.GetProviderType(), ErrValidateUnsupported)
+	}
+	return validator.Validate(fix)
+}
+
+func (e *EncryptedProvider) trash() (TrashProvider, error) {
+	trash, ok := e.inner.(TrashProvider)
+	if !ok {
+		return nil, fmt.Errorf("storage provider %s does not support the trash", e.inner.GetProviderType())
+	}
+	return trash, nil
+}
+
+// aead returns the cipher for salt, deriving the key on first use
+func (e *EncryptedProvider) aead(salt []byte) (cipher.AEAD, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if aead, ok := e.aeads[string(salt)]; ok {
+		return aead, nil
+	}
+
+	key, err := pbkdf2.Key(sha256.New, e.passphrase, salt, encryptionIterations, encryptionKeySize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive encryption key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	e.aeads[string(salt)] = aead
+	return aead, nil
+}
+
+// encrypt seals plaintext under a fresh nonce. Empty values stay empty.
+func (e *EncryptedProvider) encrypt(plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+
+	aead, err := e.aead(e.salt)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	sealed := make([]byte, 0, len(e.salt)+len(nonce)+len(plaintext)+aead.Overhead())
+	sealed = append(sealed, e.salt...)
+	sealed = append(sealed, nonce...)
+	sealed = aead.Seal(sealed, nonce, []byte(plaintext), []byte(EncryptionHeader))
+
+	return EncryptionHeader + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decrypt opens a value written by encrypt. Values without the header are
+// returned as-is.
+func (e *EncryptedProvider) decrypt(value string) (string, error) {
+	encoded, ok := strings.CutPrefix(value, EncryptionHeader)
+	if !ok {
+		return value, nil
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < EncryptionSaltSize {
+		return "", ErrDecryptionFailed
+	}
+
+	aead, err := e.aead(sealed[:EncryptionSaltSize])
+	if err != nil {
+		return "", err
+	}
+
+	rest := sealed[EncryptionSaltSize:]
+	if len(rest) < aead.NonceSize() {
+		return "", ErrDecryptionFailed
+	}
+	nonce, ciphertext := rest[:aead.NonceSize()], rest[aead.NonceSize():]
+
+	plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(EncryptionHeader))
+	if err != nil {
+		return "", ErrDecryptionFailed
+	}
+	return string(plaintext), nil
+}
+
+// encryptLabels moves labels into an encrypted metadata field, returning
+// the empty label set to store in their place. An empty, non-nil label set
+// is sealed too, since updates merge metadata and would otherwise keep the
+// labels it replaces.
+func (e *EncryptedProvider) encryptLabels(labels map[string]string, metadata map[string]any) (map[string]string, map[string]any, error) {
+	if labels == nil {
+		return labels, metadata, nil
+	}
+
+	data, err := json.Marshal(labels)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode labels: %w", err)
+	}
+	encrypted, err := e.encrypt(string(data))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	withLabels := make(map[string]any, len(metadata)+1)
+	for k, v := range metadata {
+		withLabels[k] = v
+	}
+	withLabels[encryptedLabelsKey] = encrypted
+	return map[string]string{}, withLabels, nil
+}
+
+// validatePlaintext checks memory's name, labels and content before they're
+// encrypted. The wrapped provider only sees ciphertext, which is always
+// text and longer than what it holds, so it skips these checks on it.
+func (e *EncryptedProvider) validatePlaintext(memory *storage.Memory) error {
+	if len(memory.Name) > storage.MaxNameLength {
+		return fmt.Errorf("validation failed: memory name too long (max %d characters)", storage.MaxNameLength)
+	}
+	if err := storage.ValidateLabels(memory.Labels); err != nil {
+		return fmt.Errorf("validation failed: %w", err)
+	}
+	if err := storage.ValidateContent(memory); err != nil {
+		return fmt.Errorf("validation failed: %w", err)
+	}
+	if err := storage.ValidateContentSize(memory.Content, e.maxContentBytes); err != nil {
+		return fmt.Errorf("validation failed: %w", err)
+	}
+	return nil
+}
+
+// sealMetadata encrypts the sealed fields of metadata, returning a copy so
+// callers' maps aren't modified
+func (e *EncryptedProvider) sealMetadata(metadata map[string]any) (map[string]any, error) {
+	var sealed map[string]any
+	for _, key := range sealedMetadataKeys {
+		value, ok := metadata[key].(string)
+		if !ok {
+			continue
+		}
+		encrypted, err := e.encrypt(value)
+		if err != nil {
+			return nil, err
+		}
+		if sealed == nil {
+			sealed = make(map[string]any, len(metadata))
+			for k, v := range metadata {
+				sealed[k] = v
+			}
+		}
+		sealed[key] = encrypted
+	}
+	if sealed == nil {
+		return metadata, nil
+	}
+	return sealed, nil
+}
+
+// decryptMemory decrypts memory in place
+func (e *EncryptedProvider) decryptMemory(memory *storage.Memory) error {
+	var err error
+	encrypted := storage.IsSealed(memory.Content)
+	if memory.Content, err = e.decrypt(memory.Content); err != nil {
+		return fmt.Errorf("memory %s: %w", memory.ID, err)
+	}
+	// The stored hash is of the ciphertext, which differs on every write;
+	// report the plaintext's instead, without storing it
+	if encrypted && storage.MemoryContentHash(memory) != "" {
+		storage.SetContentHash(memory)
+	}
+	if memory.Name, err = e.decrypt(memory.Name); err != nil {
+		return fmt.Errorf("memory %s: %w", memory.ID, err)
+	}
+	for _, key := range sealedMetadataKeys {
+		if value, ok := memory.Metadata[key].(string); ok {
+			if memory.Metadata[key], err = e.decrypt(value); err != nil {
+				return fmt.Errorf("memory %s: %w", memory.ID, err)
+			}
+		}
+	}
+
+	sealed, ok := memory.Metadata[encryptedLabelsKey].(string)
+	if !ok {
+		return nil
+	}
+	data, err := e.decrypt(sealed)
+	if err != nil {
+		return fmt.Errorf("memory %s: %w", memory.ID, err)
+	}
+	var labels map[string]string
+	if err := json.Unmarshal([]byte(data), &labels); err != nil {
+		return fmt.Errorf("memory %s: failed to decode labels: %w", memory.ID, err)
+	}
+
+	// Defaults applied by the wrapped provider stay visible unless the
+	// encrypted labels override them
+	if memory.Labels == nil {
+		memory.Labels = make(map[string]string, len(labels))
+	}
+	for k, v := range labels {
+		memory.Labels[k] = v
+	}
+	delete(memory.Metadata, encryptedLabelsKey)
+	return nil
+}
+
+func (e *EncryptedProvider) decryptMemories(memories []storage.Memory) error {
+	for i := range memories {
+		if err := e.decryptMemory(&memories[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,251 @@
+package providers
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+)
+
+func newTestFileProvider(t *testing.T) StorageProvider {
+	t.Helper()
+	provider, err := NewFileProvider(ProviderConfig{Type: FileProvider, StorageDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("Failed to create file provider: %v", err)
+	}
+	return provider
+}
+
+func newTestEncryptedProvider(t *testing.T, inner StorageProvider, passphrase string, encryptMetadata bool) *EncryptedProvider {
+	t.Helper()
+	provider, err := NewEncryptedProvider(inner, EncryptionConfig{
+		Passphrase:      passphrase,
+		EncryptMetadata: encryptMetadata,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create encrypted provider: %v", err)
+	}
+	return provider
+}
+
+func TestEncryptedProviderRoundTrip(t *testing.T) {
+	tests := []struct {
+		name            string
+		encryptMetadata bool
+	}{
+		{name: "content only", encryptMetadata: false},
+		{name: "with metadata", encryptMetadata: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := newTestFileProvider(t)
+			encrypted := newTestEncryptedProvider(t, inner, "correct horse", tt.encryptMetadata)
+
+			created, err := encrypted.Create(storage.CreateMemoryRequest{
+				Name:    "Secret chat",
+				Content: "the database password is hunter2",
+				Labels:  map[string]string{"type": "chat"},
+			})
+			if err != nil {
+				t.Fatalf("Failed to create memory: %v", err)
+			}
+			if created.Content != "the database password is hunter2" {
+				t.Errorf("Expected Create to return plaintext content, got %q", created.Content)
+			}
+
+			raw, err := inner.Get(created.ID)
+			if err != nil {
+				t.Fatalf("Failed to read raw memory: %v", err)
+			}
+			if !strings.HasPrefix(raw.Content, EncryptionHeader) || strings.Contains(raw.Content, "hunter2") {
+				t.Errorf("Expected content to be encrypted at rest, got %q", raw.Content)
+			}
+			if got := strings.HasPrefix(raw.Name, EncryptionHeader); got != tt.encryptMetadata {
+				t.Errorf("Expected name encrypted=%v, got %q", tt.encryptMetadata, raw.Name)
+			}
+			if got := raw.Labels["type"] != "chat"; got != tt.encryptMetadata {
+				t.Errorf("Expected labels hidden=%v, got %v", tt.encryptMetadata, raw.Labels)
+			}
+
+			memory, err := encrypted.Get(created.ID)
+			if err != nil {
+				t.Fatalf("Failed to get memory: %v", err)
+			}
+			if memory.Name != "Secret chat" || memory.Content != "the database password is hunter2" || memory.Labels["type"] != "chat" {
+				t.Errorf("Expected decrypted memory, got %+v", memory)
+			}
+
+			// Searches match against the decrypted values
+			resp, err := encrypted.Search(storage.SearchRequest{Query: "hunter2"})
+			if err != nil {
+				t.Fatalf("Failed to search: %v", err)
+			}
+			if len(resp.Memories) != 1 || resp.Memories[0].ID != created.ID {
+				t.Errorf("Expected content search to find the memory, got %+v", resp.Memories)
+			}
+
+			resp, err = encrypted.Search(storage.SearchRequest{LabelSelector: map[string]string{"type": "chat"}, IncludeContent: true})
+			if err != nil {
+				t.Fatalf("Failed to search: %v", err)
+			}
+			if len(resp.Memories) != 1 || resp.Memories[0].Content != "the database password is hunter2" {
+				t.Errorf("Expected label search to return the decrypted memory, got %+v", resp.Memories)
+			}
+
+			updated, err := encrypted.Update(storage.UpdateMemoryRequest{ID: created.ID, Content: "rotated to swordfish"})
+			if err != nil {
+				t.Fatalf("Failed to update memory: %v", err)
+			}
+			if updated.Content != "rotated to swordfish" {
+				t.Errorf("Expected updated plaintext content, got %q", updated.Content)
+			}
+		})
+	}
+}
+
+func TestEncryptedProviderWrongKey(t *testing.T) {
+	inner := newTestFileProvider(t)
+	encrypted := newTestEncryptedProvider(t, inner, "correct horse", false)
+
+	created, err := encrypted.Create(storage.CreateMemoryRequest{Name: "Secret", Content: "classified"})
+	if err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+
+	wrong := newTestEncryptedProvider(t, inner, "battery staple", false)
+	if _, err := wrong.Get(created.ID); !errors.Is(err, ErrDecryptionFailed) {
+		t.Errorf("Expected ErrDecryptionFailed with the wrong key, got %v", err)
+	}
+	if _, err := wrong.List(); !errors.Is(err, ErrDecryptionFailed) {
+		t.Errorf("Expected ErrDecryptionFailed listing with the wrong key, got %v", err)
+	}
+}
+
+func TestEncryptedProviderReadsPlaintext(t *testing.T) {
+	inner := newTestFileProvider(t)
+	plain, err := inner.Create(storage.CreateMemoryRequest{Name: "Legacy", Content: "written before encryption"})
+	if err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+
+	encrypted := newTestEncryptedProvider(t, inner, "correct horse", false)
+	memory, err := encrypted.Get(plain.ID)
+	if err != nil {
+		t.Fatalf("Failed to get plaintext memory: %v", err)
+	}
+	if memory.Content != "written before encryption" {
+		t.Errorf("Expected plaintext to pass through, got %q", memory.Content)
+	}
+}
+
+func TestEncryptedProviderSkipDuplicate(t *testing.T) {
+	provider := newTestEncryptedProvider(t, newTestFileProvider(t), "passphrase", false)
+
+	first, err := provider.Create(storage.CreateMemoryRequest{Content: "secret notes"})
+	if err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+	again, err := provider.Create(storage.CreateMemoryRequest{Content: "secret notes", SkipDuplicate: true})
+	if err != nil {
+		t.Fatalf("Failed to create duplicate: %v", err)
+	}
+	if again.ID != first.ID {
+		t.Errorf("Expected the existing memory %s, got %s", first.ID, again.ID)
+	}
+}
+
+func TestEncryptedProviderAppend(t *testing.T) {
+	provider := newTestEncryptedProvider(t, newTestFileProvider(t), "passphrase", false)
+
+	memory, err := provider.Create(storage.CreateMemoryRequest{Content: "secret"})
+	if err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+	if _, err := provider.Update(storage.UpdateMemoryRequest{ID: memory.ID, Content: "more", Append: true, Separator: "\n"}); err != nil {
+		t.Fatalf("Failed to append: %v", err)
+	}
+
+	got, err := provider.Get(memory.ID)
+	if err != nil {
+		t.Fatalf("Failed to get memory: %v", err)
+	}
+	if got.Content != "secret\nmore" {
+		t.Errorf("Expected decrypted appended content, got %q", got.Content)
+	}
+}
+
+func TestEncryptedProviderSealsSummary(t *testing.T) {
+	for _, encryptMetadata := range []bool{false, true} {
+		inner := newTestFileProvider(t)
+		encrypted := newTestEncryptedProvider(t, inner, "correct horse", encryptMetadata)
+
+		metadata := map[string]any{storage.SummaryKey: "Merger plans for Q3", "source": "cursor"}
+		created, err := encrypted.Create(storage.CreateMemoryRequest{
+			Name:     "Merger chat",
+			Content:  "Our merger plans for Q3",
+			Metadata: metadata,
+		})
+		if err != nil {
+			t.Fatalf("Failed to create memory: %v", err)
+		}
+		if metadata[storage.SummaryKey] != "Merger plans for Q3" {
+			t.Error("Expected the caller's metadata left unchanged")
+		}
+		if created.Metadata[storage.SummaryKey] != "Merger plans for Q3" {
+			t.Errorf("Expected Create to return the plaintext summary, got %v", created.Metadata[storage.SummaryKey])
+		}
+
+		raw, err := inner.Get(created.ID)
+		if err != nil {
+			t.Fatalf("Failed to read raw memory: %v", err)
+		}
+		summary, _ := raw.Metadata[storage.SummaryKey].(string)
+		if !strings.HasPrefix(summary, EncryptionHeader) || strings.Contains(summary, "Merger") {
+			t.Errorf("encryptMetadata=%v: expected the summary encrypted at rest, got %q", encryptMetadata, summary)
+		}
+		if raw.Metadata["source"] != "cursor" {
+			t.Errorf("Expected other metadata left in plaintext, got %v", raw.Metadata["source"])
+		}
+
+		if _, err := encrypted.Update(storage.UpdateMemoryRequest{ID: created.ID, Metadata: map[string]any{storage.SummaryKey: "Revised plans"}}); err != nil {
+			t.Fatalf("Failed to update memory: %v", err)
+		}
+		raw, _ = inner.Get(created.ID)
+		if summary, _ := raw.Metadata[storage.SummaryKey].(string); strings.Contains(summary, "Revised") {
+			t.Errorf("Expected an updated summary encrypted at rest, got %q", summary)
+		}
+		got, err := encrypted.Get(created.ID)
+		if err != nil {
+			t.Fatalf("Failed to get memory: %v", err)
+		}
+		if got.Metadata[storage.SummaryKey] != "Revised plans" {
+			t.Errorf("Expected the decrypted summary, got %v", got.Metadata[storage.SummaryKey])
+		}
+	}
+}
+
+func TestEncryptedProviderClearsLabels(t *testing.T) {
+	provider := newTestEncryptedProvider(t, newTestFileProvider(t), "correct horse", true)
+
+	created, err := provider.Create(storage.CreateMemoryRequest{
+		Name:    "Labelled",
+		Content: "content",
+		Labels:  map[string]string{"project": "secret"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+	if _, err := provider.Update(storage.UpdateMemoryRequest{ID: created.ID, Labels: map[string]string{}}); err != nil {
+		t.Fatalf("Failed to clear labels: %v", err)
+	}
+
+	got, err := provider.Get(created.ID)
+	if err != nil {
+		t.Fatalf("Failed to get memory: %v", err)
+	}
+	if _, ok := got.Labels["project"]; ok {
+		t.Errorf("Expected the cleared labels gone, got %v", got.Labels)
+	}
+}
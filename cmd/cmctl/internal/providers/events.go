@@ -0,0 +1,314 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+)
+
+var (
+	_ StorageProvider     = (*EventLoggingProvider)(nil)
+	_ OptimizedLister     = (*EventLoggingProvider)(nil)
+	_ MemoryStreamer      = (*EventLoggingProvider)(nil)
+	_ StorageInfoProvider = (*EventLoggingProvider)(nil)
+	_ TrashProvider       = (*EventLoggingProvider)(nil)
+	_ Syncer              = (*EventLoggingProvider)(nil)
+	_ MemoryImporter      = (*EventLoggingProvider)(nil)
+	_ Batcher             = (*EventLoggingProvider)(nil)
+	_ AccessRecorder      = (*EventLoggingProvider)(nil)
+	_ Toucher             = (*EventLoggingProvider)(nil)
+	_ Compactor           = (*EventLoggingProvider)(nil)
+	_ Validator           = (*EventLoggingProvider)(nil)
+)
+
+// EventLoggingProvider wraps another provider and appends an event to an
+// event log after every change it makes. Unlike version history, the log
+// also records deletes. Reads, access tracking and compaction aren't
+// changes to memories, so they aren't logged.
+type EventLoggingProvider struct {
+	inner  StorageProvider
+	events *storage.EventLog
+	logger *slog.Logger
+
+	// pending holds the events of a batch in progress, which are only
+	// logged if the batch succeeds
+	pending []storage.Event
+	inBatch bool
+}
+
+// NewEventLoggingProvider wraps inner so that its changes are appended to
+// events. A nil logger means slog's default.
+func NewEventLoggingProvider(inner StorageProvider, events *storage.EventLog, logger *slog.Logger) *EventLoggingProvider {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &EventLoggingProvider{inner: inner, events: events, logger: logger}
+}
+
+// record logs an event. The change has already been made, so a failure to
+// log it is reported as a warning.
+func (p *EventLoggingProvider) record(event storage.Event) {
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+	if p.inBatch {
+		p.pending = append(p.pending, event)
+		return
+	}
+	if err := p.events.Append(event); err != nil {
+		p.logger.Warn("failed to record event", "operation", event.Operation, "id", event.ID, "error", err)
+	}
+}
+
+// nameOf returns the name of the memory with id, or "" if it can't be read
+func (p *EventLoggingProvider) nameOf(id string) string {
+	memory, err := p.inner.Get(id)
+	if err != nil {
+		return ""
+	}
+	return memory.Name
+}
+
+// Create creates a memory and logs it
+func (p *EventLoggingProvider) Create(req storage.CreateMemoryRequest) (*storage.Memory, error) {
+	memory, err := p.inner.Create(req)
+	if err != nil {
+		return nil, err
+	}
+	p.record(storage.Event{Operation: storage.EventCreate, ID: memory.ID, Name: memory.Name})
+	return memory, nil
+}
+
+// Import stores a memory with its existing ID and timestamps and logs it
+func (p *EventLoggingProvider) Import(memory storage.Memory) error {
+	importer, ok := p.inner.(MemoryImporter)
+	if !ok {
+		return fmt.Errorf("storage provider %s does not support import", p.inner.GetProviderType())
+	}
+	if err := importer.Import(memory); err != nil {
+		return err
+	}
+	p.record(storage.Event{Operation: storage.EventImport, ID: memory.ID, Name: memory.Name})
+	return nil
+}
+
+// Get retrieves a memory
+func (p *EventLoggingProvider) Get(id string) (*storage.Memory, error) {
+	return p.inner.Get(id)
+}
+
+// Update updates a memory and logs it
+func (p *EventLoggingProvider) Update(req storage.UpdateMemoryRequest) (*storage.Memory, error) {
+	memory, err := p.inner.Update(req)
+	if err != nil {
+		return nil, err
+	}
+	p.record(storage.Event{Operation: storage.EventUpdate, ID: memory.ID, Name: memory.Name})
+	return memory, nil
+}
+
+// Delete permanently deletes a memory and logs it, with the name it had
+func (p *EventLoggingProvider) Delete(id string) error {
+	name := p.nameOf(id)
+	if err := p.inner.Delete(id); err != nil {
+		return err
+	}
+	p.record(storage.Event{Operation: storage.EventDelete, ID: id, Name: name})
+	return nil
+}
+
+// List returns all memories
+func (p *EventLoggingProvider) List() ([]storage.Memory, error) {
+	return p.inner.List()
+}
+
+// ListWithOptions lists memories through the wrapped provider's optimized
+// path when it has one
+func (p *EventLoggingProvider) ListWithOptions(opts storage.ListOptions) ([]storage.Memory, error) {
+	lister, ok := p.inner.(OptimizedLister)
+	if !ok {
+		return p.inner.List()
+	}
+	return lister.ListWithOptions(opts)
+}
+
+// StreamMemories streams memories from the wrapped provider
+func (p *EventLoggingProvider) StreamMemories(ctx context.Context, opts storage.ListOptions, out chan<- storage.Memory) error {
+	return StreamMemories(ctx, p.inner, opts, out)
+}
+
+// Search searches the wrapped provider
+func (p *EventLoggingProvider) Search(req storage.SearchRequest) (*storage.SearchResponse, error) {
+	return p.inner.Search(req)
+}
+
+// GetProviderType returns the wrapped provider's type
+func (p *EventLoggingProvider) GetProviderType() ProviderType {
+	return p.inner.GetProviderType()
+}
+
+// GetProviderInfo returns the wrapped provider's information along with
+// where events are logged
+func (p *EventLoggingProvider) GetProviderInfo() map[string]interface{} {
+	info := p.inner.GetProviderInfo()
+	info["eventLog"] = p.events.Path()
+	return info
+}
+
+// ValidateConfig validates the wrapped provider
+func (p *EventLoggingProvider) ValidateConfig() error {
+	return p.inner.ValidateConfig()
+}
+
+// GetStorageInfo returns the wrapped provider's storage information
+func (p *EventLoggingProvider) GetStorageInfo() (*storage.StorageInfo, error) {
+	infoProvider, ok := p.inner.(StorageInfoProvider)
+	if !ok {
+		return nil, fmt.Errorf("storage provider %s does not report storage info", p.inner.GetProviderType())
+	}
+	return infoProvider.GetStorageInfo()
+}
+
+// Trash soft-deletes a memory and logs it, with the name it had
+func (p *EventLoggingProvider) Trash(id string) error {
+	trash, err := p.trash()
+	if err != nil {
+		return err
+	}
+	name := p.nameOf(id)
+	if err := trash.Trash(id); err != nil {
+		return err
+	}
+	p.record(storage.Event{Operation: storage.EventTrash, ID: id, Name: name})
+	return nil
+}
+
+// ListTrash returns trashed memories
+func (p *EventLoggingProvider) ListTrash() ([]storage.TrashedMemory, error) {
+	trash, err := p.trash()
+	if err != nil {
+		return nil, err
+	}
+	return trash.ListTrash()
+}
+
+// Restore moves a memory out of the trash and logs it
+func (p *EventLoggingProvider) Restore(id string) (*storage.Memory, error) {
+	trash, err := p.trash()
+	if err != nil {
+		return nil, err
+	}
+	memory, err := trash.Restore(id)
+	if err != nil {
+		return nil, err
+	}
+	p.record(storage.Event{Operation: storage.EventRestore, ID: memory.ID, Name: memory.Name})
+	return memory, nil
+}
+
+// EmptyTrash permanently deletes trashed memories and logs how many were
+// removed
+func (p *EventLoggingProvider) EmptyTrash(olderThan time.Duration) (int, error) {
+	trash, err := p.trash()
+	if err != nil {
+		return 0, err
+	}
+	removed, err := trash.EmptyTrash(olderThan)
+	if removed > 0 {
+		p.record(storage.Event{Operation: storage.EventEmptyTrash, Count: removed})
+	}
+	return removed, err
+}
+
+// Sync synchronizes the wrapped provider
+func (p *EventLoggingProvider) Sync() error {
+	syncer, ok := p.inner.(Syncer)
+	if !ok {
+		return fmt.Errorf("storage provider %s does not support sync", p.inner.GetProviderType())
+	}
+	return syncer.Sync()
+}
+
+// Batch runs fn as a batch of the wrapped provider. Its events are logged
+// together at the end, and not at all if the batch is undone.
+func (p *EventLoggingProvider) Batch(fn func() error) error {
+	batcher, ok := p.inner.(Batcher)
+	if !ok {
+		return fmt.Errorf("storage provider %s: %w", p.inner.GetProviderType(), ErrBatchUnsupported)
+	}
+	if p.inBatch {
+		return batcher.Batch(fn)
+	}
+
+	p.inBatch = true
+	err := batcher.Batch(fn)
+	pending := p.pending
+	p.inBatch, p.pending = false, nil
+	if err != nil {
+		return err
+	}
+	for _, event := range pending {
+		p.record(event)
+	}
+	return nil
+}
+
+// RecordAccess records a read in the wrapped provider
+func (p *EventLoggingProvider) RecordAccess(id string, at time.Time) error {
+	recorder, ok := p.inner.(AccessRecorder)
+	if !ok {
+		return fmt.Errorf("storage provider %s: %w", p.inner.GetProviderType(), ErrAccessUnsupported)
+	}
+	return recorder.RecordAccess(id, at)
+}
+
+// Touch sets a memory's UpdatedAt in the wrapped provider and logs it
+func (p *EventLoggingProvider) Touch(id string, at time.Time) error {
+	toucher, ok := p.inner.(Toucher)
+	if !ok {
+		return fmt.Errorf("storage provider %s: %w", p.inner.GetProviderType(), ErrTouchUnsupported)
+	}
+	if err := toucher.Touch(id, at); err != nil {
+		return err
+	}
+	p.record(storage.Event{Operation: storage.EventTouch, ID: id, Name: p.nameOf(id)})
+	return nil
+}
+
+// Compact compresses memories in the wrapped provider
+func (p *EventLoggingProvider) Compact() (storage.CompactResult, error) {
+	compactor, ok := p.inner.(Compactor)
+	if !ok {
+		return storage.CompactResult{}, fmt.Errorf("storage provider %s: %w", p.inner.GetProviderType(), ErrCompactUnsupported)
+	}
+	return compactor.Compact()
+}
+
+// Validate checks memories in the wrapped provider, logging an update for
+// each memory repaired
+func (p *EventLoggingProvider) Validate(fix bool) (storage.ValidateResult, error) {
+	validator, ok := p.inner.(Validator)
+	if !ok {
+		return storage.ValidateResult{}, fmt.Errorf("storage provider %s: %w", p.inner.GetProviderType(), ErrValidateUnsupported)
+	}
+	result, err := validator.Validate(fix)
+	logged := make(map[string]bool)
+	for _, violation := range result.Violations {
+		if violation.Fixed && !logged[violation.ID] {
+			logged[violation.ID] = true
+			p.record(storage.Event{Operation: storage.EventUpdate, ID: violation.ID, Name: p.nameOf(violation.ID)})
+		}
+	}
+	return result, err
+}
+
+func (p *EventLoggingProvider) trash() (TrashProvider, error) {
+	trash, ok := p.inner.(TrashProvider)
+	if !ok {
+		return nil, fmt.Errorf("storage provider %s does not support the trash", p.inner.GetProviderType())
+	}
+	return trash, nil
+}
@@ -0,0 +1,113 @@
+package providers
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+)
+
+func newTestEventLoggingProvider(t *testing.T) (*EventLoggingProvider, *storage.EventLog) {
+	t.Helper()
+	log := storage.NewEventLog(filepath.Join(t.TempDir(), storage.EventLogFile), storage.DefaultEventLogMaxBytes)
+	return NewEventLoggingProvider(newTestFileProvider(t), log, nil), log
+}
+
+func TestEventLoggingProviderRecordsChanges(t *testing.T) {
+	provider, log := newTestEventLoggingProvider(t)
+
+	created, err := provider.Create(storage.CreateMemoryRequest{Name: "Notes", Content: "first"})
+	if err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+	if _, err := provider.Update(storage.UpdateMemoryRequest{ID: created.ID, Name: "Renamed"}); err != nil {
+		t.Fatalf("Failed to update memory: %v", err)
+	}
+	if err := provider.Touch(created.ID, time.Now()); err != nil {
+		t.Fatalf("Failed to touch memory: %v", err)
+	}
+	if err := provider.Trash(created.ID); err != nil {
+		t.Fatalf("Failed to trash memory: %v", err)
+	}
+	if _, err := provider.Restore(created.ID); err != nil {
+		t.Fatalf("Failed to restore memory: %v", err)
+	}
+	if err := provider.Delete(created.ID); err != nil {
+		t.Fatalf("Failed to delete memory: %v", err)
+	}
+	imported := storage.Memory{ID: "mem_12345678_abcdef", Name: "Imported", Content: "moved", CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	if err := provider.Import(imported); err != nil {
+		t.Fatalf("Failed to import memory: %v", err)
+	}
+	if err := provider.Trash(imported.ID); err != nil {
+		t.Fatalf("Failed to trash memory: %v", err)
+	}
+	if _, err := provider.EmptyTrash(0); err != nil {
+		t.Fatalf("Failed to empty trash: %v", err)
+	}
+	// Reads aren't changes
+	if _, err := provider.Get(created.ID); err == nil {
+		t.Fatalf("Expected the deleted memory to be gone")
+	}
+
+	events, err := log.Read()
+	if err != nil {
+		t.Fatalf("Failed to read events: %v", err)
+	}
+	want := []storage.Event{
+		{Operation: storage.EventCreate, ID: created.ID, Name: "Notes"},
+		{Operation: storage.EventUpdate, ID: created.ID, Name: "Renamed"},
+		{Operation: storage.EventTouch, ID: created.ID, Name: "Renamed"},
+		{Operation: storage.EventTrash, ID: created.ID, Name: "Renamed"},
+		{Operation: storage.EventRestore, ID: created.ID, Name: "Renamed"},
+		{Operation: storage.EventDelete, ID: created.ID, Name: "Renamed"},
+		{Operation: storage.EventImport, ID: imported.ID, Name: "Imported"},
+		{Operation: storage.EventTrash, ID: imported.ID, Name: "Imported"},
+		{Operation: storage.EventEmptyTrash, Count: 1},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("Expected %d events, got %+v", len(want), events)
+	}
+	for i, event := range events {
+		if event.Time.IsZero() {
+			t.Errorf("Expected event %d to have a time", i)
+		}
+		event.Time = time.Time{}
+		if event != want[i] {
+			t.Errorf("Expected event %d to be %+v, got %+v", i, want[i], event)
+		}
+	}
+}
+
+func TestEventLoggingProviderBatch(t *testing.T) {
+	provider, log := newTestEventLoggingProvider(t)
+
+	errUndo := errors.New("undo")
+	err := provider.Batch(func() error {
+		if _, err := provider.Create(storage.CreateMemoryRequest{Name: "Undone", Content: "gone"}); err != nil {
+			return err
+		}
+		return errUndo
+	})
+	if !errors.Is(err, errUndo) {
+		t.Fatalf("Expected the batch to fail, got %v", err)
+	}
+
+	err = provider.Batch(func() error {
+		_, err := provider.Create(storage.CreateMemoryRequest{Name: "Kept", Content: "stays"})
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Failed to run batch: %v", err)
+	}
+
+	events, err := log.Read()
+	if err != nil {
+		t.Fatalf("Failed to read events: %v", err)
+	}
+	if len(events) != 1 || events[0].Operation != storage.EventCreate || events[0].Name != "Kept" {
+		t.Errorf("Expected only the committed batch to be logged, got %+v", events)
+	}
+}
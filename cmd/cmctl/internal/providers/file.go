@@ -1,9 +1,25 @@
 package providers
 
 import (
+	"time"
+
 	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
 )
 
+var (
+	_ StorageProvider     = (*FileStorageProvider)(nil)
+	_ OptimizedLister     = (*FileStorageProvider)(nil)
+	_ MemoryStreamer      = (*FileStorageProvider)(nil)
+	_ StorageInfoProvider = (*FileStorageProvider)(nil)
+	_ TrashProvider       = (*FileStorageProvider)(nil)
+	_ MemoryImporter      = (*FileStorageProvider)(nil)
+	_ Batcher             = (*FileStorageProvider)(nil)
+	_ AccessRecorder      = (*FileStorageProvider)(nil)
+	_ Toucher             = (*FileStorageProvider)(nil)
+	_ Compactor           = (*FileStorageProvider)(nil)
+	_ Validator           = (*FileStorageProvider)(nil)
+)
+
 // FileStorageProvider implements file-based storage
 type FileStorageProvider struct {
 	*storage.FileStorage
@@ -16,6 +32,10 @@ func NewFileProvider(config ProviderConfig) (StorageProvider, error) {
 	if err != nil {
 		return nil, err
 	}
+	fileStorage.SetMaxContentBytes(config.MaxContentBytes)
+	fileStorage.SetWriteRetry(config.RetryCount, time.Duration(config.Timeout)*time.Second)
+	fileStorage.SetLogger(config.Logger)
+	fileStorage.SetCompression(config.CompressContent)
 
 	return &FileStorageProvider{
 		FileStorage: fileStorage,
@@ -0,0 +1,338 @@
+package providers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+)
+
+// DefaultGitCommitTemplate is the commit message used when none is configured
+const DefaultGitCommitTemplate = "{{.Operation}} {{.ID}}{{if .Name}} ({{.Name}}){{end}}"
+
+// DefaultGitRemote is the remote used by Sync when none is configured
+const DefaultGitRemote = "origin"
+
+var (
+	_ StorageProvider     = (*GitStorageProvider)(nil)
+	_ OptimizedLister     = (*GitStorageProvider)(nil)
+	_ MemoryStreamer      = (*GitStorageProvider)(nil)
+	_ StorageInfoProvider = (*GitStorageProvider)(nil)
+	_ TrashProvider       = (*GitStorageProvider)(nil)
+	_ Syncer              = (*GitStorageProvider)(nil)
+	_ Batcher             = (*GitStorageProvider)(nil)
+	_ AccessRecorder      = (*GitStorageProvider)(nil)
+	_ Toucher             = (*GitStorageProvider)(nil)
+	_ Compactor           = (*GitStorageProvider)(nil)
+	_ Validator           = (*GitStorageProvider)(nil)
+)
+
+// GitCommitData is the data available to the commit message template
+type GitCommitData struct {
+	Operation string
+	ID        string
+	Name      string
+}
+
+// GitStorageProvider stores memories as files in a git repository and
+// commits after every successful change
+type GitStorageProvider struct {
+	*FileStorageProvider
+	commitTemplate *template.Template
+	noCommit       bool
+	remote         string
+	// inBatch defers commits to the end of a batch
+	inBatch bool
+}
+
+// NewGitProvider creates a git-backed file storage provider, initializing
+// the repository if the storage directory isn't one yet
+func NewGitProvider(config ProviderConfig) (StorageProvider, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, NewProviderConfigError(GitProvider, "git", "git executable not found in PATH")
+	}
+
+	fileProvider, err := NewFileProvider(config)
+	if err != nil {
+		return nil, err
+	}
+
+	commitTemplate := config.CommitTemplate
+	if commitTemplate == "" {
+		commitTemplate = DefaultGitCommitTemplate
+	}
+	tmpl, err := template.New("commit").Parse(commitTemplate)
+	if err != nil {
+		return nil, NewProviderConfigError(GitProvider, "commitTemplate", err.Error())
+	}
+
+	remote := config.Remote
+	if remote == "" {
+		remote = DefaultGitRemote
+	}
+
+	provider := &GitStorageProvider{
+		FileStorageProvider: fileProvider.(*FileStorageProvider),
+		commitTemplate:      tmpl,
+		noCommit:            config.NoCommit,
+		remote:              remote,
+	}
+	if err := provider.initRepository(); err != nil {
+		return nil, err
+	}
+	return provider, nil
+}
+
+// GetProviderType returns the provider type
+func (g *GitStorageProvider) GetProviderType() ProviderType {
+	return GitProvider
+}
+
+// GetProviderInfo returns provider-specific information
+func (g *GitStorageProvider) GetProviderInfo() map[string]interface{} {
+	info := g.FileStorageProvider.GetProviderInfo()
+	info["type"] = "git"
+	info["provider"] = "git-repository"
+	info["remote"] = g.remote
+	return info
+}
+
+// ValidateConfig checks the storage directory and the repository
+func (g *GitStorageProvider) ValidateConfig() error {
+	if err := g.FileStorageProvider.ValidateConfig(); err != nil {
+		return err
+	}
+	_, err := g.git("rev-parse", "--git-dir")
+	return err
+}
+
+// Create creates a memory and commits it
+func (g *GitStorageProvider) Create(req storage.CreateMemoryRequest) (*storage.Memory, error) {
+	memory, err := g.FileStorage.Create(req)
+	if err != nil {
+		return nil, err
+	}
+	g.commit(GitCommitData{Operation: "create", ID: memory.ID, Name: memory.Name})
+	return memory, nil
+}
+
+// Update updates a memory and commits the change
+func (g *GitStorageProvider) Update(req storage.UpdateMemoryRequest) (*storage.Memory, error) {
+	memory, err := g.FileStorage.Update(req)
+	if err != nil {
+		return nil, err
+	}
+	g.commit(GitCommitData{Operation: "update", ID: memory.ID, Name: memory.Name})
+	return memory, nil
+}
+
+// Delete permanently deletes a memory and commits the removal
+func (g *GitStorageProvider) Delete(id string) error {
+	if err := g.FileStorage.Delete(id); err != nil {
+		return err
+	}
+	g.commit(GitCommitData{Operation: "delete", ID: id})
+	return nil
+}
+
+// Trash soft-deletes a memory and commits the move
+func (g *GitStorageProvider) Trash(id string) error {
+	if err := g.FileStorage.Trash(id); err != nil {
+		return err
+	}
+	g.commit(GitCommitData{Operation: "trash", ID: id})
+	return nil
+}
+
+// Restore moves a memory out of the trash and commits the move
+func (g *GitStorageProvider) Restore(id string) (*storage.Memory, error) {
+	memory, err := g.FileStorage.Restore(id)
+	if err != nil {
+		return nil, err
+	}
+	g.commit(GitCommitData{Operation: "restore", ID: memory.ID, Name: memory.Name})
+	return memory, nil
+}
+
+// EmptyTrash permanently deletes trashed memories and commits the removal
+func (g *GitStorageProvider) EmptyTrash(olderThan time.Duration) (int, error) {
+	removed, err := g.FileStorage.EmptyTrash(olderThan)
+	if err != nil {
+		return removed, err
+	}
+	if removed > 0 {
+		g.commit(GitCommitData{Operation: "empty-trash"})
+	}
+	return removed, nil
+}
+
+// Batch runs fn as a file storage batch and commits its changes once at
+// the end, rather than once per operation
+func (g *GitStorageProvider) Batch(fn func() error) error {
+	g.inBatch = true
+	err := g.FileStorage.Batch(fn)
+	g.inBatch = false
+	if err != nil {
+		return err
+	}
+	g.commit(GitCommitData{Operation: "batch"})
+	return nil
+}
+
+// RecordAccess records a read in the index without committing it. Reads
+// are too frequent to each get a commit, so access times go in with the
+// next change.
+func (g *GitStorageProvider) RecordAccess(id string, at time.Time) error {
+	return g.FileStorageProvider.RecordAccess(id, at)
+}
+
+// Touch sets a memory's UpdatedAt and commits the change
+func (g *GitStorageProvider) Touch(id string, at time.Time) error {
+	if err := g.FileStorage.Touch(id, at); err != nil {
+		return err
+	}
+	g.commit(GitCommitData{Operation: "touch", ID: id})
+	return nil
+}
+
+// Compact compresses existing memories and commits the change
+func (g *GitStorageProvider) Compact() (storage.CompactResult, error) {
+	result, err := g.FileStorage.Compact()
+	if err != nil {
+		return result, err
+	}
+	if result.Compressed > 0 {
+		g.commit(GitCommitData{Operation: "compact"})
+	}
+	return result, nil
+}
+
+// Validate checks stored memories and commits any repairs
+func (g *GitStorageProvider) Validate(fix bool) (storage.ValidateResult, error) {
+	result, err := g.FileStorage.Validate(fix)
+	if err != nil {
+		return result, err
+	}
+	if result.Rewritten > 0 {
+		g.commit(GitCommitData{Operation: "validate"})
+	}
+	return result, nil
+}
+
+// Sync commits any pending changes, pulls from the remote when the branch
+// tracks one, and pushes
+func (g *GitStorageProvider) Sync() error {
+	if err := g.commitAll("sync"); err != nil {
+		return err
+	}
+
+	if _, err := g.git("remote", "get-url", g.remote); err != nil {
+		return fmt.Errorf("no git remote %q configured in %s", g.remote, g.StorageDir())
+	}
+
+	if _, err := g.git("rev-parse", "--abbrev-ref", "@{upstream}"); err == nil {
+		if _, err := g.git("pull", "--rebase"); err != nil {
+			return err
+		}
+	}
+
+	_, err := g.git("push", "--set-upstream", g.remote, "HEAD")
+	return err
+}
+
+// initRepository runs git init in the storage directory if it isn't a
+// repository yet and commits any existing memories
+func (g *GitStorageProvider) initRepository() error {
+	if _, err := os.Stat(filepath.Join(g.StorageDir(), ".git")); err == nil {
+		return nil
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to check for git repository: %w", err)
+	}
+
+	if _, err := g.git("init"); err != nil {
+		return err
+	}
+	if g.noCommit {
+		return nil
+	}
+	return g.commitAll("Initialize memory store")
+}
+
+// commit records the changes for a completed operation. The operation has
+// already succeeded, so a failed commit is reported as a warning.
+func (g *GitStorageProvider) commit(data GitCommitData) {
+	if g.noCommit || g.inBatch {
+		return
+	}
+
+	var message bytes.Buffer
+	if err := g.commitTemplate.Execute(&message, data); err != nil {
+		g.Logger().Warn("failed to render commit message", "operation", data.Operation, "error", err)
+		return
+	}
+	if err := g.commitAll(strings.TrimSpace(message.String())); err != nil {
+		g.Logger().Warn("failed to commit", "operation", data.Operation, "error", err)
+	}
+}
+
+// storePaths are the files and directories of the memory store, as
+// patterns in the storage directory. Only these are committed: anything
+// else there, such as the stores of named contexts, stays out of the
+// repository.
+var storePaths = []string{
+	"memories",
+	"trash",
+	"index.json",
+	"config.json",
+	storage.EventLogFile + "*",
+	EncryptionSaltFile,
+}
+
+// commitAll stages the store's files and commits them, doing nothing when
+// nothing changed
+func (g *GitStorageProvider) commitAll(message string) error {
+	args := []string{"add", "--all", "--"}
+	for _, pattern := range storePaths {
+		matches, err := filepath.Glob(filepath.Join(g.StorageDir(), pattern))
+		if err != nil {
+			return err
+		}
+		for _, match := range matches {
+			args = append(args, filepath.Base(match))
+		}
+	}
+	// Without paths, add --all would stage the whole directory
+	if len(args) == 3 {
+		return nil
+	}
+	if _, err := g.git(args...); err != nil {
+		return err
+	}
+	staged, err := g.git("diff", "--cached", "--name-only")
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(staged) == "" {
+		return nil
+	}
+	_, err = g.git("commit", "--quiet", "--message", message)
+	return err
+}
+
+// git runs a git command in the storage directory
+func (g *GitStorageProvider) git(args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = g.StorageDir()
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git %s failed: %w: %s", args[0], err, strings.TrimSpace(string(output)))
+	}
+	return string(output), nil
+}
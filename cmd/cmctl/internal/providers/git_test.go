@@ -0,0 +1,176 @@
+package providers
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+)
+
+// setupGit skips the test when git isn't installed and gives commits a
+// fixed identity
+func setupGit(t *testing.T) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("GIT_AUTHOR_NAME", "Test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+}
+
+func newTestGitProvider(t *testing.T, config ProviderConfig) *GitStorageProvider {
+	t.Helper()
+	config.Type = GitProvider
+	if config.StorageDir == "" {
+		config.StorageDir = t.TempDir()
+	}
+	provider, err := NewGitProvider(config)
+	if err != nil {
+		t.Fatalf("Failed to create git provider: %v", err)
+	}
+	return provider.(*GitStorageProvider)
+}
+
+// gitLog returns the commit subjects in dir, newest first
+func gitLog(t *testing.T, dir string) []string {
+	t.Helper()
+	output, err := exec.Command("git", "-C", dir, "log", "--format=%s").CombinedOutput()
+	if err != nil {
+		t.Fatalf("Failed to read git log: %v: %s", err, output)
+	}
+	return strings.Split(strings.TrimSpace(string(output)), "\n")
+}
+
+func TestGitProviderCommitsChanges(t *testing.T) {
+	setupGit(t)
+	provider := newTestGitProvider(t, ProviderConfig{})
+
+	memory, err := provider.Create(storage.CreateMemoryRequest{Name: "Notes", Content: "first draft"})
+	if err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+	if _, err := provider.Update(storage.UpdateMemoryRequest{ID: memory.ID, Content: "second draft"}); err != nil {
+		t.Fatalf("Failed to update memory: %v", err)
+	}
+	if err := provider.Trash(memory.ID); err != nil {
+		t.Fatalf("Failed to trash memory: %v", err)
+	}
+
+	expected := []string{
+		"trash " + memory.ID,
+		"update " + memory.ID + " (Notes)",
+		"create " + memory.ID + " (Notes)",
+		"Initialize memory store",
+	}
+	log := gitLog(t, provider.StorageDir())
+	if strings.Join(log, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected commits %q, got %q", expected, log)
+	}
+}
+
+func TestGitProviderCommitTemplate(t *testing.T) {
+	setupGit(t)
+	provider := newTestGitProvider(t, ProviderConfig{CommitTemplate: "memory: {{.Operation}} {{.ID}}"})
+
+	memory, err := provider.Create(storage.CreateMemoryRequest{Content: "templated"})
+	if err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+	if log := gitLog(t, provider.StorageDir()); log[0] != "memory: create "+memory.ID {
+		t.Errorf("Expected templated commit message, got %q", log[0])
+	}
+
+	if _, err := NewGitProvider(ProviderConfig{Type: GitProvider, StorageDir: t.TempDir(), CommitTemplate: "{{.Oops"}); err == nil {
+		t.Error("Expected error for invalid commit template")
+	}
+}
+
+func TestGitProviderNoCommit(t *testing.T) {
+	setupGit(t)
+	dir := t.TempDir()
+	newTestGitProvider(t, ProviderConfig{StorageDir: dir})
+
+	batch := newTestGitProvider(t, ProviderConfig{StorageDir: dir, NoCommit: true})
+	for _, content := range []string{"one", "two"} {
+		if _, err := batch.Create(storage.CreateMemoryRequest{Content: content}); err != nil {
+			t.Fatalf("Failed to create memory: %v", err)
+		}
+	}
+	if log := gitLog(t, dir); len(log) != 1 {
+		t.Errorf("Expected no commits with NoCommit, got %q", log)
+	}
+
+	// Sync picks up the uncommitted batch before pushing
+	remote := t.TempDir()
+	if output, err := exec.Command("git", "init", "--bare", remote).CombinedOutput(); err != nil {
+		t.Fatalf("Failed to create remote: %v: %s", err, output)
+	}
+	if _, err := batch.git("remote", "add", "origin", remote); err != nil {
+		t.Fatalf("Failed to add remote: %v", err)
+	}
+	if err := batch.Sync(); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if log := gitLog(t, remote); len(log) != 2 || log[0] != "sync" {
+		t.Errorf("Expected pushed sync commit on remote, got %q", log)
+	}
+
+	// A second sync pulls from the now-tracked upstream
+	if err := batch.Sync(); err != nil {
+		t.Fatalf("Second sync failed: %v", err)
+	}
+}
+
+func TestGitProviderSyncWithoutRemote(t *testing.T) {
+	setupGit(t)
+	provider := newTestGitProvider(t, ProviderConfig{})
+	if err := provider.Sync(); err == nil || !strings.Contains(err.Error(), "no git remote") {
+		t.Errorf("Expected missing remote error, got %v", err)
+	}
+}
+
+func TestGitProviderCommitsOnlyTheStore(t *testing.T) {
+	setupGit(t)
+	dir := t.TempDir()
+
+	// Named contexts keep their stores under the default store, and a
+	// git-backed one is a repository of its own
+	work := newTestGitProvider(t, ProviderConfig{StorageDir: filepath.Join(dir, "contexts", "work")})
+	if _, err := work.Create(storage.CreateMemoryRequest{Content: "work notes"}); err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte("provider: git\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	provider := newTestGitProvider(t, ProviderConfig{StorageDir: dir})
+	if _, err := provider.Create(storage.CreateMemoryRequest{Content: "default notes"}); err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+	if err := provider.Sync(); err == nil {
+		t.Fatal("Expected sync to fail without a remote")
+	}
+
+	output, err := provider.git("ls-files")
+	if err != nil {
+		t.Fatalf("Failed to list committed files: %v", err)
+	}
+	files := strings.Fields(output)
+	if len(files) == 0 {
+		t.Fatal("Expected the store to be committed")
+	}
+	for _, file := range files {
+		if !strings.HasPrefix(file, "memories/") && file != "index.json" && file != "config.json" {
+			t.Errorf("Expected only the store committed, got %s", file)
+		}
+	}
+	if status, _ := provider.git("status", "--porcelain", "--", "memories", "index.json"); status != "" {
+		t.Errorf("Expected the store fully committed, got %q", status)
+	}
+}
@@ -1,6 +1,11 @@
 package providers
 
 import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
 	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
 )
 
@@ -9,6 +14,8 @@ type ProviderType string
 
 const (
 	FileProvider   ProviderType = "file"
+	GitProvider    ProviderType = "git"
+	SQLiteProvider ProviderType = "sqlite"
 	S3Provider     ProviderType = "s3"
 	GCSProvider    ProviderType = "gcs"
 	RemoteProvider ProviderType = "remote"
@@ -21,6 +28,14 @@ type ProviderConfig struct {
 	// File provider config
 	StorageDir string `yaml:"storageDir,omitempty" json:"storageDir,omitempty"`
 
+	// Git provider config
+	CommitTemplate string `yaml:"commitTemplate,omitempty" json:"commitTemplate,omitempty"`
+	Remote         string `yaml:"remote,omitempty" json:"remote,omitempty"`
+	NoCommit       bool   `yaml:"noCommit,omitempty" json:"noCommit,omitempty"`
+
+	// SQLite provider config
+	DatabasePath string `yaml:"databasePath,omitempty" json:"databasePath,omitempty"`
+
 	// Cloud provider config
 	Bucket    string `yaml:"bucket,omitempty" json:"bucket,omitempty"`
 	Region    string `yaml:"region,omitempty" json:"region,omitempty"`
@@ -32,9 +47,15 @@ type ProviderConfig struct {
 	Headers  map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
 
 	// Common config
-	Timeout    int  `yaml:"timeout,omitempty" json:"timeout,omitempty"`
-	RetryCount int  `yaml:"retryCount,omitempty" json:"retryCount,omitempty"`
-	EnableTLS  bool `yaml:"enableTLS,omitempty" json:"enableTLS,omitempty"`
+	Timeout         int   `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	RetryCount      int   `yaml:"retryCount,omitempty" json:"retryCount,omitempty"`
+	EnableTLS       bool  `yaml:"enableTLS,omitempty" json:"enableTLS,omitempty"`
+	MaxContentBytes int64 `yaml:"maxContentBytes,omitempty" json:"maxContentBytes,omitempty"` // 0 means unlimited
+	// CompressContent gzips large content on disk (file and git providers)
+	CompressContent bool `yaml:"compressContent,omitempty" json:"compressContent,omitempty"`
+
+	// Logger receives provider warnings; nil means slog's default
+	Logger *slog.Logger `yaml:"-" json:"-"`
 }
 
 // StorageProvider interface that all storage backends must implement
@@ -53,6 +74,129 @@ type StorageProvider interface {
 	ValidateConfig() error
 }
 
+// Optional capabilities. Commands check for these with a type assertion
+// and degrade gracefully on providers that don't implement them.
+
+// OptimizedLister is implemented by providers that can list memories
+// without loading their content
+type OptimizedLister interface {
+	ListWithOptions(opts storage.ListOptions) ([]storage.Memory, error)
+}
+
+// MemoryStreamer is implemented by providers that can send memories as
+// they're read, so large listings can be written before the scan finishes.
+// StreamMemories closes out when it returns.
+type MemoryStreamer interface {
+	StreamMemories(ctx context.Context, opts storage.ListOptions, out chan<- storage.Memory) error
+}
+
+// StreamMemories sends the memories provider lists to out, as they're read
+// when it's a MemoryStreamer and otherwise once they've all been listed,
+// and closes out when done
+func StreamMemories(ctx context.Context, provider StorageProvider, opts storage.ListOptions, out chan<- storage.Memory) error {
+	if streamer, ok := provider.(MemoryStreamer); ok {
+		return streamer.StreamMemories(ctx, opts, out)
+	}
+	defer close(out)
+
+	var memories []storage.Memory
+	var err error
+	if lister, ok := provider.(OptimizedLister); ok {
+		memories, err = lister.ListWithOptions(opts)
+	} else {
+		memories, err = provider.List()
+	}
+	if err != nil {
+		return err
+	}
+	for _, memory := range memories {
+		select {
+		case out <- memory:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// StorageInfoProvider is implemented by providers that can report storage
+// usage
+type StorageInfoProvider interface {
+	GetStorageInfo() (*storage.StorageInfo, error)
+}
+
+// TrashProvider is implemented by providers that support recoverable deletes
+type TrashProvider interface {
+	Trash(id string) error
+	ListTrash() ([]storage.TrashedMemory, error)
+	Restore(id string) (*storage.Memory, error)
+	EmptyTrash(olderThan time.Duration) (int, error)
+}
+
+// MemoryImporter is implemented by providers that can store a memory with
+// its existing ID and timestamps, as needed for migrations
+type MemoryImporter interface {
+	Import(memory storage.Memory) error
+}
+
+// Batcher is implemented by providers that can apply a group of changes
+// atomically: Batch keeps all of fn's changes if it succeeds and undoes
+// them all if it returns an error
+type Batcher interface {
+	Batch(fn func() error) error
+}
+
+// ErrBatchUnsupported is returned by Batch, before running fn, when a
+// wrapping provider's underlying provider doesn't support batches
+var ErrBatchUnsupported = errors.New("batches are not supported")
+
+// AccessRecorder is implemented by providers that can record when a memory
+// was read, in its lastAccessedAt and accessCount metadata, without
+// changing its UpdatedAt
+type AccessRecorder interface {
+	RecordAccess(id string, at time.Time) error
+}
+
+// ErrAccessUnsupported is returned by RecordAccess when a wrapping
+// provider's underlying provider can't record reads
+var ErrAccessUnsupported = errors.New("access tracking is not supported")
+
+// Toucher is implemented by providers that can set a memory's UpdatedAt
+// without changing anything else
+type Toucher interface {
+	Touch(id string, at time.Time) error
+}
+
+// ErrTouchUnsupported is returned by Touch when a wrapping provider's
+// underlying provider can't touch memories
+var ErrTouchUnsupported = errors.New("touch is not supported")
+
+// Compactor is implemented by providers that can compress the content of
+// existing memories on disk
+type Compactor interface {
+	Compact() (storage.CompactResult, error)
+}
+
+// ErrCompactUnsupported is returned by Compact when a wrapping provider's
+// underlying provider can't compress memories
+var ErrCompactUnsupported = errors.New("compaction is not supported")
+
+// Validator is implemented by providers that can check stored memories
+// against the constraints enforced on writes, and repair what they safely
+// can
+type Validator interface {
+	Validate(fix bool) (storage.ValidateResult, error)
+}
+
+// ErrValidateUnsupported is returned by Validate when a wrapping provider
+// can't check what its underlying provider stores
+var ErrValidateUnsupported = errors.New("validation is not supported")
+
+// Syncer is implemented by providers that can synchronize with a remote copy
+type Syncer interface {
+	Sync() error
+}
+
 // ProviderFactory creates storage providers based on configuration
 type ProviderFactory struct {
 	providers map[ProviderType]func(ProviderConfig) (interface{}, error)
@@ -68,7 +212,13 @@ func NewProviderFactory() *ProviderFactory {
 	factory.RegisterProvider(FileProvider, func(config ProviderConfig) (interface{}, error) {
 		return NewFileProvider(config)
 	})
-	
+	factory.RegisterProvider(GitProvider, func(config ProviderConfig) (interface{}, error) {
+		return NewGitProvider(config)
+	})
+	factory.RegisterProvider(SQLiteProvider, func(config ProviderConfig) (interface{}, error) {
+		return NewSQLiteProvider(config)
+	})
+
 	// Register placeholders for future providers (will return "not implemented" errors)
 	factory.RegisterProvider(S3Provider, NewS3Provider)
 	factory.RegisterProvider(GCSProvider, NewGCSProvider)
@@ -113,9 +263,29 @@ func GetProviderDefaults(providerType ProviderType) ProviderConfig {
 	switch providerType {
 	case FileProvider:
 		return ProviderConfig{
-			Type:       FileProvider,
-			StorageDir: "", // Will default to ~/.contextmemory
-			Timeout:    30,
+			Type:            FileProvider,
+			StorageDir:      "", // Will default to ~/.contextmemory
+			MaxContentBytes: storage.DefaultMaxContentBytes,
+			Timeout:         30,
+			RetryCount:      3,
+		}
+	case GitProvider:
+		return ProviderConfig{
+			Type:            GitProvider,
+			StorageDir:      "", // Will default to ~/.contextmemory
+			CommitTemplate:  DefaultGitCommitTemplate,
+			Remote:          DefaultGitRemote,
+			MaxContentBytes: storage.DefaultMaxContentBytes,
+			Timeout:         30,
+			RetryCount:      3,
+		}
+	case SQLiteProvider:
+		return ProviderConfig{
+			Type:            SQLiteProvider,
+			StorageDir:      "", // Will default to ~/.contextmemory
+			DatabasePath:    "", // Will default to <storageDir>/memories.db
+			MaxContentBytes: storage.DefaultMaxContentBytes,
+			Timeout:         30,
 		}
 	case S3Provider:
 		return ProviderConfig{
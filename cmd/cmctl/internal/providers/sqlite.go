@@ -0,0 +1,702 @@
+package providers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/storage"
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/utils"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// DefaultSQLiteDatabase is the database file name inside the storage
+// directory
+const DefaultSQLiteDatabase = "memories.db"
+
+// ftsMinQueryLength is the shortest query the trigram full-text index can
+// answer; shorter queries fall back to LIKE
+const ftsMinQueryLength = 3
+
+var (
+	_ StorageProvider     = (*SQLiteStorageProvider)(nil)
+	_ OptimizedLister     = (*SQLiteStorageProvider)(nil)
+	_ StorageInfoProvider = (*SQLiteStorageProvider)(nil)
+	_ TrashProvider       = (*SQLiteStorageProvider)(nil)
+	_ MemoryImporter      = (*SQLiteStorageProvider)(nil)
+	_ AccessRecorder      = (*SQLiteStorageProvider)(nil)
+	_ Toucher             = (*SQLiteStorageProvider)(nil)
+)
+
+// sqliteMemory is a row in the memories table. Trashed memories keep their
+// row with TrashedAt set.
+type sqliteMemory struct {
+	ID        string     `gorm:"primaryKey"`
+	Name      string     `gorm:"not null"`
+	Content   string     `gorm:"not null"`
+	Metadata  string     `gorm:"not null;default:''"`
+	Relations string     `gorm:"not null;default:''"`
+	CreatedAt time.Time  `gorm:"not null;index;autoCreateTime:false"`
+	UpdatedAt time.Time  `gorm:"not null;autoUpdateTime:false"`
+	ExpiresAt *time.Time `gorm:"index"`
+	// ContentHash mirrors the contentHash metadata for duplicate lookups
+	ContentHash string     `gorm:"not null;default:'';index"`
+	TrashedAt   *time.Time `gorm:"index"`
+}
+
+func (sqliteMemory) TableName() string { return "memories" }
+
+// sqliteLabel is a row in the memory_labels table
+type sqliteLabel struct {
+	MemoryID string `gorm:"primaryKey"`
+	Key      string `gorm:"primaryKey;index:idx_memory_labels_key_value,priority:1"`
+	Value    string `gorm:"not null;index:idx_memory_labels_key_value,priority:2"`
+}
+
+func (sqliteLabel) TableName() string { return "memory_labels" }
+
+// SQLiteStorageProvider stores memories in a single SQLite database, with
+// labels in an indexed table and content in a trigram FTS5 index for
+// substring search
+type SQLiteStorageProvider struct {
+	db     *gorm.DB
+	dbPath string
+	config ProviderConfig
+}
+
+// NewSQLiteProvider opens (creating if needed) the SQLite memory database
+func NewSQLiteProvider(config ProviderConfig) (StorageProvider, error) {
+	dbPath := config.DatabasePath
+	if dbPath == "" {
+		storageDir := config.StorageDir
+		if storageDir == "" {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return nil, fmt.Errorf("failed to get home directory: %w", err)
+			}
+			storageDir = filepath.Join(home, ".contextmemory")
+		}
+		dbPath = filepath.Join(storageDir, DefaultSQLiteDatabase)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create database directory: %w", err)
+	}
+
+	dsn := fmt.Sprintf("%s?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)", dbPath)
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	provider := &SQLiteStorageProvider{db: db, dbPath: dbPath, config: config}
+	if err := provider.initialize(); err != nil {
+		provider.Close()
+		return nil, fmt.Errorf("failed to initialize database: %w", err)
+	}
+	return provider, nil
+}
+
+// initialize creates the schema
+func (s *SQLiteStorageProvider) initialize() error {
+	if err := s.db.AutoMigrate(&sqliteMemory{}, &sqliteLabel{}); err != nil {
+		return err
+	}
+	return s.db.Exec(`CREATE VIRTUAL TABLE IF NOT EXISTS memories_fts
+		USING fts5(id UNINDEXED, name, content, tokenize = 'trigram')`).Error
+}
+
+// validateMemory applies the shared memory constraints and the configured
+// content size limit
+func (s *SQLiteStorageProvider) validateMemory(memory *storage.Memory) error {
+	if err := storage.ValidateMemory(memory); err != nil {
+		return err
+	}
+	return storage.ValidateContentSize(memory.Content, s.config.MaxContentBytes)
+}
+
+// StorageDir returns the directory containing the database
+func (s *SQLiteStorageProvider) StorageDir() string {
+	return filepath.Dir(s.dbPath)
+}
+
+// Close releases the database connection
+func (s *SQLiteStorageProvider) Close() error {
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
+// GetProviderType returns the provider type
+func (s *SQLiteStorageProvider) GetProviderType() ProviderType {
+	return SQLiteProvider
+}
+
+// GetProviderInfo returns provider-specific information
+func (s *SQLiteStorageProvider) GetProviderInfo() map[string]interface{} {
+	info, _ := s.GetStorageInfo()
+	if info == nil {
+		info = &storage.StorageInfo{StorageDir: s.dbPath}
+	}
+	return map[string]interface{}{
+		"type":          "sqlite",
+		"databasePath":  s.dbPath,
+		"memoriesCount": info.MemoriesCount,
+		"totalSize":     info.TotalSize,
+		"provider":      "sqlite",
+	}
+}
+
+// ValidateConfig checks that the database is reachable and writable
+func (s *SQLiteStorageProvider) ValidateConfig() error {
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return fmt.Errorf("database not accessible: %w", err)
+	}
+	if err := sqlDB.Ping(); err != nil {
+		return fmt.Errorf("database not accessible: %w", err)
+	}
+	return s.db.Exec("PRAGMA quick_check").Error
+}
+
+// Create creates a new memory
+func (s *SQLiteStorageProvider) Create(req storage.CreateMemoryRequest) (*storage.Memory, error) {
+	now := time.Now()
+	memory := &storage.Memory{
+		ID:        utils.GenerateID(),
+		Name:      req.Name,
+		Content:   req.Content,
+		Labels:    req.Labels,
+		CreatedAt: now,
+		UpdatedAt: now,
+		Metadata:  req.Metadata,
+		Relations: req.Relations,
+		ExpiresAt: req.ExpiresAt,
+	}
+
+	// Apply the same defaults as file storage
+	if memory.Name == "" {
+		memory.Name = fmt.Sprintf("Memory %s", now.Format("2006-01-02"))
+	}
+	if memory.Labels == nil {
+		memory.Labels = make(map[string]string)
+	}
+	if memory.Labels["type"] == "" {
+		memory.Labels["type"] = storage.DefaultMemoryType
+	}
+	storage.SetContentHash(memory)
+
+	if req.SkipDuplicate {
+		var row sqliteMemory
+		err := s.db.Select("id").Where("content_hash = ? AND trashed_at IS NULL", storage.MemoryContentHash(memory)).Take(&row).Error
+		if err == nil {
+			return s.Get(row.ID)
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("failed to look up duplicate: %w", err)
+		}
+	}
+
+	if err := s.Import(*memory); err != nil {
+		return nil, err
+	}
+	return memory, nil
+}
+
+// Import stores a memory as-is, keeping its ID and timestamps
+func (s *SQLiteStorageProvider) Import(memory storage.Memory) error {
+	if err := s.validateMemory(&memory); err != nil {
+		return fmt.Errorf("validation failed: %w", err)
+	}
+
+	row, err := toSQLiteMemory(&memory)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var existing int64
+		if err := tx.Model(&sqliteMemory{}).Where("id = ?", memory.ID).Count(&existing).Error; err != nil {
+			return fmt.Errorf("failed to check for existing memory: %w", err)
+		}
+		if existing > 0 {
+			return fmt.Errorf("memory %s already exists", memory.ID)
+		}
+
+		if err := tx.Create(row).Error; err != nil {
+			return fmt.Errorf("failed to write memory: %w", err)
+		}
+		if err := writeLabels(tx, memory.ID, memory.Labels); err != nil {
+			return err
+		}
+		return writeFTS(tx, &memory)
+	})
+}
+
+// Get retrieves a memory by ID
+func (s *SQLiteStorageProvider) Get(id string) (*storage.Memory, error) {
+	var row sqliteMemory
+	err := s.db.Where("id = ? AND trashed_at IS NULL", id).Take(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: %s", storage.ErrMemoryNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read memory: %w", err)
+	}
+
+	memories, err := s.withLabels([]sqliteMemory{row})
+	if err != nil {
+		return nil, err
+	}
+	return &memories[0], nil
+}
+
+// Update updates an existing memory
+func (s *SQLiteStorageProvider) Update(req storage.UpdateMemoryRequest) (*storage.Memory, error) {
+	existing, err := s.Get(req.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get existing memory: %w", err)
+	}
+
+	// Update fields if provided
+	if req.Name != "" {
+		existing.Name = req.Name
+	}
+	storage.ApplyContent(existing, req)
+	if req.Labels != nil {
+		existing.Labels = req.Labels
+	}
+	if req.Metadata != nil {
+		if existing.Metadata == nil {
+			existing.Metadata = make(map[string]any)
+		}
+		for k, v := range req.Metadata {
+			existing.Metadata[k] = v
+		}
+	}
+	if req.Relations != nil {
+		existing.Relations = req.Relations
+	}
+	storage.ApplyExpiry(existing, req.ExpiresAt)
+	existing.UpdatedAt = time.Now()
+
+	if err := s.validateMemory(storage.UpdateValidationTarget(existing, req)); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	row, err := toSQLiteMemory(existing)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(row).Error; err != nil {
+			return fmt.Errorf("failed to write memory: %w", err)
+		}
+		if req.Labels != nil {
+			if err := tx.Where("memory_id = ?", existing.ID).Delete(&sqliteLabel{}).Error; err != nil {
+				return fmt.Errorf("failed to update labels: %w", err)
+			}
+			if err := writeLabels(tx, existing.ID, existing.Labels); err != nil {
+				return err
+			}
+		}
+		return writeFTS(tx, existing)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return existing, nil
+}
+
+// RecordAccess notes that the memory was read at the given time, rewriting
+// only its metadata column
+func (s *SQLiteStorageProvider) RecordAccess(id string, at time.Time) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var row sqliteMemory
+		err := tx.Select("id", "metadata").Where("id = ? AND trashed_at IS NULL", id).Take(&row).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: %s", storage.ErrMemoryNotFound, id)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read memory: %w", err)
+		}
+
+		memory := storage.Memory{ID: id}
+		if row.Metadata != "" {
+			if err := json.Unmarshal([]byte(row.Metadata), &memory.Metadata); err != nil {
+				return fmt.Errorf("failed to unmarshal metadata for %s: %w", id, err)
+			}
+		}
+		storage.MarkAccessed(&memory, at)
+		data, err := json.Marshal(memory.Metadata)
+		if err != nil {
+			return fmt.Errorf("failed to marshal metadata: %w", err)
+		}
+		if err := tx.Model(&sqliteMemory{}).Where("id = ?", id).Update("metadata", string(data)).Error; err != nil {
+			return fmt.Errorf("failed to record access: %w", err)
+		}
+		return nil
+	})
+}
+
+// Touch sets the memory's UpdatedAt to the given time, rewriting only its
+// updated_at column
+func (s *SQLiteStorageProvider) Touch(id string, at time.Time) error {
+	result := s.db.Model(&sqliteMemory{}).Where("id = ? AND trashed_at IS NULL", id).Update("updated_at", at)
+	if result.Error != nil {
+		return fmt.Errorf("failed to touch memory: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: %s", storage.ErrMemoryNotFound, id)
+	}
+	return nil
+}
+
+// Delete permanently removes a memory by ID
+func (s *SQLiteStorageProvider) Delete(id string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND trashed_at IS NULL", id).Delete(&sqliteMemory{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete memory: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("%w: %s", storage.ErrMemoryNotFound, id)
+		}
+		return purgeRelated(tx, []string{id})
+	})
+}
+
+// List returns all memories
+func (s *SQLiteStorageProvider) List() ([]storage.Memory, error) {
+	return s.ListWithOptions(storage.ListOptions{IncludeContent: true})
+}
+
+// ListWithOptions returns memories, skipping content when it isn't needed
+func (s *SQLiteStorageProvider) ListWithOptions(opts storage.ListOptions) ([]storage.Memory, error) {
+	return s.query(s.active(opts.IncludeContent))
+}
+
+// Search searches for memories using the label index and the full-text
+// index. Results match the file provider: a case-insensitive substring
+// match on name or content, and exact label matches.
+func (s *SQLiteStorageProvider) Search(req storage.SearchRequest) (*storage.SearchResponse, error) {
+	var total int64
+	if err := s.db.Model(&sqliteMemory{}).Where("trashed_at IS NULL").Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count memories: %w", err)
+	}
+
+	queries := req.TextQueries()
+	query := s.active(req.IncludeContent || len(queries) > 0)
+	// Every query must match unless any one may; then the shared filter
+	// below does the matching
+	if !req.MatchAny || len(queries) == 1 {
+		for _, q := range queries {
+			if utf8.RuneCountInString(q) >= ftsMinQueryLength {
+				query = query.Where("id IN (SELECT id FROM memories_fts WHERE memories_fts MATCH ?)", ftsPhrase(q))
+			} else {
+				pattern := "%" + escapeLike(q) + "%"
+				query = query.Where(`(name LIKE ? ESCAPE '\' OR content LIKE ? ESCAPE '\')`, pattern, pattern)
+			}
+		}
+	}
+	for k, v := range req.LabelSelector {
+		query = query.Where("id IN (SELECT memory_id FROM memory_labels WHERE key = ? AND value = ?)", k, v)
+	}
+
+	memories, err := s.query(query)
+	if err != nil {
+		return nil, err
+	}
+
+	// The indexes narrow the candidates; the shared filter gives results
+	// identical to the file provider
+	filtered := storage.FilterMemories(memories, req)
+	if len(queries) > 0 {
+		storage.RankByRelevance(filtered, queries...)
+	}
+	if req.SortBy != "" {
+		if err := storage.SortMemories(filtered, req.SortBy, req.SortOrder); err != nil {
+			return nil, err
+		}
+	}
+	filtered = storage.ApplyLimit(filtered, req.Limit)
+
+	return &storage.SearchResponse{
+		Memories: filtered,
+		Total:    int(total),
+	}, nil
+}
+
+// GetStorageInfo returns information about the database
+func (s *SQLiteStorageProvider) GetStorageInfo() (*storage.StorageInfo, error) {
+	var count int64
+	if err := s.db.Model(&sqliteMemory{}).Where("trashed_at IS NULL").Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("failed to count memories: %w", err)
+	}
+
+	var totalSize int64
+	for _, suffix := range []string{"", "-wal"} {
+		if info, err := os.Stat(s.dbPath + suffix); err == nil {
+			totalSize += info.Size()
+		}
+	}
+
+	// Content sizes are measured in the database so no content is loaded
+	var sizes []storage.MemorySize
+	err := s.db.Model(&sqliteMemory{}).
+		Select("id, name, length(CAST(content AS BLOB)) AS size").
+		Where("trashed_at IS NULL").
+		Scan(&sizes).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to measure memories: %w", err)
+	}
+	storage.SortMemorySizes(sizes)
+
+	return &storage.StorageInfo{
+		StorageDir:    s.dbPath,
+		MemoriesCount: int(count),
+		TotalSize:     totalSize,
+		Memories:      sizes,
+	}, nil
+}
+
+// Trash soft-deletes a memory
+func (s *SQLiteStorageProvider) Trash(id string) error {
+	result := s.db.Model(&sqliteMemory{}).
+		Where("id = ? AND trashed_at IS NULL", id).
+		Update("trashed_at", time.Now())
+	if result.Error != nil {
+		return fmt.Errorf("failed to move memory to trash: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: %s", storage.ErrMemoryNotFound, id)
+	}
+	return nil
+}
+
+// ListTrash returns trashed memories, most recently deleted first
+func (s *SQLiteStorageProvider) ListTrash() ([]storage.TrashedMemory, error) {
+	var rows []sqliteMemory
+	if err := s.db.Where("trashed_at IS NOT NULL").Order("trashed_at DESC").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to read trash: %w", err)
+	}
+
+	memories, err := s.withLabels(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	trashed := make([]storage.TrashedMemory, len(memories))
+	for i, memory := range memories {
+		trashed[i] = storage.TrashedMemory{Memory: memory, DeletedAt: *rows[i].TrashedAt}
+	}
+	return trashed, nil
+}
+
+// Restore moves a trashed memory back into storage
+func (s *SQLiteStorageProvider) Restore(id string) (*storage.Memory, error) {
+	result := s.db.Model(&sqliteMemory{}).
+		Where("id = ? AND trashed_at IS NOT NULL", id).
+		Update("trashed_at", nil)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to restore memory: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, fmt.Errorf("%w in trash: %s", storage.ErrMemoryNotFound, id)
+	}
+	return s.Get(id)
+}
+
+// EmptyTrash permanently removes trashed memories deleted more than
+// olderThan ago, or all of them if olderThan is zero
+func (s *SQLiteStorageProvider) EmptyTrash(olderThan time.Duration) (int, error) {
+	query := s.db.Model(&sqliteMemory{}).Where("trashed_at IS NOT NULL")
+	if olderThan > 0 {
+		query = query.Where("trashed_at <= ?", time.Now().Add(-olderThan))
+	}
+
+	var ids []string
+	if err := query.Pluck("id", &ids).Error; err != nil {
+		return 0, fmt.Errorf("failed to read trash: %w", err)
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id IN ?", ids).Delete(&sqliteMemory{}).Error; err != nil {
+			return fmt.Errorf("failed to empty trash: %w", err)
+		}
+		return purgeRelated(tx, ids)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(ids), nil
+}
+
+// active returns a query over memories that aren't trashed, in creation
+// order
+func (s *SQLiteStorageProvider) active(includeContent bool) *gorm.DB {
+	query := s.db.Model(&sqliteMemory{}).Where("trashed_at IS NULL").Order("created_at, id")
+	if !includeContent {
+		query = query.Omit("content")
+	}
+	return query
+}
+
+// query runs a memories query and attaches labels to the results
+func (s *SQLiteStorageProvider) query(query *gorm.DB) ([]storage.Memory, error) {
+	var rows []sqliteMemory
+	if err := query.Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to query memories: %w", err)
+	}
+	return s.withLabels(rows)
+}
+
+// withLabels converts rows to memories, loading their labels in one query
+func (s *SQLiteStorageProvider) withLabels(rows []sqliteMemory) ([]storage.Memory, error) {
+	memories := make([]storage.Memory, 0, len(rows))
+	if len(rows) == 0 {
+		return memories, nil
+	}
+
+	ids := make([]string, len(rows))
+	for i, row := range rows {
+		ids[i] = row.ID
+	}
+
+	labels := make(map[string]map[string]string, len(rows))
+	// Batch the lookup to stay under SQLite's bound parameter limit
+	for start := 0; start < len(ids); start += 500 {
+		end := min(start+500, len(ids))
+		var labelRows []sqliteLabel
+		if err := s.db.Where("memory_id IN ?", ids[start:end]).Find(&labelRows).Error; err != nil {
+			return nil, fmt.Errorf("failed to read labels: %w", err)
+		}
+		for _, label := range labelRows {
+			if labels[label.MemoryID] == nil {
+				labels[label.MemoryID] = make(map[string]string)
+			}
+			labels[label.MemoryID][label.Key] = label.Value
+		}
+	}
+
+	for _, row := range rows {
+		memory := storage.Memory{
+			ID:        row.ID,
+			Name:      row.Name,
+			Content:   row.Content,
+			Labels:    labels[row.ID],
+			CreatedAt: row.CreatedAt,
+			UpdatedAt: row.UpdatedAt,
+			ExpiresAt: row.ExpiresAt,
+			Metadata:  make(map[string]any),
+		}
+		if memory.Labels == nil {
+			memory.Labels = make(map[string]string)
+		}
+		if row.Metadata != "" {
+			if err := json.Unmarshal([]byte(row.Metadata), &memory.Metadata); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal metadata for %s: %w", row.ID, err)
+			}
+		}
+		if row.Relations != "" {
+			if err := json.Unmarshal([]byte(row.Relations), &memory.Relations); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal relations for %s: %w", row.ID, err)
+			}
+		}
+		memories = append(memories, memory)
+	}
+
+	return memories, nil
+}
+
+func toSQLiteMemory(memory *storage.Memory) (*sqliteMemory, error) {
+	row := &sqliteMemory{
+		ID:          memory.ID,
+		Name:        memory.Name,
+		Content:     memory.Content,
+		CreatedAt:   memory.CreatedAt,
+		UpdatedAt:   memory.UpdatedAt,
+		ExpiresAt:   memory.ExpiresAt,
+		ContentHash: storage.MemoryContentHash(memory),
+	}
+	if len(memory.Metadata) > 0 {
+		data, err := json.Marshal(memory.Metadata)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal metadata: %w", err)
+		}
+		row.Metadata = string(data)
+	}
+	if len(memory.Relations) > 0 {
+		data, err := json.Marshal(memory.Relations)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal relations: %w", err)
+		}
+		row.Relations = string(data)
+	}
+	return row, nil
+}
+
+func writeLabels(tx *gorm.DB, id string, labels map[string]string) error {
+	if len(labels) == 0 {
+		return nil
+	}
+	rows := make([]sqliteLabel, 0, len(labels))
+	for k, v := range labels {
+		rows = append(rows, sqliteLabel{MemoryID: id, Key: k, Value: v})
+	}
+	if err := tx.Create(&rows).Error; err != nil {
+		return fmt.Errorf("failed to write labels: %w", err)
+	}
+	return nil
+}
+
+// writeFTS replaces the full-text index entry for a memory
+func writeFTS(tx *gorm.DB, memory *storage.Memory) error {
+	if err := tx.Exec("DELETE FROM memories_fts WHERE id = ?", memory.ID).Error; err != nil {
+		return fmt.Errorf("failed to update search index: %w", err)
+	}
+	if err := tx.Exec("INSERT INTO memories_fts (id, name, content) VALUES (?, ?, ?)",
+		memory.ID, memory.Name, memory.Content).Error; err != nil {
+		return fmt.Errorf("failed to update search index: %w", err)
+	}
+	return nil
+}
+
+// purgeRelated removes the labels and search index entries of deleted
+// memories
+func purgeRelated(tx *gorm.DB, ids []string) error {
+	if err := tx.Where("memory_id IN ?", ids).Delete(&sqliteLabel{}).Error; err != nil {
+		return fmt.Errorf("failed to delete labels: %w", err)
+	}
+	if err := tx.Exec("DELETE FROM memories_fts WHERE id IN ?", ids).Error; err != nil {
+		return fmt.Errorf("failed to update search index: %w", err)
+	}
+	return nil
+}
+
+// ftsPhrase quotes a query as a single FTS5 phrase, which the trigram
+// tokenizer matches as a substring
+func ftsPhrase(query string) string {
+	return `"` + strings.ReplaceAll(query, `"`, `""`) + `"`
+}
+
+// escapeLike escapes LIKE wildcards in s
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// Metadata keys recording when a memory was last read and how many times
+const (
+	AccessedAtKey  = "lastAccessedAt"
+	AccessCountKey = "accessCount"
+)
+
+// LastAccessed returns when the memory was last read, or false if its reads
+// have never been recorded
+func (m Memory) LastAccessed() (time.Time, bool) {
+	switch v := m.Metadata[AccessedAtKey].(type) {
+	case time.Time:
+		return v, true
+	case string:
+		at, err := time.Parse(time.RFC3339Nano, v)
+		return at, err == nil
+	}
+	return time.Time{}, false
+}
+
+// AccessCount returns how many reads of the memory have been recorded
+func (m Memory) AccessCount() int {
+	switch v := m.Metadata[AccessCountKey].(type) {
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	}
+	return 0
+}
+
+// MarkAccessed records a read of the memory at the given time in its
+// metadata, copying the metadata map so callers' maps aren't modified
+func MarkAccessed(memory *Memory, at time.Time) {
+	count := memory.AccessCount()
+	metadata := make(map[string]any, len(memory.Metadata)+2)
+	for k, v := range memory.Metadata {
+		metadata[k] = v
+	}
+	metadata[AccessedAtKey] = at.UTC().Format(time.RFC3339Nano)
+	metadata[AccessCountKey] = count + 1
+	memory.Metadata = metadata
+}
+
+// accessedAt returns the memory's last access time for the index, or nil if
+// it has never been read
+func accessedAt(memory *Memory) *time.Time {
+	at, ok := memory.LastAccessed()
+	if !ok {
+		return nil
+	}
+	return &at
+}
+
+// RecordAccess notes that the memory was read at the given time. The read
+// is recorded in the memory's index entry, so only the index is rewritten:
+// the memory's file and UpdatedAt are left alone, so reading a memory
+// doesn't make it look recently edited.
+func (fs *FileStorage) RecordAccess(id string, at time.Time) error {
+	index, err := fs.readIndex()
+	if err != nil {
+		return fmt.Errorf("failed to read index: %w", err)
+	}
+	for i := range index.Memories {
+		entry := &index.Memories[i]
+		if entry.ID != id {
+			continue
+		}
+		if entry.AccessedAt != nil && entry.AccessCount == 0 {
+			// Reads recorded before the index counted them are in the file
+			if memory, err := fs.readMemory(id); err == nil {
+				entry.AccessCount = memory.AccessCount()
+			}
+		}
+		at = at.UTC()
+		entry.AccessedAt = &at
+		entry.AccessCount++
+		return fs.writeIndex(index)
+	}
+
+	if _, err := fs.readMemory(id); err != nil {
+		return err
+	}
+	return fmt.Errorf("memory %s is missing from the index", id)
+}
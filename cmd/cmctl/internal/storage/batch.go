@@ -0,0 +1,137 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// ErrBatchOpen is returned by BeginBatch when a batch is already open
+var ErrBatchOpen = errors.New("a batch is already open")
+
+// ErrNoBatch is returned by Commit and Rollback when no batch is open
+var ErrNoBatch = errors.New("no batch is open")
+
+// fileBatch holds the state of an open batch: the index, which is only
+// written on Commit, and the original state of every file the batch has
+// touched, so Rollback can put them back
+type fileBatch struct {
+	index   Index
+	files   map[string]fileBackup
+	touched []string // Paths in the order they were first touched
+}
+
+// fileBackup is a file as it was before a batch first changed it
+type fileBackup struct {
+	exists  bool
+	data    []byte
+	modTime time.Time
+}
+
+// BeginBatch starts a batch. Until Commit or Rollback, index updates are
+// held in memory and every memory file written, moved or removed is
+// recorded so the batch can be undone. A FileStorage supports one batch at
+// a time and isn't safe for concurrent use while it is open.
+func (fs *FileStorage) BeginBatch() error {
+	if fs.batch != nil {
+		return ErrBatchOpen
+	}
+	index, err := fs.readIndex()
+	if err != nil {
+		return fmt.Errorf("failed to read index: %w", err)
+	}
+	fs.batch = &fileBatch{index: index, files: make(map[string]fileBackup)}
+	return nil
+}
+
+// Commit ends the batch, writing the index once for all of its changes
+func (fs *FileStorage) Commit() error {
+	if fs.batch == nil {
+		return ErrNoBatch
+	}
+	index := fs.batch.index
+	fs.batch = nil
+	if err := fs.writeIndex(index); err != nil {
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	return nil
+}
+
+// Rollback ends the batch, restoring every file it touched to its state
+// before the batch began. The index on disk was never changed by the batch.
+func (fs *FileStorage) Rollback() error {
+	if fs.batch == nil {
+		return ErrNoBatch
+	}
+	batch := fs.batch
+	fs.batch = nil
+
+	var errs []error
+	for i := len(batch.touched) - 1; i >= 0; i-- {
+		path := batch.touched[i]
+		if err := restoreFile(path, batch.files[path]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("failed to roll back batch: %w", err)
+	}
+	return nil
+}
+
+// Batch runs fn in a batch, committing if it succeeds and rolling back if
+// it returns an error, so fn's changes are either all kept or all undone
+func (fs *FileStorage) Batch(fn func() error) error {
+	if err := fs.BeginBatch(); err != nil {
+		return err
+	}
+	if err := fn(); err != nil {
+		if rollbackErr := fs.Rollback(); rollbackErr != nil {
+			return errors.Join(err, rollbackErr)
+		}
+		return err
+	}
+	return fs.Commit()
+}
+
+// touch records the state of path before an open batch first changes it
+func (fs *FileStorage) touch(paths ...string) error {
+	if fs.batch == nil {
+		return nil
+	}
+	for _, path := range paths {
+		if _, ok := fs.batch.files[path]; ok {
+			continue
+		}
+		var backup fileBackup
+		info, err := os.Stat(path)
+		switch {
+		case err == nil:
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("failed to back up %s: %w", path, err)
+			}
+			backup = fileBackup{exists: true, data: data, modTime: info.ModTime()}
+		case !os.IsNotExist(err):
+			return fmt.Errorf("failed to back up %s: %w", path, err)
+		}
+		fs.batch.files[path] = backup
+		fs.batch.touched = append(fs.batch.touched, path)
+	}
+	return nil
+}
+
+// restoreFile puts a file back as recorded in backup
+func restoreFile(path string, backup fileBackup) error {
+	if !backup.exists {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	if err := os.WriteFile(path, backup.data, 0644); err != nil {
+		return err
+	}
+	return os.Chtimes(path, backup.modTime, backup.modTime)
+}
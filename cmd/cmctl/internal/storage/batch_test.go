@@ -0,0 +1,134 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestBatchRollback(t *testing.T) {
+	fs, err := NewFileStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create FileStorage: %v", err)
+	}
+
+	edited, err := fs.Create(CreateMemoryRequest{Name: "Edited", Content: "original"})
+	if err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+	trashed, err := fs.Create(CreateMemoryRequest{Name: "Trashed", Content: "keep"})
+	if err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+	indexBefore, err := os.ReadFile(fs.indexFile)
+	if err != nil {
+		t.Fatalf("Failed to read index: %v", err)
+	}
+
+	var created []string
+	failure := errors.New("chat 3 failed")
+	err = fs.Batch(func() error {
+		for _, name := range []string{"First", "Second"} {
+			memory, err := fs.Create(CreateMemoryRequest{Name: name, Content: name})
+			if err != nil {
+				return err
+			}
+			created = append(created, memory.ID)
+		}
+		if _, err := fs.Update(UpdateMemoryRequest{ID: edited.ID, Content: "changed"}); err != nil {
+			return err
+		}
+		if err := fs.Trash(trashed.ID); err != nil {
+			return err
+		}
+
+		// Reads inside the batch see its changes
+		result, err := fs.Search(SearchRequest{UseIndex: true})
+		if err != nil {
+			return err
+		}
+		if len(result.Memories) != 3 {
+			t.Errorf("Expected 3 memories inside the batch, got %d", len(result.Memories))
+		}
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("Expected the batch to return its failure, got %v", err)
+	}
+
+	for _, id := range created {
+		if _, err := fs.Get(id); !errors.Is(err, ErrMemoryNotFound) {
+			t.Errorf("Expected memory %s created in the batch to be removed, got %v", id, err)
+		}
+	}
+	memory, err := fs.Get(edited.ID)
+	if err != nil || memory.Content != "original" {
+		t.Errorf("Expected the edited memory to be restored, got %+v (err=%v)", memory, err)
+	}
+	if _, err := fs.Get(trashed.ID); err != nil {
+		t.Errorf("Expected the trashed memory to be restored: %v", err)
+	}
+	inTrash, err := fs.ListTrash()
+	if err != nil {
+		t.Fatalf("Failed to list trash: %v", err)
+	}
+	if len(inTrash) != 0 {
+		t.Errorf("Expected an empty trash after rollback, got %d", len(inTrash))
+	}
+
+	indexAfter, err := os.ReadFile(fs.indexFile)
+	if err != nil {
+		t.Fatalf("Failed to read index: %v", err)
+	}
+	if !bytes.Equal(indexBefore, indexAfter) {
+		t.Error("Expected the index to be unchanged after rollback")
+	}
+}
+
+func TestBatchCommit(t *testing.T) {
+	fs, err := NewFileStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create FileStorage: %v", err)
+	}
+	indexBefore, err := os.ReadFile(fs.indexFile)
+	if err != nil {
+		t.Fatalf("Failed to read index: %v", err)
+	}
+
+	if err := fs.BeginBatch(); err != nil {
+		t.Fatalf("Failed to begin batch: %v", err)
+	}
+	if err := fs.BeginBatch(); !errors.Is(err, ErrBatchOpen) {
+		t.Errorf("Expected ErrBatchOpen for a second batch, got %v", err)
+	}
+	for _, name := range []string{"First", "Second"} {
+		if _, err := fs.Create(CreateMemoryRequest{Name: name, Content: name}); err != nil {
+			t.Fatalf("Failed to create memory: %v", err)
+		}
+	}
+
+	// The index is only written on commit
+	indexDuring, err := os.ReadFile(fs.indexFile)
+	if err != nil {
+		t.Fatalf("Failed to read index: %v", err)
+	}
+	if !bytes.Equal(indexBefore, indexDuring) {
+		t.Error("Expected the index on disk to be unchanged until commit")
+	}
+
+	if err := fs.Commit(); err != nil {
+		t.Fatalf("Failed to commit: %v", err)
+	}
+	if err := fs.Commit(); !errors.Is(err, ErrNoBatch) {
+		t.Errorf("Expected ErrNoBatch after commit, got %v", err)
+	}
+
+	memories, err := fs.ListWithOptions(ListOptions{UseIndex: true})
+	if err != nil {
+		t.Fatalf("Failed to list memories: %v", err)
+	}
+	if len(memories) != 2 {
+		t.Errorf("Expected 2 memories in the index after commit, got %d", len(memories))
+	}
+}
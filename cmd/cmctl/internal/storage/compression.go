@@ -0,0 +1,166 @@
+package storage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ContentCompressionGzip marks a memory file whose content is stored as
+// base64-encoded gzip
+const ContentCompressionGzip = "gzip"
+
+// MinCompressBytes is the smallest content that is compressed; below it the
+// gzip header and base64 expansion eat most of the savings
+const MinCompressBytes = 1024
+
+// storedMemory is a memory as written to its file. When ContentCompression
+// is set, Content holds the compressed content rather than the text.
+// DeletedAt is only set on files in the trash.
+type storedMemory struct {
+	Memory
+	ContentCompression string     `json:"contentCompression,omitempty"`
+	DeletedAt          *time.Time `json:"deletedAt,omitempty"`
+}
+
+// CompactResult reports what Compact rewrote
+type CompactResult struct {
+	// Compressed is the number of memory files rewritten
+	Compressed int
+	// BytesBefore and BytesAfter are the total size of those files before
+	// and after compressing them
+	BytesBefore int64
+	BytesAfter  int64
+}
+
+// SetCompression makes memories written from now on store content of at
+// least MinCompressBytes gzipped. Compressed memories are read back
+// transparently whether or not compression is enabled.
+func (fs *FileStorage) SetCompression(enabled bool) {
+	fs.compress = enabled
+}
+
+// marshalMemory returns the file contents for memory, compressing its
+// content when compress is set and that makes it smaller
+func marshalMemory(memory *Memory, compress bool) ([]byte, error) {
+	stored := storedMemory{Memory: *memory}
+	if compress && len(memory.Content) >= MinCompressBytes {
+		compressed, err := gzipContent(memory.Content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compress content: %w", err)
+		}
+		if len(compressed) < len(memory.Content) {
+			stored.Content = compressed
+			stored.ContentCompression = ContentCompressionGzip
+		}
+	}
+	return json.MarshalIndent(stored, "", "  ")
+}
+
+// unmarshalMemory parses a memory file, decompressing its content
+func unmarshalMemory(data []byte) (Memory, error) {
+	stored, err := unmarshalStored(data)
+	return stored.Memory, err
+}
+
+// unmarshalStored parses a memory file, decompressing its content and
+// keeping what the file records besides the memory
+func unmarshalStored(data []byte) (storedMemory, error) {
+	var stored storedMemory
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return storedMemory{}, err
+	}
+	switch stored.ContentCompression {
+	case "":
+	case ContentCompressionGzip:
+		content, err := gunzipContent(stored.Content)
+		if err != nil {
+			return storedMemory{}, fmt.Errorf("failed to decompress content: %w", err)
+		}
+		stored.Content = content
+		stored.ContentCompression = ""
+	default:
+		return storedMemory{}, fmt.Errorf("unsupported content compression %q", stored.ContentCompression)
+	}
+	return stored, nil
+}
+
+// gzipContent returns content gzipped and base64-encoded
+func gzipContent(content string) (string, error) {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write([]byte(content)); err != nil {
+		return "", err
+	}
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// gunzipContent reverses gzipContent
+func gunzipContent(compressed string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(compressed)
+	if err != nil {
+		return "", err
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+// Compact compresses the content of every memory file that gets smaller
+// for it, whether or not compression is enabled for new writes. Nothing
+// else about the memories changes, including their UpdatedAt.
+func (fs *FileStorage) Compact() (CompactResult, error) {
+	var result CompactResult
+	files, err := filepath.Glob(filepath.Join(fs.memoriesDir, "*.json"))
+	if err != nil {
+		return result, fmt.Errorf("failed to glob memory files: %w", err)
+	}
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return result, fmt.Errorf("failed to read memory file: %w", err)
+		}
+		memory, err := unmarshalMemory(data)
+		if err != nil {
+			fs.logger.Warn("skipping corrupted file", "file", file, "error", err)
+			continue
+		}
+
+		compacted, err := marshalMemory(&memory, true)
+		if err != nil {
+			return result, fmt.Errorf("failed to marshal memory: %w", err)
+		}
+		// Already compressed, or too small to benefit
+		if len(compacted) >= len(data) {
+			continue
+		}
+
+		if err := fs.touch(file); err != nil {
+			return result, err
+		}
+		if err := fs.writeFile(file, compacted); err != nil {
+			return result, fmt.Errorf("failed to write memory file: %w", err)
+		}
+		result.Compressed++
+		result.BytesBefore += int64(len(data))
+		result.BytesAfter += int64(len(compacted))
+	}
+	return result, nil
+}
@@ -0,0 +1,150 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// largeContent is chat-like content that compresses well
+var largeContent = strings.Repeat("**User**: How do I compress memories?\n\n**Assistant**: Enable compress-content.\n\n", 100)
+
+func memoryFileSize(t *testing.T, fs *FileStorage, id string) int64 {
+	t.Helper()
+	info, err := os.Stat(filepath.Join(fs.memoriesDir, id+".json"))
+	if err != nil {
+		t.Fatalf("Failed to stat memory file: %v", err)
+	}
+	return info.Size()
+}
+
+func TestCompressionRoundTrip(t *testing.T) {
+	plain, err := NewFileStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	compressed, err := NewFileStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	compressed.SetCompression(true)
+
+	var ids [2]string
+	for i, fs := range []*FileStorage{plain, compressed} {
+		memory, err := fs.Create(CreateMemoryRequest{Name: "Large chat", Content: largeContent})
+		if err != nil {
+			t.Fatalf("Failed to create memory: %v", err)
+		}
+		ids[i] = memory.ID
+	}
+
+	data, err := os.ReadFile(filepath.Join(compressed.memoriesDir, ids[1]+".json"))
+	if err != nil {
+		t.Fatalf("Failed to read memory file: %v", err)
+	}
+	if !strings.Contains(string(data), `"contentCompression": "gzip"`) || strings.Contains(string(data), "compress-content") {
+		t.Errorf("Expected the content to be stored compressed, got %s", data)
+	}
+	plainSize, compressedSize := memoryFileSize(t, plain, ids[0]), memoryFileSize(t, compressed, ids[1])
+	if compressedSize*4 > plainSize {
+		t.Errorf("Expected compression to shrink the file at least 4x, got %d bytes from %d", compressedSize, plainSize)
+	}
+
+	got, err := compressed.Get(ids[1])
+	if err != nil {
+		t.Fatalf("Failed to get memory: %v", err)
+	}
+	if got.Content != largeContent {
+		t.Error("Expected Get to return the original content")
+	}
+
+	for _, useIndex := range []bool{true, false} {
+		memories, err := compressed.ListWithOptions(ListOptions{IncludeContent: true, UseIndex: useIndex})
+		if err != nil {
+			t.Fatalf("Failed to list memories: %v", err)
+		}
+		if len(memories) != 1 || memories[0].Content != largeContent {
+			t.Errorf("useIndex=%v: expected List to return the original content", useIndex)
+		}
+	}
+
+	result, err := compressed.Search(SearchRequest{Query: "compress-content", IncludeContent: true})
+	if err != nil {
+		t.Fatalf("Failed to search: %v", err)
+	}
+	if len(result.Memories) != 1 {
+		t.Errorf("Expected search to match the compressed content, got %d memories", len(result.Memories))
+	}
+
+	// Small content isn't worth compressing
+	small, err := compressed.Create(CreateMemoryRequest{Name: "Small", Content: "short note"})
+	if err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+	data, err = os.ReadFile(filepath.Join(compressed.memoriesDir, small.ID+".json"))
+	if err != nil {
+		t.Fatalf("Failed to read memory file: %v", err)
+	}
+	if strings.Contains(string(data), "contentCompression") {
+		t.Errorf("Expected small content to be stored as text, got %s", data)
+	}
+
+	// Compressed memories survive the trash
+	if err := compressed.Trash(ids[1]); err != nil {
+		t.Fatalf("Failed to trash memory: %v", err)
+	}
+	trashed, err := compressed.ListTrash()
+	if err != nil {
+		t.Fatalf("Failed to list trash: %v", err)
+	}
+	if len(trashed) != 1 || trashed[0].Content != largeContent {
+		t.Error("Expected the trashed memory's content to be decompressed")
+	}
+}
+
+func TestCompact(t *testing.T) {
+	fs, err := NewFileStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	large, err := fs.Create(CreateMemoryRequest{Name: "Large chat", Content: largeContent})
+	if err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+	if _, err := fs.Create(CreateMemoryRequest{Name: "Small", Content: "short note"}); err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+	before := memoryFileSize(t, fs, large.ID)
+
+	result, err := fs.Compact()
+	if err != nil {
+		t.Fatalf("Failed to compact: %v", err)
+	}
+	after := memoryFileSize(t, fs, large.ID)
+	if result.Compressed != 1 || result.BytesBefore != before || result.BytesAfter != after {
+		t.Errorf("Expected only the large memory to be compressed from %d to %d bytes, got %+v", before, after, result)
+	}
+	if after >= before {
+		t.Errorf("Expected the file to shrink, got %d bytes from %d", after, before)
+	}
+
+	got, err := fs.Get(large.ID)
+	if err != nil {
+		t.Fatalf("Failed to get memory: %v", err)
+	}
+	if got.Content != largeContent || !got.UpdatedAt.Equal(large.UpdatedAt) {
+		t.Error("Expected compaction to leave the content and UpdatedAt unchanged")
+	}
+
+	// Compacting again finds nothing left to do
+	if result, err := fs.Compact(); err != nil || result.Compressed != 0 {
+		t.Errorf("Expected nothing to compress the second time, got %+v, %v", result, err)
+	}
+}
+
+func TestUnmarshalMemoryUnknownCompression(t *testing.T) {
+	if _, err := unmarshalMemory([]byte(`{"id": "mem_1", "content": "x", "contentCompression": "zstd"}`)); err == nil {
+		t.Error("Expected an error for an unsupported compression")
+	}
+}
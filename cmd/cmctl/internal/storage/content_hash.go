@@ -0,0 +1,34 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// ContentHashKey is the metadata key holding the SHA-256 of a memory's
+// stored content
+const ContentHashKey = "contentHash"
+
+// ContentHash returns the hex SHA-256 of content
+func ContentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// SetContentHash records the hash of the memory's content in its metadata,
+// copying the metadata map so callers' maps aren't modified
+func SetContentHash(memory *Memory) {
+	metadata := make(map[string]any, len(memory.Metadata)+1)
+	for k, v := range memory.Metadata {
+		metadata[k] = v
+	}
+	metadata[ContentHashKey] = ContentHash(memory.Content)
+	memory.Metadata = metadata
+}
+
+// MemoryContentHash returns the content hash recorded in a memory's
+// metadata, or "" for memories created before hashes were recorded
+func MemoryContentHash(memory *Memory) string {
+	hash, _ := memory.Metadata[ContentHashKey].(string)
+	return hash
+}
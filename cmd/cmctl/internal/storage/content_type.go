@@ -0,0 +1,172 @@
+package storage
+
+import (
+	"fmt"
+	"mime"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ContentTypeKey is the metadata key holding the media type of a memory's
+// content, which decides how it's rendered
+const ContentTypeKey = "contentType"
+
+// Content types recorded on memories. Source code is text/x-<language>.
+const (
+	ContentTypeMarkdown = "text/markdown"
+	ContentTypePlain    = "text/plain"
+	codeTypePrefix      = "text/x-"
+)
+
+// codeExtensions maps source file extensions onto their language
+var codeExtensions = map[string]string{
+	".go":   "go",
+	".py":   "python",
+	".js":   "javascript",
+	".mjs":  "javascript",
+	".ts":   "typescript",
+	".tsx":  "typescript",
+	".rs":   "rust",
+	".java": "java",
+	".kt":   "kotlin",
+	".c":    "c",
+	".h":    "c",
+	".cpp":  "cpp",
+	".cc":   "cpp",
+	".cs":   "csharp",
+	".rb":   "ruby",
+	".php":  "php",
+	".sh":   "bash",
+	".bash": "bash",
+	".sql":  "sql",
+	".yaml": "yaml",
+	".yml":  "yaml",
+	".json": "json",
+	".toml": "toml",
+}
+
+// shebangLanguages maps script interpreters onto their language
+var shebangLanguages = map[string]string{
+	"sh":      "bash",
+	"bash":    "bash",
+	"zsh":     "bash",
+	"python":  "python",
+	"python3": "python",
+	"node":    "javascript",
+	"ruby":    "ruby",
+}
+
+// goPackageClause matches the package clause that starts a Go file
+var goPackageClause = regexp.MustCompile(`^package [A-Za-z_][A-Za-z0-9_]*$`)
+
+// CodeContentType returns the content type of source code in language
+func CodeContentType(language string) string {
+	return codeTypePrefix + language
+}
+
+// CodeLanguage returns the language of a text/x-<language> content type,
+// or "" if contentType isn't source code
+func CodeLanguage(contentType string) string {
+	language, ok := strings.CutPrefix(contentType, codeTypePrefix)
+	if !ok {
+		return ""
+	}
+	return language
+}
+
+// ValidateContentType checks that contentType is a media type such as
+// text/markdown
+func ValidateContentType(contentType string) error {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || !strings.Contains(mediaType, "/") {
+		return fmt.Errorf("invalid content type %q (use a media type such as %s, %s or %s)",
+			contentType, ContentTypeMarkdown, ContentTypePlain, CodeContentType("go"))
+	}
+	return nil
+}
+
+// DetectContentType guesses the content type from the name of the file the
+// content came from, which may be empty, and failing that from the content
+func DetectContentType(name, content string) string {
+	ext := strings.ToLower(filepath.Ext(name))
+	switch ext {
+	case ".md", ".markdown":
+		return ContentTypeMarkdown
+	case ".txt":
+		return ContentTypePlain
+	}
+	if language, ok := codeExtensions[ext]; ok {
+		return CodeContentType(language)
+	}
+
+	if first := firstCodeLine(content); strings.HasPrefix(first, "#!") {
+		if language := shebangLanguage(first); language != "" {
+			return CodeContentType(language)
+		}
+	} else if goPackageClause.MatchString(first) {
+		return CodeContentType("go")
+	}
+	if LooksLikeMarkdown(content) {
+		return ContentTypeMarkdown
+	}
+	return ContentTypePlain
+}
+
+// firstCodeLine returns the first line of content that isn't blank or a
+// // comment
+func firstCodeLine(content string) string {
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed != "" && !strings.HasPrefix(trimmed, "//") {
+			return trimmed
+		}
+	}
+	return ""
+}
+
+// shebangLanguage returns the language of the interpreter named on a #!
+// line, looking past /usr/bin/env
+func shebangLanguage(line string) string {
+	fields := strings.Fields(strings.TrimPrefix(line, "#!"))
+	if len(fields) == 0 {
+		return ""
+	}
+	interpreter := path.Base(fields[0])
+	if interpreter == "env" && len(fields) > 1 {
+		interpreter = path.Base(fields[1])
+	}
+	return shebangLanguages[interpreter]
+}
+
+// LooksLikeMarkdown reports whether content has markdown headings or code
+// fences
+func LooksLikeMarkdown(content string) bool {
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "# ") || strings.HasPrefix(trimmed, "## ") {
+			return true
+		}
+	}
+	return false
+}
+
+// ContentType returns the content type recorded for the memory, or "" if
+// none was
+func (m Memory) ContentType() string {
+	contentType, _ := m.Metadata[ContentTypeKey].(string)
+	return contentType
+}
+
+// WithContentType returns a copy of req with contentType recorded in its
+// metadata
+func WithContentType(req CreateMemoryRequest, contentType string) CreateMemoryRequest {
+	metadata := make(map[string]any, len(req.Metadata)+1)
+	for k, v := range req.Metadata {
+		metadata[k] = v
+	}
+	metadata[ContentTypeKey] = contentType
+	req.Metadata = metadata
+	return req
+}
@@ -0,0 +1,59 @@
+package storage
+
+import "testing"
+
+func TestDetectContentType(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		content  string
+		want     string
+	}{
+		{name: "markdown extension", filename: "notes.md", content: "plain words", want: ContentTypeMarkdown},
+		{name: "text extension", filename: "notes.TXT", content: "# not a heading here", want: ContentTypePlain},
+		{name: "go extension", filename: "main.go", content: "x := 1", want: "text/x-go"},
+		{name: "python extension", filename: "dir/tool.py", content: "print(1)", want: "text/x-python"},
+		{name: "go package clause", content: "// Package demo does things\npackage demo\n\nfunc F() {}\n", want: "text/x-go"},
+		{name: "env shebang", content: "#!/usr/bin/env python3\nprint(1)\n", want: "text/x-python"},
+		{name: "shell shebang", content: "#!/bin/sh\necho hi\n", want: "text/x-bash"},
+		{name: "markdown heading", content: "# Title\n\nSome notes", want: ContentTypeMarkdown},
+		{name: "markdown fence", content: "Run this:\n```go\nfmt.Println()\n```\n", want: ContentTypeMarkdown},
+		{name: "plain text", content: "Remember to rotate the keys", want: ContentTypePlain},
+		{name: "unknown extension", filename: "data.bin", content: "just text", want: ContentTypePlain},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectContentType(tt.filename, tt.content); got != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCodeLanguage(t *testing.T) {
+	tests := map[string]string{
+		"text/x-go":         "go",
+		ContentTypeMarkdown: "",
+		ContentTypePlain:    "",
+		"":                  "",
+	}
+	for contentType, want := range tests {
+		if got := CodeLanguage(contentType); got != want {
+			t.Errorf("CodeLanguage(%q): expected %q, got %q", contentType, want, got)
+		}
+	}
+}
+
+func TestValidateContentType(t *testing.T) {
+	for _, valid := range []string{"text/markdown", "text/x-go", "text/plain; charset=utf-8"} {
+		if err := ValidateContentType(valid); err != nil {
+			t.Errorf("Expected %q to be valid, got %v", valid, err)
+		}
+	}
+	for _, invalid := range []string{"markdown", "", "text/"} {
+		if err := ValidateContentType(invalid); err == nil {
+			t.Errorf("Expected %q to be rejected", invalid)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+)
+
+// ContentEncodingKey is the metadata key marking content that is stored
+// encoded rather than as text
+const ContentEncodingKey = "contentEncoding"
+
+// ContentEncodingBase64 marks binary content stored as standard base64
+const ContentEncodingBase64 = "base64"
+
+// ErrBinaryContent is returned (wrapped) when content isn't text and isn't
+// marked as encoded
+var ErrBinaryContent = errors.New("content is binary or not valid UTF-8")
+
+// binarySniffLength is how much of the content is checked for NUL bytes,
+// as git does
+const binarySniffLength = 8000
+
+// IsBinary reports whether content looks like binary data rather than text:
+// it isn't valid UTF-8, or has a NUL byte near the start
+func IsBinary(content string) bool {
+	if !utf8.ValidString(content) {
+		return true
+	}
+	for i := 0; i < len(content) && i < binarySniffLength; i++ {
+		if content[i] == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// IsEncoded reports whether the memory's content is stored base64-encoded
+func (m Memory) IsEncoded() bool {
+	return m.Metadata[ContentEncodingKey] == ContentEncodingBase64
+}
+
+// DecodedContent returns the memory's content as the bytes that were
+// stored, decoding base64 content
+func (m Memory) DecodedContent() ([]byte, error) {
+	if !m.IsEncoded() {
+		return []byte(m.Content), nil
+	}
+	data, err := base64.StdEncoding.DecodeString(m.Content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s content of memory %s: %w", ContentEncodingBase64, m.ID, err)
+	}
+	return data, nil
+}
+
+// EncodeBinary returns a copy of req with its content base64-encoded and
+// marked as such in its metadata
+func EncodeBinary(req CreateMemoryRequest) CreateMemoryRequest {
+	metadata := make(map[string]any, len(req.Metadata)+1)
+	for k, v := range req.Metadata {
+		metadata[k] = v
+	}
+	metadata[ContentEncodingKey] = ContentEncodingBase64
+	req.Metadata = metadata
+	req.Content = base64.StdEncoding.EncodeToString([]byte(req.Content))
+	return req
+}
+
+// ValidateContent checks that content is text, or valid base64 when the
+// memory is marked as encoded. Sealed content was checked before it was
+// encrypted.
+func ValidateContent(memory *Memory) error {
+	if IsSealed(memory.Content) {
+		return nil
+	}
+	switch encoding := memory.Metadata[ContentEncodingKey]; encoding {
+	case nil:
+		if IsBinary(memory.Content) {
+			return ErrBinaryContent
+		}
+	case ContentEncodingBase64:
+		if _, err := base64.StdEncoding.DecodeString(memory.Content); err != nil {
+			return fmt.Errorf("content marked %s is not valid base64", ContentEncodingBase64)
+		}
+	default:
+		return fmt.Errorf("unsupported content encoding %v", encoding)
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// EventLogFile is the name of the event log in the storage directory
+const EventLogFile = "events.log"
+
+// DefaultEventLogMaxBytes is the size at which the event log is rotated
+const DefaultEventLogMaxBytes = 10 << 20
+
+// eventLogBackups is how many rotated event logs are kept, as
+// events.log.1 (the newest) to events.log.3
+const eventLogBackups = 3
+
+// Operations recorded in the event log
+const (
+	EventCreate     = "create"
+	EventImport     = "import"
+	EventUpdate     = "update"
+	EventTouch      = "touch"
+	EventDelete     = "delete"
+	EventTrash      = "trash"
+	EventRestore    = "restore"
+	EventEmptyTrash = "empty-trash"
+)
+
+// Event records a change to the store
+type Event struct {
+	Time      time.Time `json:"time"`
+	Operation string    `json:"operation"`
+	ID        string    `json:"id,omitempty"`
+	Name      string    `json:"name,omitempty"`
+	// Count is the number of memories changed by operations that don't
+	// name them, such as emptying the trash
+	Count int `json:"count,omitempty"`
+}
+
+// EventLog is an append-only log of events, one JSON object per line. It
+// is rotated once it grows past a size limit, keeping a few old logs.
+type EventLog struct {
+	path string
+	// maxBytes is the size that triggers rotation; 0 means unbounded
+	maxBytes int64
+}
+
+// NewEventLog returns the event log at path, rotated when it would grow
+// past maxBytes
+func NewEventLog(path string, maxBytes int64) *EventLog {
+	return &EventLog{path: path, maxBytes: max(maxBytes, 0)}
+}
+
+// Path returns the path of the current log
+func (l *EventLog) Path() string {
+	return l.path
+}
+
+// Append adds an event to the log, stamping it with the current time if it
+// has none
+func (l *EventLog) Append(event Event) error {
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+	line, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+	line = append(line, '\n')
+
+	if l.maxBytes > 0 {
+		if info, err := os.Stat(l.path); err == nil && info.Size() > 0 && info.Size()+int64(len(line)) > l.maxBytes {
+			if err := l.rotate(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return fmt.Errorf("failed to create event log directory: %w", err)
+	}
+	file, err := os.OpenFile(l.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open event log: %w", err)
+	}
+	if _, err := file.Write(line); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write event: %w", err)
+	}
+	return file.Close()
+}
+
+// backupPath returns the path of the n-th most recent rotated log
+func (l *EventLog) backupPath(n int) string {
+	return fmt.Sprintf("%s.%d", l.path, n)
+}
+
+// rotate shifts each rotated log one place older, dropping the oldest, and
+// makes the current log the newest rotated one
+func (l *EventLog) rotate() error {
+	for n := eventLogBackups - 1; n >= 1; n-- {
+		if err := os.Rename(l.backupPath(n), l.backupPath(n+1)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to rotate event log: %w", err)
+		}
+	}
+	if err := os.Rename(l.path, l.backupPath(1)); err != nil {
+		return fmt.Errorf("failed to rotate event log: %w", err)
+	}
+	return nil
+}
+
+// Read returns every event in the current and rotated logs, oldest first.
+// Lines that aren't events, such as one cut short by a crash, are skipped.
+func (l *EventLog) Read() ([]Event, error) {
+	var events []Event
+	for n := eventLogBackups; n >= 0; n-- {
+		path := l.path
+		if n > 0 {
+			path = l.backupPath(n)
+		}
+		read, err := readEvents(path)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, read...)
+	}
+	return events, nil
+}
+
+// readEvents reads the events in one log file, which may not exist
+func readEvents(path string) ([]Event, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open event log: %w", err)
+	}
+	defer file.Close()
+
+	var events []Event
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for scanner.Scan() {
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil || event.Operation == "" {
+			continue
+		}
+		events = append(events, event)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read event log: %w", err)
+	}
+	return events, nil
+}
@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEventLogAppendAndRead(t *testing.T) {
+	log := NewEventLog(filepath.Join(t.TempDir(), EventLogFile), DefaultEventLogMaxBytes)
+
+	events, err := log.Read()
+	if err != nil || len(events) != 0 {
+		t.Fatalf("Expected no events in a missing log, got %v, %v", events, err)
+	}
+
+	if err := log.Append(Event{Operation: EventCreate, ID: "mem_1", Name: "First"}); err != nil {
+		t.Fatalf("Failed to append event: %v", err)
+	}
+	if err := log.Append(Event{Operation: EventEmptyTrash, Count: 3}); err != nil {
+		t.Fatalf("Failed to append event: %v", err)
+	}
+	// A line cut short by a crash is skipped
+	file, err := os.OpenFile(log.Path(), os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("Failed to open event log: %v", err)
+	}
+	if _, err := file.WriteString(`{"time":"2025-`); err != nil {
+		t.Fatalf("Failed to write partial line: %v", err)
+	}
+	file.Close()
+
+	events, err = log.Read()
+	if err != nil {
+		t.Fatalf("Failed to read events: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %v", events)
+	}
+	if events[0].Operation != EventCreate || events[0].ID != "mem_1" || events[0].Name != "First" || events[0].Time.IsZero() {
+		t.Errorf("Expected a timestamped create of mem_1, got %+v", events[0])
+	}
+	if events[1].Operation != EventEmptyTrash || events[1].Count != 3 {
+		t.Errorf("Expected empty-trash of 3 memories, got %+v", events[1])
+	}
+}
+
+func TestEventLogRotation(t *testing.T) {
+	dir := t.TempDir()
+	// Small enough that every event rotates the log
+	log := NewEventLog(filepath.Join(dir, EventLogFile), 10)
+
+	ids := []string{"mem_1", "mem_2", "mem_3", "mem_4", "mem_5", "mem_6"}
+	for _, id := range ids {
+		if err := log.Append(Event{Operation: EventUpdate, ID: id}); err != nil {
+			t.Fatalf("Failed to append event: %v", err)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, EventLogFile+".4")); !os.IsNotExist(err) {
+		t.Errorf("Expected only %d rotated logs to be kept", eventLogBackups)
+	}
+	events, err := log.Read()
+	if err != nil {
+		t.Fatalf("Failed to read events: %v", err)
+	}
+	// The current log and three backups hold the last four events, in order
+	want := ids[len(ids)-eventLogBackups-1:]
+	if len(events) != len(want) {
+		t.Fatalf("Expected %d events, got %v", len(want), events)
+	}
+	for i, event := range events {
+		if event.ID != want[i] {
+			t.Errorf("Expected event %d to be for %s, got %s", i, want[i], event.ID)
+		}
+	}
+}
@@ -2,21 +2,52 @@ package storage
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/utils"
 )
 
+// DefaultMaxContentBytes is the default limit on the size of a memory's
+// content
+const DefaultMaxContentBytes = 1 << 20
+
+// maxIDAttempts bounds how many IDs Create tries before giving up on
+// finding one that isn't already taken
+const maxIDAttempts = 5
+
 // FileStorage implements file-based storage for memories
 type FileStorage struct {
 	storageDir  string
 	memoriesDir string
+	trashDir    string
 	indexFile   string
 	configFile  string
+	generateID  func() string
+
+	// maxContentBytes limits content size; 0 means unlimited
+	maxContentBytes int64
+	// compress gzips large content on write (see SetCompression)
+	compress bool
+
+	// batch is the open batch, if any (see BeginBatch)
+	batch *fileBatch
+
+	// retry bounds retries of transient write failures (see SetWriteRetry)
+	retry writeRetry
+	// logger receives warnings about operations that succeeded in part,
+	// such as a memory written without its index entry
+	logger *slog.Logger
+
+	// writeFileFunc and sleep are replaced in tests to simulate failures
+	writeFileFunc func(name string, data []byte, perm os.FileMode) error
+	sleep         func(time.Duration)
 }
 
 // Index represents the storage index for fast lookups
@@ -32,6 +63,57 @@ type IndexEntry struct {
 	Labels    map[string]string `json:"labels"`
 	CreatedAt time.Time         `json:"createdAt"`
 	UpdatedAt time.Time         `json:"updatedAt"`
+	ExpiresAt *time.Time        `json:"expiresAt,omitempty"`
+	// ContentHash lets duplicates be found without reading memory files
+	ContentHash string `json:"contentHash,omitempty"`
+	// AccessedAt and AccessCount record reads. They're kept here rather
+	// than in memory files so a read only rewrites the index.
+	AccessedAt  *time.Time `json:"accessedAt,omitempty"`
+	AccessCount int        `json:"accessCount,omitempty"`
+}
+
+// memory returns the memory an index entry describes, without its content
+func (entry IndexEntry) memory() Memory {
+	memory := Memory{
+		ID:        entry.ID,
+		Name:      entry.Name,
+		Labels:    entry.Labels,
+		CreatedAt: entry.CreatedAt,
+		UpdatedAt: entry.UpdatedAt,
+		ExpiresAt: entry.ExpiresAt,
+		Metadata:  make(map[string]any),
+	}
+	entry.applyAccess(&memory)
+	return memory
+}
+
+// applyAccess sets the memory's access metadata to the reads recorded in
+// the entry, copying the metadata map so callers' maps aren't modified.
+// Memories whose reads were recorded before the index held them keep the
+// access metadata in their files.
+func (entry IndexEntry) applyAccess(memory *Memory) {
+	if entry.AccessedAt == nil {
+		return
+	}
+	metadata := make(map[string]any, len(memory.Metadata)+2)
+	for k, v := range memory.Metadata {
+		metadata[k] = v
+	}
+	metadata[AccessedAtKey] = entry.AccessedAt.Format(time.RFC3339Nano)
+	if entry.AccessCount > 0 {
+		metadata[AccessCountKey] = entry.AccessCount
+	}
+	memory.Metadata = metadata
+}
+
+// entry returns the index entry for the memory with the given ID
+func (index Index) entry(id string) (IndexEntry, bool) {
+	for _, entry := range index.Memories {
+		if entry.ID == id {
+			return entry, true
+		}
+	}
+	return IndexEntry{}, false
 }
 
 // NewFileStorage creates a new file-based storage instance
@@ -47,8 +129,15 @@ func NewFileStorage(storageDir string) (*FileStorage, error) {
 	fs := &FileStorage{
 		storageDir:  storageDir,
 		memoriesDir: filepath.Join(storageDir, "memories"),
+		trashDir:    filepath.Join(storageDir, "trash"),
 		indexFile:   filepath.Join(storageDir, "index.json"),
 		configFile:  filepath.Join(storageDir, "config.json"),
+		generateID:  utils.GenerateID,
+
+		maxContentBytes: DefaultMaxContentBytes,
+		logger:          slog.Default(),
+		writeFileFunc:   os.WriteFile,
+		sleep:           time.Sleep,
 	}
 
 	if err := fs.initialize(); err != nil {
@@ -101,13 +190,15 @@ func (fs *FileStorage) initialize() error {
 // Create creates a new memory
 func (fs *FileStorage) Create(req CreateMemoryRequest) (*Memory, error) {
 	memory := &Memory{
-		ID:        utils.GenerateID(),
+		ID:        fs.generateID(),
 		Name:      req.Name,
 		Content:   req.Content,
 		Labels:    req.Labels,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 		Metadata:  req.Metadata,
+		Relations: req.Relations,
+		ExpiresAt: req.ExpiresAt,
 	}
 
 	// Apply defaults
@@ -118,42 +209,105 @@ func (fs *FileStorage) Create(req CreateMemoryRequest) (*Memory, error) {
 		memory.Labels = make(map[string]string)
 	}
 	if memory.Labels["type"] == "" {
-		memory.Labels["type"] = "manual"
+		memory.Labels["type"] = DefaultMemoryType
 	}
+	SetContentHash(memory)
 
 	// Validate
 	if err := fs.validateMemory(memory); err != nil {
 		return nil, fmt.Errorf("validation failed: %w", err)
 	}
 
-	// Write memory file
-	if err := fs.writeMemory(memory); err != nil {
-		return nil, fmt.Errorf("failed to write memory: %w", err)
+	if req.SkipDuplicate {
+		existing, err := fs.findByContentHash(MemoryContentHash(memory))
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			return existing, nil
+		}
+	}
+
+	// Write memory file, picking a new ID if the generated one is taken
+	for attempt := 1; ; attempt++ {
+		err := fs.writeNewMemory(memory)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("failed to write memory: %w", err)
+		}
+		if attempt == maxIDAttempts {
+			return nil, fmt.Errorf("failed to find a free memory ID after %d attempts", maxIDAttempts)
+		}
+		memory.ID = fs.generateID()
 	}
 
 	// Update index
 	if err := fs.updateIndex(memory, "create"); err != nil {
 		// Log warning but don't fail
-		fmt.Fprintf(os.Stderr, "Warning: failed to update index: %v\n", err)
+		fs.logger.Warn("failed to update index", "id", memory.ID, "error", err)
 	}
 
 	return memory, nil
 }
 
-// Get retrieves a memory by ID
+// Import stores a memory as-is, keeping its ID and timestamps. It is used
+// when migrating memories between storage backends.
+func (fs *FileStorage) Import(memory Memory) error {
+	if err := ValidateID(memory.ID); err != nil {
+		return err
+	}
+	if err := fs.validateMemory(&memory); err != nil {
+		return fmt.Errorf("validation failed: %w", err)
+	}
+
+	memoryFile := filepath.Join(fs.memoriesDir, memory.ID+".json")
+	if _, err := os.Stat(memoryFile); err == nil {
+		return fmt.Errorf("memory %s already exists", memory.ID)
+	}
+
+	if err := fs.writeMemory(&memory); err != nil {
+		return fmt.Errorf("failed to write memory: %w", err)
+	}
+	if err := fs.updateIndex(&memory, "create"); err != nil {
+		fs.logger.Warn("failed to update index", "id", memory.ID, "error", err)
+	}
+
+	return nil
+}
+
+// Get retrieves a memory by ID, along with the reads recorded in the index
 func (fs *FileStorage) Get(id string) (*Memory, error) {
+	memory, err := fs.readMemory(id)
+	if err != nil {
+		return nil, err
+	}
+	if index, err := fs.readIndex(); err == nil {
+		if entry, ok := index.entry(id); ok {
+			entry.applyAccess(memory)
+		}
+	}
+	return memory, nil
+}
+
+// readMemory reads a memory's file, without the reads recorded in the index
+func (fs *FileStorage) readMemory(id string) (*Memory, error) {
+	if err := ValidateID(id); err != nil {
+		return nil, err
+	}
 	memoryFile := filepath.Join(fs.memoriesDir, id+".json")
 
 	data, err := os.ReadFile(memoryFile)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return nil, fmt.Errorf("memory not found: %s", id)
+			return nil, fmt.Errorf("%w: %s", ErrMemoryNotFound, id)
 		}
 		return nil, fmt.Errorf("failed to read memory file: %w", err)
 	}
 
-	var memory Memory
-	if err := json.Unmarshal(data, &memory); err != nil {
+	memory, err := unmarshalMemory(data)
+	if err != nil {
 		return nil, fmt.Errorf("failed to unmarshal memory: %w", err)
 	}
 
@@ -162,21 +316,16 @@ func (fs *FileStorage) Get(id string) (*Memory, error) {
 
 // Update updates an existing memory
 func (fs *FileStorage) Update(req UpdateMemoryRequest) (*Memory, error) {
-	existing, err := fs.Get(req.ID)
+	existing, err := fs.readMemory(req.ID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get existing memory: %w", err)
 	}
-	if existing == nil {
-		return nil, fmt.Errorf("memory not found: %s", req.ID)
-	}
 
 	// Update fields if provided
 	if req.Name != "" {
 		existing.Name = req.Name
 	}
-	if req.Content != "" {
-		existing.Content = req.Content
-	}
+	ApplyContent(existing, req)
 	if req.Labels != nil {
 		existing.Labels = req.Labels
 	}
@@ -188,10 +337,14 @@ func (fs *FileStorage) Update(req UpdateMemoryRequest) (*Memory, error) {
 			existing.Metadata[k] = v
 		}
 	}
+	if req.Relations != nil {
+		existing.Relations = req.Relations
+	}
+	ApplyExpiry(existing, req.ExpiresAt)
 	existing.UpdatedAt = time.Now()
 
 	// Validate
-	if err := fs.validateMemory(existing); err != nil {
+	if err := fs.validateMemory(UpdateValidationTarget(existing, req)); err != nil {
 		return nil, fmt.Errorf("validation failed: %w", err)
 	}
 
@@ -202,27 +355,51 @@ func (fs *FileStorage) Update(req UpdateMemoryRequest) (*Memory, error) {
 
 	// Update index
 	if err := fs.updateIndex(existing, "update"); err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: failed to update index: %v\n", err)
+		fs.logger.Warn("failed to update index", "id", existing.ID, "error", err)
 	}
 
 	return existing, nil
 }
 
-// Delete removes a memory by ID
+// Touch sets the memory's UpdatedAt to the given time, leaving its content,
+// labels and metadata as they are
+func (fs *FileStorage) Touch(id string, at time.Time) error {
+	memory, err := fs.readMemory(id)
+	if err != nil {
+		return err
+	}
+	memory.UpdatedAt = at
+	if err := fs.writeMemory(memory); err != nil {
+		return fmt.Errorf("failed to write memory: %w", err)
+	}
+	if err := fs.updateIndex(memory, "update"); err != nil {
+		fs.logger.Warn("failed to update index", "id", memory.ID, "error", err)
+	}
+	return nil
+}
+
+// Delete permanently removes a memory by ID. Use Trash for a recoverable
+// delete.
 func (fs *FileStorage) Delete(id string) error {
+	if err := ValidateID(id); err != nil {
+		return err
+	}
 	memoryFile := filepath.Join(fs.memoriesDir, id+".json")
 
 	if _, err := os.Stat(memoryFile); os.IsNotExist(err) {
-		return fmt.Errorf("memory not found: %s", id)
+		return fmt.Errorf("%w: %s", ErrMemoryNotFound, id)
 	}
 
+	if err := fs.touch(memoryFile); err != nil {
+		return err
+	}
 	if err := os.Remove(memoryFile); err != nil {
 		return fmt.Errorf("failed to delete memory file: %w", err)
 	}
 
 	// Update index
 	if err := fs.updateIndex(&Memory{ID: id}, "delete"); err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: failed to update index: %v\n", err)
+		fs.logger.Warn("failed to update index", "id", id, "error", err)
 	}
 
 	return nil
@@ -231,18 +408,20 @@ func (fs *FileStorage) Delete(id string) error {
 // Search searches for memories based on the given criteria
 func (fs *FileStorage) Search(req SearchRequest) (*SearchResponse, error) {
 	// Set defaults for performance options
-	if !req.UseIndex && req.Query == "" {
+	hasQuery := len(req.TextQueries()) > 0
+	if !req.UseIndex && !hasQuery {
 		req.UseIndex = true // Use index for label-only searches
 	}
-	if req.Query != "" {
+	if hasQuery {
 		req.IncludeContent = true // Need content for text search
 	}
 
-	if req.UseIndex && req.Query == "" {
+	// The index doesn't hold metadata, so metadata queries load memories
+	if req.UseIndex && !hasQuery && len(req.MetadataSelector) == 0 {
 		return fs.searchFromIndex(req)
 	}
 
-	// Fallback to traditional search for text queries
+	// Fallback to traditional search for text and metadata queries
 	return fs.searchFromMemories(req)
 }
 
@@ -261,34 +440,34 @@ func (fs *FileStorage) searchFromIndex(req SearchRequest) (*SearchResponse, erro
 		}
 	}
 
-	// Apply limit to index entries first
-	if req.Limit > 0 && len(filtered) > req.Limit {
-		filtered = filtered[:req.Limit]
+	// Apply limit to index entries first, unless the results must be sorted
+	// before they can be cut
+	if req.SortBy == "" {
+		filtered = ApplyLimit(filtered, req.Limit)
 	}
 
 	// Convert to Memory objects
 	memories := make([]Memory, 0, len(filtered))
 	for _, entry := range filtered {
 		if req.IncludeContent {
-			memory, err := fs.Get(entry.ID)
+			memory, err := fs.entryMemory(entry)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "Warning: skipping memory %s: %v\n", entry.ID, err)
+				fs.logger.Warn("skipping memory", "id", entry.ID, "error", err)
 				continue
 			}
 			memories = append(memories, *memory)
 		} else {
-			memories = append(memories, Memory{
-				ID:        entry.ID,
-				Name:      entry.Name,
-				Labels:    entry.Labels,
-				CreatedAt: entry.CreatedAt,
-				UpdatedAt: entry.UpdatedAt,
-				Content:   "",
-				Metadata:  make(map[string]any),
-			})
+			memories = append(memories, entry.memory())
 		}
 	}
 
+	if req.SortBy != "" {
+		if err := SortMemories(memories, req.SortBy, req.SortOrder); err != nil {
+			return nil, err
+		}
+		memories = ApplyLimit(memories, req.Limit)
+	}
+
 	return &SearchResponse{
 		Memories: memories,
 		Total:    len(index.Memories),
@@ -306,12 +485,12 @@ func (fs *FileStorage) searchFromMemories(req SearchRequest) (*SearchResponse, e
 	filtered := fs.applyFilters(memories, req)
 
 	// Apply sorting
-	fs.applySorting(filtered, req)
+	if err := fs.applySorting(filtered, req); err != nil {
+		return nil, err
+	}
 
 	// Apply limit
-	if req.Limit > 0 && len(filtered) > req.Limit {
-		filtered = filtered[:req.Limit]
-	}
+	filtered = ApplyLimit(filtered, req.Limit)
 
 	return &SearchResponse{
 		Memories: filtered,
@@ -329,6 +508,9 @@ func (fs *FileStorage) matchesIndexEntry(entry IndexEntry, req SearchRequest) bo
 			}
 		}
 	}
+	if hasAnyLabel(entry.Labels, req.ExcludeLabels) {
+		return false
+	}
 
 	// Note: Text queries require full content, so they're handled in searchFromMemories
 	return true
@@ -363,25 +545,17 @@ func (fs *FileStorage) listFromIndex(includeContent bool) ([]Memory, error) {
 	if !includeContent {
 		// Fast metadata-only listing
 		for _, entry := range index.Memories {
-			memories = append(memories, Memory{
-				ID:        entry.ID,
-				Name:      entry.Name,
-				Labels:    entry.Labels,
-				CreatedAt: entry.CreatedAt,
-				UpdatedAt: entry.UpdatedAt,
-				Content:   "", // Skip content for performance
-				Metadata:  make(map[string]any),
-			})
+			memories = append(memories, entry.memory())
 		}
 		return memories, nil
 	}
 
 	// Load full content when requested
 	for _, entry := range index.Memories {
-		memory, err := fs.Get(entry.ID)
+		memory, err := fs.entryMemory(entry)
 		if err != nil {
 			// Skip corrupted memories but continue
-			fmt.Fprintf(os.Stderr, "Warning: skipping memory %s: %v\n", entry.ID, err)
+			fs.logger.Warn("skipping memory", "id", entry.ID, "error", err)
 			continue
 		}
 		memories = append(memories, *memory)
@@ -399,24 +573,37 @@ func (fs *FileStorage) listFromFiles() ([]Memory, error) {
 
 	var memories []Memory
 	for _, file := range files {
-		data, err := os.ReadFile(file)
+		memory, err := readMemoryFile(file)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: skipping corrupted file %s: %v\n", file, err)
+			fs.logger.Warn("skipping corrupted file", "file", file, "error", err)
 			continue
 		}
-
-		var memory Memory
-		if err := json.Unmarshal(data, &memory); err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: skipping corrupted file %s: %v\n", file, err)
-			continue
-		}
-
 		memories = append(memories, memory)
 	}
 
 	return memories, nil
 }
 
+// entryMemory reads the memory an index entry describes, with its content
+// and the reads recorded in the entry
+func (fs *FileStorage) entryMemory(entry IndexEntry) (*Memory, error) {
+	memory, err := fs.readMemory(entry.ID)
+	if err != nil {
+		return nil, err
+	}
+	entry.applyAccess(memory)
+	return memory, nil
+}
+
+// readMemoryFile reads the memory stored in file
+func readMemoryFile(file string) (Memory, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return Memory{}, err
+	}
+	return unmarshalMemory(data)
+}
+
 // Health checks if the storage is accessible and healthy
 func (fs *FileStorage) Health() error {
 	// Check if storage directory is accessible
@@ -434,6 +621,11 @@ func (fs *FileStorage) Health() error {
 	return nil
 }
 
+// StorageDir returns the root storage directory
+func (fs *FileStorage) StorageDir() string {
+	return fs.storageDir
+}
+
 // GetStorageInfo returns information about the storage
 func (fs *FileStorage) GetStorageInfo() (*StorageInfo, error) {
 	files, err := filepath.Glob(filepath.Join(fs.memoriesDir, "*.json"))
@@ -441,89 +633,305 @@ func (fs *FileStorage) GetStorageInfo() (*StorageInfo, error) {
 		return nil, fmt.Errorf("failed to glob memory files: %w", err)
 	}
 
+	// Names come from the index so no memory file has to be read
+	names := make(map[string]string)
+	if index, err := fs.readIndex(); err == nil {
+		for _, entry := range index.Memories {
+			names[entry.ID] = entry.Name
+		}
+	}
+
 	var totalSize int64
+	sizes := make([]MemorySize, 0, len(files))
 	for _, file := range files {
-		if info, err := os.Stat(file); err == nil {
-			totalSize += info.Size()
+		info, err := os.Stat(file)
+		if err != nil {
+			continue
 		}
+		totalSize += info.Size()
+		id := strings.TrimSuffix(filepath.Base(file), ".json")
+		sizes = append(sizes, MemorySize{ID: id, Name: names[id], Size: info.Size()})
 	}
+	SortMemorySizes(sizes)
 
 	return &StorageInfo{
 		StorageDir:    fs.storageDir,
 		MemoriesCount: len(files),
 		TotalSize:     totalSize,
+		Memories:      sizes,
 	}, nil
 }
 
 // Helper methods
 
+// UpdateValidationTarget returns the part of an updated memory to validate.
+// Labels and content stored before their validation existed stay untouched
+// by updates that don't replace them, so they aren't checked.
+func UpdateValidationTarget(updated *Memory, req UpdateMemoryRequest) *Memory {
+	if req.Labels != nil && req.Content != "" {
+		return updated
+	}
+	check := *updated
+	if req.Labels == nil {
+		check.Labels = nil
+	}
+	if req.Content == "" {
+		check.Content = ""
+	}
+	return &check
+}
+
+// SetMaxContentBytes sets the limit on content size enforced on create
+// and update. A limit of 0 disables the check.
+func (fs *FileStorage) SetMaxContentBytes(limit int64) {
+	fs.maxContentBytes = limit
+}
+
+// SetLogger sets the logger that receives storage warnings. A nil logger
+// restores slog's default.
+func (fs *FileStorage) SetLogger(logger *slog.Logger) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	fs.logger = logger
+}
+
+// Logger returns the logger that receives storage warnings
+func (fs *FileStorage) Logger() *slog.Logger {
+	return fs.logger
+}
+
 func (fs *FileStorage) validateMemory(memory *Memory) error {
+	if err := ValidateMemory(memory); err != nil {
+		return err
+	}
+	return ValidateContentSize(memory.Content, fs.maxContentBytes)
+}
+
+// ValidateMemory checks a memory against the constraints every storage
+// backend enforces. Sealed names were checked before they were encrypted.
+func ValidateMemory(memory *Memory) error {
 	if memory.Name == "" {
 		return fmt.Errorf("memory name cannot be empty")
 	}
-	if len(memory.Name) > 200 {
-		return fmt.Errorf("memory name too long (max 200 characters)")
+	if len(memory.Name) > MaxNameLength && !IsSealed(memory.Name) {
+		return fmt.Errorf("memory name too long (max %d characters)", MaxNameLength)
 	}
-	if memory.Labels != nil {
-		for k, v := range memory.Labels {
-			if len(k) > 63 || len(v) > 63 {
-				return fmt.Errorf("label key/value too long (max 63 characters)")
-			}
+	if err := ValidateLabels(memory.Labels); err != nil {
+		return err
+	}
+	if err := ValidateContent(memory); err != nil {
+		return err
+	}
+	return ValidateRelations(memory.ID, memory.Relations)
+}
+
+// ValidateRelations checks that relations have a target and a type that is
+// valid as a label value, and that a memory doesn't link to itself. It
+// doesn't check that targets exist.
+func ValidateRelations(id string, relations []Relation) error {
+	for _, relation := range relations {
+		if relation.TargetID == "" {
+			return fmt.Errorf("relation target cannot be empty")
+		}
+		if relation.TargetID == id {
+			return fmt.Errorf("memory %s cannot be related to itself", id)
+		}
+		if relation.Type == "" || len(relation.Type) > 63 || !validLabelString(relation.Type, false) {
+			return fmt.Errorf("invalid relation type %q: only letters, digits, '-', '_' and '.' are allowed", relation.Type)
+		}
+	}
+	return nil
+}
+
+// ValidateLabels checks label keys and values against the characters that
+// survive the key1=value1,key2=value2 selector syntax. Keys may contain
+// letters, digits, '-', '_', '.' and '/'; values the same except '/'.
+func ValidateLabels(labels map[string]string) error {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		v := labels[k]
+		if len(k) > 63 || len(v) > 63 {
+			return fmt.Errorf("label %q: key/value too long (max 63 characters)", k)
+		}
+		if k == "" {
+			return fmt.Errorf("label key cannot be empty")
+		}
+		if !validLabelString(k, true) {
+			return fmt.Errorf("invalid label key %q: only letters, digits, '-', '_', '.' and '/' are allowed", k)
+		}
+		if !validLabelString(v, false) {
+			return fmt.Errorf("invalid value %q for label %q: only letters, digits, '-', '_' and '.' are allowed", v, k)
+		}
+	}
+	return nil
+}
+
+func validLabelString(s string, isKey bool) bool {
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.':
+		case r == '/' && isKey:
+		default:
+			return false
 		}
 	}
+	return true
+}
+
+// ValidateContentSize checks content against a size limit in bytes. A
+// limit of 0 disables the check. Sealed content was checked before it was
+// encrypted, so its ciphertext isn't held to the limit.
+func ValidateContentSize(content string, limit int64) error {
+	if limit > 0 && int64(len(content)) > limit && !IsSealed(content) {
+		return fmt.Errorf("content too large (%d bytes, max %d bytes)", len(content), limit)
+	}
 	return nil
 }
 
 func (fs *FileStorage) writeMemory(memory *Memory) error {
-	data, err := json.MarshalIndent(memory, "", "  ")
+	data, err := marshalMemory(memory, fs.compress)
 	if err != nil {
 		return fmt.Errorf("failed to marshal memory: %w", err)
 	}
 
 	memoryFile := filepath.Join(fs.memoriesDir, memory.ID+".json")
-	if err := os.WriteFile(memoryFile, data, 0644); err != nil {
+	if err := fs.touch(memoryFile); err != nil {
+		return err
+	}
+	if err := fs.writeFile(memoryFile, data); err != nil {
 		return fmt.Errorf("failed to write memory file: %w", err)
 	}
 
 	return nil
 }
 
+// writeNewMemory writes a memory file that must not already exist,
+// returning an error wrapping os.ErrExist if it does
+func (fs *FileStorage) writeNewMemory(memory *Memory) error {
+	data, err := marshalMemory(memory, fs.compress)
+	if err != nil {
+		return fmt.Errorf("failed to marshal memory: %w", err)
+	}
+
+	memoryFile := filepath.Join(fs.memoriesDir, memory.ID+".json")
+	if err := fs.touch(memoryFile); err != nil {
+		return err
+	}
+	return fs.withRetry(func() error {
+		file, err := os.OpenFile(memoryFile, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to create memory file: %w", err)
+		}
+		if _, err := file.Write(data); err != nil {
+			file.Close()
+			os.Remove(memoryFile)
+			return fmt.Errorf("failed to write memory file: %w", err)
+		}
+		if err := file.Close(); err != nil {
+			os.Remove(memoryFile)
+			return fmt.Errorf("failed to write memory file: %w", err)
+		}
+		return nil
+	})
+}
+
+// findByContentHash returns the memory whose recorded content hash matches,
+// or nil if there is none. It only consults the index.
+func (fs *FileStorage) findByContentHash(hash string) (*Memory, error) {
+	index, err := fs.readIndex()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index: %w", err)
+	}
+	for _, entry := range index.Memories {
+		if entry.ContentHash == hash {
+			return fs.entryMemory(entry)
+		}
+	}
+	return nil, nil
+}
+
 func (fs *FileStorage) applyFilters(memories []Memory, req SearchRequest) []Memory {
-	var filtered []Memory
+	return FilterMemories(memories, req)
+}
 
+// FilterMemories returns the memories matching the text queries, label
+// selector and metadata selector of req, less those with excluded labels.
+// Providers that cannot search server-side use it to filter locally.
+func FilterMemories(memories []Memory, req SearchRequest) []Memory {
+	var filtered []Memory
 	for _, memory := range memories {
-		// Text search
-		if req.Query != "" {
-			query := strings.ToLower(req.Query)
-			if !strings.Contains(strings.ToLower(memory.Name), query) &&
-				!strings.Contains(strings.ToLower(memory.Content), query) {
-				continue
-			}
+		if MatchesSearch(memory, req) {
+			filtered = append(filtered, memory)
 		}
+	}
+	return filtered
+}
 
-		// Label selector
-		if req.LabelSelector != nil {
-			match := true
-			for k, v := range req.LabelSelector {
-				if memory.Labels[k] != v {
-					match = false
-					break
-				}
-			}
-			if !match {
-				continue
-			}
+// MatchesSearch reports whether memory matches the text queries, label
+// selector and metadata selector of req, and has none of its excluded
+// labels
+func MatchesSearch(memory Memory, req SearchRequest) bool {
+	// Text search
+	if queries := req.TextQueries(); len(queries) > 0 && !matchesQueries(memory, queries, req.MatchAny) {
+		return false
+	}
+
+	// Label selector
+	for k, v := range req.LabelSelector {
+		if memory.Labels[k] != v {
+			return false
 		}
+	}
+	if hasAnyLabel(memory.Labels, req.ExcludeLabels) {
+		return false
+	}
 
-		filtered = append(filtered, memory)
+	return MatchesMetadata(memory.Metadata, req.MetadataSelector)
+}
+
+// hasAnyLabel reports whether labels has any of the key=value pairs in
+// selector
+func hasAnyLabel(labels, selector map[string]string) bool {
+	for k, v := range selector {
+		if value, ok := labels[k]; ok && value == v {
+			return true
+		}
 	}
+	return false
+}
 
-	return filtered
+// matchesQueries reports whether the memory's name or content contains all
+// of the queries, or any of them when matchAny is set, ignoring case
+func matchesQueries(memory Memory, queries []string, matchAny bool) bool {
+	name := strings.ToLower(memory.Name)
+	content := strings.ToLower(memory.Content)
+	for _, q := range queries {
+		q = strings.ToLower(q)
+		matched := strings.Contains(name, q) || strings.Contains(content, q)
+		if matched == matchAny {
+			return matched
+		}
+	}
+	return !matchAny
 }
 
-func (fs *FileStorage) applySorting(memories []Memory, req SearchRequest) {
-	// Simple sorting implementation
-	// TODO: Implement proper sorting based on req.SortBy and req.SortOrder
+func (fs *FileStorage) applySorting(memories []Memory, req SearchRequest) error {
+	// Text queries are ranked by relevance unless a sort key overrides it;
+	// the scores are still reported either way
+	if queries := req.TextQueries(); len(queries) > 0 {
+		RankByRelevance(memories, queries...)
+	}
+	if req.SortBy != "" {
+		return SortMemories(memories, req.SortBy, req.SortOrder)
+	}
+	return nil
 }
 
 func (fs *FileStorage) updateIndex(memory *Memory, operation string) error {
@@ -535,23 +943,38 @@ func (fs *FileStorage) updateIndex(memory *Memory, operation string) error {
 	switch operation {
 	case "create":
 		entry := IndexEntry{
-			ID:        memory.ID,
-			Name:      memory.Name,
-			Labels:    memory.Labels,
-			CreatedAt: memory.CreatedAt,
-			UpdatedAt: memory.UpdatedAt,
+			ID:          memory.ID,
+			Name:        memory.Name,
+			Labels:      memory.Labels,
+			CreatedAt:   memory.CreatedAt,
+			UpdatedAt:   memory.UpdatedAt,
+			ExpiresAt:   memory.ExpiresAt,
+			ContentHash: MemoryContentHash(memory),
+			AccessedAt:  accessedAt(memory),
+			AccessCount: memory.AccessCount(),
 		}
 		index.Memories = append(index.Memories, entry)
 	case "update":
 		for i, entry := range index.Memories {
 			if entry.ID == memory.ID {
-				index.Memories[i] = IndexEntry{
-					ID:        memory.ID,
-					Name:      memory.Name,
-					Labels:    memory.Labels,
-					CreatedAt: entry.CreatedAt, // Preserve original creation time
-					UpdatedAt: memory.UpdatedAt,
+				updated := IndexEntry{
+					ID:          memory.ID,
+					Name:        memory.Name,
+					Labels:      memory.Labels,
+					CreatedAt:   entry.CreatedAt, // Preserve original creation time
+					UpdatedAt:   memory.UpdatedAt,
+					ExpiresAt:   memory.ExpiresAt,
+					ContentHash: MemoryContentHash(memory),
+					AccessedAt:  entry.AccessedAt,
+					AccessCount: entry.AccessCount,
+				}
+				// Reads are recorded in the index; memory files only hold
+				// reads recorded before it did
+				if updated.AccessedAt == nil {
+					updated.AccessedAt = accessedAt(memory)
+					updated.AccessCount = memory.AccessCount()
 				}
+				index.Memories[i] = updated
 				break
 			}
 		}
@@ -569,6 +992,10 @@ func (fs *FileStorage) updateIndex(memory *Memory, operation string) error {
 }
 
 func (fs *FileStorage) readIndex() (Index, error) {
+	if fs.batch != nil {
+		return fs.batch.index, nil
+	}
+
 	var index Index
 
 	data, err := os.ReadFile(fs.indexFile)
@@ -580,11 +1007,17 @@ func (fs *FileStorage) readIndex() (Index, error) {
 	return index, err
 }
 
+// writeIndex writes the index, or during a batch holds it until Commit
 func (fs *FileStorage) writeIndex(index Index) error {
+	if fs.batch != nil {
+		fs.batch.index = index
+		return nil
+	}
+
 	data, err := json.MarshalIndent(index, "", "  ")
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(fs.indexFile, data, 0644)
+	return fs.writeFile(fs.indexFile, data)
 }
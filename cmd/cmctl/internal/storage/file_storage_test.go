@@ -1,8 +1,15 @@
 package storage
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestNewFileStorage(t *testing.T) {
@@ -62,6 +69,100 @@ func TestCreateMemory(t *testing.T) {
 	}
 }
 
+func TestCreateMemoryIDCollision(t *testing.T) {
+	fs, err := NewFileStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create FileStorage: %v", err)
+	}
+
+	existing, err := fs.Create(CreateMemoryRequest{Name: "Existing", Content: "keep me"})
+	if err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+
+	ids := []string{existing.ID, "mem_fresh"}
+	fs.generateID = func() string {
+		id := ids[0]
+		ids = ids[1:]
+		return id
+	}
+
+	memory, err := fs.Create(CreateMemoryRequest{Name: "New", Content: "new content"})
+	if err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+	if memory.ID != "mem_fresh" {
+		t.Errorf("Expected a regenerated ID mem_fresh, got %s", memory.ID)
+	}
+
+	kept, err := fs.Get(existing.ID)
+	if err != nil {
+		t.Fatalf("Failed to get existing memory: %v", err)
+	}
+	if kept.Content != "keep me" {
+		t.Errorf("Expected existing memory to be untouched, got content %q", kept.Content)
+	}
+
+	fs.generateID = func() string { return existing.ID }
+	if _, err := fs.Create(CreateMemoryRequest{Content: "never written"}); err == nil {
+		t.Error("Expected an error when no free ID can be found")
+	}
+	memories, err := fs.List()
+	if err != nil {
+		t.Fatalf("Failed to list memories: %v", err)
+	}
+	if len(memories) != 2 {
+		t.Errorf("Expected 2 memories, got %d", len(memories))
+	}
+}
+
+func TestContentSizeLimit(t *testing.T) {
+	fs, err := NewFileStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create FileStorage: %v", err)
+	}
+	if fs.maxContentBytes != DefaultMaxContentBytes {
+		t.Errorf("Expected default limit %d, got %d", DefaultMaxContentBytes, fs.maxContentBytes)
+	}
+	fs.SetMaxContentBytes(10)
+
+	tests := []struct {
+		name    string
+		content string
+		wantErr bool
+	}{
+		{name: "under limit", content: strings.Repeat("a", 9)},
+		{name: "at limit", content: strings.Repeat("a", 10)},
+		{name: "over limit", content: strings.Repeat("a", 11), wantErr: true},
+		{name: "multibyte over limit", content: strings.Repeat("é", 6), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := fs.Create(CreateMemoryRequest{Content: tt.content})
+			if tt.wantErr && (err == nil || !strings.Contains(err.Error(), "content too large")) {
+				t.Errorf("Expected content too large error, got %v", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+		})
+	}
+
+	memory, err := fs.Create(CreateMemoryRequest{Content: "short"})
+	if err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+	if _, err := fs.Update(UpdateMemoryRequest{ID: memory.ID, Content: strings.Repeat("a", 11)}); err == nil {
+		t.Error("Expected update over the limit to fail")
+	}
+
+	fs.SetMaxContentBytes(0)
+	if _, err := fs.Create(CreateMemoryRequest{Content: strings.Repeat("a", 1000)}); err != nil {
+		t.Errorf("Expected no limit with 0, got %v", err)
+	}
+}
+
 func TestGetMemory(t *testing.T) {
 	tempDir := t.TempDir()
 	fs, err := NewFileStorage(tempDir)
@@ -102,9 +203,22 @@ func TestGetNonExistentMemory(t *testing.T) {
 		t.Fatalf("Failed to create FileStorage: %v", err)
 	}
 
-	_, err = fs.Get("nonexistent")
-	if err == nil {
-		t.Fatal("Expected error for nonexistent memory")
+	memory, err := fs.Get("nonexistent")
+	if !errors.Is(err, ErrMemoryNotFound) {
+		t.Fatalf("Expected ErrMemoryNotFound, got %v", err)
+	}
+	if memory != nil {
+		t.Errorf("Expected nil memory, got %+v", memory)
+	}
+
+	if err := fs.Delete("nonexistent"); !errors.Is(err, ErrMemoryNotFound) {
+		t.Errorf("Expected ErrMemoryNotFound from Delete, got %v", err)
+	}
+	if err := fs.Trash("nonexistent"); !errors.Is(err, ErrMemoryNotFound) {
+		t.Errorf("Expected ErrMemoryNotFound from Trash, got %v", err)
+	}
+	if _, err := fs.Restore("nonexistent"); !errors.Is(err, ErrMemoryNotFound) {
+		t.Errorf("Expected ErrMemoryNotFound from Restore, got %v", err)
 	}
 }
 
@@ -206,6 +320,256 @@ func TestSearchMemories(t *testing.T) {
 	}
 }
 
+func TestSearchRanksByRelevance(t *testing.T) {
+	fs, err := NewFileStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create FileStorage: %v", err)
+	}
+
+	// Created in reverse order of relevance so ranking has to reorder them
+	requests := []CreateMemoryRequest{
+		{Name: "Body only", Content: "we talked about kubernetes, kubernetes and more kubernetes"},
+		{Name: "Labelled", Content: "cluster notes on kubernetes", Labels: map[string]string{"topic": "kubernetes"}},
+		{Name: "Kubernetes upgrade", Content: "notes"},
+	}
+	for _, req := range requests {
+		if _, err := fs.Create(req); err != nil {
+			t.Fatalf("Failed to create memory: %v", err)
+		}
+	}
+
+	response, err := fs.Search(SearchRequest{Query: "kubernetes"})
+	if err != nil {
+		t.Fatalf("Failed to search memories: %v", err)
+	}
+
+	want := []string{"Kubernetes upgrade", "Labelled", "Body only"}
+	if len(response.Memories) != len(want) {
+		t.Fatalf("Expected %d results, got %d", len(want), len(response.Memories))
+	}
+	for i, name := range want {
+		if response.Memories[i].Name != name {
+			t.Errorf("Expected %q at position %d, got %q", name, i, response.Memories[i].Name)
+		}
+		if response.Memories[i].Score <= 0 {
+			t.Errorf("Expected a positive score for %q", response.Memories[i].Name)
+		}
+	}
+}
+
+func TestSortMemories(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	memories := []Memory{
+		{ID: "b", Name: "beta", Content: "12345", CreatedAt: base.Add(2 * time.Hour), UpdatedAt: base.Add(3 * time.Hour)},
+		{ID: "a", Name: "Alpha", Content: "123", CreatedAt: base.Add(1 * time.Hour), UpdatedAt: base.Add(4 * time.Hour)},
+		{ID: "c", Name: "gamma", Content: "1", CreatedAt: base.Add(3 * time.Hour), UpdatedAt: base.Add(1 * time.Hour)},
+	}
+
+	tests := []struct {
+		sortBy string
+		order  string
+		want   []string
+	}{
+		{SortByName, "", []string{"a", "b", "c"}},
+		{SortByName, SortDescending, []string{"c", "b", "a"}},
+		{SortByCreated, "", []string{"c", "b", "a"}},
+		{SortByCreated, SortAscending, []string{"a", "b", "c"}},
+		{SortByUpdated, "", []string{"a", "b", "c"}},
+		{SortByUpdated, SortAscending, []string{"c", "b", "a"}},
+		{SortBySize, "", []string{"b", "a", "c"}},
+		{SortBySize, SortAscending, []string{"c", "a", "b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sortBy+"/"+tt.order, func(t *testing.T) {
+			sorted := append([]Memory(nil), memories...)
+			if err := SortMemories(sorted, tt.sortBy, tt.order); err != nil {
+				t.Fatalf("Failed to sort memories: %v", err)
+			}
+			for i, id := range tt.want {
+				if sorted[i].ID != id {
+					t.Errorf("Expected %s at position %d, got %s", id, i, sorted[i].ID)
+				}
+			}
+		})
+	}
+
+	if err := SortMemories(memories, "colour", ""); err == nil {
+		t.Error("Expected an error for an unknown sort key")
+	}
+	if err := SortMemories(memories, SortByName, "sideways"); err == nil {
+		t.Error("Expected an error for an unknown sort order")
+	}
+}
+
+func TestSortMemoriesByLabel(t *testing.T) {
+	memories := []Memory{
+		{ID: "none", Labels: map[string]string{}},
+		{ID: "p10", Labels: map[string]string{"priority": "10"}},
+		{ID: "p2", Labels: map[string]string{"priority": "2"}},
+		{ID: "nil"},
+		{ID: "high", Labels: map[string]string{"priority": "high"}},
+		{ID: "p1", Labels: map[string]string{"priority": "1"}},
+	}
+
+	tests := []struct {
+		order string
+		want  []string
+	}{
+		// Numbers sort numerically, and memories without the label go last
+		// in either order
+		{"", []string{"p1", "p2", "p10", "high", "nil", "none"}},
+		{SortDescending, []string{"high", "p10", "p2", "p1", "none", "nil"}},
+	}
+
+	for _, tt := range tests {
+		t.Run("order "+tt.order, func(t *testing.T) {
+			sorted := append([]Memory(nil), memories...)
+			if err := SortMemories(sorted, "label:priority", tt.order); err != nil {
+				t.Fatalf("Failed to sort memories: %v", err)
+			}
+			var got []string
+			for _, memory := range sorted {
+				got = append(got, memory.ID)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	if err := SortMemories(memories, LabelSortPrefix, ""); err == nil {
+		t.Error("Expected an error for a label sort key without a label")
+	}
+	if !IsSortKey("label:priority") || IsSortKey("label:") || IsSortKey("colour") {
+		t.Error("Expected only named label sort keys to be valid")
+	}
+}
+
+func TestSearchSortBy(t *testing.T) {
+	fs, err := NewFileStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create FileStorage: %v", err)
+	}
+	for _, name := range []string{"charlie", "alpha", "bravo"} {
+		if _, err := fs.Create(CreateMemoryRequest{Name: name, Content: name, Labels: map[string]string{"type": "note"}}); err != nil {
+			t.Fatalf("Failed to create memory: %v", err)
+		}
+	}
+
+	// Both the index and the full-load paths must sort before the limit
+	for _, useIndex := range []bool{true, false} {
+		response, err := fs.Search(SearchRequest{
+			LabelSelector: map[string]string{"type": "note"},
+			SortBy:        SortByName,
+			Limit:         2,
+			UseIndex:      useIndex,
+		})
+		if err != nil {
+			t.Fatalf("Failed to search memories: %v", err)
+		}
+		if len(response.Memories) != 2 || response.Memories[0].Name != "alpha" || response.Memories[1].Name != "bravo" {
+			t.Errorf("Expected alpha, bravo with useIndex=%v, got %v", useIndex, response.Memories)
+		}
+	}
+}
+
+func TestSearchLimit(t *testing.T) {
+	fs, err := NewFileStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create FileStorage: %v", err)
+	}
+	for _, name := range []string{"charlie", "alpha", "bravo"} {
+		if _, err := fs.Create(CreateMemoryRequest{Name: name, Content: name, Labels: map[string]string{"type": "note"}}); err != nil {
+			t.Fatalf("Failed to create memory: %v", err)
+		}
+	}
+
+	// Zero and negative limits return everything, on every search path
+	tests := []struct {
+		limit int
+		want  int
+	}{
+		{limit: 0, want: 3},
+		{limit: -1, want: 3},
+		{limit: 2, want: 2},
+		{limit: 5, want: 3},
+	}
+	for _, tt := range tests {
+		for _, useIndex := range []bool{true, false} {
+			for _, sortBy := range []string{"", SortByName} {
+				response, err := fs.Search(SearchRequest{
+					LabelSelector: map[string]string{"type": "note"},
+					Limit:         tt.limit,
+					SortBy:        sortBy,
+					UseIndex:      useIndex,
+				})
+				if err != nil {
+					t.Fatalf("Failed to search memories: %v", err)
+				}
+				if len(response.Memories) != tt.want {
+					t.Errorf("Expected %d memories for limit %d (useIndex=%v, sortBy=%q), got %d", tt.want, tt.limit, useIndex, sortBy, len(response.Memories))
+				}
+			}
+		}
+	}
+}
+
+func TestMetadataValue(t *testing.T) {
+	metadata := map[string]any{
+		"source":     "cursor",
+		"dotted.key": "literal",
+		"summary":    map[string]any{"model": "gpt-4o", "tokens": float64(350)},
+		"files":      []any{"main.go", "go.mod"},
+		"imported":   true,
+	}
+
+	tests := []struct {
+		key    string
+		want   string
+		wantOK bool
+	}{
+		{"source", "cursor", true},
+		{"dotted.key", "literal", true},
+		{"summary.model", "gpt-4o", true},
+		{"summary.tokens", "350", true},
+		{"summary", `{"model":"gpt-4o","tokens":350}`, true},
+		{"files.1", "go.mod", true},
+		{"files.2", "", false},
+		{"imported", "true", true},
+		{"summary.missing", "", false},
+		{"source.nested", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			value, ok := MetadataValue(metadata, tt.key)
+			if ok != tt.wantOK {
+				t.Fatalf("Expected found=%v, got %v", tt.wantOK, ok)
+			}
+			if got := MetadataString(value); ok && got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestScoreMemory(t *testing.T) {
+	title := Memory{Name: "Auth flow", Content: "login"}
+	body := Memory{Name: "Notes", Content: strings.Repeat("auth ", 100)}
+	exact := Memory{Name: "auth", Content: ""}
+
+	if ScoreMemory(title, "auth") <= ScoreMemory(body, "auth") {
+		t.Error("Expected a title match to outrank a body-only match")
+	}
+	if ScoreMemory(exact, "auth") <= ScoreMemory(title, "auth") {
+		t.Error("Expected an exact name match to outrank a partial one")
+	}
+	if ScoreMemory(body, "") != 0 {
+		t.Error("Expected an empty query to score zero")
+	}
+}
+
 func TestMemoryLabels(t *testing.T) {
 	tempDir := t.TempDir()
 	fs, err := NewFileStorage(tempDir)
@@ -239,6 +603,64 @@ func TestMemoryLabels(t *testing.T) {
 	}
 }
 
+func TestLabelValidation(t *testing.T) {
+	fs, err := NewFileStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create FileStorage: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		labels  map[string]string
+		wantErr string
+	}{
+		{name: "valid", labels: map[string]string{"app.kubernetes.io/name": "cmctl", "tier": "v1.2_beta-3"}},
+		{name: "space in key", labels: map[string]string{"my project": "api"}, wantErr: `invalid label key "my project"`},
+		{name: "comma in key", labels: map[string]string{"a,b": "api"}, wantErr: `invalid label key "a,b"`},
+		{name: "equals in key", labels: map[string]string{"a=b": "api"}, wantErr: `invalid label key "a=b"`},
+		{name: "space in value", labels: map[string]string{"project": "my api"}, wantErr: `invalid value "my api" for label "project"`},
+		{name: "comma in value", labels: map[string]string{"languages": "go,python"}, wantErr: `invalid value "go,python" for label "languages"`},
+		{name: "equals in value", labels: map[string]string{"project": "a=b"}, wantErr: `invalid value "a=b" for label "project"`},
+		{name: "slash in value", labels: map[string]string{"path": "a/b"}, wantErr: `invalid value "a/b" for label "path"`},
+		{name: "empty key", labels: map[string]string{"": "api"}, wantErr: "label key cannot be empty"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := fs.Create(CreateMemoryRequest{Content: "content", Labels: tt.labels})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestUpdateKeepsUnvalidatedLabels(t *testing.T) {
+	fs, err := NewFileStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create FileStorage: %v", err)
+	}
+
+	// Simulate a memory written before label validation existed
+	legacy := Memory{ID: "mem_legacy", Name: "Old chat", Content: "old", Labels: map[string]string{"technologies": "go,python"}}
+	if err := fs.writeMemory(&legacy); err != nil {
+		t.Fatalf("Failed to write memory: %v", err)
+	}
+
+	if _, err := fs.Update(UpdateMemoryRequest{ID: legacy.ID, Content: "new"}); err != nil {
+		t.Errorf("Expected content update to leave existing labels alone, got %v", err)
+	}
+	if _, err := fs.Update(UpdateMemoryRequest{ID: legacy.ID, Labels: map[string]string{"technologies": "go,rust"}}); err == nil {
+		t.Error("Expected replacing labels with invalid ones to fail")
+	}
+}
+
 func TestHealth(t *testing.T) {
 	tempDir := t.TempDir()
 	fs, err := NewFileStorage(tempDir)
@@ -283,3 +705,153 @@ func TestStorageInfo(t *testing.T) {
 		t.Errorf("Expected storage location %s, got %s", tempDir, info.StorageDir)
 	}
 }
+
+func TestStorageInfoSizes(t *testing.T) {
+	info := &StorageInfo{Memories: []MemorySize{
+		{ID: "b", Size: 100}, {ID: "d", Size: 4000}, {ID: "a", Size: 100}, {ID: "c", Size: 20},
+	}}
+	SortMemorySizes(info.Memories)
+
+	var order []string
+	for _, memory := range info.Memories {
+		order = append(order, memory.ID)
+	}
+	if strings.Join(order, ",") != "d,a,b,c" {
+		t.Errorf("Expected largest first with ties by ID, got %v", order)
+	}
+	if got := info.AverageSize(); got != 1055 {
+		t.Errorf("Expected average 1055, got %d", got)
+	}
+	if got := info.MedianSize(); got != 100 {
+		t.Errorf("Expected median 100, got %d", got)
+	}
+
+	info.Memories = info.Memories[:3]
+	if got := info.MedianSize(); got != 100 {
+		t.Errorf("Expected median 100 of an odd count, got %d", got)
+	}
+	if got := (&StorageInfo{}).MedianSize(); got != 0 {
+		t.Errorf("Expected median 0 for an empty store, got %d", got)
+	}
+}
+
+func TestIndexWarningLogged(t *testing.T) {
+	fs, err := NewFileStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	var logs bytes.Buffer
+	fs.SetLogger(slog.New(slog.NewJSONHandler(&logs, nil)))
+	// Memory files are written directly on create; only the index goes
+	// through writeFileFunc
+	fs.writeFileFunc = func(name string, data []byte, perm os.FileMode) error {
+		return &os.PathError{Op: "write", Path: name, Err: os.ErrPermission}
+	}
+
+	memory, err := fs.Create(CreateMemoryRequest{Name: "Unindexed", Content: "content"})
+	if err != nil {
+		t.Fatalf("Expected create to succeed without the index, got %v", err)
+	}
+
+	var record struct {
+		Level string `json:"level"`
+		Msg   string `json:"msg"`
+		ID    string `json:"id"`
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(logs.Bytes(), &record); err != nil {
+		t.Fatalf("Expected one JSON log record, got %q: %v", logs.String(), err)
+	}
+	if record.Level != "WARN" || record.Msg != "failed to update index" || record.ID != memory.ID || record.Error == "" {
+		t.Errorf("Expected an index warning for %s, got %+v", memory.ID, record)
+	}
+}
+
+func TestRecordAccessLeavesMemoryFile(t *testing.T) {
+	fs, err := NewFileStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create FileStorage: %v", err)
+	}
+	created, err := fs.Create(CreateMemoryRequest{Name: "Read me", Content: "content"})
+	if err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+	memoryFile := filepath.Join(fs.memoriesDir, created.ID+".json")
+	before, err := os.ReadFile(memoryFile)
+	if err != nil {
+		t.Fatalf("Failed to read memory file: %v", err)
+	}
+	past := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(memoryFile, past, past); err != nil {
+		t.Fatalf("Failed to set memory file times: %v", err)
+	}
+
+	at := time.Now().Add(-time.Minute).Truncate(time.Second)
+	for range 3 {
+		if err := fs.RecordAccess(created.ID, at); err != nil {
+			t.Fatalf("Failed to record access: %v", err)
+		}
+	}
+
+	after, err := os.ReadFile(memoryFile)
+	if err != nil {
+		t.Fatalf("Failed to read memory file: %v", err)
+	}
+	if !bytes.Equal(before, after) {
+		t.Errorf("Expected reads to leave the memory file alone, got %s", after)
+	}
+	if info, err := os.Stat(memoryFile); err != nil || !info.ModTime().Equal(past) {
+		t.Errorf("Expected reads to leave the memory file's mtime alone, got %v", info.ModTime())
+	}
+
+	got, err := fs.Get(created.ID)
+	if err != nil {
+		t.Fatalf("Failed to get memory: %v", err)
+	}
+	if last, ok := got.LastAccessed(); !ok || !last.Equal(at) || got.AccessCount() != 3 {
+		t.Errorf("Expected 3 reads, the last at %v, got %d at %v", at, got.AccessCount(), last)
+	}
+
+	// Reads recorded in the index survive edits to the memory
+	if _, err := fs.Update(UpdateMemoryRequest{ID: created.ID, Content: "edited"}); err != nil {
+		t.Fatalf("Failed to update memory: %v", err)
+	}
+	result, err := fs.ListWithOptions(ListOptions{IncludeContent: true, UseIndex: true})
+	if err != nil {
+		t.Fatalf("Failed to list memories: %v", err)
+	}
+	if len(result) != 1 || result[0].AccessCount() != 3 || result[0].Content != "edited" {
+		t.Errorf("Expected the edited memory with 3 reads, got %+v", result)
+	}
+	edited, err := os.ReadFile(memoryFile)
+	if err != nil {
+		t.Fatalf("Failed to read memory file: %v", err)
+	}
+	if bytes.Contains(edited, []byte(AccessCountKey)) {
+		t.Errorf("Expected reads kept out of the memory file, got %s", edited)
+	}
+}
+
+func TestInvalidIDs(t *testing.T) {
+	fs, err := NewFileStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create FileStorage: %v", err)
+	}
+	for _, id := range []string{"", "../index", "..", `..\index`, "a/b", "mem_1\x00"} {
+		if _, err := fs.Get(id); !errors.Is(err, ErrInvalidID) {
+			t.Errorf("Get(%q): expected ErrInvalidID, got %v", id, err)
+		}
+		if _, err := fs.Update(UpdateMemoryRequest{ID: id, Content: "x"}); !errors.Is(err, ErrInvalidID) {
+			t.Errorf("Update(%q): expected ErrInvalidID, got %v", id, err)
+		}
+		if err := fs.Delete(id); !errors.Is(err, ErrInvalidID) {
+			t.Errorf("Delete(%q): expected ErrInvalidID, got %v", id, err)
+		}
+		if err := fs.Trash(id); !errors.Is(err, ErrInvalidID) {
+			t.Errorf("Trash(%q): expected ErrInvalidID, got %v", id, err)
+		}
+		if _, err := fs.Restore(id); !errors.Is(err, ErrInvalidID) {
+			t.Errorf("Restore(%q): expected ErrInvalidID, got %v", id, err)
+		}
+	}
+}
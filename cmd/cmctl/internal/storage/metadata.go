@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SummaryKey is the metadata key holding a generated summary of a memory's
+// content
+const SummaryKey = "summary"
+
+// MetadataValue looks up key in metadata. A key that isn't present as-is is
+// treated as a dotted path into nested objects and arrays, so "source.tool"
+// finds metadata["source"]["tool"] and "files.0" the first item of a list.
+func MetadataValue(metadata map[string]any, key string) (any, bool) {
+	if value, ok := metadata[key]; ok {
+		return value, true
+	}
+
+	var current any = metadata
+	for _, part := range strings.Split(key, ".") {
+		switch node := current.(type) {
+		case map[string]any:
+			value, ok := node[part]
+			if !ok {
+				return nil, false
+			}
+			current = value
+		case []any:
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, false
+			}
+			current = node[i]
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// MetadataString formats a metadata value for matching: strings as-is,
+// numbers and booleans in their usual form, and anything else as JSON
+func MetadataString(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case nil:
+		return ""
+	case bool, int, int64:
+		return fmt.Sprint(v)
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(data)
+	}
+}
+
+// MatchesMetadata reports whether every key in selector is present in
+// metadata with a value whose string form equals the selector value
+func MatchesMetadata(metadata map[string]any, selector map[string]string) bool {
+	for key, want := range selector {
+		value, ok := MetadataValue(metadata, key)
+		if !ok || MetadataString(value) != want {
+			return false
+		}
+	}
+	return true
+}
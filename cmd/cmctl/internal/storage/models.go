@@ -1,9 +1,30 @@
 package storage
 
 import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
 	"time"
 )
 
+// ErrMemoryNotFound is returned (wrapped) when a memory ID doesn't exist
+var ErrMemoryNotFound = errors.New("memory not found")
+
+// ErrInvalidID is returned (wrapped) for a memory ID that can't name a
+// memory file, such as one containing a path separator
+var ErrInvalidID = errors.New("invalid memory ID")
+
+// ValidateID checks that id can only name a file in the directory it's
+// looked up in. IDs reach storage from URLs and MCP arguments, so "../x"
+// mustn't reach outside the store.
+func ValidateID(id string) error {
+	if id == "" || strings.ContainsAny(id, "/\\\x00") || strings.Contains(id, "..") {
+		return fmt.Errorf("%w: %q", ErrInvalidID, id)
+	}
+	return nil
+}
+
 // Memory represents a stored memory with content and metadata
 type Memory struct {
 	ID        string            `json:"id"`
@@ -13,23 +34,107 @@ type Memory struct {
 	CreatedAt time.Time         `json:"createdAt"`
 	UpdatedAt time.Time         `json:"updatedAt"`
 	Metadata  map[string]any    `json:"metadata,omitempty"`
+	Relations []Relation        `json:"relations,omitempty"`
+	ExpiresAt *time.Time        `json:"expiresAt,omitempty"`
+	// Score is the relevance to a text query, set only on search results
+	Score float64 `json:"score,omitempty" yaml:"score,omitempty"`
+}
+
+// IsExpired reports whether the memory has an expiry at or before now
+func (m Memory) IsExpired(now time.Time) bool {
+	return m.ExpiresAt != nil && !m.ExpiresAt.After(now)
+}
+
+// DefaultMemoryType is the type label given to memories created without one
+const DefaultMemoryType = "manual"
+
+// PinnedLabel marks a memory as pinned when set to "true"
+const PinnedLabel = "pinned"
+
+// IsPinned reports whether the memory is pinned
+func (m Memory) IsPinned() bool {
+	return m.Labels[PinnedLabel] == "true"
+}
+
+// DefaultRelationType is used when a link is created without a type
+const DefaultRelationType = "relates-to"
+
+// Relation links a memory to another memory
+type Relation struct {
+	Type     string `json:"type"`
+	TargetID string `json:"targetId"`
 }
 
 // CreateMemoryRequest represents a request to create a new memory
 type CreateMemoryRequest struct {
-	Name     string            `json:"name,omitempty"`
-	Content  string            `json:"content"`
-	Labels   map[string]string `json:"labels,omitempty"`
-	Metadata map[string]any    `json:"metadata,omitempty"`
+	Name      string            `json:"name,omitempty"`
+	Content   string            `json:"content"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	Metadata  map[string]any    `json:"metadata,omitempty"`
+	Relations []Relation        `json:"relations,omitempty"`
+	ExpiresAt *time.Time        `json:"expiresAt,omitempty"`
+	// SkipDuplicate returns an existing memory with identical content
+	// instead of creating a new one
+	SkipDuplicate bool `json:"skipDuplicate,omitempty"`
 }
 
-// UpdateMemoryRequest represents a request to update an existing memory
+// UpdateMemoryRequest represents a request to update an existing memory.
+// Relations replaces the memory's relations when non-nil; pass an empty
+// slice to remove them all. ExpiresAt sets the expiry when non-nil; pass a
+// zero time to remove it. With Append, Content is added to the end of the
+// existing content after Separator instead of replacing it.
 type UpdateMemoryRequest struct {
-	ID       string            `json:"id"`
-	Name     string            `json:"name,omitempty"`
-	Content  string            `json:"content,omitempty"`
-	Labels   map[string]string `json:"labels,omitempty"`
-	Metadata map[string]any    `json:"metadata,omitempty"`
+	ID        string            `json:"id"`
+	Name      string            `json:"name,omitempty"`
+	Content   string            `json:"content,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	Metadata  map[string]any    `json:"metadata,omitempty"`
+	Relations []Relation        `json:"relations,omitempty"`
+	ExpiresAt *time.Time        `json:"expiresAt,omitempty"`
+	Append    bool              `json:"append,omitempty"`
+	Separator string            `json:"separator,omitempty"`
+}
+
+// DefaultAppendSeparator separates appended content from what came before
+const DefaultAppendSeparator = "\n\n"
+
+// AppendContent adds addition to the end of content, inserting separator
+// between them unless content is empty or already ends with it
+func AppendContent(content, addition, separator string) string {
+	if content == "" {
+		return addition
+	}
+	if strings.HasSuffix(content, separator) {
+		return content + addition
+	}
+	return content + separator + addition
+}
+
+// ApplyContent applies an update request's content to a memory, appending
+// or replacing it, and reports whether the content changed
+func ApplyContent(memory *Memory, req UpdateMemoryRequest) bool {
+	if req.Content == "" {
+		return false
+	}
+	if req.Append {
+		memory.Content = AppendContent(memory.Content, req.Content, req.Separator)
+	} else {
+		memory.Content = req.Content
+	}
+	SetContentHash(memory)
+	return true
+}
+
+// ApplyExpiry applies an update request's ExpiresAt to a memory
+func ApplyExpiry(memory *Memory, expiresAt *time.Time) {
+	if expiresAt == nil {
+		return
+	}
+	if expiresAt.IsZero() {
+		memory.ExpiresAt = nil
+		return
+	}
+	memory.ExpiresAt = expiresAt
 }
 
 // ListOptions controls how memories are loaded during list operations
@@ -40,16 +145,47 @@ type ListOptions struct {
 
 // SearchRequest represents a search query for memories
 type SearchRequest struct {
-	Query         string            `json:"query,omitempty"`
+	Query string `json:"query,omitempty"`
+	// Queries are further text queries. A memory must match Query and all of
+	// them, or with MatchAny any one of them.
+	Queries       []string          `json:"queries,omitempty"`
+	MatchAny      bool              `json:"matchAny,omitempty"`
 	LabelSelector map[string]string `json:"labelSelector,omitempty"`
-	Limit         int               `json:"limit,omitempty"`
-	SortBy        string            `json:"sortBy,omitempty"`
-	SortOrder     string            `json:"sortOrder,omitempty"`
+	// ExcludeLabels removes memories having any of these label values from
+	// the LabelSelector matches
+	ExcludeLabels map[string]string `json:"excludeLabels,omitempty"`
+	// MetadataSelector matches metadata values by key or dotted path
+	MetadataSelector map[string]string `json:"metadataSelector,omitempty"`
+	// Limit caps the number of memories returned. Zero or less means no
+	// limit.
+	Limit     int    `json:"limit,omitempty"`
+	SortBy    string `json:"sortBy,omitempty"`
+	SortOrder string `json:"sortOrder,omitempty"`
 	// Performance options
 	UseIndex       bool `json:"useIndex,omitempty"`
 	IncludeContent bool `json:"includeContent,omitempty"`
 }
 
+// TextQueries returns the request's non-empty text queries
+func (r SearchRequest) TextQueries() []string {
+	var queries []string
+	for _, q := range append([]string{r.Query}, r.Queries...) {
+		if q != "" {
+			queries = append(queries, q)
+		}
+	}
+	return queries
+}
+
+// ApplyLimit returns the first limit items, or all of them when limit is
+// zero or less, as SearchRequest.Limit is interpreted
+func ApplyLimit[T any](items []T, limit int) []T {
+	if limit > 0 && len(items) > limit {
+		return items[:limit]
+	}
+	return items
+}
+
 // SearchResponse represents the result of a search operation
 type SearchResponse struct {
 	Memories []Memory `json:"memories"`
@@ -61,4 +197,57 @@ type StorageInfo struct {
 	StorageDir    string `json:"storageDir"`
 	MemoriesCount int    `json:"memoriesCount"`
 	TotalSize     int64  `json:"totalSize"`
+	// Memories holds the stored size of each memory, largest first
+	Memories []MemorySize `json:"memories,omitempty"`
+}
+
+// MemorySize is the stored size of one memory in bytes
+type MemorySize struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+}
+
+// SortMemorySizes orders sizes largest first, breaking ties by ID
+func SortMemorySizes(sizes []MemorySize) {
+	sort.Slice(sizes, func(i, j int) bool {
+		if sizes[i].Size != sizes[j].Size {
+			return sizes[i].Size > sizes[j].Size
+		}
+		return sizes[i].ID < sizes[j].ID
+	})
+}
+
+// Largest returns the n largest memories, or all of them if there are
+// fewer than n
+func (info *StorageInfo) Largest(n int) []MemorySize {
+	if n < len(info.Memories) {
+		return info.Memories[:n]
+	}
+	return info.Memories
+}
+
+// AverageSize returns the mean memory size, or 0 for an empty store
+func (info *StorageInfo) AverageSize() int64 {
+	if len(info.Memories) == 0 {
+		return 0
+	}
+	var total int64
+	for _, memory := range info.Memories {
+		total += memory.Size
+	}
+	return total / int64(len(info.Memories))
+}
+
+// MedianSize returns the median memory size, or 0 for an empty store
+func (info *StorageInfo) MedianSize() int64 {
+	n := len(info.Memories)
+	if n == 0 {
+		return 0
+	}
+	// Memories is sorted largest first
+	if n%2 == 1 {
+		return info.Memories[n/2].Size
+	}
+	return (info.Memories[n/2-1].Size + info.Memories[n/2].Size) / 2
 }
@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"sort"
+	"strings"
+)
+
+// Relevance weights. A name match always outranks a label match, and a
+// label match always outranks any number of content matches, because the
+// content score saturates below contentWeight.
+const (
+	nameWeight    = 10.0
+	exactBonus    = 5.0
+	labelWeight   = 5.0
+	contentWeight = 4.0
+)
+
+// ScoreMemory returns the relevance of a memory to a text query: name
+// matches score highest, then label keys or values, then content, which
+// grows with the number of occurrences
+func ScoreMemory(memory Memory, query string) float64 {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return 0
+	}
+
+	var score float64
+	name := strings.ToLower(memory.Name)
+	if strings.Contains(name, query) {
+		score += nameWeight
+		if name == query {
+			score += exactBonus
+		}
+	}
+
+	for k, v := range memory.Labels {
+		if strings.Contains(strings.ToLower(k), query) || strings.Contains(strings.ToLower(v), query) {
+			score += labelWeight
+			break
+		}
+	}
+
+	if n := strings.Count(strings.ToLower(memory.Content), query); n > 0 {
+		score += contentWeight * float64(n) / float64(n+1)
+	}
+	return score
+}
+
+// RankByRelevance sets each memory's Score, summed over the queries, and
+// sorts the memories by descending score, keeping the existing order for ties
+func RankByRelevance(memories []Memory, queries ...string) {
+	for i := range memories {
+		memories[i].Score = 0
+		for _, query := range queries {
+			memories[i].Score += ScoreMemory(memories[i], query)
+		}
+	}
+	sort.SliceStable(memories, func(i, j int) bool {
+		return memories[i].Score > memories[j].Score
+	})
+}
@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"errors"
+	"syscall"
+	"time"
+)
+
+// Backoff between write attempts doubles from initialRetryDelay up to
+// maxRetryDelay
+const (
+	initialRetryDelay = 50 * time.Millisecond
+	maxRetryDelay     = 2 * time.Second
+)
+
+// writeRetry bounds how often and for how long failed writes are retried
+type writeRetry struct {
+	// attempts is the number of retries after the first write; 0 disables
+	// retrying
+	attempts int
+	// timeout limits the total time spent retrying; 0 means no limit
+	timeout time.Duration
+}
+
+// SetWriteRetry makes writes that fail with a transient error, such as
+// EAGAIN or EBUSY on a network filesystem, retry up to attempts times with
+// exponential backoff, giving up once timeout has passed. Permanent errors
+// are returned at once.
+func (fs *FileStorage) SetWriteRetry(attempts int, timeout time.Duration) {
+	fs.retry = writeRetry{attempts: max(attempts, 0), timeout: max(timeout, 0)}
+}
+
+// isTransientError reports whether err is worth retrying: the filesystem
+// was temporarily busy or the call was interrupted
+func isTransientError(err error) bool {
+	return errors.Is(err, syscall.EAGAIN) ||
+		errors.Is(err, syscall.EBUSY) ||
+		errors.Is(err, syscall.EINTR) ||
+		errors.Is(err, syscall.ETIMEDOUT)
+}
+
+// withRetry runs write, retrying transient failures with backoff
+func (fs *FileStorage) withRetry(write func() error) error {
+	var deadline time.Time
+	if fs.retry.timeout > 0 {
+		deadline = time.Now().Add(fs.retry.timeout)
+	}
+
+	delay := initialRetryDelay
+	for attempt := 0; ; attempt++ {
+		err := write()
+		if err == nil || !isTransientError(err) || attempt >= fs.retry.attempts {
+			return err
+		}
+		if !deadline.IsZero() && time.Now().Add(delay).After(deadline) {
+			return err
+		}
+		fs.sleep(delay)
+		delay = min(delay*2, maxRetryDelay)
+	}
+}
+
+// writeFile writes data to path, retrying transient failures
+func (fs *FileStorage) writeFile(path string, data []byte) error {
+	return fs.withRetry(func() error { return fs.writeFileFunc(path, data, 0644) })
+}
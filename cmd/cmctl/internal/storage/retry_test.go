@@ -0,0 +1,128 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// failingWriter fails the first n writes with err, then writes normally
+func failingWriter(n int, err error) (func(string, []byte, os.FileMode) error, *int) {
+	calls := 0
+	return func(name string, data []byte, perm os.FileMode) error {
+		calls++
+		if calls <= n {
+			return &os.PathError{Op: "write", Path: name, Err: err}
+		}
+		return os.WriteFile(name, data, perm)
+	}, &calls
+}
+
+func TestWriteRetry(t *testing.T) {
+	tests := []struct {
+		name     string
+		failures int
+		err      error
+		attempts int
+		timeout  time.Duration
+		wantErr  bool
+		calls    int
+		sleeps   []time.Duration
+	}{
+		{name: "transient then success", failures: 2, err: syscall.EAGAIN, attempts: 3, calls: 3,
+			sleeps: []time.Duration{50 * time.Millisecond, 100 * time.Millisecond}},
+		{name: "busy then success", failures: 1, err: syscall.EBUSY, attempts: 3, calls: 2,
+			sleeps: []time.Duration{50 * time.Millisecond}},
+		{name: "retries exhausted", failures: 5, err: syscall.EAGAIN, attempts: 2, wantErr: true, calls: 3,
+			sleeps: []time.Duration{50 * time.Millisecond, 100 * time.Millisecond}},
+		{name: "permanent error", failures: 1, err: syscall.EACCES, attempts: 3, wantErr: true, calls: 1},
+		{name: "retry disabled", failures: 1, err: syscall.EAGAIN, attempts: 0, wantErr: true, calls: 1},
+		{name: "timeout", failures: 5, err: syscall.EAGAIN, attempts: 5, timeout: 10 * time.Millisecond, wantErr: true, calls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs, err := NewFileStorage(t.TempDir())
+			if err != nil {
+				t.Fatalf("Failed to create storage: %v", err)
+			}
+			memory, err := fs.Create(CreateMemoryRequest{Name: "Retry", Content: "before"})
+			if err != nil {
+				t.Fatalf("Failed to create memory: %v", err)
+			}
+
+			write, calls := failingWriter(tt.failures, tt.err)
+			var sleeps []time.Duration
+			fs.writeFileFunc = write
+			fs.sleep = func(d time.Duration) { sleeps = append(sleeps, d) }
+			fs.SetWriteRetry(tt.attempts, tt.timeout)
+
+			memory.Content = "after"
+			err = fs.writeMemory(memory)
+			if tt.wantErr {
+				if !errors.Is(err, tt.err) {
+					t.Errorf("Expected %v, got %v", tt.err, err)
+				}
+			} else if err != nil {
+				t.Fatalf("Expected the write to succeed, got %v", err)
+			}
+			if *calls != tt.calls {
+				t.Errorf("Expected %d write attempts, got %d", tt.calls, *calls)
+			}
+			if len(sleeps) != len(tt.sleeps) {
+				t.Fatalf("Expected backoff %v, got %v", tt.sleeps, sleeps)
+			}
+			for i := range sleeps {
+				if sleeps[i] != tt.sleeps[i] {
+					t.Errorf("Expected backoff %v, got %v", tt.sleeps, sleeps)
+					break
+				}
+			}
+
+			got, err := fs.Get(memory.ID)
+			if err != nil {
+				t.Fatalf("Failed to get memory: %v", err)
+			}
+			want := "after"
+			if tt.wantErr {
+				want = "before"
+			}
+			if got.Content != want {
+				t.Errorf("Expected content %q, got %q", want, got.Content)
+			}
+		})
+	}
+}
+
+func TestIndexWriteRetry(t *testing.T) {
+	fs, err := NewFileStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	write, calls := failingWriter(2, syscall.EBUSY)
+	fs.writeFileFunc = write
+	fs.sleep = func(time.Duration) {}
+	fs.SetWriteRetry(3, 0)
+
+	index, err := fs.readIndex()
+	if err != nil {
+		t.Fatalf("Failed to read index: %v", err)
+	}
+	index.Memories = append(index.Memories, IndexEntry{ID: "mem_retry", Name: "Retried"})
+	if err := fs.writeIndex(index); err != nil {
+		t.Fatalf("Expected the index write to be retried, got %v", err)
+	}
+	if *calls != 3 {
+		t.Errorf("Expected 3 index write attempts, got %d", *calls)
+	}
+
+	index, err = fs.readIndex()
+	if err != nil {
+		t.Fatalf("Failed to read index: %v", err)
+	}
+	if len(index.Memories) != 1 || index.Memories[0].ID != "mem_retry" {
+		t.Errorf("Expected the retried index to be written, got %+v", index.Memories)
+	}
+}
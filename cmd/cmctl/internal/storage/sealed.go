@@ -0,0 +1,13 @@
+package storage
+
+import "strings"
+
+// SealedPrefix prefixes values encrypted at rest. Their plaintext is
+// checked before it's sealed, so sealed values are exempt from the checks
+// on what they hold.
+const SealedPrefix = "cmenc:v1:"
+
+// IsSealed reports whether value is encrypted at rest
+func IsSealed(value string) bool {
+	return strings.HasPrefix(value, SealedPrefix)
+}
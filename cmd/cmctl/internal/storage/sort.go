@@ -0,0 +1,130 @@
+package storage
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Sort keys accepted by SortMemories and SearchRequest.SortBy
+const (
+	SortByName    = "name"
+	SortByCreated = "created"
+	SortByUpdated = "updated"
+	SortBySize    = "size"
+	// SortByAccessed sorts by when memories were last read; ones never read
+	// count as older than any that have been
+	SortByAccessed = "accessed"
+)
+
+// Sort orders accepted by SortMemories and SearchRequest.SortOrder
+const (
+	SortAscending  = "asc"
+	SortDescending = "desc"
+)
+
+// SortKeys lists the valid sort keys, besides label sort keys
+var SortKeys = []string{SortByName, SortByCreated, SortByUpdated, SortBySize, SortByAccessed}
+
+// LabelSortPrefix starts a sort key that sorts by the value of a label, as
+// in "label:priority"
+const LabelSortPrefix = "label:"
+
+// labelSortKey returns the label a sort key sorts by, if it's a label key
+func labelSortKey(sortBy string) (string, bool) {
+	key, ok := strings.CutPrefix(sortBy, LabelSortPrefix)
+	return key, ok && key != ""
+}
+
+// IsSortKey reports whether sortBy is a valid sort key
+func IsSortKey(sortBy string) bool {
+	if _, ok := labelSortKey(sortBy); ok {
+		return true
+	}
+	return slices.Contains(SortKeys, sortBy)
+}
+
+// DefaultSortOrder returns the natural order for a sort key: names and
+// label values A to Z, and timestamps and sizes newest or largest first
+func DefaultSortOrder(sortBy string) string {
+	if _, ok := labelSortKey(sortBy); ok || sortBy == SortByName {
+		return SortAscending
+	}
+	return SortDescending
+}
+
+// SortMemories sorts memories by the given key. An empty order uses the
+// key's default order. Ties are broken by ID so the result is the same
+// whatever order the memories were loaded in. Sorting by size compares
+// content lengths, so the memories must have been loaded with content.
+func SortMemories(memories []Memory, sortBy, order string) error {
+	var compare func(a, b *Memory) int
+	// missing reports memories without a value to sort by, which go last
+	// in either order
+	missing := func(*Memory) bool { return false }
+	switch sortBy {
+	case SortByName:
+		compare = func(a, b *Memory) int {
+			return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+		}
+	case SortByCreated:
+		compare = func(a, b *Memory) int { return a.CreatedAt.Compare(b.CreatedAt) }
+	case SortByUpdated:
+		compare = func(a, b *Memory) int { return a.UpdatedAt.Compare(b.UpdatedAt) }
+	case SortBySize:
+		compare = func(a, b *Memory) int { return len(a.Content) - len(b.Content) }
+	case SortByAccessed:
+		compare = func(a, b *Memory) int {
+			aAt, _ := a.LastAccessed()
+			bAt, _ := b.LastAccessed()
+			return aAt.Compare(bAt)
+		}
+	default:
+		key, ok := labelSortKey(sortBy)
+		if !ok {
+			return fmt.Errorf("invalid sort key %q (valid keys: %s, or %s<key>)", sortBy, strings.Join(SortKeys, ", "), LabelSortPrefix)
+		}
+		compare = func(a, b *Memory) int { return compareLabelValues(a.Labels[key], b.Labels[key]) }
+		missing = func(m *Memory) bool {
+			_, ok := m.Labels[key]
+			return !ok
+		}
+	}
+
+	if order == "" {
+		order = DefaultSortOrder(sortBy)
+	}
+	if order != SortAscending && order != SortDescending {
+		return fmt.Errorf("invalid sort order %q (use %s or %s)", order, SortAscending, SortDescending)
+	}
+	descending := order == SortDescending
+
+	sort.SliceStable(memories, func(i, j int) bool {
+		if mi, mj := missing(&memories[i]), missing(&memories[j]); mi != mj {
+			return mj
+		}
+		c := compare(&memories[i], &memories[j])
+		if c == 0 {
+			c = strings.Compare(memories[i].ID, memories[j].ID)
+		}
+		if descending {
+			return c > 0
+		}
+		return c < 0
+	})
+	return nil
+}
+
+// compareLabelValues orders label values numerically when both are
+// numbers, so priority 2 comes before 10, and otherwise case-insensitively
+func compareLabelValues(a, b string) int {
+	x, errA := strconv.ParseFloat(a, 64)
+	y, errB := strconv.ParseFloat(b, 64)
+	if errA == nil && errB == nil {
+		return cmp.Compare(x, y)
+	}
+	return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+}
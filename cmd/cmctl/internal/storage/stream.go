@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+)
+
+// StreamMemories sends memories to out one at a time as they're read, in
+// index order, or file order without the index, and closes out when done.
+// Unlike ListWithOptions, callers can handle the first memories before the
+// rest are read. Memories that can't be read are skipped, as they are when
+// listing. It returns ctx's error if ctx is done before every memory is
+// sent.
+func (fs *FileStorage) StreamMemories(ctx context.Context, opts ListOptions, out chan<- Memory) error {
+	defer close(out)
+	send := func(memory Memory) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		select {
+		case out <- memory:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	if opts.UseIndex {
+		if index, err := fs.readIndex(); err == nil {
+			for _, entry := range index.Memories {
+				memory := entry.memory()
+				if opts.IncludeContent {
+					loaded, err := fs.entryMemory(entry)
+					if err != nil {
+						fs.logger.Warn("skipping memory", "id", entry.ID, "error", err)
+						continue
+					}
+					memory = *loaded
+				}
+				if err := send(memory); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		// Fall back to the files if the index is corrupted
+	}
+
+	files, err := filepath.Glob(filepath.Join(fs.memoriesDir, "*.json"))
+	if err != nil {
+		return fmt.Errorf("failed to glob memory files: %w", err)
+	}
+	for _, file := range files {
+		memory, err := readMemoryFile(file)
+		if err != nil {
+			fs.logger.Warn("skipping corrupted file", "file", file, "error", err)
+			continue
+		}
+		if err := send(memory); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestStreamMemories(t *testing.T) {
+	fs, err := NewFileStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create FileStorage: %v", err)
+	}
+	for _, name := range []string{"Memory 1", "Memory 2", "Memory 3"} {
+		if _, err := fs.Create(CreateMemoryRequest{Name: name, Content: "Content of " + name}); err != nil {
+			t.Fatalf("Failed to create memory: %v", err)
+		}
+	}
+
+	for _, opts := range []ListOptions{
+		{UseIndex: true},
+		{UseIndex: true, IncludeContent: true},
+		{IncludeContent: true},
+	} {
+		out := make(chan Memory)
+		errc := make(chan error, 1)
+		go func() { errc <- fs.StreamMemories(context.Background(), opts, out) }()
+
+		var streamed []Memory
+		for memory := range out {
+			streamed = append(streamed, memory)
+		}
+		if err := <-errc; err != nil {
+			t.Fatalf("%+v: failed to stream memories: %v", opts, err)
+		}
+		if len(streamed) != 3 {
+			t.Fatalf("%+v: expected 3 memories, got %d", opts, len(streamed))
+		}
+		for _, memory := range streamed {
+			if hasContent := memory.Content != ""; hasContent != opts.IncludeContent {
+				t.Errorf("%+v: expected content loaded %v, got %q", opts, opts.IncludeContent, memory.Content)
+			}
+		}
+	}
+
+	// Stopping part way closes the stream with the context's error
+	ctx, cancel := context.WithCancel(context.Background())
+	out := make(chan Memory)
+	errc := make(chan error, 1)
+	go func() { errc <- fs.StreamMemories(ctx, ListOptions{UseIndex: true}, out) }()
+	<-out
+	cancel()
+	for range out {
+	}
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the stream to be canceled, got %v", err)
+	}
+}
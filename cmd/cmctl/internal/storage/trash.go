@@ -0,0 +1,186 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TrashedMemory is a soft-deleted memory awaiting restore or cleanup
+type TrashedMemory struct {
+	Memory
+	DeletedAt time.Time `json:"deletedAt"`
+}
+
+// Trash soft-deletes a memory by moving it into the trash directory and
+// removing it from the index. The deletion time is recorded in the trashed
+// file, so copying or syncing the trash doesn't change it.
+func (fs *FileStorage) Trash(id string) error {
+	if err := ValidateID(id); err != nil {
+		return err
+	}
+	memoryFile := filepath.Join(fs.memoriesDir, id+".json")
+	if _, err := os.Stat(memoryFile); os.IsNotExist(err) {
+		return fmt.Errorf("%w: %s", ErrMemoryNotFound, id)
+	}
+
+	if err := os.MkdirAll(fs.trashDir, 0755); err != nil {
+		return fmt.Errorf("failed to create trash directory: %w", err)
+	}
+
+	trashFile := filepath.Join(fs.trashDir, id+".json")
+	now := time.Now().UTC()
+	if err := fs.moveStored(memoryFile, trashFile, &now); err != nil {
+		return fmt.Errorf("failed to move memory to trash: %w", err)
+	}
+
+	if err := fs.updateIndex(&Memory{ID: id}, "delete"); err != nil {
+		fs.logger.Warn("failed to update index", "id", id, "error", err)
+	}
+
+	return nil
+}
+
+// ListTrash returns trashed memories, most recently deleted first
+func (fs *FileStorage) ListTrash() ([]TrashedMemory, error) {
+	entries, err := os.ReadDir(fs.trashDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []TrashedMemory{}, nil
+		}
+		return nil, fmt.Errorf("failed to read trash: %w", err)
+	}
+
+	trashed := []TrashedMemory{}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(fs.trashDir, entry.Name()))
+		if err != nil {
+			fs.logger.Warn("skipping trashed file", "file", entry.Name(), "error", err)
+			continue
+		}
+		stored, err := unmarshalStored(data)
+		if err != nil {
+			fs.logger.Warn("skipping trashed file", "file", entry.Name(), "error", err)
+			continue
+		}
+
+		// Memories trashed before deletion times were recorded fall back
+		// to the file's modification time
+		deletedAt := info.ModTime()
+		if stored.DeletedAt != nil {
+			deletedAt = *stored.DeletedAt
+		}
+		trashed = append(trashed, TrashedMemory{Memory: stored.Memory, DeletedAt: deletedAt})
+	}
+
+	sort.Slice(trashed, func(i, j int) bool {
+		return trashed[i].DeletedAt.After(trashed[j].DeletedAt)
+	})
+
+	return trashed, nil
+}
+
+// Restore moves a trashed memory back into storage and the index
+func (fs *FileStorage) Restore(id string) (*Memory, error) {
+	if err := ValidateID(id); err != nil {
+		return nil, err
+	}
+	trashFile := filepath.Join(fs.trashDir, id+".json")
+	if _, err := os.Stat(trashFile); os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w in trash: %s", ErrMemoryNotFound, id)
+	}
+
+	memoryFile := filepath.Join(fs.memoriesDir, id+".json")
+	if _, err := os.Stat(memoryFile); err == nil {
+		return nil, fmt.Errorf("memory %s already exists", id)
+	}
+
+	if err := fs.moveStored(trashFile, memoryFile, nil); err != nil {
+		return nil, fmt.Errorf("failed to restore memory: %w", err)
+	}
+
+	memory, err := fs.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := fs.updateIndex(memory, "create"); err != nil {
+		fs.logger.Warn("failed to update index", "id", memory.ID, "error", err)
+	}
+
+	return memory, nil
+}
+
+// moveStored moves a memory file, setting the deletion time it records, or
+// clearing it when deletedAt is nil. Content is kept as stored, compressed
+// or not. Files that can't be parsed are moved unchanged, with the
+// deletion time recorded as their modification time.
+func (fs *FileStorage) moveStored(from, to string, deletedAt *time.Time) error {
+	if err := fs.touch(from, to); err != nil {
+		return err
+	}
+	data, err := os.ReadFile(from)
+	if err != nil {
+		return err
+	}
+
+	var stored storedMemory
+	if err := json.Unmarshal(data, &stored); err != nil {
+		if err := os.Rename(from, to); err != nil {
+			return err
+		}
+		if deletedAt == nil {
+			return nil
+		}
+		return os.Chtimes(to, *deletedAt, *deletedAt)
+	}
+
+	stored.DeletedAt = deletedAt
+	if data, err = json.MarshalIndent(stored, "", "  "); err != nil {
+		return err
+	}
+	if err := fs.writeFile(to, data); err != nil {
+		return err
+	}
+	return os.Remove(from)
+}
+
+// EmptyTrash permanently removes trashed memories deleted more than
+// olderThan ago, or all of them if olderThan is zero. It returns the number
+// of memories removed.
+func (fs *FileStorage) EmptyTrash(olderThan time.Duration) (int, error) {
+	trashed, err := fs.ListTrash()
+	if err != nil {
+		return 0, err
+	}
+
+	cutoff := time.Now().Add(-olderThan)
+	removed := 0
+	for _, memory := range trashed {
+		if olderThan > 0 && memory.DeletedAt.After(cutoff) {
+			continue
+		}
+		trashFile := filepath.Join(fs.trashDir, memory.ID+".json")
+		if err := fs.touch(trashFile); err != nil {
+			return removed, err
+		}
+		if err := os.Remove(trashFile); err != nil {
+			return removed, fmt.Errorf("failed to remove trashed memory %s: %w", memory.ID, err)
+		}
+		removed++
+	}
+
+	return removed, nil
+}
@@ -0,0 +1,160 @@
+package storage
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTrashAndRestore(t *testing.T) {
+	fs, err := NewFileStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create FileStorage: %v", err)
+	}
+
+	memory, err := fs.Create(CreateMemoryRequest{Name: "Keep me", Content: "Important", Labels: map[string]string{"type": "notes"}})
+	if err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+
+	if err := fs.Trash(memory.ID); err != nil {
+		t.Fatalf("Failed to trash memory: %v", err)
+	}
+
+	if _, err := fs.Get(memory.ID); err == nil {
+		t.Error("Expected trashed memory to be gone from storage")
+	}
+	result, err := fs.Search(SearchRequest{LabelSelector: map[string]string{"type": "notes"}, UseIndex: true})
+	if err != nil {
+		t.Fatalf("Failed to search: %v", err)
+	}
+	if len(result.Memories) != 0 {
+		t.Errorf("Expected trashed memory to be removed from the index, got %d results", len(result.Memories))
+	}
+
+	trashed, err := fs.ListTrash()
+	if err != nil {
+		t.Fatalf("Failed to list trash: %v", err)
+	}
+	if len(trashed) != 1 || trashed[0].ID != memory.ID || trashed[0].DeletedAt.IsZero() {
+		t.Fatalf("Expected trashed memory in trash, got %+v", trashed)
+	}
+
+	restored, err := fs.Restore(memory.ID)
+	if err != nil {
+		t.Fatalf("Failed to restore memory: %v", err)
+	}
+	if restored.Content != "Important" {
+		t.Errorf("Expected restored content, got %q", restored.Content)
+	}
+	if data, err := os.ReadFile(filepath.Join(fs.memoriesDir, memory.ID+".json")); err != nil || strings.Contains(string(data), "deletedAt") {
+		t.Errorf("Expected the restored file without a deletion time, got %s (%v)", data, err)
+	}
+
+	result, err = fs.Search(SearchRequest{LabelSelector: map[string]string{"type": "notes"}, UseIndex: true})
+	if err != nil {
+		t.Fatalf("Failed to search: %v", err)
+	}
+	if len(result.Memories) != 1 {
+		t.Errorf("Expected restored memory back in the index, got %d results", len(result.Memories))
+	}
+
+	trashed, err = fs.ListTrash()
+	if err != nil {
+		t.Fatalf("Failed to list trash: %v", err)
+	}
+	if len(trashed) != 0 {
+		t.Errorf("Expected empty trash after restore, got %d", len(trashed))
+	}
+
+	if _, err := fs.Restore(memory.ID); err == nil {
+		t.Error("Expected error restoring a memory that is not in the trash")
+	}
+}
+
+func TestEmptyTrash(t *testing.T) {
+	fs, err := NewFileStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create FileStorage: %v", err)
+	}
+
+	var ids []string
+	for _, name := range []string{"Old", "Legacy", "Recent"} {
+		memory, err := fs.Create(CreateMemoryRequest{Name: name, Content: name})
+		if err != nil {
+			t.Fatalf("Failed to create memory: %v", err)
+		}
+		if err := fs.Trash(memory.ID); err != nil {
+			t.Fatalf("Failed to trash memory: %v", err)
+		}
+		ids = append(ids, memory.ID)
+	}
+
+	// Backdate the first deletion beyond the retention period. The file's
+	// modification time is fresh, as after copying the trash, and doesn't
+	// count.
+	old := time.Now().Add(-40 * 24 * time.Hour).UTC()
+	setTrashedDeletedAt(t, fs, ids[0], &old)
+	// Memories trashed before deletion times were recorded fall back to
+	// the file's modification time
+	legacyFile := setTrashedDeletedAt(t, fs, ids[1], nil)
+	if err := os.Chtimes(legacyFile, old, old); err != nil {
+		t.Fatalf("Failed to backdate trashed memory: %v", err)
+	}
+
+	removed, err := fs.EmptyTrash(30 * 24 * time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to empty expired trash: %v", err)
+	}
+	if removed != 2 {
+		t.Errorf("Expected 2 expired memories removed, got %d", removed)
+	}
+
+	trashed, err := fs.ListTrash()
+	if err != nil {
+		t.Fatalf("Failed to list trash: %v", err)
+	}
+	if len(trashed) != 1 || trashed[0].ID != ids[2] {
+		t.Errorf("Expected only the recent memory left in trash, got %+v", trashed)
+	}
+
+	removed, err = fs.EmptyTrash(0)
+	if err != nil {
+		t.Fatalf("Failed to empty trash: %v", err)
+	}
+	if removed != 1 {
+		t.Errorf("Expected 1 memory removed, got %d", removed)
+	}
+	if _, err := fs.Restore(ids[2]); err == nil {
+		t.Error("Expected emptied memory to be unrecoverable")
+	}
+}
+
+// setTrashedDeletedAt rewrites the deletion time recorded in a trashed
+// memory's file, removing it when deletedAt is nil, and returns the file
+func setTrashedDeletedAt(t *testing.T, fs *FileStorage, id string, deletedAt *time.Time) string {
+	t.Helper()
+	trashFile := filepath.Join(fs.trashDir, id+".json")
+	data, err := os.ReadFile(trashFile)
+	if err != nil {
+		t.Fatalf("Failed to read trashed memory: %v", err)
+	}
+	var stored storedMemory
+	if err := json.Unmarshal(data, &stored); err != nil {
+		t.Fatalf("Failed to parse trashed memory: %v", err)
+	}
+	if stored.DeletedAt == nil {
+		t.Fatalf("Expected the trashed memory to record its deletion time")
+	}
+	stored.DeletedAt = deletedAt
+	if data, err = json.Marshal(stored); err != nil {
+		t.Fatalf("Failed to encode trashed memory: %v", err)
+	}
+	if err := os.WriteFile(trashFile, data, 0644); err != nil {
+		t.Fatalf("Failed to write trashed memory: %v", err)
+	}
+	return trashFile
+}
@@ -0,0 +1,189 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxNameLength is the longest memory name, in bytes
+const MaxNameLength = 200
+
+// Violation is a constraint broken by a stored memory file
+type Violation struct {
+	File string `json:"file" yaml:"file"`
+	// ID is empty when the file couldn't be read as a memory
+	ID      string `json:"id,omitempty" yaml:"id,omitempty"`
+	Problem string `json:"problem" yaml:"problem"`
+	// Fixed is set when the violation was repaired
+	Fixed bool `json:"fixed,omitempty" yaml:"fixed,omitempty"`
+}
+
+// ValidateResult reports what Validate found
+type ValidateResult struct {
+	// Checked is the number of memory files checked
+	Checked    int         `json:"checked" yaml:"checked"`
+	Violations []Violation `json:"violations" yaml:"violations"`
+	// Rewritten is the number of memory files repaired
+	Rewritten int `json:"rewritten" yaml:"rewritten"`
+}
+
+// Invalid returns the number of violations left unrepaired
+func (r ValidateResult) Invalid() int {
+	invalid := 0
+	for _, violation := range r.Violations {
+		if !violation.Fixed {
+			invalid++
+		}
+	}
+	return invalid
+}
+
+// MemoryViolations returns every constraint memory breaks, where
+// ValidateMemory stops at the first. A maxContentBytes of 0 disables the
+// size check.
+func MemoryViolations(memory *Memory, maxContentBytes int64) []string {
+	var problems []string
+	add := func(err error) {
+		if err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	if memory.ID == "" {
+		problems = append(problems, "memory ID cannot be empty")
+	}
+	if memory.Name == "" {
+		problems = append(problems, "memory name cannot be empty")
+	}
+	if len(memory.Name) > MaxNameLength && !IsSealed(memory.Name) {
+		problems = append(problems, fmt.Sprintf("memory name too long (%d bytes, max %d)", len(memory.Name), MaxNameLength))
+	}
+	for _, key := range sortedLabelKeys(memory.Labels) {
+		add(ValidateLabels(map[string]string{key: memory.Labels[key]}))
+	}
+	add(ValidateContent(memory))
+	add(ValidateContentSize(memory.Content, maxContentBytes))
+	for _, relation := range memory.Relations {
+		add(ValidateRelations(memory.ID, []Relation{relation}))
+	}
+	return problems
+}
+
+// repairMemory truncates an overlong name and drops invalid labels,
+// returning a description of each change
+func repairMemory(memory *Memory) []string {
+	var repairs []string
+	if len(memory.Name) > MaxNameLength && !IsSealed(memory.Name) {
+		memory.Name = truncateUTF8(memory.Name, MaxNameLength)
+		repairs = append(repairs, fmt.Sprintf("memory name truncated to %d bytes", len(memory.Name)))
+	}
+	for _, key := range sortedLabelKeys(memory.Labels) {
+		if err := ValidateLabels(map[string]string{key: memory.Labels[key]}); err != nil {
+			delete(memory.Labels, key)
+			repairs = append(repairs, fmt.Sprintf("dropped label %q: %v", key, err))
+		}
+	}
+	return repairs
+}
+
+// truncateUTF8 cuts s to at most n bytes without splitting a character
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+func sortedLabelKeys(labels map[string]string) []string {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Validate checks every memory file against the constraints enforced when
+// memories are written, which hand-edited or externally written files may
+// break. With fix, overlong names are truncated and invalid labels dropped;
+// other violations are only reported. Repairs leave UpdatedAt as it is.
+func (fs *FileStorage) Validate(fix bool) (ValidateResult, error) {
+	result := ValidateResult{Violations: []Violation{}}
+	files, err := filepath.Glob(filepath.Join(fs.memoriesDir, "*.json"))
+	if err != nil {
+		return result, fmt.Errorf("failed to glob memory files: %w", err)
+	}
+
+	for _, file := range files {
+		result.Checked++
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return result, fmt.Errorf("failed to read memory file: %w", err)
+		}
+		memory, err := unmarshalMemory(data)
+		if err != nil {
+			result.Violations = append(result.Violations, Violation{File: file, Problem: fmt.Sprintf("not a valid memory: %v", err)})
+			continue
+		}
+
+		violations := fileViolations(file, &memory, fs.maxContentBytes)
+		// A memory is only rewritten in place when its file is its own
+		if fix && len(violations) > 0 && memory.ID == fileID(file) {
+			if repairs := repairMemory(&memory); len(repairs) > 0 {
+				if err := fs.rewriteMemoryFile(file, &memory); err != nil {
+					return result, err
+				}
+				result.Rewritten++
+				violations = nil
+				for _, repair := range repairs {
+					violations = append(violations, Violation{File: file, ID: memory.ID, Problem: repair, Fixed: true})
+				}
+				violations = append(violations, fileViolations(file, &memory, fs.maxContentBytes)...)
+			}
+		}
+		result.Violations = append(result.Violations, violations...)
+	}
+	return result, nil
+}
+
+// fileViolations returns the violations of the memory read from file
+func fileViolations(file string, memory *Memory, maxContentBytes int64) []Violation {
+	var violations []Violation
+	if memory.ID != "" && memory.ID != fileID(file) {
+		violations = append(violations, Violation{File: file, ID: memory.ID, Problem: fmt.Sprintf("memory ID does not match the file name %s", filepath.Base(file))})
+	}
+	for _, problem := range MemoryViolations(memory, maxContentBytes) {
+		violations = append(violations, Violation{File: file, ID: memory.ID, Problem: problem})
+	}
+	return violations
+}
+
+// fileID returns the memory ID a memory file is named for
+func fileID(file string) string {
+	return strings.TrimSuffix(filepath.Base(file), ".json")
+}
+
+// rewriteMemoryFile writes a repaired memory back to its file and the index
+func (fs *FileStorage) rewriteMemoryFile(file string, memory *Memory) error {
+	data, err := marshalMemory(memory, fs.compress)
+	if err != nil {
+		return fmt.Errorf("failed to marshal memory: %w", err)
+	}
+	if err := fs.touch(file); err != nil {
+		return err
+	}
+	if err := fs.writeFile(file, data); err != nil {
+		return fmt.Errorf("failed to write memory file: %w", err)
+	}
+	if err := fs.updateIndex(memory, "update"); err != nil {
+		fs.logger.Warn("failed to update index", "id", memory.ID, "error", err)
+	}
+	return nil
+}
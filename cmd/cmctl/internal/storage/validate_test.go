@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// seedInvalidMemories writes memory files that break the constraints
+// enforced on write, as a hand edit might
+func seedInvalidMemories(t *testing.T, fs *FileStorage) time.Time {
+	t.Helper()
+	updated := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	write := func(name string, memory any) {
+		data, err := json.Marshal(memory)
+		if err != nil {
+			t.Fatalf("Failed to marshal memory: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(fs.memoriesDir, name), data, 0644); err != nil {
+			t.Fatalf("Failed to write memory file: %v", err)
+		}
+	}
+
+	write("mem_long.json", Memory{
+		ID:        "mem_long",
+		Name:      strings.Repeat("é", MaxNameLength),
+		Content:   "content",
+		Labels:    map[string]string{"type": "chat", "bad key": "x", "topic": "has space"},
+		UpdatedAt: updated,
+	})
+	write("mem_moved.json", Memory{ID: "mem_other", Name: "Moved", Content: "content"})
+	if err := os.WriteFile(filepath.Join(fs.memoriesDir, "mem_broken.json"), []byte("{"), 0644); err != nil {
+		t.Fatalf("Failed to write memory file: %v", err)
+	}
+	return updated
+}
+
+func violationProblems(violations []Violation, file string) []string {
+	var problems []string
+	for _, violation := range violations {
+		if filepath.Base(violation.File) == file {
+			problems = append(problems, violation.Problem)
+		}
+	}
+	return problems
+}
+
+func TestValidate(t *testing.T) {
+	fs, err := NewFileStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create FileStorage: %v", err)
+	}
+	if _, err := fs.Create(CreateMemoryRequest{Name: "Valid", Content: "content", Labels: map[string]string{"type": "note"}}); err != nil {
+		t.Fatalf("Failed to create memory: %v", err)
+	}
+	seedInvalidMemories(t, fs)
+
+	result, err := fs.Validate(false)
+	if err != nil {
+		t.Fatalf("Failed to validate: %v", err)
+	}
+	if result.Checked != 4 || result.Rewritten != 0 {
+		t.Errorf("Expected 4 files checked and none rewritten, got %d and %d", result.Checked, result.Rewritten)
+	}
+	if result.Invalid() != 5 {
+		t.Errorf("Expected 5 violations, got %v", result.Violations)
+	}
+
+	long := violationProblems(result.Violations, "mem_long.json")
+	if len(long) != 3 || !strings.HasPrefix(long[0], "memory name too long") ||
+		!strings.Contains(long[1], `"bad key"`) || !strings.Contains(long[2], `"has space"`) {
+		t.Errorf("Expected the name and both labels reported, got %v", long)
+	}
+	if moved := violationProblems(result.Violations, "mem_moved.json"); len(moved) != 1 || !strings.Contains(moved[0], "does not match the file name") {
+		t.Errorf("Expected the ID mismatch reported, got %v", moved)
+	}
+	if broken := violationProblems(result.Violations, "mem_broken.json"); len(broken) != 1 || !strings.HasPrefix(broken[0], "not a valid memory") {
+		t.Errorf("Expected the unparseable file reported, got %v", broken)
+	}
+}
+
+func TestValidateFix(t *testing.T) {
+	fs, err := NewFileStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create FileStorage: %v", err)
+	}
+	updated := seedInvalidMemories(t, fs)
+
+	result, err := fs.Validate(true)
+	if err != nil {
+		t.Fatalf("Failed to validate: %v", err)
+	}
+	if result.Rewritten != 1 {
+		t.Errorf("Expected 1 file rewritten, got %d", result.Rewritten)
+	}
+	// The repairs are reported, the ID mismatch and broken file are not fixable
+	if result.Invalid() != 2 || len(result.Violations) != 5 {
+		t.Errorf("Expected 3 repairs and 2 violations left, got %v", result.Violations)
+	}
+
+	memory, err := fs.Get("mem_long")
+	if err != nil {
+		t.Fatalf("Failed to get repaired memory: %v", err)
+	}
+	if len(memory.Name) > MaxNameLength || !strings.HasPrefix(strings.Repeat("é", MaxNameLength), memory.Name) {
+		t.Errorf("Expected the name truncated on a character boundary, got %d bytes", len(memory.Name))
+	}
+	if len(memory.Labels) != 1 || memory.Labels["type"] != "chat" {
+		t.Errorf("Expected only the valid label kept, got %v", memory.Labels)
+	}
+	if !memory.UpdatedAt.Equal(updated) {
+		t.Errorf("Expected UpdatedAt unchanged, got %v", memory.UpdatedAt)
+	}
+
+	result, err = fs.Validate(true)
+	if err != nil {
+		t.Fatalf("Failed to validate: %v", err)
+	}
+	if result.Rewritten != 0 || len(violationProblems(result.Violations, "mem_long.json")) != 0 {
+		t.Errorf("Expected the repaired memory to validate, got %v", result.Violations)
+	}
+}
@@ -1,15 +1,19 @@
 package utils
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
-	"math/rand"
 	"time"
 )
 
-// GenerateID generates a unique memory ID using timestamp and random suffix
+// idRandomBytes is the number of random bytes in an ID suffix
+const idRandomBytes = 5
+
+// GenerateID generates a unique memory ID from a nanosecond timestamp and
+// a crypto/rand suffix, formatted as mem_<16 hex>_<10 hex>
 func GenerateID() string {
-	timestamp := time.Now().Unix()
-	random := rand.Intn(999999)
-	return fmt.Sprintf("mem_%x_%06x", timestamp, random)
+	var random [idRandomBytes]byte
+	rand.Read(random[:])
+	return fmt.Sprintf("mem_%016x_%s", time.Now().UnixNano(), hex.EncodeToString(random[:]))
 }
-
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"strings"
+	"sync"
 	"testing"
 )
 
@@ -22,13 +23,13 @@ func TestGenerateID(t *testing.T) {
 		t.Errorf("ID should have 3 parts separated by '_', got: %s", id)
 	}
 
-	// Check that hex parts are the right length (8 characters each)
-	if len(parts[1]) != 8 {
-		t.Errorf("First hex part should be 8 characters, got %d: %s", len(parts[1]), parts[1])
+	// Check that hex parts are the right length
+	if len(parts[1]) != 16 {
+		t.Errorf("First hex part should be 16 characters, got %d: %s", len(parts[1]), parts[1])
 	}
 
-	if len(parts[2]) != 6 {
-		t.Errorf("Second hex part should be 6 characters, got %d: %s", len(parts[2]), parts[2])
+	if len(parts[2]) != 10 {
+		t.Errorf("Second hex part should be 10 characters, got %d: %s", len(parts[2]), parts[2])
 	}
 
 	// Check uniqueness by generating multiple IDs
@@ -45,8 +46,8 @@ func TestGenerateID(t *testing.T) {
 func TestGenerateIDFormat(t *testing.T) {
 	id := GenerateID()
 
-	// Should match pattern: mem_[a-f0-9]{8}_[a-f0-9]{6}
-	expectedLen := len("mem_") + 8 + len("_") + 6
+	// Should match pattern: mem_[a-f0-9]{16}_[a-f0-9]{10}
+	expectedLen := len("mem_") + 16 + len("_") + 10
 	if len(id) != expectedLen {
 		t.Errorf("Expected ID length %d, got %d: %s", expectedLen, len(id), id)
 	}
@@ -59,3 +60,33 @@ func TestGenerateIDFormat(t *testing.T) {
 		}
 	}
 }
+
+func TestGenerateIDConcurrentUniqueness(t *testing.T) {
+	const workers = 16
+	const total = 100000
+
+	ids := make(chan string, total)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < total/workers; i++ {
+				ids <- GenerateID()
+			}
+		}()
+	}
+	wg.Wait()
+	close(ids)
+
+	seen := make(map[string]bool, total)
+	for id := range ids {
+		if seen[id] {
+			t.Fatalf("Generated duplicate ID: %s", id)
+		}
+		seen[id] = true
+	}
+	if len(seen) != workers*(total/workers) {
+		t.Errorf("Expected %d IDs, got %d", workers*(total/workers), len(seen))
+	}
+}
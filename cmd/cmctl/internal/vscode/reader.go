@@ -0,0 +1,292 @@
+// Package vscode reads GitHub Copilot Chat sessions from VS Code's workspace
+// storage and maps them onto the chat types used for Cursor imports.
+package vscode
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/cloudygreybeard/contextmemory/cmd/cmctl/internal/cursor"
+)
+
+// Source is recorded on chats read from VS Code
+const Source = "vscode-copilot"
+
+// sessionsDir is the per-workspace directory holding chat session files
+const sessionsDir = "chatSessions"
+
+var _ cursor.ChatReader = (*CopilotReader)(nil)
+
+// CopilotReader provides access to Copilot Chat sessions in VS Code's
+// workspace storage
+type CopilotReader struct {
+	StoragePath string
+}
+
+// NewCopilotReader creates a reader for the default VS Code storage path
+func NewCopilotReader() *CopilotReader {
+	return &CopilotReader{
+		StoragePath: getDefaultStoragePath(),
+	}
+}
+
+// NewCopilotReaderWithPath creates a reader with custom storage path
+func NewCopilotReaderWithPath(path string) *CopilotReader {
+	return &CopilotReader{
+		StoragePath: path,
+	}
+}
+
+// getDefaultStoragePath returns the default VS Code workspace storage path
+func getDefaultStoragePath() string {
+	homeDir, _ := os.UserHomeDir()
+
+	switch runtime.GOOS {
+	case "darwin":
+		return filepath.Join(homeDir, "Library", "Application Support", "Code", "User", "workspaceStorage")
+	case "windows":
+		return filepath.Join(os.Getenv("APPDATA"), "Code", "User", "workspaceStorage")
+	default:
+		return filepath.Join(homeDir, ".config", "Code", "User", "workspaceStorage")
+	}
+}
+
+// chatSession is the on-disk format of a Copilot Chat session
+type chatSession struct {
+	SessionID       string        `json:"sessionId"`
+	CustomTitle     string        `json:"customTitle,omitempty"`
+	CreationDate    int64         `json:"creationDate"`
+	LastMessageDate int64         `json:"lastMessageDate"`
+	Requests        []chatRequest `json:"requests"`
+}
+
+// chatRequest is one user prompt and the response it produced
+type chatRequest struct {
+	RequestID string `json:"requestId"`
+	Message   struct {
+		Text string `json:"text"`
+	} `json:"message"`
+	Response  []responsePart `json:"response"`
+	Timestamp int64          `json:"timestamp"`
+}
+
+// responsePart is a fragment of a response. Plain markdown fragments carry
+// a string value; newer versions wrap it in a markdownContent part. Other
+// kinds (references, edits, tool calls) carry no chat text.
+type responsePart struct {
+	Kind    string          `json:"kind,omitempty"`
+	Value   json.RawMessage `json:"value,omitempty"`
+	Content *struct {
+		Value string `json:"value"`
+	} `json:"content,omitempty"`
+}
+
+// text returns the markdown carried by the part, if any
+func (p responsePart) text() string {
+	switch p.Kind {
+	case "", "markdownContent":
+	default:
+		return ""
+	}
+	if p.Content != nil {
+		return p.Content.Value
+	}
+	var value string
+	if len(p.Value) > 0 && json.Unmarshal(p.Value, &value) == nil {
+		return value
+	}
+	return ""
+}
+
+// FindSessions returns the paths of all chat session files. StoragePath may
+// point at the workspaceStorage root, a single workspace directory, or a
+// session file directly.
+func (cr *CopilotReader) FindSessions() ([]string, error) {
+	info, err := os.Stat(cr.StoragePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read workspace storage: %w", err)
+	}
+	if !info.IsDir() {
+		return []string{cr.StoragePath}, nil
+	}
+
+	if _, err := os.Stat(filepath.Join(cr.StoragePath, sessionsDir)); err == nil {
+		return listSessionFiles(filepath.Join(cr.StoragePath, sessionsDir))
+	}
+
+	entries, err := os.ReadDir(cr.StoragePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read workspace storage: %w", err)
+	}
+
+	var sessions []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		files, err := listSessionFiles(filepath.Join(cr.StoragePath, entry.Name(), sessionsDir))
+		if err != nil {
+			continue // Workspace without chat sessions
+		}
+		sessions = append(sessions, files...)
+	}
+
+	return sessions, nil
+}
+
+// listSessionFiles returns the JSON files in a chatSessions directory
+func listSessionFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") {
+			files = append(files, filepath.Join(dir, entry.Name()))
+		}
+	}
+	return files, nil
+}
+
+// ReadSession parses a chat session file into a ChatTab
+func (cr *CopilotReader) ReadSession(path string) (*cursor.ChatTab, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read chat session: %w", err)
+	}
+
+	var session chatSession
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, fmt.Errorf("failed to parse chat session %s: %w", path, err)
+	}
+
+	return sessionToChatTab(session, strings.TrimSuffix(filepath.Base(path), ".json")), nil
+}
+
+// sessionToChatTab maps a session onto a ChatTab, using fallbackID when the
+// session does not record its own ID
+func sessionToChatTab(session chatSession, fallbackID string) *cursor.ChatTab {
+	chat := &cursor.ChatTab{
+		ID:        session.SessionID,
+		Title:     session.CustomTitle,
+		Timestamp: session.LastMessageDate,
+		Source:    Source,
+	}
+	if chat.ID == "" {
+		chat.ID = fallbackID
+	}
+
+	for _, req := range session.Requests {
+		if text := strings.TrimSpace(req.Message.Text); text != "" {
+			chat.Messages = append(chat.Messages, cursor.Message{
+				ID:        req.RequestID,
+				Role:      "user",
+				Content:   text,
+				Timestamp: req.Timestamp,
+			})
+		}
+
+		var response strings.Builder
+		for _, part := range req.Response {
+			response.WriteString(part.text())
+		}
+		if text := strings.TrimSpace(response.String()); text != "" {
+			chat.Messages = append(chat.Messages, cursor.Message{
+				ID:        req.RequestID + ":response",
+				Role:      "assistant",
+				Content:   text,
+				Timestamp: req.Timestamp,
+			})
+		}
+
+		if req.Timestamp > chat.Timestamp {
+			chat.Timestamp = req.Timestamp
+		}
+	}
+
+	if chat.Timestamp == 0 {
+		chat.Timestamp = session.CreationDate
+	}
+
+	return chat
+}
+
+// ListAllChats returns all chats from all workspaces with workspace info
+func (cr *CopilotReader) ListAllChats() ([]cursor.ChatTabWithWorkspace, error) {
+	sessions, err := cr.FindSessions()
+	if err != nil {
+		return nil, err
+	}
+
+	var allChats []cursor.ChatTabWithWorkspace
+
+	for _, sessionPath := range sessions {
+		chat, err := cr.ReadSession(sessionPath)
+		if err != nil {
+			continue // Skip unreadable sessions
+		}
+
+		// Sessions live in <workspace>/chatSessions/<id>.json
+		workspacePath := filepath.Dir(filepath.Dir(sessionPath))
+
+		allChats = append(allChats, cursor.ChatTabWithWorkspace{
+			ChatTab:       *chat,
+			WorkspacePath: workspacePath,
+			WorkspaceName: filepath.Base(workspacePath),
+		})
+	}
+
+	// Sort by timestamp (newest first)
+	sort.Slice(allChats, func(i, j int) bool {
+		return allChats[i].Timestamp > allChats[j].Timestamp
+	})
+
+	return allChats, nil
+}
+
+// GetLatestChat returns the most recent chat across all workspaces
+func (cr *CopilotReader) GetLatestChat() (*cursor.ChatTab, error) {
+	chats, err := cr.ListAllChats()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(chats) == 0 {
+		return nil, fmt.Errorf("no chats found in %s", cr.StoragePath)
+	}
+
+	return &chats[0].ChatTab, nil
+}
+
+// GetChatByID retrieves a specific chat by its session ID
+func (cr *CopilotReader) GetChatByID(chatID string) (*cursor.ChatTab, string, error) {
+	chats, err := cr.ListAllChats()
+	if err != nil {
+		return nil, "", err
+	}
+
+	for i := range chats {
+		if chats[i].ID == chatID {
+			return &chats[i].ChatTab, chats[i].WorkspacePath, nil
+		}
+	}
+
+	return nil, "", fmt.Errorf("chat with ID %s not found", chatID)
+}
+
+// SearchChats searches for chats containing specific text
+func (cr *CopilotReader) SearchChats(query string) ([]cursor.ChatTabWithWorkspace, error) {
+	allChats, err := cr.ListAllChats()
+	if err != nil {
+		return nil, err
+	}
+
+	return cursor.FilterChats(allChats, query), nil
+}
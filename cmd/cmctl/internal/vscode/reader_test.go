@@ -0,0 +1,115 @@
+package vscode
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+const fixtureStorage = "testdata/workspaceStorage"
+
+func TestListAllChats(t *testing.T) {
+	reader := NewCopilotReaderWithPath(fixtureStorage)
+
+	chats, err := reader.ListAllChats()
+	if err != nil {
+		t.Fatalf("ListAllChats failed: %v", err)
+	}
+
+	// The malformed session is skipped
+	if len(chats) != 2 {
+		t.Fatalf("Expected 2 chats, got %d", len(chats))
+	}
+
+	latest := chats[0]
+	if latest.ID != "session-a" {
+		t.Errorf("Expected newest chat first, got %s", latest.ID)
+	}
+	if latest.Title != "Fixing the flaky index test" {
+		t.Errorf("Expected custom title, got %q", latest.Title)
+	}
+	if latest.Source != Source {
+		t.Errorf("Expected source %s, got %q", Source, latest.Source)
+	}
+	if latest.WorkspaceName != "ws-one" {
+		t.Errorf("Expected workspace ws-one, got %s", latest.WorkspaceName)
+	}
+	if latest.Timestamp != 1758700060000 {
+		t.Errorf("Expected timestamp 1758700060000, got %d", latest.Timestamp)
+	}
+
+	want := []struct {
+		role    string
+		content string
+	}{
+		{"user", "Why does TestIndexRebuild fail intermittently?"},
+		{"assistant", "The test races with the index writer goroutine."},
+		{"user", "How do I fix it?"},
+		{"assistant", "Wait for the writer with a `sync.WaitGroup`."},
+	}
+	if len(latest.Messages) != len(want) {
+		t.Fatalf("Expected %d messages, got %d: %+v", len(want), len(latest.Messages), latest.Messages)
+	}
+	for i, msg := range latest.Messages {
+		if msg.Role != want[i].role || msg.Content != want[i].content {
+			t.Errorf("Message %d: got %s %q, want %s %q", i, msg.Role, msg.Content, want[i].role, want[i].content)
+		}
+	}
+
+	if chats[1].GetDisplayTitle() == "" {
+		t.Error("Expected a display title for an untitled session")
+	}
+}
+
+func TestGetChatByID(t *testing.T) {
+	reader := NewCopilotReaderWithPath(fixtureStorage)
+
+	chat, workspacePath, err := reader.GetChatByID("session-b")
+	if err != nil {
+		t.Fatalf("GetChatByID failed: %v", err)
+	}
+	if len(chat.Messages) != 2 {
+		t.Errorf("Expected 2 messages, got %d", len(chat.Messages))
+	}
+	if filepath.Base(workspacePath) != "ws-two" {
+		t.Errorf("Expected workspace ws-two, got %s", workspacePath)
+	}
+
+	if _, _, err := reader.GetChatByID("missing"); err == nil {
+		t.Error("Expected error for unknown chat ID")
+	}
+}
+
+func TestScopedStoragePath(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{name: "workspace directory", path: filepath.Join(fixtureStorage, "ws-one"), want: "session-a"},
+		{name: "session file", path: filepath.Join(fixtureStorage, "ws-two", "chatSessions", "session-b.json"), want: "session-b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chats, err := NewCopilotReaderWithPath(tt.path).ListAllChats()
+			if err != nil {
+				t.Fatalf("ListAllChats failed: %v", err)
+			}
+			if len(chats) != 1 || chats[0].ID != tt.want {
+				t.Errorf("Expected only %s, got %+v", tt.want, chats)
+			}
+		})
+	}
+}
+
+func TestSearchChats(t *testing.T) {
+	reader := NewCopilotReaderWithPath(fixtureStorage)
+
+	matches, err := reader.SearchChats("automigrate")
+	if err != nil {
+		t.Fatalf("SearchChats failed: %v", err)
+	}
+	if len(matches) != 1 || matches[0].ID != "session-b" {
+		t.Errorf("Expected session-b to match, got %+v", matches)
+	}
+}